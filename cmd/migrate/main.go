@@ -0,0 +1,63 @@
+// Command migrate applies the database migrations outside of the server.
+//
+//	migrate up       apply every pending migration
+//	migrate status   list the migrations and when they were applied
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/migrate"
+	"airsense-be.com/internal/repository/mongo"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: migrate up|status")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, cfg.MongoDB)
+	if err != nil {
+		log.Fatalf("mongodb: %v", err)
+	}
+	defer client.Disconnect(ctx)
+	db := client.Database(cfg.MongoDB.Database)
+
+	switch os.Args[1] {
+	case "up":
+		if err := migrate.Up(ctx, db); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		if err := mongo.EnsureAnomalyTTL(ctx, db, cfg.Alert.AnomalyRetention); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		log.Println("migrate: database is up to date")
+	case "status":
+		statuses, err := migrate.List(ctx, db)
+		if err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-28s %-25s %s\n", s.ID, applied, s.Description)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "migrate: unknown command %q\n", os.Args[1])
+		os.Exit(2)
+	}
+}
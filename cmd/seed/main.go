@@ -0,0 +1,267 @@
+// Command seed fills a development database with a demo user, devices and
+// a history of plausible readings, written through the same repositories
+// and services as the server.
+//
+//	seed [-email demo@airsense.local] [-password ...] [-devices 3] [-days 7] [-interval 5m]
+//
+// Devices that already exist are left alone, so running it again only adds
+// what is missing.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/migrate"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/quality"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/repository/influx"
+	"airsense-be.com/internal/repository/mongo"
+	"airsense-be.com/internal/service"
+	"airsense-be.com/internal/utils"
+)
+
+// insertBatch is the number of readings written per InsertMany.
+const insertBatch = 1000
+
+func main() {
+	email := flag.String("email", "demo@airsense.local", "email of the demo user")
+	password := flag.String("password", "airsense-demo1", "password of the demo user, if it is created")
+	devices := flag.Int("devices", 3, "number of demo devices")
+	days := flag.Int("days", 7, "days of readings to generate, up to now")
+	interval := flag.Duration("interval", 5*time.Minute, "time between two readings of a device")
+	flag.Parse()
+	if *devices < 1 || *days < 1 || *interval < time.Second {
+		fmt.Fprintln(os.Stderr, "seed: -devices and -days must be positive and -interval at least 1s")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, cfg.MongoDB)
+	if err != nil {
+		log.Fatalf("mongodb: %v", err)
+	}
+	defer client.Disconnect(ctx)
+	db := client.Database(cfg.MongoDB.Database)
+	if err := migrate.Up(ctx, db); err != nil {
+		log.Fatalf("mongodb: %v", err)
+	}
+
+	var sensorRepo repository.SensorDataRepository
+	switch cfg.Storage.Backend {
+	case config.StorageMongoDB:
+		sensorRepo = mongo.NewSensorRepository(db, mongo.NewRetryPolicy(cfg.MongoDB))
+	case config.StorageInfluxDB:
+		influxClient, err := influx.Connect(ctx, cfg.InfluxDB)
+		if err != nil {
+			log.Fatalf("influxdb: %v", err)
+		}
+		defer influxClient.Close()
+		sensorRepo = influx.NewSensorRepository(influxClient, cfg.InfluxDB)
+	default:
+		log.Fatalf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+	userRepo := mongo.NewUserRepository(db)
+	deviceService := service.NewDeviceService(mongo.NewDeviceRepository(db), mongo.NewShareRepository(db), mongo.NewOrgMemberRepository(db), sensorRepo,
+		service.NewQuotaService(userRepo, cfg.Server.DeviceQuota, cfg.Server.RequireVerifiedEmail), cache.NewLatestCache())
+
+	user, err := demoUser(ctx, userRepo, *email, *password, cfg.JWT.BcryptCost, *devices)
+	if err != nil {
+		log.Fatalf("seed: %v", err)
+	}
+
+	to := time.Now().Truncate(*interval)
+	from := to.Add(-time.Duration(*days) * 24 * time.Hour)
+	scorer := quality.NewScorer()
+	for i := 0; i < *devices; i++ {
+		profile := profiles[i%len(profiles)]
+		id := fmt.Sprintf("demo-%02d", i+1)
+		name := fmt.Sprintf("%s %d", profile.name, i/len(profiles)+1)
+		_, _, err := deviceService.Register(ctx, user.ID, id, name, profile.location)
+		if errors.Is(err, service.ErrDeviceExists) {
+			log.Printf("seed: device %s exists, skipped", id)
+			continue
+		}
+		if err != nil {
+			log.Fatalf("seed: register device %s: %v", id, err)
+		}
+		n, err := seedReadings(ctx, sensorRepo, scorer, id, profile, from, to, *interval)
+		if err != nil {
+			log.Fatalf("seed: readings of device %s: %v", id, err)
+		}
+		log.Printf("seed: device %s (%s) with %d readings", id, name, n)
+	}
+	log.Printf("seed: sign in as %s", user.Email)
+}
+
+// demoUser returns the user with email, creating it with password, hashed
+// at cost, if there is none, and lets it own at least devices devices.
+func demoUser(ctx context.Context, users *mongo.UserRepo, email, password string, cost, devices int) (*models.User, error) {
+	u, err := users.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+		if err != nil {
+			return nil, err
+		}
+		u = &models.User{
+			ID:        primitive.NewObjectID().Hex(),
+			Email:     email,
+			Password:  string(hash),
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := users.Create(ctx, u); err != nil {
+			return nil, fmt.Errorf("create user %s: %w", email, err)
+		}
+		log.Printf("seed: created user %s with password %q", email, password)
+	} else if err != nil {
+		return nil, err
+	}
+	limit := max(devices, u.DeviceCount+devices)
+	if err := users.SetDeviceLimit(ctx, u.ID, &limit); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// profile shapes the readings of a kind of room.
+type profile struct {
+	name, location string
+	// occupied reports whether people are in the room at local time t.
+	occupied func(t time.Time) bool
+	// co2Peak is the CO2 level, in ppm, a fully occupied room tends to.
+	co2Peak float64
+	// spikesPerDay is the mean number of PM2.5 spikes a day, e.g. cooking
+	// or traffic.
+	spikesPerDay float64
+}
+
+var profiles = []profile{
+	{
+		name: "Office", location: "Floor 2, open space",
+		occupied: func(t time.Time) bool {
+			wd, h := t.Weekday(), t.Hour()
+			return wd != time.Saturday && wd != time.Sunday && h >= 8 && h < 18
+		},
+		co2Peak: 1400, spikesPerDay: 1,
+	},
+	{
+		name: "Living Room", location: "Home",
+		occupied: func(t time.Time) bool {
+			wd, h := t.Weekday(), t.Hour()
+			if wd == time.Saturday || wd == time.Sunday {
+				return h >= 9 && h < 23
+			}
+			return (h >= 6 && h < 8) || (h >= 17 && h < 23)
+		},
+		co2Peak: 1000, spikesPerDay: 3,
+	},
+	{
+		name: "Bedroom", location: "Home",
+		occupied: func(t time.Time) bool {
+			h := t.Hour()
+			return h >= 22 || h < 7
+		},
+		co2Peak: 1800, spikesPerDay: 0.5,
+	},
+}
+
+// seedReadings writes a reading of deviceID every interval from from to
+// to, following p, and returns how many it wrote.
+func seedReadings(ctx context.Context, repo repository.SensorDataRepository, scorer *quality.Scorer,
+	deviceID string, p profile, from, to time.Time, interval time.Duration) (int, error) {
+	steps := interval.Minutes()
+	// Per step: how far CO2 moves towards its target and how much of a
+	// PM2.5 spike is left, both relative to 5 minute steps.
+	co2Rate := 1 - math.Pow(0.85, steps/5)
+	spikeDecay := math.Pow(0.8, steps/5)
+	spikeChance := p.spikesPerDay * interval.Hours() / 24
+
+	co2, spike := 420.0, 0.0
+	pmBase := 5 + rand.Float64()*5
+	var batch []*models.SensorData
+	n := 0
+	for t := from; !t.After(to); t = t.Add(interval) {
+		local := t.Local()
+		hour := float64(local.Hour()) + float64(local.Minute())/60
+		// Warmest mid-afternoon, coolest before dawn.
+		daily := math.Sin(2 * math.Pi * (hour - 9) / 24)
+
+		target := 420.0
+		occupied := p.occupied(local)
+		if occupied {
+			target = p.co2Peak
+		}
+		co2 += (target-co2)*co2Rate + rand.NormFloat64()*10
+
+		spike *= spikeDecay
+		if rand.Float64() < spikeChance {
+			spike += 30 + rand.Float64()*120
+		}
+		pm25 := pmBase + spike + rand.NormFloat64()
+
+		temperature := 21 + 2*daily + rand.NormFloat64()*0.2
+		if occupied {
+			temperature += 1
+		}
+		humidity := 50 - 8*daily + rand.NormFloat64()
+		co := 0.4 + spike*0.02 + rand.NormFloat64()*0.05
+
+		d := &models.SensorData{
+			DeviceID:  deviceID,
+			Timestamp: t.UTC(),
+			Source:    models.SourceSeed,
+			Sensors: models.Sensors{
+				PM25:        models.SensorValue{Value: round(clamp("pm25", pm25)), Unit: "µg/m³"},
+				CO2:         models.SensorValue{Value: math.Round(clamp("co2", co2)), Unit: "ppm"},
+				CO:          models.SensorValue{Value: round(clamp("co", co)), Unit: "ppm"},
+				Temperature: models.SensorValue{Value: round(clamp("temperature", temperature)), Unit: "°C"},
+				Humidity:    models.SensorValue{Value: round(clamp("humidity", humidity)), Unit: "%"},
+			},
+		}
+		if err := utils.ValidateSensorData(d); err != nil {
+			return n, err
+		}
+		utils.ApplyAQI(d)
+		d.Quality = scorer.Score(*d)
+		batch = append(batch, d)
+		if len(batch) == insertBatch {
+			if err := repo.InsertMany(ctx, batch); err != nil {
+				return n, err
+			}
+			n += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if err := repo.InsertMany(ctx, batch); err != nil {
+		return n, err
+	}
+	return n + len(batch), nil
+}
+
+// clamp keeps v within the physical range of sensor field.
+func clamp(field string, v float64) float64 {
+	r := utils.SensorRanges[field]
+	return math.Min(math.Max(v, r.Min), r.Max)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
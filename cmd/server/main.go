@@ -1,11 +1,409 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	// Embedded zone database for timezone-aware aggregation on hosts
+	// without tzdata, e.g. scratch containers.
+	_ "time/tzdata"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"golang.org/x/crypto/bcrypt"
+
+	"airsense-be.com/internal/alert"
+	"airsense-be.com/internal/api"
+	"airsense-be.com/internal/api/gql"
+	"airsense-be.com/internal/api/handlers"
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/events"
+	"airsense-be.com/internal/migrate"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/mqtt"
+	"airsense-be.com/internal/notifications"
+	"airsense-be.com/internal/quality"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/repository/influx"
+	"airsense-be.com/internal/repository/mongo"
+	"airsense-be.com/internal/service"
+	"airsense-be.com/internal/storage"
+)
+
+// minJWTSecretLength is the least length of JWT_SECRET, 256 bits for the
+// HS256 key.
+const minJWTSecretLength = 32
+
+// mongoDisconnectTimeout bounds closing the MongoDB connections, the last
+// step of a shutdown.
+const mongoDisconnectTimeout = 10 * time.Second
+
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildTime = "unknown"
 )
 
 func main() {
-	log.Println("Server is starting...")
-	// Server initialization and startup logic goes here
+	log.Printf("Server is starting... (version=%s commit=%s built=%s)", version, commit, buildTime)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	// Access tokens are HMAC-signed with the secret: an empty or short one
+	// lets anyone forge tokens for any user.
+	if len(cfg.JWT.Secret) < minJWTSecretLength {
+		log.Fatalf("config: JWT_SECRET must be at least %d bytes long", minJWTSecretLength)
+	}
+	if cfg.JWT.BcryptCost < bcrypt.MinCost || cfg.JWT.BcryptCost > bcrypt.MaxCost {
+		log.Fatalf("config: BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	ctx := context.Background()
+	if cfg.Command.ActionsFile != "" {
+		if err := loadActions(cfg.Command.ActionsFile); err != nil {
+			log.Fatalf("commands: %v", err)
+		}
+	}
+	if err := setScoreBreakpoints(cfg.Alert.ScoreBreakpoints); err != nil {
+		log.Fatalf("score: %v", err)
+	}
+
+	mongoClient, err := mongo.Connect(ctx, cfg.MongoDB)
+	if err != nil {
+		log.Fatalf("mongodb: %v", err)
+	}
+	db := mongoClient.Database(cfg.MongoDB.Database)
+	if err := migrate.Up(ctx, db); err != nil {
+		log.Fatalf("mongodb: %v", err)
+	}
+	if err := mongo.EnsureAnomalyTTL(ctx, db, cfg.Alert.AnomalyRetention); err != nil {
+		log.Fatalf("mongodb: %v", err)
+	}
+
+	var sensorRepo repository.SensorDataRepository
+	var influxClient influxdb2.Client
+	switch cfg.Storage.Backend {
+	case config.StorageMongoDB:
+		sensorRepo = mongo.NewSensorRepository(db, mongo.NewRetryPolicy(cfg.MongoDB))
+	case config.StorageInfluxDB:
+		influxClient, err = influx.Connect(ctx, cfg.InfluxDB)
+		if err != nil {
+			log.Fatalf("influxdb: %v", err)
+		}
+		sensorRepo = influx.NewSensorRepository(influxClient, cfg.InfluxDB)
+	default:
+		log.Fatalf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+	deviceRepo := mongo.NewDeviceRepository(db)
+	userRepo := mongo.NewUserRepository(db)
+	shareRepo := mongo.NewShareRepository(db)
+	orgMemberRepo := mongo.NewOrgMemberRepository(db)
+	prefRepo := mongo.NewNotificationPreferenceRepository(db)
+	userDeviceRepo := mongo.NewUserDeviceRepository(db)
+
+	var alertSinks []alert.Sink
+	var emailSender *notifications.EmailSender
+	if cfg.SMTP.Host != "" {
+		emailSender = notifications.NewEmailSender(cfg.SMTP)
+		emailSender.Start()
+		alertSinks = append(alertSinks, notifications.NewAlertEmailer(deviceRepo, userRepo, prefRepo, emailSender, cfg.SMTP.DashboardURL))
+	} else {
+		log.Println("SMTP_HOST is not set, email alerts are disabled.")
+	}
+	var pushSender *notifications.FCMSender
+	if cfg.FCM.CredentialsFile != "" {
+		if pushSender, err = notifications.NewFCMSender(cfg.FCM, userDeviceRepo); err != nil {
+			log.Fatalf("notifications: %v", err)
+		}
+		pushSender.Start()
+		alertSinks = append(alertSinks, notifications.NewAlertPusher(deviceRepo, sensorRepo, prefRepo, userDeviceRepo, pushSender))
+	} else {
+		log.Println("FCM_CREDENTIALS_FILE is not set, push alerts are disabled.")
+	}
+	silenceRepo := mongo.NewSilenceRepository(db)
+	evaluator := alert.NewEvaluator(cfg.Alert, silenceRepo, alertSinks...)
+
+	latestCache := cache.NewLatestCache()
+	hub := events.NewHub()
+	anomalies := alert.NewAnomalyDetector(cfg.Alert)
+	var sensorWriter service.ReadingWriter
+	var writerPool *storage.WriterPool
+	if cfg.Storage.WorkerCount > 0 {
+		writerPool = storage.NewWriterPool(sensorRepo, cfg.Storage)
+		writerPool.Start()
+		sensorWriter = writerPool
+	}
+	sensorService := service.NewSensorService(sensorRepo, sensorWriter, deviceRepo, anomalies, mongo.NewSensorAnomalyRepository(db), evaluator,
+		quality.NewScorer(), latestCache, hub)
+	anomalyService := service.NewAnomalyService(sensorRepo, cfg.Alert)
+
+	watcher := config.NewWatcher(cfg)
+	watcher.Register(evaluator)
+	watcher.Register(anomalies)
+	go watcher.Watch(ctx)
+	quotaService := service.NewQuotaService(userRepo, cfg.Server.DeviceQuota, cfg.Server.RequireVerifiedEmail)
+	deviceService := service.NewDeviceService(deviceRepo, shareRepo, orgMemberRepo, sensorRepo, quotaService, latestCache)
+	shareService := service.NewShareService(shareRepo, userRepo)
+	refreshTokenRepo := mongo.NewRefreshTokenRepository(db)
+	tokenService := auth.NewService(refreshTokenRepo, userRepo, cfg.JWT)
+	sessionService := service.NewSessionService(userRepo, refreshTokenRepo, cfg.JWT)
+	var passwordChangedMailer service.PasswordChangedMailer
+	if emailSender != nil {
+		passwordChangedMailer = notifications.NewPasswordChangedMailer(emailSender, cfg.SMTP.DashboardURL)
+	}
+	var verificationMailer service.VerificationMailer
+	if emailSender != nil {
+		verificationMailer = notifications.NewVerificationMailer(emailSender, cfg.SMTP.DashboardURL)
+	}
+	apiKeyRepo := mongo.NewAPIKeyRepository(db)
+	registrationService := service.NewRegistrationService(mongo.NewEmailVerificationRepository(db), userRepo, sessionService, apiKeyRepo,
+		verificationMailer, cfg.JWT)
+	resetRepo := mongo.NewPasswordResetRepository(db)
+	userService := service.NewUserService(userRepo, sessionService, resetRepo, registrationService, passwordChangedMailer, cfg.JWT)
+	loginThrottle := service.NewLoginThrottle(mongo.NewLoginFailureRepository(db), cfg.JWT)
+	var resetMailer service.PasswordResetMailer
+	if emailSender != nil {
+		resetMailer = notifications.NewPasswordResetMailer(emailSender, cfg.SMTP.DashboardURL)
+	}
+	resetService := service.NewPasswordResetService(resetRepo, userRepo, sessionService, resetMailer, cfg.JWT)
+	var invitationMailer service.OrgInvitationMailer
+	if emailSender != nil {
+		invitationMailer = notifications.NewOrgInvitationMailer(emailSender, cfg.SMTP.DashboardURL)
+	}
+	orgService := service.NewOrgService(mongo.NewOrganizationRepository(db), orgMemberRepo, mongo.NewOrgInvitationRepository(db),
+		userRepo, deviceRepo, deviceService, invitationMailer)
+	keyService := service.NewDeviceKeyService(mongo.NewDeviceCredentialRepository(db))
+	topics, err := mqtt.NewTopics(cfg.MQTT)
+	if err != nil {
+		log.Fatalf("mqtt: %v", err)
+	}
+	credentialRepo := mongo.NewMQTTCredentialRepository(db)
+	credentialService := service.NewMQTTCredentialService(credentialRepo, deviceRepo, topics, cfg.MQTT)
+	switch {
+	case cfg.Provisioning.Secret == "":
+		log.Println("PROVISIONING_SECRET is not set, device provisioning is disabled.")
+	case cfg.Provisioning.Secret == cfg.JWT.Secret:
+		log.Fatalf("provisioning: PROVISIONING_SECRET must differ from JWT_SECRET")
+	}
+	var oidcProvider *auth.OIDCProvider
+	if cfg.OIDC.IssuerURL == "" {
+		log.Println("OIDC_ISSUER_URL is not set, OIDC sign-in is disabled.")
+	} else {
+		if cfg.OIDC.ClientID == "" || cfg.OIDC.RedirectURL == "" {
+			log.Fatalf("oidc: OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required with OIDC_ISSUER_URL")
+		}
+		if u, err := url.Parse(cfg.OIDC.DashboardURL); err != nil || !u.IsAbs() {
+			log.Fatalf("oidc: OIDC_DASHBOARD_URL %q is not an absolute URL", cfg.OIDC.DashboardURL)
+		}
+		oidcProvider = auth.NewOIDCProvider(cfg.OIDC)
+	}
+	oidcService := service.NewOIDCService(oidcProvider, mongo.NewOIDCLoginRepository(db), mongo.NewOIDCResultRepository(db),
+		mongo.NewOIDCLinkRepository(db), userRepo, cfg.OIDC)
+	provisioningService := service.NewProvisioningService(mongo.NewProvisioningTokenRepository(db), userRepo,
+		deviceService, credentialService, cfg.Provisioning)
+
+	mqttClient := mqtt.NewClient(cfg.MQTT)
+	commandService := service.NewCommandService(mongo.NewCommandRepository(db), mongo.NewCommandKeyRepository(db), deviceRepo, mqtt.NewPublisher(mqttClient, topics), hub, cfg.Command)
+	reaperCtx, stopReaper := context.WithCancel(ctx)
+	go commandService.RunReaper(reaperCtx, mongo.NewLockRepository(db), cfg.Command.ReapInterval, cfg.Command.PendingTTL)
+	scheduleService := service.NewCommandScheduleService(mongo.NewCommandScheduleRepository(db), mongo.NewScheduleRunRepository(db), commandService, deviceService)
+	go scheduleService.RunScheduler(reaperCtx, cfg.Command.ScheduleInterval)
+	transferService := service.NewTransferService(mongo.NewTransferRepository(db), deviceRepo, userRepo, sensorRepo, shareRepo,
+		quotaService, commandService, scheduleService)
+
+	handler := mqtt.NewHandler(topics, sensorService, deviceService, commandService, mqtt.NewDeduplicator(cfg.MQTT))
+	pool := mqtt.NewWorkerPool(cfg.MQTT, handler.Route)
+	pool.Start()
+
+	if err := mqttClient.Connect(); err != nil {
+		log.Fatalf("mqtt: %v", err)
+	}
+	if err := mqttClient.Subscribe(topics.Sensors(), 0, pool.Handler()); err != nil {
+		log.Fatalf("mqtt: %v", err)
+	}
+	if err := mqttClient.Subscribe(topics.Status(), 1, pool.Handler()); err != nil {
+		log.Fatalf("mqtt: %v", err)
+	}
+	if err := mqttClient.Subscribe(topics.Acks(), 1, pool.Handler()); err != nil {
+		log.Fatalf("mqtt: %v", err)
+	}
+
+	groupService := service.NewGroupService(mongo.NewGroupRepository(db), deviceService, sensorRepo)
+	decommissionService := service.NewDecommissionService(deviceRepo, sensorRepo, credentialRepo, commandService, latestCache)
+	batchService := service.NewCommandBatchService(mongo.NewCommandBatchRepository(db), commandService, deviceService, groupService)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	scopedTokenService := service.NewScopedTokenService(mongo.NewScopedTokenRepository(db), deviceService, cfg.JWT)
+	var graphqlHandler *handlers.GraphQLHandler
+	if cfg.Server.GraphQLEnabled {
+		schema, err := gql.NewSchema(gql.NewResolver(deviceService, sensorService, userRepo, hub))
+		if err != nil {
+			log.Fatalf("graphql: %v", err)
+		}
+		graphqlHandler = handlers.NewGraphQLHandler(schema)
+	}
+	auditLog := audit.NewLog(mongo.NewAuditRepository(db))
+	router := api.NewRouter(cfg, watcher, deviceService, keyService, apiKeyService, sessionService, scopedTokenService, userRepo, auditLog, api.Handlers{
+		Admin:   handlers.NewAdminHandler(quotaService, deviceService, service.NewFleetService(deviceRepo, userRepo), sessionService, auditLog),
+		APIKeys: handlers.NewAPIKeyHandler(apiKeyService),
+		Health: handlers.NewHealthHandler(func(ctx context.Context) error {
+			return mongoClient.Ping(ctx, nil)
+		}, cfg.MongoDB),
+		Auth:           handlers.NewAuthHandler(userService, tokenService, resetService, sessionService, loginThrottle, registrationService),
+		Commands:       handlers.NewCommandHandler(commandService, deviceService, userRepo),
+		CommandBatches: handlers.NewCommandBatchHandler(batchService),
+		Schedules:      handlers.NewCommandScheduleHandler(scheduleService),
+		Events:         handlers.NewEventHandler(hub, sensorService),
+		Dashboard:      handlers.NewDashboardHandler(service.NewDashboardService(deviceService, sensorRepo, latestCache, evaluator)),
+		Groups:         handlers.NewGroupHandler(groupService),
+		GraphQL:        graphqlHandler,
+		MQTTAuth:       handlers.NewMQTTAuthHandler(credentialService),
+		OIDC:           handlers.NewOIDCHandler(oidcService, tokenService, loginThrottle, cfg.OIDC),
+		Devices:        handlers.NewDeviceHandler(deviceService, credentialService, decommissionService, userRepo),
+		DeviceKeys:     handlers.NewDeviceKeyHandler(keyService),
+		Notifications:  handlers.NewNotificationHandler(service.NewNotificationService(prefRepo, userDeviceRepo)),
+		Orgs:           handlers.NewOrgHandler(orgService),
+		Profile:        handlers.NewProfileHandler(userService, tokenService, loginThrottle),
+		Provisioning:   handlers.NewProvisioningHandler(provisioningService),
+		Sensors:        handlers.NewSensorHandler(sensorService, anomalyService, cfg.Server),
+		Shares:         handlers.NewShareHandler(shareService),
+		Silences:       handlers.NewSilenceHandler(service.NewSilenceService(silenceRepo)),
+		Transfers:      handlers.NewTransferHandler(transferService),
+		ScopedTokens:   handlers.NewScopedTokenHandler(scopedTokenService),
+	})
+	// Requests run in requestCtx, cancelled when they outlive the shutdown
+	// timeout.
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	srv := &http.Server{
+		Addr:        ":" + cfg.Server.Port,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return requestCtx },
+	}
+	var redirect *http.Server
+	if api.TLSEnabled(cfg.Server.TLS) {
+		tlsConfig, certManager, err := api.NewTLSConfig(cfg.Server.TLS)
+		if err != nil {
+			log.Fatalf("http: %v", err)
+		}
+		srv.TLSConfig = tlsConfig
+
+		var redirectHandler http.Handler = api.RedirectHandler(cfg.Server.Port)
+		if certManager != nil {
+			redirectHandler = certManager.HTTPHandler(redirectHandler)
+		}
+		redirect = &http.Server{Addr: ":" + cfg.Server.TLS.RedirectPort, Handler: redirectHandler}
+		go func() {
+			if err := redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("http: redirect: %v", err)
+			}
+		}()
+	}
+	go func() {
+		var err error
+		switch {
+		case srv.TLSConfig == nil:
+			err = srv.ListenAndServe()
+		case cfg.Server.TLS.AutoCert:
+			// Certificates come from TLSConfig.GetCertificate.
+			err = srv.ListenAndServeTLS("", "")
+		default:
+			err = srv.ListenAndServeTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("http: %v", err)
+		}
+	}()
+
 	log.Println("Server is running.")
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	log.Println("Server is shutting down...")
+	// Requests and the queues behind them each get their own deadline, so
+	// that slow requests cannot use up the time to drain the queues.
+	shutdownCtx, cancel := context.WithTimeout(ctx, cfg.Server.ShutdownTimeout)
+	defer cancel()
+	shutdownHTTP(shutdownCtx, srv, cancelRequests)
+	if redirect != nil {
+		if err := redirect.Shutdown(shutdownCtx); err != nil {
+			log.Printf("http: redirect shutdown: %v", err)
+		}
+	}
+	stopReaper()
+	drainCtx, cancelDrain := context.WithTimeout(ctx, cfg.Server.DrainTimeout)
+	defer cancelDrain()
+	if err := mqttClient.Drain(drainCtx); err != nil {
+		log.Printf("mqtt: drain: %v", err)
+	}
+	if err := pool.Shutdown(drainCtx); err != nil {
+		log.Printf("mqtt: drain worker pool: %v", err)
+	}
+	if writerPool != nil {
+		if err := writerPool.Shutdown(drainCtx); err != nil {
+			log.Printf("storage: drain sensor writer: %v", err)
+		}
+	}
+	if emailSender != nil {
+		if err := emailSender.Shutdown(drainCtx); err != nil {
+			log.Printf("notifications: drain email queue: %v", err)
+		}
+	}
+	if pushSender != nil {
+		if err := pushSender.Shutdown(drainCtx); err != nil {
+			log.Printf("notifications: drain push queue: %v", err)
+		}
+	}
+	if influxClient != nil {
+		influxClient.Close()
+	}
+	disconnectCtx, cancelDisconnect := context.WithTimeout(ctx, mongoDisconnectTimeout)
+	defer cancelDisconnect()
+	if err := mongoClient.Disconnect(disconnectCtx); err != nil {
+		log.Printf("mongodb: disconnect: %v", err)
+	}
+}
+
+// shutdownHTTP stops srv and waits for the requests it is handling until
+// ctx expires, then cancels those still running with cancelRequests.
+func shutdownHTTP(ctx context.Context, srv *http.Server, cancelRequests context.CancelFunc) {
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("http: shutdown: %v; cancelling running requests", err)
+		cancelRequests()
+		srv.Close()
+	}
+}
+
+func loadActions(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return models.LoadActions(f)
+}
+
+// setScoreBreakpoints applies the configured health score breakpoints.
+func setScoreBreakpoints(tables map[string][]config.ScoreBreakpoint) error {
+	for field, table := range tables {
+		points := make([]models.ScoreBreakpoint, len(table))
+		for i, p := range table {
+			points[i] = models.ScoreBreakpoint{Value: p.Value, Score: p.Score}
+		}
+		if err := models.SetScoreBreakpoints(field, points); err != nil {
+			return err
+		}
+	}
+	return nil
 }
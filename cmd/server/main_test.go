@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShutdownHTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		// handlerTime is how long the request takes unless cancelled.
+		handlerTime   time.Duration
+		wantCompleted bool
+	}{
+		{"running request completes", 5 * time.Second, 100 * time.Millisecond, true},
+		{"request outliving the timeout is cancelled", 50 * time.Millisecond, time.Minute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			requestCtx, cancelRequests := context.WithCancel(context.Background())
+			defer cancelRequests()
+			started := make(chan struct{})
+			outcome := make(chan error, 1)
+			srv := &http.Server{
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					close(started)
+					select {
+					case <-time.After(tt.handlerTime):
+						outcome <- nil
+						io.WriteString(w, "done")
+					case <-r.Context().Done():
+						outcome <- r.Context().Err()
+					}
+				}),
+				BaseContext: func(net.Listener) context.Context { return requestCtx },
+			}
+			go srv.Serve(ln)
+
+			response := make(chan string, 1)
+			go func() {
+				resp, err := http.Get("http://" + ln.Addr().String())
+				if err != nil {
+					response <- err.Error()
+					return
+				}
+				defer resp.Body.Close()
+				body, _ := io.ReadAll(resp.Body)
+				response <- string(body)
+			}()
+			<-started
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			shutdownHTTP(ctx, srv, cancelRequests)
+
+			// The handler finishes, or sees its request cancelled.
+			select {
+			case err := <-outcome:
+				if completed := err == nil; completed != tt.wantCompleted {
+					t.Errorf("request completed = %v (%v), want %v", completed, err, tt.wantCompleted)
+				}
+				if err != nil && !errors.Is(err, context.Canceled) {
+					t.Errorf("request ended with %v, want context.Canceled", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("the request was neither completed nor cancelled")
+			}
+			if tt.wantCompleted {
+				if body := <-response; body != "done" {
+					t.Errorf("response = %q, want done", body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: anomaly.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the rolling z-score anomaly detector of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package alert
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+)
+
+// Anomaly is a reading whose z-score against the recent window exceeded K.
+type Anomaly struct {
+	DeviceID string
+	Field    string
+	Value    float64
+	Mean     float64
+	StdDev   float64
+	ZScore   float64
+}
+
+// ring is a fixed-size window that keeps running sums so mean and variance
+// are O(1) per reading.
+type ring struct {
+	vals       []float64
+	next, n    int
+	sum, sumSq float64
+}
+
+func (r *ring) push(v float64) {
+	if r.n == len(r.vals) {
+		old := r.vals[r.next]
+		r.sum -= old
+		r.sumSq -= old * old
+	} else {
+		r.n++
+	}
+	r.vals[r.next] = v
+	r.next = (r.next + 1) % len(r.vals)
+	r.sum += v
+	r.sumSq += v * v
+}
+
+func (r *ring) stats() (mean, stddev float64) {
+	n := float64(r.n)
+	mean = r.sum / n
+	variance := r.sumSq/n - mean*mean
+	if variance < 0 { // rounding
+		variance = 0
+	}
+	return mean, math.Sqrt(variance)
+}
+
+// AnomalyDetector flags readings that deviate from the rolling mean of the
+// same device and field by more than K standard deviations. Memory per
+// device and field is bounded by the window size.
+type AnomalyDetector struct {
+	window     int
+	minSamples int
+	k          float64
+
+	mu      sync.Mutex
+	windows map[string]*ring
+}
+
+func NewAnomalyDetector(cfg config.AlertConfig) *AnomalyDetector {
+	window := max(cfg.AnomalyWindow, 2)
+	return &AnomalyDetector{
+		window:     window,
+		minSamples: min(max(cfg.AnomalyMinSamples, 2), window),
+		k:          cfg.AnomalyK,
+		windows:    make(map[string]*ring),
+	}
+}
+
+// OnConfigChange applies a new K and warm-up. A new window size only
+// applies after a restart, as it would discard the collected windows.
+func (d *AnomalyDetector) OnConfigChange(old, new config.Config) {
+	if old.Alert.AnomalyK == new.Alert.AnomalyK && old.Alert.AnomalyMinSamples == new.Alert.AnomalyMinSamples {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.minSamples = min(max(new.Alert.AnomalyMinSamples, 2), d.window)
+	d.k = new.Alert.AnomalyK
+}
+
+// Observe scores value against the window of deviceID/field and then adds
+// it to the window. Nothing is flagged until minSamples values have been
+// seen, or while the window has no variance.
+func (d *AnomalyDetector) Observe(deviceID, field string, value float64) (Anomaly, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := deviceID + "/" + field
+	w, ok := d.windows[key]
+	if !ok {
+		w = &ring{vals: make([]float64, d.window)}
+		d.windows[key] = w
+	}
+	defer w.push(value)
+
+	if w.n < d.minSamples {
+		return Anomaly{}, false
+	}
+	mean, stddev := w.stats()
+	if stddev == 0 {
+		return Anomaly{}, false
+	}
+	z := (value - mean) / stddev
+	if math.Abs(z) <= d.k {
+		return Anomaly{}, false
+	}
+	return Anomaly{DeviceID: deviceID, Field: field, Value: value, Mean: mean, StdDev: stddev, ZScore: z}, true
+}
+
+// Outlier is a value of a series flagged by FindOutliers, at Index.
+type Outlier struct {
+	Index  int
+	Mean   float64
+	StdDev float64
+	ZScore float64
+}
+
+// FindOutliers scores each of values, oldest first, against the window
+// values before it, as the AnomalyDetector does for live readings, and
+// returns those whose z-score exceeds k in absolute value. Nothing is
+// flagged before minSamples values, or while the window has no variance.
+func FindOutliers(values []float64, window, minSamples int, k float64) []Outlier {
+	w := &ring{vals: make([]float64, max(window, 2))}
+	minSamples = min(max(minSamples, 2), len(w.vals))
+	var out []Outlier
+	for i, v := range values {
+		if w.n >= minSamples {
+			if mean, stddev := w.stats(); stddev > 0 {
+				if z := (v - mean) / stddev; math.Abs(z) > k {
+					out = append(out, Outlier{Index: i, Mean: mean, StdDev: stddev, ZScore: z})
+				}
+			}
+		}
+		w.push(v)
+	}
+	return out
+}
+
+// Check observes every sensor field of a reading.
+func (d *AnomalyDetector) Check(data *models.SensorData) []Anomaly {
+	fields := data.Sensors.Fields()
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []Anomaly
+	for _, name := range names {
+		if a, ok := d.Observe(data.DeviceID, name, fields[name].Value); ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
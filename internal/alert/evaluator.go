@@ -0,0 +1,196 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: evaluator.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the threshold alert evaluator of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package alert
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+)
+
+type Severity string
+
+const (
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityWarning:
+		return 1
+	case SeverityCritical:
+		return 2
+	}
+	return 0
+}
+
+// Event is emitted when a reading crosses a warning or critical threshold.
+type Event struct {
+	DeviceID  string    `json:"device_id"`
+	Field     string    `json:"field"`
+	Value     float64   `json:"value"`
+	Unit      string    `json:"unit"`
+	Threshold float64   `json:"threshold"`
+	Severity  Severity  `json:"severity"`
+	At        time.Time `json:"at"`
+}
+
+// Silences looks up the silences of a device that have not ended at now.
+type Silences interface {
+	ListActive(ctx context.Context, deviceID string, now time.Time) ([]models.Silence, error)
+}
+
+// Sink receives the events fired by the Evaluator, e.g. to notify users.
+type Sink interface {
+	Notify(ctx context.Context, e Event)
+}
+
+// Evaluator compares readings against the configured thresholds. An event
+// fires when a device/field escalates to a higher severity; it does not
+// fire again until the value has dropped back below that severity. Events
+// covered by a silence are not dispatched, and are not sent later either.
+type Evaluator struct {
+	silences Silences
+	sinks    []Sink
+
+	mu         sync.Mutex
+	thresholds map[string]config.Threshold
+	state      map[string]Severity
+}
+
+// NewEvaluator returns an Evaluator; silences may be nil.
+func NewEvaluator(cfg config.AlertConfig, silences Silences, sinks ...Sink) *Evaluator {
+	return &Evaluator{
+		thresholds: cfg.Thresholds,
+		silences:   silences,
+		sinks:      sinks,
+		state:      make(map[string]Severity),
+	}
+}
+
+// Evaluate checks every sensor of a reading and dispatches the events it
+// fires, less the silenced ones, to the sinks.
+func (e *Evaluator) Evaluate(ctx context.Context, data *models.SensorData) []Event {
+	fields := data.Sensors.Fields()
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var events []Event
+	for _, name := range names {
+		v := fields[name]
+		if ev, ok := e.check(data.DeviceID, name, v, data.Timestamp); ok {
+			events = append(events, ev)
+		}
+	}
+	events = e.unsilenced(ctx, data.DeviceID, events)
+	for _, ev := range events {
+		for _, s := range e.sinks {
+			s.Notify(ctx, ev)
+		}
+	}
+	return events
+}
+
+// unsilenced drops the events covered by an active silence. If silences
+// cannot be loaded the events go out: a missed alert is worse than a noisy
+// one.
+func (e *Evaluator) unsilenced(ctx context.Context, deviceID string, events []Event) []Event {
+	if len(events) == 0 || e.silences == nil {
+		return events
+	}
+	silences, err := e.silences.ListActive(ctx, deviceID, time.Now())
+	if err != nil {
+		log.Printf("alert: load silences of device %s: %v", deviceID, err)
+		return events
+	}
+	kept := events[:0]
+	for _, ev := range events {
+		if i := slices.IndexFunc(silences, func(s models.Silence) bool { return s.Covers(ev.Field, ev.At) }); i >= 0 {
+			log.Printf("alert: %s %s alert of device %s suppressed by silence %s", ev.Severity, ev.Field, deviceID, silences[i].ID)
+			continue
+		}
+		kept = append(kept, ev)
+	}
+	return kept
+}
+
+// Active counts the sensors of deviceID currently at warning or critical.
+func (e *Evaluator) Active(deviceID string) int {
+	prefix := deviceID + "/"
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := 0
+	for key, sev := range e.state {
+		if sev != "" && strings.HasPrefix(key, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// OnConfigChange applies new thresholds. A device/field whose severity
+// rises under them fires on its next reading.
+func (e *Evaluator) OnConfigChange(old, new config.Config) {
+	if reflect.DeepEqual(old.Alert.Thresholds, new.Alert.Thresholds) {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.thresholds = new.Alert.Thresholds
+}
+
+func (e *Evaluator) check(deviceID, field string, v models.SensorValue, at time.Time) (Event, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	t, ok := e.thresholds[field]
+	if !ok {
+		return Event{}, false
+	}
+	var (
+		sev       Severity
+		threshold float64
+	)
+	switch {
+	case t.Critical > 0 && v.Value >= t.Critical:
+		sev, threshold = SeverityCritical, t.Critical
+	case t.Warning > 0 && v.Value >= t.Warning:
+		sev, threshold = SeverityWarning, t.Warning
+	}
+
+	key := deviceID + "/" + field
+	prev := e.state[key]
+	e.state[key] = sev
+	if sev.rank() <= prev.rank() {
+		return Event{}, false
+	}
+	return Event{
+		DeviceID:  deviceID,
+		Field:     field,
+		Value:     v.Value,
+		Unit:      v.Unit,
+		Threshold: threshold,
+		Severity:  sev,
+		At:        at,
+	}, true
+}
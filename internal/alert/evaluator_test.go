@@ -0,0 +1,96 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: evaluator_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of suppressing alerts with silences.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package alert
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+)
+
+// fakeSilences returns its silences that have not ended at now, as the
+// repository does, or err.
+type fakeSilences struct {
+	silences []models.Silence
+	err      error
+}
+
+func (f *fakeSilences) ListActive(_ context.Context, deviceID string, now time.Time) ([]models.Silence, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var out []models.Silence
+	for _, s := range f.silences {
+		if s.DeviceID == deviceID && s.EndsAt.After(now) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+type recordingSink struct {
+	events []Event
+}
+
+func (s *recordingSink) Notify(_ context.Context, e Event) {
+	s.events = append(s.events, e)
+}
+
+func TestEvaluateSilences(t *testing.T) {
+	now := time.Now().UTC()
+	silence := func(starts, ends time.Duration, alertIDs ...string) models.Silence {
+		return models.Silence{ID: "s1", DeviceID: "d1", AlertIDs: alertIDs, StartsAt: now.Add(starts), EndsAt: now.Add(ends)}
+	}
+	tests := []struct {
+		name     string
+		silences *fakeSilences
+		want     []string // fields alerted
+	}{
+		{name: "no silences", silences: &fakeSilences{}, want: []string{"co2", "pm25"}},
+		{name: "active silence", silences: &fakeSilences{silences: []models.Silence{silence(-time.Hour, time.Hour)}}},
+		{name: "active silence of one alert", silences: &fakeSilences{silences: []models.Silence{silence(-time.Hour, time.Hour, "pm25")}},
+			want: []string{"co2"}},
+		{name: "expired silence", silences: &fakeSilences{silences: []models.Silence{silence(-2*time.Hour, -time.Hour)}},
+			want: []string{"co2", "pm25"}},
+		{name: "silence yet to start", silences: &fakeSilences{silences: []models.Silence{silence(time.Hour, 2*time.Hour)}},
+			want: []string{"co2", "pm25"}},
+		{name: "silence of another device", silences: &fakeSilences{silences: []models.Silence{{ID: "s2", DeviceID: "d2",
+			StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}}}, want: []string{"co2", "pm25"}},
+		{name: "silences not loaded", silences: &fakeSilences{err: errors.New("down")}, want: []string{"co2", "pm25"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &recordingSink{}
+			e := NewEvaluator(config.AlertConfig{Thresholds: map[string]config.Threshold{
+				"pm25": {Warning: 35, Critical: 150},
+				"co2":  {Warning: 1000, Critical: 2000},
+			}}, tt.silences, sink)
+			data := &models.SensorData{DeviceID: "d1", Timestamp: now, Sensors: models.Sensors{
+				PM25: models.SensorValue{Value: 200},
+				CO2:  models.SensorValue{Value: 1500},
+			}}
+			events := e.Evaluate(context.Background(), data)
+			if len(events) != len(tt.want) || len(sink.events) != len(tt.want) {
+				t.Fatalf("Evaluate() = %v, sink got %v, want alerts of %v", events, sink.events, tt.want)
+			}
+			for i, ev := range sink.events {
+				if ev.Field != tt.want[i] {
+					t.Errorf("alert %d of %s, want %s", i, ev.Field, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: resolver.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the resolvers of the GraphQL dashboard queries.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package gql
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"airsense-be.com/internal/events"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+)
+
+type userKey struct{}
+
+// WithUser returns ctx carrying the authenticated userID, which every
+// resolver checks access against.
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+func userID(ctx context.Context) string {
+	id, _ := ctx.Value(userKey{}).(string)
+	return id
+}
+
+var errForbidden = errors.New("forbidden")
+
+// Resolver is the root resolver of the schema.
+type Resolver struct {
+	devices *service.DeviceService
+	sensors *service.SensorService
+	users   repository.UserRepository
+	hub     *events.Hub
+}
+
+func NewResolver(devices *service.DeviceService, sensors *service.SensorService, users repository.UserRepository, hub *events.Hub) *Resolver {
+	return &Resolver{devices: devices, sensors: sensors, users: users, hub: hub}
+}
+
+func (r *Resolver) Devices(ctx context.Context, args struct{ UserID *graphql.ID }) ([]*deviceResolver, error) {
+	owner := userID(ctx)
+	if args.UserID != nil && string(*args.UserID) != owner {
+		u, err := r.users.GetByID(ctx, owner)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, resolveError(err)
+		}
+		if u == nil || u.Role != models.RoleAdmin {
+			return nil, errForbidden
+		}
+		owner = string(*args.UserID)
+	}
+	views, err := r.devices.All(ctx, owner)
+	if err != nil {
+		return nil, resolveError(err)
+	}
+	out := make([]*deviceResolver, len(views))
+	for i := range views {
+		out[i] = &deviceResolver{views[i]}
+	}
+	return out, nil
+}
+
+type telemetryArgs struct {
+	DeviceID graphql.ID
+	From     graphql.Time
+	To       graphql.Time
+	Bucket   string
+	Tz       *string
+}
+
+func (r *Resolver) Telemetry(ctx context.Context, args telemetryArgs) ([]*bucketResolver, error) {
+	if _, err := r.devices.Authorize(ctx, string(args.DeviceID), userID(ctx), service.AccessRead, false); err != nil {
+		return nil, resolveError(err)
+	}
+	var tz string
+	if args.Tz != nil {
+		tz = *args.Tz
+	}
+	filter := repository.SensorFilter{DeviceID: string(args.DeviceID), From: args.From.Time, To: args.To.Time}
+	buckets, _, err := r.sensors.Aggregate(ctx, filter, args.Bucket, tz, false)
+	if err != nil {
+		return nil, resolveError(err)
+	}
+	out := make([]*bucketResolver, len(buckets))
+	for i := range buckets {
+		out[i] = &bucketResolver{buckets[i]}
+	}
+	return out, nil
+}
+
+func (r *Resolver) LatestReading(ctx context.Context, args struct{ DeviceID graphql.ID }) (*readingResolver, error) {
+	if _, err := r.devices.Authorize(ctx, string(args.DeviceID), userID(ctx), service.AccessRead, false); err != nil {
+		return nil, resolveError(err)
+	}
+	d, err := r.sensors.Latest(ctx, string(args.DeviceID))
+	if err != nil {
+		return nil, resolveError(err)
+	}
+	if d == nil {
+		return nil, nil
+	}
+	return &readingResolver{d}, nil
+}
+
+// LiveTelemetry sends the new readings of the device until ctx ends, when
+// the client goes away.
+func (r *Resolver) LiveTelemetry(ctx context.Context, args struct{ DeviceID graphql.ID }) (<-chan *readingResolver, error) {
+	if _, err := r.devices.Authorize(ctx, string(args.DeviceID), userID(ctx), service.AccessRead, false); err != nil {
+		return nil, resolveError(err)
+	}
+	ch, unsubscribe := r.hub.Subscribe(string(args.DeviceID))
+	out := make(chan *readingResolver)
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-ch:
+				d, ok := e.Data.(*models.SensorData)
+				if e.Type != events.TypeSensorData || !ok {
+					continue
+				}
+				select {
+				case out <- &readingResolver{d}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// resolveError keeps the message of the errors a client can act on and
+// logs the others, which might reveal internals, behind a generic one.
+func resolveError(err error) error {
+	for _, known := range []error{
+		service.ErrDeviceNotFound, service.ErrForbidden, service.ErrDeviceDeleted,
+		service.ErrInvalidInterval, service.ErrInvalidTimezone, service.ErrUnsupportedQuery,
+		context.DeadlineExceeded, context.Canceled,
+	} {
+		if errors.Is(err, known) {
+			return err
+		}
+	}
+	log.Printf("graphql: %v", err)
+	return errors.New("internal error")
+}
+
+type deviceResolver struct {
+	d service.DeviceView
+}
+
+func (r *deviceResolver) ID() graphql.ID       { return graphql.ID(r.d.ID) }
+func (r *deviceResolver) Name() string         { return r.d.Name }
+func (r *deviceResolver) Location() string     { return r.d.Location }
+func (r *deviceResolver) Status() string       { return string(r.d.Status) }
+func (r *deviceResolver) Tags() []*tagResolver { return tags(r.d.Tags) }
+
+func (r *deviceResolver) LastSeenAt() *graphql.Time {
+	if r.d.LastSeenAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.d.LastSeenAt}
+}
+
+func (r *deviceResolver) FirmwareVersion() *string { return optional(r.d.FirmwareVersion) }
+
+func (r *deviceResolver) SharedBy() *graphql.ID {
+	if r.d.SharedBy == "" {
+		return nil
+	}
+	id := graphql.ID(r.d.SharedBy)
+	return &id
+}
+
+func (r *deviceResolver) Permission() *string { return optional(string(r.d.Permission)) }
+
+type readingResolver struct {
+	d *models.SensorData
+}
+
+func (r *readingResolver) ID() graphql.ID       { return graphql.ID(r.d.ID) }
+func (r *readingResolver) DeviceID() graphql.ID { return graphql.ID(r.d.DeviceID) }
+func (r *readingResolver) Timestamp() graphql.Time {
+	return graphql.Time{Time: r.d.Timestamp}
+}
+func (r *readingResolver) Pm25() *valueResolver { return &valueResolver{r.d.Sensors.PM25} }
+func (r *readingResolver) Co2() *valueResolver  { return &valueResolver{r.d.Sensors.CO2} }
+func (r *readingResolver) Co() *valueResolver   { return &valueResolver{r.d.Sensors.CO} }
+func (r *readingResolver) Temperature() *valueResolver {
+	return &valueResolver{r.d.Sensors.Temperature}
+}
+func (r *readingResolver) Humidity() *valueResolver { return &valueResolver{r.d.Sensors.Humidity} }
+func (r *readingResolver) Aqi() int32               { return int32(r.d.AQI) }
+func (r *readingResolver) AqiCategory() *string     { return optional(r.d.AQICategory) }
+func (r *readingResolver) Quality() *string         { return optional(string(r.d.Quality)) }
+func (r *readingResolver) Tags() []*tagResolver     { return tags(r.d.Tags) }
+
+type valueResolver struct {
+	v models.SensorValue
+}
+
+func (r *valueResolver) Value() float64       { return r.v.Value }
+func (r *valueResolver) Unit() string         { return r.v.Unit }
+func (r *valueResolver) Confidence() *float64 { return r.v.Confidence }
+
+type tagResolver struct {
+	key, value string
+}
+
+func (r *tagResolver) Key() string   { return r.key }
+func (r *tagResolver) Value() string { return r.value }
+
+// tags returns m sorted by key.
+func tags(m map[string]string) []*tagResolver {
+	out := make([]*tagResolver, 0, len(m))
+	for k, v := range m {
+		out = append(out, &tagResolver{k, v})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].key < out[j].key })
+	return out
+}
+
+type bucketResolver struct {
+	a models.SensorAggregate
+}
+
+func (r *bucketResolver) Bucket() graphql.Time { return graphql.Time{Time: r.a.Bucket} }
+func (r *bucketResolver) Count() int32         { return int32(r.a.Count) }
+
+// Fields returns the stats sorted by field.
+func (r *bucketResolver) Fields() []*statsResolver {
+	out := make([]*statsResolver, 0, len(r.a.Fields))
+	for f, s := range r.a.Fields {
+		out = append(out, &statsResolver{f, s})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].field < out[j].field })
+	return out
+}
+
+type statsResolver struct {
+	field string
+	s     models.AggregateStats
+}
+
+func (r *statsResolver) Field() string { return r.field }
+func (r *statsResolver) Avg() *float64 { return r.s.Avg }
+func (r *statsResolver) Min() *float64 { return r.s.Min }
+func (r *statsResolver) Max() *float64 { return r.s.Max }
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,103 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: schema.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the GraphQL schema of the dashboard queries.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+// Package gql serves dashboard queries over GraphQL, resolved with the
+// same services and access checks as the REST API.
+package gql
+
+import (
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// maxDepth bounds the nesting of a query.
+const maxDepth = 8
+
+const schema = `
+scalar Time
+
+schema {
+	query: Query
+	subscription: Subscription
+}
+
+type Query {
+	# The devices userID owns or has been shared, the caller's by default.
+	# Only admins may list those of another user.
+	devices(userID: ID): [Device!]!
+	# The readings of a device the caller can read, aggregated into hour,
+	# day, week or month buckets in tz, UTC by default.
+	telemetry(deviceID: ID!, from: Time!, to: Time!, bucket: String!, tz: String): [TelemetryBucket!]!
+	# The newest reading of a device the caller can read, null if none.
+	latestReading(deviceID: ID!): Reading
+}
+
+type Subscription {
+	# Every new reading of a device the caller can read.
+	liveTelemetry(deviceID: ID!): Reading!
+}
+
+type Device {
+	id: ID!
+	name: String!
+	location: String!
+	status: String!
+	lastSeenAt: Time
+	firmwareVersion: String
+	tags: [Tag!]!
+	# The owner and permission of a device shared with the caller.
+	sharedBy: ID
+	permission: String
+}
+
+type Reading {
+	id: ID!
+	deviceID: ID!
+	timestamp: Time!
+	pm25: SensorValue!
+	co2: SensorValue!
+	co: SensorValue!
+	temperature: SensorValue!
+	humidity: SensorValue!
+	aqi: Int!
+	aqiCategory: String
+	quality: String
+	tags: [Tag!]!
+}
+
+type SensorValue {
+	value: Float!
+	unit: String!
+	confidence: Float
+}
+
+type Tag {
+	key: String!
+	value: String!
+}
+
+type TelemetryBucket {
+	bucket: Time!
+	count: Int!
+	fields: [FieldStats!]!
+}
+
+type FieldStats {
+	field: String!
+	avg: Float
+	min: Float
+	max: Float
+}
+`
+
+// NewSchema parses the schema with r resolving it.
+func NewSchema(r *Resolver) (*graphql.Schema, error) {
+	return graphql.ParseSchema(schema, r, graphql.MaxDepth(maxDepth))
+}
@@ -0,0 +1,162 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: admin.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the admin REST handlers of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type AdminHandler struct {
+	quota    *service.QuotaService
+	devices  *service.DeviceService
+	fleet    *service.FleetService
+	sessions *service.SessionService
+	audit    *audit.Log
+}
+
+func NewAdminHandler(quota *service.QuotaService, devices *service.DeviceService, fleet *service.FleetService,
+	sessions *service.SessionService, audit *audit.Log) *AdminHandler {
+	return &AdminHandler{quota: quota, devices: devices, fleet: fleet, sessions: sessions, audit: audit}
+}
+
+// Docs implements openapi.Documented.
+func (h *AdminHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"SetQuota": {
+			Summary: "Set the device quota of a user",
+			Body:    setQuotaRequest{},
+			Response: struct {
+				UserID      string `json:"user_id"`
+				DeviceLimit *int   `json:"device_limit"`
+			}{},
+		},
+		"ListDevices": {
+			Summary:  "List the devices of every user",
+			Params:   append(deviceListParams, openapi.Param{Name: "user_id", Description: "Only the devices of this user."}),
+			Response: service.DevicePage{},
+		},
+		"Stats": {Summary: "Statistics of the whole fleet", Response: service.FleetStats{}},
+		"RevokeSessions": {
+			Summary:     "Log a user out on every device",
+			Description: "Revokes every refresh token of the user and refuses the access tokens issued so far. API keys of the user keep working.",
+			Status:      http.StatusNoContent,
+		},
+		"AuditLog": {
+			Summary: "List who changed what, newest first",
+			Params: []openapi.Param{
+				{Name: "resource_id", Description: "Only the changes of this resource, e.g. a device ID."},
+				{Name: "user_id", Description: "Only the changes made by this user."},
+				{Name: "limit", Type: "integer", Description: "1 to 1000, 100 by default."},
+			},
+			Response: dataResponse[[]models.AuditEntry]{},
+		},
+		"Metrics": {
+			Summary:     "The counters and gauges of the process",
+			Description: "The expvar variables of the process, e.g. mqtt_messages_deduplicated_total, by name.",
+			Response:    map[string]any{},
+		},
+	}
+}
+
+// Metrics handles GET /debug/vars with the variables published through
+// expvar, see package metrics.
+func (h *AdminHandler) Metrics(c *gin.Context) {
+	expvar.Handler().ServeHTTP(c.Writer, c.Request)
+}
+
+type setQuotaRequest struct {
+	// DeviceLimit null resets the user to the global default.
+	DeviceLimit *int `json:"device_limit"`
+}
+
+// SetQuota handles PUT /admin/users/:id/quota.
+func (h *AdminHandler) SetQuota(c *gin.Context) {
+	var req setQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "device_limit must be an integer or null.")
+		return
+	}
+	if err := h.quota.SetLimit(c.Request.Context(), c.Param("id"), req.DeviceLimit); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": c.Param("id"), "device_limit": req.DeviceLimit})
+}
+
+// ListDevices handles GET /admin/devices, which takes the parameters of
+// GET /devices over the devices of every user, or with user_id of one.
+func (h *AdminHandler) ListDevices(c *gin.Context) {
+	q, ok := deviceQuery(c)
+	if !ok {
+		return
+	}
+	page, err := h.devices.ListAll(c.Request.Context(), c.Query("user_id"), q)
+	respondDevicePage(c, page, err)
+}
+
+// Stats handles GET /admin/stats.
+func (h *AdminHandler) Stats(c *gin.Context) {
+	stats, err := h.fleet.Stats(c.Request.Context())
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// RevokeSessions handles POST /admin/users/:id/revoke-sessions, e.g. for
+// a user whose account was compromised.
+func (h *AdminHandler) RevokeSessions(c *gin.Context) {
+	if err := h.sessions.RevokeAll(c.Request.Context(), c.Param("id")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AuditLog handles GET /admin/audit?resource_id=&user_id=&limit=
+func (h *AdminHandler) AuditLog(c *gin.Context) {
+	filter := repository.AuditFilter{
+		ResourceID: c.Query("resource_id"),
+		UserID:     c.Query("user_id"),
+		Limit:      defaultAuditLimit,
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			respondError(c, http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 1000.")
+			return
+		}
+		filter.Limit = n
+	}
+	entries, err := h.audit.List(c.Request.Context(), filter)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
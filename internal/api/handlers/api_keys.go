@@ -0,0 +1,141 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: api_keys.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers for user API keys in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type APIKeyHandler struct {
+	keys *service.APIKeyService
+}
+
+func NewAPIKeyHandler(keys *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys}
+}
+
+// Docs implements openapi.Documented.
+func (h *APIKeyHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Create": {
+			Summary:     "Create an API key",
+			Description: "The plaintext key is only returned by this call.",
+			Body:        createAPIKeyRequest{},
+			Status:      http.StatusCreated,
+			Response:    service.CreatedAPIKey{},
+		},
+		"List":   {Summary: "List your API keys", Response: dataResponse[[]models.APIKey]{}},
+		"Get":    {Summary: "Get an API key", Response: models.APIKey{}},
+		"Update": {Summary: "Update an API key", Description: `"expires_at": null makes the key never expire.`, Body: updateAPIKeyRequest{}, Response: models.APIKey{}},
+		"Delete": {Summary: "Revoke an API key", Status: http.StatusNoContent},
+	}
+}
+
+type createAPIKeyRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Permissions []string   `json:"permissions" binding:"required"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// Create handles POST /apikeys. The plaintext key is only ever returned by
+// this call.
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "name and permissions are required; expires_at must be an RFC 3339 timestamp.")
+		return
+	}
+	key, err := h.keys.Create(c.Request.Context(), middleware.UserID(c), req.Name, req.Permissions, req.ExpiresAt)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+// List handles GET /apikeys.
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.keys.List(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// Get handles GET /apikeys/:keyId.
+func (h *APIKeyHandler) Get(c *gin.Context) {
+	key, err := h.keys.Get(c.Request.Context(), middleware.UserID(c), c.Param("keyId"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
+
+type updateAPIKeyRequest struct {
+	Name        *string  `json:"name"`
+	Permissions []string `json:"permissions"`
+	// ExpiresAt is kept raw so an explicit null, which clears the expiry,
+	// can be told apart from an absent field.
+	ExpiresAt json.RawMessage `json:"expires_at"`
+}
+
+// Update handles PATCH /apikeys/:keyId. "expires_at": null makes the key
+// never expire.
+func (h *APIKeyHandler) Update(c *gin.Context) {
+	var req updateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "Request body must be a JSON object.")
+		return
+	}
+	var u service.APIKeyUpdate
+	u.Name = req.Name
+	u.Permissions = req.Permissions
+	switch {
+	case len(req.ExpiresAt) == 0:
+	case bytes.Equal(req.ExpiresAt, []byte("null")):
+		u.ClearExpiry = true
+	default:
+		var t time.Time
+		if err := json.Unmarshal(req.ExpiresAt, &t); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_BODY", "expires_at must be an RFC 3339 timestamp or null.")
+			return
+		}
+		u.ExpiresAt = &t
+	}
+	key, err := h.keys.Update(c.Request.Context(), middleware.UserID(c), c.Param("keyId"), u)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
+
+// Delete handles DELETE /apikeys/:keyId, revoking the key at once.
+func (h *APIKeyHandler) Delete(c *gin.Context) {
+	if err := h.keys.Delete(c.Request.Context(), middleware.UserID(c), c.Param("keyId")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
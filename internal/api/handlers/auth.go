@@ -0,0 +1,274 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: auth.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers for authentication in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/service"
+)
+
+type AuthHandler struct {
+	users         *service.UserService
+	tokens        *auth.Service
+	resets        *service.PasswordResetService
+	sessions      *service.SessionService
+	throttle      *service.LoginThrottle
+	registrations *service.RegistrationService
+}
+
+func NewAuthHandler(users *service.UserService, tokens *auth.Service, resets *service.PasswordResetService,
+	sessions *service.SessionService, throttle *service.LoginThrottle, registrations *service.RegistrationService) *AuthHandler {
+	return &AuthHandler{users: users, tokens: tokens, resets: resets, sessions: sessions, throttle: throttle,
+		registrations: registrations}
+}
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+// Docs implements openapi.Documented.
+func (h *AuthHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Login": {
+			Summary:     "Log in with email and password",
+			Description: "Repeated failures lock the account for the client IP, and many more the IP for every account, with 429 and Retry-After. Failures from anywhere beyond a higher limit slow logins to the account down.",
+			Body:        loginRequest{},
+			Response:    auth.TokenPair{},
+		},
+		"Refresh": {Summary: "Exchange a refresh token for a new token pair", Body: refreshRequest{}, Response: auth.TokenPair{}},
+		"ForgotPassword": {
+			Summary:     "Mail a password reset token",
+			Description: "Answers 202 for any address, so it does not tell which ones are registered.",
+			Body:        forgotPasswordRequest{},
+			Status:      http.StatusAccepted,
+			Response:    messageResponse{},
+		},
+		"ResetPassword": {
+			Summary:     "Set a new password with a reset token",
+			Description: "Every session of the user ends.",
+			Body:        resetPasswordRequest{},
+			Status:      http.StatusNoContent,
+		},
+		"Register": {
+			Summary:     "Sign up with email and password",
+			Description: "Creates an unverified user and mails it a verification token; registering the address of an unverified user replaces its password instead. Answers 202 also when the address is taken, so it does not tell which ones are registered. Unverified users can log in but, by default, not add devices.",
+			Body:        registerRequest{},
+			Status:      http.StatusAccepted,
+			Response:    messageResponse{},
+		},
+		"Verify": {Summary: "Confirm an email address with a verification token", Body: verifyRequest{}, Response: messageResponse{}},
+		"VerifyLink": {
+			Summary:  "Confirm an email address with a verification token",
+			Params:   []openapi.Param{{Name: "token", Required: true, Description: "The token from the verification email."}},
+			Response: messageResponse{},
+		},
+		"ResendVerification": {
+			Summary:     "Mail a new verification token",
+			Description: "Answers 202 for any address, so it does not tell which ones are registered or verified.",
+			Body:        forgotPasswordRequest{},
+			Status:      http.StatusAccepted,
+			Response:    messageResponse{},
+		},
+		"LogoutAll": {
+			Summary:     "Log out on every device",
+			Description: "Revokes every refresh token of the user and refuses the access tokens issued so far, including the one of the request. API keys keep working.",
+			Status:      http.StatusNoContent,
+		},
+	}
+}
+
+type loginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// Login handles POST /auth/login. While too many logins to the account
+// failed it answers 429, without checking the password.
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "email and password are required.")
+		return
+	}
+	ctx, ip := c.Request.Context(), c.ClientIP()
+	if err := h.throttle.Attempt(ctx, req.Email, ip); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	user, err := h.users.Authenticate(ctx, req.Email, req.Password)
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		h.throttle.Fail()
+		respondError(c, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password.")
+		return
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if err := h.throttle.Reset(ctx, req.Email, ip); err != nil {
+		log.Printf("auth: reset failed logins: %v", err)
+	}
+	pair, err := h.tokens.IssuePair(c.Request.Context(), user.ID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh handles POST /auth/refresh, rotating the presented refresh token.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "refresh_token is required.")
+		return
+	}
+	pair, err := h.tokens.Refresh(c.Request.Context(), req.RefreshToken)
+	if errors.Is(err, auth.ErrInvalidRefreshToken) || errors.Is(err, auth.ErrRefreshTokenReused) {
+		respondError(c, http.StatusUnauthorized, "INVALID_REFRESH_TOKEN", "Refresh token is invalid, expired or revoked.")
+		return
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// ForgotPassword handles POST /auth/forgot-password. It answers 202 for
+// any address so that it does not tell which ones are registered.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "email is required.")
+		return
+	}
+	if err := h.resets.Forgot(c.Request.Context(), req.Email); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered, a reset link has been sent to it."})
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ResetPassword handles POST /auth/reset-password, setting the password
+// and signing the user out everywhere.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "token and password are required.")
+		return
+	}
+	if err := h.resets.Reset(c.Request.Context(), req.Token, req.Password); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+type registerRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// Register handles POST /auth/register. It answers 202 whether or not the
+// address was taken.
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req registerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "email and password are required.")
+		return
+	}
+	if err := h.registrations.Register(c.Request.Context(), req.Email, req.Password); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Check your email to confirm the address and finish signing up."})
+}
+
+type verifyRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Verify handles POST /auth/verify.
+func (h *AuthHandler) Verify(c *gin.Context) {
+	var req verifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "token is required.")
+		return
+	}
+	h.verify(c, req.Token)
+}
+
+// VerifyLink handles GET /auth/verify?token=, for links followed straight
+// from the email.
+func (h *AuthHandler) VerifyLink(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		respondError(c, http.StatusBadRequest, "INVALID_QUERY", "token is required.")
+		return
+	}
+	h.verify(c, token)
+}
+
+func (h *AuthHandler) verify(c *gin.Context, token string) {
+	if err := h.registrations.Verify(c.Request.Context(), token); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email address verified."})
+}
+
+// ResendVerification handles POST /auth/verify/resend. Like ForgotPassword
+// it answers 202 for any address.
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "email is required.")
+		return
+	}
+	if err := h.registrations.Resend(c.Request.Context(), req.Email); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email awaits confirmation, a new link has been sent to it."})
+}
+
+// LogoutAll handles POST /auth/logout-all, ending every session of the
+// user, this one included.
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	if err := h.sessions.RevokeAll(c.Request.Context(), middleware.UserID(c)); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
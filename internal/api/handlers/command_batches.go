@@ -0,0 +1,94 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_batches.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers for bulk commands in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type CommandBatchHandler struct {
+	batches *service.CommandBatchService
+}
+
+func NewCommandBatchHandler(batches *service.CommandBatchService) *CommandBatchHandler {
+	return &CommandBatchHandler{batches: batches}
+}
+
+// Docs implements openapi.Documented.
+func (h *CommandBatchHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Create": {
+			Summary:     "Send a command to several devices",
+			Description: "Devices the caller cannot control are listed in skipped.",
+			Body:        createCommandBatchRequest{},
+			Status:      http.StatusCreated,
+			Response:    service.BatchSummary{},
+		},
+		"Get": {Summary: "Get a command batch", Response: service.BatchSummary{}},
+	}
+}
+
+type createCommandBatchRequest struct {
+	DeviceIDs  []string             `json:"device_ids"`
+	GroupID    string               `json:"group_id"`
+	Action     string               `json:"action" binding:"required"`
+	Params     map[string]any       `json:"params"`
+	TTLSeconds int                  `json:"ttl_seconds"`
+	Retry      *commandRetryRequest `json:"retry"`
+}
+
+// Create handles POST /commands/bulk. Devices the caller cannot control
+// are listed in skipped; the batch is created as long as the request
+// itself is valid.
+func (h *CommandBatchHandler) Create(c *gin.Context) {
+	var req createCommandBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "action and either device_ids or group_id are required.")
+		return
+	}
+
+	br := service.BatchRequest{
+		UserID:    middleware.UserID(c),
+		DeviceIDs: req.DeviceIDs,
+		GroupID:   req.GroupID,
+		Action:    req.Action,
+		Params:    req.Params,
+		TTL:       time.Duration(req.TTLSeconds) * time.Second,
+	}
+	if req.Retry != nil {
+		br.Retry = &models.CommandRetry{MaxAttempts: req.Retry.MaxAttempts, BackoffSeconds: req.Retry.BackoffSeconds}
+	}
+	summary, err := h.batches.Create(c.Request.Context(), br)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, batchView(c, summary))
+}
+
+// Get handles GET /commands/bulk/:batchId.
+func (h *CommandBatchHandler) Get(c *gin.Context) {
+	summary, err := h.batches.Get(c.Request.Context(), c.Param("batchId"), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, batchView(c, summary))
+}
@@ -0,0 +1,144 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_schedules.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the REST handlers for scheduled commands in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type CommandScheduleHandler struct {
+	schedules *service.CommandScheduleService
+}
+
+func NewCommandScheduleHandler(schedules *service.CommandScheduleService) *CommandScheduleHandler {
+	return &CommandScheduleHandler{schedules: schedules}
+}
+
+// Docs implements openapi.Documented.
+func (h *CommandScheduleHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Create": {Summary: "Schedule a command", Body: scheduleRequest{}, Status: http.StatusCreated, Response: models.CommandSchedule{}},
+		"List":   {Summary: "List the command schedules of a device", Response: dataResponse[[]models.CommandSchedule]{}},
+		"Get":    {Summary: "Get a command schedule", Response: models.CommandSchedule{}},
+		"Update": {Summary: "Replace a command schedule", Body: scheduleRequest{}, Response: models.CommandSchedule{}},
+		"Delete": {Summary: "Delete a command schedule", Status: http.StatusNoContent},
+		"Runs": {
+			Summary:  "List the runs of a command schedule",
+			Params:   []openapi.Param{{Name: "limit", Type: "integer", Description: "1 to 200, 50 by default."}},
+			Response: dataResponse[[]models.ScheduleRun]{},
+		},
+	}
+}
+
+const (
+	defaultRunLimit = 50
+	maxRunLimit     = 200
+)
+
+type scheduleRequest struct {
+	Action   string         `json:"action" binding:"required"`
+	Params   map[string]any `json:"params"`
+	RunAt    *time.Time     `json:"run_at"`
+	Cron     string         `json:"cron"`
+	Timezone string         `json:"timezone"`
+}
+
+func (r scheduleRequest) spec() service.ScheduleSpec {
+	return service.ScheduleSpec{Action: r.Action, Params: r.Params, RunAt: r.RunAt, Cron: r.Cron, Timezone: r.Timezone}
+}
+
+// Create handles POST /devices/:id/schedules.
+func (h *CommandScheduleHandler) Create(c *gin.Context) {
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "action is required and run_at must be RFC 3339.")
+		return
+	}
+	sch, err := h.schedules.Create(c.Request.Context(), c.Param("id"), middleware.UserID(c), req.spec())
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, sch)
+}
+
+// List handles GET /devices/:id/schedules.
+func (h *CommandScheduleHandler) List(c *gin.Context) {
+	schedules, err := h.schedules.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": schedules})
+}
+
+// Get handles GET /devices/:id/schedules/:scheduleId.
+func (h *CommandScheduleHandler) Get(c *gin.Context) {
+	sch, err := h.schedules.Get(c.Request.Context(), c.Param("id"), c.Param("scheduleId"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sch)
+}
+
+// Update handles PUT /devices/:id/schedules/:scheduleId.
+func (h *CommandScheduleHandler) Update(c *gin.Context) {
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "action is required and run_at must be RFC 3339.")
+		return
+	}
+	sch, err := h.schedules.Update(c.Request.Context(), c.Param("id"), c.Param("scheduleId"), middleware.UserID(c), req.spec())
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sch)
+}
+
+// Delete handles DELETE /devices/:id/schedules/:scheduleId.
+func (h *CommandScheduleHandler) Delete(c *gin.Context) {
+	if err := h.schedules.Delete(c.Request.Context(), c.Param("id"), c.Param("scheduleId")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Runs handles GET /devices/:id/schedules/:scheduleId/runs?limit=
+func (h *CommandScheduleHandler) Runs(c *gin.Context) {
+	limit := int64(defaultRunLimit)
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 || n > maxRunLimit {
+			respondError(c, http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 200.")
+			return
+		}
+		limit = n
+	}
+	runs, err := h.schedules.Runs(c.Request.Context(), c.Param("id"), c.Param("scheduleId"), limit)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": runs})
+}
@@ -0,0 +1,98 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_view.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains how commands are shown to API clients: legacy statuses and issuers.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+// Clients opt in to the sent status with this header, or the query
+// parameter for those that cannot set headers such as EventSource. Others
+// still see sent commands as pending until the mapping is removed.
+const (
+	commandStatusHeader  = "X-Command-Status-Version"
+	commandStatusParam   = "command_status_version"
+	commandStatusVersion = "2"
+)
+
+// legacyStatuses reports whether the client expects the statuses from
+// before models.CommandSent.
+func legacyStatuses(c *gin.Context) bool {
+	return c.GetHeader(commandStatusHeader) != commandStatusVersion && c.Query(commandStatusParam) != commandStatusVersion
+}
+
+// issuerHidden reports whether the client may not see who issued cmd: only
+// the device owner sees every issuer, other users only themselves.
+func issuerHidden(c *gin.Context, cmd *models.Command) bool {
+	return cmd.IssuedBy != "" && cmd.IssuedBy != middleware.UserID(c) && !middleware.OwnsDevice(c)
+}
+
+// commandView returns cmd as the client may see it.
+func commandView(c *gin.Context, cmd *models.Command) *models.Command {
+	if cmd == nil {
+		return nil
+	}
+	legacy := legacyStatuses(c) && cmd.Status != cmd.Status.Legacy()
+	hidden := issuerHidden(c, cmd)
+	if !legacy && !hidden {
+		return cmd
+	}
+	v := *cmd
+	showCommand(&v, legacy, hidden)
+	return &v
+}
+
+// commandViews maps cmds in place as the client may see them.
+func commandViews(c *gin.Context, cmds []models.Command) []models.Command {
+	legacy := legacyStatuses(c)
+	for i := range cmds {
+		showCommand(&cmds[i], legacy, issuerHidden(c, &cmds[i]))
+	}
+	return cmds
+}
+
+func showCommand(cmd *models.Command, legacy, hidden bool) {
+	if legacy {
+		cmd.Status = cmd.Status.Legacy()
+	}
+	if hidden {
+		cmd.IssuedBy = ""
+	}
+}
+
+// batchView maps the commands and counts of summary in place as the
+// client may see them.
+func batchView(c *gin.Context, summary *service.BatchSummary) *service.BatchSummary {
+	commandViews(c, summary.Commands)
+	if !legacyStatuses(c) {
+		return summary
+	}
+	summary.Counts[models.CommandPending] += summary.Counts[models.CommandSent]
+	delete(summary.Counts, models.CommandSent)
+	return summary
+}
+
+// statusFilter parses the status filter of a command list. For legacy
+// clients pending also matches sent, as it did before sent existed.
+func statusFilter(c *gin.Context, status models.CommandStatus) []models.CommandStatus {
+	if status == "" {
+		return nil
+	}
+	if status == models.CommandPending && legacyStatuses(c) {
+		return []models.CommandStatus{models.CommandPending, models.CommandSent}
+	}
+	return []models.CommandStatus{status}
+}
@@ -0,0 +1,71 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_view_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of mapping command statuses for legacy clients.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/models"
+)
+
+func statusContext(header, query string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/commands"+query, nil)
+	if header != "" {
+		c.Request.Header.Set(commandStatusHeader, header)
+	}
+	return c
+}
+
+func TestCommandStatusMapping(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		query      string
+		status     models.CommandStatus
+		wantShown  models.CommandStatus
+		filter     models.CommandStatus
+		wantFilter []models.CommandStatus
+	}{
+		{"legacy client sees sent as pending", "", "", models.CommandSent, models.CommandPending,
+			models.CommandPending, []models.CommandStatus{models.CommandPending, models.CommandSent}},
+		{"legacy client sees final statuses", "", "", models.CommandSuccess, models.CommandSuccess,
+			models.CommandSuccess, []models.CommandStatus{models.CommandSuccess}},
+		{"header opts in", "2", "", models.CommandSent, models.CommandSent,
+			models.CommandPending, []models.CommandStatus{models.CommandPending}},
+		{"query opts in", "", "?command_status_version=2", models.CommandSent, models.CommandSent,
+			models.CommandSent, []models.CommandStatus{models.CommandSent}},
+		{"unknown version is legacy", "3", "", models.CommandSent, models.CommandPending,
+			models.CommandPending, []models.CommandStatus{models.CommandPending, models.CommandSent}},
+		{"no filter", "2", "", models.CommandQueued, models.CommandQueued, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := statusContext(tt.header, tt.query)
+			cmd := &models.Command{CommandID: "c1", Status: tt.status}
+			if got := commandView(c, cmd).Status; got != tt.wantShown {
+				t.Errorf("shown status = %q, want %q", got, tt.wantShown)
+			}
+			if cmd.Status != tt.status {
+				t.Errorf("commandView changed the command to %q", cmd.Status)
+			}
+			if got := statusFilter(c, tt.filter); !slices.Equal(got, tt.wantFilter) {
+				t.Errorf("filter = %v, want %v", got, tt.wantFilter)
+			}
+		})
+	}
+}
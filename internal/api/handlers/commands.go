@@ -0,0 +1,372 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: commands.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers for device commands in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+	"airsense-be.com/internal/utils"
+)
+
+type CommandHandler struct {
+	commands *service.CommandService
+	devices  *service.DeviceService
+	users    repository.UserRepository
+}
+
+func NewCommandHandler(commands *service.CommandService, devices *service.DeviceService, users repository.UserRepository) *CommandHandler {
+	return &CommandHandler{commands: commands, devices: devices, users: users}
+}
+
+// Docs implements openapi.Documented.
+func (h *CommandHandler) Docs() openapi.Docs {
+	created := "A command that could not be published is answered with 502 COMMAND_NOT_PUBLISHED and the stored command; " +
+		"a repeated Idempotency-Key with 200 and the original command."
+	idempotencyKey := openapi.Param{Name: "Idempotency-Key", In: "header", Description: "Makes retries of the request create one command."}
+	return openapi.Docs{
+		"List": {
+			Summary:     "List the commands of a device",
+			Description: "Newest first. metadata.<key>=<value> parameters keep the commands with that metadata.",
+			Params: append([]openapi.Param{
+				{Name: "status"},
+				{Name: "action"},
+				{Name: "issued_by", Description: "Only the device owner may name another user."},
+				{Name: "limit", Type: "integer", Description: "1 to 200, 50 by default."},
+				{Name: "cursor", Description: "next_cursor of the previous page."},
+			}, timeRangeParams...),
+			Response: struct {
+				Data       []models.Command `json:"data"`
+				NextCursor *string          `json:"next_cursor"`
+			}{},
+		},
+		"Create": {
+			Summary:     "Send a command to a device",
+			Description: created,
+			Params:      []openapi.Param{idempotencyKey},
+			Body:        createCommandRequest{},
+			Status:      http.StatusCreated,
+			Response:    models.Command{},
+		},
+		"Send": {
+			Summary:     "Send a command to a device named in the body",
+			Description: "Only the device owner may use it. " + created,
+			Params:      []openapi.Param{idempotencyKey},
+			Body:        sendCommandRequest{},
+			Status:      http.StatusCreated,
+			Response:    models.Command{},
+		},
+		"UpdateFirmware": {
+			Summary:     "Send a firmware update to a device",
+			Description: "Refused with 409 OTA_IN_PROGRESS while another update of the device is unfinished.",
+			Body:        updateFirmwareRequest{},
+			Status:      http.StatusCreated,
+			Response:    models.Command{},
+		},
+		"Get":          {Summary: "Get a command", Response: models.Command{}},
+		"Cancel":       {Summary: "Cancel a command", Response: models.Command{}},
+		"UpdateStatus": {Summary: "Report the outcome of a command", Body: updateCommandStatusRequest{}, Response: models.Command{}},
+	}
+}
+
+const (
+	defaultCommandLimit = 50
+	maxCommandLimit     = 200
+)
+
+// List handles GET /devices/:id/commands?action=&status=&issued_by=&from=&to=&limit=&cursor=
+func (h *CommandHandler) List(c *gin.Context) {
+	status := models.CommandStatus(c.Query("status"))
+	if status != "" && !status.Valid() {
+		respondError(c, http.StatusBadRequest, "INVALID_STATUS", "status must be one of queued, pending, sent, success, error, timeout, cancelled.")
+		return
+	}
+	filter := repository.CommandFilter{
+		DeviceID: c.Param("id"),
+		Action:   c.Query("action"),
+		IssuedBy: c.Query("issued_by"),
+		Statuses: statusFilter(c, status),
+		Limit:    defaultCommandLimit,
+	}
+	if filter.IssuedBy != "" && filter.IssuedBy != middleware.UserID(c) && !middleware.OwnsDevice(c) {
+		respondError(c, http.StatusForbidden, "FORBIDDEN", "Only the device owner may filter by another issuer.")
+		return
+	}
+	metadata, ok := metadataFilter(c)
+	if !ok {
+		return
+	}
+	filter.Metadata = metadata
+	var err error
+	if filter.From, filter.To, err = parseTimeRange(c); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", err.Error())
+		return
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 || n > maxCommandLimit {
+			respondError(c, http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 200.")
+			return
+		}
+		filter.Limit = n
+	}
+
+	cmds, next, err := h.commands.History(c.Request.Context(), filter, c.Query("cursor"))
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		respondError(c, http.StatusBadRequest, "INVALID_CURSOR", "cursor is not valid.")
+		return
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": commandViews(c, cmds), "next_cursor": next})
+}
+
+// metadataFilter parses the metadata.<key>=<value> query parameters of a
+// command list. On failure the response is written and false is returned.
+func metadataFilter(c *gin.Context) (map[string]string, bool) {
+	var metadata map[string]string
+	for param, values := range c.Request.URL.Query() {
+		key, ok := strings.CutPrefix(param, "metadata.")
+		if !ok {
+			continue
+		}
+		if !models.ValidMetadataKey(key) {
+			respondError(c, http.StatusBadRequest, "INVALID_METADATA_FILTER", "metadata keys must be 1-64 letters, digits or _.")
+			return nil, false
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[key] = values[0]
+	}
+	return metadata, true
+}
+
+type createCommandRequest struct {
+	Action string         `json:"action" binding:"required"`
+	Params map[string]any `json:"params"`
+	// TTLSeconds overrides COMMAND_DEFAULT_TTL.
+	TTLSeconds int                  `json:"ttl_seconds"`
+	Retry      *commandRetryRequest `json:"retry"`
+	Priority   string               `json:"priority"`
+	// Raw sends an action that is not registered; admins only.
+	Raw bool `json:"raw"`
+	// Metadata is stored with the command and returned with it.
+	Metadata map[string]string `json:"metadata"`
+}
+
+type commandRetryRequest struct {
+	MaxAttempts    int `json:"max_attempts"`
+	BackoffSeconds int `json:"backoff_seconds"`
+}
+
+// Create handles POST /devices/:id/commands. A command that could not be
+// published is still stored: with a retry policy it stays pending with
+// nextAttemptAt set, otherwise it has status error and is returned with 502.
+// A request repeating an Idempotency-Key gets the original command with 200
+// and Idempotent-Replayed: true. Params violating the action's spec are
+// rejected with 400 listing every violation.
+func (h *CommandHandler) Create(c *gin.Context) {
+	var req createCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "action is required.")
+		return
+	}
+	h.create(c, c.Param("id"), req, time.Duration(req.TTLSeconds)*time.Second)
+}
+
+type sendCommandRequest struct {
+	createCommandRequest
+	DeviceID  string     `json:"device_id" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Send handles POST /commands, which names the device in the body. Only
+// the owner of the device may use it; otherwise it behaves like Create,
+// with expires_at instead of ttl_seconds.
+func (h *CommandHandler) Send(c *gin.Context) {
+	var req sendCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "device_id and action are required and expires_at must be RFC 3339.")
+		return
+	}
+	if req.TTLSeconds != 0 {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "Use expires_at instead of ttl_seconds.")
+		return
+	}
+	var ttl time.Duration
+	if req.ExpiresAt != nil {
+		if ttl = time.Until(*req.ExpiresAt); ttl <= 0 {
+			respondError(c, http.StatusBadRequest, "INVALID_COMMAND", "expires_at must be in the future.")
+			return
+		}
+	}
+	if _, err := h.devices.Authorize(c.Request.Context(), req.DeviceID, middleware.UserID(c), service.AccessOwner, false); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	h.create(c, req.DeviceID, req.createCommandRequest, ttl)
+}
+
+func (h *CommandHandler) create(c *gin.Context, deviceID string, req createCommandRequest, ttl time.Duration) {
+	if req.Raw {
+		admin, err := middleware.IsAdmin(c, h.users)
+		if err != nil {
+			respondServiceError(c, err)
+			return
+		}
+		if !admin {
+			respondError(c, http.StatusForbidden, "FORBIDDEN", "Raw commands require the admin role.")
+			return
+		}
+	}
+
+	cr := service.CommandRequest{
+		DeviceID: deviceID,
+		Action:   req.Action,
+		Params:   req.Params,
+		TTL:      ttl,
+		Priority: models.CommandPriority(req.Priority),
+		Metadata: req.Metadata,
+
+		UserID:         middleware.UserID(c),
+		IdempotencyKey: c.GetHeader("Idempotency-Key"),
+		Raw:            req.Raw,
+	}
+	if req.Retry != nil {
+		cr.Retry = &models.CommandRetry{MaxAttempts: req.Retry.MaxAttempts, BackoffSeconds: req.Retry.BackoffSeconds}
+	}
+	cmd, err := h.commands.Create(c.Request.Context(), cr)
+	respondCreatedCommand(c, cmd, err)
+}
+
+// respondCreatedCommand writes the response to a command created by
+// CommandService.Create or a helper built on it.
+func respondCreatedCommand(c *gin.Context, cmd *models.Command, err error) {
+	switch {
+	case err == nil:
+		c.JSON(http.StatusCreated, commandView(c, cmd))
+	case errors.Is(err, service.ErrCommandReplayed):
+		c.Header("Idempotent-Replayed", "true")
+		c.JSON(http.StatusOK, commandView(c, cmd))
+	case errors.Is(err, service.ErrCommandNotPublished):
+		c.JSON(http.StatusBadGateway, gin.H{
+			"code":    "COMMAND_NOT_PUBLISHED",
+			"message": "The command was stored with status error because it could not be sent to the device.",
+			"command": commandView(c, cmd),
+		})
+	default:
+		respondServiceError(c, err)
+	}
+}
+
+type updateFirmwareRequest struct {
+	Version string `json:"version" binding:"required"`
+	URL     string `json:"url" binding:"required"`
+	SHA256  string `json:"sha256" binding:"required"`
+}
+
+// UpdateFirmware handles POST /devices/:id/firmware, which sends the device
+// an ota_update command answered like Create. It is refused with 409 while
+// another ota_update of the device is unfinished.
+func (h *CommandHandler) UpdateFirmware(c *gin.Context) {
+	var req updateFirmwareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "version, url and sha256 are required.")
+		return
+	}
+	cmd, err := h.commands.PublishFirmwareUpdate(c.Request.Context(), middleware.UserID(c), c.Param("id"),
+		req.Version, req.URL, req.SHA256)
+	respondCreatedCommand(c, cmd, err)
+}
+
+// Get handles GET /devices/:id/commands/:commandId.
+func (h *CommandHandler) Get(c *gin.Context) {
+	cmd, err := h.commands.Get(c.Request.Context(), c.Param("id"), c.Param("commandId"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, commandView(c, cmd))
+}
+
+// Cancel handles POST /commands/:commandId/cancel for users who may
+// control the device of the command. A command that is already final is
+// returned with 409 and its current status.
+func (h *CommandHandler) Cancel(c *gin.Context) {
+	cmd, err := h.commands.Lookup(c.Request.Context(), c.Param("commandId"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if _, err := h.devices.Authorize(c.Request.Context(), cmd.DeviceID, middleware.UserID(c), service.AccessControl, false); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	cmd, err = h.commands.Cancel(c.Request.Context(), cmd.DeviceID, cmd.CommandID)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, commandView(c, cmd))
+	case errors.Is(err, service.ErrCommandTerminal):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"code":    "COMMAND_TERMINAL",
+			"message": fmt.Sprintf("Command is already %s.", cmd.Status),
+			"status":  cmd.Status,
+		})
+	default:
+		respondServiceError(c, err)
+	}
+}
+
+type updateCommandStatusRequest struct {
+	Status models.CommandStatus `json:"status" binding:"required"`
+	Result map[string]any       `json:"result"`
+	Error  string               `json:"error"`
+}
+
+// UpdateStatus handles POST /devices/:id/commands/:commandId/status, called
+// by the device firmware once it has executed (or failed) a command.
+func (h *CommandHandler) UpdateStatus(c *gin.Context) {
+	var req updateCommandStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "status is required.")
+		return
+	}
+
+	cmd, err := h.commands.UpdateStatus(c.Request.Context(), c.Param("id"), c.Param("commandId"),
+		service.CommandAck{Status: req.Status, Result: req.Result, Error: req.Error})
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, commandView(c, cmd))
+	case errors.Is(err, service.ErrCommandExpired):
+		respondError(c, http.StatusConflict, "COMMAND_EXPIRED",
+			fmt.Sprintf("Command expired at %s and was marked %s.", cmd.ExpiresAt.Format(time.RFC3339), cmd.Status))
+	case errors.Is(err, service.ErrCommandTerminal):
+		respondError(c, http.StatusConflict, "COMMAND_TERMINAL",
+			fmt.Sprintf("Command is already %s.", cmd.Status))
+	default:
+		respondServiceError(c, err)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: commands_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the REST handlers of device commands.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCommandMetadataFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		want     map[string]string
+		wantCode string
+	}{
+		{"none", "?action=reboot", nil, ""},
+		{"one key", "?metadata.ticket=OPS-42", map[string]string{"ticket": "OPS-42"}, ""},
+		{"several keys", "?metadata.ticket=OPS-42&metadata.team=ops&status=success",
+			map[string]string{"ticket": "OPS-42", "team": "ops"}, ""},
+		{"first of repeated values", "?metadata.ticket=a&metadata.ticket=b", map[string]string{"ticket": "a"}, ""},
+		{"empty value", "?metadata.ticket=", map[string]string{"ticket": ""}, ""},
+		{"escaped value", "?metadata.note=a%26b%3Dc", map[string]string{"note": "a&b=c"}, ""},
+		{"nested path", "?metadata.a.b=c", nil, "INVALID_METADATA_FILTER"},
+		{"operator", "?metadata.$ne=x", nil, "INVALID_METADATA_FILTER"},
+		{"empty key", "?metadata.=x", nil, "INVALID_METADATA_FILTER"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/commands"+tt.query, nil)
+			got, ok := metadataFilter(c)
+			if tt.wantCode != "" {
+				var body struct{ Code string }
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatal(err)
+				}
+				if ok || w.Code != http.StatusBadRequest || body.Code != tt.wantCode {
+					t.Errorf("ok = %v, status = %d, code = %q, want %d %s", ok, w.Code, body.Code, http.StatusBadRequest, tt.wantCode)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("filter refused: %s", w.Body)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("filter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: dashboard.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handler of the dashboard summary.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/service"
+)
+
+type DashboardHandler struct {
+	dashboard *service.DashboardService
+}
+
+func NewDashboardHandler(dashboard *service.DashboardService) *DashboardHandler {
+	return &DashboardHandler{dashboard: dashboard}
+}
+
+// Docs implements openapi.Documented.
+func (h *DashboardHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Get":    {Summary: "Summary of your devices", Response: dataResponse[[]service.DashboardEntry]{}},
+		"Latest": {Summary: "Latest reading and status of your devices, by device ID", Response: dataResponse[map[string]service.FleetReading]{}},
+		"Compare": {
+			Summary: "Compare the aggregates of up to ten devices",
+			Params: []openapi.Param{
+				{Name: "devices", Required: true, Description: "Comma-separated device IDs."},
+				{Name: "from", Required: true, Description: "Start of the time range, RFC 3339."},
+				{Name: "to", Required: true, Description: "End of the time range, RFC 3339."},
+				{Name: "bucket", Description: "hour, day (default), week or month."},
+				{Name: "tz", Description: "IANA timezone of the buckets."},
+			},
+			Response: dataResponse[*service.Comparison]{},
+		},
+	}
+}
+
+// Get handles GET /dashboard.
+func (h *DashboardHandler) Get(c *gin.Context) {
+	entries, err := h.dashboard.Summary(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// Latest handles GET /telemetry/latest: the latest reading and status of
+// every device of the caller, by device ID.
+func (h *DashboardHandler) Latest(c *gin.Context) {
+	latest, err := h.dashboard.Latest(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": latest})
+}
+
+// Compare handles GET /telemetry/compare?devices=a,b&from=&to=&bucket=&tz=:
+// the aggregates of up to ten devices on one shared axis of buckets.
+func (h *DashboardHandler) Compare(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", err.Error())
+		return
+	}
+	if from.IsZero() || to.IsZero() {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", "from and to are required")
+		return
+	}
+
+	comparison, err := h.dashboard.Compare(c.Request.Context(), middleware.UserID(c),
+		strings.Split(c.Query("devices"), ","), from, to, c.DefaultQuery("bucket", "day"), c.Query("tz"))
+	if errors.Is(err, service.ErrInvalidInterval) {
+		respondError(c, http.StatusBadRequest, "INVALID_INTERVAL", "bucket must be one of hour, day, week, month.")
+		return
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": comparison})
+}
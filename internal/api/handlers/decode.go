@@ -0,0 +1,101 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: decode.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the strict JSON body decoding of the AirSense REST API.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// decodeBody decodes a request body of at most limit bytes holding a single
+// JSON value into v. Fields v does not have are refused, so that typos are
+// not silently dropped. On failure the response is written, 413 for a body
+// over the limit and 400 naming the offending field otherwise, and false is
+// returned.
+func decodeBody(c *gin.Context, limit int64, v any) bool {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil && dec.More() {
+		err = errors.New("body must contain a single JSON value")
+	}
+	if err == nil {
+		return true
+	}
+
+	var (
+		tooLarge  *http.MaxBytesError
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &tooLarge):
+		respondError(c, http.StatusRequestEntityTooLarge, "BODY_TOO_LARGE",
+			fmt.Sprintf("Body must not exceed %d bytes.", tooLarge.Limit))
+	case errors.Is(err, io.EOF):
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "Body must not be empty.")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "Body is truncated JSON.")
+	case errors.As(err, &syntaxErr):
+		respondError(c, http.StatusBadRequest, "INVALID_BODY",
+			fmt.Sprintf("Body is not valid JSON at byte %d.", syntaxErr.Offset))
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"code":    "INVALID_FIELD",
+			"message": fmt.Sprintf("%s must be %s, not %s.", field, jsonKind(typeErr.Type), typeErr.Value),
+			"field":   field,
+		})
+	default:
+		// DisallowUnknownFields has no error type of its own; its message
+		// is `json: unknown field "name"`.
+		var name string
+		if _, scanErr := fmt.Sscanf(err.Error(), "json: unknown field %q", &name); scanErr == nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"code":    "UNKNOWN_FIELD",
+				"message": fmt.Sprintf("Unknown field %q.", name),
+				"field":   name,
+			})
+			return false
+		}
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", err.Error())
+	}
+	return false
+}
+
+// jsonKind names the JSON value expected for t.
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
@@ -0,0 +1,74 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_keys.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers for device API keys in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type DeviceKeyHandler struct {
+	keys *service.DeviceKeyService
+}
+
+func NewDeviceKeyHandler(keys *service.DeviceKeyService) *DeviceKeyHandler {
+	return &DeviceKeyHandler{keys: keys}
+}
+
+// Docs implements openapi.Documented.
+func (h *DeviceKeyHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Mint": {
+			Summary:     "Mint a device API key",
+			Description: "The plaintext key is only returned by this call.",
+			Status:      http.StatusCreated,
+			Response:    service.MintedKey{},
+		},
+		"List":   {Summary: "List the API keys of a device", Response: dataResponse[[]models.DeviceCredential]{}},
+		"Revoke": {Summary: "Revoke a device API key", Status: http.StatusNoContent},
+	}
+}
+
+// Mint handles POST /devices/:id/keys. The plaintext key is only ever
+// returned by this call.
+func (h *DeviceKeyHandler) Mint(c *gin.Context) {
+	key, err := h.keys.Mint(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+// List handles GET /devices/:id/keys.
+func (h *DeviceKeyHandler) List(c *gin.Context) {
+	keys, err := h.keys.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// Revoke handles DELETE /devices/:id/keys/:keyId.
+func (h *DeviceKeyHandler) Revoke(c *gin.Context) {
+	if err := h.keys.Revoke(c.Request.Context(), c.Param("id"), c.Param("keyId")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
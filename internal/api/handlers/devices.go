@@ -0,0 +1,363 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: devices.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers for devices in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+	"airsense-be.com/internal/utils"
+)
+
+const (
+	defaultDeviceLimit = 50
+	maxDeviceLimit     = 200
+)
+
+type DeviceHandler struct {
+	devices       *service.DeviceService
+	credentials   *service.MQTTCredentialService
+	decommissions *service.DecommissionService
+	users         repository.UserRepository
+}
+
+func NewDeviceHandler(devices *service.DeviceService, credentials *service.MQTTCredentialService,
+	decommissions *service.DecommissionService, users repository.UserRepository) *DeviceHandler {
+	return &DeviceHandler{devices: devices, credentials: credentials, decommissions: decommissions, users: users}
+}
+
+// Docs implements openapi.Documented.
+func (h *DeviceHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"List": {Summary: "List your devices", Params: deviceListParams, Response: service.DevicePage{}},
+		"Register": {
+			Summary:     "Register a device",
+			Description: "Answers 200 instead of 201 when it restores a device the caller deleted. The MQTT credentials are only returned by this call.",
+			Body:        registerDeviceRequest{},
+			Status:      http.StatusCreated,
+			Response:    registeredDevice{},
+		},
+		"Patch": {
+			Summary:     "Update a device",
+			Description: "Only the fields present in the body are changed.",
+			Body:        service.DevicePatch{},
+			Response:    models.Device{},
+		},
+		"SetTag":       {Summary: "Set a tag of a device", Body: setTagRequest{}, Response: models.Device{}},
+		"RemoveTag":    {Summary: "Remove a tag of a device", Response: models.Device{}},
+		"Delete":       {Summary: "Delete a device, keeping its history", Status: http.StatusNoContent},
+		"Decommission": {Summary: "Retire a device, keeping it on record", Response: service.Decommission{}},
+		"Purge": {
+			Summary: "Remove a deleted device and its readings for good",
+			Response: struct {
+				DeletedReadings int64 `json:"deleted_readings"`
+			}{},
+		},
+		"Import": {
+			Summary: "Register devices from a CSV file",
+			Description: "The CSV, with the header name,location[,user_id], is the body or the file field of a multipart form. " +
+				"Only admins may set user_id to create the device for another user.",
+			BodyType: "text/csv",
+			Response: struct {
+				Imported int                    `json:"imported"`
+				Failed   int                    `json:"failed"`
+				Results  []service.ImportResult `json:"results"`
+			}{},
+		},
+	}
+}
+
+// deviceListParams documents the parameters read by deviceQuery.
+var deviceListParams = []openapi.Param{
+	{Name: "name"},
+	{Name: "location"},
+	{Name: "status", Description: "online or offline."},
+	{Name: "firmware"},
+	{Name: "tag", Repeated: true, Description: "key:value; devices must have every tag."},
+	{Name: "near", Description: "longitude,latitude; devices with coordinates within radius_km of it."},
+	{Name: "radius_km", Type: "number", Description: "Radius of near, up to 20000."},
+	{Name: "sort", Description: "name, created_at or last_seen, prefixed with - for descending."},
+	{Name: "cursor", Description: "next_cursor of the previous page."},
+	{Name: "limit", Type: "integer", Description: "1 to 200, 50 by default."},
+	{Name: "total", Type: "boolean", Description: "Add the number of matching devices."},
+	{Name: "include_decommissioned", Type: "boolean"},
+}
+
+// registeredDevice carries the MQTT credentials issued at provisioning; they
+// are not retrievable afterwards, only rotated.
+type registeredDevice struct {
+	*models.Device
+	MQTTCredentials *service.MQTTCredentials `json:"mqtt_credentials"`
+}
+
+// List handles GET /devices?name=&location=&status=&firmware=&tag=key:value&near=lng,lat&radius_km=&sort=&cursor=&limit=&total=&include_decommissioned=
+// The tag parameter may be repeated; total=true adds the number of matching
+// devices, which costs an extra count.
+func (h *DeviceHandler) List(c *gin.Context) {
+	q, ok := deviceQuery(c)
+	if !ok {
+		return
+	}
+	page, err := h.devices.List(c.Request.Context(), middleware.UserID(c), q)
+	respondDevicePage(c, page, err)
+}
+
+// deviceQuery reads the query parameters of a device list, responding
+// with 400 and returning false if they are invalid.
+func deviceQuery(c *gin.Context) (service.DeviceQuery, bool) {
+	limit := int64(defaultDeviceLimit)
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 || n > maxDeviceLimit {
+			respondError(c, http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 200.")
+			return service.DeviceQuery{}, false
+		}
+		limit = n
+	}
+
+	q := service.DeviceQuery{
+		Name:      c.Query("name"),
+		Location:  c.Query("location"),
+		Status:    models.DeviceStatus(c.Query("status")),
+		Firmware:  c.Query("firmware"),
+		Sort:      c.Query("sort"),
+		Cursor:    c.Query("cursor"),
+		Limit:     limit,
+		WithTotal: c.Query("total") == "true",
+
+		IncludeDecommissioned: c.Query("include_decommissioned") == "true",
+	}
+	for _, t := range c.QueryArray("tag") {
+		k, v, ok := strings.Cut(t, ":")
+		if !ok {
+			respondError(c, http.StatusBadRequest, "INVALID_QUERY", "tag must be key:value.")
+			return service.DeviceQuery{}, false
+		}
+		if q.Tags == nil {
+			q.Tags = make(map[string]string)
+		}
+		q.Tags[k] = v
+	}
+	if near, radius := c.Query("near"), c.Query("radius_km"); near != "" || radius != "" {
+		p, ok := parseNear(near)
+		r, err := strconv.ParseFloat(radius, 64)
+		if !ok || err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_QUERY", "near must be longitude,latitude and go with radius_km.")
+			return service.DeviceQuery{}, false
+		}
+		q.Near, q.RadiusKm = p, r
+	}
+	return q, true
+}
+
+// parseNear reads a longitude,latitude pair; the service checks the range.
+func parseNear(s string) (*models.GeoPoint, bool) {
+	lng, lat, ok := strings.Cut(s, ",")
+	if !ok {
+		return nil, false
+	}
+	x, err := strconv.ParseFloat(strings.TrimSpace(lng), 64)
+	if err != nil {
+		return nil, false
+	}
+	y, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil {
+		return nil, false
+	}
+	return models.NewGeoPoint(x, y), true
+}
+
+// respondDevicePage writes the response to a device list.
+func respondDevicePage(c *gin.Context, page *service.DevicePage, err error) {
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		respondError(c, http.StatusBadRequest, "INVALID_CURSOR", "cursor is not valid.")
+		return
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+type registerDeviceRequest struct {
+	DeviceID string `json:"deviceID" binding:"required"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+}
+
+// Register handles POST /devices. Re-registering a device the caller deleted
+// restores it and answers 200 instead of 201.
+func (h *DeviceHandler) Register(c *gin.Context) {
+	var req registerDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "deviceID is required.")
+		return
+	}
+	d, restored, err := h.devices.Register(c.Request.Context(), middleware.UserID(c), req.DeviceID, req.Name, req.Location)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	creds, err := h.credentials.Issue(c.Request.Context(), d.ID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if restored {
+		c.JSON(http.StatusOK, registeredDevice{d, creds})
+		return
+	}
+	c.JSON(http.StatusCreated, registeredDevice{d, creds})
+}
+
+// Patch handles PATCH /devices/:id. Only the fields present in the body are
+// updated; the names of the top-level keys form the field mask.
+func (h *DeviceHandler) Patch(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	var present map[string]json.RawMessage
+	var patch service.DevicePatch
+	if json.Unmarshal(body, &present) != nil || json.Unmarshal(body, &patch) != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "Body must be a JSON object of device fields.")
+		return
+	}
+	mask := make([]string, 0, len(present))
+	for f := range present {
+		mask = append(mask, f)
+	}
+	d, err := h.devices.Patch(c.Request.Context(), middleware.Device(c), mask, patch)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+type setTagRequest struct {
+	Value string `json:"value" binding:"required"`
+}
+
+// SetTag handles PUT /devices/:id/tags/:key with {"value": ...}, adding or
+// changing one tag without replacing the others.
+func (h *DeviceHandler) SetTag(c *gin.Context) {
+	var req setTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "Body must be {\"value\": string}.")
+		return
+	}
+	d, err := h.devices.SetTag(c.Request.Context(), middleware.Device(c), c.Param("key"), req.Value)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// RemoveTag handles DELETE /devices/:id/tags/:key.
+func (h *DeviceHandler) RemoveTag(c *gin.Context) {
+	d, err := h.devices.RemoveTag(c.Request.Context(), middleware.Device(c), c.Param("key"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// Delete handles DELETE /devices/:id. The device is soft-deleted: it leaves
+// the listings and stops accepting data, but its history is kept.
+func (h *DeviceHandler) Delete(c *gin.Context) {
+	if err := h.devices.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Decommission handles POST /devices/:id/decommission, retiring the device
+// while keeping it, and its archived readings, on record.
+func (h *DeviceHandler) Decommission(c *gin.Context) {
+	out, err := h.decommissions.Decommission(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// Purge handles DELETE /devices/:id/purge, permanently removing a
+// soft-deleted device and all of its readings.
+func (h *DeviceHandler) Purge(c *gin.Context) {
+	deleted, err := h.devices.Purge(c.Request.Context(), c.Param("id"), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted_readings": deleted})
+}
+
+// Import handles POST /devices/import with a CSV file, either as the raw
+// request body or as the "file" field of a multipart form.
+func (h *DeviceHandler) Import(c *gin.Context) {
+	body := c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_BODY", "Multipart upload must contain a file field.")
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			respondServiceError(c, err)
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	admin, err := middleware.IsAdmin(c, h.users)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	results, err := h.devices.Import(c.Request.Context(), middleware.UserID(c), admin, body)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	imported := 0
+	for i, r := range results {
+		if r.Error != "" {
+			continue
+		}
+		imported++
+		if results[i].MQTTCredentials, err = h.credentials.Issue(c.Request.Context(), r.DeviceID); err != nil {
+			respondServiceError(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"imported": imported, "failed": len(results) - imported, "results": results})
+}
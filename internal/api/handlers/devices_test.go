@@ -0,0 +1,137 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: devices_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the device REST handlers.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+)
+
+// listedDevices returns up to the limit of its devices, ignoring the rest
+// of the filter.
+type listedDevices struct {
+	repository.DeviceRepository
+	devices []models.Device
+}
+
+func (r *listedDevices) List(_ context.Context, filter repository.DeviceFilter) ([]models.Device, error) {
+	out := []models.Device{}
+	for _, d := range r.devices {
+		if filter.Limit > 0 && int64(len(out)) == filter.Limit {
+			break
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// unshared shares no device.
+type unshared struct {
+	repository.ShareRepository
+}
+
+func (unshared) ListByGrantee(context.Context, string) ([]models.DeviceShare, error) {
+	return nil, nil
+}
+
+// listDevices serves GET /devices with query and returns the status and
+// the raw body.
+func listDevices(t *testing.T, repo repository.DeviceRepository, query string) (int, map[string]json.RawMessage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := NewDeviceHandler(service.NewDeviceService(repo, unshared{}, nil, nil, nil, nil), nil, nil, nil)
+	r := gin.New()
+	r.GET("/devices", h.List)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/devices?"+query, nil))
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	return w.Code, body
+}
+
+func TestDeviceListNextCursor(t *testing.T) {
+	devices := []models.Device{{ID: "d1"}, {ID: "d2"}, {ID: "d3"}}
+	tests := []struct {
+		name     string
+		limit    string
+		wantNext bool
+	}{
+		{"more pages", "2", true},
+		{"last page", "3", false},
+		{"past the end", "10", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := listDevices(t, &listedDevices{devices: devices}, "limit="+tt.limit)
+			if code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", code)
+			}
+			next, ok := body["next_cursor"]
+			if !ok {
+				t.Fatal("no next_cursor in the response")
+			}
+			if got := string(next) != "null"; got != tt.wantNext {
+				t.Errorf("next_cursor = %s, want a cursor: %v", next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestDeviceListInvalidCursor(t *testing.T) {
+	for _, cursor := range []string{"!!!", "ZDE", "e30"} {
+		t.Run(cursor, func(t *testing.T) {
+			code, body := listDevices(t, &listedDevices{}, "cursor="+cursor)
+			if code != http.StatusBadRequest || string(body["code"]) != `"INVALID_CURSOR"` {
+				t.Errorf("got %d %s, want 400 INVALID_CURSOR", code, body["code"])
+			}
+		})
+	}
+}
+
+func TestDeviceListNear(t *testing.T) {
+	tests := []struct {
+		query    string
+		wantCode int
+	}{
+		{"near=105.85,21.03&radius_km=5", http.StatusOK},
+		{"near=105.85,%2021.03&radius_km=0.5", http.StatusOK},
+		{"near=105.85,21.03", http.StatusBadRequest},
+		{"radius_km=5", http.StatusBadRequest},
+		{"near=105.85&radius_km=5", http.StatusBadRequest},
+		{"near=east,north&radius_km=5", http.StatusBadRequest},
+		{"near=105.85,21.03&radius_km=far", http.StatusBadRequest},
+		{"near=185,21.03&radius_km=5", http.StatusBadRequest},
+		{"near=105.85,21.03&radius_km=-1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			code, body := listDevices(t, &listedDevices{}, tt.query)
+			if code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", code, tt.wantCode)
+			}
+			if code == http.StatusBadRequest && string(body["code"]) != `"INVALID_QUERY"` {
+				t.Errorf("code = %s, want INVALID_QUERY", body["code"])
+			}
+		})
+	}
+}
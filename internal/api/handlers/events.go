@@ -0,0 +1,219 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: events.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the server-sent events stream of device events.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/events"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+)
+
+// sseHeartbeat keeps proxies from closing an idle stream.
+const sseHeartbeat = 15 * time.Second
+
+// sensorReplayLimit bounds the readings replayed to a reconnecting sensor
+// stream; a client that was away longer should query the history.
+const sensorReplayLimit = 1000
+
+type EventHandler struct {
+	hub       *events.Hub
+	sensors   *service.SensorService
+	heartbeat time.Duration
+}
+
+func NewEventHandler(hub *events.Hub, sensors *service.SensorService) *EventHandler {
+	return &EventHandler{hub: hub, sensors: sensors, heartbeat: sseHeartbeat}
+}
+
+// Docs implements openapi.Documented.
+func (h *EventHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Stream": {
+			Summary:      "Stream the readings and command changes of a device",
+			Description:  "Server-sent sensor_data and command_status events until the client disconnects.",
+			Params:       []openapi.Param{{Name: "command_status_version", Description: "2 to see the sent status."}},
+			ResponseType: "text/event-stream",
+		},
+		"SensorStream": {
+			Summary:     "Stream the readings of a device",
+			Description: "Server-sent readings with their timestamp as id; a reconnecting client first gets the readings it missed.",
+			Params: []openapi.Param{
+				{Name: "token", Description: "Access token, for clients that cannot send an Authorization header."},
+				{Name: "Last-Event-ID", In: "header", Description: "id of the last event received."},
+			},
+			ResponseType: "text/event-stream",
+		},
+	}
+}
+
+// Stream handles GET /devices/:id/events, a text/event-stream of the
+// device's new readings (sensor_data) and command changes (command_status)
+// until the client disconnects. Command statuses are mapped like in the
+// command endpoints; EventSource clients opt in with
+// ?command_status_version=2.
+func (h *EventHandler) Stream(c *gin.Context) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, "STREAMING_UNSUPPORTED", "Streaming is not supported.")
+		return
+	}
+	ch, unsubscribe := h.hub.Subscribe(c.Param("id"))
+	defer unsubscribe()
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Stops nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case e := <-ch:
+			if cmd, ok := e.Data.(*models.Command); ok {
+				// Events are shared between subscribers; map a copy.
+				e.Data = commandView(c, cmd)
+			}
+			payload, err := json.Marshal(e.Data)
+			if err != nil {
+				log.Printf("events: encode %s event: %v", e.Type, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, payload); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// SensorStream handles GET /devices/:id/sensors/stream, a text/event-stream
+// of the device's new readings only, for clients that prefer it to a
+// WebSocket. Each reading is sent as "id: <timestamp>" and "data: <JSON>";
+// a client reconnecting with Last-Event-ID first gets the readings it
+// missed since that timestamp, up to sensorReplayLimit. A reading at
+// exactly that timestamp may be sent twice, but none is lost.
+func (h *EventHandler) SensorStream(c *gin.Context) {
+	if accept := c.GetHeader("Accept"); accept != "" && !isEventStreamAccepted(accept) {
+		respondError(c, http.StatusNotAcceptable, "NOT_ACCEPTABLE", "This endpoint only serves text/event-stream.")
+		return
+	}
+	var since time.Time
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339Nano, id); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_LAST_EVENT_ID", "Last-Event-ID must be the id of an event of this stream.")
+			return
+		}
+	}
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, "STREAMING_UNSUPPORTED", "Streaming is not supported.")
+		return
+	}
+	deviceID := c.Param("id")
+	// Subscribed before the replay, so nothing falls in between; readings
+	// both replayed and received live are sent once.
+	ch, unsubscribe := h.hub.Subscribe(deviceID)
+	defer unsubscribe()
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	ctx := c.Request.Context()
+	replayed := map[string]bool{}
+	if !since.IsZero() {
+		filter := repository.SensorFilter{DeviceID: deviceID, From: since, Limit: sensorReplayLimit}
+		err := h.sensors.Export(ctx, filter, func(d *models.SensorData) error {
+			replayed[d.ID] = true
+			return writeSensorEvent(w, d)
+		})
+		if err != nil {
+			log.Printf("events: replay readings of device %s since %s: %v", deviceID, since.Format(time.RFC3339Nano), err)
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ":\n\n"); err != nil {
+				return
+			}
+		case e := <-ch:
+			d, ok := e.Data.(*models.SensorData)
+			if e.Type != events.TypeSensorData || !ok || replayed[d.ID] {
+				continue
+			}
+			if err := writeSensorEvent(w, d); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeSensorEvent writes d as one event, identified by its timestamp for
+// Last-Event-ID.
+func writeSensorEvent(w io.Writer, d *models.SensorData) error {
+	payload, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\ndata: %s\n\n", d.Timestamp.UTC().Format(time.RFC3339Nano), payload)
+	return err
+}
+
+// isEventStreamAccepted reports whether an Accept header allows an event
+// stream.
+func isEventStreamAccepted(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "text/event-stream", "text/*", "*/*":
+			return true
+		}
+	}
+	return false
+}
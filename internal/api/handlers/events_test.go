@@ -0,0 +1,272 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: events_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the frames, heartbeats and replay of the sensor stream.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/events"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+)
+
+var streamStart = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+func streamReading(id string, minutes int) *models.SensorData {
+	return &models.SensorData{ID: id, DeviceID: "d1", Timestamp: streamStart.Add(time.Duration(minutes) * time.Minute),
+		Sensors: models.Sensors{PM25: models.SensorValue{Value: float64(minutes), Unit: "µg/m³"}}}
+}
+
+// storedReadings streams the readings it holds from filter.From on, in
+// order, and keeps the last filter.
+type storedReadings struct {
+	repository.SensorDataRepository
+	readings []*models.SensorData
+
+	mu     sync.Mutex
+	filter repository.SensorFilter
+}
+
+func (r *storedReadings) Stream(_ context.Context, filter repository.SensorFilter, fn func(*models.SensorData) error) error {
+	r.mu.Lock()
+	r.filter = filter
+	r.mu.Unlock()
+	for _, d := range r.readings {
+		if d.DeviceID == filter.DeviceID && !d.Timestamp.Before(filter.From) {
+			if err := fn(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sseFrame is one event of a stream, or a comment.
+type sseFrame struct {
+	id, data, comment string
+}
+
+// sseStream serves the sensor stream of h and reads its frames.
+type sseStream struct {
+	t      *testing.T
+	resp   *http.Response
+	body   *bufio.Reader
+	frames chan sseFrame
+}
+
+func openSensorStream(t *testing.T, h *EventHandler, header http.Header) *sseStream {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/devices/:id/sensors/stream", h.SensorStream)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/devices/d1/sensors/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	// Headers arrive once the handler subscribed to the hub.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	s := &sseStream{t: t, resp: resp, body: bufio.NewReader(resp.Body), frames: make(chan sseFrame, 16)}
+	if resp.StatusCode == http.StatusOK {
+		go s.read()
+	}
+	return s
+}
+
+func (s *sseStream) read() {
+	defer close(s.frames)
+	var f sseFrame
+	for {
+		line, err := s.body.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			s.frames <- f
+			f = sseFrame{}
+		case strings.HasPrefix(line, ":"):
+			f.comment = line
+		case strings.HasPrefix(line, "id: "):
+			f.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			f.data = strings.TrimPrefix(line, "data: ")
+		default:
+			s.t.Errorf("unexpected line %q", line)
+		}
+	}
+}
+
+// next returns the next frame, failing the test if none comes in time.
+func (s *sseStream) next() sseFrame {
+	s.t.Helper()
+	select {
+	case f, ok := <-s.frames:
+		if !ok {
+			s.t.Fatal("stream closed")
+		}
+		return f
+	case <-time.After(5 * time.Second):
+		s.t.Fatal("no frame")
+	}
+	return sseFrame{}
+}
+
+// expectReading checks that f is the event of d.
+func expectReading(t *testing.T, f sseFrame, d *models.SensorData) {
+	t.Helper()
+	if want := d.Timestamp.Format(time.RFC3339Nano); f.id != want {
+		t.Errorf("id = %q, want %q", f.id, want)
+	}
+	var got models.SensorData
+	if err := json.Unmarshal([]byte(f.data), &got); err != nil {
+		t.Fatalf("data %q: %v", f.data, err)
+	}
+	if got.ID != d.ID || got.Sensors.PM25.Value != d.Sensors.PM25.Value {
+		t.Errorf("data = %+v, want reading %s", got, d.ID)
+	}
+}
+
+func (r *storedReadings) lastFilter() repository.SensorFilter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.filter
+}
+
+func newStreamHandler(repo *storedReadings, heartbeat time.Duration) *EventHandler {
+	h := NewEventHandler(events.NewHub(), service.NewSensorService(repo, nil, nil, nil, nil, nil, nil, nil, nil))
+	h.heartbeat = heartbeat
+	return h
+}
+
+func TestSensorStreamFrames(t *testing.T) {
+	h := newStreamHandler(&storedReadings{}, time.Hour)
+	s := openSensorStream(t, h, http.Header{"Accept": {"text/event-stream"}})
+	if got := s.resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+	d := streamReading("r1", 1)
+	h.hub.Publish(events.Event{Type: events.TypeCommandStatus, DeviceID: "d1", Data: &models.Command{CommandID: "c1"}})
+	h.hub.Publish(events.Event{Type: events.TypeSensorData, DeviceID: "d2", Data: streamReading("other", 1)})
+	h.hub.Publish(events.Event{Type: events.TypeSensorData, DeviceID: "d1", Data: d})
+	expectReading(t, s.next(), d)
+}
+
+func TestSensorStreamHeartbeat(t *testing.T) {
+	if sseHeartbeat != 15*time.Second {
+		t.Errorf("heartbeat interval = %v, want 15s", sseHeartbeat)
+	}
+	const interval = 50 * time.Millisecond
+	h := newStreamHandler(&storedReadings{}, interval)
+	start := time.Now()
+	s := openSensorStream(t, h, nil)
+	for i := 1; i <= 3; i++ {
+		f := s.next()
+		if f.comment != ":" || f.id != "" || f.data != "" {
+			t.Fatalf("heartbeat %d = %+v, want a bare comment", i, f)
+		}
+		if elapsed := time.Since(start); elapsed < time.Duration(i)*interval {
+			t.Errorf("heartbeat %d after %v, want at least %v", i, elapsed, time.Duration(i)*interval)
+		}
+	}
+}
+
+func TestSensorStreamReplay(t *testing.T) {
+	stored := []*models.SensorData{streamReading("r0", 0), streamReading("r1", 1), streamReading("r2", 2)}
+	tests := []struct {
+		name   string
+		lastID string
+		want   []string
+	}{
+		{"from the last event", stored[1].Timestamp.Format(time.RFC3339Nano), []string{"r1", "r2"}},
+		{"between events", streamStart.Add(90 * time.Second).Format(time.RFC3339Nano), []string{"r2"}},
+		{"nothing missed", streamStart.Add(time.Hour).Format(time.RFC3339Nano), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &storedReadings{readings: stored}
+			h := newStreamHandler(repo, time.Hour)
+			s := openSensorStream(t, h, http.Header{"Last-Event-ID": {tt.lastID}})
+			byID := map[string]*models.SensorData{}
+			for _, d := range stored {
+				byID[d.ID] = d
+			}
+			for _, id := range tt.want {
+				expectReading(t, s.next(), byID[id])
+			}
+			if f := repo.lastFilter(); f.Limit != sensorReplayLimit || f.DeviceID != "d1" {
+				t.Errorf("replay filter = %+v", f)
+			}
+			// Replayed readings arriving live are not sent again.
+			live := streamReading("r3", 3)
+			h.hub.Publish(events.Event{Type: events.TypeSensorData, DeviceID: "d1", Data: stored[2]})
+			h.hub.Publish(events.Event{Type: events.TypeSensorData, DeviceID: "d1", Data: live})
+			if tt.want == nil {
+				expectReading(t, s.next(), stored[2])
+			}
+			expectReading(t, s.next(), live)
+		})
+	}
+
+	t.Run("no Last-Event-ID", func(t *testing.T) {
+		repo := &storedReadings{readings: stored}
+		h := newStreamHandler(repo, time.Hour)
+		s := openSensorStream(t, h, nil)
+		live := streamReading("r3", 3)
+		h.hub.Publish(events.Event{Type: events.TypeSensorData, DeviceID: "d1", Data: live})
+		expectReading(t, s.next(), live)
+		if f := repo.lastFilter(); f.DeviceID != "" {
+			t.Errorf("replayed with %+v", f)
+		}
+	})
+}
+
+func TestSensorStreamRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"json only", http.Header{"Accept": {"application/json"}}, http.StatusNotAcceptable},
+		{"bad Last-Event-ID", http.Header{"Last-Event-ID": {"42"}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openSensorStream(t, newStreamHandler(&storedReadings{}, time.Hour), tt.header)
+			if s.resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", s.resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
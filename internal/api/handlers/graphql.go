@@ -0,0 +1,162 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: graphql.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the HTTP transport of the GraphQL endpoint.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"airsense-be.com/internal/api/gql"
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+)
+
+// graphqlMaxBody bounds the body of a GraphQL request.
+const graphqlMaxBody = 64 << 10
+
+type GraphQLHandler struct {
+	schema *graphql.Schema
+}
+
+func NewGraphQLHandler(schema *graphql.Schema) *GraphQLHandler {
+	return &GraphQLHandler{schema: schema}
+}
+
+// Docs implements openapi.Documented.
+func (h *GraphQLHandler) Docs() openapi.Docs {
+	params := []openapi.Param{
+		{Name: "query", Description: "Query of a GET request."},
+		{Name: "operationName"},
+		{Name: "variables", Description: "JSON object of variables."},
+	}
+	return openapi.Docs{
+		"Query": {
+			Summary:     "Run a GraphQL query",
+			Description: "A POST request sends the query as a JSON body, a GET request as parameters. Errors of the query are part of the 200 response.",
+			Params:      params,
+			Response:    map[string]any{},
+		},
+		"Stream": {
+			Summary:      "Run a GraphQL subscription",
+			Description:  "Server-sent next events with each result, then a complete event.",
+			Params:       params,
+			ResponseType: "text/event-stream",
+		},
+	}
+}
+
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Query handles GET and POST /graphql. A GET request passes query,
+// operationName and a JSON variables object as query parameters; a POST
+// request sends them as a JSON body. Errors of the query are part of the
+// 200 response, as GraphQL clients expect.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	req, ok := bindGraphQL(c)
+	if !ok {
+		return
+	}
+	ctx := gql.WithUser(c.Request.Context(), middleware.UserID(c))
+	c.JSON(http.StatusOK, h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+// Stream handles GET /graphql/stream, running a subscription and sending
+// each of its results as a text/event-stream "next" event until the
+// subscription ends, which is followed by a "complete" event, or the
+// client disconnects.
+func (h *GraphQLHandler) Stream(c *gin.Context) {
+	req, ok := bindGraphQL(c)
+	if !ok {
+		return
+	}
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, "STREAMING_UNSUPPORTED", "Streaming is not supported.")
+		return
+	}
+	ctx := gql.WithUser(c.Request.Context(), middleware.UserID(c))
+	results, err := h.schema.Subscribe(ctx, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_QUERY", err.Error())
+		return
+	}
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case res, open := <-results:
+			if !open {
+				fmt.Fprint(w, "event: complete\ndata: \n\n")
+				flusher.Flush()
+				return
+			}
+			payload, err := json.Marshal(res)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: next\ndata: %s\n\n", payload); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// bindGraphQL reads the request from the query parameters of a GET
+// request or the body of a POST one. On failure the response is written
+// and false is returned.
+func bindGraphQL(c *gin.Context) (graphqlRequest, bool) {
+	var req graphqlRequest
+	if c.Request.Method == http.MethodPost {
+		if !decodeBody(c, graphqlMaxBody, &req) {
+			return req, false
+		}
+	} else {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if raw := c.Query("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				respondError(c, http.StatusBadRequest, "INVALID_VARIABLES", "variables must be a JSON object.")
+				return req, false
+			}
+		}
+	}
+	if req.Query == "" {
+		respondError(c, http.StatusBadRequest, "INVALID_QUERY", "query is required.")
+		return req, false
+	}
+	return req, true
+}
@@ -0,0 +1,106 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: groups.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers of device groups.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type GroupHandler struct {
+	groups *service.GroupService
+}
+
+func NewGroupHandler(groups *service.GroupService) *GroupHandler {
+	return &GroupHandler{groups: groups}
+}
+
+// Docs implements openapi.Documented.
+func (h *GroupHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Create": {Summary: "Create a device group", Body: createGroupRequest{}, Status: http.StatusCreated, Response: models.DeviceGroup{}},
+		"List":   {Summary: "List your device groups", Response: dataResponse[[]models.DeviceGroup]{}},
+		"Get":    {Summary: "Get a device group", Response: models.DeviceGroup{}},
+		"Delete": {Summary: "Delete a device group", Status: http.StatusNoContent},
+		"Latest": {
+			Summary:     "Latest reading of each device of a group",
+			Description: "Devices that never reported map to null.",
+			Response:    dataResponse[map[string]*service.LatestReading]{},
+		},
+	}
+}
+
+type createGroupRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	DeviceIDs []string `json:"device_ids" binding:"required"`
+}
+
+// Create handles POST /groups.
+func (h *GroupHandler) Create(c *gin.Context) {
+	var req createGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "name and device_ids are required.")
+		return
+	}
+	g, err := h.groups.Create(c.Request.Context(), middleware.UserID(c), req.Name, req.DeviceIDs)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, g)
+}
+
+// List handles GET /groups.
+func (h *GroupHandler) List(c *gin.Context) {
+	groups, err := h.groups.List(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+// Get handles GET /groups/:id.
+func (h *GroupHandler) Get(c *gin.Context) {
+	g, err := h.groups.Get(c.Request.Context(), c.Param("id"), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+// Delete handles DELETE /groups/:id.
+func (h *GroupHandler) Delete(c *gin.Context) {
+	if err := h.groups.Delete(c.Request.Context(), c.Param("id"), middleware.UserID(c)); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Latest handles GET /groups/:id/sensors/latest. Devices that never
+// reported map to null.
+func (h *GroupHandler) Latest(c *gin.Context) {
+	latest, err := h.groups.Latest(c.Request.Context(), c.Param("id"), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": latest})
+}
@@ -0,0 +1,77 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: health.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the liveness and readiness handlers of the AirSense REST API.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/config"
+)
+
+const readinessTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	pingMongo func(ctx context.Context) error
+	mongoCfg  config.MongoDBConfig
+}
+
+func NewHealthHandler(pingMongo func(ctx context.Context) error, mongoCfg config.MongoDBConfig) *HealthHandler {
+	return &HealthHandler{pingMongo: pingMongo, mongoCfg: mongoCfg}
+}
+
+// Docs implements openapi.Documented.
+func (h *HealthHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Live": {
+			Summary: "Liveness probe",
+			Response: struct {
+				Status string `json:"status"`
+			}{},
+		},
+		"Ready": {
+			Summary:     "Readiness probe",
+			Description: "Answers 503 while MongoDB is unreachable.",
+			Response:    map[string]any{},
+		},
+	}
+}
+
+// Live handles GET /healthz.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready handles GET /readyz. The MongoDB pool settings are included to help
+// debug connection exhaustion.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	mongoStatus := gin.H{
+		"max_pool_size":            h.mongoCfg.MaxPoolSize,
+		"min_pool_size":            h.mongoCfg.MinPoolSize,
+		"connect_timeout":          h.mongoCfg.ConnectTimeout.String(),
+		"server_selection_timeout": h.mongoCfg.ServerSelectionTimeout.String(),
+	}
+	status, code := "ok", http.StatusOK
+	if err := h.pingMongo(ctx); err != nil {
+		status, code = "unavailable", http.StatusServiceUnavailable
+		mongoStatus["error"] = err.Error()
+	}
+	mongoStatus["status"] = status
+	c.JSON(code, gin.H{"status": status, "mongodb": mongoStatus})
+}
@@ -0,0 +1,127 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: mqtt_auth.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers for MQTT credentials and the broker auth webhook.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/service"
+)
+
+type MQTTAuthHandler struct {
+	credentials *service.MQTTCredentialService
+}
+
+func NewMQTTAuthHandler(credentials *service.MQTTCredentialService) *MQTTAuthHandler {
+	return &MQTTAuthHandler{credentials: credentials}
+}
+
+// Docs implements openapi.Documented.
+func (h *MQTTAuthHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Rotate": {
+			Summary:     "Issue new MQTT credentials for a device",
+			Description: "The previous credentials stay valid for a grace period.",
+			Status:      http.StatusCreated,
+			Response:    service.MQTTCredentials{},
+		},
+		"Authenticate": {
+			Summary:     "MQTT broker login webhook",
+			Description: "Answers 403 with result deny to refuse the login.",
+			Body:        mqttAuthRequest{},
+			Response:    webhookResponse{},
+		},
+		"Authorize": {
+			Summary:     "MQTT broker ACL webhook",
+			Description: "Answers 403 with result deny to refuse the action.",
+			Body:        mqttACLRequest{},
+			Response:    webhookResponse{},
+		},
+	}
+}
+
+// Rotate handles POST /devices/:id/mqtt-credentials/rotate. The previous
+// credentials stay valid for the grace period.
+func (h *MQTTAuthHandler) Rotate(c *gin.Context) {
+	creds, err := h.credentials.Issue(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, creds)
+}
+
+type mqttAuthRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password"`
+	ClientID string `json:"clientid"`
+}
+
+// Authenticate handles the broker's POST /internal/mqtt/auth webhook.
+func (h *MQTTAuthHandler) Authenticate(c *gin.Context) {
+	var req mqttAuthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "username is required.")
+		return
+	}
+	ok, err := h.credentials.Authenticate(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if !ok {
+		metrics.MQTTAuthRejected.Add(1)
+		log.Printf("mqtt: rejected login of %q (client %q)", req.Username, req.ClientID)
+	}
+	respondWebhook(c, ok)
+}
+
+type mqttACLRequest struct {
+	Username string `json:"username" binding:"required"`
+	Topic    string `json:"topic" binding:"required"`
+	Action   string `json:"action" binding:"required,oneof=publish subscribe"`
+}
+
+// Authorize handles the broker's POST /internal/mqtt/acl webhook.
+func (h *MQTTAuthHandler) Authorize(c *gin.Context) {
+	var req mqttACLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "username, topic and action (publish or subscribe) are required.")
+		return
+	}
+	ok := h.credentials.Authorize(req.Username, req.Topic, req.Action)
+	if !ok {
+		metrics.MQTTACLRejected.Add(1)
+		log.Printf("mqtt: rejected %s of %q on %q", req.Action, req.Username, req.Topic)
+	}
+	respondWebhook(c, ok)
+}
+
+// webhookResponse is the body of the webhook answers, allow or deny.
+type webhookResponse struct {
+	Result string `json:"result"`
+}
+
+// respondWebhook answers in the format of the EMQX HTTP auth backend; other
+// brokers only look at the status code.
+func respondWebhook(c *gin.Context, allow bool) {
+	if allow {
+		c.JSON(http.StatusOK, webhookResponse{Result: "allow"})
+		return
+	}
+	c.JSON(http.StatusForbidden, webhookResponse{Result: "deny"})
+}
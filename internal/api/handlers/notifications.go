@@ -0,0 +1,97 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: notifications.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers for notification preferences in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type NotificationHandler struct {
+	notifications *service.NotificationService
+}
+
+func NewNotificationHandler(notifications *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications}
+}
+
+// Docs implements openapi.Documented.
+func (h *NotificationHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Get":         {Summary: "Get your notification preferences", Response: models.NotificationPreference{}},
+		"Update":      {Summary: "Set your notification preferences", Body: updateNotificationRequest{}, Response: models.NotificationPreference{}},
+		"RegisterFCM": {Summary: "Register a mobile app for push notifications", Body: registerFCMRequest{}, Response: models.UserDevice{}},
+	}
+}
+
+// Get handles GET /users/me/notifications.
+func (h *NotificationHandler) Get(c *gin.Context) {
+	pref, err := h.notifications.Get(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pref)
+}
+
+type updateNotificationRequest struct {
+	EmailEnabled    bool     `json:"email_enabled"`
+	EmailAddress    string   `json:"email_address" binding:"omitempty,email"`
+	AlertSeverities []string `json:"alert_severities"`
+}
+
+// Update handles PUT /users/me/notifications.
+func (h *NotificationHandler) Update(c *gin.Context) {
+	var req updateNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "email_address must be a valid email address.")
+		return
+	}
+	pref := &models.NotificationPreference{
+		UserID:          middleware.UserID(c),
+		EmailEnabled:    req.EmailEnabled,
+		EmailAddress:    req.EmailAddress,
+		AlertSeverities: req.AlertSeverities,
+	}
+	if err := h.notifications.Update(c.Request.Context(), pref); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pref)
+}
+
+type registerFCMRequest struct {
+	FCMToken string `json:"fcm_token" binding:"required"`
+	Platform string `json:"platform" binding:"required"`
+}
+
+// RegisterFCM handles POST /users/devices/fcm, called by the mobile app
+// to receive alerts as push notifications.
+func (h *NotificationHandler) RegisterFCM(c *gin.Context) {
+	var req registerFCMRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "fcm_token and platform are required.")
+		return
+	}
+	d, err := h.notifications.RegisterFCM(c.Request.Context(), middleware.UserID(c), req.FCMToken, req.Platform)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
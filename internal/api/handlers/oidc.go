@@ -0,0 +1,228 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: oidc.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the REST handlers of signing in with an OpenID Connect provider.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/service"
+)
+
+const (
+	// oidcStateCookie binds a sign-in to the browser that started it, so
+	// that nobody can sign a victim in to their own account by handing it
+	// a callback URL.
+	oidcStateCookie = "airsense_oidc_state"
+	oidcCookiePath  = "/api/v1/auth/oidc"
+)
+
+type OIDCHandler struct {
+	oidc     *service.OIDCService
+	tokens   *auth.Service
+	throttle *service.LoginThrottle
+	cfg      config.OIDCConfig
+}
+
+func NewOIDCHandler(oidc *service.OIDCService, tokens *auth.Service, throttle *service.LoginThrottle, cfg config.OIDCConfig) *OIDCHandler {
+	return &OIDCHandler{oidc: oidc, tokens: tokens, throttle: throttle, cfg: cfg}
+}
+
+// Docs implements openapi.Documented.
+func (h *OIDCHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Login": {
+			Summary: "Sign in with the OpenID Connect provider",
+			Description: "Redirects the browser to the provider, which sends it back to the callback. " +
+				"Should that fail, e.g. when OIDC is not configured, it redirects to the dashboard with #error=<code>.",
+			Status: http.StatusFound,
+		},
+		"Callback": {
+			Summary: "Finish signing in with the OpenID Connect provider",
+			Description: "Called by the browser redirected from the provider, with the cookie set by the login. " +
+				"Redirects to the dashboard with #code=<code> to exchange, or #error=<code> if the sign-in failed.",
+			Params: []openapi.Param{
+				{Name: "code", Required: true, Description: "The authorization code from the provider."},
+				{Name: "state", Required: true, Description: "The state from the provider."},
+			},
+			Status: http.StatusFound,
+		},
+		"Exchange": {
+			Summary: "Get the outcome of signing in with the OpenID Connect provider",
+			Description: "Exchanges the code the callback passed to the dashboard, once and within a minute. " +
+				"The first sign-in of an identity creates a user, unless a password user has its email address: " +
+				"then it answers 409 OIDC_LINK_REQUIRED with a link_token to confirm the link with.",
+			Body:     oidcExchangeRequest{},
+			Response: auth.TokenPair{},
+		},
+		"Link": {
+			Summary:     "Link your account to an OpenID Connect identity",
+			Description: "Confirms the link offered by the callback with the password of the account, then signs in. Wrong passwords count as failed logins.",
+			Body:        oidcLinkRequest{},
+			Response:    auth.TokenPair{},
+		},
+	}
+}
+
+// Login handles GET /auth/oidc/login, sending the browser to the provider
+// with a cookie holding the state it must come back with.
+func (h *OIDCHandler) Login(c *gin.Context) {
+	redirect, state, err := h.oidc.Start(c.Request.Context())
+	if err != nil {
+		h.toDashboard(c, "error", oidcErrorCode(err))
+		return
+	}
+	h.setStateCookie(c, state, int(h.cfg.StateTTL.Seconds()))
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// Callback handles GET /auth/oidc/callback, where the provider sends the
+// browser back to. The browser goes on to the dashboard, which cannot read
+// this response, with a code to exchange for the outcome.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	cookie, _ := c.Cookie(oidcStateCookie)
+	h.setStateCookie(c, "", -1)
+	if reason := c.Query("error"); reason != "" {
+		log.Printf("auth: oidc provider refused the sign-in: %s", reason)
+		h.toDashboard(c, "error", "OIDC_FAILED")
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		h.toDashboard(c, "error", "INVALID_OIDC_STATE")
+		return
+	}
+	result, err := h.oidc.Callback(c.Request.Context(), code, state)
+	if err != nil {
+		h.toDashboard(c, "error", oidcErrorCode(err))
+		return
+	}
+	h.toDashboard(c, "code", result)
+}
+
+// toDashboard redirects the browser to the dashboard with key=value in the
+// fragment, which is not sent on to servers.
+func (h *OIDCHandler) toDashboard(c *gin.Context, key, value string) {
+	u, err := url.Parse(h.cfg.DashboardURL)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	u.Fragment = url.Values{key: {value}}.Encode()
+	// The page the browser lands on must not pass the code on either.
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Redirect(http.StatusFound, u.String())
+}
+
+// oidcErrorCode is the error code the dashboard is sent for err, the one
+// respondServiceError would answer with.
+func oidcErrorCode(err error) string {
+	switch {
+	case errors.Is(err, service.ErrOIDCDisabled):
+		return "OIDC_DISABLED"
+	case errors.Is(err, service.ErrInvalidOIDCState):
+		return "INVALID_OIDC_STATE"
+	case errors.Is(err, service.ErrOIDCFailed):
+		return "OIDC_FAILED"
+	case errors.Is(err, service.ErrOIDCEmailNotVerified):
+		return "OIDC_EMAIL_NOT_VERIFIED"
+	case errors.Is(err, service.ErrOIDCDomainNotAllowed):
+		return "OIDC_DOMAIN_NOT_ALLOWED"
+	case errors.Is(err, service.ErrOIDCAccountConflict):
+		return "OIDC_ACCOUNT_CONFLICT"
+	}
+	log.Printf("auth: oidc sign-in: %v", err)
+	return "INTERNAL_ERROR"
+}
+
+type oidcExchangeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// Exchange handles POST /auth/oidc/exchange, where the dashboard gets the
+// outcome of the sign-in the callback passed it the code of.
+func (h *OIDCHandler) Exchange(c *gin.Context) {
+	var req oidcExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "code is required.")
+		return
+	}
+	u, err := h.oidc.Exchange(c.Request.Context(), req.Code)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	pair, err := h.tokens.IssuePair(c.Request.Context(), u.ID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
+
+type oidcLinkRequest struct {
+	LinkToken string `json:"link_token" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+}
+
+// Link handles POST /auth/oidc/link. Wrong passwords are throttled like
+// failed logins to the account.
+func (h *OIDCHandler) Link(c *gin.Context) {
+	var req oidcLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "link_token and password are required.")
+		return
+	}
+	ctx, ip := c.Request.Context(), c.ClientIP()
+	u, err := h.oidc.LinkUser(ctx, req.LinkToken)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if err := h.throttle.Attempt(ctx, u.Email, ip); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	err = h.oidc.ConfirmLink(ctx, req.LinkToken, req.Password)
+	if errors.Is(err, service.ErrWrongPassword) {
+		h.throttle.Fail()
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if err := h.throttle.Reset(ctx, u.Email, ip); err != nil {
+		log.Printf("auth: reset failed logins: %v", err)
+	}
+	pair, err := h.tokens.IssuePair(ctx, u.ID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
+
+// setStateCookie sets the state cookie, or deletes it with a negative
+// maxAge. It is Lax so that the redirect from the provider carries it.
+func (h *OIDCHandler) setStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, oidcCookiePath, "", strings.HasPrefix(h.cfg.RedirectURL, "https://"), true)
+}
@@ -0,0 +1,208 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: oidc_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the REST handlers of signing in with an OpenID Connect provider.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+)
+
+const testDashboardURL = "https://app.example.com/oidc/callback"
+
+// noOIDCLogins has no sign-in in progress.
+type noOIDCLogins struct {
+	repository.OIDCLoginRepository
+}
+
+func (noOIDCLogins) Take(context.Context, string) (*models.OIDCLogin, error) {
+	return nil, repository.ErrNotFound
+}
+
+type oidcResultCodes struct {
+	repository.OIDCResultRepository
+	results map[string]models.OIDCResult
+}
+
+func (r *oidcResultCodes) Take(_ context.Context, codeHash string) (*models.OIDCResult, error) {
+	res, ok := r.results[codeHash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	delete(r.results, codeHash)
+	return &res, nil
+}
+
+type createdLinks struct {
+	repository.OIDCLinkRepository
+	n int
+}
+
+func (r *createdLinks) Create(context.Context, *models.OIDCLink) error {
+	r.n++
+	return nil
+}
+
+type oidcUser struct {
+	repository.UserRepository
+}
+
+func (oidcUser) GetByID(_ context.Context, id string) (*models.User, error) {
+	return &models.User{ID: id, Email: "ann@example.com"}, nil
+}
+
+type keptRefreshTokens struct {
+	repository.RefreshTokenRepository
+}
+
+func (keptRefreshTokens) Create(context.Context, *models.RefreshToken) error {
+	return nil
+}
+
+func newTestOIDCRouter(provider *auth.OIDCProvider, results *oidcResultCodes, links *createdLinks) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := config.OIDCConfig{IssuerURL: "https://id.example.com", RedirectURL: "https://api.example.com/api/v1/auth/oidc/callback",
+		DashboardURL: testDashboardURL, StateTTL: time.Minute, LinkTTL: time.Minute}
+	oidc := service.NewOIDCService(provider, noOIDCLogins{}, results, links, oidcUser{}, cfg)
+	tokens := auth.NewService(keptRefreshTokens{}, oidcUser{}, config.JWTConfig{Secret: "test-secret-that-is-long-enough-for-hmac",
+		Expire: time.Minute, RefreshExpire: time.Hour})
+	h := NewOIDCHandler(oidc, tokens, nil, cfg)
+	r := gin.New()
+	r.GET("/auth/oidc/login", h.Login)
+	r.GET("/auth/oidc/callback", h.Callback)
+	r.POST("/auth/oidc/exchange", h.Exchange)
+	return r
+}
+
+func TestOIDCCallbackRedirect(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		cookie    string
+		wantError string
+	}{
+		{"refused by the provider", "?error=access_denied&state=s1", "s1", "OIDC_FAILED"},
+		{"no code", "?state=s1", "s1", "INVALID_OIDC_STATE"},
+		{"no cookie", "?code=c1&state=s1", "", "INVALID_OIDC_STATE"},
+		{"cookie of another sign-in", "?code=c1&state=s1", "s2", "INVALID_OIDC_STATE"},
+		{"unknown state", "?code=c1&state=s1", "s1", "INVALID_OIDC_STATE"},
+	}
+	r := newTestOIDCRouter(auth.NewOIDCProvider(config.OIDCConfig{}), &oidcResultCodes{}, &createdLinks{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback"+tt.query, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusFound {
+				t.Fatalf("status = %d, want 302: %s", w.Code, w.Body)
+			}
+			loc, err := url.Parse(w.Header().Get("Location"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loc.Scheme+"://"+loc.Host+loc.Path != testDashboardURL || loc.RawQuery != "" {
+				t.Errorf("redirected to %s, want the dashboard", loc)
+			}
+			if frag, _ := url.ParseQuery(loc.Fragment); frag.Get("error") != tt.wantError || frag.Has("code") {
+				t.Errorf("fragment = %q, want error=%s", loc.Fragment, tt.wantError)
+			}
+			if c := w.Result().Cookies(); len(c) != 1 || c[0].Name != oidcStateCookie || c[0].MaxAge >= 0 {
+				t.Errorf("cookies = %v, want the state cookie deleted", c)
+			}
+		})
+	}
+}
+
+func TestOIDCLoginDisabled(t *testing.T) {
+	r := newTestOIDCRouter(nil, &oidcResultCodes{}, &createdLinks{})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	if want := testDashboardURL + "#error=OIDC_DISABLED"; w.Code != http.StatusFound || w.Header().Get("Location") != want {
+		t.Errorf("got %d to %q, want 302 to %s", w.Code, w.Header().Get("Location"), want)
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	future := time.Now().Add(time.Minute)
+	tests := []struct {
+		name     string
+		result   *models.OIDCResult
+		want     int
+		wantCode string
+	}{
+		{"signed in", &models.OIDCResult{UserID: "u1", ExpiresAt: future}, http.StatusOK, ""},
+		{"link required", &models.OIDCResult{UserID: "u1", Issuer: "https://id.example.com", LinkSubject: "s1", ExpiresAt: future},
+			http.StatusConflict, "OIDC_LINK_REQUIRED"},
+		{"expired", &models.OIDCResult{UserID: "u1", ExpiresAt: time.Now().Add(-time.Second)}, http.StatusBadRequest, "INVALID_OIDC_CODE"},
+		{"unknown code", nil, http.StatusBadRequest, "INVALID_OIDC_CODE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := &oidcResultCodes{results: map[string]models.OIDCResult{}}
+			if tt.result != nil {
+				results.results[auth.HashToken("code")] = *tt.result
+			}
+			links := &createdLinks{}
+			r := newTestOIDCRouter(auth.NewOIDCProvider(config.OIDCConfig{}), results, links)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/oidc/exchange", bytes.NewReader([]byte(`{"code":"code"}`))))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			var body struct {
+				Code        string `json:"code"`
+				Email       string `json:"email"`
+				LinkToken   string `json:"link_token"`
+				AccessToken string `json:"access_token"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+			switch tt.want {
+			case http.StatusOK:
+				if body.AccessToken == "" {
+					t.Errorf("no access token in %s", w.Body)
+				}
+			case http.StatusConflict:
+				if body.Email != "ann@example.com" || body.LinkToken == "" || links.n != 1 {
+					t.Errorf("body %s with %d links, want a link token", w.Body, links.n)
+				}
+			}
+			if len(results.results) != 0 {
+				t.Error("the code can be used again")
+			}
+			if strings.Contains(w.Header().Get("Location"), "token") {
+				t.Error("redirected with a token")
+			}
+		})
+	}
+}
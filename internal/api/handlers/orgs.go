@@ -0,0 +1,211 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: orgs.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the REST handlers of organizations.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type OrgHandler struct {
+	orgs *service.OrgService
+}
+
+func NewOrgHandler(orgs *service.OrgService) *OrgHandler {
+	return &OrgHandler{orgs: orgs}
+}
+
+// Docs implements openapi.Documented.
+func (h *OrgHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Create": {
+			Summary:     "Create an organization",
+			Description: "The caller becomes its owner.",
+			Body:        createOrgRequest{},
+			Status:      http.StatusCreated,
+			Response:    service.OrgMembership{},
+		},
+		"List":    {Summary: "List your organizations", Response: dataResponse[[]service.OrgMembership]{}},
+		"Get":     {Summary: "Get an organization you are a member of", Response: service.OrgMembership{}},
+		"Members": {Summary: "List the members of an organization", Response: dataResponse[[]models.OrgMember]{}},
+		"Invite": {
+			Summary:     "Invite a user to an organization",
+			Description: "Admins invite admins and viewers, owners also owners. The token is mailed to the address and only returned by this call; it must be accepted by the user registered under the address.",
+			Body:        inviteOrgMemberRequest{},
+			Status:      http.StatusCreated,
+			Response:    service.IssuedOrgInvitation{},
+		},
+		"Accept": {Summary: "Join an organization with an invitation", Body: acceptOrgInvitationRequest{}, Response: models.OrgMember{}},
+		"RemoveMember": {
+			Summary:     "Remove a member from an organization",
+			Description: "Members may remove themselves; admins remove admins and viewers, owners anyone. The last owner cannot be removed.",
+			Status:      http.StatusNoContent,
+		},
+		"Devices": {Summary: "List the devices of an organization", Params: deviceListParams, Response: service.DevicePage{}},
+		"AddDevice": {
+			Summary:     "Add one of your devices to an organization",
+			Description: "Requires the admin role. The device stays counted against your quota.",
+			Body:        orgDeviceRequest{},
+			Response:    models.Device{},
+		},
+		"RemoveDevice": {
+			Summary:     "Take a device out of an organization",
+			Description: "Allowed to the device owner and the admins of the organization.",
+			Response:    models.Device{},
+		},
+	}
+}
+
+type createOrgRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// Create handles POST /orgs.
+func (h *OrgHandler) Create(c *gin.Context) {
+	var req createOrgRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "name is required.")
+		return
+	}
+	o, err := h.orgs.Create(c.Request.Context(), middleware.UserID(c), req.Name)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, o)
+}
+
+// List handles GET /orgs.
+func (h *OrgHandler) List(c *gin.Context) {
+	orgs, err := h.orgs.List(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": orgs})
+}
+
+// Get handles GET /orgs/:orgId.
+func (h *OrgHandler) Get(c *gin.Context) {
+	o, err := h.orgs.Get(c.Request.Context(), c.Param("orgId"), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, o)
+}
+
+// Members handles GET /orgs/:orgId/members.
+func (h *OrgHandler) Members(c *gin.Context) {
+	members, err := h.orgs.Members(c.Request.Context(), c.Param("orgId"), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+type inviteOrgMemberRequest struct {
+	Email string `json:"email" binding:"required"`
+	// Role is owner, admin or viewer.
+	Role models.OrgRole `json:"role" binding:"required"`
+}
+
+// Invite handles POST /orgs/:orgId/invitations.
+func (h *OrgHandler) Invite(c *gin.Context) {
+	var req inviteOrgMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "email and role are required.")
+		return
+	}
+	inv, err := h.orgs.Invite(c.Request.Context(), c.Param("orgId"), middleware.UserID(c), req.Email, req.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, inv)
+}
+
+type acceptOrgInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Accept handles POST /orgs/invitations/accept.
+func (h *OrgHandler) Accept(c *gin.Context) {
+	var req acceptOrgInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "token is required.")
+		return
+	}
+	m, err := h.orgs.Accept(c.Request.Context(), req.Token, middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// RemoveMember handles DELETE /orgs/:orgId/members/:userId.
+func (h *OrgHandler) RemoveMember(c *gin.Context) {
+	err := h.orgs.RemoveMember(c.Request.Context(), c.Param("orgId"), middleware.UserID(c), c.Param("userId"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Devices handles GET /orgs/:orgId/devices, with the query parameters of
+// GET /devices.
+func (h *OrgHandler) Devices(c *gin.Context) {
+	q, ok := deviceQuery(c)
+	if !ok {
+		return
+	}
+	page, err := h.orgs.Devices(c.Request.Context(), c.Param("orgId"), middleware.UserID(c), q)
+	respondDevicePage(c, page, err)
+}
+
+type orgDeviceRequest struct {
+	DeviceID string `json:"device_id" binding:"required"`
+}
+
+// AddDevice handles POST /orgs/:orgId/devices.
+func (h *OrgHandler) AddDevice(c *gin.Context) {
+	var req orgDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "device_id is required.")
+		return
+	}
+	d, err := h.orgs.AddDevice(c.Request.Context(), c.Param("orgId"), middleware.UserID(c), req.DeviceID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// RemoveDevice handles DELETE /orgs/:orgId/devices/:deviceId.
+func (h *OrgHandler) RemoveDevice(c *gin.Context) {
+	d, err := h.orgs.RemoveDevice(c.Request.Context(), c.Param("orgId"), middleware.UserID(c), c.Param("deviceId"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
@@ -0,0 +1,164 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: profile.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the REST handlers of the profile of the signed-in user.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type ProfileHandler struct {
+	users    *service.UserService
+	tokens   *auth.Service
+	throttle *service.LoginThrottle
+}
+
+func NewProfileHandler(users *service.UserService, tokens *auth.Service, throttle *service.LoginThrottle) *ProfileHandler {
+	return &ProfileHandler{users: users, tokens: tokens, throttle: throttle}
+}
+
+// Docs implements openapi.Documented.
+func (h *ProfileHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Get": {Summary: "Get your profile", Response: models.User{}},
+		"Update": {
+			Summary: "Change your email address or name",
+			Description: "Changing the email address takes current_password and answers 202 whether or not the address is in use, so it does not tell which ones are. " +
+				"A free address becomes the user's, unverified until confirmed with the link mailed to it. " +
+				"Either way every session of the user ends, this one included. Wrong current passwords count as failed logins.",
+			Body:     updateProfileRequest{},
+			Response: models.User{},
+		},
+		"ChangePassword": {
+			Summary:     "Change your password",
+			Description: "Every other session of the user ends; this one continues with the returned tokens. Wrong current passwords count as failed logins. The user is emailed about the change.",
+			Body:        changePasswordRequest{},
+			Response:    auth.TokenPair{},
+		},
+	}
+}
+
+// Get handles GET /users/me.
+func (h *ProfileHandler) Get(c *gin.Context) {
+	u, err := h.users.Profile(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, u)
+}
+
+type updateProfileRequest struct {
+	Email *string `json:"email"`
+	Name  *string `json:"name"`
+	// CurrentPassword is required to change the email address.
+	CurrentPassword string `json:"current_password"`
+}
+
+// Update handles PATCH /users/me. An email change answers 202 whether or
+// not the address was free, and wrong current passwords are throttled like
+// failed logins.
+func (h *ProfileHandler) Update(c *gin.Context) {
+	var req updateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "Body must be a JSON object with email and/or name.")
+		return
+	}
+	ctx, ip, userID := c.Request.Context(), c.ClientIP(), middleware.UserID(c)
+	var email string
+	if req.Email != nil && req.CurrentPassword != "" {
+		u, err := h.users.Profile(ctx, userID)
+		if err != nil {
+			respondServiceError(c, err)
+			return
+		}
+		email = u.Email
+		if err := h.throttle.Attempt(ctx, email, ip); err != nil {
+			respondServiceError(c, err)
+			return
+		}
+	}
+	u, emailChanged, err := h.users.UpdateProfile(ctx, userID,
+		service.ProfileUpdate{Email: req.Email, Name: req.Name, CurrentPassword: req.CurrentPassword})
+	if errors.Is(err, service.ErrWrongPassword) {
+		h.throttle.Fail()
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if email != "" {
+		if err := h.throttle.Reset(ctx, email, ip); err != nil {
+			log.Printf("auth: reset failed logins: %v", err)
+		}
+	}
+	if emailChanged {
+		c.JSON(http.StatusAccepted, messageResponse{
+			Message: "Unless the address is in use, confirm it with the link mailed to it. Every session has ended; sign in again.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, u)
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangePassword handles POST /users/me/password. Wrong current passwords
+// are throttled like failed logins, so a stolen token cannot be used to
+// guess the password. The change revokes every token of the user, so the
+// caller gets a new pair to stay signed in.
+func (h *ProfileHandler) ChangePassword(c *gin.Context) {
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "current_password and new_password are required.")
+		return
+	}
+	ctx, ip, userID := c.Request.Context(), c.ClientIP(), middleware.UserID(c)
+	u, err := h.users.Profile(ctx, userID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if err := h.throttle.Attempt(ctx, u.Email, ip); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	err = h.users.ChangePassword(ctx, userID, req.CurrentPassword, req.NewPassword)
+	if errors.Is(err, service.ErrWrongPassword) {
+		h.throttle.Fail()
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if err := h.throttle.Reset(ctx, u.Email, ip); err != nil {
+		log.Printf("auth: reset failed logins: %v", err)
+	}
+	pair, err := h.tokens.IssuePair(ctx, userID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
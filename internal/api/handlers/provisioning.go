@@ -0,0 +1,97 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: provisioning.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the REST handlers of device provisioning.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/service"
+)
+
+type ProvisioningHandler struct {
+	provisioning *service.ProvisioningService
+}
+
+func NewProvisioningHandler(provisioning *service.ProvisioningService) *ProvisioningHandler {
+	return &ProvisioningHandler{provisioning: provisioning}
+}
+
+// Docs implements openapi.Documented.
+func (h *ProvisioningHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Provision": {
+			Summary:     "Register a device with a provisioning token",
+			Description: "Called by the device itself. The MQTT password is only returned by this call.",
+			Body:        provisionRequest{},
+			Status:      http.StatusCreated,
+			Response:    service.ProvisionedDevice{},
+		},
+		"CreateToken": {
+			Summary:     "Create a provisioning token",
+			Description: "The signed token is only returned by this call.",
+			Body:        createProvisioningTokenRequest{},
+			Status:      http.StatusCreated,
+			Response:    service.IssuedProvisioningToken{},
+		},
+	}
+}
+
+type provisionRequest struct {
+	Token        string `json:"token" binding:"required"`
+	SerialNumber string `json:"serial_number" binding:"required"`
+}
+
+// Provision handles POST /provision, called by a device registering
+// itself with a provisioning token instead of a user JWT. The MQTT
+// password is only ever returned by this call.
+func (h *ProvisioningHandler) Provision(c *gin.Context) {
+	var req provisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "token and serial_number are required.")
+		return
+	}
+	device, err := h.provisioning.Provision(c.Request.Context(), req.Token, req.SerialNumber)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, device)
+}
+
+type createProvisioningTokenRequest struct {
+	// UserID owns the provisioned devices; the admin by default.
+	UserID     string `json:"user_id"`
+	TTLSeconds int    `json:"ttl_seconds" binding:"min=0"`
+	MaxUses    int    `json:"max_uses" binding:"min=0"`
+}
+
+// CreateToken handles POST /admin/provisioning-tokens. The signed token
+// is only ever returned by this call.
+func (h *ProvisioningHandler) CreateToken(c *gin.Context) {
+	var req createProvisioningTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "ttl_seconds and max_uses must be non-negative integers.")
+		return
+	}
+	token, err := h.provisioning.CreateToken(c.Request.Context(), middleware.UserID(c), req.UserID,
+		time.Duration(req.TTLSeconds)*time.Second, req.MaxUses)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, token)
+}
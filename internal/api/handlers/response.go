@@ -0,0 +1,294 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: response.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the shared response helpers of the AirSense REST API.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+// ErrorResponse is the body of error responses. Some errors add fields
+// to it, e.g. QUOTA_EXCEEDED its count and limit.
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// dataResponse documents the {"data": ...} responses of lists.
+type dataResponse[T any] struct {
+	Data T `json:"data"`
+}
+
+func respondError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, ErrorResponse{Code: code, Message: message})
+}
+
+// respondServiceError maps the well-known service errors to HTTP responses
+// and logs anything unexpected as an internal error.
+func respondServiceError(c *gin.Context, err error) {
+	var (
+		fieldsErr *service.FieldsError
+		quotaErr  *service.QuotaExceededError
+		paramsErr *models.ParamsError
+		lockedErr *service.LoginLockedError
+		linkErr   *service.OIDCLinkRequiredError
+	)
+	switch {
+	case errors.As(err, &lockedErr):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		respondError(c, http.StatusTooManyRequests, "LOGIN_LOCKED", "Too many failed logins, try again later.")
+	case errors.As(err, &linkErr):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"code":       "OIDC_LINK_REQUIRED",
+			"message":    "An account with this email address exists. Confirm linking it with its password.",
+			"email":      linkErr.Email,
+			"link_token": linkErr.LinkToken,
+			"expires_at": linkErr.ExpiresAt,
+		})
+	case errors.As(err, &quotaErr):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"code":    "QUOTA_EXCEEDED",
+			"message": "Device quota exceeded.",
+			"count":   quotaErr.Count,
+			"limit":   quotaErr.Limit,
+		})
+	case errors.Is(err, service.ErrInvalidDeviceQuery):
+		respondError(c, http.StatusBadRequest, "INVALID_QUERY",
+			"sort must be name, created_at or last_seen (optionally prefixed with -), status online or offline, tags key:value, and near a longitude,latitude with radius_km between 0 and 20000.")
+	case errors.Is(err, service.ErrInvalidQuota):
+		respondError(c, http.StatusBadRequest, "INVALID_QUOTA", "device_limit must not be negative.")
+	case errors.As(err, &fieldsErr):
+		respondError(c, http.StatusBadRequest, strings.ToUpper(fieldsErr.Reason)+"_FIELDS",
+			"Cannot set "+fieldsErr.Reason+" fields: "+strings.Join(fieldsErr.Fields, ", ")+".")
+	case errors.Is(err, service.ErrInvalidDevice):
+		respondError(c, http.StatusBadRequest, "INVALID_DEVICE", err.Error())
+	case errors.Is(err, service.ErrTagNotFound):
+		respondError(c, http.StatusNotFound, "TAG_NOT_FOUND", "Device has no such tag.")
+	case errors.Is(err, service.ErrReadingNotFound):
+		respondError(c, http.StatusNotFound, "READING_NOT_FOUND", "Reading not found.")
+	case errors.Is(err, service.ErrDeviceNotFound):
+		respondError(c, http.StatusNotFound, "DEVICE_NOT_FOUND", "Device not found.")
+	case errors.Is(err, service.ErrDeviceExists):
+		respondError(c, http.StatusConflict, "DEVICE_EXISTS", "A device with this ID is already registered.")
+	case errors.Is(err, service.ErrDeviceNotDeleted):
+		respondError(c, http.StatusConflict, "DEVICE_NOT_DELETED", "Delete the device before purging it.")
+	case errors.Is(err, service.ErrInvalidCSVHeader):
+		respondError(c, http.StatusBadRequest, "INVALID_CSV_HEADER", "CSV header must be name,location[,user_id].")
+	case errors.Is(err, service.ErrTooManyRows):
+		respondError(c, http.StatusRequestEntityTooLarge, "TOO_MANY_ROWS", "At most 1000 rows can be imported at once.")
+	case errors.Is(err, service.ErrForbidden):
+		respondError(c, http.StatusForbidden, "FORBIDDEN", "You do not have access to this resource.")
+	case errors.Is(err, service.ErrOrgNotFound):
+		respondError(c, http.StatusNotFound, "ORG_NOT_FOUND", "Organization not found.")
+	case errors.Is(err, service.ErrOrgMemberNotFound):
+		respondError(c, http.StatusNotFound, "ORG_MEMBER_NOT_FOUND", "Organization member not found.")
+	case errors.Is(err, service.ErrInvalidOrg):
+		respondError(c, http.StatusBadRequest, "INVALID_ORG", err.Error())
+	case errors.Is(err, service.ErrInvalidOrgRole):
+		respondError(c, http.StatusBadRequest, "INVALID_ORG_ROLE", "role must be owner, admin or viewer.")
+	case errors.Is(err, service.ErrOrgMemberExists):
+		respondError(c, http.StatusConflict, "ORG_MEMBER_EXISTS", "User is already a member of the organization.")
+	case errors.Is(err, service.ErrLastOrgOwner):
+		respondError(c, http.StatusConflict, "LAST_ORG_OWNER", "An organization must keep an owner.")
+	case errors.Is(err, service.ErrInvalidInvitation):
+		respondError(c, http.StatusBadRequest, "INVALID_INVITATION", "Invitation is invalid or expired.")
+	case errors.Is(err, service.ErrDeviceInOtherOrg):
+		respondError(c, http.StatusConflict, "DEVICE_IN_OTHER_ORG", "Device belongs to another organization.")
+	case errors.Is(err, service.ErrUserNotFound):
+		respondError(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found.")
+	case errors.Is(err, service.ErrShareNotFound):
+		respondError(c, http.StatusNotFound, "SHARE_NOT_FOUND", "Share not found.")
+	case errors.Is(err, service.ErrShareExists):
+		respondError(c, http.StatusConflict, "SHARE_EXISTS", "Device is already shared with this user.")
+	case errors.Is(err, service.ErrShareWithOwner):
+		respondError(c, http.StatusBadRequest, "SHARE_WITH_OWNER", "Cannot share a device with its owner.")
+	case errors.Is(err, service.ErrInvalidPermission):
+		respondError(c, http.StatusBadRequest, "INVALID_PERMISSION", "permission must be read or control.")
+	case errors.Is(err, service.ErrCommandNotFound):
+		respondError(c, http.StatusNotFound, "COMMAND_NOT_FOUND", "Command not found.")
+	case errors.Is(err, service.ErrBatchNotFound):
+		respondError(c, http.StatusNotFound, "BATCH_NOT_FOUND", "Command batch not found.")
+	case errors.Is(err, service.ErrScheduleNotFound):
+		respondError(c, http.StatusNotFound, "SCHEDULE_NOT_FOUND", "Command schedule not found.")
+	case errors.Is(err, service.ErrIdempotencyKeyInUse):
+		respondError(c, http.StatusConflict, "IDEMPOTENCY_KEY_IN_USE", "Idempotency-Key is already used for this device by another user.")
+	case errors.As(err, &paramsErr):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"code":       "INVALID_PARAMS",
+			"message":    "Params do not match the spec of action " + paramsErr.Action + ".",
+			"violations": paramsErr.Violations,
+		})
+	case errors.Is(err, service.ErrInvalidCommand):
+		respondError(c, http.StatusBadRequest, "INVALID_COMMAND", err.Error())
+	case errors.Is(err, service.ErrInvalidSchedule):
+		respondError(c, http.StatusBadRequest, "INVALID_SCHEDULE", err.Error())
+	case errors.Is(err, service.ErrInvalidTransition):
+		respondError(c, http.StatusConflict, "INVALID_TRANSITION", "The command cannot take this status now.")
+	case errors.Is(err, service.ErrInvalidCommandStatus):
+		respondError(c, http.StatusBadRequest, "INVALID_STATUS", "status must be success or error.")
+	case errors.Is(err, service.ErrOTAInProgress):
+		respondError(c, http.StatusConflict, "OTA_IN_PROGRESS", "A firmware update of this device is already in progress.")
+	case errors.Is(err, service.ErrDeviceDecommissioned):
+		respondError(c, http.StatusConflict, "DEVICE_DECOMMISSIONED", "Device has been decommissioned.")
+	case errors.Is(err, service.ErrDeviceDeleted):
+		respondError(c, http.StatusGone, "DEVICE_DELETED", "Device has been deleted.")
+	case errors.Is(err, service.ErrKeyNotFound):
+		respondError(c, http.StatusNotFound, "KEY_NOT_FOUND", "API key not found.")
+	case errors.Is(err, service.ErrWeakPassword):
+		respondError(c, http.StatusBadRequest, "WEAK_PASSWORD", err.Error())
+	case errors.Is(err, service.ErrInvalidResetToken):
+		respondError(c, http.StatusBadRequest, "INVALID_RESET_TOKEN", "Password reset token is invalid, expired or used.")
+	case errors.Is(err, service.ErrInvalidEmail):
+		respondError(c, http.StatusBadRequest, "INVALID_EMAIL", "Email address is invalid.")
+	case errors.Is(err, service.ErrWrongPassword):
+		respondError(c, http.StatusForbidden, "WRONG_PASSWORD", "Current password is incorrect.")
+	case errors.Is(err, service.ErrInvalidProfile):
+		respondError(c, http.StatusBadRequest, "INVALID_PROFILE", err.Error())
+	case errors.Is(err, service.ErrInvalidVerificationToken):
+		respondError(c, http.StatusBadRequest, "INVALID_VERIFICATION_TOKEN", "Verification token is invalid or expired.")
+	case errors.Is(err, service.ErrEmailNotVerified):
+		respondError(c, http.StatusForbidden, "EMAIL_NOT_VERIFIED", "Confirm your email address before adding devices.")
+	case errors.Is(err, service.ErrOIDCDisabled):
+		respondError(c, http.StatusServiceUnavailable, "OIDC_DISABLED", "OIDC sign-in is not enabled on this server.")
+	case errors.Is(err, service.ErrInvalidOIDCState):
+		respondError(c, http.StatusBadRequest, "INVALID_OIDC_STATE", "Sign-in is invalid or expired, start it again.")
+	case errors.Is(err, service.ErrInvalidOIDCCode):
+		respondError(c, http.StatusBadRequest, "INVALID_OIDC_CODE", "Sign-in is invalid or expired, start it again.")
+	case errors.Is(err, service.ErrOIDCFailed):
+		respondError(c, http.StatusUnauthorized, "OIDC_FAILED", "The identity provider did not confirm the sign-in.")
+	case errors.Is(err, service.ErrOIDCEmailNotVerified):
+		respondError(c, http.StatusForbidden, "OIDC_EMAIL_NOT_VERIFIED", "The identity provider has not verified your email address.")
+	case errors.Is(err, service.ErrOIDCDomainNotAllowed):
+		respondError(c, http.StatusForbidden, "OIDC_DOMAIN_NOT_ALLOWED", "Your email domain may not sign in.")
+	case errors.Is(err, service.ErrInvalidOIDCLink):
+		respondError(c, http.StatusBadRequest, "INVALID_LINK_TOKEN", "Link token is invalid, expired or used.")
+	case errors.Is(err, service.ErrOIDCAccountConflict):
+		respondError(c, http.StatusConflict, "OIDC_ACCOUNT_CONFLICT", "The account with this email address cannot be linked to this identity.")
+	case errors.Is(err, service.ErrInvalidKeyRequest):
+		respondError(c, http.StatusBadRequest, "INVALID_API_KEY_REQUEST", err.Error())
+	case errors.Is(err, service.ErrScopedTokenNotFound):
+		respondError(c, http.StatusNotFound, "SCOPED_TOKEN_NOT_FOUND", "Scoped token not found.")
+	case errors.Is(err, service.ErrInvalidScope):
+		respondError(c, http.StatusBadRequest, "INVALID_SCOPED_TOKEN_REQUEST", err.Error())
+	case errors.Is(err, service.ErrProvisioningDisabled):
+		respondError(c, http.StatusServiceUnavailable, "PROVISIONING_DISABLED", "Device provisioning is not enabled on this server.")
+	case errors.Is(err, service.ErrInvalidProvisioningToken):
+		respondError(c, http.StatusUnauthorized, "INVALID_PROVISIONING_TOKEN", "Provisioning token is invalid, expired or used up.")
+	case errors.Is(err, service.ErrInvalidProvisioning):
+		respondError(c, http.StatusBadRequest, "INVALID_PROVISIONING_REQUEST", err.Error())
+	case errors.Is(err, service.ErrSerialNumberExists):
+		respondError(c, http.StatusConflict, "SERIAL_NUMBER_EXISTS", "A device with this serial number is already provisioned.")
+	case errors.Is(err, service.ErrInvalidInterval):
+		respondError(c, http.StatusBadRequest, "INVALID_INTERVAL", "interval must be one of hour, day, week, month.")
+	case errors.Is(err, service.ErrInvalidComparison):
+		respondError(c, http.StatusBadRequest, "INVALID_COMPARISON", err.Error())
+	case errors.Is(err, service.ErrInvalidTimezone):
+		respondError(c, http.StatusBadRequest, "INVALID_TIMEZONE", "tz must be an IANA timezone name such as Europe/Berlin.")
+	case errors.Is(err, service.ErrInvalidInterpolation):
+		respondError(c, http.StatusBadRequest, "INVALID_INTERPOLATION",
+			"from must be before to, interval at least 1s, max_gap at least 1, method linear or previous, and at most 10000 readings generated.")
+	case errors.Is(err, service.ErrInvalidWindow):
+		respondError(c, http.StatusBadRequest, "INVALID_WINDOW", "window must be an odd number between 3 and 51.")
+	case errors.Is(err, service.ErrInvalidSmoothing):
+		respondError(c, http.StatusBadRequest, "INVALID_SMOOTHING", err.Error())
+	case errors.Is(err, service.ErrInvalidAnomalyQuery):
+		respondError(c, http.StatusBadRequest, "INVALID_ANOMALY_QUERY", err.Error())
+	case errors.Is(err, service.ErrUnknownField):
+		respondError(c, http.StatusBadRequest, "INVALID_FIELDS", "fields must list some of pm25, co2, co, temperature, humidity.")
+	case errors.Is(err, service.ErrTooManyReadings):
+		respondError(c, http.StatusBadRequest, "TOO_MANY_READINGS", "The time range holds more than 10000 readings; narrow from and to.")
+	case errors.Is(err, service.ErrTooManyStatsReadings):
+		respondError(c, http.StatusBadRequest, "TOO_MANY_READINGS",
+			"Percentiles of more than 100000 readings need MongoDB 7.0 or later; narrow from and to.")
+	case errors.Is(err, service.ErrUnsupportedQuery):
+		respondError(c, http.StatusNotImplemented, "UNSUPPORTED_QUERY", "The storage backend of this server cannot run this query.")
+	case errors.Is(err, service.ErrGroupNotFound):
+		respondError(c, http.StatusNotFound, "GROUP_NOT_FOUND", "Group not found.")
+	case errors.Is(err, service.ErrInvalidGroup):
+		respondError(c, http.StatusBadRequest, "INVALID_GROUP", err.Error())
+	case errors.Is(err, service.ErrSilenceNotFound):
+		respondError(c, http.StatusNotFound, "SILENCE_NOT_FOUND", "Silence not found.")
+	case errors.Is(err, service.ErrInvalidSilence):
+		respondError(c, http.StatusBadRequest, "INVALID_SILENCE", err.Error())
+	case errors.Is(err, service.ErrInvalidSeverity):
+		respondError(c, http.StatusBadRequest, "INVALID_SEVERITY", "alert_severities may only contain warning and critical.")
+	case errors.Is(err, service.ErrInvalidPushApp):
+		respondError(c, http.StatusBadRequest, "INVALID_PUSH_APP", err.Error())
+	case errors.Is(err, service.ErrTransferNotFound):
+		respondError(c, http.StatusNotFound, "TRANSFER_NOT_FOUND", "Transfer not found.")
+	case errors.Is(err, service.ErrTransferExpired):
+		respondError(c, http.StatusGone, "TRANSFER_EXPIRED", "Transfer has expired.")
+	case errors.Is(err, service.ErrTransferInvalid):
+		respondError(c, http.StatusConflict, "TRANSFER_INVALID", "Transfer is no longer valid.")
+	case errors.Is(err, service.ErrTransferToSelf):
+		respondError(c, http.StatusBadRequest, "TRANSFER_TO_SELF", "Cannot transfer a device to its current owner.")
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(c.Request.Context().Err(), context.DeadlineExceeded):
+		// The deadline of middleware.Timeout passed mid-query.
+		log.Printf("api: %s %s: %v", c.Request.Method, c.FullPath(), err)
+		respondError(c, http.StatusGatewayTimeout, "TIMEOUT", "The request took too long.")
+	default:
+		log.Printf("api: %s %s: %v", c.Request.Method, c.FullPath(), err)
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error.")
+	}
+}
+
+// timeRangeParams documents the parameters read by parseTimeRange.
+var timeRangeParams = []openapi.Param{
+	{Name: "from", Description: "Start of the time range, RFC 3339."},
+	{Name: "to", Description: "End of the time range, RFC 3339."},
+}
+
+// parseTimeRange reads the optional RFC 3339 from/to query parameters.
+func parseTimeRange(c *gin.Context) (from, to time.Time, err error) {
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, errors.New("from must be an RFC 3339 timestamp")
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, errors.New("to must be an RFC 3339 timestamp")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return from, to, errors.New("to must not be before from")
+	}
+	return from, to, nil
+}
+
+// parseLocation reads the optional tz query parameter, an IANA timezone
+// name, UTC if it is empty. "Local" is refused: it would be the zone of
+// whichever server instance answers.
+func parseLocation(c *gin.Context) (*time.Location, bool) {
+	tz := c.Query("tz")
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		respondError(c, http.StatusBadRequest, "INVALID_TIMEZONE", "tz must be an IANA timezone name such as Europe/Berlin.")
+		return nil, false
+	}
+	return loc, true
+}
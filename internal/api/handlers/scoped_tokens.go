@@ -0,0 +1,92 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: scoped_tokens.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the REST handlers of device-scoped access tokens.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type ScopedTokenHandler struct {
+	tokens *service.ScopedTokenService
+}
+
+func NewScopedTokenHandler(tokens *service.ScopedTokenService) *ScopedTokenHandler {
+	return &ScopedTokenHandler{tokens: tokens}
+}
+
+// Docs implements openapi.Documented.
+func (h *ScopedTokenHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Create": {
+			Summary:     "Create a device-scoped token",
+			Description: "The token is a bearer token limited to operations on devices the caller owns, e.g. for a kiosk. It is only returned by this call.",
+			Body:        createScopedTokenRequest{},
+			Status:      http.StatusCreated,
+			Response:    service.IssuedScopedToken{},
+		},
+		"List":   {Summary: "List your device-scoped tokens", Response: dataResponse[[]models.ScopedToken]{}},
+		"Revoke": {Summary: "Revoke a device-scoped token", Status: http.StatusNoContent},
+	}
+}
+
+type createScopedTokenRequest struct {
+	Name      string   `json:"name"`
+	DeviceIDs []string `json:"device_ids" binding:"required"`
+	// Operations are read, control or both.
+	Operations []string `json:"operations" binding:"required"`
+	TTLSeconds int64    `json:"ttl_seconds" binding:"required"`
+}
+
+// Create handles POST /scoped-tokens. The signed token is only ever
+// returned by this call.
+func (h *ScopedTokenHandler) Create(c *gin.Context) {
+	var req createScopedTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "device_ids, operations and ttl_seconds are required.")
+		return
+	}
+	token, err := h.tokens.Create(c.Request.Context(), middleware.UserID(c), req.Name, req.DeviceIDs, req.Operations,
+		time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, token)
+}
+
+// List handles GET /scoped-tokens.
+func (h *ScopedTokenHandler) List(c *gin.Context) {
+	tokens, err := h.tokens.List(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tokens})
+}
+
+// Revoke handles DELETE /scoped-tokens/:tokenId, refusing the token at
+// once. The sessions of the owner are not affected.
+func (h *ScopedTokenHandler) Revoke(c *gin.Context) {
+	if err := h.tokens.Revoke(c.Request.Context(), middleware.UserID(c), c.Param("tokenId")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
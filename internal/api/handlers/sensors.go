@@ -0,0 +1,594 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensors.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers for sensor data in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/export"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+	"airsense-be.com/internal/utils"
+)
+
+const (
+	defaultSensorLimit = 100
+	maxSensorLimit     = 1000
+)
+
+type SensorHandler struct {
+	sensors   *service.SensorService
+	anomalies *service.AnomalyService
+	// Body limits in bytes of Ingest and BulkUpload.
+	ingestMaxBody int64
+	bulkMaxBody   int64
+}
+
+func NewSensorHandler(sensors *service.SensorService, anomalies *service.AnomalyService, cfg config.ServerConfig) *SensorHandler {
+	return &SensorHandler{sensors: sensors, anomalies: anomalies, ingestMaxBody: cfg.IngestMaxBody, bulkMaxBody: cfg.BulkMaxBody}
+}
+
+// Docs implements openapi.Documented.
+func (h *SensorHandler) Docs() openapi.Docs {
+	source := openapi.Param{Name: "source", Description: "mqtt, bulk_upload, manual, http, interpolated or seed."}
+	return openapi.Docs{
+		"List": {
+			Summary: "List the readings of a device",
+			Params: append([]openapi.Param{
+				source,
+				{Name: "category", Description: "AQI category, e.g. good or unhealthy."},
+				{Name: "limit", Type: "integer", Description: "1 to 1000, 100 by default."},
+				{Name: "min_confidence", Type: "number", Description: "0 to 1."},
+				{Name: "tag", Repeated: true, Description: "key:value; readings must have every tag."},
+				{Name: "tz", Description: "IANA timezone the timestamps are given in, UTC by default."},
+			}, timeRangeParams...),
+			Response: dataResponse[[]models.SensorData]{},
+		},
+		"Export": {
+			Summary:      "Export the readings of a device as NDJSON",
+			Description:  "Oldest first, one reading per line. Ranges of at most a week carry X-Total-Count.",
+			Params:       timeRangeParams,
+			ResponseType: "application/x-ndjson",
+		},
+		"ExportFile": {
+			Summary:      "Export the readings of a device as a file",
+			Params:       append([]openapi.Param{{Name: "format", Description: "parquet, the default and only format."}}, timeRangeParams...),
+			ResponseType: "application/octet-stream",
+		},
+		"Aggregate": {
+			Summary: "Aggregate the readings of a device",
+			Params: append([]openapi.Param{
+				{Name: "interval", Description: "hour, day (default), week or month."},
+				{Name: "tz", Description: "IANA timezone of the buckets."},
+				source,
+				{Name: "weight", Description: "confidence to weight readings by their confidence."},
+				{Name: "smooth", Type: "integer", Description: "Replace each average by the mean of the averages of this many buckets around it, from 2 to the number of buckets."},
+			}, timeRangeParams...),
+			Response: struct {
+				Data      []models.SensorAggregate `json:"data"`
+				Timezone  string                   `json:"timezone"`
+				UTCOffset string                   `json:"utc_offset"`
+			}{},
+		},
+		"Stats": {
+			Summary:     "Statistics of the readings of a device",
+			Description: "Count, mean, sample standard deviation, extremes and percentiles of each field, and the Pearson correlation of each pair of fields.",
+			Params: append([]openapi.Param{
+				{Name: "fields", Description: "Comma-separated sensors, all by default."},
+			}, timeRangeParams...),
+			Response: models.SensorStats{},
+		},
+		"Anomalies": {
+			Summary:     "Flag anomalous readings of a device",
+			Description: "Scores each value against the rolling mean and standard deviation of the window readings before it and returns, oldest first, those whose z-score exceeds z_threshold in absolute value. Nothing is stored.",
+			Params: append([]openapi.Param{
+				{Name: "fields", Description: "Comma-separated sensors, all by default."},
+				{Name: "z_threshold", Type: "number", Description: "ALERT_ANOMALY_K by default."},
+				{Name: "window", Type: "integer", Description: "2 to 1000 readings, ALERT_ANOMALY_WINDOW by default."},
+			}, timeRangeParams...),
+			Response: dataResponse[[]models.SensorAnomaly]{},
+		},
+		"Delete": {
+			Summary:     "Delete the readings of a device",
+			Description: "Without confirm=true, deleting many readings is refused with the number it would delete.",
+			Params:      append([]openapi.Param{{Name: "confirm", Type: "boolean"}}, timeRangeParams...),
+			Response: struct {
+				DeletedCount int64 `json:"deleted_count"`
+			}{},
+		},
+		"Interpolate": {
+			Summary: "Fill the gaps in the readings of a device",
+			Params: []openapi.Param{
+				{Name: "from", Required: true, Description: "Start of the time range, RFC 3339."},
+				{Name: "to", Required: true, Description: "End of the time range, RFC 3339."},
+				{Name: "interval", Description: "Expected time between readings, 1m by default."},
+				{Name: "method", Description: "linear (default) or previous."},
+				{Name: "max_gap", Type: "integer", Description: "Longest gap filled, in intervals; 10 by default."},
+			},
+			Response: service.InterpolateResult{},
+		},
+		"Smoothed": {
+			Summary: "Moving average of the readings of a device",
+			Params: append([]openapi.Param{
+				{Name: "window", Type: "integer", Description: "Odd number of readings from 3 to 51, 5 by default."},
+				{Name: "fields", Description: "Comma-separated sensors, all by default."},
+			}, timeRangeParams...),
+			Response: struct {
+				Data   []service.SmoothedPoint `json:"data"`
+				Window int                     `json:"window"`
+			}{},
+		},
+		"BulkUpload": {
+			Summary: "Upload readings of a device",
+			Body:    []models.SensorData{},
+			Status:  http.StatusCreated,
+			Response: struct {
+				Inserted int `json:"inserted"`
+			}{},
+		},
+		"Ingest": {
+			Summary:  "Post a reading as the device",
+			Body:     models.SensorData{},
+			Status:   http.StatusCreated,
+			Response: models.SensorData{},
+		},
+		"MergeTags": {Summary: "Add tags to a reading", Body: map[string]string{}, Response: models.SensorData{}},
+		"Score": {
+			Summary:     "Health score of the newest reading of a device",
+			Description: "PM2.5, CO2 and CO are each scored from 0 to 100, lower being worse; the score is the lowest of them, and limiting names the pollutants that set it.",
+			Response:    service.HealthScore{},
+		},
+	}
+}
+
+// List handles GET /devices/:id/sensors?source=&category=&from=&to=&limit=&min_confidence=&tag=key:value&tz=
+// The tag parameter may be repeated; readings must have all those tags.
+// Timestamps are stored in UTC and given in tz, with its offset at the
+// time, so daylight saving shows.
+func (h *SensorHandler) List(c *gin.Context) {
+	deviceID := c.Param("id")
+	filter := repository.SensorFilter{DeviceID: deviceID, Limit: defaultSensorLimit}
+	if v := c.Query("source"); v != "" {
+		filter.Source = models.DataSource(v)
+		if !filter.Source.Valid() {
+			respondError(c, http.StatusBadRequest, "INVALID_SOURCE", "source must be one of mqtt, bulk_upload, manual, http, interpolated, seed.")
+			return
+		}
+	}
+	if v := c.Query("category"); v != "" {
+		if !utils.ValidAQICategory(v) {
+			respondError(c, http.StatusBadRequest, "INVALID_CATEGORY", "category must be one of good, moderate, unhealthy_for_sensitive_groups, unhealthy, very_unhealthy, hazardous.")
+			return
+		}
+		filter.Category = v
+	}
+	var err error
+	if filter.From, filter.To, err = parseTimeRange(c); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", err.Error())
+		return
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 || n > maxSensorLimit {
+			respondError(c, http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 1000.")
+			return
+		}
+		filter.Limit = n
+	}
+	if v := c.Query("min_confidence"); v != "" {
+		minConfidence, err := strconv.ParseFloat(v, 64)
+		// NaN fails every comparison, so the range is checked as what
+		// passes rather than what fails.
+		if err != nil || !(minConfidence >= 0 && minConfidence <= 1) {
+			respondError(c, http.StatusBadRequest, "INVALID_MIN_CONFIDENCE", "min_confidence must be between 0 and 1.")
+			return
+		}
+		filter.MinConfidence = &minConfidence
+	}
+	for _, t := range c.QueryArray("tag") {
+		k, v, ok := strings.Cut(t, ":")
+		if !ok {
+			respondError(c, http.StatusBadRequest, "INVALID_QUERY", "tag must be key:value.")
+			return
+		}
+		if filter.Tags == nil {
+			filter.Tags = make(map[string]string)
+		}
+		filter.Tags[k] = v
+	}
+	if err := utils.ValidateSensorTags(filter.Tags); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_QUERY", err.Error())
+		return
+	}
+	loc, ok := parseLocation(c)
+	if !ok {
+		return
+	}
+
+	data, err := h.sensors.Query(c.Request.Context(), filter)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	for i := range data {
+		data[i].Timestamp = data[i].Timestamp.In(loc)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+const (
+	// exportCountSpan is the longest range whose readings are counted for
+	// X-Total-Count before an export.
+	exportCountSpan = 7 * 24 * time.Hour
+	// An export is flushed every exportFlushLines readings, or sooner when
+	// exportFlushInterval has passed, so consumers can process it as it
+	// arrives.
+	exportFlushLines    = 500
+	exportFlushInterval = time.Second
+)
+
+// Export handles GET /devices/:id/telemetry.ndjson?from=&to=, streaming the
+// readings of the range oldest first, one JSON object per line. Ranges of
+// at most a week carry X-Total-Count.
+func (h *SensorHandler) Export(c *gin.Context) {
+	filter := repository.SensorFilter{DeviceID: c.Param("id")}
+	var err error
+	if filter.From, filter.To, err = parseTimeRange(c); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", err.Error())
+		return
+	}
+	ctx := c.Request.Context()
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Sub(filter.From) <= exportCountSpan {
+		n, err := h.sensors.Count(ctx, filter)
+		if err != nil {
+			respondServiceError(c, err)
+			return
+		}
+		c.Header("X-Total-Count", strconv.FormatInt(n, 10))
+	}
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	lines, flushed := 0, time.Now()
+	err = h.sensors.Export(ctx, filter, func(d *models.SensorData) error {
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+		lines++
+		if lines%exportFlushLines == 0 || time.Since(flushed) >= exportFlushInterval {
+			w.Flush()
+			flushed = time.Now()
+		}
+		return nil
+	})
+	if err != nil {
+		if lines == 0 {
+			w.Header().Del("Content-Type")
+			respondServiceError(c, err)
+			return
+		}
+		// The status is sent; cutting the stream short is all that is left.
+		log.Printf("sensors: export of device %s stopped after %d readings: %v", filter.DeviceID, lines, err)
+		return
+	}
+	w.WriteHeaderNow()
+}
+
+// ExportFile handles GET /devices/:id/sensors/export?format=parquet&from=&to=,
+// streaming the readings of the range oldest first as a file download.
+// Parquet is the only format so far.
+func (h *SensorHandler) ExportFile(c *gin.Context) {
+	if format := c.DefaultQuery("format", "parquet"); format != "parquet" {
+		respondError(c, http.StatusBadRequest, "INVALID_FORMAT", "format must be parquet.")
+		return
+	}
+	filter := repository.SensorFilter{DeviceID: c.Param("id")}
+	var err error
+	if filter.From, filter.To, err = parseTimeRange(c); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", err.Error())
+		return
+	}
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="sensors.parquet"`)
+	pw := export.NewParquetWriter(w)
+	err = h.sensors.Export(c.Request.Context(), filter, pw.Write)
+	if err == nil {
+		err = pw.Close()
+	}
+	if err != nil {
+		if !w.Written() {
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Disposition")
+			respondServiceError(c, err)
+			return
+		}
+		// The file is cut short without its footer, which readers reject.
+		log.Printf("sensors: parquet export of device %s failed: %v", filter.DeviceID, err)
+	}
+}
+
+// Aggregate handles GET /devices/:id/sensors/aggregate?interval=&tz=&source=&from=&to=&weight=&smooth=
+// The response carries the resolved timezone and its current UTC offset;
+// each bucket time is also rendered with its own offset. With smooth, the
+// bucket averages are smoothed after bucketing, see service.SmoothBuckets.
+func (h *SensorHandler) Aggregate(c *gin.Context) {
+	filter := repository.SensorFilter{DeviceID: c.Param("id")}
+	if v := c.Query("source"); v != "" {
+		filter.Source = models.DataSource(v)
+		if !filter.Source.Valid() {
+			respondError(c, http.StatusBadRequest, "INVALID_SOURCE", "source must be one of mqtt, bulk_upload, manual, http, interpolated, seed.")
+			return
+		}
+	}
+	var err error
+	if filter.From, filter.To, err = parseTimeRange(c); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", err.Error())
+		return
+	}
+
+	weight := c.Query("weight")
+	if weight != "" && weight != "confidence" {
+		respondError(c, http.StatusBadRequest, "INVALID_WEIGHT", "weight must be confidence or omitted.")
+		return
+	}
+	smooth := 0
+	if v := c.Query("smooth"); v != "" {
+		if smooth, err = strconv.Atoi(v); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_SMOOTHING", "smooth must be a number of buckets.")
+			return
+		}
+	}
+
+	buckets, loc, err := h.sensors.Aggregate(c.Request.Context(), filter, c.DefaultQuery("interval", "day"), c.Query("tz"), weight == "confidence")
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if c.Query("smooth") != "" {
+		if err := service.SmoothBuckets(buckets, smooth); err != nil {
+			respondServiceError(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":       buckets,
+		"timezone":   loc.String(),
+		"utc_offset": time.Now().In(loc).Format("-07:00"),
+	})
+}
+
+// Stats handles GET /devices/:id/sensors/stats?from=&to=&fields=pm25,co2
+func (h *SensorHandler) Stats(c *gin.Context) {
+	filter := repository.SensorFilter{DeviceID: c.Param("id")}
+	var err error
+	if filter.From, filter.To, err = parseTimeRange(c); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", err.Error())
+		return
+	}
+	var fields []string
+	if v := c.Query("fields"); v != "" {
+		fields = strings.Split(v, ",")
+	}
+
+	stats, err := h.sensors.Stats(c.Request.Context(), filter, fields)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// Anomalies handles GET /devices/:id/sensors/anomalies?from=&to=&z_threshold=3.0&window=60&fields=pm25
+func (h *SensorHandler) Anomalies(c *gin.Context) {
+	req := service.AnomalyRequest{DeviceID: c.Param("id")}
+	var err error
+	if req.From, req.To, err = parseTimeRange(c); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", err.Error())
+		return
+	}
+	if v := c.Query("fields"); v != "" {
+		req.Fields = strings.Split(v, ",")
+	}
+	if v := c.Query("z_threshold"); v != "" {
+		if req.ZThreshold, err = strconv.ParseFloat(v, 64); err != nil || req.ZThreshold <= 0 {
+			respondError(c, http.StatusBadRequest, "INVALID_ANOMALY_QUERY", "z_threshold must be a positive number.")
+			return
+		}
+	}
+	if v := c.Query("window"); v != "" {
+		if req.Window, err = strconv.Atoi(v); err != nil || req.Window < 2 || req.Window > service.MaxAnomalyWindow {
+			respondError(c, http.StatusBadRequest, "INVALID_ANOMALY_QUERY",
+				fmt.Sprintf("window must be an integer between 2 and %d.", service.MaxAnomalyWindow))
+			return
+		}
+	}
+
+	anomalies, err := h.anomalies.Scan(c.Request.Context(), req)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": anomalies})
+}
+
+// Score handles GET /devices/:id/score.
+func (h *SensorHandler) Score(c *gin.Context) {
+	score, err := h.sensors.Score(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, score)
+}
+
+// Delete handles DELETE /devices/:id/sensors?from=&to=&confirm=, erasing
+// every reading of the device in the optional time range.
+func (h *SensorHandler) Delete(c *gin.Context) {
+	filter := repository.SensorFilter{DeviceID: c.Param("id")}
+	var err error
+	if filter.From, filter.To, err = parseTimeRange(c); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", err.Error())
+		return
+	}
+
+	deleted, err := h.sensors.Delete(c.Request.Context(), filter, c.Query("confirm") == "true")
+	if errors.Is(err, service.ErrConfirmationRequired) {
+		respondError(c, http.StatusBadRequest, "CONFIRMATION_REQUIRED",
+			fmt.Sprintf("This would delete %d readings; repeat with confirm=true.", deleted))
+		return
+	}
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted_count": deleted})
+}
+
+// Interpolate handles POST /devices/:id/sensors/interpolate?from=&to=&interval=1m&method=linear&max_gap=10
+func (h *SensorHandler) Interpolate(c *gin.Context) {
+	req := service.InterpolateRequest{
+		DeviceID: c.Param("id"),
+		Interval: time.Minute,
+		MaxGap:   10,
+		Method:   c.DefaultQuery("method", service.InterpolateLinear),
+	}
+	var err error
+	if req.From, req.To, err = parseTimeRange(c); err != nil || req.From.IsZero() || req.To.IsZero() {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", "from and to must be RFC 3339 timestamps.")
+		return
+	}
+	if v := c.Query("interval"); v != "" {
+		if req.Interval, err = time.ParseDuration(v); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_INTERVAL", "interval must be a duration such as 1m.")
+			return
+		}
+	}
+	if v := c.Query("max_gap"); v != "" {
+		if req.MaxGap, err = strconv.Atoi(v); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_INTERPOLATION", "max_gap must be a number of intervals.")
+			return
+		}
+	}
+
+	res, err := h.sensors.Interpolate(c.Request.Context(), req)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Smoothed handles GET /devices/:id/sensors/smoothed?from=&to=&window=5&fields=pm25,co2
+func (h *SensorHandler) Smoothed(c *gin.Context) {
+	req := service.SmoothRequest{
+		DeviceID: c.Param("id"),
+		Window:   5,
+		Fields:   strings.Split(c.DefaultQuery("fields", "pm25,co2,co,temperature,humidity"), ","),
+	}
+	var err error
+	if req.From, req.To, err = parseTimeRange(c); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_TIME_RANGE", err.Error())
+		return
+	}
+	if v := c.Query("window"); v != "" {
+		if req.Window, err = strconv.Atoi(v); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_WINDOW", "window must be an odd number between 3 and 51.")
+			return
+		}
+	}
+
+	points, err := h.sensors.Smooth(c.Request.Context(), req)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": points, "window": req.Window})
+}
+
+// BulkUpload handles POST /devices/:id/sensors/bulk with a JSON array of readings.
+func (h *SensorHandler) BulkUpload(c *gin.Context) {
+	deviceID := c.Param("id")
+	var readings []*models.SensorData
+	if !decodeBody(c, h.bulkMaxBody, &readings) {
+		return
+	}
+	if len(readings) == 0 {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "At least one reading is required.")
+		return
+	}
+
+	if err := h.sensors.IngestBulk(c.Request.Context(), deviceID, readings); err != nil {
+		if errors.Is(err, utils.ErrInvalidSensorData) {
+			respondError(c, http.StatusBadRequest, "INVALID_SENSOR_DATA", err.Error())
+			return
+		}
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"inserted": len(readings)})
+}
+
+// Ingest handles POST /devices/:id/telemetry, a single reading posted by
+// the device itself with its API key.
+func (h *SensorHandler) Ingest(c *gin.Context) {
+	var data models.SensorData
+	if !decodeBody(c, h.ingestMaxBody, &data) {
+		return
+	}
+	data.ResetServerFields()
+	data.DeviceID = c.Param("id")
+	data.Source = models.SourceHTTP
+	if err := h.sensors.Ingest(c.Request.Context(), &data); err != nil {
+		if errors.Is(err, utils.ErrInvalidSensorData) {
+			respondError(c, http.StatusBadRequest, "INVALID_SENSOR_DATA", err.Error())
+			return
+		}
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, data)
+}
+
+// MergeTags handles PATCH /devices/:id/sensors/:readingId/tags with a JSON
+// object of tags, which are added to those of the reading.
+func (h *SensorHandler) MergeTags(c *gin.Context) {
+	var tags map[string]string
+	if err := c.ShouldBindJSON(&tags); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "Body must be an object of string tags.")
+		return
+	}
+	data, err := h.sensors.MergeTags(c.Request.Context(), c.Param("id"), c.Param("readingId"), tags)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidSensorData) {
+			respondError(c, http.StatusBadRequest, "INVALID_SENSOR_DATA", err.Error())
+			return
+		}
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
@@ -0,0 +1,405 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensors_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the query parameters of listing readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/events"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+)
+
+// foundReadings returns its readings from Find and keeps the filter it
+// was given.
+type foundReadings struct {
+	repository.SensorDataRepository
+	readings []models.SensorData
+	filter   *repository.SensorFilter
+}
+
+func (r *foundReadings) Find(_ context.Context, filter repository.SensorFilter) ([]models.SensorData, error) {
+	r.filter = &filter
+	out := make([]models.SensorData, len(r.readings))
+	copy(out, r.readings)
+	return out, nil
+}
+
+// listResponse is the body of a list, or of an error.
+type listResponse struct {
+	Data []models.SensorData `json:"data"`
+	Code string              `json:"code"`
+}
+
+// listReadings serves GET /devices/d1/sensors with query and decodes the
+// response.
+func listReadings(t *testing.T, repo *foundReadings, query string) (int, listResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := &SensorHandler{sensors: service.NewSensorService(repo, nil, nil, nil, nil, nil, nil, nil, nil)}
+	r := gin.New()
+	r.GET("/devices/:id/sensors", h.List)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/devices/d1/sensors?"+query, nil))
+	var body listResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	return w.Code, body
+}
+
+func TestSensorListMinConfidence(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"0", 0, true},
+		{"0.5", 0.5, true},
+		{"1", 1, true},
+		{"-0.1", 0, false},
+		{"1.5", 0, false},
+		{"NaN", 0, false},
+		{"nan", 0, false},
+		{"Inf", 0, false},
+		{"-Inf", 0, false},
+		{"+Infinity", 0, false},
+		{"high", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			repo := &foundReadings{}
+			code, body := listReadings(t, repo, "min_confidence="+tt.value)
+			if !tt.ok {
+				if code != http.StatusBadRequest || body.Code != "INVALID_MIN_CONFIDENCE" {
+					t.Errorf("got %d %q, want 400 INVALID_MIN_CONFIDENCE", code, body.Code)
+				}
+				if repo.filter != nil {
+					t.Error("queried the readings")
+				}
+				return
+			}
+			if code != http.StatusOK {
+				t.Fatalf("got %d %q, want 200", code, body.Code)
+			}
+			if got := repo.filter.MinConfidence; got == nil || *got != tt.want {
+				t.Errorf("MinConfidence = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSensorListSource(t *testing.T) {
+	tests := []struct {
+		query string
+		want  models.DataSource
+		ok    bool
+	}{
+		{"", "", true},
+		{"source=mqtt", models.SourceMQTT, true},
+		{"source=http", models.SourceHTTP, true},
+		{"source=bulk_upload", models.SourceBulkUpload, true},
+		{"source=seed", models.SourceSeed, true},
+		{"source=MQTT", "", false},
+		{"source=satellite", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			repo := &foundReadings{}
+			code, body := listReadings(t, repo, tt.query)
+			if !tt.ok {
+				if code != http.StatusBadRequest || body.Code != "INVALID_SOURCE" {
+					t.Errorf("got %d %q, want 400 INVALID_SOURCE", code, body.Code)
+				}
+				if repo.filter != nil {
+					t.Error("queried the readings")
+				}
+				return
+			}
+			if code != http.StatusOK {
+				t.Fatalf("got %d %q, want 200", code, body.Code)
+			}
+			if repo.filter.Source != tt.want {
+				t.Errorf("Source = %q, want %q", repo.filter.Source, tt.want)
+			}
+		})
+	}
+}
+
+// slowReadings is a database that answers no query before its context
+// ends.
+type slowReadings struct {
+	repository.SensorDataRepository
+}
+
+func (slowReadings) Find(ctx context.Context, _ repository.SensorFilter) ([]models.SensorData, error) {
+	<-ctx.Done()
+	return nil, fmt.Errorf("find readings: %w", ctx.Err())
+}
+
+func TestSensorListTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &SensorHandler{sensors: service.NewSensorService(slowReadings{}, nil, nil, nil, nil, nil, nil, nil, nil)}
+	r := gin.New()
+	r.Use(middleware.Timeout(20 * time.Millisecond))
+	r.GET("/devices/:id/sensors", h.List)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/devices/d1/sensors", nil))
+	var body listResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if w.Code != http.StatusGatewayTimeout || body.Code != "TIMEOUT" {
+		t.Errorf("got %d %q, want 504 TIMEOUT", w.Code, body.Code)
+	}
+}
+
+func TestSensorListTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  map[string]string
+		ok    bool
+	}{
+		{"one tag", "tag=event:cooking", map[string]string{"event": "cooking"}, true},
+		{"all of several tags", "tag=event:cooking&tag=windows:closed", map[string]string{"event": "cooking", "windows": "closed"}, true},
+		{"colon in the value", "tag=time:12:30", map[string]string{"time": "12:30"}, true},
+		{"no colon", "tag=event", nil, false},
+		{"bad key", "tag=ev.ent:cooking", nil, false},
+		{"empty value", "tag=event:", nil, false},
+		{"value over 256 characters", "tag=note:" + strings.Repeat("a", 257), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &foundReadings{}
+			code, body := listReadings(t, repo, tt.query)
+			if !tt.ok {
+				if code != http.StatusBadRequest || body.Code != "INVALID_QUERY" {
+					t.Errorf("got %d %q, want 400 INVALID_QUERY", code, body.Code)
+				}
+				return
+			}
+			if code != http.StatusOK {
+				t.Fatalf("got %d %q, want 200", code, body.Code)
+			}
+			if !maps.Equal(repo.filter.Tags, tt.want) {
+				t.Errorf("Tags = %v, want %v", repo.filter.Tags, tt.want)
+			}
+		})
+	}
+}
+
+func TestSensorListTimezone(t *testing.T) {
+	at := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	tests := []struct {
+		name  string
+		tz    string
+		times []string
+		want  []string
+	}{
+		{"UTC by default", "", []string{"2026-03-29T00:30:00Z"}, []string{"2026-03-29T00:30:00Z"}},
+		{"clocks go forward", "Europe/Berlin",
+			[]string{"2026-03-29T00:30:00Z", "2026-03-29T01:30:00Z"},
+			[]string{"2026-03-29T01:30:00+01:00", "2026-03-29T03:30:00+02:00"}},
+		{"clocks go back to the same local time", "Europe/Berlin",
+			[]string{"2026-10-25T00:30:00Z", "2026-10-25T01:30:00Z"},
+			[]string{"2026-10-25T02:30:00+02:00", "2026-10-25T02:30:00+01:00"}},
+		{"west of UTC", "America/New_York",
+			[]string{"2026-03-08T06:30:00Z", "2026-03-08T07:30:00Z"},
+			[]string{"2026-03-08T01:30:00-05:00", "2026-03-08T03:30:00-04:00"}},
+		{"no daylight saving", "Asia/Kolkata", []string{"2026-10-25T01:30:00Z"}, []string{"2026-10-25T07:00:00+05:30"}},
+		{"unknown zone", "Mars/Olympus_Mons", nil, nil},
+		{"server zone", "Local", nil, nil},
+		{"path", "../../etc/passwd", nil, nil},
+		{"offset", "+02:00", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &foundReadings{}
+			for _, s := range tt.times {
+				repo.readings = append(repo.readings, models.SensorData{DeviceID: "d1", Timestamp: at(s)})
+			}
+			code, body := listReadings(t, repo, "tz="+url.QueryEscape(tt.tz))
+			if tt.want == nil {
+				if code != http.StatusBadRequest || body.Code != "INVALID_TIMEZONE" {
+					t.Errorf("got %d %q, want 400 INVALID_TIMEZONE", code, body.Code)
+				}
+				if repo.filter != nil {
+					t.Error("queried the readings")
+				}
+				return
+			}
+			if code != http.StatusOK {
+				t.Fatalf("got %d %q, want 200", code, body.Code)
+			}
+			if len(body.Data) != len(tt.want) {
+				t.Fatalf("got %d readings, want %d", len(body.Data), len(tt.want))
+			}
+			for i, d := range body.Data {
+				if got := d.Timestamp.Format(time.RFC3339); got != tt.want[i] {
+					t.Errorf("reading %d at %s, want %s", i, got, tt.want[i])
+				}
+				if !d.Timestamp.Equal(at(tt.times[i])) {
+					t.Errorf("reading %d moved to %s", i, d.Timestamp.UTC())
+				}
+			}
+		})
+	}
+}
+
+// insertedReadings keeps the readings inserted into it.
+type insertedReadings struct {
+	repository.SensorDataRepository
+	readings []models.SensorData
+}
+
+func (r *insertedReadings) Insert(_ context.Context, d *models.SensorData) error {
+	r.readings = append(r.readings, *d)
+	return nil
+}
+
+func (r *insertedReadings) InsertMany(_ context.Context, data []*models.SensorData) error {
+	for _, d := range data {
+		r.readings = append(r.readings, *d)
+	}
+	return nil
+}
+
+// onlineDevice is device d1, online.
+type onlineDevice struct {
+	repository.DeviceRepository
+}
+
+func (onlineDevice) GetByID(_ context.Context, id string) (*models.Device, error) {
+	if id != "d1" {
+		return nil, repository.ErrNotFound
+	}
+	return &models.Device{ID: id, Status: models.DeviceOnline}, nil
+}
+
+func (onlineDevice) Touch(context.Context, string, time.Time) error { return nil }
+
+// TestSensorIngestServerFields checks that readings posted by clients
+// cannot set the fields the server manages.
+func TestSensorIngestServerFields(t *testing.T) {
+	reading := fmt.Sprintf(`{"id": "r-chosen", "device_id": "d2", "timestamp": %q,
+		"sensors": {"pm25": {"value": 12, "unit": "µg/m³"}, "co2": {"value": 400, "unit": "ppm"},
+			"co": {"value": 0.5, "unit": "ppm"}, "temperature": {"value": 21, "unit": "°C"}, "humidity": {"value": 40, "unit": "%%"}},
+		"source": "interpolated", "schema_version": 7, "aqi": 999, "aqi_category": "hazardous",
+		"quality": "good", "prior_owner_id": "u-former"}`, time.Now().UTC().Add(-time.Minute).Format(time.RFC3339))
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantSource models.DataSource
+	}{
+		{"telemetry", "/devices/d1/telemetry", reading, models.SourceHTTP},
+		{"bulk", "/devices/d1/sensors/bulk", "[" + reading + "]", models.SourceBulkUpload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			repo := &insertedReadings{}
+			h := &SensorHandler{
+				sensors:       service.NewSensorService(repo, nil, onlineDevice{}, nil, nil, nil, nil, cache.NewLatestCache(), events.NewHub()),
+				ingestMaxBody: 64 << 10,
+				bulkMaxBody:   64 << 10,
+			}
+			r := gin.New()
+			r.POST("/devices/:id/telemetry", h.Ingest)
+			r.POST("/devices/:id/sensors/bulk", h.BulkUpload)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			if w.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+			}
+			if len(repo.readings) != 1 {
+				t.Fatalf("stored %d readings, want 1", len(repo.readings))
+			}
+			got := repo.readings[0]
+			if got.ID == "r-chosen" {
+				t.Error("stored the id of the client")
+			}
+			if got.PriorOwnerID != "" {
+				t.Errorf("prior_owner_id = %q, want none", got.PriorOwnerID)
+			}
+			if got.DeviceID != "d1" || got.Source != tt.wantSource || got.SchemaVersion != 0 {
+				t.Errorf("device %q, source %q, schema version %d; want d1, %q, 0", got.DeviceID, got.Source, got.SchemaVersion, tt.wantSource)
+			}
+			if got.AQI == 999 || got.AQICategory == "hazardous" || got.Quality != "" {
+				t.Errorf("aqi %d %q, quality %q came from the client", got.AQI, got.AQICategory, got.Quality)
+			}
+		})
+	}
+}
+
+func TestSensorAnomaliesWindow(t *testing.T) {
+	tests := []struct {
+		window   string
+		wantCode int
+	}{
+		{"", http.StatusOK},
+		{"2", http.StatusOK},
+		{"1000", http.StatusOK},
+		{"1", http.StatusBadRequest},
+		{"1001", http.StatusBadRequest},
+		{"100000000", http.StatusBadRequest},
+		{"-5", http.StatusBadRequest},
+		{"ten", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.window, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			repo := &foundReadings{}
+			h := &SensorHandler{anomalies: service.NewAnomalyService(repo, config.AlertConfig{AnomalyWindow: 60, AnomalyK: 3})}
+			r := gin.New()
+			r.GET("/devices/:id/sensors/anomalies", h.Anomalies)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/devices/d1/sensors/anomalies?window="+tt.window, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if scanned := repo.filter != nil; scanned != (tt.wantCode == http.StatusOK) {
+				t.Errorf("scanned = %v, want %v", scanned, tt.wantCode == http.StatusOK)
+			}
+			if tt.wantCode != http.StatusBadRequest {
+				return
+			}
+			// The handler answers before the service is asked.
+			want := `{"code":"INVALID_ANOMALY_QUERY","message":"window must be an integer between 2 and 1000."}`
+			if w.Body.String() != want {
+				t.Errorf("body = %s, want %s", w.Body, want)
+			}
+		})
+	}
+}
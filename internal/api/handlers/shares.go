@@ -0,0 +1,88 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: shares.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers for device sharing in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type ShareHandler struct {
+	shares *service.ShareService
+}
+
+func NewShareHandler(shares *service.ShareService) *ShareHandler {
+	return &ShareHandler{shares: shares}
+}
+
+// Docs implements openapi.Documented.
+func (h *ShareHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Create": {
+			Summary:     "Share a device with a user",
+			Description: "permission is read by default.",
+			Body:        createShareRequest{},
+			Status:      http.StatusCreated,
+			Response:    models.DeviceShare{},
+		},
+		"List":   {Summary: "List the shares of a device", Response: dataResponse[[]models.DeviceShare]{}},
+		"Revoke": {Summary: "Revoke a share", Status: http.StatusNoContent},
+	}
+}
+
+type createShareRequest struct {
+	Email      string                 `json:"email" binding:"required,email"`
+	Permission models.SharePermission `json:"permission"`
+}
+
+// Create handles POST /devices/:id/shares. Permission defaults to read.
+func (h *ShareHandler) Create(c *gin.Context) {
+	var req createShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "email must be a valid email address.")
+		return
+	}
+	if req.Permission == "" {
+		req.Permission = models.ShareRead
+	}
+	share, err := h.shares.Create(c.Request.Context(), middleware.Device(c), req.Email, req.Permission)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, share)
+}
+
+// List handles GET /devices/:id/shares.
+func (h *ShareHandler) List(c *gin.Context) {
+	shares, err := h.shares.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shares})
+}
+
+// Revoke handles DELETE /devices/:id/shares/:shareId.
+func (h *ShareHandler) Revoke(c *gin.Context) {
+	if err := h.shares.Revoke(c.Request.Context(), c.Param("id"), c.Param("shareId")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
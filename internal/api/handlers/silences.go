@@ -0,0 +1,113 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: silences.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers of alert silences.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type SilenceHandler struct {
+	silences *service.SilenceService
+}
+
+func NewSilenceHandler(silences *service.SilenceService) *SilenceHandler {
+	return &SilenceHandler{silences: silences}
+}
+
+// Docs implements openapi.Documented.
+func (h *SilenceHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Create": {Summary: "Silence alerts of a device", Body: silenceRequest{}, Status: http.StatusCreated, Response: models.Silence{}},
+		"List":   {Summary: "List the silences of a device", Response: dataResponse[[]models.Silence]{}},
+		"Get":    {Summary: "Get a silence", Response: models.Silence{}},
+		"Update": {Summary: "Replace a silence", Body: silenceRequest{}, Response: models.Silence{}},
+		"Delete": {Summary: "Delete a silence", Status: http.StatusNoContent},
+	}
+}
+
+type silenceRequest struct {
+	AlertIDs []string  `json:"alert_ids"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason"`
+}
+
+func (r silenceRequest) spec() service.SilenceSpec {
+	return service.SilenceSpec{AlertIDs: r.AlertIDs, StartsAt: r.StartsAt, EndsAt: r.EndsAt, Reason: r.Reason}
+}
+
+// Create handles POST /devices/:id/silences.
+func (h *SilenceHandler) Create(c *gin.Context) {
+	var req silenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "ends_at is required and times must be RFC 3339.")
+		return
+	}
+	silence, err := h.silences.Create(c.Request.Context(), c.Param("id"), middleware.UserID(c), req.spec())
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, silence)
+}
+
+// List handles GET /devices/:id/silences.
+func (h *SilenceHandler) List(c *gin.Context) {
+	silences, err := h.silences.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": silences})
+}
+
+// Get handles GET /devices/:id/silences/:silenceId.
+func (h *SilenceHandler) Get(c *gin.Context) {
+	silence, err := h.silences.Get(c.Request.Context(), c.Param("id"), c.Param("silenceId"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, silence)
+}
+
+// Update handles PUT /devices/:id/silences/:silenceId.
+func (h *SilenceHandler) Update(c *gin.Context) {
+	var req silenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "ends_at is required and times must be RFC 3339.")
+		return
+	}
+	silence, err := h.silences.Update(c.Request.Context(), c.Param("id"), c.Param("silenceId"), req.spec())
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, silence)
+}
+
+// Delete handles DELETE /devices/:id/silences/:silenceId.
+func (h *SilenceHandler) Delete(c *gin.Context) {
+	if err := h.silences.Delete(c.Request.Context(), c.Param("id"), c.Param("silenceId")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
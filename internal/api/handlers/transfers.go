@@ -0,0 +1,119 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: transfers.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the REST handlers for device ownership transfers in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type TransferHandler struct {
+	transfers *service.TransferService
+}
+
+func NewTransferHandler(transfers *service.TransferService) *TransferHandler {
+	return &TransferHandler{transfers: transfers}
+}
+
+// Docs implements openapi.Documented.
+func (h *TransferHandler) Docs() openapi.Docs {
+	return openapi.Docs{
+		"Initiate": {
+			Summary:     "Offer a device to another user",
+			Description: "The token, which the recipient accepts the transfer with, is only returned by this call.",
+			Body:        initiateTransferRequest{},
+			Status:      http.StatusCreated,
+			Response: struct {
+				Transfer *models.DeviceTransfer `json:"transfer"`
+				Token    string                 `json:"token"`
+			}{},
+		},
+		"Transfer": {
+			Summary:     "Give a device to another user",
+			Description: "The device moves at once, without acceptance. Historical readings stay with it, tagged with the previous owner, unless keep_history is false.",
+			Body:        transferRequest{},
+			Response:    models.DeviceTransfer{},
+		},
+		"Accept": {Summary: "Accept a device transfer", Body: acceptTransferRequest{}, Response: models.DeviceTransfer{}},
+	}
+}
+
+type initiateTransferRequest struct {
+	ToEmail     string `json:"to_email" binding:"required,email"`
+	KeepHistory *bool  `json:"keep_history"`
+}
+
+// Initiate handles POST /devices/:id/transfers. Historical sensor data stays
+// with the device unless keep_history is explicitly false.
+func (h *TransferHandler) Initiate(c *gin.Context) {
+	var req initiateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "to_email must be a valid email address.")
+		return
+	}
+	keepHistory := req.KeepHistory == nil || *req.KeepHistory
+
+	t, token, err := h.transfers.Initiate(c.Request.Context(), c.Param("id"), middleware.UserID(c), req.ToEmail, keepHistory)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"transfer": t, "token": token})
+}
+
+type transferRequest struct {
+	ToUserID    string `json:"to_user_id" binding:"required"`
+	KeepHistory *bool  `json:"keep_history"`
+}
+
+// Transfer handles POST /devices/:id/transfer. Historical sensor data stays
+// with the device unless keep_history is explicitly false.
+func (h *TransferHandler) Transfer(c *gin.Context) {
+	var req transferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "to_user_id is required.")
+		return
+	}
+	keepHistory := req.KeepHistory == nil || *req.KeepHistory
+
+	t, err := h.transfers.Transfer(c.Request.Context(), c.Param("id"), middleware.UserID(c), req.ToUserID, keepHistory)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+type acceptTransferRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Accept handles POST /transfers/accept.
+func (h *TransferHandler) Accept(c *gin.Context) {
+	var req acceptTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_BODY", "token is required.")
+		return
+	}
+	t, err := h.transfers.Accept(c.Request.Context(), req.Token, middleware.UserID(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
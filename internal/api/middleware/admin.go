@@ -0,0 +1,88 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: admin.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the admin-only middleware of the AirSense REST API.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// RequireRole only lets users with role through; everybody else gets 403
+// FORBIDDEN. The role is read from the user record on every request, not
+// from the role claim of the token, so granting or revoking it takes
+// effect at once, whatever tokens the user holds.
+func RequireRole(users repository.UserRepository, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, err := HasRole(c, users, role)
+		if err != nil {
+			abortInternal(c, err)
+			return
+		}
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": "FORBIDDEN", "message": fmt.Sprintf("Role %s required.", role)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Admin is RequireRole(users, models.RoleAdmin).
+func Admin(users repository.UserRepository) gin.HandlerFunc {
+	return RequireRole(users, models.RoleAdmin)
+}
+
+// AdminOr lets admins through and runs otherwise for everybody else, e.g.
+// a DeviceAccess check. Handlers behind it must not rely on Device(c).
+func AdminOr(users repository.UserRepository, otherwise gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin, err := IsAdmin(c, users)
+		if err != nil {
+			abortInternal(c, err)
+			return
+		}
+		if admin {
+			c.Next()
+			return
+		}
+		otherwise(c)
+	}
+}
+
+// IsAdmin reports whether the authenticated user has the admin role.
+func IsAdmin(c *gin.Context, users repository.UserRepository) (bool, error) {
+	return HasRole(c, users, models.RoleAdmin)
+}
+
+// HasRole reports whether the user record of the authenticated user has
+// role.
+func HasRole(c *gin.Context, users repository.UserRepository, role string) (bool, error) {
+	u, err := users.GetByID(c.Request.Context(), UserID(c))
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return u.Role == role, nil
+}
+
+func abortInternal(c *gin.Context, err error) {
+	log.Printf("api: load user %s: %v", UserID(c), err)
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"code": "INTERNAL_ERROR", "message": "Internal server error."})
+}
@@ -0,0 +1,148 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: admin_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the admin-only middleware.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+	"airsense-be.com/internal/utils"
+)
+
+type roleUsers struct {
+	repository.UserRepository
+	roles map[string]string
+}
+
+func (f *roleUsers) GetByID(_ context.Context, id string) (*models.User, error) {
+	role, ok := f.roles[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &models.User{ID: id, Role: role}, nil
+}
+
+// serve runs handlers for a request of userID and returns the response.
+func serve(userID string, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", append([]gin.HandlerFunc{func(c *gin.Context) { c.Set(userIDKey, userID) }}, handlers...)...)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func ok(c *gin.Context) { c.Status(http.StatusNoContent) }
+
+func TestRequireRole(t *testing.T) {
+	users := &roleUsers{roles: map[string]string{"admin": models.RoleAdmin, "user": ""}}
+	tests := []struct {
+		name   string
+		userID string
+		want   int
+	}{
+		{"admin", "admin", http.StatusNoContent},
+		{"user", "user", http.StatusForbidden},
+		{"unknown user", "ghost", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.userID, Admin(users), ok)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusForbidden && !strings.Contains(rec.Body.String(), `"code":"FORBIDDEN"`) {
+				t.Errorf("body = %s, want code FORBIDDEN", rec.Body)
+			}
+		})
+	}
+}
+
+func TestAdminOr(t *testing.T) {
+	users := &roleUsers{roles: map[string]string{"admin": models.RoleAdmin, "owner": "", "other": ""}}
+	// ownerOnly stands in for the DeviceAccess check of the device owner.
+	ownerOnly := func(c *gin.Context) {
+		if UserID(c) != "owner" {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	}
+	tests := []struct {
+		name   string
+		userID string
+		want   int
+	}{
+		{"admin skips the check", "admin", http.StatusNoContent},
+		{"owner passes the check", "owner", http.StatusNoContent},
+		{"others fail the check", "other", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serve(tt.userID, AdminOr(users, ownerOnly), ok); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// TestRequireRoleTokens checks admin routes behind Auth with both shapes
+// of access token: with the role claim, and without one as issued before
+// roles existed. Only the user record counts.
+func TestRequireRoleTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := &roleUsers{roles: map[string]string{"admin": models.RoleAdmin, "demoted": "", "user": ""}}
+	sessions := service.NewSessionService(users, nil, config.JWTConfig{SessionCacheTTL: time.Minute})
+	r := gin.New()
+	r.GET("/admin/devices", Auth(testSecret, nil, sessions), Admin(users), ok)
+
+	tests := []struct {
+		name   string
+		userID string
+		claim  string
+		want   int
+	}{
+		{"admin with role claim", "admin", models.RoleAdmin, http.StatusNoContent},
+		{"admin without role claim", "admin", "", http.StatusNoContent},
+		{"demoted admin with stale claim", "demoted", models.RoleAdmin, http.StatusForbidden},
+		{"user with user claim", "user", "user", http.StatusForbidden},
+		{"user without role claim", "user", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := utils.GenerateToken(tt.userID, tt.claim, testSecret, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/admin/devices", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusForbidden && rec.Body.String() != `{"code":"FORBIDDEN","message":"Role admin required."}` {
+				t.Errorf("body = %s", rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: audit.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the middleware writing the audit log of a request.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/audit"
+)
+
+// auditWriteTimeout bounds the write of the audit entries of a request.
+const auditWriteTimeout = 5 * time.Second
+
+// Audit collects the audit entries the services record while handling the
+// request and writes them, as done by the authenticated user, once the
+// handler returned. It goes after the authentication. The entry is written
+// even when the request timed out or the client left, since the change is
+// made by then; a failed write does not change the response.
+func Audit(log *audit.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, entries := audit.Collect(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditWriteTimeout)
+		defer cancel()
+		log.Write(ctx, UserID(c), entries.Entries())
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: audit_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the audit log middleware.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type auditEntries struct {
+	repository.AuditRepository
+	written []models.AuditEntry
+	// live tells whether the context of each write was still usable.
+	live []bool
+}
+
+func (r *auditEntries) CreateMany(ctx context.Context, entries []models.AuditEntry) error {
+	r.written = append(r.written, entries...)
+	r.live = append(r.live, ctx.Err() == nil)
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+	record := func(c *gin.Context) {
+		audit.Record(c.Request.Context(), audit.ActionDeviceUpdate, audit.ResourceDevice, "d1", nil)
+	}
+	tests := []struct {
+		name     string
+		handlers []gin.HandlerFunc
+		want     int
+	}{
+		{name: "change recorded", handlers: []gin.HandlerFunc{record, ok}, want: 1},
+		{name: "no change", handlers: []gin.HandlerFunc{ok}, want: 0},
+		{
+			// The change is made by the time the request fails.
+			name:     "request failed after the change",
+			handlers: []gin.HandlerFunc{record, func(c *gin.Context) { c.AbortWithStatus(http.StatusInternalServerError) }},
+			want:     1,
+		},
+		{
+			name: "recorded under a cancelled context",
+			handlers: []gin.HandlerFunc{func(c *gin.Context) {
+				ctx, cancel := context.WithCancel(c.Request.Context())
+				cancel()
+				c.Request = c.Request.WithContext(ctx)
+			}, record, ok},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &auditEntries{}
+			serve("u1", append([]gin.HandlerFunc{Audit(audit.NewLog(repo))}, tt.handlers...)...)
+			if len(repo.written) != tt.want {
+				t.Fatalf("wrote %d entries, want %d", len(repo.written), tt.want)
+			}
+			for i, e := range repo.written {
+				if e.UserID != "u1" || e.Action != audit.ActionDeviceUpdate {
+					t.Errorf("entry %d = %s by %q, want %s by u1", i, e.Action, e.UserID, audit.ActionDeviceUpdate)
+				}
+			}
+			for i, live := range repo.live {
+				if !live {
+					t.Errorf("write %d got a cancelled context", i)
+				}
+			}
+		})
+	}
+}
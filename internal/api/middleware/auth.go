@@ -0,0 +1,206 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: auth.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the authentication middleware of the AirSense REST API.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+	"airsense-be.com/internal/utils"
+)
+
+const (
+	userIDKey      = "userID"
+	apiKeyIDKey    = "apiKeyID"
+	scopedTokenKey = "scopedToken"
+)
+
+// Auth requires either a valid "Authorization: Bearer <jwt>" header or an
+// API key, as "Authorization: ApiKey <key>" or "X-API-Key: <key>", and
+// stores the authenticated user ID in the request context. An API key must
+// hold the read permission for GET and HEAD requests and the write
+// permission for any other method. A JWT issued before its user last reset
+// their password or had their sessions revoked is refused; sessions caches
+// when that was, so most requests cost no user lookup. Device-scoped
+// tokens are refused, see ScopedAuth.
+func Auth(secret string, keys *service.APIKeyService, sessions *service.SessionService) gin.HandlerFunc {
+	return authenticate(secret, keys, sessions, nil)
+}
+
+// ScopedAuth is Auth for routes of a single device, which also accept the
+// device-scoped tokens of scopes as their owner. Every such route must run
+// DeviceAccess, which limits the token to its devices and operations.
+// Scoped tokens end with the sessions of their owner, like session tokens.
+func ScopedAuth(secret string, keys *service.APIKeyService, sessions *service.SessionService, scopes *service.ScopedTokenService) gin.HandlerFunc {
+	return authenticate(secret, keys, sessions, scopes)
+}
+
+// authenticate accepts scoped tokens unless scopes is nil.
+func authenticate(secret string, keys *service.APIKeyService, sessions *service.SessionService, scopes *service.ScopedTokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if key, ok := strings.CutPrefix(header, "ApiKey "); ok {
+			authenticateKey(c, keys, key)
+			return
+		}
+		if key := c.GetHeader("X-API-Key"); key != "" && header == "" {
+			authenticateKey(c, keys, key)
+			return
+		}
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			abortUnauthorized(c, "missing bearer token")
+			return
+		}
+		claims, err := utils.ParseToken(token, secret)
+		if err != nil {
+			abortUnauthorized(c, "invalid or expired token")
+			return
+		}
+		if claims.Scoped() {
+			authenticateScoped(c, scopes, sessions, claims)
+			return
+		}
+		if !checkSessions(c, sessions, claims, claims.UserID) {
+			return
+		}
+		c.Set(userIDKey, claims.UserID)
+		c.Next()
+	}
+}
+
+// checkSessions refuses claims issued before the sessions of userID were
+// last ended. On failure the response is written and false is returned.
+func checkSessions(c *gin.Context, sessions *service.SessionService, claims *utils.Claims, userID string) bool {
+	since, err := sessions.ValidSince(c.Request.Context(), userID)
+	if errors.Is(err, service.ErrUserNotFound) {
+		abortUnauthorized(c, "invalid or expired token")
+		return false
+	}
+	if err != nil {
+		log.Printf("api: load sessions of user %s: %v", userID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"code": "INTERNAL_ERROR", "message": "Internal server error."})
+		return false
+	}
+	if revoked(claims, since) {
+		abortUnauthorized(c, "token revoked")
+		return false
+	}
+	return true
+}
+
+// revoked reports whether claims may have been issued before since, the
+// time the sessions of their user were last ended. Tokens carry their
+// issue time in whole seconds, so since is compared at that precision: a
+// token issued within the second of it may predate it and is refused too.
+func revoked(claims *utils.Claims, since time.Time) bool {
+	if since.IsZero() || claims.IssuedAt == nil {
+		return !since.IsZero()
+	}
+	return claims.IssuedAt.Unix() <= since.Unix()
+}
+
+func authenticateScoped(c *gin.Context, scopes *service.ScopedTokenService, sessions *service.SessionService, claims *utils.Claims) {
+	if scopes == nil {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": "FORBIDDEN", "message": "A device-scoped token cannot be used here."})
+		return
+	}
+	t, err := scopes.Authenticate(c.Request.Context(), claims)
+	switch {
+	case errors.Is(err, service.ErrInvalidScopedToken):
+		abortUnauthorized(c, "token revoked or expired")
+		return
+	case err != nil:
+		log.Printf("api: authenticate scoped token %s: %v", claims.ID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"code": "INTERNAL_ERROR", "message": "Internal server error."})
+		return
+	}
+	if !checkSessions(c, sessions, claims, t.UserID) {
+		return
+	}
+	c.Set(userIDKey, t.UserID)
+	c.Set(scopedTokenKey, t)
+	c.Next()
+}
+
+func authenticateKey(c *gin.Context, keys *service.APIKeyService, raw string) {
+	if raw == "" {
+		abortUnauthorized(c, "missing API key")
+		return
+	}
+	key, err := keys.Authenticate(c.Request.Context(), raw)
+	switch {
+	case errors.Is(err, service.ErrInvalidAPIKey):
+		abortUnauthorized(c, "invalid API key")
+		return
+	case errors.Is(err, service.ErrAPIKeyExpired):
+		abortUnauthorized(c, "API key expired")
+		return
+	case err != nil:
+		log.Printf("api: authenticate API key: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"code": "INTERNAL_ERROR", "message": "Internal server error."})
+		return
+	}
+	want := models.PermissionWrite
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		want = models.PermissionRead
+	}
+	if !key.Allows(want) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": "FORBIDDEN", "message": "API key lacks the " + want + " permission."})
+		return
+	}
+	c.Set(userIDKey, key.UserID)
+	c.Set(apiKeyIDKey, key.ID)
+	c.Next()
+}
+
+// QueryToken lets clients that cannot set headers, such as EventSource,
+// pass their JWT as ?token=. It must run before Auth and only on routes
+// meant for such clients, since URLs end up in logs and browser history.
+func QueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Next()
+	}
+}
+
+// SessionOnly rejects requests authenticated with an API key or a
+// device-scoped token, for the routes managing the account, so a leaked
+// key or token cannot take it over.
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, scoped := c.Get(scopedTokenKey)
+		if c.GetString(apiKeyIDKey) != "" || scoped {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": "FORBIDDEN", "message": "Sign in with a user token to manage your account."})
+			return
+		}
+		c.Next()
+	}
+}
+
+// UserID returns the user authenticated by Auth.
+func UserID(c *gin.Context) string {
+	return c.GetString(userIDKey)
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": "UNAUTHORIZED", "message": message})
+}
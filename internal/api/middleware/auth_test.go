@@ -0,0 +1,281 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: auth_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of authenticating with API keys and of session-only routes.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+	"airsense-be.com/internal/utils"
+)
+
+const testSecret = "test-secret-that-is-long-enough-for-hmac"
+
+// hashedKeys holds API keys by the hash of their raw key.
+type hashedKeys struct {
+	repository.APIKeyRepository
+	keys map[string]*models.APIKey
+}
+
+func (r *hashedKeys) GetByHash(_ context.Context, hash string) (*models.APIKey, error) {
+	k, ok := r.keys[hash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return k, nil
+}
+
+func (r *hashedKeys) Touch(context.Context, string, time.Time) error {
+	return nil
+}
+
+func TestAuthAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	past := time.Now().Add(-time.Hour)
+	keys := service.NewAPIKeyService(&hashedKeys{keys: map[string]*models.APIKey{
+		auth.HashToken("asu_read"):    {ID: "k1", UserID: "u1", Permissions: []string{models.PermissionRead}},
+		auth.HashToken("asu_write"):   {ID: "k2", UserID: "u1", Permissions: []string{models.PermissionRead, models.PermissionWrite}},
+		auth.HashToken("asu_expired"): {ID: "k3", UserID: "u1", Permissions: []string{models.PermissionRead}, ExpiresAt: &past},
+	}})
+	sessions := service.NewSessionService(&roleUsers{roles: map[string]string{"u1": ""}}, nil, config.JWTConfig{SessionCacheTTL: time.Minute})
+	token, err := utils.GenerateToken("u1", "", testSecret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(Auth(testSecret, keys, sessions))
+	data := func(c *gin.Context) { c.String(http.StatusOK, UserID(c)) }
+	r.GET("/devices", data)
+	r.POST("/devices", data)
+	account := r.Group("", SessionOnly())
+	account.GET("/users/me", data)
+	account.POST("/auth/logout-all", data)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"read key reads", http.MethodGet, "/devices", "Authorization", "ApiKey asu_read", http.StatusOK},
+		{"read key in X-API-Key", http.MethodGet, "/devices", "X-API-Key", "asu_read", http.StatusOK},
+		{"read key writes", http.MethodPost, "/devices", "Authorization", "ApiKey asu_read", http.StatusForbidden},
+		{"write key writes", http.MethodPost, "/devices", "Authorization", "ApiKey asu_write", http.StatusOK},
+		{"expired key", http.MethodGet, "/devices", "Authorization", "ApiKey asu_expired", http.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/devices", "Authorization", "ApiKey asu_guess", http.StatusUnauthorized},
+		{"empty key", http.MethodGet, "/devices", "Authorization", "ApiKey ", http.StatusUnauthorized},
+		{"key on account route", http.MethodGet, "/users/me", "Authorization", "ApiKey asu_write", http.StatusForbidden},
+		{"key signs out everywhere", http.MethodPost, "/auth/logout-all", "X-API-Key", "asu_write", http.StatusForbidden},
+		{"token on account route", http.MethodGet, "/users/me", "Authorization", "Bearer " + token, http.StatusOK},
+		{"token on data route", http.MethodPost, "/devices", "Authorization", "Bearer " + token, http.StatusOK},
+		{"no credentials", http.MethodGet, "/devices", "", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusOK && rec.Body.String() != "u1" {
+				t.Errorf("user = %q, want u1", rec.Body)
+			}
+		})
+	}
+}
+
+func TestRevoked(t *testing.T) {
+	since := time.Date(2026, 10, 15, 12, 0, 0, 500_000_000, time.UTC)
+	at := func(t time.Time) *utils.Claims {
+		return &utils.Claims{RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(t)}}
+	}
+	tests := []struct {
+		name   string
+		claims *utils.Claims
+		since  time.Time
+		want   bool
+	}{
+		{"never revoked", at(since), time.Time{}, false},
+		{"no issue time, never revoked", &utils.Claims{}, time.Time{}, false},
+		{"no issue time", &utils.Claims{}, since, true},
+		{"a second before", at(since.Add(-time.Second)), since, true},
+		{"earlier in the second", at(since.Add(-100 * time.Millisecond)), since, true},
+		{"later in the second", at(since.Add(100 * time.Millisecond)), since, true},
+		{"the next second", at(since.Add(500 * time.Millisecond)), since, false},
+		{"revoked on the second", at(since.Truncate(time.Second)), since.Truncate(time.Second), true},
+		{"an hour later", at(since.Add(time.Hour)), since, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := revoked(tt.claims, tt.since); got != tt.want {
+				t.Errorf("revoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkAuth measures the hot path of a session token: its signature
+// checked and its user's revocation time found in the cache.
+func BenchmarkAuth(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	sessions := service.NewSessionService(&roleUsers{roles: map[string]string{"u1": ""}}, nil, config.JWTConfig{SessionCacheTTL: time.Hour})
+	token, err := utils.GenerateToken("u1", "", testSecret, time.Hour)
+	if err != nil {
+		b.Fatal(err)
+	}
+	r := gin.New()
+	r.Use(Auth(testSecret, nil, sessions))
+	r.GET("/devices", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	req := httptest.NewRequest(http.MethodGet, "/devices", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent {
+			b.Fatalf("status = %d", rec.Code)
+		}
+	}
+}
+
+// revocations holds when the sessions of each user were last ended.
+type revocations struct {
+	repository.UserRepository
+	at map[string]*time.Time
+}
+
+func (r *revocations) GetByID(_ context.Context, id string) (*models.User, error) {
+	at, ok := r.at[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &models.User{ID: id, SessionsRevokedAt: at}, nil
+}
+
+type scopedRecords struct {
+	repository.ScopedTokenRepository
+	tokens map[string]*models.ScopedToken
+}
+
+func (r *scopedRecords) GetByID(_ context.Context, id string) (*models.ScopedToken, error) {
+	t, ok := r.tokens[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return t, nil
+}
+
+func TestScopedAuthSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	users := &revocations{at: map[string]*time.Time{"fresh": nil, "signed-out-before": &past, "signed-out-since": &future}}
+	records := &scopedRecords{tokens: map[string]*models.ScopedToken{}}
+	tokens := map[string]string{}
+	for _, user := range []string{"fresh", "signed-out-before", "signed-out-since", "deleted"} {
+		id := "t-" + user
+		records.tokens[id] = &models.ScopedToken{ID: id, UserID: user, DeviceIDs: []string{"d1"}, ExpiresAt: future}
+		token, err := utils.GenerateScopedToken(id, user, []string{"d1"}, []string{models.ScopeRead}, testSecret, future)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[user] = token
+	}
+	sessions := service.NewSessionService(users, nil, config.JWTConfig{SessionCacheTTL: time.Minute})
+	scopes := service.NewScopedTokenService(records, nil, config.JWTConfig{Secret: testSecret})
+
+	tests := []struct {
+		name string
+		user string
+		want int
+	}{
+		{"never signed out", "fresh", http.StatusOK},
+		{"signed out before it was issued", "signed-out-before", http.StatusOK},
+		{"signed out since it was issued", "signed-out-since", http.StatusUnauthorized},
+		{"owner deleted", "deleted", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/devices/:id", ScopedAuth(testSecret, nil, sessions, scopes), func(c *gin.Context) { c.String(http.StatusOK, UserID(c)) })
+			req := httptest.NewRequest(http.MethodGet, "/devices/d1", nil)
+			req.Header.Set("Authorization", "Bearer "+tokens[tt.user])
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusOK && rec.Body.String() != tt.user {
+				t.Errorf("user = %q, want %q", rec.Body, tt.user)
+			}
+		})
+	}
+}
+
+func TestSessionOnlyScoped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	future := time.Now().Add(time.Hour)
+	records := &scopedRecords{tokens: map[string]*models.ScopedToken{
+		"t1": {ID: "t1", UserID: "u1", DeviceIDs: []string{"d1"}, ExpiresAt: future},
+	}}
+	scoped, err := utils.GenerateScopedToken("t1", "u1", []string{"d1"}, []string{models.ScopeRead}, testSecret, future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := utils.GenerateToken("u1", "", testSecret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := service.NewSessionService(&revocations{at: map[string]*time.Time{"u1": nil}}, nil, config.JWTConfig{SessionCacheTTL: time.Minute})
+	scopes := service.NewScopedTokenService(records, nil, config.JWTConfig{Secret: testSecret})
+	r := gin.New()
+	r.GET("/devices/:id", ScopedAuth(testSecret, nil, sessions, scopes), SessionOnly(), func(c *gin.Context) { c.String(http.StatusOK, UserID(c)) })
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"session token", session, http.StatusOK},
+		{"device-scoped token", scoped, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/devices/d1", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: compress.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the gzip response compression middleware of the AirSense REST API.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(io.Discard) },
+}
+
+// Compress gzips responses of at least minSize bytes for clients that
+// accept it. Responses that are flushed before they reach minSize, such as
+// event streams, and WebSocket upgrades are sent as is, as are bodies whose
+// content type is already compressed.
+func Compress(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isUpgrade(c.Request) || isEventStream(c.GetHeader("Accept")) {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		w := &gzipWriter{ResponseWriter: c.Writer, minSize: minSize}
+		c.Writer = w
+		// On panic the buffered body is dropped and the recovery handler
+		// writes its error to the original writer.
+		defer func() { c.Writer = w.ResponseWriter }()
+		c.Next()
+		w.close()
+	}
+}
+
+// gzipWriter buffers the body until minSize bytes are written or the
+// handler returns, then decides whether to compress it.
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize int
+	buf     []byte
+	started bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	if w.started {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow sends the headers, so the body can no longer be
+// compressed.
+func (w *gzipWriter) WriteHeaderNow() {
+	if !w.started {
+		_ = w.start(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush streams the response: a body that has not been compressed yet is
+// sent uncompressed.
+func (w *gzipWriter) Flush() {
+	if !w.started {
+		_ = w.start(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// start sets the headers for the chosen encoding and writes out the
+// buffered body.
+func (w *gzipWriter) start(compress bool) error {
+	w.started = true
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		// Sniff now; once compressed the body would sniff as gzip.
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if compress && h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.gz != nil {
+		_, err := w.gz.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// close writes a body that stayed below minSize uncompressed and finishes
+// the gzip stream.
+func (w *gzipWriter) close() {
+	if !w.started {
+		_ = w.start(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// compressible reports whether a body of contentType is worth gzipping.
+func compressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if i := strings.IndexByte(mediaType, '/'); i >= 0 {
+		switch mediaType[:i] {
+		case "image", "audio", "video":
+			return mediaType == "image/svg+xml"
+		}
+	}
+	switch mediaType {
+	case "text/event-stream",
+		"application/gzip", "application/x-gzip", "application/zip",
+		"application/zstd", "application/x-bzip2", "application/x-7z-compressed",
+		"application/x-rar-compressed", "application/octet-stream",
+		"font/woff", "font/woff2":
+		return false
+	}
+	return true
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func isUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+func isEventStream(accept string) bool {
+	return strings.Contains(accept, "text/event-stream")
+}
@@ -0,0 +1,86 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: cors.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the CORS middleware of the AirSense REST API.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/config"
+)
+
+// corsExposedHeaders are the response headers of the API that scripts on
+// other origins may read.
+var corsExposedHeaders = strings.Join([]string{"Content-Disposition", "Idempotent-Replayed", "X-Total-Count"}, ", ")
+
+// CORS answers preflight requests and adds the CORS headers for requests
+// from the origins of cfg. The matched origin is echoed, never "*", so
+// the same policy works with credentials. Requests from other origins get
+// no CORS headers and are left for the browser to block.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			log.Printf("cors: ignoring the wildcard origin; list every allowed origin")
+			continue
+		}
+		origins[strings.ToLower(strings.TrimSuffix(o, "/"))] = true
+	}
+	methods := make(map[string]bool, len(cfg.AllowedMethods))
+	for _, m := range cfg.AllowedMethods {
+		methods[strings.ToUpper(m)] = true
+	}
+	allowMethods := strings.ToUpper(strings.Join(cfg.AllowedMethods, ", "))
+	allowHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		if len(origins) == 0 {
+			c.Next()
+			return
+		}
+		// Responses differ by origin, so caches must key on it.
+		c.Writer.Header().Add("Vary", "Origin")
+		origin := c.GetHeader("Origin")
+		if origin == "" || !origins[strings.ToLower(origin)] {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		requested := c.GetHeader("Access-Control-Request-Method")
+		if c.Request.Method != http.MethodOptions || requested == "" {
+			h.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			c.Next()
+			return
+		}
+
+		// Preflight: answered here, before authentication, which browsers
+		// never send along with it.
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		if methods[strings.ToUpper(requested)] {
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			h.Set("Access-Control-Allow-Headers", allowHeaders)
+			h.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
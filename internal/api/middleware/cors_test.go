@@ -0,0 +1,162 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: cors_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the CORS middleware.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/config"
+)
+
+var corsHeaders = []string{
+	"Access-Control-Allow-Origin",
+	"Access-Control-Allow-Credentials",
+	"Access-Control-Allow-Methods",
+	"Access-Control-Allow-Headers",
+	"Access-Control-Max-Age",
+	"Access-Control-Expose-Headers",
+}
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com/", "*"},
+		AllowedMethods:   []string{"get", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	tests := []struct {
+		name       string
+		cfg        config.CORSConfig
+		method     string
+		origin     string
+		preflight  string
+		wantStatus int
+		want       map[string]string
+		wantVary   []string
+	}{
+		{
+			name: "allowed origin", cfg: cfg, method: http.MethodGet, origin: "https://app.example.com",
+			wantStatus: http.StatusOK,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    corsExposedHeaders,
+			},
+			wantVary: []string{"Origin"},
+		},
+		{
+			name: "origin case", cfg: cfg, method: http.MethodGet, origin: "https://APP.example.com",
+			wantStatus: http.StatusOK,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://APP.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    corsExposedHeaders,
+			},
+			wantVary: []string{"Origin"},
+		},
+		{
+			name: "preflight", cfg: cfg, method: http.MethodOptions, origin: "https://app.example.com", preflight: "POST",
+			wantStatus: http.StatusNoContent,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, POST",
+				"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+				"Access-Control-Max-Age":           "600",
+			},
+			wantVary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name: "preflight of a method not allowed", cfg: cfg, method: http.MethodOptions, origin: "https://app.example.com",
+			preflight: "DELETE", wantStatus: http.StatusNoContent,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+			wantVary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name: "without credentials", method: http.MethodGet, origin: "https://app.example.com",
+			cfg:        config.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}},
+			wantStatus: http.StatusOK,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":   "https://app.example.com",
+				"Access-Control-Expose-Headers": corsExposedHeaders,
+			},
+			wantVary: []string{"Origin"},
+		},
+		{
+			name: "disallowed origin", cfg: cfg, method: http.MethodGet, origin: "https://evil.example.com",
+			wantStatus: http.StatusOK, wantVary: []string{"Origin"},
+		},
+		{
+			name: "disallowed origin preflight", cfg: cfg, method: http.MethodOptions, origin: "https://evil.example.com",
+			preflight: "POST", wantStatus: http.StatusNotFound, wantVary: []string{"Origin"},
+		},
+		{
+			name: "subdomain of an allowed origin", cfg: cfg, method: http.MethodGet, origin: "https://x.app.example.com",
+			wantStatus: http.StatusOK, wantVary: []string{"Origin"},
+		},
+		{
+			name: "null origin", cfg: cfg, method: http.MethodGet, origin: "null",
+			wantStatus: http.StatusOK, wantVary: []string{"Origin"},
+		},
+		{
+			name: "no origin", cfg: cfg, method: http.MethodGet,
+			wantStatus: http.StatusOK, wantVary: []string{"Origin"},
+		},
+		{
+			name: "only the wildcard configured", method: http.MethodGet, origin: "https://app.example.com",
+			cfg:        config.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "no origins configured", method: http.MethodGet, origin: "https://app.example.com",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(CORS(tt.cfg))
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.preflight)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			for _, h := range corsHeaders {
+				if got := rec.Header().Get(h); got != tt.want[h] {
+					t.Errorf("%s = %q, want %q", h, got, tt.want[h])
+				}
+			}
+			if got := rec.Header().Values("Vary"); !slices.Equal(got, tt.wantVary) {
+				t.Errorf("Vary = %v, want %v", got, tt.wantVary)
+			}
+		})
+	}
+}
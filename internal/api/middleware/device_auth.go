@@ -0,0 +1,46 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_auth.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the API key middleware authenticating devices in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/service"
+)
+
+// DeviceAuth requires an "X-API-Key" header minted for the :id device.
+// Keys of other devices are rejected like unknown ones.
+func DeviceAuth(keys *service.DeviceKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			abortUnauthorized(c, "missing API key")
+			return
+		}
+		deviceID, err := keys.Authenticate(c.Request.Context(), key)
+		switch {
+		case errors.Is(err, service.ErrInvalidAPIKey):
+			abortUnauthorized(c, "invalid API key")
+		case err != nil:
+			log.Printf("api: authenticate device %s: %v", c.Param("id"), err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"code": "INTERNAL_ERROR", "message": "Internal server error."})
+		case deviceID != c.Param("id"):
+			abortUnauthorized(c, "API key does not belong to this device")
+		default:
+			c.Next()
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: rate_limit.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the rate limiting middleware.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"expvar"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/utils"
+)
+
+// KeyRateLimiter lets each API key make a number of requests a minute, in
+// bursts, and answers the rest with 429 and a Retry-After header. Requests
+// with a JWT are not limited, and neither is anything while the limit is 0.
+// Limits are per server instance. It is a config.ConfigObserver: a reload
+// applies new API_KEY_RATE_LIMIT and API_KEY_RATE_BURST values.
+type KeyRateLimiter struct {
+	limiter   *utils.RateLimiter
+	perMinute atomic.Int64
+}
+
+func NewKeyRateLimiter(perMinute, burst int) *KeyRateLimiter {
+	l := &KeyRateLimiter{limiter: utils.NewRateLimiter(perMinute, time.Minute, burst)}
+	l.perMinute.Store(int64(perMinute))
+	return l
+}
+
+// Handler limits the requests of the API keys; it must run after Auth.
+func (l *KeyRateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetString(apiKeyIDKey)
+		if id == "" || l.perMinute.Load() <= 0 {
+			c.Next()
+			return
+		}
+		limit(c, l.limiter, id, metrics.APIKeyRequestsLimited, "Too many requests with this API key.")
+	}
+}
+
+// OnConfigChange implements config.ConfigObserver.
+func (l *KeyRateLimiter) OnConfigChange(old, new config.Config) {
+	if old.Server.APIKeyRateLimit == new.Server.APIKeyRateLimit && old.Server.APIKeyBurst == new.Server.APIKeyBurst {
+		return
+	}
+	if new.Server.APIKeyRateLimit > 0 {
+		l.limiter.SetLimit(new.Server.APIKeyRateLimit, time.Minute, new.Server.APIKeyBurst)
+	}
+	l.perMinute.Store(int64(new.Server.APIKeyRateLimit))
+}
+
+// ClientRateLimiter lets each client IP make a number of requests per
+// period, in bursts of up to that number, like KeyRateLimiter; a limit of
+// 0 disables it. It is a config.ConfigObserver: setting picks the limit
+// from the configuration, so a reload applies a new value.
+type ClientRateLimiter struct {
+	limiter *utils.RateLimiter
+	period  time.Duration
+	setting func(config.Config) int
+	n       atomic.Int64
+}
+
+func NewClientRateLimiter(cfg config.Config, period time.Duration, setting func(config.Config) int) *ClientRateLimiter {
+	n := setting(cfg)
+	l := &ClientRateLimiter{limiter: utils.NewRateLimiter(n, period, n), period: period, setting: setting}
+	l.n.Store(int64(n))
+	return l
+}
+
+// Handler limits the requests of each client IP.
+func (l *ClientRateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l.n.Load() <= 0 {
+			c.Next()
+			return
+		}
+		limit(c, l.limiter, c.ClientIP(), metrics.ClientRequestsLimited, "Too many requests, try again later.")
+	}
+}
+
+// OnConfigChange implements config.ConfigObserver.
+func (l *ClientRateLimiter) OnConfigChange(old, new config.Config) {
+	n := l.setting(new)
+	if n == l.setting(old) {
+		return
+	}
+	if n > 0 {
+		l.limiter.SetLimit(n, l.period, n)
+	}
+	l.n.Store(int64(n))
+}
+
+// limit takes a request of key from limiter, or counts it in limited and
+// answers 429.
+func limit(c *gin.Context, limiter *utils.RateLimiter, key string, limited *expvar.Int, message string) {
+	if ok, wait := limiter.Allow(key); !ok {
+		limited.Add(1)
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"code": "RATE_LIMITED", "message": message})
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,197 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: rate_limit_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the rate limiting middleware.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/metrics"
+)
+
+// keyRouter serves GET / with the API key of the X-Key header, if any.
+func keyRouter(l *KeyRateLimiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		if key := c.GetHeader("X-Key"); key != "" {
+			c.Set(apiKeyIDKey, key)
+		}
+	}, l.Handler(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+// get sends n requests with key and returns their statuses.
+func get(r http.Handler, key string, n int) []int {
+	codes := make([]int, n)
+	for i := range n {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			req.Header.Set("X-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		codes[i] = rec.Code
+	}
+	return codes
+}
+
+func serverConfig(perMinute, burst int) config.Config {
+	return config.Config{Server: config.ServerConfig{APIKeyRateLimit: perMinute, APIKeyBurst: burst}}
+}
+
+func TestKeyRateLimiter(t *testing.T) {
+	const ok, limited = http.StatusOK, http.StatusTooManyRequests
+	tests := []struct {
+		name   string
+		limit  [2]int
+		reload *[2]int
+		key    string
+		want   []int
+	}{
+		{"within burst", [2]int{1, 2}, nil, "k1", []int{ok, ok}},
+		{"burst used up", [2]int{1, 2}, nil, "k1", []int{ok, ok, limited, limited}},
+		{"no API key", [2]int{1, 1}, nil, "", []int{ok, ok, ok}},
+		{"disabled", [2]int{0, 1}, nil, "k1", []int{ok, ok, ok}},
+		// Reloads follow a first request with k1; they keep the tokens
+		// a bucket has left, up to the new burst.
+		{"reloaded key keeps its tokens", [2]int{1, 1}, &[2]int{60, 4}, "k1", []int{ok, limited}},
+		{"reloaded new key", [2]int{1, 1}, &[2]int{60, 4}, "k2", []int{ok, ok, ok, ok, ok, limited}},
+		{"reloaded smaller burst", [2]int{60, 4}, &[2]int{1, 1}, "k1", []int{ok, ok, limited}},
+		{"reloaded off", [2]int{1, 1}, &[2]int{0, 1}, "k1", []int{ok, ok, ok}},
+		{"reloaded on", [2]int{0, 1}, &[2]int{1, 1}, "k1", []int{ok, ok, limited}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewKeyRateLimiter(tt.limit[0], tt.limit[1])
+			r := keyRouter(l)
+			want := tt.want
+			if tt.reload != nil {
+				if got := get(r, "k1", 1); got[0] != ok {
+					t.Fatalf("first request = %d, want %d", got[0], ok)
+				}
+				l.OnConfigChange(serverConfig(tt.limit[0], tt.limit[1]), serverConfig(tt.reload[0], tt.reload[1]))
+				want = want[1:]
+			}
+			before := metrics.APIKeyRequestsLimited.Value()
+			got := get(r, tt.key, len(want))
+			var wantLimited int64
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("request %d = %d, want %d", i+1, got[i], want[i])
+				}
+				if want[i] == limited {
+					wantLimited++
+				}
+			}
+			if n := metrics.APIKeyRequestsLimited.Value() - before; n != wantLimited {
+				t.Errorf("counted %d limited requests, want %d", n, wantLimited)
+			}
+		})
+	}
+}
+
+func TestKeyRateLimiterRetryAfter(t *testing.T) {
+	r := keyRouter(NewKeyRateLimiter(2, 1))
+	get(r, "k1", 1)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Key", "k1")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+	// Another key has a bucket of its own.
+	if got := get(r, "k2", 1); got[0] != http.StatusOK {
+		t.Errorf("other key = %d, want 200", got[0])
+	}
+}
+
+// clientGet sends a request from each of ips and returns their statuses.
+func clientGet(r http.Handler, ips ...string) []int {
+	codes := make([]int, len(ips))
+	for i, ip := range ips {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		codes[i] = rec.Code
+	}
+	return codes
+}
+
+func resetLimit(n int) config.Config {
+	return config.Config{JWT: config.JWTConfig{PasswordResetIPLimit: n}}
+}
+
+func TestClientRateLimiter(t *testing.T) {
+	const ok, limited = http.StatusOK, http.StatusTooManyRequests
+	const a, b = "192.0.2.1", "192.0.2.2"
+	tests := []struct {
+		name   string
+		limit  int
+		reload *int
+		ips    []string
+		want   []int
+	}{
+		{"burst used up", 2, nil, []string{a, a, a, b}, []int{ok, ok, limited, ok}},
+		{"disabled", 0, nil, []string{a, a, a}, []int{ok, ok, ok}},
+		// Reloads follow a first request from a, whose bucket keeps the
+		// tokens it has left, up to the new burst; another setting
+		// changes with them.
+		{"reloaded higher", 1, ptr(3), []string{a, b, b, b, b}, []int{limited, ok, ok, ok, limited}},
+		{"reloaded lower", 3, ptr(1), []string{a, a, b, b}, []int{ok, limited, ok, limited}},
+		{"reloaded off", 1, ptr(0), []string{a, a, a}, []int{ok, ok, ok}},
+		{"reloaded on", 0, ptr(1), []string{a, b, b}, []int{ok, ok, limited}},
+		{"limit unchanged", 1, ptr(1), []string{a}, []int{limited}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewClientRateLimiter(resetLimit(tt.limit), time.Hour, func(c config.Config) int { return c.JWT.PasswordResetIPLimit })
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.GET("/", l.Handler(), func(c *gin.Context) { c.Status(http.StatusOK) })
+			if tt.reload != nil {
+				if got := clientGet(r, a); got[0] != ok {
+					t.Fatalf("first request = %d, want %d", got[0], ok)
+				}
+				next := resetLimit(*tt.reload)
+				next.JWT.RegisterIPLimit = 100
+				l.OnConfigChange(resetLimit(tt.limit), next)
+			}
+			before := metrics.ClientRequestsLimited.Value()
+			got := clientGet(r, tt.ips...)
+			var wantLimited int64
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("request %d = %d, want %d", i+1, got[i], tt.want[i])
+				}
+				if tt.want[i] == limited {
+					wantLimited++
+				}
+			}
+			if n := metrics.ClientRequestsLimited.Value() - before; n != wantLimited {
+				t.Errorf("counted %d limited requests, want %d", n, wantLimited)
+			}
+		})
+	}
+}
+
+func ptr(n int) *int { return &n }
@@ -0,0 +1,47 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: timeout.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the middleware bounding the time spent on a request.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout gives the context of each request a deadline of d, so database
+// and broker calls made for it are cancelled once it passes. A request
+// that ran out of time without writing a response gets 504. Requests to
+// the route paths of streams, e.g. "/api/v1/devices/:id/events", run
+// unbounded, as does everything when d is 0.
+func Timeout(d time.Duration, streams ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(streams))
+	for _, p := range streams {
+		skip[p] = true
+	}
+	return func(c *gin.Context) {
+		if d <= 0 || skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"code": "TIMEOUT", "message": "The request took too long."})
+		}
+	}
+}
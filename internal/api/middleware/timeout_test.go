@@ -0,0 +1,102 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: timeout_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the request timeout middleware.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// query stands for a database call: it waits for the context or for
+	// took, whichever ends first.
+	query := func(took time.Duration) func(*gin.Context) error {
+		return func(c *gin.Context) error {
+			select {
+			case <-c.Request.Context().Done():
+				return c.Request.Context().Err()
+			case <-time.After(took):
+				return nil
+			}
+		}
+	}
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		path         string
+		query        func(*gin.Context) error
+		respond      bool
+		wantStatus   int
+		wantErr      error
+		wantDeadline bool
+	}{
+		{name: "fast query", timeout: time.Second, path: "/readings", query: query(0),
+			wantStatus: http.StatusOK, wantDeadline: true},
+		{name: "slow query is cancelled", timeout: 20 * time.Millisecond, path: "/readings", query: query(time.Minute),
+			wantStatus: http.StatusGatewayTimeout, wantErr: context.DeadlineExceeded, wantDeadline: true},
+		{name: "handler answers the timeout itself", timeout: 20 * time.Millisecond, path: "/readings", query: query(time.Minute),
+			respond: true, wantStatus: http.StatusServiceUnavailable, wantErr: context.DeadlineExceeded, wantDeadline: true},
+		{name: "stream is unbounded", timeout: 20 * time.Millisecond, path: "/devices/d1/events", query: query(50 * time.Millisecond),
+			wantStatus: http.StatusOK},
+		{name: "disabled", timeout: 0, path: "/readings", query: query(50 * time.Millisecond),
+			wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				err         error
+				hasDeadline bool
+			)
+			handler := func(c *gin.Context) {
+				_, hasDeadline = c.Request.Context().Deadline()
+				err = tt.query(c)
+				switch {
+				case err != nil && tt.respond:
+					c.JSON(http.StatusServiceUnavailable, gin.H{"code": "UNAVAILABLE"})
+				case err == nil:
+					c.JSON(http.StatusOK, gin.H{"data": []string{}})
+				}
+			}
+			r := gin.New()
+			r.Use(Timeout(tt.timeout, "/devices/:id/events"))
+			r.GET("/readings", handler)
+			r.GET("/devices/:id/events", handler)
+			w := httptest.NewRecorder()
+			start := time.Now()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("query err = %v, want %v", err, tt.wantErr)
+			}
+			if hasDeadline != tt.wantDeadline {
+				t.Errorf("deadline set = %v, want %v", hasDeadline, tt.wantDeadline)
+			}
+			if tt.wantStatus == http.StatusGatewayTimeout && !strings.Contains(w.Body.String(), `"TIMEOUT"`) {
+				t.Errorf("body = %s, want code TIMEOUT", w.Body)
+			}
+			if took := time.Since(start); took > 5*time.Second {
+				t.Errorf("took %v", took)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: webhook.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the shared-secret middleware protecting internal webhooks.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SharedSecret requires header to carry secret. An empty secret rejects
+// every request, so an unconfigured webhook is never left open.
+func SharedSecret(header, secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := c.GetHeader(header)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
+			abortUnauthorized(c, "invalid webhook secret")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: router.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the router that registers routes on gin and in the OpenAPI document.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package openapi
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Router registers routes on a gin router group, like the group itself,
+// and adds each to the spec with the security of the router. The last
+// handler of a route is the one it is documented by.
+type Router struct {
+	spec     *Spec
+	group    *gin.RouterGroup
+	security []string
+}
+
+// Router returns a router for g whose routes require one of the security
+// schemes security, or none if it is empty.
+func (s *Spec) Router(g *gin.RouterGroup, security ...string) *Router {
+	return &Router{spec: s, group: g, security: security}
+}
+
+// Group is like gin.RouterGroup.Group.
+func (r *Router) Group(relativePath string, handlers ...gin.HandlerFunc) *Router {
+	return &Router{spec: r.spec, group: r.group.Group(relativePath, handlers...), security: r.security}
+}
+
+// With returns a router for the same group whose routes require one of
+// the security schemes security instead, for routes that authenticate
+// differently from their group.
+func (r *Router) With(security ...string) *Router {
+	return &Router{spec: r.spec, group: r.group, security: security}
+}
+
+func (r *Router) Handle(method, relativePath string, handlers ...gin.HandlerFunc) {
+	r.group.Handle(method, relativePath, handlers...)
+	full := r.group.BasePath()
+	if relativePath != "" {
+		full = path.Join(full, relativePath)
+		if strings.HasSuffix(relativePath, "/") {
+			full += "/"
+		}
+	}
+	r.spec.add(route{method: method, path: full, security: r.security, handler: funcName(handlers[len(handlers)-1])})
+}
+
+func (r *Router) GET(relativePath string, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodGet, relativePath, handlers...)
+}
+
+func (r *Router) POST(relativePath string, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPost, relativePath, handlers...)
+}
+
+func (r *Router) PUT(relativePath string, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPut, relativePath, handlers...)
+}
+
+func (r *Router) PATCH(relativePath string, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPatch, relativePath, handlers...)
+}
+
+func (r *Router) DELETE(relativePath string, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodDelete, relativePath, handlers...)
+}
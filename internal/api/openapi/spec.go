@@ -0,0 +1,333 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: spec.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the OpenAPI document built from the registered routes.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+// Package openapi generates the OpenAPI 3 document of the HTTP API from
+// the routes as they are registered, so that it cannot drift from them.
+// Routes are registered through a Router, which records their method,
+// path and security; what they take and return comes from the Docs of
+// the handler type, next to the handlers themselves.
+package openapi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Names of the security schemes of the document.
+const (
+	// BearerAuth is a user access token: Authorization: Bearer <JWT>.
+	BearerAuth = "bearerAuth"
+	// APIKeyAuth is a user API key in the X-API-Key header.
+	APIKeyAuth = "apiKeyAuth"
+	// DeviceKeyAuth is a device API key in the X-API-Key header.
+	DeviceKeyAuth = "deviceKeyAuth"
+	// WebhookSecret is the shared secret of the MQTT broker webhooks.
+	WebhookSecret = "webhookSecret"
+)
+
+var securitySchemes = map[string]any{
+	BearerAuth: map[string]any{
+		"type": "http", "scheme": "bearer", "bearerFormat": "JWT",
+		"description": "Access token from POST /api/v1/auth/login.",
+	},
+	APIKeyAuth: map[string]any{
+		"type": "apiKey", "in": "header", "name": "X-API-Key",
+		"description": "API key of a user, created with POST /api/v1/apikeys.",
+	},
+	DeviceKeyAuth: map[string]any{
+		"type": "apiKey", "in": "header", "name": "X-API-Key",
+		"description": "API key of a device, minted with POST /api/v1/devices/{id}/keys.",
+	},
+	WebhookSecret: map[string]any{
+		"type": "apiKey", "in": "header", "name": "X-Webhook-Secret",
+		"description": "MQTT_AUTH_WEBHOOK_SECRET, shared with the MQTT broker.",
+	},
+}
+
+// Param is a query or header parameter of an operation. Path parameters
+// are taken from the route.
+type Param struct {
+	Name string
+	// In is query, the default, or header.
+	In string
+	// Type is string, the default, integer, number or boolean.
+	Type        string
+	Description string
+	Required    bool
+	// Repeated parameters may be given more than once.
+	Repeated bool
+}
+
+// Operation documents what a handler takes and returns.
+type Operation struct {
+	Summary     string
+	Description string
+	Params      []Param
+	// Body is a value of the type of the request body, nil if there is
+	// none. Its schema is derived from the json and binding tags.
+	Body any
+	// BodyType is the media type of the body, application/json by default.
+	BodyType string
+	// Status is the status of a successful response, 200 by default.
+	Status int
+	// Response is a value of the type of a successful response body, nil
+	// if there is none.
+	Response any
+	// ResponseType is the media type of the response, application/json by
+	// default.
+	ResponseType string
+}
+
+// Docs documents the handlers of a type by method name.
+type Docs map[string]Operation
+
+// Documented is implemented by handler types that document their methods.
+type Documented interface {
+	Docs() Docs
+}
+
+type route struct {
+	method, path string
+	security     []string
+	handler      string
+}
+
+// Spec collects the routes and docs of the API and renders them as an
+// OpenAPI document.
+type Spec struct {
+	title, version string
+	errorBody      any
+
+	mu     sync.Mutex
+	docs   map[string]Operation
+	routes []route
+	doc    []byte
+}
+
+// New returns an empty spec. errorBody is a value of the type of error
+// responses, which every operation may answer with.
+func New(title, version string, errorBody any) *Spec {
+	return &Spec{title: title, version: version, errorBody: errorBody, docs: make(map[string]Operation)}
+}
+
+// Describe adds the docs of handler types, used for the routes of their
+// methods.
+func (s *Spec) Describe(handlers ...Documented) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, h := range handlers {
+		t := reflect.TypeOf(h)
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		for method, op := range h.Docs() {
+			s.docs[t.Name()+"."+method] = op
+		}
+	}
+	s.doc = nil
+}
+
+func (s *Spec) add(r route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = append(s.routes, r)
+	s.doc = nil
+}
+
+// JSON returns the document as JSON. It is rendered once, on the first
+// call after a change.
+func (s *Spec) JSON() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.doc != nil {
+		return s.doc, nil
+	}
+	doc, err := json.MarshalIndent(s.build(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	s.doc = doc
+	return doc, nil
+}
+
+// Handler serves the document.
+func (s *Spec) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		doc, err := s.JSON()
+		if err != nil {
+			log.Printf("openapi: render document: %v", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		c.Data(http.StatusOK, "application/json", doc)
+	}
+}
+
+// handlerName matches the runtime name of a method value, e.g.
+// airsense-be.com/internal/api/handlers.(*AuthHandler).Login-fm.
+var handlerName = regexp.MustCompile(`\.\(\*?(\w+)\)\.(\w+)-fm$`)
+
+// pathParam matches the :name and *name segments of a gin path.
+var pathParam = regexp.MustCompile(`[:*](\w+)`)
+
+func (s *Spec) build() map[string]any {
+	g := newSchemas()
+	paths := make(map[string]any)
+	ids := make(map[string]bool)
+	for _, r := range s.routes {
+		tag, key, id := "", r.handler, r.handler[strings.LastIndex(r.handler, "/")+1:]
+		if m := handlerName.FindStringSubmatch(r.handler); m != nil {
+			tag = strings.TrimSuffix(m[1], "Handler")
+			key, id = m[1]+"."+m[2], tag+"."+m[2]
+		}
+		op, ok := s.docs[key]
+		if !ok {
+			log.Printf("openapi: %s %s is not documented", r.method, r.path)
+		}
+
+		o := map[string]any{"responses": s.responses(g, op)}
+		if tag != "" {
+			o["tags"] = []string{tag}
+		}
+		id = strings.ReplaceAll(id, ".", "_")
+		if ids[id] {
+			id += "_" + strings.ToLower(r.method)
+		}
+		ids[id] = true
+		o["operationId"] = id
+		if op.Summary != "" {
+			o["summary"] = op.Summary
+		}
+		if op.Description != "" {
+			o["description"] = op.Description
+		}
+		if params := parameters(r.path, op.Params); len(params) > 0 {
+			o["parameters"] = params
+		}
+		if op.Body != nil || op.BodyType != "" {
+			o["requestBody"] = map[string]any{
+				"required": true,
+				"content":  content(g, op.BodyType, op.Body),
+			}
+		}
+		if len(r.security) > 0 {
+			var security []map[string][]string
+			for _, name := range r.security {
+				security = append(security, map[string][]string{name: {}})
+			}
+			o["security"] = security
+		}
+
+		p := pathParam.ReplaceAllString(r.path, "{$1}")
+		item, _ := paths[p].(map[string]any)
+		if item == nil {
+			item = make(map[string]any)
+			paths[p] = item
+		}
+		item[strings.ToLower(r.method)] = o
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info":    map[string]any{"title": s.title, "version": s.version},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas":         g.defs,
+			"securitySchemes": securitySchemes,
+		},
+	}
+}
+
+func (s *Spec) responses(g *schemas, op Operation) map[string]any {
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	ok := map[string]any{"description": http.StatusText(status)}
+	if op.Response != nil || op.ResponseType != "" {
+		ok["content"] = content(g, op.ResponseType, op.Response)
+	}
+	res := map[string]any{strconv.Itoa(status): ok}
+	if s.errorBody != nil {
+		res["default"] = map[string]any{
+			"description": "Error",
+			"content":     content(g, "", s.errorBody),
+		}
+	}
+	return res
+}
+
+// content is the content object of a body of media type mediaType,
+// application/json if empty, and Go type of v. Bodies of other media
+// types without a Go type are plain strings or, for binary types, bytes.
+func content(g *schemas, mediaType string, v any) map[string]any {
+	if mediaType == "" {
+		mediaType = "application/json"
+	}
+	var schema map[string]any
+	switch {
+	case v != nil:
+		schema = g.of(reflect.TypeOf(v))
+	case mediaType == "application/octet-stream":
+		schema = map[string]any{"type": "string", "format": "binary"}
+	default:
+		schema = map[string]any{"type": "string"}
+	}
+	return map[string]any{mediaType: map[string]any{"schema": schema}}
+}
+
+// parameters lists the parameters of the route at path: its path
+// parameters, in order, followed by params.
+func parameters(path string, params []Param) []map[string]any {
+	var out []map[string]any
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		out = append(out, map[string]any{
+			"name": m[1], "in": "path", "required": true,
+			"schema": map[string]any{"type": "string"},
+		})
+	}
+	for _, p := range params {
+		in, typ := p.In, p.Type
+		if in == "" {
+			in = "query"
+		}
+		if typ == "" {
+			typ = "string"
+		}
+		schema := map[string]any{"type": typ}
+		if p.Repeated {
+			schema = map[string]any{"type": "array", "items": schema}
+		}
+		param := map[string]any{"name": p.Name, "in": in, "schema": schema}
+		if p.Description != "" {
+			param["description"] = p.Description
+		}
+		if p.Required {
+			param["required"] = true
+		}
+		out = append(out, param)
+	}
+	return out
+}
+
+// funcName returns the runtime name of h.
+func funcName(h gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+}
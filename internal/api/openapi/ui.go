@@ -0,0 +1,89 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: ui.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the Swagger UI page of the OpenAPI document.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package openapi
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerUIVersion is the release of swagger-ui-dist the page loads.
+const swaggerUIVersion = "5.17.14"
+
+// The Subresource Integrity hashes of the assets of swaggerUIVersion, so
+// that the browser refuses them should the CDN serve anything else. They
+// change with the version; compute them with
+//
+//	curl -sL https://unpkg.com/swagger-ui-dist@<version>/<file> | openssl dgst -sha384 -binary | openssl base64 -A
+//
+// and prefix "sha384-". Until both are set, /docs answers 503 rather than
+// run unverified script on this origin.
+const (
+	swaggerUICSSIntegrity    = ""
+	swaggerUIBundleIntegrity = ""
+)
+
+// The assets come from a CDN, so the page needs the browser to reach
+// unpkg.com; the document itself is served by this server.
+var uiPage = template.Must(template.New("ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>AirSense API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui.css" integrity="{{.CSSIntegrity}}" crossorigin="anonymous">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-bundle.js" integrity="{{.BundleIntegrity}}" crossorigin="anonymous"></script>
+<script>
+window.onload = () => {
+  window.ui = SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui"});
+};
+</script>
+</body>
+</html>
+`))
+
+type uiAssets struct {
+	Version, CSSIntegrity, BundleIntegrity string
+}
+
+var swaggerUI = uiAssets{swaggerUIVersion, swaggerUICSSIntegrity, swaggerUIBundleIntegrity}
+
+// UI serves Swagger UI showing the document at specURL.
+func UI(specURL string) gin.HandlerFunc {
+	return serveUI(swaggerUI, specURL)
+}
+
+func serveUI(assets uiAssets, specURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if assets.CSSIntegrity == "" || assets.BundleIntegrity == "" {
+			c.String(http.StatusServiceUnavailable, "Swagger UI %s is not pinned to integrity hashes; the document is at %s.", assets.Version, specURL)
+			return
+		}
+		var page bytes.Buffer
+		err := uiPage.Execute(&page, struct {
+			uiAssets
+			SpecURL string
+		}{assets, specURL})
+		if err != nil {
+			log.Printf("openapi: render ui: %v", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: ui_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the Swagger UI page of the OpenAPI document.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUI(t *testing.T) {
+	pinned := uiAssets{"5.17.14", "sha384-css", "sha384-bundle"}
+	tests := []struct {
+		name       string
+		assets     uiAssets
+		wantStatus int
+		want       []string
+	}{
+		{"pinned", pinned, http.StatusOK, []string{
+			`href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css" integrity="sha384-css" crossorigin="anonymous"`,
+			`src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js" integrity="sha384-bundle" crossorigin="anonymous"`,
+			`url: "/openapi.json"`,
+		}},
+		{"stylesheet not pinned", uiAssets{"5.17.14", "", "sha384-bundle"}, http.StatusServiceUnavailable, []string{"/openapi.json"}},
+		{"bundle not pinned", uiAssets{"5.17.14", "sha384-css", ""}, http.StatusServiceUnavailable, []string{"/openapi.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/docs", nil)
+			serveUI(tt.assets, "/openapi.json")(c)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(w.Body.String(), s) {
+					t.Errorf("page lacks %s:\n%s", s, w.Body)
+				}
+			}
+			if tt.wantStatus != http.StatusOK && strings.Contains(w.Body.String(), "<script") {
+				t.Error("an unpinned page loads script")
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: routes.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the route table of the AirSense REST API.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package api
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/handlers"
+	"airsense-be.com/internal/api/middleware"
+	"airsense-be.com/internal/api/openapi"
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+)
+
+type Handlers struct {
+	Admin          *handlers.AdminHandler
+	APIKeys        *handlers.APIKeyHandler
+	Health         *handlers.HealthHandler
+	Auth           *handlers.AuthHandler
+	Commands       *handlers.CommandHandler
+	CommandBatches *handlers.CommandBatchHandler
+	Schedules      *handlers.CommandScheduleHandler
+	Dashboard      *handlers.DashboardHandler
+	Events         *handlers.EventHandler
+	Groups         *handlers.GroupHandler
+	MQTTAuth       *handlers.MQTTAuthHandler
+	OIDC           *handlers.OIDCHandler
+	Devices        *handlers.DeviceHandler
+	DeviceKeys     *handlers.DeviceKeyHandler
+	Notifications  *handlers.NotificationHandler
+	Orgs           *handlers.OrgHandler
+	Profile        *handlers.ProfileHandler
+	Provisioning   *handlers.ProvisioningHandler
+	Sensors        *handlers.SensorHandler
+	Shares         *handlers.ShareHandler
+	Silences       *handlers.SilenceHandler
+	Transfers      *handlers.TransferHandler
+	ScopedTokens   *handlers.ScopedTokenHandler
+
+	// GraphQL is nil unless the GraphQL endpoint is enabled.
+	GraphQL *handlers.GraphQLHandler
+}
+
+// apiVersion is the version of the API in the OpenAPI document.
+const apiVersion = "1.0.0"
+
+// streamRoutes are exempt from the request timeout: they last as long as
+// the client listens or the export takes.
+var streamRoutes = []string{
+	"/api/v1/devices/:id/events",
+	"/api/v1/devices/:id/sensors/stream",
+	"/api/v1/devices/:id/telemetry.ndjson",
+	"/api/v1/devices/:id/sensors/export",
+	"/api/v1/graphql/stream",
+}
+
+// NewRouter builds the routes of cfg. Middleware that applies
+// configuration changes is registered with watcher.
+func NewRouter(cfg *config.Config, watcher *config.Watcher, devices *service.DeviceService, keys *service.DeviceKeyService,
+	apiKeys *service.APIKeyService, sessions *service.SessionService, scopedTokens *service.ScopedTokenService,
+	users repository.UserRepository, auditLog *audit.Log, h Handlers) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Logger(), gin.Recovery(), middleware.CORS(cfg.Server.CORS), middleware.Compress(cfg.Server.CompressionMinSize),
+		middleware.Timeout(cfg.Server.RequestTimeout, streamRoutes...))
+
+	// Routes are registered through the spec, which documents them with
+	// the Docs of their handlers.
+	spec := openapi.New("AirSense API", apiVersion, handlers.ErrorResponse{})
+	spec.Describe(h.Admin, h.APIKeys, h.Health, h.Auth, h.Commands, h.CommandBatches, h.Schedules, h.Dashboard,
+		h.Events, h.Groups, h.MQTTAuth, h.OIDC, h.Devices, h.DeviceKeys, h.Notifications, h.Orgs, h.Profile, h.Provisioning, h.Sensors,
+		h.Shares, h.Silences, h.Transfers, h.ScopedTokens, h.GraphQL)
+	r.GET("/openapi.json", spec.Handler())
+	r.GET("/docs", openapi.UI("/openapi.json"))
+
+	root := spec.Router(&r.RouterGroup)
+	root.GET("/healthz", h.Health.Live)
+	root.GET("/readyz", h.Health.Ready)
+
+	public := spec.Router(r.Group("/api/v1"))
+	public.POST("/auth/login", h.Auth.Login)
+	public.POST("/auth/refresh", h.Auth.Refresh)
+	// The per-IP limits follow reloads of the configuration.
+	clientLimit := func(setting func(config.Config) int) gin.HandlerFunc {
+		l := middleware.NewClientRateLimiter(*cfg, time.Hour, setting)
+		watcher.Register(l)
+		return l.Handler()
+	}
+	public.POST("/auth/forgot-password", clientLimit(func(c config.Config) int { return c.JWT.PasswordResetIPLimit }), h.Auth.ForgotPassword)
+	public.POST("/auth/reset-password", h.Auth.ResetPassword)
+	registerLimit := clientLimit(func(c config.Config) int { return c.JWT.RegisterIPLimit })
+	public.POST("/auth/register", registerLimit, h.Auth.Register)
+	public.GET("/auth/verify", h.Auth.VerifyLink)
+	public.POST("/auth/verify", h.Auth.Verify)
+	public.POST("/auth/verify/resend", registerLimit, h.Auth.ResendVerification)
+	// Every sign-in stores its state until it expires.
+	public.GET("/auth/oidc/login", clientLimit(func(c config.Config) int { return c.OIDC.LoginIPLimit }), h.OIDC.Login)
+	public.GET("/auth/oidc/callback", h.OIDC.Callback)
+	public.POST("/auth/oidc/exchange", h.OIDC.Exchange)
+	public.POST("/auth/oidc/link", h.OIDC.Link)
+
+	hooks := spec.Router(r.Group("/internal/mqtt", middleware.SharedSecret("X-Webhook-Secret", cfg.MQTT.AuthWebhookSecret)),
+		openapi.WebhookSecret)
+	hooks.POST("/auth", h.MQTTAuth.Authenticate)
+	hooks.POST("/acl", h.MQTTAuth.Authorize)
+
+	// Called by devices with an API key instead of a user JWT.
+	public.With(openapi.DeviceKeyAuth).POST("/devices/:id/telemetry", middleware.DeviceAuth(keys), h.Sensors.Ingest)
+	// Called by unregistered devices with a provisioning token.
+	public.POST("/provision", h.Provisioning.Provision)
+
+	keyLimiter := middleware.NewKeyRateLimiter(cfg.Server.APIKeyRateLimit, cfg.Server.APIKeyBurst)
+	watcher.Register(keyLimiter)
+	keyLimit := keyLimiter.Handler()
+	// Changes made through the authenticated routes are audited.
+	audited := middleware.Audit(auditLog)
+	v1 := spec.Router(r.Group("/api/v1", middleware.Auth(cfg.JWT.Secret, apiKeys, sessions), keyLimit, audited),
+		openapi.BearerAuth, openapi.APIKeyAuth)
+
+	read := middleware.DeviceAccess(devices, service.AccessRead)
+	control := middleware.DeviceAccess(devices, service.AccessControl)
+	owner := middleware.DeviceAccess(devices, service.AccessOwner)
+
+	// EventSource cannot send an Authorization header.
+	public.With(openapi.BearerAuth, openapi.APIKeyAuth).GET("/devices/:id/sensors/stream", middleware.QueryToken(), middleware.ScopedAuth(cfg.JWT.Secret, apiKeys, sessions, scopedTokens), keyLimit, read, h.Events.SensorStream)
+
+	// Account routes take user tokens only: an API key reaches devices and
+	// their data, not the account, its credentials or who else has access.
+	account := v1.With(openapi.BearerAuth).Group("", middleware.SessionOnly())
+	account.POST("/auth/logout-all", h.Auth.LogoutAll)
+
+	userKeys := account.Group("/apikeys")
+	userKeys.POST("", h.APIKeys.Create)
+	userKeys.GET("", h.APIKeys.List)
+	userKeys.GET("/:keyId", h.APIKeys.Get)
+	userKeys.PATCH("/:keyId", h.APIKeys.Update)
+	userKeys.DELETE("/:keyId", h.APIKeys.Delete)
+
+	userTokens := account.Group("/scoped-tokens")
+	userTokens.POST("", h.ScopedTokens.Create)
+	userTokens.GET("", h.ScopedTokens.List)
+	userTokens.DELETE("/:tokenId", h.ScopedTokens.Revoke)
+
+	v1.GET("/dashboard", h.Dashboard.Get)
+	v1.GET("/telemetry/latest", h.Dashboard.Latest)
+	v1.GET("/telemetry/compare", h.Dashboard.Compare)
+	v1.POST("/commands", h.Commands.Send)
+	v1.POST("/commands/:commandId/cancel", h.Commands.Cancel)
+	v1.POST("/commands/bulk", h.CommandBatches.Create)
+	v1.GET("/commands/bulk/:batchId", h.CommandBatches.Get)
+	v1.POST("/groups", h.Groups.Create)
+	v1.GET("/groups", h.Groups.List)
+	v1.GET("/groups/:id", h.Groups.Get)
+	v1.DELETE("/groups/:id", h.Groups.Delete)
+	v1.GET("/groups/:id/sensors/latest", h.Groups.Latest)
+	v1.GET("/devices", h.Devices.List)
+	v1.POST("/devices", h.Devices.Register)
+	v1.POST("/devices/import", h.Devices.Import)
+
+	device := v1.Group("/devices/:id")
+	device.DELETE("", owner, h.Devices.Delete)
+	device.DELETE("/purge", h.Devices.Purge)
+	device.POST("/decommission", owner, h.Devices.Decommission)
+	device.DELETE("/sensors", middleware.AdminOr(users, owner), h.Sensors.Delete)
+
+	deviceAccount := account.Group("/devices/:id")
+	deviceAccount.POST("/keys", owner, h.DeviceKeys.Mint)
+	deviceAccount.GET("/keys", owner, h.DeviceKeys.List)
+	deviceAccount.DELETE("/keys/:keyId", owner, h.DeviceKeys.Revoke)
+	deviceAccount.POST("/mqtt-credentials/rotate", owner, h.MQTTAuth.Rotate)
+	deviceAccount.POST("/transfers", owner, h.Transfers.Initiate)
+	deviceAccount.POST("/transfer", owner, h.Transfers.Transfer)
+	deviceAccount.POST("/shares", owner, h.Shares.Create)
+	deviceAccount.GET("/shares", owner, h.Shares.List)
+	deviceAccount.DELETE("/shares/:shareId", owner, h.Shares.Revoke)
+
+	// Routes that need no more than read or control access also take the
+	// device-scoped tokens of the device, limited by DeviceAccess.
+	scoped := spec.Router(r.Group("/api/v1/devices/:id", middleware.ScopedAuth(cfg.JWT.Secret, apiKeys, sessions, scopedTokens), keyLimit, audited),
+		openapi.BearerAuth, openapi.APIKeyAuth)
+	scoped.PATCH("", control, h.Devices.Patch)
+	scoped.PUT("/tags/:key", control, h.Devices.SetTag)
+	scoped.DELETE("/tags/:key", control, h.Devices.RemoveTag)
+	scoped.GET("/events", read, h.Events.Stream)
+	scoped.GET("/sensors", read, h.Sensors.List)
+	scoped.GET("/telemetry.ndjson", read, h.Sensors.Export)
+	scoped.GET("/sensors/export", read, h.Sensors.ExportFile)
+	scoped.GET("/sensors/aggregate", read, h.Sensors.Aggregate)
+	scoped.GET("/sensors/stats", read, h.Sensors.Stats)
+	scoped.GET("/sensors/anomalies", read, h.Sensors.Anomalies)
+	scoped.GET("/sensors/smoothed", read, h.Sensors.Smoothed)
+	scoped.GET("/score", read, h.Sensors.Score)
+	scoped.POST("/sensors/bulk", control, h.Sensors.BulkUpload)
+	scoped.POST("/sensors/interpolate", control, h.Sensors.Interpolate)
+	scoped.PATCH("/sensors/:readingId/tags", control, h.Sensors.MergeTags)
+	scoped.POST("/commands", control, h.Commands.Create)
+	scoped.GET("/commands", read, h.Commands.List)
+	scoped.POST("/firmware", control, h.Commands.UpdateFirmware)
+	scoped.POST("/silences", control, h.Silences.Create)
+	scoped.GET("/silences", read, h.Silences.List)
+	scoped.GET("/silences/:silenceId", read, h.Silences.Get)
+	scoped.PUT("/silences/:silenceId", control, h.Silences.Update)
+	scoped.DELETE("/silences/:silenceId", control, h.Silences.Delete)
+	scoped.GET("/commands/:commandId", read, h.Commands.Get)
+	scoped.POST("/commands/:commandId/status", control, h.Commands.UpdateStatus)
+	scoped.POST("/schedules", control, h.Schedules.Create)
+	scoped.GET("/schedules", read, h.Schedules.List)
+	scoped.GET("/schedules/:scheduleId", read, h.Schedules.Get)
+	scoped.PUT("/schedules/:scheduleId", control, h.Schedules.Update)
+	scoped.DELETE("/schedules/:scheduleId", control, h.Schedules.Delete)
+	scoped.GET("/schedules/:scheduleId/runs", read, h.Schedules.Runs)
+
+	account.POST("/transfers/accept", h.Transfers.Accept)
+
+	account.POST("/orgs", h.Orgs.Create)
+	account.GET("/orgs", h.Orgs.List)
+	account.POST("/orgs/invitations/accept", h.Orgs.Accept)
+	account.GET("/orgs/:orgId", h.Orgs.Get)
+	account.GET("/orgs/:orgId/members", h.Orgs.Members)
+	account.DELETE("/orgs/:orgId/members/:userId", h.Orgs.RemoveMember)
+	account.POST("/orgs/:orgId/invitations", h.Orgs.Invite)
+	account.GET("/orgs/:orgId/devices", h.Orgs.Devices)
+	account.POST("/orgs/:orgId/devices", h.Orgs.AddDevice)
+	account.DELETE("/orgs/:orgId/devices/:deviceId", h.Orgs.RemoveDevice)
+
+	if h.GraphQL != nil {
+		v1.GET("/graphql", h.GraphQL.Query)
+		v1.POST("/graphql", h.GraphQL.Query)
+		v1.GET("/graphql/stream", h.GraphQL.Stream)
+	}
+
+	admin := account.Group("/admin", middleware.Admin(users))
+	admin.PUT("/users/:id/quota", h.Admin.SetQuota)
+	admin.POST("/users/:id/revoke-sessions", h.Admin.RevokeSessions)
+	admin.GET("/devices", h.Admin.ListDevices)
+	admin.GET("/stats", h.Admin.Stats)
+	admin.GET("/audit", h.Admin.AuditLog)
+	admin.POST("/provisioning-tokens", h.Provisioning.CreateToken)
+
+	// Metrics sit where expvar puts them, for scrapers that expect them there.
+	debug := spec.Router(r.Group("/debug", middleware.Auth(cfg.JWT.Secret, apiKeys, sessions), middleware.SessionOnly(), middleware.Admin(users)),
+		openapi.BearerAuth)
+	debug.GET("/vars", h.Admin.Metrics)
+
+	account.GET("/users/me", h.Profile.Get)
+	account.PATCH("/users/me", h.Profile.Update)
+	account.POST("/users/me/password", clientLimit(func(c config.Config) int { return c.JWT.PasswordChangeIPLimit }), h.Profile.ChangePassword)
+	account.GET("/users/me/notifications", h.Notifications.Get)
+	account.PUT("/users/me/notifications", h.Notifications.Update)
+	account.POST("/users/devices/fcm", h.Notifications.RegisterFCM)
+
+	return r
+}
@@ -0,0 +1,149 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: routes_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the route table of the REST API.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"airsense-be.com/internal/api/handlers"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+	"airsense-be.com/internal/utils"
+)
+
+const testSecret = "test-secret-that-is-long-enough-for-hmac"
+
+type roleUsers struct {
+	repository.UserRepository
+	roles map[string]string
+}
+
+func (f *roleUsers) GetByID(_ context.Context, id string) (*models.User, error) {
+	role, ok := f.roles[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &models.User{ID: id, Role: role}, nil
+}
+
+func TestRouterMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := &roleUsers{roles: map[string]string{"admin": models.RoleAdmin, "user": ""}}
+	cfg := &config.Config{JWT: config.JWTConfig{Secret: testSecret, SessionCacheTTL: time.Minute}}
+	sessions := service.NewSessionService(users, nil, cfg.JWT)
+	r := NewRouter(cfg, config.NewWatcher(cfg), nil, nil, nil, sessions, nil, users, nil,
+		Handlers{Admin: handlers.NewAdminHandler(nil, nil, nil, nil, nil)})
+	metrics.MQTTDeduplicated.Add(1)
+
+	tests := []struct {
+		name   string
+		userID string
+		want   int
+	}{
+		{"admin", "admin", http.StatusOK},
+		{"user", "user", http.StatusForbidden},
+		{"anonymous", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+			if tt.userID != "" {
+				token, err := utils.GenerateToken(tt.userID, "", testSecret, time.Hour)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			var vars map[string]json.RawMessage
+			if err := json.Unmarshal(rec.Body.Bytes(), &vars); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			var n int64
+			if err := json.Unmarshal(vars["mqtt_messages_deduplicated_total"], &n); err != nil || n < 1 {
+				t.Errorf("mqtt_messages_deduplicated_total = %s, want at least 1", vars["mqtt_messages_deduplicated_total"])
+			}
+		})
+	}
+}
+
+func TestRouterMetricsDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	r := NewRouter(cfg, config.NewWatcher(cfg), nil, nil, nil, nil, nil, nil, nil,
+		Handlers{Admin: handlers.NewAdminHandler(nil, nil, nil, nil, nil)})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Summary string `json:"summary"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if op, ok := doc.Paths["/debug/vars"]["get"]; !ok || op.Summary == "" {
+		t.Errorf("GET /debug/vars is not documented: %+v", doc.Paths["/debug/vars"])
+	}
+}
+
+// TestRouterClientLimitReload checks that the per-IP limits are registered
+// with the watcher: a reload raises the forgot-password limit.
+func TestRouterClientLimitReload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("PASSWORD_RESET_IP_LIMIT", "3")
+	cfg := &config.Config{JWT: config.JWTConfig{PasswordResetIPLimit: 1}}
+	watcher := config.NewWatcher(cfg)
+	r := NewRouter(cfg, watcher, nil, nil, nil, nil, nil, nil, nil, Handlers{})
+	forgot := func(ip string, n int) []int {
+		codes := make([]int, n)
+		for i := range n {
+			// The body is invalid, so nothing past the limiter is needed.
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/forgot-password", nil)
+			req.RemoteAddr = ip + ":1234"
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			codes[i] = rec.Code
+		}
+		return codes
+	}
+
+	const bad, limited = http.StatusBadRequest, http.StatusTooManyRequests
+	if got := forgot("192.0.2.1", 2); !slices.Equal(got, []int{bad, limited}) {
+		t.Fatalf("before the reload: %v, want %v", got, []int{bad, limited})
+	}
+	if err := watcher.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := forgot("192.0.2.2", 4), []int{bad, bad, bad, limited}; !slices.Equal(got, want) {
+		t.Errorf("after the reload: %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: tls.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the TLS setup and HTTPS redirect of the AirSense HTTP server.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"airsense-be.com/internal/config"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSEnabled reports whether the server should serve HTTPS.
+func TLSEnabled(cfg config.TLSConfig) bool {
+	return cfg.AutoCert || (cfg.CertFile != "" && cfg.KeyFile != "")
+}
+
+// NewTLSConfig builds the server tls.Config. With AutoCert the certificates
+// come from Let's Encrypt through the returned manager, which must also
+// answer the ACME challenges on port 80; otherwise the manager is nil and
+// the caller loads CertFile/KeyFile with ListenAndServeTLS.
+func NewTLSConfig(cfg config.TLSConfig) (*tls.Config, *autocert.Manager, error) {
+	var minVersion uint16 = tls.VersionTLS12
+	if cfg.MinVersion != "" {
+		v, ok := tlsVersions[cfg.MinVersion]
+		if !ok {
+			return nil, nil, fmt.Errorf("tls: unsupported minimum version %q, want 1.2 or 1.3", cfg.MinVersion)
+		}
+		minVersion = v
+	}
+
+	if !cfg.AutoCert {
+		return &tls.Config{MinVersion: minVersion}, nil, nil
+	}
+	if cfg.AutoCertDomain == "" {
+		return nil, nil, fmt.Errorf("tls: TLS_AUTOCERT_DOMAIN is required with TLS_AUTOCERT")
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.AutoCertDomain),
+		Cache:      autocert.DirCache(cfg.AutoCertCacheDir),
+	}
+	tc := m.TLSConfig()
+	tc.MinVersion = minVersion
+	return tc, m, nil
+}
+
+// RedirectHandler sends every plain HTTP request to the same URL over HTTPS
+// on httpsPort.
+func RedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,128 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: tls_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the TLS setup of the HTTP server.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"airsense-be.com/internal/config"
+)
+
+func TestTLSEnabled(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.TLSConfig
+		want bool
+	}{
+		{"nothing set", config.TLSConfig{}, false},
+		{"cert only", config.TLSConfig{CertFile: "cert.pem"}, false},
+		{"key only", config.TLSConfig{KeyFile: "key.pem"}, false},
+		{"cert and key", config.TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}, true},
+		{"autocert", config.TLSConfig{AutoCert: true, AutoCertDomain: "api.example.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TLSEnabled(tt.cfg); got != tt.want {
+				t.Errorf("TLSEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.TLSConfig
+		wantMin     uint16
+		wantManager bool
+		wantErr     bool
+	}{
+		{"default minimum", config.TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}, tls.VersionTLS12, false, false},
+		{"minimum 1.2", config.TLSConfig{MinVersion: "1.2"}, tls.VersionTLS12, false, false},
+		{"minimum 1.3", config.TLSConfig{MinVersion: "1.3"}, tls.VersionTLS13, false, false},
+		{"minimum 1.1 refused", config.TLSConfig{MinVersion: "1.1"}, 0, false, true},
+		{"minimum garbage refused", config.TLSConfig{MinVersion: "tls13"}, 0, false, true},
+		{"autocert", config.TLSConfig{AutoCert: true, AutoCertDomain: "api.example.com", AutoCertCacheDir: t.TempDir()}, tls.VersionTLS12, true, false},
+		{"autocert minimum 1.3", config.TLSConfig{AutoCert: true, AutoCertDomain: "api.example.com", MinVersion: "1.3", AutoCertCacheDir: t.TempDir()}, tls.VersionTLS13, true, false},
+		{"autocert without domain", config.TLSConfig{AutoCert: true}, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, m, err := NewTLSConfig(tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewTLSConfig() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTLSConfig() error = %v", err)
+			}
+			if tc.MinVersion != tt.wantMin {
+				t.Errorf("MinVersion = %#x, want %#x", tc.MinVersion, tt.wantMin)
+			}
+			if (m != nil) != tt.wantManager {
+				t.Fatalf("manager = %v, want one: %v", m, tt.wantManager)
+			}
+			if m == nil {
+				if tc.GetCertificate != nil {
+					t.Error("GetCertificate is set without autocert")
+				}
+				return
+			}
+			if tc.GetCertificate == nil {
+				t.Error("GetCertificate is not set with autocert")
+			}
+			if !slices.Contains(tc.NextProtos, "acme-tls/1") {
+				t.Errorf("NextProtos = %v, want acme-tls/1", tc.NextProtos)
+			}
+			if err := m.HostPolicy(context.Background(), "api.example.com"); err != nil {
+				t.Errorf("HostPolicy(configured domain) = %v", err)
+			}
+			if err := m.HostPolicy(context.Background(), "evil.example.com"); err == nil {
+				t.Error("HostPolicy(other domain) = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name  string
+		port  string
+		host  string
+		path  string
+		wantL string
+	}{
+		{"default port", "443", "api.example.com", "/api/v1/devices?limit=5", "https://api.example.com/api/v1/devices?limit=5"},
+		{"default port, host with port", "443", "api.example.com:80", "/", "https://api.example.com/"},
+		{"other port", "8443", "api.example.com:8080", "/health", "https://api.example.com:8443/health"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://"+tt.host+tt.path, nil)
+			rec := httptest.NewRecorder()
+			RedirectHandler(tt.port).ServeHTTP(rec, req)
+			if rec.Code != http.StatusMovedPermanently {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusMovedPermanently)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantL {
+				t.Errorf("Location = %q, want %q", got, tt.wantL)
+			}
+		})
+	}
+}
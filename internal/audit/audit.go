@@ -0,0 +1,165 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: audit.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the audit log of changes made by users.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+// Package audit keeps a trail of who changed what. The services Record
+// each change once it is made; the entries of a request are collected in
+// its context and written, as done by the authenticated user, when the
+// request ends (see middleware.Audit). Changes made outside a request,
+// e.g. by the command scheduler, are not recorded.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// Resource types and actions of the entries.
+const (
+	ResourceDevice  = "device"
+	ResourceCommand = "command"
+	ResourceUser    = "user"
+	ResourceOrg     = "organization"
+
+	ActionDeviceCreate       = "device.create"
+	ActionDeviceRestore      = "device.restore"
+	ActionDeviceUpdate       = "device.update"
+	ActionDeviceDelete       = "device.delete"
+	ActionDevicePurge        = "device.purge"
+	ActionDeviceDecommission = "device.decommission"
+	ActionDeviceCalibrate    = "device.calibrate"
+	ActionTransferOffer      = "device.transfer_offer"
+	ActionTransfer           = "device.transfer"
+	ActionCommandPublish     = "command.publish"
+	ActionUserUpdate         = "user.update"
+	ActionUserPasswordChange = "user.password_change"
+	ActionOrgCreate          = "organization.create"
+	ActionOrgInvite          = "organization.invite"
+	ActionOrgMemberAdd       = "organization.member_add"
+	ActionOrgMemberRemove    = "organization.member_remove"
+)
+
+type collectorKey struct{}
+
+// Collector gathers the entries recorded during one request.
+type Collector struct {
+	mu      sync.Mutex
+	entries []models.AuditEntry
+}
+
+// Collect returns a context in which Record adds to the returned
+// collector.
+func Collect(ctx context.Context) (context.Context, *Collector) {
+	c := &Collector{}
+	return context.WithValue(ctx, collectorKey{}, c), c
+}
+
+// Entries returns what was recorded so far.
+func (c *Collector) Entries() []models.AuditEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]models.AuditEntry(nil), c.entries...)
+}
+
+// Record notes that action was done to a resource, with the changes in
+// diff. It is a no-op when ctx collects no entries.
+func Record(ctx context.Context, action, resourceType, resourceID string, diff models.AuditDiff) {
+	c, ok := ctx.Value(collectorKey{}).(*Collector)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, models.AuditEntry{
+		ID:           primitive.NewObjectID().Hex(),
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Diff:         diff,
+		Timestamp:    time.Now().UTC(),
+	})
+}
+
+// Changes returns the top-level JSON fields that differ between before and
+// after, either of which may be nil. updated_at is left out.
+func Changes(before, after any) models.AuditDiff {
+	from, to := fields(before), fields(after)
+	diff := models.AuditDiff{}
+	for k, v := range from {
+		if w, ok := to[k]; !ok || !reflect.DeepEqual(v, w) {
+			diff[k] = models.AuditChange{From: v, To: to[k]}
+		}
+	}
+	for k, w := range to {
+		if _, ok := from[k]; !ok {
+			diff[k] = models.AuditChange{To: w}
+		}
+	}
+	delete(diff, "updated_at")
+	return diff
+}
+
+// fields returns the JSON object v encodes to, nil for nil.
+func fields(v any) map[string]any {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil() {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if json.Unmarshal(b, &m) != nil {
+		return nil
+	}
+	return m
+}
+
+// Log stores the entries.
+type Log struct {
+	repo repository.AuditRepository
+}
+
+func NewLog(repo repository.AuditRepository) *Log {
+	return &Log{repo: repo}
+}
+
+// Write stores entries as done by userID. The changes they record are made
+// already, so a failure is logged and counted in audit_writes_failed_total
+// rather than returned.
+func (l *Log) Write(ctx context.Context, userID string, entries []models.AuditEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	for i := range entries {
+		entries[i].UserID = userID
+	}
+	if err := l.repo.CreateMany(ctx, entries); err != nil {
+		metrics.AuditWritesFailed.Add(int64(len(entries)))
+		for _, e := range entries {
+			log.Printf("audit: write %s of %s %s by user %s: %v", e.Action, e.ResourceType, e.ResourceID, userID, err)
+		}
+	}
+}
+
+// List returns the entries matching filter, newest first.
+func (l *Log) List(ctx context.Context, filter repository.AuditFilter) ([]models.AuditEntry, error) {
+	return l.repo.List(ctx, filter)
+}
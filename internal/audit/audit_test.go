@@ -0,0 +1,154 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: audit_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of recording and writing the audit log.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type thing struct {
+	Name      string   `json:"name"`
+	Tags      []string `json:"tags,omitempty"`
+	Owner     string   `json:"owner"`
+	UpdatedAt int      `json:"updated_at"`
+}
+
+func TestChanges(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after any
+		want          models.AuditDiff
+	}{
+		{
+			name:   "created",
+			before: (*thing)(nil),
+			after:  &thing{Name: "a", Owner: "u1"},
+			want:   models.AuditDiff{"name": {To: "a"}, "owner": {To: "u1"}},
+		},
+		{
+			name:   "deleted",
+			before: &thing{Name: "a", Owner: "u1"},
+			want:   models.AuditDiff{"name": {From: "a"}, "owner": {From: "u1"}},
+		},
+		{
+			name:   "one field changed",
+			before: &thing{Name: "a", Owner: "u1", UpdatedAt: 1},
+			after:  &thing{Name: "b", Owner: "u1", UpdatedAt: 2},
+			want:   models.AuditDiff{"name": {From: "a", To: "b"}},
+		},
+		{
+			name:   "field added and removed",
+			before: &thing{Name: "a", Tags: []string{"x"}},
+			after:  &thing{Name: "a"},
+			want:   models.AuditDiff{"tags": {From: []any{"x"}}},
+		},
+		{
+			name:   "nothing changed",
+			before: thing{Name: "a", UpdatedAt: 1},
+			after:  thing{Name: "a", UpdatedAt: 2},
+			want:   models.AuditDiff{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Changes(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	// Without a collector nothing is recorded, and nothing breaks.
+	Record(context.Background(), ActionDeviceUpdate, ResourceDevice, "d1", nil)
+
+	ctx, c := Collect(context.Background())
+	Record(ctx, ActionDeviceCreate, ResourceDevice, "d1", models.AuditDiff{"name": {To: "a"}})
+	Record(ctx, ActionCommandPublish, ResourceCommand, "c1", nil)
+	entries := c.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("recorded %d entries, want 2", len(entries))
+	}
+	for i, want := range []models.AuditEntry{
+		{Action: ActionDeviceCreate, ResourceType: ResourceDevice, ResourceID: "d1"},
+		{Action: ActionCommandPublish, ResourceType: ResourceCommand, ResourceID: "c1"},
+	} {
+		e := entries[i]
+		if e.Action != want.Action || e.ResourceType != want.ResourceType || e.ResourceID != want.ResourceID {
+			t.Errorf("entry %d = %s %s %s, want %s %s %s", i, e.Action, e.ResourceType, e.ResourceID,
+				want.Action, want.ResourceType, want.ResourceID)
+		}
+		if e.ID == "" || e.Timestamp.IsZero() {
+			t.Errorf("entry %d has no ID or timestamp", i)
+		}
+	}
+	if entries[0].ID == entries[1].ID {
+		t.Error("entries share an ID")
+	}
+}
+
+type memAudit struct {
+	repository.AuditRepository
+	err     error
+	written []models.AuditEntry
+}
+
+func (r *memAudit) CreateMany(_ context.Context, entries []models.AuditEntry) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.written = append(r.written, entries...)
+	return nil
+}
+
+func TestLogWrite(t *testing.T) {
+	tests := []struct {
+		name        string
+		entries     int
+		err         error
+		wantWritten int
+		wantFailed  int64
+	}{
+		{name: "written as done by the user", entries: 2, wantWritten: 2},
+		{name: "nothing to write", entries: 0},
+		{name: "failure counted", entries: 3, err: errors.New("down"), wantFailed: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memAudit{err: tt.err}
+			ctx, c := Collect(context.Background())
+			for range tt.entries {
+				Record(ctx, ActionDeviceUpdate, ResourceDevice, "d1", nil)
+			}
+			failed := metrics.AuditWritesFailed.Value()
+			NewLog(repo).Write(context.Background(), "u1", c.Entries())
+			if len(repo.written) != tt.wantWritten {
+				t.Errorf("wrote %d entries, want %d", len(repo.written), tt.wantWritten)
+			}
+			for _, e := range repo.written {
+				if e.UserID != "u1" {
+					t.Errorf("entry of user %q, want u1", e.UserID)
+				}
+			}
+			if got := metrics.AuditWritesFailed.Value() - failed; got != tt.wantFailed {
+				t.Errorf("counted %d failed writes, want %d", got, tt.wantFailed)
+			}
+		})
+	}
+}
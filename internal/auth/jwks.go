@@ -0,0 +1,197 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: jwks.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the cache of the signing keys of an OpenID Connect provider.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minKeysRefresh is the least time between two fetches of a key set, so
+// tokens naming made-up keys cannot make us hammer the provider.
+const minKeysRefresh = time.Minute
+
+// maxKeysBytes bounds the key set document read from a provider.
+const maxKeysBytes = 1 << 20
+
+// keySet caches the JSON Web Key Set of a provider by key ID. It is
+// fetched when first needed and again once older than ttl, or sooner when
+// a token names a key the set lacks, as happens after the provider rotated
+// its keys. Should a refresh fail, the cached keys stay in use.
+type keySet struct {
+	client *http.Client
+	url    string
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func newKeySet(client *http.Client, url string, ttl time.Duration) *keySet {
+	return &keySet{client: client, url: url, ttl: ttl}
+}
+
+// key returns the public key kid. An empty kid names the only key of sets
+// with a single one.
+func (s *keySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	age := time.Since(s.fetchedAt)
+	k, ok := s.lookup(kid)
+	if ok && age < s.ttl {
+		return k, nil
+	}
+	if !ok && age < minKeysRefresh {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidIDToken, kid)
+	}
+	if err := s.refresh(ctx); err != nil {
+		if ok {
+			log.Printf("auth: refresh oidc signing keys: %v", err)
+			return k, nil
+		}
+		return nil, err
+	}
+	if k, ok = s.lookup(kid); !ok {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidIDToken, kid)
+	}
+	return k, nil
+}
+
+func (s *keySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, k := range s.keys {
+			return k, true
+		}
+	}
+	k, ok := s.keys[kid]
+	return k, ok
+}
+
+// refresh fetches the key set. It counts as fetched also when it fails,
+// which spaces out the attempts.
+func (s *keySet) refresh(ctx context.Context) error {
+	s.fetchedAt = time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch oidc signing keys: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch oidc signing keys: %s", resp.Status)
+	}
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxKeysBytes)).Decode(&doc); err != nil {
+		return fmt.Errorf("decode oidc signing keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		k, err := jwk.publicKey()
+		if err != nil {
+			// Keys of types we do not know are skipped, not fatal.
+			log.Printf("auth: skip oidc signing key %q: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = k
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("oidc key set at %s has no usable signing key", s.url)
+	}
+	s.keys = keys
+	return nil
+}
+
+// jsonWebKey is an RSA or elliptic curve public key of a key set, see RFC
+// 7517 and 7518.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeKeyInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeKeyInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("bad RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeKeyInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeKeyInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeKeyInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("bad key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,281 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: oidc.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the OpenID Connect client signing users in with an external provider.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"airsense-be.com/internal/config"
+)
+
+var (
+	ErrInvalidIDToken = errors.New("invalid id token")
+	ErrCodeExchange   = errors.New("authorization code exchange failed")
+)
+
+const (
+	oidcTimeout = 10 * time.Second
+	// oidcScopes ask for the email address users are matched by.
+	oidcScopes = "openid email profile"
+	// idTokenLeeway allows for clock skew with the provider.
+	idTokenLeeway = time.Minute
+	// maxOIDCResponseBytes bounds the documents read from a provider.
+	maxOIDCResponseBytes = 1 << 20
+)
+
+// idTokenAlgs are the signature algorithms accepted on ID tokens. The
+// symmetric ones are left out: they would make the client secret a
+// signing key.
+var idTokenAlgs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// IDClaims are the claims of a verified ID token used to sign in.
+type IDClaims struct {
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	Name          string   `json:"name"`
+	Nonce         string   `json:"nonce"`
+	// AuthorizedParty is the client the token was issued to, set when
+	// the audience names others too.
+	AuthorizedParty string `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// flexBool also accepts "true" and "false", as some providers send
+// email_verified as a string.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case bool:
+		*b = flexBool(v)
+	case string:
+		*b = flexBool(v == "true")
+	}
+	return nil
+}
+
+// OIDCProvider signs users in with the authorization code flow of an
+// OpenID Connect provider, with PKCE. Its endpoints are discovered from
+// the issuer on first use.
+type OIDCProvider struct {
+	cfg    config.OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	endpoints *oidcEndpoints
+	keys      *keySet
+}
+
+type oidcEndpoints struct {
+	Issuer        string `json:"issuer"`
+	Authorization string `json:"authorization_endpoint"`
+	Token         string `json:"token_endpoint"`
+	JWKS          string `json:"jwks_uri"`
+}
+
+func NewOIDCProvider(cfg config.OIDCConfig) *OIDCProvider {
+	return &OIDCProvider{cfg: cfg, client: &http.Client{Timeout: oidcTimeout}}
+}
+
+// Issuer returns the issuer users of the provider are linked under.
+func (p *OIDCProvider) Issuer() string {
+	return p.cfg.IssuerURL
+}
+
+// AllowsEmail reports whether the domain of email may sign in.
+func (p *OIDCProvider) AllowsEmail(email string) bool {
+	if len(p.cfg.AllowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	return slices.ContainsFunc(p.cfg.AllowedDomains, func(d string) bool { return strings.EqualFold(d, domain) })
+}
+
+// AuthCodeURL returns the URL of the provider sending the user back to
+// the redirect URL with a code and state. The code can only be exchanged
+// with verifier, and the ID token will carry nonce.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {oidcScopes},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(ep.Authorization, "?") {
+		sep = "&"
+	}
+	return ep.Authorization + sep + q.Encode(), nil
+}
+
+// Exchange redeems an authorization code at the token endpoint and
+// returns the ID token, unverified.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.Token, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic, whose credentials are form-encoded first.
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oidc token request: %w", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOIDCResponseBytes)).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode oidc token response (%s): %w", resp.Status, err)
+	}
+	switch {
+	case body.Error != "":
+		return "", fmt.Errorf("%w: %s %s", ErrCodeExchange, body.Error, body.ErrorDescription)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("oidc token request: %s", resp.Status)
+	case body.IDToken == "":
+		return "", fmt.Errorf("%w: no id_token in the response", ErrCodeExchange)
+	}
+	return body.IDToken, nil
+}
+
+// Verify checks the signature of an ID token against the keys of the
+// provider, that the provider issued it to us and it has not expired, and
+// that it carries nonce.
+func (p *OIDCProvider) Verify(ctx context.Context, raw, nonce string) (*IDClaims, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := &IDClaims{}
+	var keyErr error
+	_, err = jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := p.keys.key(ctx, kid)
+		keyErr = err
+		return key, err
+	}, jwt.WithValidMethods(idTokenAlgs), jwt.WithAudience(p.cfg.ClientID), jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(), jwt.WithLeeway(idTokenLeeway))
+	if keyErr != nil {
+		// Either an unknown key or the provider could not be reached.
+		return nil, keyErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if !validIssuer(ep.Issuer, claims.Issuer) {
+		return nil, fmt.Errorf("%w: issued by %q", ErrInvalidIDToken, claims.Issuer)
+	}
+	if (len(claims.Audience) > 1 || claims.AuthorizedParty != "") && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: authorized party %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// validIssuer reports whether iss is the issuer of the provider. Google
+// also issues tokens naming itself without the scheme.
+func validIssuer(issuer, iss string) bool {
+	if iss == issuer {
+		return true
+	}
+	return issuer == "https://accounts.google.com" && iss == "accounts.google.com"
+}
+
+// discover fetches the endpoints of the provider once; failures are
+// retried on the next call.
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcEndpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+	wellKnown := strings.TrimRight(p.cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery: %s", resp.Status)
+	}
+	var ep oidcEndpoints
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOIDCResponseBytes)).Decode(&ep); err != nil {
+		return nil, fmt.Errorf("decode oidc discovery: %w", err)
+	}
+	// The document must be the issuer's own, or tokens of another
+	// issuer would pass.
+	if ep.Issuer != p.cfg.IssuerURL {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", ep.Issuer, p.cfg.IssuerURL)
+	}
+	if ep.Authorization == "" || ep.Token == "" || ep.JWKS == "" {
+		return nil, fmt.Errorf("oidc discovery: endpoints missing from %s", wellKnown)
+	}
+	p.endpoints = &ep
+	p.keys = newKeySet(p.client, ep.JWKS, p.cfg.KeysTTL)
+	return p.endpoints, nil
+}
@@ -0,0 +1,171 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: oidc_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of verifying OpenID Connect ID tokens.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"airsense-be.com/internal/config"
+)
+
+const testClientID = "airsense"
+
+// fakeIssuer is an OpenID Connect provider serving its discovery document
+// and the key set of key, under kid.
+type fakeIssuer struct {
+	srv *httptest.Server
+	key *rsa.PrivateKey
+	kid string
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeIssuer{key: key, kid: "k1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidcEndpoints{
+			Issuer:        f.srv.URL,
+			Authorization: f.srv.URL + "/authorize",
+			Token:         f.srv.URL + "/token",
+			JWKS:          f.srv.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string][]jsonWebKey{"keys": {{
+			Kty: "RSA",
+			Kid: f.kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeIssuer) provider() *OIDCProvider {
+	return NewOIDCProvider(config.OIDCConfig{IssuerURL: f.srv.URL, ClientID: testClientID, ClientSecret: "secret", KeysTTL: time.Hour})
+}
+
+// sign returns an ID token with claims, signed by the issuer's key or, if
+// method is HMAC, with the client secret.
+func (f *fakeIssuer) sign(t *testing.T, claims *IDClaims, method jwt.SigningMethod, kid string) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	tok.Header["kid"] = kid
+	var key any = f.key
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		key = []byte("secret")
+	}
+	raw, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestOIDCVerify(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := f.provider()
+	now := time.Now()
+	valid := func() *IDClaims {
+		return &IDClaims{
+			Email:         "ann@example.com",
+			EmailVerified: true,
+			Nonce:         "n1",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    f.srv.URL,
+				Subject:   "sub-1",
+				Audience:  jwt.ClaimStrings{testClientID},
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+		}
+	}
+	tests := []struct {
+		name   string
+		change func(*IDClaims)
+		method jwt.SigningMethod
+		kid    string
+		nonce  string
+		ok     bool
+	}{
+		{"valid", nil, jwt.SigningMethodRS256, "k1", "n1", true},
+		{"other nonce", nil, jwt.SigningMethodRS256, "k1", "n2", false},
+		{"no nonce expected", func(c *IDClaims) { c.Nonce = "" }, jwt.SigningMethodRS256, "k1", "", false},
+		{"no nonce", func(c *IDClaims) { c.Nonce = "" }, jwt.SigningMethodRS256, "k1", "n1", false},
+		{"other audience", func(c *IDClaims) { c.Audience = jwt.ClaimStrings{"someone-else"} }, jwt.SigningMethodRS256, "k1", "n1", false},
+		{"no audience", func(c *IDClaims) { c.Audience = nil }, jwt.SigningMethodRS256, "k1", "n1", false},
+		{"audiences without authorized party", func(c *IDClaims) { c.Audience = jwt.ClaimStrings{testClientID, "other"} },
+			jwt.SigningMethodRS256, "k1", "n1", false},
+		{"audiences authorized to us", func(c *IDClaims) {
+			c.Audience, c.AuthorizedParty = jwt.ClaimStrings{testClientID, "other"}, testClientID
+		}, jwt.SigningMethodRS256, "k1", "n1", true},
+		{"authorized to another party", func(c *IDClaims) { c.AuthorizedParty = "other" }, jwt.SigningMethodRS256, "k1", "n1", false},
+		{"other issuer", func(c *IDClaims) { c.Issuer = "https://evil.example.com" }, jwt.SigningMethodRS256, "k1", "n1", false},
+		{"expired", func(c *IDClaims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-2 * idTokenLeeway)) }, jwt.SigningMethodRS256, "k1", "n1", false},
+		{"no expiry", func(c *IDClaims) { c.ExpiresAt = nil }, jwt.SigningMethodRS256, "k1", "n1", false},
+		{"issued in the future", func(c *IDClaims) { c.IssuedAt = jwt.NewNumericDate(now.Add(2 * idTokenLeeway)) },
+			jwt.SigningMethodRS256, "k1", "n1", false},
+		{"no subject", func(c *IDClaims) { c.Subject = "" }, jwt.SigningMethodRS256, "k1", "n1", false},
+		{"signed with the client secret", nil, jwt.SigningMethodHS256, "k1", "n1", false},
+		{"unknown key", nil, jwt.SigningMethodRS256, "k2", "n1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			if tt.change != nil {
+				tt.change(claims)
+			}
+			got, err := p.Verify(context.Background(), f.sign(t, claims, tt.method, tt.kid), tt.nonce)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidIDToken) {
+					t.Errorf("err = %v, want ErrInvalidIDToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Subject != "sub-1" || got.Email != "ann@example.com" || !bool(got.EmailVerified) {
+				t.Errorf("claims = %+v", got)
+			}
+		})
+	}
+}
+
+func TestOIDCDiscoveryIssuer(t *testing.T) {
+	f := newFakeIssuer(t)
+	// A provider configured with another issuer must not trust the
+	// document, nor the tokens it would vouch for.
+	p := NewOIDCProvider(config.OIDCConfig{IssuerURL: f.srv.URL + "/", ClientID: testClientID})
+	if _, err := p.AuthCodeURL(context.Background(), "s", "n", "v"); err == nil {
+		t.Error("accepted the discovery document of another issuer")
+	}
+}
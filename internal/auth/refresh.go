@@ -0,0 +1,175 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: refresh.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the access/refresh token issuance and rotation of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type Service struct {
+	tokens repository.RefreshTokenRepository
+	users  repository.UserRepository
+	cfg    config.JWTConfig
+}
+
+func NewService(tokens repository.RefreshTokenRepository, users repository.UserRepository, cfg config.JWTConfig) *Service {
+	return &Service{tokens: tokens, users: users, cfg: cfg}
+}
+
+// IssuePair starts a new refresh token family for userID, e.g. on login.
+func (s *Service) IssuePair(ctx context.Context, userID string) (*TokenPair, error) {
+	refresh, err := s.GenerateRefreshToken(ctx, userID, primitive.NewObjectID().Hex())
+	if err != nil {
+		return nil, err
+	}
+	return s.pair(ctx, userID, refresh)
+}
+
+// GenerateRefreshToken mints a refresh token in the given family and stores
+// its hash. The plaintext is returned to the caller only.
+func (s *Service) GenerateRefreshToken(ctx context.Context, userID, familyID string) (string, error) {
+	raw, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	err = s.tokens.Create(ctx, &models.RefreshToken{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: HashToken(raw),
+		ExpiresAt: now.Add(s.cfg.RefreshExpire),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", fmt.Errorf("store refresh token: %w", err)
+	}
+	return raw, nil
+}
+
+// Refresh exchanges a valid refresh token for a new access/refresh pair and
+// revokes the presented token. Presenting a token that was already rotated
+// means it leaked, so the whole family is revoked.
+func (s *Service) Refresh(ctx context.Context, raw string) (*TokenPair, error) {
+	old, err := s.tokens.GetByHash(ctx, HashToken(raw))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if old.RevokedAt != nil {
+		return nil, s.revokeFamily(ctx, old)
+	}
+	if time.Now().After(old.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Revoke first: if two requests race with the same token only one wins
+	// the conditional update, and the loser is treated as reuse.
+	next := primitive.NewObjectID().Hex()
+	revoked, err := s.tokens.Revoke(ctx, old.ID, next)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, s.revokeFamily(ctx, old)
+	}
+
+	refresh, err := s.GenerateRefreshToken(ctx, old.UserID, old.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	return s.pair(ctx, old.UserID, refresh)
+}
+
+func (s *Service) revokeFamily(ctx context.Context, t *models.RefreshToken) error {
+	log.Printf("auth: refresh token reuse for user %s, revoking family %s", t.UserID, t.FamilyID)
+	if err := s.tokens.RevokeFamily(ctx, t.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// pair issues an access token carrying the current role of userID, so a
+// role change reaches the claim at the next refresh.
+//
+// Tokens issued within the second the sessions of the user were last
+// ended are refused, as their issue time in whole seconds cannot tell
+// whether they predate it. One issued after it, e.g. right after a
+// password change, is dated the next second instead.
+func (s *Service) pair(ctx context.Context, userID, refresh string) (*TokenPair, error) {
+	var role string
+	issuedAt := time.Now()
+	u, err := s.users.GetByID(ctx, userID)
+	switch {
+	case err == nil:
+		role = u.Role
+		if since := u.SessionsValidSince(); !since.IsZero() && issuedAt.Unix() <= since.Unix() {
+			issuedAt = since.Truncate(time.Second).Add(time.Second)
+		}
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, err
+	}
+	access, err := utils.GenerateTokenAt(userID, role, s.cfg.Secret, issuedAt, s.cfg.Expire)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.cfg.Expire / time.Second),
+	}, nil
+}
+
+// HashToken returns the hex SHA-256 digest under which a token is stored.
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateOpaqueToken returns 32 random bytes encoded as URL-safe base64.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
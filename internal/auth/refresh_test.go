@@ -0,0 +1,81 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: refresh_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of issuing token pairs.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+const testSecret = "test-secret-that-is-long-enough-for-hmac"
+
+type revokedUsers struct {
+	repository.UserRepository
+	revokedAt *time.Time
+}
+
+func (r *revokedUsers) GetByID(_ context.Context, id string) (*models.User, error) {
+	return &models.User{ID: id, SessionsRevokedAt: r.revokedAt}, nil
+}
+
+type noRefreshTokens struct {
+	repository.RefreshTokenRepository
+}
+
+func (noRefreshTokens) Create(context.Context, *models.RefreshToken) error {
+	return nil
+}
+
+func TestIssuePairAfterRevocation(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		revokedAt time.Time
+		// wantAfter is the second the token must be issued after.
+		wantAfter int64
+	}{
+		{"never revoked", time.Time{}, now.Unix() - 1},
+		{"revoked a minute ago", now.Add(-time.Minute), now.Unix() - 1},
+		{"revoked just now", now, now.Unix()},
+		// A revocation recorded by an instance whose clock runs ahead.
+		{"revoked in a second", now.Add(time.Second), now.Unix() + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &revokedUsers{}
+			if !tt.revokedAt.IsZero() {
+				users.revokedAt = &tt.revokedAt
+			}
+			s := NewService(noRefreshTokens{}, users, config.JWTConfig{Secret: testSecret, Expire: time.Hour})
+			pair, err := s.IssuePair(context.Background(), "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := utils.ParseToken(pair.AccessToken, testSecret)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if iat := claims.IssuedAt.Unix(); iat <= tt.wantAfter || iat > tt.wantAfter+2 {
+				t.Errorf("issued at %d, want just after %d", iat, tt.wantAfter)
+			}
+			if got, want := claims.ExpiresAt.Sub(claims.IssuedAt.Time), time.Hour; got != want {
+				t.Errorf("valid for %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: latest.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the in-memory cache of the latest reading per device.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"airsense-be.com/internal/models"
+)
+
+// LatestCache keeps the most recent reading of every device that reported
+// since startup, and the time of the most recent one stored. It is safe for
+// concurrent use.
+type LatestCache struct {
+	mu       sync.RWMutex
+	readings map[string]models.SensorData
+	stored   map[string]time.Time
+}
+
+func NewLatestCache() *LatestCache {
+	return &LatestCache{readings: make(map[string]models.SensorData), stored: make(map[string]time.Time)}
+}
+
+// Set stores data unless a newer reading of the device is already cached,
+// so late or replayed readings never move the latest value backwards.
+func (c *LatestCache) Set(data models.SensorData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cur, ok := c.readings[data.DeviceID]; ok && !data.Timestamp.After(cur.Timestamp) {
+		return
+	}
+	c.readings[data.DeviceID] = data
+}
+
+func (c *LatestCache) Get(deviceID string) (models.SensorData, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	d, ok := c.readings[deviceID]
+	return d, ok
+}
+
+// SetStored records that a reading of deviceID taken at ts was stored,
+// unless a newer one was already.
+func (c *LatestCache) SetStored(deviceID string, ts time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cur, ok := c.stored[deviceID]; ok && !ts.After(cur) {
+		return
+	}
+	c.stored[deviceID] = ts
+}
+
+// StoredAt returns the time of the newest stored reading of deviceID
+// recorded with SetStored.
+func (c *LatestCache) StoredAt(deviceID string) (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ts, ok := c.stored[deviceID]
+	return ts, ok
+}
+
+func (c *LatestCache) Delete(deviceID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.readings, deviceID)
+	delete(c.stored, deviceID)
+}
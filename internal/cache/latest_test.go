@@ -0,0 +1,79 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: latest_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the latest-reading cache.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+)
+
+func TestLatestCacheSet(t *testing.T) {
+	t0 := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		offsets []time.Duration // of the readings set, in order
+		want    int             // index of the latest reading
+	}{
+		{"one reading", []time.Duration{0}, 0},
+		{"in order", []time.Duration{0, time.Minute, 2 * time.Minute}, 2},
+		{"older after newer", []time.Duration{time.Minute, 0}, 0},
+		{"replayed buffer", []time.Duration{5 * time.Minute, time.Minute, 2 * time.Minute, 3 * time.Minute}, 0},
+		{"same timestamp", []time.Duration{time.Minute, time.Minute}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLatestCache()
+			for i, off := range tt.offsets {
+				c.Set(models.SensorData{ID: fmt.Sprintf("r%d", i), DeviceID: "d1", Timestamp: t0.Add(off)})
+				c.SetStored("d1", t0.Add(off))
+			}
+			d, ok := c.Get("d1")
+			if !ok {
+				t.Fatal("no latest reading")
+			}
+			want := t0.Add(tt.offsets[tt.want])
+			if wantID := fmt.Sprintf("r%d", tt.want); d.ID != wantID || !d.Timestamp.Equal(want) {
+				t.Errorf("latest is %s at %s, want %s at %s", d.ID, d.Timestamp, wantID, want)
+			}
+			if at, ok := c.StoredAt("d1"); !ok || !at.Equal(want) {
+				t.Errorf("stored at %s, want %s", at, want)
+			}
+		})
+	}
+}
+
+func TestLatestCacheDelete(t *testing.T) {
+	c := NewLatestCache()
+	at := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	for _, id := range []string{"d1", "d2"} {
+		c.Set(models.SensorData{DeviceID: id, Timestamp: at})
+		c.SetStored(id, at)
+	}
+	c.Delete("d1")
+	if _, ok := c.Get("d1"); ok {
+		t.Error("deleted device still has a latest reading")
+	}
+	if _, ok := c.StoredAt("d1"); ok {
+		t.Error("deleted device still has a stored time")
+	}
+	if _, ok := c.Get("d2"); !ok {
+		t.Error("other device lost its latest reading")
+	}
+	// A deleted device starts afresh, even with an older reading.
+	c.Set(models.SensorData{DeviceID: "d1", Timestamp: at.Add(-time.Hour)})
+	if d, ok := c.Get("d1"); !ok || !d.Timestamp.Equal(at.Add(-time.Hour)) {
+		t.Errorf("latest after delete = %v, %v", d.Timestamp, ok)
+	}
+}
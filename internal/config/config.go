@@ -3,7 +3,7 @@
  * Filename: config.go
  * Author: [trung.la]
  * Created: [2025-10-30]
- * Last Updated: [2025-10-30]
+ * Last Updated: [2026-10-14]
  * Description: This file contains the data models for sensor data in the AirSense system.
  *
  * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
@@ -11,22 +11,146 @@
 
 package config
 
-import "time"
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 type Config struct {
 	Server  ServerConfig
 	MongoDB MongoDBConfig
 	MQTT    MQTTConfig
 	JWT     JWTConfig
+	Alert   AlertConfig
+	SMTP    SMTPConfig
+	Command CommandConfig
+
+	Storage  StorageConfig
+	InfluxDB InfluxDBConfig
+
+	Provisioning ProvisioningConfig
+	FCM          FCMConfig
+	OIDC         OIDCConfig
 }
 
 type ServerConfig struct {
 	Port string
+	TLS  TLSConfig
+
+	// DeviceQuota is the number of devices a user may own unless their
+	// record sets its own limit.
+	DeviceQuota int
+	// RequireVerifiedEmail keeps users from taking devices until they
+	// confirmed their email address.
+	RequireVerifiedEmail bool
+
+	// CompressionMinSize is the smallest response body, in bytes, that is
+	// gzipped for clients accepting it.
+	CompressionMinSize int
+
+	// IngestMaxBody and BulkMaxBody bound, in bytes, the request bodies of
+	// single readings posted by devices and of bulk uploads.
+	IngestMaxBody int64
+	BulkMaxBody   int64
+
+	// RequestTimeout bounds the handling of a request, except streams;
+	// 0 disables it.
+	RequestTimeout time.Duration
+
+	// ShutdownTimeout is how long a shutdown waits for running requests;
+	// those still running then are cancelled.
+	ShutdownTimeout time.Duration
+	// DrainTimeout is how long a shutdown then waits for the MQTT messages
+	// received and the readings, emails and pushes queued.
+	DrainTimeout time.Duration
+
+	// GraphQLEnabled serves the dashboard queries at /api/v1/graphql too.
+	GraphQLEnabled bool
+
+	// APIKeyRateLimit is the number of requests per minute one API key may
+	// sustain, in bursts of up to APIKeyBurst; 0 disables the limit.
+	APIKeyRateLimit int
+	APIKeyBurst     int
+
+	CORS CORSConfig
+}
+
+// CORSConfig lets browser apps on other origins call the API. With no
+// AllowedOrigins, the default, no cross-origin request is allowed; there
+// is no wildcard, every origin must be listed.
+type CORSConfig struct {
+	// AllowedOrigins are exact origins such as https://app.example.com.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// AllowCredentials lets browsers send cookies and Authorization
+	// headers along.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// MinVersion is "1.2" (default) or "1.3".
+	MinVersion string
+
+	// AutoCert obtains certificates for AutoCertDomain from Let's Encrypt
+	// instead of reading CertFile/KeyFile.
+	AutoCert         bool
+	AutoCertDomain   string
+	AutoCertCacheDir string
+
+	// RedirectPort serves the HTTP-to-HTTPS redirect while TLS is active.
+	RedirectPort string
 }
 
 type MongoDBConfig struct {
 	URI      string
 	Database string
+
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+
+	// RetryMaxAttempts bounds the attempts at a sensor data insert or
+	// query failing with a transient error; 1 disables retries.
+	RetryMaxAttempts int
+	RetryBaseDelay   time.Duration
+}
+
+type StorageConfig struct {
+	// Backend stores sensor data in StorageMongoDB (default) or
+	// StorageInfluxDB. Everything else always lives in MongoDB.
+	Backend string
+
+	// WorkerCount goroutines write readings received over MQTT in batches
+	// of up to BatchSize, flushed at least every FlushInterval. Up to
+	// QueueSize readings wait for a worker; beyond that ingestion blocks.
+	// A WorkerCount of 0 writes every reading on its own instead.
+	WorkerCount   int
+	BatchSize     int
+	FlushInterval time.Duration
+	QueueSize     int
+}
+
+const (
+	StorageMongoDB  = "mongodb"
+	StorageInfluxDB = "influxdb"
+)
+
+type InfluxDBConfig struct {
+	URL    string
+	Token  string
+	Org    string
+	Bucket string
 }
 
 type MQTTConfig struct {
@@ -34,9 +158,519 @@ type MQTTConfig struct {
 	Username string
 	Password string
 	ClientID string
+	// TenantID scopes the topic tree: airsense/{TenantID}/devices/...
+	TenantID string
+
+	// Topic templates of each device topic kind. {tenantID} is replaced
+	// by TenantID and {deviceID}, which every template must contain as a
+	// whole topic level, by the device. See mqtt.NewTopics.
+	TelemetryTopic string
+	StatusTopic    string
+	CommandTopic   string
+	AckTopic       string
+
+	// Workers is the number of goroutines processing inbound messages.
+	Workers int
+	// QueueSize bounds the number of messages waiting for a worker.
+	QueueSize int
+	// OverflowPolicy decides what happens when the queue is full:
+	// OverflowBlock or OverflowDropOldest.
+	OverflowPolicy string
+
+	// DedupWindow is how long a sensor reading is remembered to drop its
+	// retransmissions, 0 to keep them; at most DedupMaxSize readings are
+	// remembered. See mqtt.Deduplicator.
+	DedupWindow  time.Duration
+	DedupMaxSize int
+
+	// AuthWebhookSecret must be sent by the broker in X-Webhook-Secret when
+	// calling the auth/ACL webhooks.
+	AuthWebhookSecret string
+	// CredentialGracePeriod is how long rotated-out device credentials keep
+	// working.
+	CredentialGracePeriod time.Duration
 }
 
+const (
+	OverflowBlock      = "block"
+	OverflowDropOldest = "drop_oldest"
+)
+
 type JWTConfig struct {
+	Secret        string
+	Expire        time.Duration
+	RefreshExpire time.Duration
+
+	// PasswordResetTTL is the lifetime of password reset tokens. Reset
+	// requests are limited to PasswordResetIPLimit per client IP and
+	// PasswordResetEmailLimit per email address an hour.
+	PasswordResetTTL        time.Duration
+	PasswordResetIPLimit    int
+	PasswordResetEmailLimit int
+
+	// VerificationTTL is the lifetime of email verification tokens.
+	// Registrations and requests for a new token are limited together to
+	// RegisterIPLimit per client IP an hour, and verification emails to
+	// VerificationEmailLimit per address.
+	VerificationTTL        time.Duration
+	RegisterIPLimit        int
+	VerificationEmailLimit int
+
+	// SessionCacheTTL is how long a server instance caches since when the
+	// access tokens of a user are valid. Sessions revoked on another
+	// instance are ended here within this time.
+	SessionCacheTTL time.Duration
+	// ScopedTokenMaxTTL is the longest lifetime of a device-scoped token.
+	ScopedTokenMaxTTL time.Duration
+
+	// LoginMaxFailures failed logins to one email address from one client
+	// IP lock it there for LoginLockout, doubled with every further
+	// lockout up to LoginMaxLockout. After LoginIPMaxFailures failed
+	// logins to any accounts, a client IP may try once per LoginLockout;
+	// after LoginAccountMaxFailures from anywhere, logins to an account
+	// are slowed down. Failures are forgotten LoginFailureWindow after the
+	// last one. LoginMaxFailures of 0 disables the throttling,
+	// LoginIPMaxFailures of 0 the limit of client IPs.
+	LoginMaxFailures        int
+	LoginIPMaxFailures      int
+	LoginAccountMaxFailures int
+	LoginLockout            time.Duration
+	LoginMaxLockout         time.Duration
+	LoginFailureWindow      time.Duration
+
+	// BcryptCost is the work factor of password hashes made from now on,
+	// between bcrypt.MinCost and bcrypt.MaxCost; stored hashes keep theirs
+	// until the password is set again. Password changes are limited to
+	// PasswordChangeIPLimit per client IP an hour.
+	BcryptCost            int
+	PasswordChangeIPLimit int
+}
+
+type AlertConfig struct {
+	// AnomalyWindow is the number of recent readings per device and field
+	// the anomaly detector keeps.
+	AnomalyWindow int
+	// AnomalyMinSamples is the warm-up: readings are not scored before this
+	// many values are in the window.
+	AnomalyMinSamples int
+	// AnomalyK is the z-score above which a reading is anomalous.
+	AnomalyK float64
+	// AnomalyRetention is how long flagged readings are kept after they
+	// were flagged.
+	AnomalyRetention time.Duration
+
+	// Thresholds maps a sensor field to its warning/critical levels.
+	Thresholds map[string]Threshold
+
+	// ScoreBreakpoints replace the default health score breakpoints of
+	// the pollutants they name, see models.Sensors.CompositeScore.
+	ScoreBreakpoints map[string][]ScoreBreakpoint
+}
+
+// Threshold is the value at or above which a sensor is in warning or
+// critical state. A zero level is disabled.
+type Threshold struct {
+	Warning  float64
+	Critical float64
+}
+
+// ScoreBreakpoint is a concentration and its health score from 0 to 100.
+type ScoreBreakpoint struct {
+	Value float64
+	Score int
+}
+
+// DefaultThresholds follow the WHO/ASHRAE guidance for indoor air.
+var DefaultThresholds = map[string]Threshold{
+	"pm25":        {Warning: 35, Critical: 55},
+	"co2":         {Warning: 1000, Critical: 2000},
+	"co":          {Warning: 9, Critical: 35},
+	"temperature": {Warning: 30, Critical: 35},
+	"humidity":    {Warning: 70, Critical: 85},
+}
+
+type CommandConfig struct {
+	// DefaultTTL is the expiry of commands created without one.
+	DefaultTTL time.Duration
+	// ReapInterval is how often one instance times out expired commands,
+	// fails stale ones and republishes due retries.
+	ReapInterval time.Duration
+	// ScheduleInterval is how often due command schedules are run, and so
+	// how late a scheduled command may be sent.
+	ScheduleInterval time.Duration
+	// ActionsFile is an optional JSON file of extra command actions and
+	// their parameter specs, see models.LoadActions.
+	ActionsFile string
+	// QueueOffline holds commands for devices marked offline until they
+	// report being online, instead of publishing them right away.
+	QueueOffline bool
+	// PendingTTL fails commands still pending this long after they were
+	// created, whatever their expiry and retries; 0 disables the sweep.
+	PendingTTL time.Duration
+}
+
+// ProvisioningConfig governs device self-registration with provisioning
+// tokens. Without a Secret provisioning is disabled.
+type ProvisioningConfig struct {
+	// Secret signs provisioning tokens; it must differ from the JWT
+	// secret so neither kind of token passes for the other.
 	Secret string
-	Expire time.Duration
+	// TokenTTL is the lifetime of tokens created without one, MaxTokenTTL
+	// the longest an admin may ask for.
+	TokenTTL    time.Duration
+	MaxTokenTTL time.Duration
+}
+
+// OIDCConfig configures signing in with an OpenID Connect provider, e.g.
+// Google; it is off without an issuer.
+type OIDCConfig struct {
+	// IssuerURL is the issuer of the provider, whose discovery document
+	// is served under /.well-known/openid-configuration.
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback registered with the provider, ending in
+	// /api/v1/auth/oidc/callback.
+	RedirectURL string
+	// DashboardURL is the page of the dashboard the callback sends the
+	// browser back to, with the code of the sign-in or an error code.
+	DashboardURL string
+	// AllowedDomains are the email domains that may sign in; empty allows
+	// any.
+	AllowedDomains []string
+	// StateTTL is how long a login may take at the provider, LinkTTL how
+	// long a user has to confirm linking an existing account.
+	StateTTL time.Duration
+	LinkTTL  time.Duration
+	// KeysTTL is how long the signing keys of the provider are cached.
+	// Tokens signed with an unknown key refresh them sooner.
+	KeysTTL time.Duration
+	// LoginIPLimit is how many sign-ins a client IP may start an hour.
+	LoginIPLimit int
+}
+
+// FCMConfig configures push notifications through the HTTP v1 API of
+// Firebase Cloud Messaging; they are off without service account
+// credentials.
+type FCMConfig struct {
+	// CredentialsFile is the JSON key of a service account allowed to
+	// send messages, as downloaded from the Google Cloud console.
+	CredentialsFile string
+	// ProjectID is the Firebase project; the one of the service account
+	// by default.
+	ProjectID string
+	// Endpoint is the base URL of the FCM API, overridable for testing.
+	Endpoint string
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// DashboardURL is linked from alert and password reset emails.
+	DashboardURL string
+}
+
+// Load builds the configuration from environment variables, falling back
+// to development defaults for anything that is not set. If CONFIG_FILE
+// names a file of KEY=VALUE lines, its values take precedence over the
+// environment, so that they can be changed at runtime; see Watcher.
+func Load() (*Config, error) {
+	src, err := readSource(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return nil, err
+	}
+	breakpoints, err := src.getEnvScoreBreakpoints("SCORE_BREAKPOINTS")
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		Server: ServerConfig{
+			Port: src.getEnv("SERVER_PORT", "8080"),
+			TLS: TLSConfig{
+				CertFile:         src.getEnv("TLS_CERT_FILE", ""),
+				KeyFile:          src.getEnv("TLS_KEY_FILE", ""),
+				MinVersion:       src.getEnv("TLS_MIN_VERSION", "1.2"),
+				AutoCert:         src.getEnvBool("TLS_AUTOCERT", false),
+				AutoCertDomain:   src.getEnv("TLS_AUTOCERT_DOMAIN", ""),
+				AutoCertCacheDir: src.getEnv("TLS_AUTOCERT_CACHE_DIR", "./certs"),
+				RedirectPort:     src.getEnv("TLS_REDIRECT_PORT", "80"),
+			},
+			DeviceQuota:          src.getEnvInt("DEVICE_QUOTA", 3),
+			RequireVerifiedEmail: src.getEnvBool("REQUIRE_VERIFIED_EMAIL", true),
+			CompressionMinSize:   src.getEnvInt("COMPRESSION_MIN_SIZE", 1024),
+			IngestMaxBody:        int64(src.getEnvInt("INGEST_MAX_BODY_BYTES", 64<<10)),
+			BulkMaxBody:          int64(src.getEnvInt("BULK_MAX_BODY_BYTES", 4<<20)),
+			RequestTimeout:       src.getEnvDuration("REQUEST_TIMEOUT", 15*time.Second),
+			ShutdownTimeout:      src.getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+			DrainTimeout:         src.getEnvDuration("SHUTDOWN_DRAIN_TIMEOUT", 30*time.Second),
+			GraphQLEnabled:       src.getEnvBool("GRAPHQL_ENABLED", false),
+			APIKeyRateLimit:      src.getEnvInt("API_KEY_RATE_LIMIT", 120),
+			APIKeyBurst:          src.getEnvInt("API_KEY_RATE_BURST", 30),
+			CORS: CORSConfig{
+				AllowedOrigins:   src.getEnvList("CORS_ALLOWED_ORIGINS", nil),
+				AllowedMethods:   src.getEnvList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE"}),
+				AllowedHeaders:   src.getEnvList("CORS_ALLOWED_HEADERS", []string{"Authorization", "Content-Type", "Idempotency-Key", "Last-Event-ID", "X-API-Key", "X-Command-Status-Version"}),
+				AllowCredentials: src.getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+				MaxAge:           src.getEnvDuration("CORS_MAX_AGE", 10*time.Minute),
+			},
+		},
+		MongoDB: MongoDBConfig{
+			URI:                    src.getEnv("MONGODB_URI", "mongodb://localhost:27017"),
+			Database:               src.getEnv("MONGODB_DATABASE", "airsense"),
+			MaxPoolSize:            uint64(src.getEnvInt("MONGODB_MAX_POOL_SIZE", 100)),
+			MinPoolSize:            uint64(src.getEnvInt("MONGODB_MIN_POOL_SIZE", 0)),
+			ConnectTimeout:         src.getEnvDuration("MONGODB_CONNECT_TIMEOUT", 10*time.Second),
+			ServerSelectionTimeout: src.getEnvDuration("MONGODB_SERVER_SELECTION_TIMEOUT", 5*time.Second),
+
+			RetryMaxAttempts: src.getEnvInt("MONGODB_RETRY_MAX_ATTEMPTS", 3),
+			RetryBaseDelay:   src.getEnvDuration("MONGODB_RETRY_BASE_DELAY", 100*time.Millisecond),
+		},
+		MQTT: MQTTConfig{
+			Broker:         src.getEnv("MQTT_BROKER", "tcp://localhost:1883"),
+			Username:       src.getEnv("MQTT_USERNAME", ""),
+			Password:       src.getEnv("MQTT_PASSWORD", ""),
+			ClientID:       src.getEnv("MQTT_CLIENT_ID", "airsense-backend"),
+			TenantID:       src.getEnv("MQTT_TENANT_ID", "default"),
+			Workers:        src.getEnvInt("MQTT_WORKERS", 4),
+			QueueSize:      src.getEnvInt("MQTT_QUEUE_SIZE", 1000),
+			OverflowPolicy: src.getEnv("MQTT_OVERFLOW_POLICY", OverflowBlock),
+			DedupWindow:    src.getEnvDuration("MQTT_DEDUP_WINDOW", 10*time.Minute),
+			DedupMaxSize:   src.getEnvInt("MQTT_DEDUP_MAX_SIZE", 10000),
+
+			TelemetryTopic: src.getEnv("MQTT_TELEMETRY_TOPIC", "airsense/{tenantID}/devices/{deviceID}/sensors"),
+			StatusTopic:    src.getEnv("MQTT_STATUS_TOPIC", "airsense/{tenantID}/devices/{deviceID}/status"),
+			CommandTopic:   src.getEnv("MQTT_COMMAND_TOPIC", "airsense/{tenantID}/devices/{deviceID}/commands"),
+			AckTopic:       src.getEnv("MQTT_ACK_TOPIC", "airsense/{tenantID}/devices/{deviceID}/commands/ack"),
+
+			AuthWebhookSecret:     src.getEnv("MQTT_AUTH_WEBHOOK_SECRET", ""),
+			CredentialGracePeriod: src.getEnvDuration("MQTT_CREDENTIAL_GRACE_PERIOD", 24*time.Hour),
+		},
+		JWT: JWTConfig{
+			Secret:        src.getEnv("JWT_SECRET", ""),
+			Expire:        src.getEnvDuration("JWT_EXPIRY", 24*time.Hour),
+			RefreshExpire: src.getEnvDuration("JWT_REFRESH_EXPIRY", 30*24*time.Hour),
+
+			PasswordResetTTL:        src.getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+			PasswordResetIPLimit:    src.getEnvInt("PASSWORD_RESET_IP_LIMIT", 10),
+			PasswordResetEmailLimit: src.getEnvInt("PASSWORD_RESET_EMAIL_LIMIT", 3),
+			VerificationTTL:         src.getEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+			RegisterIPLimit:         src.getEnvInt("REGISTER_IP_LIMIT", 10),
+			VerificationEmailLimit:  src.getEnvInt("EMAIL_VERIFICATION_EMAIL_LIMIT", 3),
+			SessionCacheTTL:         src.getEnvDuration("SESSION_CACHE_TTL", 30*time.Second),
+			ScopedTokenMaxTTL:       src.getEnvDuration("SCOPED_TOKEN_MAX_TTL", 30*24*time.Hour),
+			LoginMaxFailures:        src.getEnvInt("LOGIN_MAX_FAILURES", 5),
+			LoginIPMaxFailures:      src.getEnvInt("LOGIN_IP_MAX_FAILURES", 100),
+			LoginAccountMaxFailures: src.getEnvInt("LOGIN_ACCOUNT_MAX_FAILURES", 50),
+			LoginLockout:            src.getEnvDuration("LOGIN_LOCKOUT", time.Minute),
+			LoginMaxLockout:         src.getEnvDuration("LOGIN_MAX_LOCKOUT", time.Hour),
+			LoginFailureWindow:      src.getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+
+			BcryptCost:            src.getEnvInt("BCRYPT_COST", bcrypt.DefaultCost),
+			PasswordChangeIPLimit: src.getEnvInt("PASSWORD_CHANGE_IP_LIMIT", 10),
+		},
+		Alert: AlertConfig{
+			AnomalyWindow:     src.getEnvInt("ALERT_ANOMALY_WINDOW", 60),
+			AnomalyMinSamples: src.getEnvInt("ALERT_ANOMALY_MIN_SAMPLES", 10),
+			AnomalyK:          src.getEnvFloat("ALERT_ANOMALY_K", 3),
+			AnomalyRetention:  src.getEnvDuration("ALERT_ANOMALY_RETENTION", 90*24*time.Hour),
+			Thresholds:        src.getEnvThresholds("ALERT_THRESHOLDS", DefaultThresholds),
+			ScoreBreakpoints:  breakpoints,
+		},
+		SMTP: SMTPConfig{
+			Host:         src.getEnv("SMTP_HOST", ""),
+			Port:         src.getEnvInt("SMTP_PORT", 587),
+			Username:     src.getEnv("SMTP_USERNAME", ""),
+			Password:     src.getEnv("SMTP_PASSWORD", ""),
+			From:         src.getEnv("SMTP_FROM", "alerts@airsense.example.com"),
+			DashboardURL: src.getEnv("DASHBOARD_URL", "http://localhost:3000"),
+		},
+		Command: CommandConfig{
+			DefaultTTL:       src.getEnvDuration("COMMAND_DEFAULT_TTL", 5*time.Minute),
+			ReapInterval:     src.getEnvDuration("COMMAND_REAP_INTERVAL", 30*time.Second),
+			ScheduleInterval: src.getEnvDuration("COMMAND_SCHEDULE_INTERVAL", 15*time.Second),
+			ActionsFile:      src.getEnv("COMMAND_ACTIONS_FILE", ""),
+			QueueOffline:     src.getEnvBool("COMMAND_QUEUE_OFFLINE", false),
+			PendingTTL:       src.getEnvDuration("COMMAND_PENDING_TTL", time.Hour),
+		},
+		Storage: StorageConfig{
+			Backend:       src.getEnv("STORAGE_BACKEND", StorageMongoDB),
+			WorkerCount:   src.getEnvInt("STORAGE_WRITE_WORKERS", 4),
+			BatchSize:     src.getEnvInt("STORAGE_WRITE_BATCH_SIZE", 100),
+			FlushInterval: src.getEnvDuration("STORAGE_WRITE_FLUSH_INTERVAL", 100*time.Millisecond),
+			QueueSize:     src.getEnvInt("STORAGE_WRITE_QUEUE_SIZE", 10000),
+		},
+		InfluxDB: InfluxDBConfig{
+			URL:    src.getEnv("INFLUXDB_URL", "http://localhost:8086"),
+			Token:  src.getEnv("INFLUXDB_TOKEN", ""),
+			Org:    src.getEnv("INFLUXDB_ORG", "airsense"),
+			Bucket: src.getEnv("INFLUXDB_BUCKET", "sensor_data"),
+		},
+		Provisioning: ProvisioningConfig{
+			Secret:      src.getEnv("PROVISIONING_SECRET", ""),
+			TokenTTL:    src.getEnvDuration("PROVISIONING_TOKEN_TTL", 24*time.Hour),
+			MaxTokenTTL: src.getEnvDuration("PROVISIONING_MAX_TOKEN_TTL", 30*24*time.Hour),
+		},
+		FCM: FCMConfig{
+			CredentialsFile: src.getEnv("FCM_CREDENTIALS_FILE", ""),
+			ProjectID:       src.getEnv("FCM_PROJECT_ID", ""),
+			Endpoint:        src.getEnv("FCM_ENDPOINT", "https://fcm.googleapis.com"),
+		},
+		OIDC: OIDCConfig{
+			IssuerURL:      src.getEnv("OIDC_ISSUER_URL", ""),
+			ClientID:       src.getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret:   src.getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:    src.getEnv("OIDC_REDIRECT_URL", ""),
+			DashboardURL:   src.getEnv("OIDC_DASHBOARD_URL", strings.TrimRight(src.getEnv("DASHBOARD_URL", "http://localhost:3000"), "/")+"/oidc/callback"),
+			AllowedDomains: src.getEnvList("OIDC_ALLOWED_DOMAINS", nil),
+			StateTTL:       src.getEnvDuration("OIDC_STATE_TTL", 10*time.Minute),
+			LinkTTL:        src.getEnvDuration("OIDC_LINK_TTL", 15*time.Minute),
+			KeysTTL:        src.getEnvDuration("OIDC_KEYS_TTL", time.Hour),
+			LoginIPLimit:   src.getEnvInt("OIDC_LOGIN_IP_LIMIT", 60),
+		},
+	}, nil
+}
+
+// source holds the values of the config file, which override the
+// environment.
+type source map[string]string
+
+func (src source) lookup(key string) string {
+	if v, ok := src[key]; ok {
+		return v
+	}
+	return os.Getenv(key)
+}
+
+// readSource parses a file of KEY=VALUE lines. Blank lines and lines
+// starting with # are skipped and values may be quoted. An empty path
+// yields no values.
+func readSource(path string) (source, error) {
+	src := source{}
+	if path == "" {
+		return src, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("config file %s line %d: want KEY=VALUE", path, i+1)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		src[key] = value
+	}
+	return src, nil
+}
+
+func (src source) getEnv(key, fallback string) string {
+	if v := src.lookup(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func (src source) getEnvInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(src.lookup(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
+func (src source) getEnvBool(key string, fallback bool) bool {
+	if v, err := strconv.ParseBool(src.lookup(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
+func (src source) getEnvFloat(key string, fallback float64) float64 {
+	if v, err := strconv.ParseFloat(src.lookup(key), 64); err == nil {
+		return v
+	}
+	return fallback
+}
+
+func (src source) getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if v, err := time.ParseDuration(src.lookup(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
+// getEnvList parses a comma-separated list, dropping empty entries.
+func (src source) getEnvList(key string, fallback []string) []string {
+	var out []string
+	for _, v := range strings.Split(src.lookup(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	if len(out) == 0 {
+		return fallback
+	}
+	return out
+}
+
+// getEnvThresholds parses "field:warning:critical,..." and overrides the
+// matching fallback entries. Malformed entries are ignored.
+func (src source) getEnvThresholds(key string, fallback map[string]Threshold) map[string]Threshold {
+	out := make(map[string]Threshold, len(fallback))
+	for k, v := range fallback {
+		out[k] = v
+	}
+	for _, entry := range strings.Split(src.lookup(key), ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 {
+			continue
+		}
+		warn, err1 := strconv.ParseFloat(parts[1], 64)
+		crit, err2 := strconv.ParseFloat(parts[2], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		out[parts[0]] = Threshold{Warning: warn, Critical: crit}
+	}
+	return out
+}
+
+// getEnvScoreBreakpoints parses "field:value=score/value=score/...,..."
+// into breakpoints by field. Empty entries are skipped; a malformed one
+// is an error.
+func (src source) getEnvScoreBreakpoints(key string) (map[string][]ScoreBreakpoint, error) {
+	out := map[string][]ScoreBreakpoint{}
+	for _, entry := range strings.Split(src.lookup(key), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		field, table, ok := strings.Cut(entry, ":")
+		if !ok || field == "" {
+			return nil, fmt.Errorf("%s: entry %q: want field:value=score/...", key, entry)
+		}
+		var points []ScoreBreakpoint
+		for _, pair := range strings.Split(table, "/") {
+			v, sc, ok := strings.Cut(pair, "=")
+			value, err1 := strconv.ParseFloat(v, 64)
+			score, err2 := strconv.Atoi(sc)
+			if !ok || err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("%s: entry %q: breakpoint %q: want value=score", key, entry, pair)
+			}
+			points = append(points, ScoreBreakpoint{Value: value, Score: score})
+		}
+		out[field] = points
+	}
+	return out, nil
 }
@@ -0,0 +1,60 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: config_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of loading the configuration.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetEnvScoreBreakpoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string][]ScoreBreakpoint
+		wantErr bool
+	}{
+		{"unset", "", map[string][]ScoreBreakpoint{}, false},
+		{"one field", "co2:400=100/1000=60/2000=0", map[string][]ScoreBreakpoint{
+			"co2": {{400, 100}, {1000, 60}, {2000, 0}},
+		}, false},
+		{"two fields, spaces and a trailing comma", " co2:400=100/2000=0 , pm25:12=100/55.5=0,", map[string][]ScoreBreakpoint{
+			"co2":  {{400, 100}, {2000, 0}},
+			"pm25": {{12, 100}, {55.5, 0}},
+		}, false},
+		{"no field", "400=100/2000=0", nil, true},
+		{"empty field", ":400=100/2000=0", nil, true},
+		{"no score", "co2:400=100/2000", nil, true},
+		{"bad value", "co2:low=100/2000=0", nil, true},
+		{"fractional score", "co2:400=99.5/2000=0", nil, true},
+		{"one bad of two", "co2:400=100/2000=0,pm25:12=100/55.5", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := source{"SCORE_BREAKPOINTS": tt.value}.getEnvScoreBreakpoints("SCORE_BREAKPOINTS")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsScoreBreakpoints(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("SCORE_BREAKPOINTS", "co2:400=100/1000")
+	if _, err := Load(); err == nil {
+		t.Fatal("Load() accepted a malformed SCORE_BREAKPOINTS")
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: watcher.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the reloading of the configuration on SIGHUP.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// ConfigObserver is a live component that applies configuration changes
+// without a restart. OnConfigChange is called after every reload that
+// changed anything; observers compare the sections they use.
+type ConfigObserver interface {
+	OnConfigChange(old, new Config)
+}
+
+// Watcher reloads the configuration on SIGHUP and notifies the registered
+// observers. Settings without an observer, such as ports or database
+// URIs, still need a restart to apply.
+type Watcher struct {
+	mu        sync.Mutex
+	current   *Config
+	observers []ConfigObserver
+}
+
+func NewWatcher(cfg *Config) *Watcher {
+	return &Watcher{current: cfg}
+}
+
+// Register adds o to the observers notified of changes.
+func (w *Watcher) Register(o ConfigObserver) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.observers = append(w.observers, o)
+}
+
+// Current returns the configuration as of the last reload.
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Watch reloads the configuration on every SIGHUP until ctx is done.
+func (w *Watcher) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+		if err := w.Reload(); err != nil {
+			log.Printf("config: reload: %v; keeping the current configuration", err)
+		}
+	}
+}
+
+// Reload re-reads the configuration and, if any section changed, logs
+// which and notifies the observers.
+func (w *Watcher) Reload() error {
+	next, err := Load()
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	changed := Diff(*w.current, *next)
+	if len(changed) == 0 {
+		log.Println("config: reloaded, nothing changed")
+		return nil
+	}
+	log.Printf("config: reloaded, changed: %s", strings.Join(changed, ", "))
+	old := w.current
+	w.current = next
+	for _, o := range w.observers {
+		o.OnConfigChange(*old, *next)
+	}
+	return nil
+}
+
+// Diff returns the names of the sections of Config that differ between a
+// and b, e.g. "Alert".
+func Diff(a, b Config) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	var changed []string
+	for i := 0; i < va.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			changed = append(changed, va.Type().Field(i).Name)
+		}
+	}
+	return changed
+}
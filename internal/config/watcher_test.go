@@ -0,0 +1,132 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: watcher_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of reloading the configuration.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// changes records the configurations observers are told about.
+type changes chan [2]Config
+
+func (c changes) OnConfigChange(old, new Config) {
+	c <- [2]Config{old, new}
+}
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "airsense.env")
+	t.Setenv("CONFIG_FILE", path)
+	writeConfigFile(t, path, "API_KEY_RATE_LIMIT=120\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWatcher(cfg)
+	seen := make(changes, 4)
+	w.Register(seen)
+
+	tests := []struct {
+		name     string
+		file     string
+		wantErr  bool
+		notified bool
+		want     int
+	}{
+		{"unchanged", "API_KEY_RATE_LIMIT=120\n", false, false, 120},
+		{"changed", "# slower\nAPI_KEY_RATE_LIMIT=\"30\"\n", false, true, 30},
+		{"malformed", "API_KEY_RATE_LIMIT\n", true, false, 30},
+		{"back to the default", "", false, true, 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := w.Current().Server.APIKeyRateLimit
+			writeConfigFile(t, path, tt.file)
+			if err := w.Reload(); (err != nil) != tt.wantErr {
+				t.Fatalf("Reload() err = %v, want error %v", err, tt.wantErr)
+			}
+			if got := w.Current().Server.APIKeyRateLimit; got != tt.want {
+				t.Errorf("APIKeyRateLimit = %d, want %d", got, tt.want)
+			}
+			select {
+			case c := <-seen:
+				if !tt.notified {
+					t.Fatal("observer notified without a change")
+				}
+				if c[0].Server.APIKeyRateLimit != before || c[1].Server.APIKeyRateLimit != tt.want {
+					t.Errorf("notified of %d -> %d, want %d -> %d", c[0].Server.APIKeyRateLimit, c[1].Server.APIKeyRateLimit, before, tt.want)
+				}
+			default:
+				if tt.notified {
+					t.Fatal("observer not notified")
+				}
+			}
+		})
+	}
+}
+
+func TestWatcherSIGHUP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "airsense.env")
+	t.Setenv("CONFIG_FILE", path)
+	writeConfigFile(t, path, "API_KEY_RATE_LIMIT=120\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWatcher(cfg)
+	seen := make(changes, 1)
+	w.Register(seen)
+
+	// Keeps SIGHUP from ending the test process until Watch is listening.
+	hup := make(chan os.Signal, 16)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Watch(ctx)
+
+	writeConfigFile(t, path, "API_KEY_RATE_LIMIT=30\n")
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case c := <-seen:
+			if got := c[1].Server.APIKeyRateLimit; got != 30 {
+				t.Errorf("reloaded APIKeyRateLimit = %d, want 30", got)
+			}
+			if changed := Diff(c[0], c[1]); !slices.Equal(changed, []string{"Server"}) {
+				t.Errorf("changed sections = %v, want [Server]", changed)
+			}
+			return
+		case <-tick.C:
+		case <-timeout:
+			t.Fatal("no reload after SIGHUP")
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: hub.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the in-process publish/subscribe hub of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+// Package events fans server-side events out to in-process subscribers,
+// such as streaming API clients.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"airsense-be.com/internal/metrics"
+)
+
+// Event types.
+const (
+	TypeSensorData    = "sensor_data"
+	TypeCommandStatus = "command_status"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// new ones are dropped for it.
+const subscriberBuffer = 32
+
+type Event struct {
+	Type     string    `json:"type"`
+	DeviceID string    `json:"device_id"`
+	At       time.Time `json:"at"`
+	Data     any       `json:"data"`
+}
+
+// Hub delivers every published event to the subscribers of its device.
+// Publishing never blocks: a subscriber whose buffer is full misses the
+// event.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[string]map[chan Event]struct{})}
+}
+
+// Subscribe returns the events of deviceID and a function that must be
+// called to stop receiving them.
+func (h *Hub) Subscribe(deviceID string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	h.mu.Lock()
+	if h.subs[deviceID] == nil {
+		h.subs[deviceID] = make(map[chan Event]struct{})
+	}
+	h.subs[deviceID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[deviceID], ch)
+			if len(h.subs[deviceID]) == 0 {
+				delete(h.subs, deviceID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (h *Hub) Publish(e Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs[e.DeviceID] {
+		select {
+		case ch <- e:
+		default:
+			metrics.EventsDropped.Add(1)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: parquet.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the Parquet encoding of sensor data exports in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package export
+
+import (
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"airsense-be.com/internal/models"
+)
+
+// ParquetBatch is the number of readings buffered before they are written
+// out as one row group, which bounds the memory of an export.
+const ParquetBatch = 10000
+
+// ParquetRow is the schema of exported readings: one row per reading with
+// a value and a unit column per sensor.
+type ParquetRow struct {
+	Timestamp   time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	DeviceID    string    `parquet:"device_id,dict"`
+	Source      string    `parquet:"source,dict"`
+	AQI         int32     `parquet:"aqi"`
+	AQICategory string    `parquet:"aqi_category,dict"`
+	Quality     string    `parquet:"quality,dict"`
+
+	PM25Value        float64 `parquet:"pm25_value"`
+	PM25Unit         string  `parquet:"pm25_unit,dict"`
+	CO2Value         float64 `parquet:"co2_value"`
+	CO2Unit          string  `parquet:"co2_unit,dict"`
+	COValue          float64 `parquet:"co_value"`
+	COUnit           string  `parquet:"co_unit,dict"`
+	TemperatureValue float64 `parquet:"temperature_value"`
+	TemperatureUnit  string  `parquet:"temperature_unit,dict"`
+	HumidityValue    float64 `parquet:"humidity_value"`
+	HumidityUnit     string  `parquet:"humidity_unit,dict"`
+}
+
+func parquetRow(d *models.SensorData) ParquetRow {
+	return ParquetRow{
+		Timestamp:   d.Timestamp.UTC(),
+		DeviceID:    d.DeviceID,
+		Source:      string(d.Source),
+		AQI:         int32(d.AQI),
+		AQICategory: d.AQICategory,
+		Quality:     string(d.Quality),
+
+		PM25Value:        d.Sensors.PM25.Value,
+		PM25Unit:         d.Sensors.PM25.Unit,
+		CO2Value:         d.Sensors.CO2.Value,
+		CO2Unit:          d.Sensors.CO2.Unit,
+		COValue:          d.Sensors.CO.Value,
+		COUnit:           d.Sensors.CO.Unit,
+		TemperatureValue: d.Sensors.Temperature.Value,
+		TemperatureUnit:  d.Sensors.Temperature.Unit,
+		HumidityValue:    d.Sensors.Humidity.Value,
+		HumidityUnit:     d.Sensors.Humidity.Unit,
+	}
+}
+
+// ParquetWriter streams readings as a Snappy-compressed Parquet file. The
+// file is only complete once Close returns.
+type ParquetWriter struct {
+	w    *parquet.GenericWriter[ParquetRow]
+	rows []ParquetRow
+}
+
+func NewParquetWriter(w io.Writer) *ParquetWriter {
+	return &ParquetWriter{
+		w:    parquet.NewGenericWriter[ParquetRow](w, parquet.Compression(&parquet.Snappy)),
+		rows: make([]ParquetRow, 0, ParquetBatch),
+	}
+}
+
+// Write adds a reading, writing out a row group once ParquetBatch readings
+// are buffered.
+func (p *ParquetWriter) Write(d *models.SensorData) error {
+	p.rows = append(p.rows, parquetRow(d))
+	if len(p.rows) < ParquetBatch {
+		return nil
+	}
+	return p.flush()
+}
+
+func (p *ParquetWriter) flush() error {
+	if len(p.rows) == 0 {
+		return nil
+	}
+	if _, err := p.w.Write(p.rows); err != nil {
+		return err
+	}
+	p.rows = p.rows[:0]
+	return p.w.Flush()
+}
+
+// Close writes the buffered readings and the file footer.
+func (p *ParquetWriter) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	return p.w.Close()
+}
@@ -0,0 +1,132 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: parquet_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the schema and rows of Parquet exports.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package export
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"airsense-be.com/internal/models"
+)
+
+var parquetColumns = []string{
+	"timestamp", "device_id", "source", "aqi", "aqi_category", "quality",
+	"pm25_value", "pm25_unit", "co2_value", "co2_unit", "co_value", "co_unit",
+	"temperature_value", "temperature_unit", "humidity_value", "humidity_unit",
+}
+
+func exportReading(i int) *models.SensorData {
+	return &models.SensorData{
+		DeviceID:    "d1",
+		Timestamp:   time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Minute),
+		Source:      models.SourceMQTT,
+		AQI:         42,
+		AQICategory: "good",
+		Quality:     models.QualityGood,
+		Sensors: models.Sensors{
+			PM25:        models.SensorValue{Value: float64(i), Unit: "µg/m³"},
+			CO2:         models.SensorValue{Value: 600, Unit: "ppm"},
+			CO:          models.SensorValue{Value: 1.5, Unit: "ppm"},
+			Temperature: models.SensorValue{Value: 21.5, Unit: "°C"},
+			Humidity:    models.SensorValue{Value: 45, Unit: "%"},
+		},
+	}
+}
+
+func TestParquetWriter(t *testing.T) {
+	tests := []struct {
+		name      string
+		readings  int
+		rowGroups int
+	}{
+		{"empty", 0, 0},
+		{"one reading", 1, 1},
+		{"one full batch", ParquetBatch, 1},
+		{"batch and a half", ParquetBatch + ParquetBatch/2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewParquetWriter(&buf)
+			for i := range tt.readings {
+				if err := w.Write(exportReading(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var columns []string
+			for _, field := range f.Schema().Fields() {
+				columns = append(columns, field.Name())
+			}
+			if !slices.Equal(columns, parquetColumns) {
+				t.Errorf("columns = %v, want %v", columns, parquetColumns)
+			}
+			if got := f.NumRows(); got != int64(tt.readings) {
+				t.Errorf("rows = %d, want %d", got, tt.readings)
+			}
+			if got := len(f.RowGroups()); got != tt.rowGroups {
+				t.Errorf("row groups = %d, want %d", got, tt.rowGroups)
+			}
+
+			rows, err := parquet.Read[ParquetRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, row := range rows {
+				if want := parquetRow(exportReading(i)); row != want {
+					t.Fatalf("row %d = %+v, want %+v", i, row, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParquetSchemaTypes(t *testing.T) {
+	schema := parquet.SchemaOf(ParquetRow{})
+	tests := []struct {
+		column string
+		kind   parquet.Kind
+	}{
+		{"timestamp", parquet.Int64},
+		{"device_id", parquet.ByteArray},
+		{"aqi", parquet.Int32},
+		{"pm25_value", parquet.Double},
+		{"pm25_unit", parquet.ByteArray},
+		{"humidity_value", parquet.Double},
+	}
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			col, ok := schema.Lookup(tt.column)
+			if !ok {
+				t.Fatalf("no column %s", tt.column)
+			}
+			if got := col.Node.Type().Kind(); got != tt.kind {
+				t.Errorf("kind = %v, want %v", got, tt.kind)
+			}
+		})
+	}
+	ts, _ := schema.Lookup("timestamp")
+	if lt := ts.Node.Type().LogicalType(); lt == nil || lt.Timestamp == nil || lt.Timestamp.Unit.Millis == nil {
+		t.Errorf("timestamp logical type = %v, want timestamp(millisecond)", lt)
+	}
+}
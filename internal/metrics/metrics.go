@@ -0,0 +1,56 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: metrics.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the process-wide counters and gauges exported by the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package metrics
+
+import "expvar"
+
+// Metrics are published through expvar and exposed to admins on
+// /debug/vars.
+var (
+	MQTTQueueDepth        = expvar.NewInt("mqtt_queue_depth")
+	MQTTMessagesDropped   = expvar.NewInt("mqtt_messages_dropped_total")
+	MQTTMessagesFailed    = expvar.NewInt("mqtt_messages_failed_total")
+	MQTTReconnectAttempts = expvar.NewInt("mqtt_reconnect_attempts_total")
+	MQTTAuthRejected      = expvar.NewInt("mqtt_auth_rejected_total")
+	MQTTACLRejected       = expvar.NewInt("mqtt_acl_rejected_total")
+	MQTTDeviceMismatch    = expvar.NewInt("mqtt_device_mismatch_total")
+	MQTTUnknownSchema     = expvar.NewInt("mqtt_unknown_schema_total")
+	MQTTUnknownDevice     = expvar.NewInt("mqtt_unknown_device_total")
+	MQTTAcksIgnored       = expvar.NewInt("mqtt_acks_ignored_total")
+	MQTTDeduplicated      = expvar.NewInt("mqtt_messages_deduplicated_total")
+
+	SensorAnomalies = expvar.NewInt("sensor_anomalies_total")
+	AlertsFired     = expvar.NewInt("alerts_fired_total")
+
+	SensorWriteQueueDepth = expvar.NewInt("sensor_write_queue_depth")
+	SensorWritesFailed    = expvar.NewInt("sensor_writes_failed_total")
+	ReadingsThinned       = expvar.NewInt("readings_thinned_total")
+	ReadingsReplaced      = expvar.NewInt("readings_replaced_total")
+
+	EmailsDropped = expvar.NewInt("emails_dropped_total")
+	EmailsFailed  = expvar.NewInt("emails_failed_total")
+
+	PushesDropped = expvar.NewInt("pushes_dropped_total")
+	PushesFailed  = expvar.NewInt("pushes_failed_total")
+
+	APIKeyRequestsLimited = expvar.NewInt("api_key_requests_limited_total")
+	ClientRequestsLimited = expvar.NewInt("client_requests_limited_total")
+
+	LoginFailures = expvar.NewInt("login_failures_total")
+	LoginLockouts = expvar.NewInt("login_lockouts_total")
+
+	AuditWritesFailed = expvar.NewInt("audit_writes_failed_total")
+
+	EventsDropped    = expvar.NewInt("events_dropped_total")
+	CommandsTimedOut = expvar.NewInt("commands_timed_out_total")
+	CommandsExpired  = expvar.NewInt("commands_expired_total")
+)
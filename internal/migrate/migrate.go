@@ -0,0 +1,186 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: migrate.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the database migration runner of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+// Package migrate applies ordered, run-once schema and data migrations.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// MigrationsCollection records the migrations that have been applied.
+	MigrationsCollection = "migrations"
+	// LockCollection holds the lock document serialising runners across
+	// replicas.
+	LockCollection = "migration_lock"
+
+	lockID = "migrate"
+	// lockLease is how long a lock is honoured; a runner that died while
+	// holding it blocks the others for at most this long. It is renewed
+	// before each migration, so a single migration must finish within it.
+	lockLease    = 10 * time.Minute
+	lockInterval = 2 * time.Second
+)
+
+// Migration is one step. Up must leave the database consistent if it fails
+// halfway, since it will run again on the next attempt.
+type Migration struct {
+	ID          string
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// Applied is the record stored for a migration that has run.
+type Applied struct {
+	ID        string    `bson:"_id"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// Up applies the migrations not yet recorded, in order, while holding the
+// migration lock.
+func Up(ctx context.Context, db *mongo.Database) error {
+	owner, err := lock(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer unlock(db, owner)
+
+	applied, err := appliedIDs(ctx, db)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if applied[m.ID] {
+			continue
+		}
+		if err := extend(ctx, db, owner); err != nil {
+			return err
+		}
+		log.Printf("migrate: applying %s: %s", m.ID, m.Description)
+		if err := m.Up(ctx, db); err != nil {
+			return fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+		if _, err := db.Collection(MigrationsCollection).InsertOne(ctx, Applied{ID: m.ID, AppliedAt: time.Now().UTC()}); err != nil {
+			return fmt.Errorf("record migration %s: %w", m.ID, err)
+		}
+	}
+	return nil
+}
+
+// Status pairs each known migration with when it was applied, nil if it is
+// pending.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+func List(ctx context.Context, db *mongo.Database) ([]Status, error) {
+	cur, err := db.Collection(MigrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var records []Applied
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	at := make(map[string]time.Time, len(records))
+	for _, r := range records {
+		at[r.ID] = r.AppliedAt
+	}
+
+	out := make([]Status, len(migrations))
+	for i, m := range migrations {
+		out[i].Migration = m
+		if t, ok := at[m.ID]; ok {
+			out[i].AppliedAt = &t
+		}
+	}
+	return out, nil
+}
+
+func appliedIDs(ctx context.Context, db *mongo.Database) (map[string]bool, error) {
+	statuses, err := List(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(statuses))
+	for _, s := range statuses {
+		ids[s.ID] = s.AppliedAt != nil
+	}
+	return ids, nil
+}
+
+// lock takes the lock document, waiting for another runner to release it
+// or for its lease to run out.
+func lock(ctx context.Context, db *mongo.Database) (string, error) {
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s/%d/%d", host, os.Getpid(), time.Now().UnixNano())
+	coll := db.Collection(LockCollection)
+
+	for {
+		now := time.Now()
+		// Matches only a missing or stale lock; with upsert, a lock held by
+		// someone else makes the insert fail on the duplicate _id.
+		_, err := coll.UpdateOne(ctx,
+			bson.M{"_id": lockID, "expires_at": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(lockLease)}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			return owner, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return "", fmt.Errorf("acquire migration lock: %w", err)
+		}
+
+		log.Printf("migrate: waiting for the migration lock")
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("acquire migration lock: %w", ctx.Err())
+		case <-time.After(lockInterval):
+		}
+	}
+}
+
+// extend renews the lease of the lock held by owner.
+func extend(ctx context.Context, db *mongo.Database, owner string) error {
+	res, err := db.Collection(LockCollection).UpdateOne(ctx,
+		bson.M{"_id": lockID, "owner": owner},
+		bson.M{"$set": bson.M{"expires_at": time.Now().Add(lockLease)}},
+	)
+	if err != nil {
+		return fmt.Errorf("renew migration lock: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("migration lock lost to another runner")
+	}
+	return nil
+}
+
+func unlock(db *mongo.Database, owner string) {
+	// The caller's context may already be cancelled; the lock must still go.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := db.Collection(LockCollection).DeleteOne(ctx, bson.M{"_id": lockID, "owner": owner})
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		log.Printf("migrate: release lock: %v", err)
+	}
+}
@@ -0,0 +1,334 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: migrations.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the ordered list of database migrations of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package migrate
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	store "airsense-be.com/internal/repository/mongo"
+)
+
+// migrations run in slice order. Append new ones; never edit, reorder or
+// remove one that has shipped. A change to the index definitions needs a
+// new migration calling ensureIndexes.
+var migrations = []Migration{
+	{
+		ID:          "0001_initial_indexes",
+		Description: "create the repository indexes",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0002_backfill_aqi",
+		Description: "store aqi and aqi_category on existing readings",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			n, err := store.NewSensorRepository(db, repository.RetryPolicy{}).BackfillAQI(ctx)
+			log.Printf("migrate: backfilled the AQI of %d readings", n)
+			return err
+		},
+	},
+	{
+		ID:          "0003_device_group_indexes",
+		Description: "index device groups by owner",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0004_command_expiry_index",
+		Description: "index pending commands by expiry for the reaper",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0005_command_retry_index",
+		Description: "index pending commands by next retry",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0006_alert_silence_indexes",
+		Description: "index alert silences by device and end",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0007_recount_devices",
+		Description: "stop counting soft-deleted devices against the quota",
+		Up:          recountDevices,
+	},
+	{
+		ID:          "0008_command_idempotency_keys",
+		Description: "index command idempotency keys by device and expire them",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0009_command_batch_index",
+		Description: "index commands by batch",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0010_api_key_indexes",
+		Description: "index user API keys by hash and owner",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0011_command_schedule_indexes",
+		Description: "index command schedules by device and next run, and their runs by time",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0012_sensor_archive_indexes",
+		Description: "index archived readings by device and time",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0013_command_status_created_index",
+		Description: "index commands by status and creation time for the stale command sweep",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0014_command_device_status_created_index",
+		Description: "sort commands filtered by device and status on the index",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// Superseded by the same keys with created_at appended.
+			if err := dropIndex(ctx, db.Collection(store.CommandsCollection), "device_id_1_status_1"); err != nil {
+				return err
+			}
+			return ensureIndexes(ctx, db)
+		},
+	},
+	{
+		ID:          "0015_command_sent_status",
+		Description: "mark published commands awaiting their ack as sent",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// Pending used to cover published commands; those waiting to
+			// be republished stay pending.
+			res, err := db.Collection(store.CommandsCollection).UpdateMany(ctx,
+				bson.M{"status": models.CommandPending, "next_attempt_at": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"status": models.CommandSent}})
+			if err != nil {
+				return err
+			}
+			log.Printf("migrate: marked %d pending command(s) as sent", res.ModifiedCount)
+			return nil
+		},
+	},
+	{
+		ID:          "0016_command_issuer_validator",
+		Description: "reject new commands without an issuer and origin",
+		Up:          requireCommandIssuer,
+	},
+	{
+		ID:          "0017_device_provisioning_indexes",
+		Description: "index devices by serial number and expire provisioning tokens",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0018_device_firmware_index",
+		Description: "index devices by firmware version",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0019_user_devices_index",
+		Description: "index the mobile app installations of users",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0020_password_reset_indexes",
+		Description: "index password resets and the refresh tokens of users",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0021_scoped_token_indexes",
+		Description: "index device-scoped tokens by user and expire them",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0022_login_failure_indexes",
+		Description: "expire failed login counters",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0023_audit_log_indexes",
+		Description: "index the audit log by resource, user and time",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0024_sensor_anomaly_indexes",
+		Description: "index flagged sensor readings by device and time",
+		Up:          ensureIndexes,
+	},
+	{
+		// Fails if two users already share an email address; resolve
+		// those by hand first.
+		ID:          "0025_email_verification_indexes",
+		Description: "make user emails unique and expire email verification tokens",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0026_organization_indexes",
+		Description: "index organization members, invitations and devices",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0027_unique_sensor_timestamps",
+		Description: "keep one reading per device and timestamp and enforce it",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropDuplicateReadings(ctx, db); err != nil {
+				return err
+			}
+			_, err := db.Collection(store.SensorDataCollection).Indexes().CreateOne(ctx, store.UniqueReadingIndex)
+			return err
+		},
+	},
+	{
+		ID:          "0028_oidc_indexes",
+		Description: "make OIDC identities unique and expire OIDC logins and links",
+		Up:          ensureIndexes,
+	},
+	{
+		// Fails if a device already has two unfinished firmware updates;
+		// cancel all but one first.
+		ID:          "0029_one_open_ota_index",
+		Description: "allow one unfinished ota_update command per device",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0030_sensor_anomaly_ttl",
+		Description: "expire flagged sensor readings",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0031_oidc_results",
+		Description: "index and expire finished OIDC sign-ins",
+		Up:          ensureIndexes,
+	},
+	{
+		ID:          "0032_device_coordinates",
+		Description: "index device coordinates for searches by distance",
+		Up:          ensureIndexes,
+	},
+}
+
+// recountDevices sets the device_count of every user to their devices that
+// are not soft-deleted.
+func recountDevices(ctx context.Context, db *mongo.Database) error {
+	cur, err := db.Collection(store.DevicesCollection).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted_at": bson.M{"$exists": false}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$user_id", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return err
+	}
+	var counts []struct {
+		UserID string `bson:"_id"`
+		Count  int    `bson:"count"`
+	}
+	if err := cur.All(ctx, &counts); err != nil {
+		return err
+	}
+
+	users := db.Collection(store.UsersCollection)
+	owners := make(bson.A, 0, len(counts))
+	for _, c := range counts {
+		if _, err := users.UpdateOne(ctx, bson.M{"_id": c.UserID}, bson.M{"$set": bson.M{"device_count": c.Count}}); err != nil {
+			return err
+		}
+		owners = append(owners, c.UserID)
+	}
+	_, err = users.UpdateMany(ctx, bson.M{"_id": bson.M{"$nin": owners}}, bson.M{"$set": bson.M{"device_count": 0}})
+	return err
+}
+
+// requireCommandIssuer makes MongoDB reject commands without issued_by
+// and origin. The moderate level leaves updates of older commands, which
+// lack both, unchecked.
+func requireCommandIssuer(ctx context.Context, db *mongo.Database) error {
+	validator := bson.M{"$jsonSchema": bson.M{
+		"bsonType": "object",
+		"required": bson.A{"issued_by", "origin"},
+		"properties": bson.M{
+			"issued_by": bson.M{"bsonType": "string", "minLength": 1},
+			"origin":    bson.M{"enum": bson.A{models.OriginAPI, models.OriginSchedule, models.OriginAlert}},
+		},
+	}}
+	err := db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: store.CommandsCollection},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+	}).Err()
+	var ce mongo.CommandError
+	if errors.As(err, &ce) && ce.Code == 26 {
+		// NamespaceNotFound: no command was ever stored.
+		return db.CreateCollection(ctx, store.CommandsCollection,
+			options.CreateCollection().SetValidator(validator).SetValidationLevel("moderate"))
+	}
+	return err
+}
+
+// dropDuplicateReadings deletes all but the last stored reading of each
+// device and timestamp, as upserting them would have left.
+func dropDuplicateReadings(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(store.SensorDataCollection)
+	cur, err := coll.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"device_id": bson.M{"$type": "string"}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"device_id": "$device_id", "timestamp": "$timestamp"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	var deleted int64
+	for cur.Next(ctx) {
+		var group struct {
+			IDs []string `bson:"ids"`
+		}
+		if err := cur.Decode(&group); err != nil {
+			return err
+		}
+		// IDs are hex ObjectIDs, so the last one was stored last.
+		res, err := coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.IDs[:len(group.IDs)-1]}})
+		if err != nil {
+			return err
+		}
+		deleted += res.DeletedCount
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+	log.Printf("migrate: deleted %d duplicate reading(s)", deleted)
+	return nil
+}
+
+// dropIndex drops the index name of coll if it exists.
+func dropIndex(ctx context.Context, coll *mongo.Collection, name string) error {
+	_, err := coll.Indexes().DropOne(ctx, name)
+	var ce mongo.CommandError
+	if errors.As(err, &ce) && (ce.Code == 26 || ce.Code == 27) {
+		// NamespaceNotFound or IndexNotFound: nothing to drop.
+		return nil
+	}
+	return err
+}
+
+func ensureIndexes(ctx context.Context, db *mongo.Database) error {
+	return store.EnsureIndexes(ctx, db)
+}
@@ -0,0 +1,61 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: aggregate.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the data models for aggregated sensor data in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// SensorAggregate summarises the readings of one time bucket. Fields is
+// keyed like Sensors.Fields.
+type SensorAggregate struct {
+	Bucket time.Time                 `bson:"_id" json:"bucket"`
+	Count  int64                     `bson:"count" json:"count"`
+	Fields map[string]AggregateStats `bson:"fields" json:"fields"`
+}
+
+// AggregateStats are the statistics of one field in a bucket; they are nil
+// when no reading of the bucket has the field.
+type AggregateStats struct {
+	Avg *float64 `bson:"avg" json:"avg"`
+	Min *float64 `bson:"min" json:"min"`
+	Max *float64 `bson:"max" json:"max"`
+}
+
+// SensorStats summarises the readings of a time range. Fields is keyed
+// like Sensors.Fields.
+type SensorStats struct {
+	Count        int64                 `json:"count"`
+	Fields       map[string]FieldStats `json:"fields"`
+	Correlations []Correlation         `json:"correlations"`
+}
+
+// FieldStats describes the values of one sensor. StdDev is the sample
+// standard deviation, 0 with fewer than two values. Percentiles are
+// nearest-rank values, approximated on large ranges.
+type FieldStats struct {
+	Count  int64   `bson:"count" json:"count"`
+	Avg    float64 `bson:"avg" json:"avg"`
+	StdDev float64 `bson:"stddev" json:"stddev"`
+	Min    float64 `bson:"min" json:"min"`
+	Max    float64 `bson:"max" json:"max"`
+	P50    float64 `bson:"p50" json:"p50"`
+	P95    float64 `bson:"p95" json:"p95"`
+	P99    float64 `bson:"p99" json:"p99"`
+}
+
+// Correlation is the Pearson correlation coefficient of two sensors over
+// the readings that have both. R is nil when it is undefined, with fewer
+// than two such readings or a sensor whose value never changes.
+type Correlation struct {
+	X string   `json:"x"`
+	Y string   `json:"y"`
+	R *float64 `json:"r"`
+}
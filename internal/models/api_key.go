@@ -0,0 +1,52 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: api_key.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the data model for user API keys in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// APIKey lets firmware and integrations call the REST API as UserID with
+// "Authorization: ApiKey <key>" or "X-API-Key: <key>" instead of a JWT.
+// Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID          string     `bson:"_id" json:"id"`
+	UserID      string     `bson:"user_id" json:"user_id"`
+	Name        string     `bson:"name,omitempty" json:"name,omitempty"`
+	KeyHash     string     `bson:"key_hash" json:"-"`
+	Permissions []string   `bson:"permissions" json:"permissions"`
+	LastUsedAt  *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	// ExpiresAt is nil for keys that do not expire.
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+}
+
+// API key permissions: PermissionRead allows GET requests, PermissionWrite
+// every other method.
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+)
+
+func ValidPermission(p string) bool {
+	return p == PermissionRead || p == PermissionWrite
+}
+
+// Expired reports whether the key can no longer be used at now.
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+func (k *APIKey) Allows(permission string) bool {
+	return slices.Contains(k.Permissions, permission)
+}
@@ -0,0 +1,37 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: audit.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the data model for audit log entries.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// AuditEntry records that a user changed a resource, see internal/audit.
+type AuditEntry struct {
+	ID     string `bson:"_id" json:"id"`
+	UserID string `bson:"user_id" json:"user_id"`
+	// Action is the resource type and what was done to it, e.g.
+	// "device.update".
+	Action       string    `bson:"action" json:"action"`
+	ResourceType string    `bson:"resource_type" json:"resource_type"`
+	ResourceID   string    `bson:"resource_id" json:"resource_id"`
+	Diff         AuditDiff `bson:"diff,omitempty" json:"diff,omitempty"`
+	Timestamp    time.Time `bson:"timestamp" json:"timestamp"`
+}
+
+// AuditDiff maps the JSON name of each changed field to its change.
+type AuditDiff map[string]AuditChange
+
+// AuditChange is the value of a field before and after a change; From is
+// null for a created resource, To for a deleted one.
+type AuditChange struct {
+	From any `bson:"from" json:"from"`
+	To   any `bson:"to" json:"to"`
+}
@@ -3,7 +3,7 @@
  * Filename: command.go
  * Author: [trung.la]
  * Created: [2025-10-30]
- * Last Updated: [2025-10-30]
+ * Last Updated: [2026-10-14]
  * Description: This file contains the data models for command data in the AirSense system.
  *
  * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
@@ -11,7 +11,11 @@
 
 package models
 
-import "time"
+import (
+	"regexp"
+	"slices"
+	"time"
+)
 
 type Command struct {
 	CommandID string         `bson:"command_id" json:"commandID"`
@@ -19,14 +23,238 @@ type Command struct {
 	Action    string         `bson:"action" json:"action"`
 	Params    map[string]any `bson:"params" json:"params"`
 	Status    CommandStatus  `bson:"status" json:"status"`
-	CreatedAt time.Time      `bson:"created_at" json:"createdAt"`
-	UpdatedAt time.Time      `bson:"updated_at" json:"updatedAt"`
+	// StatusDetail explains a status to users, e.g. DetailQueuedOffline or
+	// DetailPreempted.
+	StatusDetail string `bson:"status_detail,omitempty" json:"statusDetail,omitempty"`
+	// Priority tells the device which queued commands to run first.
+	Priority CommandPriority `bson:"priority,omitempty" json:"priority,omitempty"`
+	// BatchID is set on commands created by a CommandBatch.
+	BatchID string `bson:"batch_id,omitempty" json:"batchID,omitempty"`
+	// ScheduleID is set on commands created by a CommandSchedule.
+	ScheduleID string `bson:"schedule_id,omitempty" json:"scheduleID,omitempty"`
+	// IssuedBy is the user the command was sent for and Origin what sent
+	// it. Both are required on new commands; older ones may lack them.
+	IssuedBy string        `bson:"issued_by,omitempty" json:"issuedBy,omitempty"`
+	Origin   CommandOrigin `bson:"origin,omitempty" json:"origin,omitempty"`
+	// RuleID is set on commands created by an alert rule.
+	RuleID string `bson:"rule_id,omitempty" json:"ruleID,omitempty"`
+	// Metadata is context the client attached to the command, e.g. the ID
+	// of a ticket, see ValidMetadataKey.
+	Metadata map[string]string `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	// Result is the data the device reported along with the final status,
+	// at most MaxCommandResultSize bytes of JSON. It stays null until then
+	// and for acks without a result, unlike an empty result.
+	Result    map[string]any `bson:"result" json:"result"`
+	ExpiresAt *time.Time     `bson:"expires_at,omitempty" json:"expiresAt,omitempty"`
+
+	// Retry is the republish policy; nil means a single attempt.
+	Retry         *CommandRetry `bson:"retry,omitempty" json:"retry,omitempty"`
+	Attempts      int           `bson:"attempts" json:"attempts"`
+	LastAttemptAt *time.Time    `bson:"last_attempt_at,omitempty" json:"lastAttemptAt,omitempty"`
+	// NextAttemptAt is set while a failed attempt waits to be republished.
+	NextAttemptAt *time.Time `bson:"next_attempt_at,omitempty" json:"nextAttemptAt,omitempty"`
+	// Error is why the last attempt failed, or the error the device acked.
+	Error string `bson:"error,omitempty" json:"error,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// CommandKeyTTL is how long an Idempotency-Key keeps returning the command
+// it created.
+const CommandKeyTTL = 24 * time.Hour
+
+// CommandKey records the command created for an Idempotency-Key, so a
+// retried request returns it instead of creating another. Keys are unique
+// per device.
+type CommandKey struct {
+	DeviceID  string    `bson:"device_id"`
+	Key       string    `bson:"key"`
+	UserID    string    `bson:"user_id"`
+	CommandID string    `bson:"command_id"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// ReasonMQTTPublishFailed is the Error of a command attempt that could not
+// be published to the broker.
+const ReasonMQTTPublishFailed = "mqtt_publish_failed"
+
+// ReasonTimeout is the Error of a command the stale command sweep failed
+// because it stayed pending longer than the configured TTL.
+const ReasonTimeout = "timeout"
+
+// ReasonResultTooLarge is the Error of a command whose device acked a result
+// over MaxCommandResultSize, which was dropped.
+const ReasonResultTooLarge = "result_too_large"
+
+const (
+	// MaxCommandResultSize bounds the JSON encoding of a stored result.
+	MaxCommandResultSize = 16 << 10
+	// MaxCommandErrorLen bounds a stored error reported by a device.
+	MaxCommandErrorLen = 1024
+)
+
+// Bounds of Command.Metadata.
+const (
+	MaxCommandMetadataKeys     = 10
+	MaxCommandMetadataValueLen = 512
+)
+
+// Metadata keys become part of a MongoDB field path when commands are
+// filtered by them, so they are kept to a safe alphabet.
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,64}$`)
+
+// ValidMetadataKey reports whether k may be a key of Command.Metadata: 1 to
+// 64 letters, digits or underscores.
+func ValidMetadataKey(k string) bool {
+	return metadataKeyPattern.MatchString(k)
+}
+
+// CommandOrigin is what created a command.
+type CommandOrigin string
+
+const (
+	// OriginAPI is a user request, including bulk commands.
+	OriginAPI      CommandOrigin = "api"
+	OriginSchedule CommandOrigin = "schedule"
+	// OriginAlert is an alert rule acting on a reading.
+	OriginAlert CommandOrigin = "alert_automation"
+)
+
+func (o CommandOrigin) Valid() bool {
+	switch o {
+	case OriginAPI, OriginSchedule, OriginAlert:
+		return true
+	}
+	return false
+}
+
+type CommandPriority string
+
+const (
+	PriorityLow    CommandPriority = "low"
+	PriorityNormal CommandPriority = "normal"
+	PriorityHigh   CommandPriority = "high"
+)
+
+func (p CommandPriority) Valid() bool {
+	switch p {
+	case PriorityLow, PriorityNormal, PriorityHigh:
+		return true
+	}
+	return false
+}
+
+// Rank orders priorities for dispatch, higher first. Commands stored
+// without a priority rank as normal.
+func (p CommandPriority) Rank() int {
+	switch p {
+	case PriorityLow:
+		return 0
+	case PriorityHigh:
+		return 2
+	}
+	return 1
 }
 
 type CommandStatus string
 
 const (
+	// CommandPending is stored but not yet published, or waiting to be
+	// republished after a failed attempt.
 	CommandPending CommandStatus = "pending"
-	CommandSuccess CommandStatus = "success"
-	CommandError   CommandStatus = "error"
+	// CommandSent is published to the broker and waiting for the ack.
+	CommandSent     CommandStatus = "sent"
+	CommandSuccess  CommandStatus = "success"
+	CommandError    CommandStatus = "error"
+	CommandTimedOut CommandStatus = "timeout"
+	// CommandCancelled is set by the user before the device acked.
+	CommandCancelled CommandStatus = "cancelled"
+	// CommandQueued holds a command for an offline device until it comes
+	// back online; it then becomes pending and is published.
+	CommandQueued CommandStatus = "queued"
 )
+
+// DetailQueuedOffline is the StatusDetail of commands queued for an offline
+// device.
+const DetailQueuedOffline = "queued — device offline"
+
+// DetailPreempted is the StatusDetail of a command cancelled for a
+// high-priority command with the same action, see CommandService.Create.
+const DetailPreempted = "preempted by a high-priority command"
+
+// DetailTransferred is the StatusDetail of a command cancelled because its
+// device was transferred to another owner, see TransferService.
+const DetailTransferred = "cancelled — device transferred"
+
+// CommandRetry republishes a command whose publish fails or which gets no
+// ack before it expires, up to MaxAttempts attempts in total.
+type CommandRetry struct {
+	MaxAttempts    int `bson:"max_attempts" json:"maxAttempts"`
+	BackoffSeconds int `bson:"backoff_seconds" json:"backoffSeconds"`
+}
+
+// maxRetryDelay caps the exponential backoff.
+const maxRetryDelay = time.Hour
+
+// Delay is how long to wait after the given failed attempt: the backoff,
+// doubled for every earlier attempt.
+func (r CommandRetry) Delay(attempt int) time.Duration {
+	d := time.Duration(r.BackoffSeconds) * time.Second
+	for i := 1; i < attempt && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryDelay)
+}
+
+// CanRetry reports whether another attempt is allowed.
+func (c *Command) CanRetry() bool {
+	return c.Retry != nil && c.Attempts < c.Retry.MaxAttempts
+}
+
+// Expired reports whether the command can no longer be executed at now.
+// Commands without ExpiresAt never expire.
+func (c *Command) Expired(now time.Time) bool {
+	return c.ExpiresAt != nil && now.After(*c.ExpiresAt)
+}
+
+func (s CommandStatus) Valid() bool {
+	switch s {
+	case CommandPending, CommandSent, CommandSuccess, CommandError, CommandTimedOut, CommandCancelled, CommandQueued:
+		return true
+	}
+	return false
+}
+
+// commandTransitions lists the statuses each status may move to; the
+// statuses without an entry are final. An ack may overtake the move to
+// sent, so pending commands may finish directly, and a sent command goes
+// back to pending when it will be republished.
+var commandTransitions = map[CommandStatus][]CommandStatus{
+	CommandQueued:  {CommandPending, CommandTimedOut, CommandCancelled},
+	CommandPending: {CommandSent, CommandSuccess, CommandError, CommandTimedOut, CommandCancelled},
+	CommandSent:    {CommandPending, CommandSuccess, CommandError, CommandTimedOut, CommandCancelled},
+}
+
+// Terminal reports whether the status is final.
+func (s CommandStatus) Terminal() bool {
+	return len(commandTransitions[s]) == 0
+}
+
+// CanTransition reports whether a command may move from s to to. Staying
+// in a status that is not final is allowed, e.g. to record another attempt.
+func (s CommandStatus) CanTransition(to CommandStatus) bool {
+	if s == to {
+		return !s.Terminal()
+	}
+	return slices.Contains(commandTransitions[s], to)
+}
+
+// Legacy maps s to the statuses clients knew before CommandSent, which
+// they saw as pending.
+func (s CommandStatus) Legacy() CommandStatus {
+	if s == CommandSent {
+		return CommandPending
+	}
+	return s
+}
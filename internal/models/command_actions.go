@@ -0,0 +1,265 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_actions.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the registry of command actions and their parameters in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownAction = errors.New("unknown command action")
+	ErrInvalidParams = errors.New("invalid command params")
+)
+
+// ParamsError lists every way the params of a command violate the spec of
+// its action.
+type ParamsError struct {
+	Action     string
+	Violations []string
+}
+
+func (e *ParamsError) Error() string {
+	return fmt.Sprintf("%v of %s: %s", ErrInvalidParams, e.Action, strings.Join(e.Violations, "; "))
+}
+
+func (e *ParamsError) Unwrap() error {
+	return ErrInvalidParams
+}
+
+// ParamType is the JSON type a command parameter must have.
+type ParamType string
+
+const (
+	ParamString ParamType = "string"
+	ParamNumber ParamType = "number"
+	// ParamInteger is a number without a fractional part.
+	ParamInteger ParamType = "integer"
+	ParamBool    ParamType = "boolean"
+)
+
+// ParamSpec constrains one parameter. Min and Max bound numbers and Enum
+// lists the values a string may take; unset constraints are not checked.
+type ParamSpec struct {
+	Type     ParamType `json:"type"`
+	Required bool      `json:"required,omitempty"`
+	Min      *float64  `json:"min,omitempty"`
+	Max      *float64  `json:"max,omitempty"`
+	Enum     []string  `json:"enum,omitempty"`
+}
+
+// ActionSpec lists the parameters an action accepts, by name. Parameters
+// not listed are rejected.
+type ActionSpec struct {
+	Params map[string]ParamSpec `json:"params,omitempty"`
+}
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+var (
+	actionsMu sync.RWMutex
+	actions   = map[string]ActionSpec{
+		"reboot": {},
+		"calibrate": {Params: map[string]ParamSpec{
+			"targetSensor": {Type: ParamString, Required: true},
+		}},
+		"request_calibration": {Params: map[string]ParamSpec{
+			"sensor": {Type: ParamString, Required: true, Enum: []string{"pm25", "co2", "co", "temperature", "humidity"}},
+		}},
+		"power": {Params: map[string]ParamSpec{
+			"on": {Type: ParamBool, Required: true},
+		}},
+		"set_mode": {Params: map[string]ParamSpec{
+			"mode": {Type: ParamString, Required: true, Enum: []string{"auto", "manual", "sleep", "turbo"}},
+		}},
+		"set_fan_speed": {Params: map[string]ParamSpec{
+			"speed": {Type: ParamInteger, Required: true, Min: bound(0), Max: bound(100)},
+		}},
+		"set_report_interval": {Params: map[string]ParamSpec{
+			"seconds": {Type: ParamInteger, Required: true, Min: bound(1), Max: bound(86400)},
+		}},
+		"set_led": {Params: map[string]ParamSpec{
+			"on":         {Type: ParamBool, Required: true},
+			"brightness": {Type: ParamNumber, Min: bound(0), Max: bound(100)},
+		}},
+		ActionOTAUpdate: {Params: map[string]ParamSpec{
+			"version": {Type: ParamString, Required: true},
+			"url":     {Type: ParamString, Required: true},
+			"sha256":  {Type: ParamString, Required: true},
+		}},
+	}
+)
+
+// ActionOTAUpdate makes a device download and install the firmware
+// version at url, checking it against sha256 first.
+const ActionOTAUpdate = "ota_update"
+
+// RegisterAction adds or replaces an action. It is meant to be called at
+// startup, before commands are accepted.
+func RegisterAction(name string, spec ActionSpec) {
+	actionsMu.Lock()
+	defer actionsMu.Unlock()
+	actions[name] = spec
+}
+
+// LoadActions registers the actions of a JSON object mapping action names
+// to their ActionSpec, e.g. {"set_mode": {"params": {"mode": {"type":
+// "string", "required": true, "enum": ["auto", "sleep"]}}}}. Nothing is
+// registered if any spec is invalid.
+func LoadActions(r io.Reader) error {
+	var specs map[string]ActionSpec
+	if err := json.NewDecoder(r).Decode(&specs); err != nil {
+		return fmt.Errorf("decode actions: %w", err)
+	}
+	for name, spec := range specs {
+		if name == "" {
+			return errors.New("action name must not be empty")
+		}
+		for param, p := range spec.Params {
+			if err := p.check(); err != nil {
+				return fmt.Errorf("action %s: param %s: %w", name, param, err)
+			}
+		}
+	}
+	for name, spec := range specs {
+		RegisterAction(name, spec)
+	}
+	return nil
+}
+
+func (p ParamSpec) check() error {
+	switch p.Type {
+	case ParamString, ParamNumber, ParamInteger, ParamBool:
+	default:
+		return fmt.Errorf("unknown type %q", p.Type)
+	}
+	if (p.Min != nil || p.Max != nil) && p.Type != ParamNumber && p.Type != ParamInteger {
+		return errors.New("min and max only apply to numbers")
+	}
+	if p.Min != nil && p.Max != nil && *p.Min > *p.Max {
+		return errors.New("min must not be above max")
+	}
+	if len(p.Enum) > 0 && p.Type != ParamString {
+		return errors.New("enum only applies to strings")
+	}
+	return nil
+}
+
+// Actions returns the registered action names, sorted.
+func Actions() []string {
+	actionsMu.RLock()
+	defer actionsMu.RUnlock()
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// KnownAction reports whether action is registered.
+func KnownAction(action string) bool {
+	actionsMu.RLock()
+	defer actionsMu.RUnlock()
+	_, ok := actions[action]
+	return ok
+}
+
+// ValidateParams checks Params against the spec registered for Action. A
+// *ParamsError lists every violation at once.
+func (c *Command) ValidateParams() error {
+	actionsMu.RLock()
+	spec, ok := actions[c.Action]
+	actionsMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownAction, c.Action)
+	}
+
+	var violations []string
+	for name, p := range spec.Params {
+		v, ok := c.Params[name]
+		if !ok {
+			if p.Required {
+				violations = append(violations, name+" is required")
+			}
+			continue
+		}
+		violations = append(violations, p.violations(name, v)...)
+	}
+	for name := range c.Params {
+		if _, ok := spec.Params[name]; !ok {
+			violations = append(violations, fmt.Sprintf("%s is not a parameter of %s", name, c.Action))
+		}
+	}
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return &ParamsError{Action: c.Action, Violations: violations}
+	}
+	return nil
+}
+
+func (p ParamSpec) violations(name string, v any) []string {
+	if !p.Type.matches(v) {
+		return []string{fmt.Sprintf("%s must be of type %s", name, p.Type)}
+	}
+	var out []string
+	if f, ok := number(v); ok {
+		if p.Min != nil && f < *p.Min {
+			out = append(out, fmt.Sprintf("%s must be at least %v", name, *p.Min))
+		}
+		if p.Max != nil && f > *p.Max {
+			out = append(out, fmt.Sprintf("%s must be at most %v", name, *p.Max))
+		}
+	}
+	if s, ok := v.(string); ok && len(p.Enum) > 0 && !slices.Contains(p.Enum, s) {
+		out = append(out, fmt.Sprintf("%s must be one of %s", name, strings.Join(p.Enum, ", ")))
+	}
+	return out
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func (t ParamType) matches(v any) bool {
+	switch t {
+	case ParamString:
+		_, ok := v.(string)
+		return ok
+	case ParamBool:
+		_, ok := v.(bool)
+		return ok
+	case ParamNumber, ParamInteger:
+		f, ok := number(v)
+		return ok && (t == ParamNumber || f == math.Trunc(f))
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_batch.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the data model for bulk commands in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// CommandBatch is one action sent to several devices at once. Every device
+// it reached has its own Command carrying the batch ID; the devices it did
+// not are listed in Skipped.
+type CommandBatch struct {
+	ID        string          `bson:"_id" json:"batchID"`
+	UserID    string          `bson:"user_id" json:"userID"`
+	GroupID   string          `bson:"group_id,omitempty" json:"groupID,omitempty"`
+	Action    string          `bson:"action" json:"action"`
+	Params    map[string]any  `bson:"params" json:"params"`
+	Skipped   []SkippedDevice `bson:"skipped" json:"skipped"`
+	CreatedAt time.Time       `bson:"created_at" json:"createdAt"`
+}
+
+// SkippedDevice is a device of a batch that got no command, and why.
+type SkippedDevice struct {
+	DeviceID string     `bson:"device_id" json:"deviceID"`
+	Reason   SkipReason `bson:"reason" json:"reason"`
+}
+
+type SkipReason string
+
+const (
+	SkipNotFound  SkipReason = "not_found"
+	SkipForbidden SkipReason = "forbidden"
+	// SkipFailed means the command could not be stored.
+	SkipFailed SkipReason = "failed"
+	// SkipDecommissioned means the device no longer takes commands.
+	SkipDecommissioned SkipReason = "decommissioned"
+	// SkipDeviceDeleted and SkipMissed only apply to schedule runs: the
+	// device was soft-deleted, or the server was down at the scheduled time.
+	SkipDeviceDeleted SkipReason = "device_deleted"
+	SkipMissed        SkipReason = "missed"
+)
@@ -0,0 +1,65 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_schedule.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the data models for scheduled commands in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// CommandSchedule sends a command to a device at RunAt, once, or at every
+// time matching the cron expression Cron in Timezone. NextRunAt is when it
+// runs next and is unset once a one-shot schedule has run.
+type CommandSchedule struct {
+	ID       string         `bson:"_id" json:"scheduleID"`
+	DeviceID string         `bson:"device_id" json:"deviceID"`
+	UserID   string         `bson:"user_id" json:"userID"`
+	Action   string         `bson:"action" json:"action"`
+	Params   map[string]any `bson:"params" json:"params"`
+
+	RunAt    *time.Time `bson:"run_at,omitempty" json:"runAt,omitempty"`
+	Cron     string     `bson:"cron,omitempty" json:"cron,omitempty"`
+	Timezone string     `bson:"timezone,omitempty" json:"timezone,omitempty"`
+
+	NextRunAt *time.Time `bson:"next_run_at,omitempty" json:"nextRunAt,omitempty"`
+	LastRunAt *time.Time `bson:"last_run_at,omitempty" json:"lastRunAt,omitempty"`
+
+	// LeasedBy is the server instance running the schedule until
+	// LeaseExpiresAt, so that no other instance runs it as well.
+	LeasedBy       string     `bson:"leased_by,omitempty" json:"-"`
+	LeaseExpiresAt *time.Time `bson:"lease_expires_at,omitempty" json:"-"`
+
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// ScheduleRun records one due run of a schedule: the command it created or
+// why it was skipped.
+type ScheduleRun struct {
+	ID          string            `bson:"_id" json:"runID"`
+	ScheduleID  string            `bson:"schedule_id" json:"scheduleID"`
+	DeviceID    string            `bson:"device_id" json:"deviceID"`
+	ScheduledAt time.Time         `bson:"scheduled_at" json:"scheduledAt"`
+	RanAt       time.Time         `bson:"ran_at" json:"ranAt"`
+	Status      ScheduleRunStatus `bson:"status" json:"status"`
+	Reason      SkipReason        `bson:"reason,omitempty" json:"reason,omitempty"`
+	CommandID   string            `bson:"command_id,omitempty" json:"commandID,omitempty"`
+	// Command is the created command as it is now, filled in when the
+	// history is read.
+	Command *Command `bson:"-" json:"command,omitempty"`
+}
+
+type ScheduleRunStatus string
+
+const (
+	// RunFired means a command was created; its status tells whether the
+	// device executed it.
+	RunFired   ScheduleRunStatus = "fired"
+	RunSkipped ScheduleRunStatus = "skipped"
+)
@@ -0,0 +1,86 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the command status state machine.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "testing"
+
+var allStatuses = []CommandStatus{
+	CommandQueued, CommandPending, CommandSent, CommandSuccess, CommandError, CommandTimedOut, CommandCancelled,
+}
+
+func TestCommandStatusCanTransition(t *testing.T) {
+	// Every legal edge; every other pair of statuses must be refused.
+	legal := map[[2]CommandStatus]bool{
+		{CommandQueued, CommandQueued}:    true,
+		{CommandQueued, CommandPending}:   true,
+		{CommandQueued, CommandTimedOut}:  true,
+		{CommandQueued, CommandCancelled}: true,
+
+		{CommandPending, CommandPending}:   true,
+		{CommandPending, CommandSent}:      true,
+		{CommandPending, CommandSuccess}:   true,
+		{CommandPending, CommandError}:     true,
+		{CommandPending, CommandTimedOut}:  true,
+		{CommandPending, CommandCancelled}: true,
+
+		{CommandSent, CommandSent}:      true,
+		{CommandSent, CommandPending}:   true,
+		{CommandSent, CommandSuccess}:   true,
+		{CommandSent, CommandError}:     true,
+		{CommandSent, CommandTimedOut}:  true,
+		{CommandSent, CommandCancelled}: true,
+	}
+	for _, from := range allStatuses {
+		for _, to := range allStatuses {
+			t.Run(string(from)+"->"+string(to), func(t *testing.T) {
+				want := legal[[2]CommandStatus{from, to}]
+				if got := from.CanTransition(to); got != want {
+					t.Errorf("CanTransition = %v, want %v", got, want)
+				}
+			})
+		}
+	}
+	if CommandPending.CanTransition("done") {
+		t.Error("pending may move to an unknown status")
+	}
+}
+
+func TestCommandStatus(t *testing.T) {
+	tests := []struct {
+		status   CommandStatus
+		valid    bool
+		terminal bool
+		legacy   CommandStatus
+	}{
+		{CommandQueued, true, false, CommandQueued},
+		{CommandPending, true, false, CommandPending},
+		{CommandSent, true, false, CommandPending},
+		{CommandSuccess, true, true, CommandSuccess},
+		{CommandError, true, true, CommandError},
+		{CommandTimedOut, true, true, CommandTimedOut},
+		{CommandCancelled, true, true, CommandCancelled},
+		{"done", false, true, "done"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.Valid(); got != tt.valid {
+				t.Errorf("Valid = %v, want %v", got, tt.valid)
+			}
+			if got := tt.status.Terminal(); got != tt.terminal {
+				t.Errorf("Terminal = %v, want %v", got, tt.terminal)
+			}
+			if got := tt.status.Legacy(); got != tt.legacy {
+				t.Errorf("Legacy = %q, want %q", got, tt.legacy)
+			}
+		})
+	}
+}
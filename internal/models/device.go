@@ -3,7 +3,7 @@
  * Filename: device.go
  * Author: [trung.la]
  * Created: [2025-10-30]
- * Last Updated: [2025-10-30]
+ * Last Updated: [2026-10-15]
  * Description: This file contains the data models for device data in the AirSense system.
  *
  * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
@@ -20,4 +20,77 @@ type Device struct {
 	Location  string    `bson:"location" json:"location"`
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+	// DeletedAt is set when the owner deletes the device. Soft-deleted
+	// devices keep their history until purged.
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	// OrgID is set on devices added to an organization, whose members
+	// reach them according to their role.
+	OrgID string `bson:"org_id,omitempty" json:"org_id,omitempty"`
+
+	// Coordinates is where the device stands, if its owner said so;
+	// Location is a free-text label and cannot be searched by distance.
+	Coordinates *GeoPoint `bson:"coordinates,omitempty" json:"coordinates,omitempty"`
+
+	Tags map[string]string `bson:"tags,omitempty" json:"tags,omitempty"`
+	// SerialNumber is set on devices that provisioned themselves; it is
+	// unique across devices, deleted ones included.
+	SerialNumber string `bson:"serial_number,omitempty" json:"serial_number,omitempty"`
+	// Status and LastSeenAt are maintained from the device's traffic until
+	// the device is decommissioned, which is final.
+	Status     DeviceStatus `bson:"status,omitempty" json:"status,omitempty"`
+	LastSeenAt *time.Time   `bson:"last_seen_at,omitempty" json:"last_seen_at,omitempty"`
+	// DecommissionedAt is set with Status DeviceDecommissioned. The device
+	// stays readable but takes no more data or commands.
+	DecommissionedAt *time.Time `bson:"decommissioned_at,omitempty" json:"decommissioned_at,omitempty"`
+
+	// FirmwareTarget is the version the open ota_update command asks for,
+	// until the command is final; FirmwareVersion is the last version the
+	// device acked as installed.
+	FirmwareTarget  string `bson:"firmware_target,omitempty" json:"firmware_target,omitempty"`
+	FirmwareVersion string `bson:"firmware_version,omitempty" json:"firmware_version,omitempty"`
+
+	// MinIntervalMs thins the readings of a chatty device: one taken less
+	// than this many milliseconds after the last stored one is not stored.
+	// 0 stores every reading.
+	MinIntervalMs int64 `bson:"min_interval_ms,omitempty" json:"min_interval_ms,omitempty"`
+}
+
+// GeoPoint is a GeoJSON point, as MongoDB's 2dsphere indexes take it.
+// Coordinates are the longitude then the latitude, in degrees.
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// NewGeoPoint returns the point at lng, lat.
+func NewGeoPoint(lng, lat float64) *GeoPoint {
+	return &GeoPoint{Type: "Point", Coordinates: []float64{lng, lat}}
+}
+
+// Valid reports whether p is a point on Earth.
+func (p *GeoPoint) Valid() bool {
+	if p.Type != "Point" || len(p.Coordinates) != 2 {
+		return false
+	}
+	lng, lat := p.Coordinates[0], p.Coordinates[1]
+	return lng >= -180 && lng <= 180 && lat >= -90 && lat <= 90
+}
+
+// MinInterval returns MinIntervalMs as a duration.
+func (d *Device) MinInterval() time.Duration {
+	return time.Duration(d.MinIntervalMs) * time.Millisecond
+}
+
+type DeviceStatus string
+
+const (
+	DeviceOnline  DeviceStatus = "online"
+	DeviceOffline DeviceStatus = "offline"
+	// DeviceDecommissioned is set by the owner, never reported by devices.
+	DeviceDecommissioned DeviceStatus = "decommissioned"
+)
+
+// Valid reports whether s is a status a device may report.
+func (s DeviceStatus) Valid() bool {
+	return s == DeviceOnline || s == DeviceOffline
 }
@@ -0,0 +1,24 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_credential.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the data models for device API keys in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// DeviceCredential is an API key a device uses to call the REST API. Only
+// the SHA-256 hash of the key is stored.
+type DeviceCredential struct {
+	ID        string     `bson:"_id" json:"id"`
+	DeviceID  string     `bson:"device_id" json:"device_id"`
+	KeyHash   string     `bson:"key_hash" json:"-"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	RevokedAt *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
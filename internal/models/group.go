@@ -0,0 +1,23 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: group.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the data model for device groups in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// DeviceGroup is a named set of devices a user can see, owned by that user.
+type DeviceGroup struct {
+	ID        string    `bson:"_id" json:"id"`
+	UserID    string    `bson:"user_id" json:"user_id"`
+	Name      string    `bson:"name" json:"name"`
+	DeviceIDs []string  `bson:"device_ids" json:"device_ids"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,27 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: login_failures.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the data model for failed login tracking.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// LoginFailures counts the logins under one key, an email address alone
+// or together with a client IP, or a client IP alone, that did not
+// succeed. Locks counts the times the key was locked, which lengthens the
+// next lockout. The document expires once no login has failed for a
+// while.
+type LoginFailures struct {
+	Key         string     `bson:"_id"`
+	Count       int        `bson:"count"`
+	Locks       int        `bson:"locks"`
+	LockedUntil *time.Time `bson:"locked_until,omitempty"`
+	ExpiresAt   time.Time  `bson:"expires_at"`
+}
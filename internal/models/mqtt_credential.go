@@ -0,0 +1,30 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: mqtt_credential.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the data models for per-device MQTT credentials in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// MQTTCredential is a broker password issued to a device. The MQTT username
+// is the device ID; only the SHA-256 hash of the password is stored.
+type MQTTCredential struct {
+	ID         string    `bson:"_id" json:"id"`
+	DeviceID   string    `bson:"device_id" json:"device_id"`
+	SecretHash string    `bson:"secret_hash" json:"-"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	// ExpiresAt is set when the credential is rotated out; it keeps working
+	// until then so the device can pick up the new one.
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+func (c *MQTTCredential) Active(now time.Time) bool {
+	return c.ExpiresAt == nil || now.Before(*c.ExpiresAt)
+}
@@ -0,0 +1,36 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: notification.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the data models for notification preferences in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// NotificationPreference says how a user wants to be told about alerts.
+// The document _id is the user ID.
+type NotificationPreference struct {
+	UserID       string `bson:"_id" json:"user_id"`
+	EmailEnabled bool   `bson:"email_enabled" json:"email_enabled"`
+	// EmailAddress overrides the account email when set.
+	EmailAddress string `bson:"email_address,omitempty" json:"email_address,omitempty"`
+	// AlertSeverities lists the severities (warning, critical) to deliver.
+	AlertSeverities []string  `bson:"alert_severities" json:"alert_severities"`
+	UpdatedAt       time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Wants reports whether alerts of severity should be delivered.
+func (p *NotificationPreference) Wants(severity string) bool {
+	for _, s := range p.AlertSeverities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: oidc.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the data models of signing in with an OpenID Connect provider.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// OIDCLogin is a sign-in in progress at the OpenID Connect provider, known
+// by the hash of the state the provider sends back. It holds the nonce the
+// ID token must carry and the PKCE verifier redeeming the code, and is used
+// once.
+type OIDCLogin struct {
+	ID        string    `bson:"_id" json:"id"`
+	StateHash string    `bson:"state_hash" json:"-"`
+	Nonce     string    `bson:"nonce" json:"-"`
+	Verifier  string    `bson:"verifier" json:"-"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// OIDCLink is an identity at the OpenID Connect provider, Subject at
+// Issuer, waiting to be linked to the existing UserID with the same email
+// address until ExpiresAt. The user confirms the link with its password
+// and a single-use token, of which only the SHA-256 hash is stored.
+type OIDCLink struct {
+	ID        string    `bson:"_id" json:"id"`
+	UserID    string    `bson:"user_id" json:"user_id"`
+	Issuer    string    `bson:"issuer" json:"issuer"`
+	Subject   string    `bson:"subject" json:"-"`
+	TokenHash string    `bson:"token_hash" json:"-"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// OIDCResult is a finished sign-in at the OpenID Connect provider, waiting
+// until ExpiresAt for the dashboard to exchange its single-use code, of
+// which only the SHA-256 hash is stored. It signs in as UserID or, with
+// LinkSubject set, offers to link that identity at Issuer to UserID.
+type OIDCResult struct {
+	ID          string    `bson:"_id" json:"id"`
+	CodeHash    string    `bson:"code_hash" json:"-"`
+	UserID      string    `bson:"user_id" json:"user_id"`
+	Issuer      string    `bson:"issuer,omitempty" json:"issuer,omitempty"`
+	LinkSubject string    `bson:"link_subject,omitempty" json:"-"`
+	ExpiresAt   time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,69 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: organization.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the data models of organizations and their members.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// Organization owns devices together with its members. A device of an
+// organization keeps the UserID of the member who registered it, which
+// counts it against their quota, and gains an OrgID through which the
+// other members reach it.
+type Organization struct {
+	ID        string    `bson:"_id" json:"id"`
+	Name      string    `bson:"name" json:"name"`
+	CreatedBy string    `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+type OrgRole string
+
+const (
+	// OrgOwner manages the members and has owner access to the devices.
+	OrgOwner OrgRole = "owner"
+	// OrgAdmin invites members and controls the devices.
+	OrgAdmin OrgRole = "admin"
+	// OrgViewer reads the devices and their data.
+	OrgViewer OrgRole = "viewer"
+)
+
+func (r OrgRole) Valid() bool {
+	return r == OrgOwner || r == OrgAdmin || r == OrgViewer
+}
+
+// AtLeast reports whether r grants everything want does.
+func (r OrgRole) AtLeast(want OrgRole) bool {
+	rank := map[OrgRole]int{OrgViewer: 1, OrgAdmin: 2, OrgOwner: 3}
+	return rank[r] >= rank[want] && rank[r] > 0
+}
+
+// OrgMember is the membership of UserID in OrgID.
+type OrgMember struct {
+	ID        string    `bson:"_id" json:"id"`
+	OrgID     string    `bson:"org_id" json:"org_id"`
+	UserID    string    `bson:"user_id" json:"user_id"`
+	Role      OrgRole   `bson:"role" json:"role"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// OrgInvitation asks the user registered under Email to join OrgID with
+// Role until ExpiresAt. Only the SHA-256 hash of the token is stored; the
+// invitation is dropped once accepted.
+type OrgInvitation struct {
+	ID        string    `bson:"_id" json:"id"`
+	OrgID     string    `bson:"org_id" json:"org_id"`
+	Email     string    `bson:"email" json:"email"`
+	Role      OrgRole   `bson:"role" json:"role"`
+	InvitedBy string    `bson:"invited_by" json:"invited_by"`
+	TokenHash string    `bson:"token_hash" json:"-"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
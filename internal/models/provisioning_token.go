@@ -0,0 +1,27 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: provisioning_token.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the data model for device provisioning tokens in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// ProvisioningToken records a signed provisioning token an admin created:
+// up to MaxUses devices may register themselves with it, for UserID, until
+// ExpiresAt. The token itself is a JWT whose ID is the ID of this record.
+type ProvisioningToken struct {
+	ID        string    `bson:"_id" json:"id"`
+	UserID    string    `bson:"user_id" json:"user_id"`
+	CreatedBy string    `bson:"created_by" json:"created_by"`
+	MaxUses   int       `bson:"max_uses" json:"max_uses"`
+	Uses      int       `bson:"uses" json:"uses"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,53 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: scoped_token.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the data model for device-scoped access tokens.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// ScopedToken records an access token an owner minted for a few of their
+// devices, e.g. for a kiosk showing one device. The token itself is a JWT
+// whose ID is the ID of this record; deleting the record revokes it
+// without touching the owner's sessions.
+type ScopedToken struct {
+	ID     string `bson:"_id" json:"id"`
+	UserID string `bson:"user_id" json:"user_id"`
+	Name   string `bson:"name,omitempty" json:"name,omitempty"`
+	// DeviceIDs are the only devices the token can access, and Operations
+	// what it can do with them.
+	DeviceIDs  []string  `bson:"device_ids" json:"device_ids"`
+	Operations []string  `bson:"operations" json:"operations"`
+	ExpiresAt  time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Scoped token operations: ScopeRead allows reading a device and its data,
+// ScopeControl commands and changes, as a share of the same permission
+// does.
+const (
+	ScopeRead    = "read"
+	ScopeControl = "control"
+)
+
+// MaxScopedTokenDevices bounds the devices of one scoped token.
+const MaxScopedTokenDevices = 50
+
+func ValidScopeOperation(op string) bool {
+	return op == ScopeRead || op == ScopeControl
+}
+
+// Allows reports whether the token may perform operation on deviceID.
+func (t *ScopedToken) Allows(deviceID, operation string) bool {
+	return slices.Contains(t.DeviceIDs, deviceID) && slices.Contains(t.Operations, operation)
+}
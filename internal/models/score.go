@@ -0,0 +1,96 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: score.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the composite health score of sensor readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// ScoreBreakpoint maps a concentration to a health subscore from 100,
+// best, to 0, worst.
+type ScoreBreakpoint struct {
+	Value float64
+	Score int
+}
+
+var (
+	scoreMu sync.RWMutex
+	// scoreBreakpoints are the breakpoints of each scored pollutant, by
+	// ascending Value. The defaults follow the EPA AQI breakpoints for
+	// PM2.5 (µg/m³, 24 hours) and CO (ppm, 8 hours), and the usual
+	// ventilation guidance for indoor CO2 (ppm).
+	scoreBreakpoints = map[string][]ScoreBreakpoint{
+		"pm25": {{0, 100}, {9, 80}, {35.4, 60}, {55.4, 40}, {125.4, 20}, {225.4, 0}},
+		"co2":  {{400, 100}, {800, 80}, {1000, 60}, {1500, 40}, {2000, 20}, {5000, 0}},
+		"co":   {{0, 100}, {4.4, 80}, {9.4, 60}, {12.4, 40}, {15.4, 20}, {30.4, 0}},
+	}
+)
+
+// SetScoreBreakpoints replaces the breakpoints of field, one of pm25, co2
+// and co. They need at least two points with ascending values and scores
+// from 0 to 100 that do not rise. It is meant to be called at startup.
+func SetScoreBreakpoints(field string, points []ScoreBreakpoint) error {
+	scoreMu.Lock()
+	defer scoreMu.Unlock()
+	if _, ok := scoreBreakpoints[field]; !ok {
+		return fmt.Errorf("%s is not a scored pollutant", field)
+	}
+	if len(points) < 2 {
+		return fmt.Errorf("%s: at least two breakpoints are needed", field)
+	}
+	for i, p := range points {
+		if p.Score < 0 || p.Score > 100 {
+			return fmt.Errorf("%s: score %d is not between 0 and 100", field, p.Score)
+		}
+		if i > 0 && (p.Value <= points[i-1].Value || p.Score > points[i-1].Score) {
+			return fmt.Errorf("%s: values must ascend and scores must not rise", field)
+		}
+	}
+	scoreBreakpoints[field] = append([]ScoreBreakpoint(nil), points...)
+	return nil
+}
+
+// CompositeScore scores PM2.5, CO2 and CO from 0 to 100, lower being
+// worse, by interpolating between their breakpoints. It returns the overall
+// score, which is the lowest subscore since the worst pollutant limits the
+// air quality, and the subscores by field name.
+func (s Sensors) CompositeScore() (int, map[string]int) {
+	scoreMu.RLock()
+	defer scoreMu.RUnlock()
+	values := s.Fields()
+	overall := 100
+	subscores := make(map[string]int, len(scoreBreakpoints))
+	for field, points := range scoreBreakpoints {
+		score := subscore(points, values[field].Value)
+		subscores[field] = score
+		overall = min(overall, score)
+	}
+	return overall, subscores
+}
+
+// subscore interpolates v between the breakpoints around it. Values
+// outside the table take the score of its nearest end.
+func subscore(points []ScoreBreakpoint, v float64) int {
+	i := sort.Search(len(points), func(i int) bool { return points[i].Value >= v })
+	switch i {
+	case 0:
+		return points[0].Score
+	case len(points):
+		return points[len(points)-1].Score
+	}
+	lo, hi := points[i-1], points[i]
+	score := float64(lo.Score) + float64(hi.Score-lo.Score)*(v-lo.Value)/(hi.Value-lo.Value)
+	return int(math.Round(score))
+}
@@ -0,0 +1,44 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_anomaly.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the data model for flagged sensor readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// AnomalyReasonZScore flags a value too many standard deviations away from
+// the rolling mean of the readings before it.
+const AnomalyReasonZScore = "z_score"
+
+// SensorAnomaly is a field of a reading flagged as anomalous. It is keyed
+// by the device, timestamp, field and reason, so flagging it again updates
+// it. ReadingID is set by scans only: the live detector flags a reading
+// before it is stored.
+type SensorAnomaly struct {
+	ID        string  `bson:"_id" json:"-"`
+	DeviceID  string  `bson:"device_id" json:"device_id"`
+	ReadingID string  `bson:"reading_id,omitempty" json:"reading_id,omitempty"`
+	Field     string  `bson:"field" json:"field"`
+	Value     float64 `bson:"value" json:"value"`
+	Reason    string  `bson:"reason" json:"reason"`
+	// Mean and StdDev are those of the window the value was scored
+	// against.
+	Mean       float64   `bson:"mean" json:"mean"`
+	StdDev     float64   `bson:"stddev" json:"stddev"`
+	ZScore     float64   `bson:"z_score" json:"z_score"`
+	Timestamp  time.Time `bson:"timestamp" json:"timestamp"`
+	DetectedAt time.Time `bson:"detected_at" json:"detected_at"`
+}
+
+// SensorAnomalyID is the key of the anomaly of field in the reading of
+// deviceID at timestamp.
+func SensorAnomalyID(deviceID string, timestamp time.Time, field, reason string) string {
+	return deviceID + "/" + timestamp.UTC().Format(time.RFC3339Nano) + "/" + field + "/" + reason
+}
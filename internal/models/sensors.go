@@ -3,7 +3,7 @@
  * Filename: sensors.go
  * Author: [trung.la]
  * Created: [2025-10-30]
- * Last Updated: [2025-10-30]
+ * Last Updated: [2026-10-14]
  * Description: This file contains the data models for sensor data in the AirSense system.
  *
  * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
@@ -14,10 +14,71 @@ package models
 import "time"
 
 type SensorData struct {
-	ID        string    `bson:"_id" json:"id"`
-	DeviceID  string    `bson:"device_id" json:"device_id"`
-	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
-	Sensors   Sensors   `bson:"sensors" json:"sensors"`
+	ID        string     `bson:"_id" json:"id"`
+	DeviceID  string     `bson:"device_id" json:"device_id"`
+	Timestamp time.Time  `bson:"timestamp" json:"timestamp"`
+	Sensors   Sensors    `bson:"sensors" json:"sensors"`
+	Source    DataSource `bson:"source,omitempty" json:"source,omitempty"`
+	// SchemaVersion is the payload version the reading was sent in.
+	SchemaVersion int `bson:"schema_version,omitempty" json:"schema_version,omitempty"`
+	// AQI and AQICategory are derived from PM2.5 when the reading is stored.
+	AQI         int    `bson:"aqi" json:"aqi"`
+	AQICategory string `bson:"aqi_category,omitempty" json:"aqi_category,omitempty"`
+	// Quality is rated when a device reading is ingested, see quality.Scorer.
+	Quality DataQuality `bson:"quality,omitempty" json:"quality,omitempty"`
+	// Tags are labels the device or a user put on the reading, e.g.
+	// {"event": "cooking"}, see utils.ValidateSensorTags.
+	Tags map[string]string `bson:"tags,omitempty" json:"tags,omitempty"`
+
+	// PriorOwnerID is the user who owned the device when the reading was
+	// taken, set when the device is transferred with its history.
+	PriorOwnerID string `bson:"prior_owner_id,omitempty" json:"prior_owner_id,omitempty"`
+}
+
+// ResetServerFields clears the fields of d that only the server sets: its
+// ID, AQI, quality, source, schema version and prior owner. Readings sent
+// by clients go through it, so that those fields are ignored.
+func (d *SensorData) ResetServerFields() {
+	d.ID = ""
+	d.Source = ""
+	d.SchemaVersion = 0
+	d.AQI, d.AQICategory = 0, ""
+	d.Quality = ""
+	d.PriorOwnerID = ""
+}
+
+// DataQuality tells consumers how far a reading can be trusted.
+type DataQuality string
+
+const (
+	QualityGood DataQuality = "good"
+	// QualityDegraded is a reading that spiked against the previous one.
+	QualityDegraded DataQuality = "degraded"
+	// QualityPoor is a reading from a sensor that looks saturated.
+	QualityPoor DataQuality = "poor"
+)
+
+// DataSource records how a reading reached the backend.
+type DataSource string
+
+const (
+	SourceMQTT       DataSource = "mqtt"
+	SourceBulkUpload DataSource = "bulk_upload"
+	SourceManual     DataSource = "manual"
+	// SourceHTTP is a reading a device posted with its API key.
+	SourceHTTP DataSource = "http"
+	// SourceInterpolated is a synthetic reading filling a gap.
+	SourceInterpolated DataSource = "interpolated"
+	// SourceSeed is a demo reading generated by cmd/seed.
+	SourceSeed DataSource = "seed"
+)
+
+func (s DataSource) Valid() bool {
+	switch s {
+	case SourceMQTT, SourceBulkUpload, SourceManual, SourceHTTP, SourceInterpolated, SourceSeed:
+		return true
+	}
+	return false
 }
 
 type Sensors struct {
@@ -31,4 +92,18 @@ type Sensors struct {
 type SensorValue struct {
 	Value float64 `bson:"value" json:"value"`
 	Unit  string  `bson:"unit" json:"unit"`
+	// Confidence is the 0–1 certainty firmware with ML-enhanced sensors
+	// reports along with the value; nil for plain sensors.
+	Confidence *float64 `bson:"confidence,omitempty" json:"confidence,omitempty"`
+}
+
+// Fields returns the sensor readings keyed by their bson/json field name.
+func (s Sensors) Fields() map[string]SensorValue {
+	return map[string]SensorValue{
+		"pm25":        s.PM25,
+		"co2":         s.CO2,
+		"co":          s.CO,
+		"temperature": s.Temperature,
+		"humidity":    s.Humidity,
+	}
 }
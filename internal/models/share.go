@@ -0,0 +1,37 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: share.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the data models for device sharing in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// DeviceShare grants a user other than the owner access to a device.
+type DeviceShare struct {
+	ID            string          `bson:"_id" json:"id"`
+	DeviceID      string          `bson:"device_id" json:"device_id"`
+	OwnerID       string          `bson:"owner_id" json:"owner_id"`
+	GranteeUserID string          `bson:"grantee_user_id" json:"grantee_user_id"`
+	Permission    SharePermission `bson:"permission" json:"permission"`
+	CreatedAt     time.Time       `bson:"created_at" json:"created_at"`
+}
+
+type SharePermission string
+
+const (
+	// ShareRead allows reading the device and its sensor data.
+	ShareRead SharePermission = "read"
+	// ShareControl additionally allows commands and metadata changes.
+	ShareControl SharePermission = "control"
+)
+
+func (p SharePermission) Valid() bool {
+	return p == ShareRead || p == ShareControl
+}
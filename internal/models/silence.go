@@ -0,0 +1,39 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: silence.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the data model for alert silences in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Silence suppresses the alerts of a device between StartsAt and EndsAt,
+// e.g. during maintenance. AlertIDs are the sensor fields whose threshold
+// alerts are silenced; empty silences every alert of the device.
+type Silence struct {
+	ID        string    `bson:"_id" json:"id"`
+	DeviceID  string    `bson:"device_id" json:"device_id"`
+	AlertIDs  []string  `bson:"alert_ids" json:"alert_ids"`
+	StartsAt  time.Time `bson:"starts_at" json:"starts_at"`
+	EndsAt    time.Time `bson:"ends_at" json:"ends_at"`
+	CreatedBy string    `bson:"created_by" json:"created_by"`
+	Reason    string    `bson:"reason" json:"reason"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Covers reports whether the silence suppresses the alert at at.
+func (s *Silence) Covers(alertID string, at time.Time) bool {
+	if at.Before(s.StartsAt) || !at.Before(s.EndsAt) {
+		return false
+	}
+	return len(s.AlertIDs) == 0 || slices.Contains(s.AlertIDs, alertID)
+}
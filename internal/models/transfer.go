@@ -0,0 +1,37 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: transfer.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the data models for device ownership transfers in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// DeviceTransfer moves a device from one owner to another. The document is
+// kept after acceptance and doubles as the audit record of the transfer.
+type DeviceTransfer struct {
+	ID          string         `bson:"_id" json:"id"`
+	DeviceID    string         `bson:"device_id" json:"device_id"`
+	FromUserID  string         `bson:"from_user_id" json:"from_user_id"`
+	ToEmail     string         `bson:"to_email" json:"to_email"`
+	ToUserID    string         `bson:"to_user_id,omitempty" json:"to_user_id,omitempty"`
+	KeepHistory bool           `bson:"keep_history" json:"keep_history"`
+	TokenHash   string         `bson:"token_hash" json:"-"`
+	Status      TransferStatus `bson:"status" json:"status"`
+	ExpiresAt   time.Time      `bson:"expires_at" json:"expires_at"`
+	AcceptedAt  *time.Time     `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	CreatedAt   time.Time      `bson:"created_at" json:"created_at"`
+}
+
+type TransferStatus string
+
+const (
+	TransferPending  TransferStatus = "pending"
+	TransferAccepted TransferStatus = "accepted"
+)
@@ -0,0 +1,98 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: user.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the data models for user data in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+type User struct {
+	ID        string    `bson:"_id" json:"id"`
+	Email     string    `bson:"email" json:"email"`
+	Name      string    `bson:"name,omitempty" json:"name,omitempty"`
+	Password  string    `bson:"password" json:"-"`
+	Role      string    `bson:"role,omitempty" json:"role,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+
+	// Unverified marks a self-registered user whose email address is not
+	// confirmed yet. Users created otherwise are verified.
+	Unverified bool       `bson:"unverified,omitempty" json:"unverified,omitempty"`
+	VerifiedAt *time.Time `bson:"verified_at,omitempty" json:"verified_at,omitempty"`
+
+	// DeviceLimit overrides the default device quota when set.
+	DeviceLimit *int `bson:"device_limit,omitempty" json:"device_limit,omitempty"`
+	// DeviceCount is the number of device slots in use, deleted devices
+	// included until they are purged.
+	DeviceCount int `bson:"device_count" json:"device_count"`
+
+	// PasswordChangedAt is when the password was last reset; access
+	// tokens issued before it are refused.
+	PasswordChangedAt *time.Time `bson:"password_changed_at,omitempty" json:"-"`
+	// SessionsRevokedAt is when every session of the user was last ended,
+	// see SessionsValidSince.
+	SessionsRevokedAt *time.Time `bson:"sessions_revoked_at,omitempty" json:"-"`
+
+	// OIDCIssuer and OIDCSubject name the identity at an OpenID Connect
+	// provider the user signs in with, if any. Users created by signing in
+	// there have no password.
+	OIDCIssuer  string `bson:"oidc_issuer,omitempty" json:"oidc_issuer,omitempty"`
+	OIDCSubject string `bson:"oidc_subject,omitempty" json:"-"`
+}
+
+// SessionsValidSince returns the time before which access tokens of u are
+// refused: the later of its last password reset and the last time its
+// sessions were revoked. It is zero if neither happened.
+func (u *User) SessionsValidSince() time.Time {
+	var since time.Time
+	for _, t := range []*time.Time{u.PasswordChangedAt, u.SessionsRevokedAt} {
+		if t != nil && t.After(since) {
+			since = *t
+		}
+	}
+	return since
+}
+
+const RoleAdmin = "admin"
+
+// RefreshToken is a rotating, single-use refresh token. Only the SHA-256
+// hash of the token is stored. Tokens descending from the same login share
+// a FamilyID so the whole chain can be revoked when reuse is detected.
+type RefreshToken struct {
+	ID         string     `bson:"_id" json:"id"`
+	UserID     string     `bson:"user_id" json:"user_id"`
+	FamilyID   string     `bson:"family_id" json:"family_id"`
+	TokenHash  string     `bson:"token_hash" json:"-"`
+	ExpiresAt  time.Time  `bson:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	ReplacedBy string     `bson:"replaced_by,omitempty" json:"replaced_by,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+}
+
+// PasswordReset is a single-use token letting UserID choose a new password
+// until ExpiresAt. Only the SHA-256 hash of the token is stored.
+type PasswordReset struct {
+	ID        string     `bson:"_id" json:"id"`
+	UserID    string     `bson:"user_id" json:"user_id"`
+	TokenHash string     `bson:"token_hash" json:"-"`
+	ExpiresAt time.Time  `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `bson:"used_at,omitempty" json:"used_at,omitempty"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+}
+
+// EmailVerification is a token confirming the email address of a
+// registered UserID until ExpiresAt. Only the SHA-256 hash of the token is
+// stored.
+type EmailVerification struct {
+	ID        string    `bson:"_id" json:"id"`
+	UserID    string    `bson:"user_id" json:"user_id"`
+	TokenHash string    `bson:"token_hash" json:"-"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,31 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: user_device.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the data model of the mobile app installations receiving push notifications.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package models
+
+import "time"
+
+// Mobile platforms of a UserDevice.
+const (
+	PlatformAndroid = "android"
+	PlatformIOS     = "ios"
+)
+
+// UserDevice is a mobile app installation of a user that receives alerts
+// as push notifications. The document _id is the FCM registration token,
+// which identifies the installation: registering it again moves it to the
+// registering user.
+type UserDevice struct {
+	FCMToken     string    `bson:"_id" json:"fcm_token"`
+	UserID       string    `bson:"user_id" json:"user_id"`
+	Platform     string    `bson:"platform" json:"platform"`
+	RegisteredAt time.Time `bson:"registered_at" json:"registered_at"`
+}
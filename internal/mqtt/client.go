@@ -0,0 +1,248 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: client.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MQTT client wrapper used by the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/metrics"
+)
+
+const (
+	operationTimeout = 10 * time.Second
+
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = 5 * time.Minute
+)
+
+type subscription struct {
+	qos     byte
+	handler paho.MessageHandler
+}
+
+// MQTTClient wraps the paho client with its own reconnect loop so that
+// disconnects are logged, backed off exponentially, and every subscription
+// is restored once the broker is reachable again.
+type MQTTClient struct {
+	client paho.Client
+
+	mu   sync.Mutex
+	subs map[string]subscription
+
+	// reconnecting is set while reconnectLoop runs, which is at most once;
+	// lostAgain records a connection lost meanwhile, e.g. while the
+	// subscriptions were restored, so the loop goes on instead of exiting.
+	reconnectMu  sync.Mutex
+	reconnecting bool
+	lostAgain    bool
+	done         chan struct{}
+	closeOnce    sync.Once
+	// after waits out a backoff; tests replace it.
+	after func(time.Duration) <-chan time.Time
+
+	// inflight counts the subscription callbacks running, which Drain
+	// waits for. Callbacks are only added to it under trackMu while
+	// draining is unset, so none is added while Drain waits.
+	inflight sync.WaitGroup
+	trackMu  sync.Mutex
+	draining bool
+}
+
+func NewClient(cfg config.MQTTConfig) *MQTTClient {
+	c := &MQTTClient{
+		subs:  make(map[string]subscription),
+		done:  make(chan struct{}),
+		after: time.After,
+	}
+	opts := paho.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(false).
+		SetConnectionLostHandler(c.onConnectionLost)
+	c.client = paho.NewClient(opts)
+	return c
+}
+
+func (c *MQTTClient) Connect() error {
+	return wait(c.client.Connect(), "connect")
+}
+
+// Subscribe registers the subscription with the broker and remembers it so
+// it can be re-established after a reconnect.
+func (c *MQTTClient) Subscribe(topic string, qos byte, handler paho.MessageHandler) error {
+	handler = c.track(handler)
+	c.mu.Lock()
+	c.subs[topic] = subscription{qos: qos, handler: handler}
+	c.mu.Unlock()
+	return wait(c.client.Subscribe(topic, qos, handler), "subscribe "+topic)
+}
+
+func (c *MQTTClient) Publish(topic string, qos byte, payload []byte) error {
+	return wait(c.client.Publish(topic, qos, false, payload), "publish "+topic)
+}
+
+func (c *MQTTClient) Disconnect() {
+	c.closeOnce.Do(func() { close(c.done) })
+	c.client.Disconnect(uint(operationTimeout / time.Millisecond))
+}
+
+// Drain stops the subscriptions, waits for the callbacks of messages
+// already received to return, or for ctx to expire, and disconnects.
+// Messages arriving meanwhile are dropped.
+// Messages the callbacks handed to a WorkerPool are drained by its
+// Shutdown.
+func (c *MQTTClient) Drain(ctx context.Context) error {
+	c.closeOnce.Do(func() { close(c.done) })
+
+	c.mu.Lock()
+	topics := make([]string, 0, len(c.subs))
+	for topic := range c.subs {
+		topics = append(topics, topic)
+	}
+	c.mu.Unlock()
+	if len(topics) > 0 && c.client.IsConnectionOpen() {
+		if err := wait(c.client.Unsubscribe(topics...), "unsubscribe"); err != nil {
+			log.Printf("mqtt: drain: %v", err)
+		}
+	}
+
+	c.trackMu.Lock()
+	c.draining = true
+	c.trackMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	c.Disconnect()
+	return err
+}
+
+// track wraps handler so that Drain can wait for its calls. Messages
+// arriving once Drain started are dropped.
+func (c *MQTTClient) track(handler paho.MessageHandler) paho.MessageHandler {
+	return func(client paho.Client, m paho.Message) {
+		c.trackMu.Lock()
+		if c.draining {
+			c.trackMu.Unlock()
+			log.Printf("mqtt: drop message on %s received while draining", m.Topic())
+			return
+		}
+		c.inflight.Add(1)
+		c.trackMu.Unlock()
+		defer c.inflight.Done()
+		handler(client, m)
+	}
+}
+
+func (c *MQTTClient) onConnectionLost(_ paho.Client, err error) {
+	log.Printf("mqtt: connection lost: %v", err)
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+	if c.reconnecting {
+		c.lostAgain = true
+		return
+	}
+	c.reconnecting = true
+	go c.reconnectLoop()
+}
+
+// reconnectLoop reconnects until the connection holds, also when it is
+// lost again while being restored.
+func (c *MQTTClient) reconnectLoop() {
+	for c.reconnect() {
+		c.reconnectMu.Lock()
+		if !c.lostAgain {
+			c.reconnecting = false
+			c.reconnectMu.Unlock()
+			return
+		}
+		c.lostAgain = false
+		c.reconnectMu.Unlock()
+	}
+	c.reconnectMu.Lock()
+	c.reconnecting = false
+	c.reconnectMu.Unlock()
+}
+
+// reconnect connects with exponential backoff and restores the
+// subscriptions, reporting false if the client was closed first. Should a
+// subscription fail, it disconnects and tries again, rather than go on
+// without the messages of that topic.
+func (c *MQTTClient) reconnect() bool {
+	backoff := minReconnectBackoff
+	for attempt := 1; ; attempt++ {
+		log.Printf("mqtt: reconnect attempt %d in %s", attempt, backoff)
+		select {
+		case <-c.done:
+			return false
+		case <-c.after(backoff):
+		}
+
+		metrics.MQTTReconnectAttempts.Add(1)
+		if err := wait(c.client.Connect(), "connect"); err != nil {
+			log.Printf("mqtt: reconnect attempt %d failed: %v", attempt, err)
+			backoff = min(backoff*2, maxReconnectBackoff)
+			continue
+		}
+
+		if err := c.resubscribe(); err != nil {
+			log.Printf("mqtt: reconnect attempt %d failed to restore the subscriptions: %v", attempt, err)
+			c.client.Disconnect(0)
+			backoff = min(backoff*2, maxReconnectBackoff)
+			continue
+		}
+		log.Printf("mqtt: reconnected after %d attempt(s)", attempt)
+		return true
+	}
+}
+
+// resubscribe restores every subscription and returns the errors of those
+// that failed.
+func (c *MQTTClient) resubscribe() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for topic, sub := range c.subs {
+		if err := wait(c.client.Subscribe(topic, sub.qos, sub.handler), "subscribe "+topic); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func wait(token paho.Token, op string) error {
+	if !token.WaitTimeout(operationTimeout) {
+		return fmt.Errorf("mqtt %s: timed out", op)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("mqtt %s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: client_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of draining the MQTT client.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"airsense-be.com/internal/config"
+)
+
+type testMessage struct {
+	paho.Message
+	topic string
+}
+
+func (m testMessage) Topic() string { return m.topic }
+
+// newDrainClient returns a client that never connected; Drain only
+// unsubscribes from an open connection.
+func newDrainClient() *MQTTClient {
+	return NewClient(config.MQTTConfig{Broker: "tcp://127.0.0.1:1", ClientID: "test"})
+}
+
+func TestDrainWaitsForInflight(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		release  bool
+		wantErr  error
+		wantDone bool
+	}{
+		{"callback returns", 5 * time.Second, true, nil, true},
+		{"callback outlives the deadline", 50 * time.Millisecond, false, context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newDrainClient()
+			started, release := make(chan struct{}), make(chan struct{})
+			var done atomic.Bool
+			handler := c.track(func(paho.Client, paho.Message) {
+				close(started)
+				<-release
+				done.Store(true)
+			})
+			go handler(nil, testMessage{topic: "devices/d1/data"})
+			<-started
+
+			drained := make(chan error, 1)
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			go func() { drained <- c.Drain(ctx) }()
+			select {
+			case err := <-drained:
+				if tt.release {
+					t.Fatalf("Drain returned %v with a callback running", err)
+				}
+			case <-time.After(20 * time.Millisecond):
+			}
+			if tt.release {
+				close(release)
+			} else {
+				defer close(release)
+			}
+			select {
+			case err := <-drained:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Drain() = %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Drain did not return")
+			}
+			if done.Load() != tt.wantDone {
+				t.Errorf("callback finished = %v, want %v", done.Load(), tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestDrainDropsLateMessages(t *testing.T) {
+	c := newDrainClient()
+	var calls atomic.Int32
+	handler := c.track(func(paho.Client, paho.Message) { calls.Add(1) })
+	handler(nil, testMessage{topic: "devices/d1/data"})
+	if err := c.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	handler(nil, testMessage{topic: "devices/d1/data"})
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1: a message after Drain was handled", n)
+	}
+}
+
+// TestDrainConcurrentMessages runs Drain while messages keep arriving; run
+// with -race, adding to the WaitGroup while Drain waits would be reported.
+func TestDrainConcurrentMessages(t *testing.T) {
+	c := newDrainClient()
+	var handled, finished atomic.Int32
+	handler := c.track(func(paho.Client, paho.Message) {
+		handled.Add(1)
+		time.Sleep(time.Millisecond)
+		finished.Add(1)
+	})
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := testMessage{topic: "devices/d" + strconv.Itoa(i) + "/data"}
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					handler(nil, m)
+				}
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := c.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Every callback admitted before Drain has returned.
+	if h, f := handled.Load(), finished.Load(); h != f {
+		t.Errorf("Drain returned with %d of %d callbacks running", h-f, h)
+	}
+	close(stop)
+	wg.Wait()
+}
+
+// doneToken is a finished paho.Token with err.
+type doneToken struct {
+	paho.Token
+	err error
+}
+
+func (t doneToken) WaitTimeout(time.Duration) bool { return true }
+func (t doneToken) Error() error                   { return t.err }
+
+// flakyBroker is a paho.Client whose first failConnects connects fail, and
+// whose subscriptions to the topics in failSubscribe fail once after a
+// reconnect.
+type flakyBroker struct {
+	paho.Client
+
+	mu            sync.Mutex
+	failConnects  int
+	failSubscribe map[string]bool
+	connects      int
+	disconnects   int
+	subscribed    []string
+}
+
+func (b *flakyBroker) Connect() paho.Token {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connects++
+	if b.failConnects > 0 {
+		b.failConnects--
+		return doneToken{err: errors.New("connection refused")}
+	}
+	return doneToken{}
+}
+
+func (b *flakyBroker) Subscribe(topic string, _ byte, _ paho.MessageHandler) paho.Token {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.connects > 0 && b.failSubscribe[topic] {
+		delete(b.failSubscribe, topic)
+		return doneToken{err: errors.New("not authorized")}
+	}
+	b.subscribed = append(b.subscribed, topic)
+	return doneToken{}
+}
+
+func (b *flakyBroker) Disconnect(uint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.disconnects++
+}
+
+// dropConnection subscribes c to topics on broker, drops the connection
+// and returns the backoffs waited out until c reconnected.
+func dropConnection(t *testing.T, c *MQTTClient, broker *flakyBroker, topics ...string) []time.Duration {
+	t.Helper()
+	c.client = broker
+	var mu sync.Mutex
+	var backoffs []time.Duration
+	c.after = func(d time.Duration) <-chan time.Time {
+		mu.Lock()
+		backoffs = append(backoffs, d)
+		mu.Unlock()
+		ch := make(chan time.Time, 1)
+		ch <- time.Time{}
+		return ch
+	}
+	for _, topic := range topics {
+		if err := c.Subscribe(topic, 1, func(paho.Client, paho.Message) {}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	broker.mu.Lock()
+	broker.subscribed = nil
+	broker.mu.Unlock()
+
+	c.onConnectionLost(nil, errors.New("EOF"))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.reconnectMu.Lock()
+		reconnecting := c.reconnecting
+		c.reconnectMu.Unlock()
+		if !reconnecting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("did not reconnect")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return backoffs
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	c := newDrainClient()
+	broker := &flakyBroker{failConnects: 11}
+	backoffs := dropConnection(t, c, broker, "devices/+/data", "devices/+/status", "tenants/t1/devices/+/data")
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second,
+		32 * time.Second, 64 * time.Second, 128 * time.Second, 256 * time.Second, 5 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	if !slices.Equal(backoffs, want) {
+		t.Errorf("backoffs = %v, want %v", backoffs, want)
+	}
+	if broker.connects != 12 {
+		t.Errorf("connected %d times, want 12", broker.connects)
+	}
+	slices.Sort(broker.subscribed)
+	if wantSubs := []string{"devices/+/data", "devices/+/status", "tenants/t1/devices/+/data"}; !slices.Equal(broker.subscribed, wantSubs) {
+		t.Errorf("resubscribed to %v, want %v", broker.subscribed, wantSubs)
+	}
+}
+
+func TestReconnectResubscribeFails(t *testing.T) {
+	c := newDrainClient()
+	broker := &flakyBroker{failSubscribe: map[string]bool{"tenants/t2/devices/+/data": true}}
+	backoffs := dropConnection(t, c, broker, "tenants/t1/devices/+/data", "tenants/t2/devices/+/data")
+
+	if want := []time.Duration{time.Second, 2 * time.Second}; !slices.Equal(backoffs, want) {
+		t.Errorf("backoffs = %v, want %v", backoffs, want)
+	}
+	if broker.connects != 2 || broker.disconnects != 1 {
+		t.Errorf("connected %d and disconnected %d times, want 2 and 1: a failed subscription must force a reconnect",
+			broker.connects, broker.disconnects)
+	}
+	var t2 int
+	for _, topic := range broker.subscribed {
+		if topic == "tenants/t2/devices/+/data" {
+			t2++
+		}
+	}
+	if t2 != 1 {
+		t.Errorf("subscribed to the failed topic %d times after reconnecting, want 1: %v", t2, broker.subscribed)
+	}
+}
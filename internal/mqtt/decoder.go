@@ -0,0 +1,108 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: decoder.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the versioned decoder of MQTT sensor payloads.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"airsense-be.com/internal/models"
+)
+
+var ErrUnknownSchemaVersion = errors.New("unknown payload schema version")
+
+// payloadDecoder parses one schema version into SensorData.
+type payloadDecoder func(payload []byte) (*models.SensorData, error)
+
+// decoders maps the "version" of a payload to its parser. Payloads without
+// a version come from firmware older than versioning and are v1.
+var decoders = map[int]payloadDecoder{
+	1: decodeV1,
+	2: decodeV2,
+}
+
+// DecodeSensorPayload normalises a devices/{id}/data payload of any known
+// schema version. SchemaVersion records the version it arrived in; the
+// other fields only the server sets are cleared.
+func DecodeSensorPayload(payload []byte) (*models.SensorData, error) {
+	var head struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(payload, &head); err != nil {
+		return nil, err
+	}
+	version := 1
+	if head.Version != nil {
+		version = *head.Version
+	}
+	decode, ok := decoders[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownSchemaVersion, version)
+	}
+	data, err := decode(payload)
+	if err != nil {
+		return nil, err
+	}
+	data.ResetServerFields()
+	data.SchemaVersion = version
+	return data, nil
+}
+
+// decodeV1 reads the original shape, which is SensorData itself:
+// {"timestamp": "...", "sensors": {"pm25": {"value": 12, "unit": "µg/m³"}, ...}}
+func decodeV1(payload []byte) (*models.SensorData, error) {
+	var data models.SensorData
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// v2Units are implied by the v2 schema, which only sends values.
+var v2Units = map[string]string{
+	"pm25":        "µg/m³",
+	"co2":         "ppm",
+	"co":          "ppm",
+	"temperature": "°C",
+	"humidity":    "%",
+}
+
+// decodeV2 reads the compact shape with a Unix timestamp and bare values:
+// {"version": 2, "device_id": "...", "ts": 1760400000, "readings": {"pm25": 12, ...}, "tags": {...}}
+func decodeV2(payload []byte) (*models.SensorData, error) {
+	var p struct {
+		DeviceID string             `json:"device_id"`
+		TS       int64              `json:"ts"`
+		Readings map[string]float64 `json:"readings"`
+		Tags     map[string]string  `json:"tags"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, err
+	}
+	data := &models.SensorData{DeviceID: p.DeviceID, Tags: p.Tags}
+	if p.TS != 0 {
+		data.Timestamp = time.Unix(p.TS, 0).UTC()
+	}
+	value := func(field string) models.SensorValue {
+		return models.SensorValue{Value: p.Readings[field], Unit: v2Units[field]}
+	}
+	data.Sensors = models.Sensors{
+		PM25:        value("pm25"),
+		CO2:         value("co2"),
+		CO:          value("co"),
+		Temperature: value("temperature"),
+		Humidity:    value("humidity"),
+	}
+	return data, nil
+}
@@ -0,0 +1,55 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: decoder_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the versioned decoder of MQTT sensor payloads.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeSensorPayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     string
+		wantVersion int
+		wantPM25    float64
+		wantErr     error
+	}{
+		{"v1 without a version", `{"timestamp": "2026-10-15T12:00:00Z", "sensors": {"pm25": {"value": 12, "unit": "µg/m³"}}}`, 1, 12, nil},
+		{"v1 with server fields",
+			`{"id": "r-chosen", "timestamp": "2026-10-15T12:00:00Z", "sensors": {"pm25": {"value": 12, "unit": "µg/m³"}},
+			"source": "bulk_upload", "schema_version": 7, "aqi": 999, "aqi_category": "hazardous", "quality": "good", "prior_owner_id": "u-former"}`,
+			1, 12, nil},
+		{"v2", `{"version": 2, "ts": 1792065600, "readings": {"pm25": 30}}`, 2, 30, nil},
+		{"unknown version", `{"version": 9}`, 0, 0, ErrUnknownSchemaVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := DecodeSensorPayload([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			at := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+			if data.ID != "" || data.Source != "" || data.AQI != 0 || data.AQICategory != "" || data.Quality != "" || data.PriorOwnerID != "" {
+				t.Errorf("server fields set from the payload: %+v", data)
+			}
+			if data.SchemaVersion != tt.wantVersion || !data.Timestamp.Equal(at) || data.Sensors.PM25.Value != tt.wantPM25 {
+				t.Errorf("got version %d at %s pm25 %v, want %d at %s pm25 %v", data.SchemaVersion, data.Timestamp,
+					data.Sensors.PM25.Value, tt.wantVersion, at, tt.wantPM25)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: dedup.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the deduplication of retransmitted MQTT sensor readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+)
+
+type dedupKey [sha256.Size]byte
+
+type dedupEntry struct {
+	key    dedupKey
+	seenAt time.Time
+}
+
+// Deduplicator drops readings a device sent twice, as QoS 1 allows after a
+// lost PUBACK. A reading is known by the hash of its device, timestamp and
+// sensor values, and a copy arriving within WindowDuration of the first is
+// a duplicate. The keys are kept in an LRU cache of at most MaxSize
+// entries, so under load a duplicate may arrive after its original was
+// evicted and be stored again. Each server instance keeps its own cache.
+type Deduplicator struct {
+	WindowDuration time.Duration
+	MaxSize        int
+
+	mu      sync.Mutex
+	order   *list.List // of *dedupEntry, most recently seen first
+	entries map[dedupKey]*list.Element
+}
+
+// NewDeduplicator returns the deduplicator of cfg, nil if its window is 0,
+// which turns deduplication off.
+func NewDeduplicator(cfg config.MQTTConfig) *Deduplicator {
+	if cfg.DedupWindow <= 0 {
+		return nil
+	}
+	return &Deduplicator{
+		WindowDuration: cfg.DedupWindow,
+		MaxSize:        max(cfg.DedupMaxSize, 1),
+		order:          list.New(),
+		entries:        make(map[dedupKey]*list.Element),
+	}
+}
+
+// Seen reports whether data was seen within the window, and remembers it
+// as seen now if it was not.
+func (d *Deduplicator) Seen(data *models.SensorData) bool {
+	key := readingKey(data)
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if el, ok := d.entries[key]; ok {
+		e := el.Value.(*dedupEntry)
+		d.order.MoveToFront(el)
+		if now.Sub(e.seenAt) < d.WindowDuration {
+			return true
+		}
+		e.seenAt = now
+		return false
+	}
+	d.entries[key] = d.order.PushFront(&dedupEntry{key: key, seenAt: now})
+	d.evict(now)
+	return false
+}
+
+// Forget drops data from the cache, so that a retransmission of a reading
+// that failed to be ingested is not taken for a duplicate.
+func (d *Deduplicator) Forget(data *models.SensorData) {
+	key := readingKey(data)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if el, ok := d.entries[key]; ok {
+		d.order.Remove(el)
+		delete(d.entries, key)
+	}
+}
+
+// evict removes the least recently seen entries while there are too many
+// or they are out of the window.
+func (d *Deduplicator) evict(now time.Time) {
+	for el := d.order.Back(); el != nil; el = d.order.Back() {
+		e := el.Value.(*dedupEntry)
+		if d.order.Len() <= d.MaxSize && now.Sub(e.seenAt) < d.WindowDuration {
+			return
+		}
+		d.order.Remove(el)
+		delete(d.entries, e.key)
+	}
+}
+
+// readingKey hashes the device, the Unix timestamp and the sensor values
+// of data.
+func readingKey(data *models.SensorData) dedupKey {
+	h := sha256.New()
+	h.Write([]byte(data.DeviceID))
+	h.Write([]byte{0})
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(data.Timestamp.Unix())))
+	// Sensors holds plain values only, so encoding it cannot fail.
+	sensors, _ := json.Marshal(data.Sensors)
+	sensorHash := sha256.Sum256(sensors)
+	h.Write(sensorHash[:])
+	var key dedupKey
+	h.Sum(key[:0])
+	return key
+}
@@ -0,0 +1,86 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: dedup_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of deduplicating retransmitted MQTT sensor readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+)
+
+func dedupReading(deviceID string, at time.Time, pm25 float64) *models.SensorData {
+	return &models.SensorData{DeviceID: deviceID, Timestamp: at, Sensors: models.Sensors{
+		PM25: models.SensorValue{Value: pm25, Unit: "µg/m³"},
+	}}
+}
+
+func TestDeduplicatorSeen(t *testing.T) {
+	t0 := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	a := dedupReading("d1", t0, 12)
+	tests := []struct {
+		name     string
+		maxSize  int
+		readings []*models.SensorData
+		forget   int // index of the reading forgotten after it was seen, or -1
+		want     []bool
+	}{
+		{"retransmit dropped", 10, []*models.SensorData{a, dedupReading("d1", t0, 12)}, -1, []bool{false, true}},
+		{"every copy dropped", 10, []*models.SensorData{a, a, a}, -1, []bool{false, true, true}},
+		{"same second", 10, []*models.SensorData{a, dedupReading("d1", t0.Add(300*time.Millisecond), 12)}, -1, []bool{false, true}},
+		{"next timestamp kept", 10, []*models.SensorData{a, dedupReading("d1", t0.Add(time.Second), 12)}, -1, []bool{false, false}},
+		{"other values kept", 10, []*models.SensorData{a, dedupReading("d1", t0, 13)}, -1, []bool{false, false}},
+		{"other device kept", 10, []*models.SensorData{a, dedupReading("d2", t0, 12)}, -1, []bool{false, false}},
+		{"forgotten after a failed ingest", 10, []*models.SensorData{a, a}, 0, []bool{false, false}},
+		{"evicted when full", 1, []*models.SensorData{a, dedupReading("d2", t0, 12), a}, -1, []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDeduplicator(config.MQTTConfig{DedupWindow: time.Hour, DedupMaxSize: tt.maxSize})
+			var got []bool
+			for i, r := range tt.readings {
+				got = append(got, d.Seen(r))
+				if i == tt.forget {
+					d.Forget(r)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("seen = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeduplicatorWindow(t *testing.T) {
+	const window = 20 * time.Millisecond
+	d := NewDeduplicator(config.MQTTConfig{DedupWindow: window, DedupMaxSize: 10})
+	r := dedupReading("d1", time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC), 12)
+	if d.Seen(r) {
+		t.Fatal("first reading taken for a duplicate")
+	}
+	time.Sleep(2 * window)
+	if d.Seen(r) {
+		t.Error("copy after the window dropped")
+	}
+	// The copy restarted the window.
+	if !d.Seen(r) {
+		t.Error("copy within the window of the last one kept")
+	}
+}
+
+func TestNewDeduplicatorDisabled(t *testing.T) {
+	if d := NewDeduplicator(config.MQTTConfig{DedupMaxSize: 10}); d != nil {
+		t.Errorf("NewDeduplicator() = %v with no window, want nil", d)
+	}
+}
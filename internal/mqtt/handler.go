@@ -0,0 +1,160 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: handler.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MQTT message handlers of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/service"
+)
+
+type Handler struct {
+	topics   Topics
+	sensors  *service.SensorService
+	devices  *service.DeviceService
+	commands *service.CommandService
+	// dedup drops retransmitted readings; nil keeps them all.
+	dedup *Deduplicator
+}
+
+func NewHandler(topics Topics, sensors *service.SensorService, devices *service.DeviceService, commands *service.CommandService, dedup *Deduplicator) *Handler {
+	return &Handler{topics: topics, sensors: sensors, devices: devices, commands: commands, dedup: dedup}
+}
+
+// StatusPayload is what a device publishes on its status topic.
+type StatusPayload struct {
+	Status models.DeviceStatus `json:"status"`
+}
+
+// AckPayload is what a device publishes on its commands/ack topic once it
+// has executed, or failed to execute, a command.
+type AckPayload struct {
+	CommandID string               `json:"commandID"`
+	Status    models.CommandStatus `json:"status"`
+	Result    map[string]any       `json:"result,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// Route dispatches a message received on one of the tenant wildcard
+// subscriptions by the kind of its topic. Messages for devices that are not
+// registered are logged and dropped.
+func (h *Handler) Route(ctx context.Context, msg Message) error {
+	deviceID, kind, err := h.topics.Parse(msg.Topic)
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case KindSensors:
+		err = h.handleSensorData(ctx, deviceID, msg.Payload)
+	case KindStatus:
+		err = h.handleStatus(ctx, deviceID, msg.Payload)
+	case KindAck:
+		err = h.handleAck(ctx, deviceID, msg.Payload)
+	default:
+		return fmt.Errorf("no handler for topic %q", msg.Topic)
+	}
+	if errors.Is(err, service.ErrDeviceNotFound) {
+		metrics.MQTTUnknownDevice.Add(1)
+		log.Printf("mqtt: warning: drop %s message from unknown device %s", kind, deviceID)
+		return nil
+	}
+	if errors.Is(err, service.ErrDeviceDecommissioned) {
+		log.Printf("mqtt: warning: drop %s message from decommissioned device %s", kind, deviceID)
+		return nil
+	}
+	return err
+}
+
+// handleSensorData decodes a sensor payload, validates and persists it. The
+// device ID is always taken from the topic, which the broker ACL restricts
+// to the authenticated device; a payload claiming a different device is
+// rejected. Duplicates of a recent reading are dropped.
+func (h *Handler) handleSensorData(ctx context.Context, deviceID string, payload []byte) error {
+	data, err := DecodeSensorPayload(payload)
+	if errors.Is(err, ErrUnknownSchemaVersion) {
+		// Dropped rather than failed: retrying cannot help until the
+		// backend learns the version.
+		metrics.MQTTUnknownSchema.Add(1)
+		log.Printf("mqtt: drop payload from %s: %v", deviceID, err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("decode sensor data from %s: %w", deviceID, err)
+	}
+	if data.DeviceID != "" && data.DeviceID != deviceID {
+		metrics.MQTTDeviceMismatch.Add(1)
+		return fmt.Errorf("payload device_id %q does not match topic device %s", data.DeviceID, deviceID)
+	}
+	data.DeviceID = deviceID
+	data.Source = models.SourceMQTT
+	if h.dedup == nil {
+		return h.sensors.Ingest(ctx, data)
+	}
+	if h.dedup.Seen(data) {
+		metrics.MQTTDeduplicated.Add(1)
+		return nil
+	}
+	if err := h.sensors.Ingest(ctx, data); err != nil {
+		h.dedup.Forget(data)
+		return err
+	}
+	return nil
+}
+
+func (h *Handler) handleStatus(ctx context.Context, deviceID string, payload []byte) error {
+	var p StatusPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("decode status from %s: %w", deviceID, err)
+	}
+	if err := h.devices.ReportStatus(ctx, deviceID, p.Status, time.Now().UTC()); err != nil {
+		return err
+	}
+	if p.Status == models.DeviceOnline {
+		if _, err := h.commands.FlushQueued(ctx, deviceID); err != nil {
+			return fmt.Errorf("flush queued commands of %s: %w", deviceID, err)
+		}
+	}
+	return nil
+}
+
+// handleAck completes the acknowledged command. Acks that cannot apply, for
+// an unknown or already finished command, are logged and ignored: the
+// device cannot do anything about them and a late ack must never move a
+// finished command to another status.
+func (h *Handler) handleAck(ctx context.Context, deviceID string, payload []byte) error {
+	var p AckPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("decode command ack from %s: %w", deviceID, err)
+	}
+	cmd, err := h.commands.UpdateStatus(ctx, deviceID, p.CommandID,
+		service.CommandAck{Status: p.Status, Result: p.Result, Error: p.Error})
+	switch {
+	case errors.Is(err, service.ErrCommandNotFound),
+		errors.Is(err, service.ErrCommandTerminal),
+		errors.Is(err, service.ErrInvalidCommandStatus),
+		errors.Is(err, service.ErrInvalidTransition):
+		metrics.MQTTAcksIgnored.Add(1)
+		log.Printf("mqtt: ignore %s ack for command %q of device %s: %v", p.Status, p.CommandID, deviceID, err)
+		return nil
+	case errors.Is(err, service.ErrCommandExpired):
+		log.Printf("mqtt: command %s of device %s acked after expiry, marked %s", p.CommandID, deviceID, cmd.Status)
+		return nil
+	}
+	return err
+}
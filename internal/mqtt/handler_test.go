@@ -0,0 +1,88 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: handler_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of handling the MQTT messages of devices.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/events"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/service"
+)
+
+// insertedReadings keeps the readings inserted into it.
+type insertedReadings struct {
+	repository.SensorDataRepository
+	readings []models.SensorData
+}
+
+func (r *insertedReadings) Insert(_ context.Context, d *models.SensorData) error {
+	r.readings = append(r.readings, *d)
+	return nil
+}
+
+// onlineDevice is device dev-1, online.
+type onlineDevice struct {
+	repository.DeviceRepository
+}
+
+func (onlineDevice) GetByID(_ context.Context, id string) (*models.Device, error) {
+	if id != "dev-1" {
+		return nil, repository.ErrNotFound
+	}
+	return &models.Device{ID: id, Status: models.DeviceOnline}, nil
+}
+
+func (onlineDevice) Touch(context.Context, string, time.Time) error { return nil }
+
+func TestHandlerSensorSource(t *testing.T) {
+	topics, err := NewTopics(defaultTopicConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"v1", fmt.Sprintf(`{"timestamp": %q, "sensors": {"pm25": {"value": 12, "unit": "µg/m³"}, "co2": {"value": 400, "unit": "ppm"},
+			"co": {"value": 0.5, "unit": "ppm"}, "temperature": {"value": 21, "unit": "°C"}, "humidity": {"value": 40, "unit": "%%"}}}`,
+			at.Format(time.RFC3339))},
+		{"v1 claiming another source", fmt.Sprintf(`{"timestamp": %q, "source": "http", "sensors": {"pm25": {"value": 12, "unit": "µg/m³"},
+			"co2": {"value": 400, "unit": "ppm"}, "co": {"value": 0.5, "unit": "ppm"}, "temperature": {"value": 21, "unit": "°C"},
+			"humidity": {"value": 40, "unit": "%%"}}}`, at.Format(time.RFC3339))},
+		{"v2", fmt.Sprintf(`{"version": 2, "ts": %d, "readings": {"pm25": 12, "co2": 400, "co": 0.5, "temperature": 21, "humidity": 40}}`,
+			at.Unix())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &insertedReadings{}
+			sensors := service.NewSensorService(repo, nil, onlineDevice{}, nil, nil, nil, nil, cache.NewLatestCache(), events.NewHub())
+			h := NewHandler(topics, sensors, nil, nil, nil)
+			msg := Message{Topic: "airsense/acme/devices/dev-1/sensors", Payload: []byte(tt.payload)}
+			if err := h.Route(context.Background(), msg); err != nil {
+				t.Fatalf("Route() = %v", err)
+			}
+			if len(repo.readings) != 1 {
+				t.Fatalf("stored %d readings, want 1", len(repo.readings))
+			}
+			if got := repo.readings[0]; got.Source != models.SourceMQTT || got.DeviceID != "dev-1" {
+				t.Errorf("stored source %q of device %q, want mqtt of dev-1", got.Source, got.DeviceID)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: pool.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the bounded worker pool that processes MQTT messages in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/metrics"
+)
+
+// Message is the part of an inbound MQTT message the workers need.
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+type ProcessFunc func(ctx context.Context, msg Message) error
+
+// WorkerPool moves message processing off the paho callback goroutine so a
+// slow database write never stalls the network loop.
+type WorkerPool struct {
+	queue   chan Message
+	workers int
+	policy  string
+	process ProcessFunc
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func NewWorkerPool(cfg config.MQTTConfig, process ProcessFunc) *WorkerPool {
+	workers := cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	size := cfg.QueueSize
+	if size < 1 {
+		size = 1
+	}
+	return &WorkerPool{
+		queue:   make(chan Message, size),
+		workers: workers,
+		policy:  cfg.OverflowPolicy,
+		process: process,
+	}
+}
+
+func (p *WorkerPool) Start() {
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.run()
+	}
+}
+
+func (p *WorkerPool) run() {
+	defer p.wg.Done()
+	for msg := range p.queue {
+		metrics.MQTTQueueDepth.Set(int64(len(p.queue)))
+		if err := p.process(context.Background(), msg); err != nil {
+			metrics.MQTTMessagesFailed.Add(1)
+			log.Printf("mqtt: process %s: %v", msg.Topic, err)
+		}
+	}
+}
+
+// Handler adapts the pool to a paho subscription callback.
+func (p *WorkerPool) Handler() paho.MessageHandler {
+	return func(_ paho.Client, m paho.Message) {
+		p.Submit(Message{Topic: m.Topic(), Payload: m.Payload()})
+	}
+}
+
+// Submit enqueues a message. When the queue is full it either blocks or
+// evicts the oldest queued message, depending on the overflow policy.
+func (p *WorkerPool) Submit(msg Message) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		metrics.MQTTMessagesDropped.Add(1)
+		return
+	}
+
+	if p.policy == config.OverflowDropOldest {
+		for {
+			select {
+			case p.queue <- msg:
+				metrics.MQTTQueueDepth.Set(int64(len(p.queue)))
+				return
+			default:
+			}
+			select {
+			case old := <-p.queue:
+				metrics.MQTTMessagesDropped.Add(1)
+				log.Printf("mqtt: queue full, dropped oldest message on %s", old.Topic)
+			default:
+			}
+		}
+	}
+
+	p.queue <- msg
+	metrics.MQTTQueueDepth.Set(int64(len(p.queue)))
+}
+
+// Shutdown stops accepting messages and waits for the workers to drain
+// everything already queued, or for ctx to expire.
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
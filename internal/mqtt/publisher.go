@@ -0,0 +1,80 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: publisher.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MQTT publisher that delivers commands to devices in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"encoding/json"
+	"time"
+
+	"airsense-be.com/internal/models"
+)
+
+// commandQoS is QoS 1 per documents/topic-tree.txt, so the broker keeps
+// delivering a command until the device has it.
+const commandQoS = 1
+
+// CommandPayload is the JSON a device receives on its commands topic.
+type CommandPayload struct {
+	CommandID string         `json:"commandID"`
+	Action    string         `json:"action"`
+	Params    map[string]any `json:"params,omitempty"`
+	Priority  string         `json:"priority,omitempty"`
+	ExpiresAt *time.Time     `json:"expires_at,omitempty"`
+	// Attempt counts from 1; a republished command keeps its commandID, so
+	// devices must deduplicate on it.
+	Attempt int `json:"attempt,omitempty"`
+	// Cancelled tells the device not to execute commandID, should it
+	// still be queued or arrive later.
+	Cancelled bool `json:"cancelled,omitempty"`
+}
+
+// messagePublisher is the part of MQTTClient the Publisher sends with.
+type messagePublisher interface {
+	Publish(topic string, qos byte, payload []byte) error
+}
+
+type Publisher struct {
+	client messagePublisher
+	topics Topics
+}
+
+func NewPublisher(client *MQTTClient, topics Topics) *Publisher {
+	return &Publisher{client: client, topics: topics}
+}
+
+// PublishCommand sends cmd to its device. Devices must ignore commands
+// received after expires_at.
+func (p *Publisher) PublishCommand(cmd *models.Command) error {
+	payload, err := json.Marshal(CommandPayload{
+		CommandID: cmd.CommandID,
+		Action:    cmd.Action,
+		Params:    cmd.Params,
+		Priority:  string(cmd.Priority),
+		ExpiresAt: cmd.ExpiresAt,
+		Attempt:   cmd.Attempts,
+	})
+	if err != nil {
+		return err
+	}
+	return p.client.Publish(p.topics.Command(cmd.DeviceID), commandQoS, payload)
+}
+
+// PublishCancel tells the device of cmd to drop it. It is sent on the same
+// topic as the command, so a device with a persistent session receives it
+// after the command.
+func (p *Publisher) PublishCancel(cmd *models.Command) error {
+	payload, err := json.Marshal(CommandPayload{CommandID: cmd.CommandID, Action: cmd.Action, Cancelled: true})
+	if err != nil {
+		return err
+	}
+	return p.client.Publish(p.topics.Command(cmd.DeviceID), commandQoS, payload)
+}
@@ -0,0 +1,114 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: publisher_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the topic and payload of published commands.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+)
+
+type sentMessage struct {
+	topic   string
+	qos     byte
+	payload []byte
+}
+
+// recordingClient records what is published, or fails with err.
+type recordingClient struct {
+	err  error
+	sent []sentMessage
+}
+
+func (c *recordingClient) Publish(topic string, qos byte, payload []byte) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.sent = append(c.sent, sentMessage{topic, qos, payload})
+	return nil
+}
+
+func TestPublishCommand(t *testing.T) {
+	topics, err := NewTopics(defaultTopicConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		cmd     models.Command
+		cancel  bool
+		want    map[string]any
+		wantErr error
+	}{
+		{
+			name: "full command",
+			cmd: models.Command{CommandID: "c1", DeviceID: "dev-1", Action: "set_interval",
+				Params: map[string]any{"interval": 60}, Priority: models.PriorityHigh, ExpiresAt: &expires, Attempts: 2},
+			want: map[string]any{"commandID": "c1", "action": "set_interval", "params": map[string]any{"interval": float64(60)},
+				"priority": "high", "expires_at": "2026-10-15T12:00:00Z", "attempt": float64(2)},
+		},
+		{
+			name: "bare command",
+			cmd:  models.Command{CommandID: "c2", DeviceID: "dev-2", Action: "reboot"},
+			want: map[string]any{"commandID": "c2", "action": "reboot"},
+		},
+		{
+			name:   "cancellation",
+			cmd:    models.Command{CommandID: "c3", DeviceID: "dev-1", Action: "reboot", Params: map[string]any{"delay": 5}},
+			cancel: true,
+			want:   map[string]any{"commandID": "c3", "action": "reboot", "cancelled": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &recordingClient{}
+			p := &Publisher{client: client, topics: topics}
+			publish := p.PublishCommand
+			if tt.cancel {
+				publish = p.PublishCancel
+			}
+			if err := publish(&tt.cmd); err != nil {
+				t.Fatal(err)
+			}
+			if len(client.sent) != 1 {
+				t.Fatalf("published %d messages, want 1", len(client.sent))
+			}
+			msg := client.sent[0]
+			if want := "airsense/acme/devices/" + tt.cmd.DeviceID + "/commands"; msg.topic != want {
+				t.Errorf("topic = %q, want %q", msg.topic, want)
+			}
+			if msg.qos != 1 {
+				t.Errorf("qos = %d, want 1", msg.qos)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(msg.payload, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("payload = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("publish fails", func(t *testing.T) {
+		broken := errors.New("not connected")
+		p := &Publisher{client: &recordingClient{err: broken}, topics: topics}
+		if err := p.PublishCommand(&models.Command{CommandID: "c1", DeviceID: "dev-1"}); !errors.Is(err, broken) {
+			t.Errorf("err = %v, want %v", err, broken)
+		}
+	})
+}
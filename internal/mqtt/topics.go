@@ -0,0 +1,140 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: topics.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MQTT topic tree of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"fmt"
+	"strings"
+
+	"airsense-be.com/internal/config"
+)
+
+// Topic kinds, the last segment(s) of a device topic. See
+// documents/topic-tree.txt.
+const (
+	KindSensors  = "sensors"
+	KindStatus   = "status"
+	KindCommands = "commands"
+	KindAck      = "commands/ack"
+)
+
+// Placeholders of the topic templates in config.MQTTConfig.
+const (
+	TenantPlaceholder = "{tenantID}"
+	DevicePlaceholder = "{deviceID}"
+)
+
+// topicTemplate is a template split around its device placeholder.
+type topicTemplate struct {
+	kind          string
+	before, after string
+}
+
+func (t topicTemplate) build(deviceID string) string {
+	return t.before + deviceID + t.after
+}
+
+// match returns the device ID of topic if it fits the template.
+func (t topicTemplate) match(topic string) (string, bool) {
+	rest, ok := strings.CutPrefix(topic, t.before)
+	if !ok {
+		return "", false
+	}
+	deviceID, ok := strings.CutSuffix(rest, t.after)
+	if !ok || deviceID == "" || strings.ContainsAny(deviceID, "/+#") {
+		return "", false
+	}
+	return deviceID, true
+}
+
+// Topics builds and parses the topics of one tenant from the templates of
+// the MQTT configuration, by default
+// airsense/{tenantID}/devices/{deviceID}/{kind}.
+type Topics struct {
+	sensors, status, commands, ack topicTemplate
+}
+
+// NewTopics resolves the topic templates of cfg for its tenant. Every
+// template must contain {deviceID} exactly once as a whole topic level:
+// the backend subscribes with a + wildcard in its place and takes the
+// device ID from it.
+func NewTopics(cfg config.MQTTConfig) (Topics, error) {
+	var t Topics
+	templates := []struct {
+		dst  *topicTemplate
+		kind string
+		tmpl string
+	}{
+		{&t.sensors, KindSensors, cfg.TelemetryTopic},
+		{&t.status, KindStatus, cfg.StatusTopic},
+		{&t.commands, KindCommands, cfg.CommandTopic},
+		{&t.ack, KindAck, cfg.AckTopic},
+	}
+	seen := make(map[string]string, len(templates))
+	for _, tt := range templates {
+		tmpl := strings.ReplaceAll(tt.tmpl, TenantPlaceholder, cfg.TenantID)
+		parsed, err := parseTemplate(tt.kind, tmpl)
+		if err != nil {
+			return Topics{}, err
+		}
+		if other, ok := seen[tmpl]; ok {
+			return Topics{}, fmt.Errorf("%s and %s topics are both %q", other, tt.kind, tmpl)
+		}
+		seen[tmpl] = tt.kind
+		*tt.dst = parsed
+	}
+	return t, nil
+}
+
+func parseTemplate(kind, tmpl string) (topicTemplate, error) {
+	if strings.Count(tmpl, DevicePlaceholder) != 1 {
+		return topicTemplate{}, fmt.Errorf("%s topic %q must contain %s exactly once", kind, tmpl, DevicePlaceholder)
+	}
+	if strings.ContainsAny(tmpl, "+#") {
+		return topicTemplate{}, fmt.Errorf("%s topic %q must not contain wildcards", kind, tmpl)
+	}
+	before, after, _ := strings.Cut(tmpl, DevicePlaceholder)
+	if (before != "" && !strings.HasSuffix(before, "/")) || (after != "" && !strings.HasPrefix(after, "/")) {
+		return topicTemplate{}, fmt.Errorf("%s topic %q: %s must be a whole topic level", kind, tmpl, DevicePlaceholder)
+	}
+	return topicTemplate{kind: kind, before: before, after: after}, nil
+}
+
+// Sensors matches the sensor readings of every device of the tenant.
+func (t Topics) Sensors() string {
+	return t.sensors.build("+")
+}
+
+// Status matches the status reports of every device of the tenant.
+func (t Topics) Status() string {
+	return t.status.build("+")
+}
+
+// Acks matches the command acknowledgements of every device of the tenant.
+func (t Topics) Acks() string {
+	return t.ack.build("+")
+}
+
+// Command is where the backend publishes commands for deviceID.
+func (t Topics) Command(deviceID string) string {
+	return t.commands.build(deviceID)
+}
+
+// Parse extracts the device ID and kind from a topic of the tenant.
+func (t Topics) Parse(topic string) (deviceID, kind string, err error) {
+	for _, tt := range []topicTemplate{t.sensors, t.status, t.commands, t.ack} {
+		if deviceID, ok := tt.match(topic); ok {
+			return deviceID, tt.kind, nil
+		}
+	}
+	return "", "", fmt.Errorf("malformed topic %q", topic)
+}
@@ -0,0 +1,126 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: topics_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the MQTT topic templates.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mqtt
+
+import (
+	"context"
+	"testing"
+
+	"airsense-be.com/internal/config"
+)
+
+func defaultTopicConfig() config.MQTTConfig {
+	return config.MQTTConfig{
+		TenantID:       "acme",
+		TelemetryTopic: "airsense/{tenantID}/devices/{deviceID}/sensors",
+		StatusTopic:    "airsense/{tenantID}/devices/{deviceID}/status",
+		CommandTopic:   "airsense/{tenantID}/devices/{deviceID}/commands",
+		AckTopic:       "airsense/{tenantID}/devices/{deviceID}/commands/ack",
+	}
+}
+
+func TestTopicsSubscriptions(t *testing.T) {
+	topics, err := NewTopics(defaultTopicConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"sensors", topics.Sensors(), "airsense/acme/devices/+/sensors"},
+		{"status", topics.Status(), "airsense/acme/devices/+/status"},
+		{"acks", topics.Acks(), "airsense/acme/devices/+/commands/ack"},
+		{"command", topics.Command("dev-1"), "airsense/acme/devices/dev-1/commands"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopicsParse(t *testing.T) {
+	topics, err := NewTopics(defaultTopicConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		topic      string
+		wantDevice string
+		wantKind   string
+		wantErr    bool
+	}{
+		{"sensors", "airsense/acme/devices/dev-1/sensors", "dev-1", KindSensors, false},
+		{"status", "airsense/acme/devices/dev-1/status", "dev-1", KindStatus, false},
+		{"ack", "airsense/acme/devices/dev-1/commands/ack", "dev-1", KindAck, false},
+		{"commands", "airsense/acme/devices/dev-1/commands", "dev-1", KindCommands, false},
+		{"object ID device", "airsense/acme/devices/6700f1c2a9b3e4d5f6a7b8c9/sensors", "6700f1c2a9b3e4d5f6a7b8c9", KindSensors, false},
+		{"other tenant", "airsense/other/devices/dev-1/sensors", "", "", true},
+		{"no device", "airsense/acme/devices//sensors", "", "", true},
+		{"device over two levels", "airsense/acme/devices/a/b/sensors", "", "", true},
+		{"wildcard device", "airsense/acme/devices/+/sensors", "", "", true},
+		{"unknown kind", "airsense/acme/devices/dev-1/config", "", "", true},
+		{"truncated", "airsense/acme/devices/dev-1", "", "", true},
+		{"empty", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceID, kind, err := topics.Parse(tt.topic)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.topic, err, tt.wantErr)
+			}
+			if deviceID != tt.wantDevice || kind != tt.wantKind {
+				t.Errorf("Parse(%q) = %q, %q, want %q, %q", tt.topic, deviceID, kind, tt.wantDevice, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestNewTopicsRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*config.MQTTConfig)
+	}{
+		{"no device placeholder", func(c *config.MQTTConfig) { c.StatusTopic = "airsense/{tenantID}/status" }},
+		{"two device placeholders", func(c *config.MQTTConfig) { c.StatusTopic = "{deviceID}/{deviceID}/status" }},
+		{"wildcard", func(c *config.MQTTConfig) { c.StatusTopic = "airsense/+/{deviceID}/status" }},
+		{"placeholder inside a level", func(c *config.MQTTConfig) { c.StatusTopic = "airsense/dev-{deviceID}/status" }},
+		{"two kinds on one topic", func(c *config.MQTTConfig) { c.StatusTopic = c.TelemetryTopic }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultTopicConfig()
+			tt.edit(&cfg)
+			if _, err := NewTopics(cfg); err == nil {
+				t.Error("NewTopics() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestHandlerRouteMalformedTopic(t *testing.T) {
+	topics, err := NewTopics(defaultTopicConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(topics, nil, nil, nil, nil)
+	for _, topic := range []string{"airsense/acme/devices/dev-1/config", "airsense/other/devices/dev-1/sensors", "garbage"} {
+		if err := h.Route(context.Background(), Message{Topic: topic, Payload: []byte(`{}`)}); err == nil {
+			t.Errorf("Route(%q) error = nil, want an error", topic)
+		}
+	}
+}
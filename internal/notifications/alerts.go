@@ -0,0 +1,121 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: alerts.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the alert sink that emails users according to their notification preferences.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+
+	"airsense-be.com/internal/alert"
+	"airsense-be.com/internal/repository"
+)
+
+var alertBody = template.Must(template.New("alert").Parse(`{{.Severity}} alert on {{.DeviceName}}
+
+Sensor:    {{.Field}}
+Value:     {{printf "%.2f" .Value}} {{.Unit}}
+Threshold: {{printf "%.2f" .Threshold}} {{.Unit}}
+Time:      {{.At.Format "2006-01-02 15:04:05 MST"}}
+
+View the device: {{.Link}}
+`))
+
+// AlertEmailer is an alert.Sink that emails the device owner when the
+// alert severity is one they opted in to.
+type AlertEmailer struct {
+	devices      repository.DeviceRepository
+	users        repository.UserRepository
+	prefs        repository.NotificationPreferenceRepository
+	sender       *EmailSender
+	dashboardURL string
+}
+
+func NewAlertEmailer(devices repository.DeviceRepository, users repository.UserRepository,
+	prefs repository.NotificationPreferenceRepository, sender *EmailSender, dashboardURL string) *AlertEmailer {
+	return &AlertEmailer{
+		devices:      devices,
+		users:        users,
+		prefs:        prefs,
+		sender:       sender,
+		dashboardURL: strings.TrimRight(dashboardURL, "/"),
+	}
+}
+
+func (n *AlertEmailer) Notify(ctx context.Context, e alert.Event) {
+	email, err := n.build(ctx, e)
+	if err != nil {
+		log.Printf("notifications: alert %s/%s: %v", e.DeviceID, e.Field, err)
+		return
+	}
+	if email != nil {
+		n.sender.Enqueue(*email)
+	}
+}
+
+// build returns nil if the owner has not opted in to this alert.
+func (n *AlertEmailer) build(ctx context.Context, e alert.Event) (*Email, error) {
+	device, err := n.devices.GetByID(ctx, e.DeviceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pref, err := n.prefs.Get(ctx, device.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !pref.EmailEnabled || !pref.Wants(string(e.Severity)) {
+		return nil, nil
+	}
+
+	to := pref.EmailAddress
+	if to == "" {
+		u, err := n.users.GetByID(ctx, device.UserID)
+		if err != nil {
+			return nil, err
+		}
+		to = u.Email
+	}
+
+	name := device.Name
+	if name == "" {
+		name = device.ID
+	}
+	var body strings.Builder
+	err = alertBody.Execute(&body, struct {
+		alert.Event
+		Severity   string
+		DeviceName string
+		Link       string
+	}{
+		Event:      e,
+		Severity:   strings.ToUpper(string(e.Severity)),
+		DeviceName: name,
+		Link:       n.dashboardURL + "/devices/" + device.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Email{
+		To:      []string{to},
+		Subject: fmt.Sprintf("[AirSense][%s] %s: %s is %.2f", strings.ToUpper(string(e.Severity)), name, e.Field, e.Value),
+		Body:    body.String(),
+	}, nil
+}
@@ -0,0 +1,143 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: email.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the SMTP email sender used for alert notifications in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/metrics"
+)
+
+const emailQueueSize = 100
+
+// ErrHeaderInjection refuses emails whose recipients or subject hold a
+// line break, which would start a header of their own.
+var ErrHeaderInjection = errors.New("email header contains a line break")
+
+type Email struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// SendMailFunc has the signature of smtp.SendMail so it can be swapped out.
+type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// EmailSender delivers emails from a queue on a background goroutine so
+// that alert evaluation never waits on the SMTP server.
+type EmailSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+	send SendMailFunc
+
+	queue chan Email
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+func NewEmailSender(cfg config.SMTPConfig) *EmailSender {
+	return NewEmailSenderWith(cfg, smtp.SendMail)
+}
+
+// NewEmailSenderWith uses send instead of smtp.SendMail.
+func NewEmailSenderWith(cfg config.SMTPConfig, send SendMailFunc) *EmailSender {
+	s := &EmailSender{
+		addr:  net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		from:  cfg.From,
+		send:  send,
+		queue: make(chan Email, emailQueueSize),
+	}
+	if cfg.Username != "" {
+		s.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return s
+}
+
+func (s *EmailSender) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for e := range s.queue {
+			if err := s.Send(e); err != nil {
+				metrics.EmailsFailed.Add(1)
+				log.Printf("notifications: send email to %v: %v", e.To, err)
+			}
+		}
+	}()
+}
+
+// Enqueue queues e for delivery. It never blocks; when the queue is full
+// the email is dropped and false is returned.
+func (s *EmailSender) Enqueue(e Email) bool {
+	select {
+	case s.queue <- e:
+		return true
+	default:
+		metrics.EmailsDropped.Add(1)
+		log.Printf("notifications: email queue full, dropping %q", e.Subject)
+		return false
+	}
+}
+
+// Send delivers e synchronously.
+func (s *EmailSender) Send(e Email) error {
+	msg, err := s.message(e)
+	if err != nil {
+		return err
+	}
+	return s.send(s.addr, s.auth, s.from, e.To, msg)
+}
+
+// Shutdown stops accepting emails and waits for the queue to drain.
+func (s *EmailSender) Shutdown(ctx context.Context) error {
+	s.once.Do(func() { close(s.queue) })
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// message formats e. The subject, which may hold device names, is encoded
+// as RFC 2047 when not plain ASCII.
+func (s *EmailSender) message(e Email) ([]byte, error) {
+	for _, v := range append([]string{s.from, e.Subject}, e.To...) {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, ErrHeaderInjection
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", e.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(e.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,245 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: email_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the SMTP email sender and the alert emails.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package notifications
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/alert"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// sentMail records the calls of a SendMailFunc.
+type sentMail struct {
+	to  []string
+	msg string
+}
+
+func recordingSender(sent *[]sentMail) SendMailFunc {
+	return func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		*sent = append(*sent, sentMail{to: to, msg: string(msg)})
+		return nil
+	}
+}
+
+// header returns the value of header name in msg.
+func header(msg, name string) string {
+	head, _, _ := strings.Cut(msg, "\r\n\r\n")
+	for _, line := range strings.Split(head, "\r\n") {
+		if v, ok := strings.CutPrefix(line, name+": "); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func TestEmailSenderSend(t *testing.T) {
+	tests := []struct {
+		name        string
+		email       Email
+		wantSubject string
+		wantErr     error
+	}{
+		{
+			name:        "ascii subject",
+			email:       Email{To: []string{"a@example.com"}, Subject: "[AirSense] Reset your password", Body: "hi"},
+			wantSubject: "[AirSense] Reset your password",
+		},
+		{
+			name:        "utf-8 subject",
+			email:       Email{To: []string{"a@example.com"}, Subject: "Phòng khách", Body: "hi"},
+			wantSubject: "=?utf-8?q?Ph=C3=B2ng_kh=C3=A1ch?=",
+		},
+		{
+			name:    "line break in subject",
+			email:   Email{To: []string{"a@example.com"}, Subject: "Kitchen\r\nBcc: victim@example.com", Body: "hi"},
+			wantErr: ErrHeaderInjection,
+		},
+		{
+			name:    "bare newline in subject",
+			email:   Email{To: []string{"a@example.com"}, Subject: "Kitchen\nBcc: victim@example.com", Body: "hi"},
+			wantErr: ErrHeaderInjection,
+		},
+		{
+			name:    "line break in recipient",
+			email:   Email{To: []string{"a@example.com\r\nBcc: victim@example.com"}, Subject: "hi", Body: "hi"},
+			wantErr: ErrHeaderInjection,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []sentMail
+			s := NewEmailSenderWith(config.SMTPConfig{Host: "localhost", Port: 25, From: "alerts@airsense.example"}, recordingSender(&sent))
+			err := s.Send(tt.email)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Send() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(sent) != 0 {
+					t.Fatalf("Send() sent %d emails, want none", len(sent))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if len(sent) != 1 {
+				t.Fatalf("Send() sent %d emails, want 1", len(sent))
+			}
+			if got := header(sent[0].msg, "Subject"); got != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", got, tt.wantSubject)
+			}
+		})
+	}
+}
+
+func TestEmailSenderBodyLineEndings(t *testing.T) {
+	var sent []sentMail
+	s := NewEmailSenderWith(config.SMTPConfig{Host: "localhost", Port: 25}, recordingSender(&sent))
+	if err := s.Send(Email{To: []string{"a@example.com"}, Subject: "s", Body: "one\ntwo\r\nthree"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	_, body, _ := strings.Cut(sent[0].msg, "\r\n\r\n")
+	if body != "one\r\ntwo\r\nthree" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+type fakeDevices struct {
+	repository.DeviceRepository
+	devices map[string]*models.Device
+}
+
+func (f *fakeDevices) GetByID(_ context.Context, id string) (*models.Device, error) {
+	if d, ok := f.devices[id]; ok {
+		return d, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+type fakeUsers struct {
+	repository.UserRepository
+	users map[string]*models.User
+}
+
+func (f *fakeUsers) GetByID(_ context.Context, id string) (*models.User, error) {
+	if u, ok := f.users[id]; ok {
+		return u, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+type fakePrefs struct {
+	repository.NotificationPreferenceRepository
+	prefs map[string]*models.NotificationPreference
+}
+
+func (f *fakePrefs) Get(_ context.Context, userID string) (*models.NotificationPreference, error) {
+	if p, ok := f.prefs[userID]; ok {
+		return p, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func TestAlertEmailer(t *testing.T) {
+	devices := &fakeDevices{devices: map[string]*models.Device{
+		"d1": {ID: "d1", UserID: "u1", Name: "Kitchen"},
+		"d2": {ID: "d2", UserID: "u2", Name: "Garage"},
+		"d3": {ID: "d3", UserID: "u3", Name: "Attic"},
+	}}
+	users := &fakeUsers{users: map[string]*models.User{
+		"u1": {ID: "u1", Email: "owner@example.com"},
+		"u2": {ID: "u2", Email: "other@example.com"},
+	}}
+	prefs := &fakePrefs{prefs: map[string]*models.NotificationPreference{
+		"u1": {UserID: "u1", EmailEnabled: true, AlertSeverities: []string{"warning", "critical"}},
+		"u2": {UserID: "u2", EmailEnabled: true, EmailAddress: "ops@example.com", AlertSeverities: []string{"critical"}},
+		"u3": {UserID: "u3", EmailEnabled: false, AlertSeverities: []string{"warning", "critical"}},
+	}}
+	at := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		event       alert.Event
+		wantTo      string
+		wantSubject string
+	}{
+		{
+			name:        "warning to account address",
+			event:       alert.Event{DeviceID: "d1", Field: "pm25", Value: 40, Threshold: 35, Severity: alert.SeverityWarning, At: at},
+			wantTo:      "owner@example.com",
+			wantSubject: "[AirSense][WARNING] Kitchen: pm25 is 40.00",
+		},
+		{
+			name:        "critical to account address",
+			event:       alert.Event{DeviceID: "d1", Field: "co2", Value: 2100, Threshold: 2000, Severity: alert.SeverityCritical, At: at},
+			wantTo:      "owner@example.com",
+			wantSubject: "[AirSense][CRITICAL] Kitchen: co2 is 2100.00",
+		},
+		{
+			name:        "critical to preferred address",
+			event:       alert.Event{DeviceID: "d2", Field: "pm25", Value: 80, Threshold: 75, Severity: alert.SeverityCritical, At: at},
+			wantTo:      "ops@example.com",
+			wantSubject: "[AirSense][CRITICAL] Garage: pm25 is 80.00",
+		},
+		{
+			name:  "severity not opted in",
+			event: alert.Event{DeviceID: "d2", Field: "pm25", Value: 40, Threshold: 35, Severity: alert.SeverityWarning, At: at},
+		},
+		{
+			name:  "email disabled",
+			event: alert.Event{DeviceID: "d3", Field: "pm25", Value: 80, Threshold: 75, Severity: alert.SeverityCritical, At: at},
+		},
+		{
+			name:  "unknown device",
+			event: alert.Event{DeviceID: "d9", Field: "pm25", Value: 80, Threshold: 75, Severity: alert.SeverityCritical, At: at},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []sentMail
+			sender := NewEmailSenderWith(config.SMTPConfig{Host: "localhost", Port: 25}, recordingSender(&sent))
+			sender.Start()
+			n := NewAlertEmailer(devices, users, prefs, sender, "https://dash.example/")
+			n.Notify(context.Background(), tt.event)
+			if err := sender.Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantTo == "" {
+				if len(sent) != 0 {
+					t.Fatalf("sent %d emails, want none", len(sent))
+				}
+				return
+			}
+			if len(sent) != 1 {
+				t.Fatalf("sent %d emails, want 1", len(sent))
+			}
+			if len(sent[0].to) != 1 || sent[0].to[0] != tt.wantTo {
+				t.Errorf("recipients = %v, want [%s]", sent[0].to, tt.wantTo)
+			}
+			if got := header(sent[0].msg, "Subject"); got != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", got, tt.wantSubject)
+			}
+			if !strings.Contains(sent[0].msg, "https://dash.example/devices/"+tt.event.DeviceID) {
+				t.Errorf("body lacks the device link:\n%s", sent[0].msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: fcm.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the Firebase Cloud Messaging sender used for push notifications in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/repository"
+)
+
+const (
+	pushQueueSize = 100
+	fcmTimeout    = 10 * time.Second
+	// maxFCMResponseBytes bounds the responses read from FCM.
+	maxFCMResponseBytes = 64 << 10
+)
+
+// ErrInvalidFCMToken is returned by Send when FCM no longer knows the
+// token, e.g. because the app was uninstalled.
+var ErrInvalidFCMToken = errors.New("fcm token is not registered")
+
+// PushMessage is an FCM message to one app installation. Without
+// Notification it is a data message, handled by the app itself; with it,
+// the system shows the notification and the app gets Data when opened.
+type PushMessage struct {
+	To string
+	// Priority is "high" to wake the device, or "normal".
+	Priority     string
+	Notification *PushNotification
+	Data         map[string]string
+}
+
+type PushNotification struct {
+	Title string
+	Body  string
+	Sound string
+}
+
+// fcmRequest is the body of a send request of the HTTP v1 API, see
+// https://firebase.google.com/docs/reference/fcm/rest/v1/projects.messages.
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification *fcmNotification  `json:"notification,omitempty"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      fcmAndroid        `json:"android"`
+	APNS         fcmAPNS           `json:"apns"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmAndroid struct {
+	Priority     string                  `json:"priority"`
+	Notification *fcmAndroidNotification `json:"notification,omitempty"`
+}
+
+type fcmAndroidNotification struct {
+	Sound string `json:"sound,omitempty"`
+}
+
+type fcmAPNS struct {
+	Headers map[string]string `json:"headers"`
+	Payload map[string]any    `json:"payload"`
+}
+
+// newFCMRequest translates m to the v1 API, which sets priority and sound
+// per platform.
+func newFCMRequest(m PushMessage) fcmRequest {
+	msg := fcmMessage{Token: m.To, Data: m.Data, Android: fcmAndroid{Priority: "NORMAL"}}
+	aps := map[string]any{}
+	// APNs only takes priority 10 for alerts; data messages wake the app
+	// in the background at 5.
+	apnsPriority := "5"
+	if m.Notification != nil {
+		msg.Notification = &fcmNotification{Title: m.Notification.Title, Body: m.Notification.Body}
+		if m.Notification.Sound != "" {
+			msg.Android.Notification = &fcmAndroidNotification{Sound: m.Notification.Sound}
+			aps["sound"] = m.Notification.Sound
+		}
+		if m.Priority == "high" {
+			apnsPriority = "10"
+		}
+	} else {
+		aps["content-available"] = 1
+	}
+	if m.Priority == "high" {
+		msg.Android.Priority = "HIGH"
+	}
+	msg.APNS = fcmAPNS{Headers: map[string]string{"apns-priority": apnsPriority}, Payload: map[string]any{"aps": aps}}
+	return fcmRequest{Message: msg}
+}
+
+// fcmError is the error response of the v1 API. The FCM error code, e.g.
+// UNREGISTERED, is among the details.
+type fcmError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// FCMSender delivers push messages from a queue on a background goroutine,
+// like EmailSender. Tokens FCM reports as unregistered are deleted.
+type FCMSender struct {
+	endpoint string
+	client   *http.Client
+	auth     *serviceAccountTokens
+	tokens   repository.UserDeviceRepository
+
+	queue chan PushMessage
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// NewFCMSender returns a sender signing in with the service account of
+// cfg.CredentialsFile.
+func NewFCMSender(cfg config.FCMConfig, tokens repository.UserDeviceRepository) (*FCMSender, error) {
+	return NewFCMSenderWith(cfg, tokens, &http.Client{Timeout: fcmTimeout})
+}
+
+// NewFCMSenderWith sends through client instead of a default one.
+func NewFCMSenderWith(cfg config.FCMConfig, tokens repository.UserDeviceRepository, client *http.Client) (*FCMSender, error) {
+	account, err := loadServiceAccount(cfg.CredentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	project := cfg.ProjectID
+	if project == "" {
+		project = account.ProjectID
+	}
+	if project == "" {
+		return nil, errors.New("fcm: FCM_PROJECT_ID is not set and the service account names no project")
+	}
+	auth, err := newServiceAccountTokens(account, client)
+	if err != nil {
+		return nil, err
+	}
+	return &FCMSender{
+		endpoint: strings.TrimRight(cfg.Endpoint, "/") + "/v1/projects/" + url.PathEscape(project) + "/messages:send",
+		client:   client,
+		auth:     auth,
+		tokens:   tokens,
+		queue:    make(chan PushMessage, pushQueueSize),
+	}, nil
+}
+
+func (s *FCMSender) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for m := range s.queue {
+			err := s.Send(context.Background(), m)
+			if errors.Is(err, ErrInvalidFCMToken) {
+				if err := s.tokens.Delete(context.Background(), m.To); err != nil {
+					log.Printf("notifications: delete fcm token: %v", err)
+				}
+				continue
+			}
+			if err != nil {
+				metrics.PushesFailed.Add(1)
+				log.Printf("notifications: send push: %v", err)
+			}
+		}
+	}()
+}
+
+// Enqueue queues m for delivery. It never blocks; when the queue is full
+// the message is dropped and false is returned.
+func (s *FCMSender) Enqueue(m PushMessage) bool {
+	select {
+	case s.queue <- m:
+		return true
+	default:
+		metrics.PushesDropped.Add(1)
+		log.Printf("notifications: push queue full, dropping message")
+		return false
+	}
+}
+
+// Send delivers m synchronously.
+func (s *FCMSender) Send(ctx context.Context, m PushMessage) error {
+	body, err := json.Marshal(newFCMRequest(m))
+	if err != nil {
+		return err
+	}
+	token, err := s.auth.token(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxFCMResponseBytes))
+	var e fcmError
+	if json.Unmarshal(raw, &e) != nil || e.Error.Status == "" {
+		return fmt.Errorf("fcm: %s: %s", resp.Status, bytes.TrimSpace(raw))
+	}
+	for _, d := range e.Error.Details {
+		if d.ErrorCode == "UNREGISTERED" {
+			return ErrInvalidFCMToken
+		}
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The access token was revoked or expired early; sign in again
+		// for the next message.
+		s.auth.reset()
+	}
+	return fmt.Errorf("fcm: %s: %s", e.Error.Status, e.Error.Message)
+}
+
+// Shutdown stops accepting messages and waits for the queue to drain.
+func (s *FCMSender) Shutdown(ctx context.Context) error {
+	s.once.Do(func() { close(s.queue) })
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: fcm_auth.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains signing in to Firebase Cloud Messaging as a Google service account.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package notifications
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// fcmScope is the OAuth scope of sending FCM messages.
+	fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+	// assertionTTL is the lifetime of the JWT traded for an access token;
+	// Google accepts at most an hour.
+	assertionTTL = time.Hour
+	// tokenRefreshMargin renews an access token this long before it
+	// expires, so none expires in flight.
+	tokenRefreshMargin = time.Minute
+)
+
+// serviceAccount is the part of a service account JSON key used to sign
+// in.
+type serviceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+func loadServiceAccount(path string) (*serviceAccount, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fcm: read credentials: %w", err)
+	}
+	var a serviceAccount
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return nil, fmt.Errorf("fcm: decode credentials %s: %w", path, err)
+	}
+	if a.Type != "service_account" || a.ClientEmail == "" || a.PrivateKey == "" || a.TokenURI == "" {
+		return nil, fmt.Errorf("fcm: %s is not a service account key", path)
+	}
+	return &a, nil
+}
+
+// serviceAccountTokens gets OAuth access tokens for a service account with
+// the JWT bearer grant of RFC 7523, signing the assertion with the key of
+// the account, and caches each until shortly before it expires.
+type serviceAccountTokens struct {
+	account *serviceAccount
+	key     *rsa.PrivateKey
+	client  *http.Client
+
+	mu        sync.Mutex
+	access    string
+	expiresAt time.Time
+}
+
+func newServiceAccountTokens(account *serviceAccount, client *http.Client) (*serviceAccountTokens, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("fcm: private key of %s: %w", account.ClientEmail, err)
+	}
+	return &serviceAccountTokens{account: account, key: key, client: client}, nil
+}
+
+// token returns a valid access token, signing in if the cached one is
+// about to expire. Concurrent callers wait for one sign-in.
+func (t *serviceAccountTokens) token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.access != "" && time.Until(t.expiresAt) > tokenRefreshMargin {
+		return t.access, nil
+	}
+	access, ttl, err := t.signIn(ctx)
+	if err != nil {
+		return "", err
+	}
+	t.access, t.expiresAt = access, time.Now().Add(ttl)
+	return access, nil
+}
+
+// reset drops the cached access token.
+func (t *serviceAccountTokens) reset() {
+	t.mu.Lock()
+	t.access = ""
+	t.mu.Unlock()
+}
+
+func (t *serviceAccountTokens) signIn(ctx context.Context) (string, time.Duration, error) {
+	now := time.Now()
+	assertion := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   t.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   t.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(assertionTTL).Unix(),
+	})
+	assertion.Header["kid"] = t.account.PrivateKeyID
+	signed, err := assertion.SignedString(t.key)
+	if err != nil {
+		return "", 0, fmt.Errorf("fcm: sign assertion: %w", err)
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signed},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("fcm: token request: %w", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxFCMResponseBytes)).Decode(&body); err != nil {
+		return "", 0, fmt.Errorf("fcm: decode token response (%s): %w", resp.Status, err)
+	}
+	switch {
+	case body.Error != "":
+		return "", 0, fmt.Errorf("fcm: token request: %s %s", body.Error, body.ErrorDescription)
+	case resp.StatusCode != http.StatusOK:
+		return "", 0, fmt.Errorf("fcm: token request: %s", resp.Status)
+	case body.AccessToken == "" || body.ExpiresIn <= 0:
+		return "", 0, errors.New("fcm: token response without an access token")
+	}
+	return body.AccessToken, time.Duration(body.ExpiresIn) * time.Second, nil
+}
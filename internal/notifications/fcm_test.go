@@ -0,0 +1,322 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: fcm_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the Firebase Cloud Messaging sender.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package notifications
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"airsense-be.com/internal/config"
+)
+
+// fakeFCM is a Google token endpoint and FCM send endpoint for project p1.
+type fakeFCM struct {
+	t      *testing.T
+	key    *rsa.PrivateKey
+	server *httptest.Server
+
+	mu        sync.Mutex
+	signIns   int
+	access    string
+	sendError func(w http.ResponseWriter)
+	sent      []map[string]any
+}
+
+func newFakeFCM(t *testing.T) *fakeFCM {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeFCM{t: t, key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", f.handleToken)
+	mux.HandleFunc("/v1/projects/p1/messages:send", f.handleSend)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeFCM) handleToken(w http.ResponseWriter, r *http.Request) {
+	if got := r.PostFormValue("grant_type"); got != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+		f.t.Errorf("grant_type = %q", got)
+	}
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(r.PostFormValue("assertion"), claims, func(*jwt.Token) (any, error) {
+		return &f.key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithAudience(f.server.URL+"/token"), jwt.WithIssuedAt())
+	if err != nil {
+		f.t.Errorf("assertion: %v", err)
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	if token.Header["kid"] != "k1" || claims["iss"] != "push@p1.iam.gserviceaccount.com" || claims["scope"] != fcmScope {
+		f.t.Errorf("assertion header %v, claims %v", token.Header, claims)
+	}
+	f.mu.Lock()
+	f.signIns++
+	f.access = "access-" + strconv.Itoa(f.signIns)
+	access := f.access
+	f.mu.Unlock()
+	_ = json.NewEncoder(w).Encode(map[string]any{"access_token": access, "expires_in": 3600, "token_type": "Bearer"})
+}
+
+func (f *fakeFCM) handleSend(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if got, want := r.Header.Get("Authorization"), "Bearer "+f.access; got != want {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"code":401,"message":"Request had invalid authentication credentials.","status":"UNAUTHENTICATED"}}`))
+		return
+	}
+	if f.sendError != nil {
+		f.sendError(w)
+		return
+	}
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		f.t.Errorf("decode message: %v", err)
+	}
+	f.sent = append(f.sent, body)
+	_, _ = w.Write([]byte(`{"name":"projects/p1/messages/1"}`))
+}
+
+// credentials writes a service account key of f and returns its path.
+func (f *fakeFCM) credentials(t *testing.T, project string) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(f.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     project,
+		"private_key_id": "k1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "push@p1.iam.gserviceaccount.com",
+		"token_uri":      f.server.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "service-account.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func (f *fakeFCM) sender(t *testing.T) *FCMSender {
+	t.Helper()
+	s, err := NewFCMSenderWith(config.FCMConfig{CredentialsFile: f.credentials(t, "p1"), Endpoint: f.server.URL + "/"},
+		nil, f.server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// jsonValue round-trips v through JSON, so it compares with decoded bodies.
+func jsonValue(t *testing.T, v any) any {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestFCMSenderPayload(t *testing.T) {
+	data := map[string]string{"type": "alert", "device_id": "d1", "aqi_category": "unhealthy"}
+	tests := []struct {
+		name string
+		msg  PushMessage
+		want map[string]any
+	}{
+		{
+			name: "notification",
+			msg: PushMessage{To: "tok-1", Priority: "high", Data: data,
+				Notification: &PushNotification{Title: "Kitchen: CRITICAL alert", Body: "pm25 is 80 µg/m³.", Sound: "default"}},
+			want: map[string]any{"message": map[string]any{
+				"token":        "tok-1",
+				"notification": map[string]any{"title": "Kitchen: CRITICAL alert", "body": "pm25 is 80 µg/m³."},
+				"data":         data,
+				"android":      map[string]any{"priority": "HIGH", "notification": map[string]any{"sound": "default"}},
+				"apns": map[string]any{
+					"headers": map[string]any{"apns-priority": "10"},
+					"payload": map[string]any{"aps": map[string]any{"sound": "default"}},
+				},
+			}},
+		},
+		{
+			name: "data",
+			msg:  PushMessage{To: "tok-2", Priority: "normal", Data: data},
+			want: map[string]any{"message": map[string]any{
+				"token":   "tok-2",
+				"data":    data,
+				"android": map[string]any{"priority": "NORMAL"},
+				"apns": map[string]any{
+					"headers": map[string]any{"apns-priority": "5"},
+					"payload": map[string]any{"aps": map[string]any{"content-available": 1}},
+				},
+			}},
+		},
+		{
+			name: "high priority data",
+			msg:  PushMessage{To: "tok-3", Priority: "high", Data: data},
+			want: map[string]any{"message": map[string]any{
+				"token":   "tok-3",
+				"data":    data,
+				"android": map[string]any{"priority": "HIGH"},
+				"apns": map[string]any{
+					"headers": map[string]any{"apns-priority": "5"},
+					"payload": map[string]any{"aps": map[string]any{"content-available": 1}},
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFCM(t)
+			if err := f.sender(t).Send(context.Background(), tt.msg); err != nil {
+				t.Fatal(err)
+			}
+			if len(f.sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(f.sent))
+			}
+			if got, want := jsonValue(t, f.sent[0]), jsonValue(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("body = %v\nwant %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFCMSenderErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantErr    error
+		wantFailed bool
+	}{
+		{"unregistered", http.StatusNotFound,
+			`{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND",
+			"details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`,
+			ErrInvalidFCMToken, true},
+		{"invalid argument", http.StatusBadRequest,
+			`{"error":{"code":400,"message":"Invalid value at 'message.data'","status":"INVALID_ARGUMENT",
+			"details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"INVALID_ARGUMENT"}]}}`,
+			nil, true},
+		{"unavailable", http.StatusServiceUnavailable, `upstream connect error`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFCM(t)
+			f.sendError = func(w http.ResponseWriter) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}
+			err := f.sender(t).Send(context.Background(), PushMessage{To: "tok-1"})
+			if (err != nil) != tt.wantFailed {
+				t.Fatalf("err = %v, want failure %v", err, tt.wantFailed)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && errors.Is(err, ErrInvalidFCMToken) {
+				t.Errorf("err = %v, must not drop the token", err)
+			}
+		})
+	}
+}
+
+func TestFCMSenderAccessToken(t *testing.T) {
+	f := newFakeFCM(t)
+	s := f.sender(t)
+	ctx := context.Background()
+	for range 3 {
+		if err := s.Send(ctx, PushMessage{To: "tok-1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if f.signIns != 1 {
+		t.Errorf("signed in %d times for 3 messages, want 1", f.signIns)
+	}
+
+	// A revoked access token fails the message and signs in again for
+	// the next one.
+	f.mu.Lock()
+	f.access = "revoked"
+	f.mu.Unlock()
+	if err := s.Send(ctx, PushMessage{To: "tok-1"}); err == nil {
+		t.Error("send with a revoked access token succeeded")
+	}
+	if err := s.Send(ctx, PushMessage{To: "tok-1"}); err != nil {
+		t.Errorf("send after signing in again: %v", err)
+	}
+	if f.signIns != 2 {
+		t.Errorf("signed in %d times, want 2", f.signIns)
+	}
+}
+
+func TestNewFCMSenderConfig(t *testing.T) {
+	f := newFakeFCM(t)
+	dir := t.TempDir()
+	notAccount := filepath.Join(dir, "user.json")
+	if err := os.WriteFile(notAccount, []byte(`{"type":"authorized_user","client_id":"x"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		cfg          config.FCMConfig
+		wantEndpoint string
+		wantErr      bool
+	}{
+		{"project of the account", config.FCMConfig{CredentialsFile: f.credentials(t, "p1"), Endpoint: "https://fcm.example.com"},
+			"https://fcm.example.com/v1/projects/p1/messages:send", false},
+		{"project override", config.FCMConfig{CredentialsFile: f.credentials(t, "p1"), ProjectID: "p2", Endpoint: "https://fcm.example.com"},
+			"https://fcm.example.com/v1/projects/p2/messages:send", false},
+		{"no project", config.FCMConfig{CredentialsFile: f.credentials(t, ""), Endpoint: "https://fcm.example.com"}, "", true},
+		{"missing file", config.FCMConfig{CredentialsFile: filepath.Join(dir, "missing.json")}, "", true},
+		{"not a service account", config.FCMConfig{CredentialsFile: notAccount}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewFCMSender(tt.cfg, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && s.endpoint != tt.wantEndpoint {
+				t.Errorf("endpoint = %q, want %q", s.endpoint, tt.wantEndpoint)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: org_invitation.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the organization invitation emails of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package notifications
+
+import (
+	"log"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var orgInvitationBody = template.Must(template.New("org_invitation").Parse(`You have been invited to join {{.OrgName}} on AirSense as {{.Role}}.
+
+Accept the invitation here before {{.ExpiresAt.Format "2006-01-02 15:04 MST"}}, signed in with this email address:
+{{.Link}}
+
+If you do not want to join, ignore this email.
+`))
+
+// OrgInvitationMailer emails invitation links pointing at the dashboard's
+// /org-invitations page, which passes the token on to the API.
+type OrgInvitationMailer struct {
+	sender       *EmailSender
+	dashboardURL string
+}
+
+func NewOrgInvitationMailer(sender *EmailSender, dashboardURL string) *OrgInvitationMailer {
+	return &OrgInvitationMailer{sender: sender, dashboardURL: strings.TrimRight(dashboardURL, "/")}
+}
+
+func (m *OrgInvitationMailer) SendOrgInvitation(to, orgName, role, token string, expiresAt time.Time) {
+	var body strings.Builder
+	err := orgInvitationBody.Execute(&body, struct {
+		OrgName   string
+		Role      string
+		ExpiresAt time.Time
+		Link      string
+	}{
+		OrgName:   orgName,
+		Role:      role,
+		ExpiresAt: expiresAt.UTC(),
+		Link:      m.dashboardURL + "/org-invitations?token=" + url.QueryEscape(token),
+	})
+	if err != nil {
+		log.Printf("notifications: build organization invitation email: %v", err)
+		return
+	}
+	m.sender.Enqueue(Email{To: []string{to}, Subject: "[AirSense] Invitation to " + orgName, Body: body.String()})
+}
@@ -0,0 +1,53 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: password_changed.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the emails telling users their password was changed.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package notifications
+
+import (
+	"log"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var passwordChangedBody = template.Must(template.New("password_changed").Parse(`The password of your AirSense account was changed at {{.At.Format "2006-01-02 15:04 MST"}}.
+Every other session was signed out.
+
+If it was not you, reset your password from the sign-in page at once:
+{{.Link}}
+`))
+
+// PasswordChangedMailer tells users their password was changed, pointing
+// at the dashboard in case it was not them.
+type PasswordChangedMailer struct {
+	sender       *EmailSender
+	dashboardURL string
+}
+
+func NewPasswordChangedMailer(sender *EmailSender, dashboardURL string) *PasswordChangedMailer {
+	return &PasswordChangedMailer{sender: sender, dashboardURL: strings.TrimRight(dashboardURL, "/")}
+}
+
+func (m *PasswordChangedMailer) SendPasswordChanged(to string, at time.Time) {
+	var body strings.Builder
+	err := passwordChangedBody.Execute(&body, struct {
+		At   time.Time
+		Link string
+	}{
+		At:   at.UTC(),
+		Link: m.dashboardURL,
+	})
+	if err != nil {
+		log.Printf("notifications: build password changed email: %v", err)
+		return
+	}
+	m.sender.Enqueue(Email{To: []string{to}, Subject: "[AirSense] Your password was changed", Body: body.String()})
+}
@@ -0,0 +1,55 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: password_reset.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the password reset emails of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package notifications
+
+import (
+	"log"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var passwordResetBody = template.Must(template.New("password_reset").Parse(`Someone asked to reset the password of your AirSense account.
+
+Choose a new password here before {{.ExpiresAt.Format "2006-01-02 15:04 MST"}}:
+{{.Link}}
+
+If it was not you, ignore this email; your password stays the same.
+`))
+
+// PasswordResetMailer emails reset links pointing at the dashboard's
+// /reset-password page, which passes the token on to the API.
+type PasswordResetMailer struct {
+	sender       *EmailSender
+	dashboardURL string
+}
+
+func NewPasswordResetMailer(sender *EmailSender, dashboardURL string) *PasswordResetMailer {
+	return &PasswordResetMailer{sender: sender, dashboardURL: strings.TrimRight(dashboardURL, "/")}
+}
+
+func (m *PasswordResetMailer) SendPasswordReset(to, token string, expiresAt time.Time) {
+	var body strings.Builder
+	err := passwordResetBody.Execute(&body, struct {
+		ExpiresAt time.Time
+		Link      string
+	}{
+		ExpiresAt: expiresAt.UTC(),
+		Link:      m.dashboardURL + "/reset-password?token=" + url.QueryEscape(token),
+	})
+	if err != nil {
+		log.Printf("notifications: build password reset email: %v", err)
+		return
+	}
+	m.sender.Enqueue(Email{To: []string{to}, Subject: "[AirSense] Reset your password", Body: body.String()})
+}
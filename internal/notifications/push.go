@@ -0,0 +1,142 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: push.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the alert sink that pushes alerts to the mobile apps of users.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"airsense-be.com/internal/alert"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// AlertPusher is an alert.Sink that pushes alerts to every app
+// installation of the device owner: critical ones unless the owner saved
+// notification preferences, then the severities they opted in to.
+type AlertPusher struct {
+	devices     repository.DeviceRepository
+	sensors     repository.SensorDataRepository
+	prefs       repository.NotificationPreferenceRepository
+	userDevices repository.UserDeviceRepository
+	sender      *FCMSender
+}
+
+func NewAlertPusher(devices repository.DeviceRepository, sensors repository.SensorDataRepository,
+	prefs repository.NotificationPreferenceRepository, userDevices repository.UserDeviceRepository, sender *FCMSender) *AlertPusher {
+	return &AlertPusher{
+		devices:     devices,
+		sensors:     sensors,
+		prefs:       prefs,
+		userDevices: userDevices,
+		sender:      sender,
+	}
+}
+
+func (n *AlertPusher) Notify(ctx context.Context, e alert.Event) {
+	msgs, err := n.build(ctx, e)
+	if err != nil {
+		log.Printf("notifications: push alert %s/%s: %v", e.DeviceID, e.Field, err)
+		return
+	}
+	for _, m := range msgs {
+		n.sender.Enqueue(m)
+	}
+}
+
+// build returns a message per app installation of the owner, none if they
+// do not want this alert.
+func (n *AlertPusher) build(ctx context.Context, e alert.Event) ([]PushMessage, error) {
+	device, err := n.devices.GetByID(ctx, e.DeviceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pref, err := n.prefs.Get(ctx, device.UserID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		if e.Severity != alert.SeverityCritical {
+			return nil, nil
+		}
+	case err != nil:
+		return nil, err
+	case !pref.Wants(string(e.Severity)):
+		return nil, nil
+	}
+	installs, err := n.userDevices.ListByUser(ctx, device.UserID)
+	if err != nil || len(installs) == 0 {
+		return nil, err
+	}
+
+	// The reading that fired the alert is stored before it is evaluated.
+	var reading *models.SensorData
+	latest, err := n.sensors.Latest(ctx, []string{device.ID})
+	if err != nil {
+		log.Printf("notifications: latest reading of %s: %v", device.ID, err)
+	} else if len(latest) > 0 {
+		reading = &latest[0]
+	}
+
+	name := device.Name
+	if name == "" {
+		name = device.ID
+	}
+	title, body, data := alertPush(e, name, reading)
+	msgs := make([]PushMessage, len(installs))
+	for i, in := range installs {
+		msgs[i] = PushMessage{
+			To:           in.FCMToken,
+			Priority:     "high",
+			Notification: &PushNotification{Title: title, Body: body, Sound: "default"},
+			Data:         data,
+		}
+	}
+	return msgs, nil
+}
+
+// alertPush returns the notification title and body of e and the data the
+// app gets with it: the alert, the device and, if known, the values and
+// AQI of the reading.
+func alertPush(e alert.Event, deviceName string, reading *models.SensorData) (string, string, map[string]string) {
+	severity := strings.ToUpper(string(e.Severity))
+	title := fmt.Sprintf("%s: %s alert", deviceName, severity)
+	body := fmt.Sprintf("%s is %.2f %s (threshold %.2f %s).", e.Field, e.Value, e.Unit, e.Threshold, e.Unit)
+	data := map[string]string{
+		"type":        "alert",
+		"device_id":   e.DeviceID,
+		"device_name": deviceName,
+		"field":       e.Field,
+		"value":       strconv.FormatFloat(e.Value, 'f', -1, 64),
+		"unit":        e.Unit,
+		"threshold":   strconv.FormatFloat(e.Threshold, 'f', -1, 64),
+		"severity":    string(e.Severity),
+		"at":          e.At.UTC().Format(time.RFC3339),
+	}
+	if reading != nil {
+		for field, v := range reading.Sensors.Fields() {
+			data[field] = strconv.FormatFloat(v.Value, 'f', -1, 64)
+		}
+		if reading.AQICategory != "" {
+			body += fmt.Sprintf(" AQI %d, %s.", reading.AQI, strings.ReplaceAll(reading.AQICategory, "_", " "))
+			data["aqi"] = strconv.Itoa(reading.AQI)
+			data["aqi_category"] = reading.AQICategory
+		}
+	}
+	return title, body, data
+}
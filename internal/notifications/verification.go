@@ -0,0 +1,55 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: verification.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the email verification emails of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package notifications
+
+import (
+	"log"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var verificationBody = template.Must(template.New("verification").Parse(`Welcome to AirSense!
+
+Confirm your email address here before {{.ExpiresAt.Format "2006-01-02 15:04 MST"}}:
+{{.Link}}
+
+If you did not sign up, ignore this email; the account cannot add devices until it is confirmed.
+`))
+
+// VerificationMailer emails verification links pointing at the dashboard's
+// /verify-email page, which passes the token on to the API.
+type VerificationMailer struct {
+	sender       *EmailSender
+	dashboardURL string
+}
+
+func NewVerificationMailer(sender *EmailSender, dashboardURL string) *VerificationMailer {
+	return &VerificationMailer{sender: sender, dashboardURL: strings.TrimRight(dashboardURL, "/")}
+}
+
+func (m *VerificationMailer) SendVerification(to, token string, expiresAt time.Time) {
+	var body strings.Builder
+	err := verificationBody.Execute(&body, struct {
+		ExpiresAt time.Time
+		Link      string
+	}{
+		ExpiresAt: expiresAt.UTC(),
+		Link:      m.dashboardURL + "/verify-email?token=" + url.QueryEscape(token),
+	})
+	if err != nil {
+		log.Printf("notifications: build verification email: %v", err)
+		return
+	}
+	m.sender.Enqueue(Email{To: []string{to}, Subject: "[AirSense] Confirm your email address", Body: body.String()})
+}
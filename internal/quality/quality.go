@@ -0,0 +1,72 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: quality.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the signal quality heuristic for sensor readings in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package quality
+
+import (
+	"math"
+	"sync"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/utils"
+)
+
+// maxChange is the largest relative change between consecutive readings of
+// a sensor that is not treated as a spike.
+const maxChange = 0.5
+
+// Scorer rates how trustworthy a reading is. It remembers the newest
+// reading of every device to detect spikes and is safe for concurrent use.
+type Scorer struct {
+	mu       sync.Mutex
+	previous map[string]models.SensorData
+}
+
+func NewScorer() *Scorer {
+	return &Scorer{previous: make(map[string]models.SensorData)}
+}
+
+// Score rates d and remembers it as the previous reading of its device if
+// it is the newest seen:
+//   - poor when a sensor sits at the limit of its physical range
+//     (utils.SensorRanges), which suggests saturation. A lower limit of zero
+//     is a legitimate reading and does not count;
+//   - degraded when a sensor changed by more than 50% since the previous
+//     reading of the device. Previous values of zero are not compared;
+//   - good otherwise.
+func (s *Scorer) Score(d models.SensorData) models.DataQuality {
+	s.mu.Lock()
+	prev, hasPrev := s.previous[d.DeviceID]
+	if !hasPrev || d.Timestamp.After(prev.Timestamp) {
+		s.previous[d.DeviceID] = d
+	}
+	s.mu.Unlock()
+
+	current := d.Sensors.Fields()
+	for field, v := range current {
+		r := utils.SensorRanges[field]
+		if v.Value >= r.Max || (r.Min != 0 && v.Value <= r.Min) {
+			return models.QualityPoor
+		}
+	}
+	if !hasPrev {
+		return models.QualityGood
+	}
+	for field, p := range prev.Sensors.Fields() {
+		if p.Value == 0 {
+			continue
+		}
+		if math.Abs(current[field].Value-p.Value)/math.Abs(p.Value) > maxChange {
+			return models.QualityDegraded
+		}
+	}
+	return models.QualityGood
+}
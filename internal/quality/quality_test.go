@@ -0,0 +1,79 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: quality_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the signal quality heuristic.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package quality
+
+import (
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+)
+
+var t0 = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+// reading returns a reading of device at t0+minutes with everything but pm25
+// at a calm indoor level.
+func reading(device string, minutes int, pm25 float64) models.SensorData {
+	return models.SensorData{
+		DeviceID:  device,
+		Timestamp: t0.Add(time.Duration(minutes) * time.Minute),
+		Sensors: models.Sensors{
+			PM25:        models.SensorValue{Value: pm25},
+			CO2:         models.SensorValue{Value: 600},
+			CO:          models.SensorValue{Value: 1},
+			Temperature: models.SensorValue{Value: 22},
+			Humidity:    models.SensorValue{Value: 45},
+		},
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []models.SensorData
+		reading  models.SensorData
+		want     models.DataQuality
+	}{
+		{"first reading", nil, reading("d1", 0, 12), models.QualityGood},
+		{"steady", []models.SensorData{reading("d1", 0, 12)}, reading("d1", 1, 14), models.QualityGood},
+		{"change of exactly half", []models.SensorData{reading("d1", 0, 10)}, reading("d1", 1, 15), models.QualityGood},
+		{"spike up", []models.SensorData{reading("d1", 0, 10)}, reading("d1", 1, 16), models.QualityDegraded},
+		{"spike down", []models.SensorData{reading("d1", 0, 10)}, reading("d1", 1, 4), models.QualityDegraded},
+		{"from zero", []models.SensorData{reading("d1", 0, 0)}, reading("d1", 1, 40), models.QualityGood},
+		{"other device", []models.SensorData{reading("d2", 0, 10)}, reading("d1", 1, 40), models.QualityGood},
+		{"compared with the newest", []models.SensorData{reading("d1", 1, 10), reading("d1", 0, 40)}, reading("d1", 2, 12), models.QualityGood},
+		{"saturated", nil, reading("d1", 0, 1000), models.QualityPoor},
+		{"saturated after a spike", []models.SensorData{reading("d1", 0, 10)}, reading("d1", 1, 1000), models.QualityPoor},
+		{"zero is a reading", nil, reading("d1", 0, 0), models.QualityGood},
+		{"at the lower limit", nil, func() models.SensorData {
+			d := reading("d1", 0, 12)
+			d.Sensors.Temperature.Value = -40
+			return d
+		}(), models.QualityPoor},
+		{"at the upper humidity limit", nil, func() models.SensorData {
+			d := reading("d1", 0, 12)
+			d.Sensors.Humidity.Value = 100
+			return d
+		}(), models.QualityPoor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScorer()
+			for _, p := range tt.previous {
+				s.Score(p)
+			}
+			if got := s.Score(tt.reading); got != tt.want {
+				t.Errorf("Score = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
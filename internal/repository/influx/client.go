@@ -0,0 +1,36 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: client.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the InfluxDB connection setup of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package influx
+
+import (
+	"context"
+	"fmt"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+
+	"airsense-be.com/internal/config"
+)
+
+// Connect creates a client for cfg.URL and checks that the server answers.
+func Connect(ctx context.Context, cfg config.InfluxDBConfig) (influxdb2.Client, error) {
+	client := influxdb2.NewClient(cfg.URL, cfg.Token)
+	ok, err := client.Ping(ctx)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ping %s: %w", cfg.URL, err)
+	}
+	if !ok {
+		client.Close()
+		return nil, fmt.Errorf("ping %s: server is not ready", cfg.URL)
+	}
+	return client, nil
+}
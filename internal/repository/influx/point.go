@@ -0,0 +1,129 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: point.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the line protocol mapping of sensor data in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package influx
+
+import (
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+
+	"airsense-be.com/internal/models"
+)
+
+// Measurement holds every reading. device_id is its only tag, so each
+// device has one series and a reading is keyed by device and time, as its
+// ID promises; source, aqi_category and quality are string fields, as they
+// may differ between writes of the same reading. Each sensor is a float
+// field with its unit in <name>_unit and its confidence, if any, in
+// <name>_confidence. Each tag of the reading is a string field named
+// tagFieldPrefix plus its key. The reading ID is the id field, which every
+// point has.
+const Measurement = "sensor_data"
+
+// tagFieldPrefix prefixes the fields holding the tags of a reading, which
+// are not InfluxDB tags: they can change after the reading was written.
+const tagFieldPrefix = "tag_"
+
+// ArchiveMeasurement holds the readings of decommissioned devices, as in
+// Measurement plus an archived_at field in Unix milliseconds.
+const ArchiveMeasurement = "sensor_data_archive"
+
+// NewPoint maps a reading to a point of Measurement.
+func NewPoint(d *models.SensorData) *write.Point {
+	return newPoint(Measurement, d)
+}
+
+func newPoint(measurement string, d *models.SensorData) *write.Point {
+	p := write.NewPointWithMeasurement(measurement).SetTime(d.Timestamp)
+	if d.DeviceID != "" {
+		p.AddTag("device_id", d.DeviceID)
+	}
+	if d.Source != "" {
+		p.AddField("source", string(d.Source))
+	}
+	if d.AQICategory != "" {
+		p.AddField("aqi_category", d.AQICategory)
+	}
+	if d.Quality != "" {
+		p.AddField("quality", string(d.Quality))
+	}
+	for name, v := range d.Sensors.Fields() {
+		p.AddField(name, v.Value)
+		if v.Unit != "" {
+			p.AddField(name+"_unit", v.Unit)
+		}
+		if v.Confidence != nil {
+			p.AddField(name+"_confidence", *v.Confidence)
+		}
+	}
+	for k, v := range d.Tags {
+		p.AddField(tagFieldPrefix+k, v)
+	}
+	p.AddField("id", d.ID).AddField("aqi", d.AQI)
+	if d.SchemaVersion != 0 {
+		p.AddField("schema_version", d.SchemaVersion)
+	}
+	return p.SortTags().SortFields()
+}
+
+// sensorRefs returns the sensor values of s keyed like Sensors.Fields.
+func sensorRefs(s *models.Sensors) map[string]*models.SensorValue {
+	return map[string]*models.SensorValue{
+		"pm25":        &s.PM25,
+		"co2":         &s.CO2,
+		"co":          &s.CO,
+		"temperature": &s.Temperature,
+		"humidity":    &s.Humidity,
+	}
+}
+
+// fromRecord rebuilds a reading from a row pivoted on _field.
+func fromRecord(values map[string]any) models.SensorData {
+	d := models.SensorData{}
+	d.ID, _ = values["id"].(string)
+	d.DeviceID, _ = values["device_id"].(string)
+	d.Timestamp, _ = values["_time"].(time.Time)
+	if s, ok := values["source"].(string); ok {
+		d.Source = models.DataSource(s)
+	}
+	d.AQICategory, _ = values["aqi_category"].(string)
+	if s, ok := values["quality"].(string); ok {
+		d.Quality = models.DataQuality(s)
+	}
+	if v, ok := values["aqi"].(int64); ok {
+		d.AQI = int(v)
+	}
+	if v, ok := values["schema_version"].(int64); ok {
+		d.SchemaVersion = int(v)
+	}
+	for name, ref := range sensorRefs(&d.Sensors) {
+		ref.Value, _ = values[name].(float64)
+		ref.Unit, _ = values[name+"_unit"].(string)
+		if c, ok := values[name+"_confidence"].(float64); ok {
+			ref.Confidence = &c
+		}
+	}
+	for col, v := range values {
+		k, ok := strings.CutPrefix(col, tagFieldPrefix)
+		if !ok {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			if d.Tags == nil {
+				d.Tags = make(map[string]string)
+			}
+			d.Tags[k] = s
+		}
+	}
+	return d
+}
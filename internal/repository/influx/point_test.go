@@ -0,0 +1,140 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: point_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of mapping readings to InfluxDB points and queries.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package influx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+func TestNewPoint(t *testing.T) {
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	conf := 0.5
+	tests := []struct {
+		name string
+		data models.SensorData
+		want string
+	}{
+		{
+			name: "minimal",
+			data: models.SensorData{ID: "r1", DeviceID: "d1", Timestamp: at},
+			want: `sensor_data,device_id=d1 aqi=0i,co=0,co2=0,humidity=0,id="r1",pm25=0,temperature=0 1790856000000000000`,
+		},
+		{
+			name: "source, category and quality are fields",
+			data: models.SensorData{ID: "r1", DeviceID: "d1", Timestamp: at, Source: models.SourceMQTT,
+				AQICategory: "good", Quality: models.QualityGood, AQI: 12},
+			want: `sensor_data,device_id=d1 aqi=12i,aqi_category="good",co=0,co2=0,humidity=0,id="r1",pm25=0,quality="good",` +
+				`source="mqtt",temperature=0 1790856000000000000`,
+		},
+		{
+			name: "units, confidence, tags and schema version",
+			data: models.SensorData{ID: "r1", DeviceID: "d1", Timestamp: at, SchemaVersion: 2,
+				Sensors: models.Sensors{PM25: models.SensorValue{Value: 12.5, Unit: "µg/m³", Confidence: &conf}},
+				Tags:    map[string]string{"event": "cooking"}},
+			want: `sensor_data,device_id=d1 aqi=0i,co=0,co2=0,humidity=0,id="r1",pm25=12.5,pm25_confidence=0.5,pm25_unit="µg/m³",` +
+				`schema_version=2i,tag_event="cooking",temperature=0 1790856000000000000`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.TrimSpace(write.PointToLineProtocol(NewPoint(&tt.data), time.Nanosecond))
+			if got != tt.want {
+				t.Errorf("line protocol\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPointSeries(t *testing.T) {
+	// Two writes of a reading differing only in source, category and
+	// quality must land in the same series, or the second becomes a
+	// second reading.
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	a := NewPoint(&models.SensorData{ID: "r1", DeviceID: "d1", Timestamp: at, Source: models.SourceMQTT, AQICategory: "good", Quality: models.QualityGood})
+	b := NewPoint(&models.SensorData{ID: "r1", DeviceID: "d1", Timestamp: at, Source: models.SourceManual, AQICategory: "moderate", Quality: models.QualityPoor})
+	if len(a.TagList()) != 1 || len(b.TagList()) != 1 || a.TagList()[0].Key != "device_id" {
+		t.Fatalf("tags = %v and %v, want device_id only", a.TagList(), b.TagList())
+	}
+	if *a.TagList()[0] != *b.TagList()[0] || !a.Time().Equal(b.Time()) {
+		t.Errorf("series %v at %v and %v at %v differ", a.TagList(), a.Time(), b.TagList(), b.Time())
+	}
+}
+
+func TestFromRecord(t *testing.T) {
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	got := fromRecord(map[string]any{
+		"_time": at, "device_id": "d1", "id": "r1", "source": "mqtt", "aqi_category": "good", "quality": "poor",
+		"aqi": int64(12), "pm25": 12.5, "pm25_unit": "µg/m³", "pm25_confidence": 0.5, "tag_event": "cooking",
+	})
+	if got.ID != "r1" || got.DeviceID != "d1" || !got.Timestamp.Equal(at) || got.Source != models.SourceMQTT ||
+		got.AQICategory != "good" || got.Quality != models.QualityPoor || got.AQI != 12 {
+		t.Errorf("fromRecord() = %+v", got)
+	}
+	if got.Sensors.PM25.Value != 12.5 || got.Sensors.PM25.Unit != "µg/m³" || got.Sensors.PM25.Confidence == nil || *got.Sensors.PM25.Confidence != 0.5 {
+		t.Errorf("pm25 = %+v", got.Sensors.PM25)
+	}
+	if got.Tags["event"] != "cooking" {
+		t.Errorf("tags = %v", got.Tags)
+	}
+}
+
+func TestFieldConditions(t *testing.T) {
+	r := &SensorRepo{bucket: "b"}
+	tests := []struct {
+		name   string
+		filter repository.SensorFilter
+		want   []string
+	}{
+		{name: "none", filter: repository.SensorFilter{DeviceID: "d1"}},
+		{name: "source", filter: repository.SensorFilter{DeviceID: "d1", Source: models.SourceMQTT}, want: []string{`r.source == "mqtt"`}},
+		{name: "source and category", filter: repository.SensorFilter{DeviceID: "d1", Source: models.SourceMQTT, Category: "good"},
+			want: []string{`r.source == "mqtt"`, `r.aqi_category == "good"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := r.rows(tt.filter, true)
+			pivot := strings.Index(rows, "pivot(")
+			if strings.Contains(r.from(tt.filter), "source") || strings.Contains(r.from(tt.filter), "aqi_category") {
+				t.Errorf("from() filters fields before the pivot: %s", r.from(tt.filter))
+			}
+			fields := r.fields(tt.filter)
+			for _, cond := range tt.want {
+				if i := strings.Index(rows, cond); i < pivot {
+					t.Errorf("rows() checks %s before the pivot: %s", cond, rows)
+				}
+				if !strings.Contains(fields, cond) || !strings.Contains(fields, "experimental.unpivot()") {
+					t.Errorf("fields() = %s, want %s on pivoted rows", fields, cond)
+				}
+			}
+			if len(tt.want) == 0 && fields != r.from(tt.filter) {
+				t.Errorf("fields() = %s, want the points unpivoted", fields)
+			}
+		})
+	}
+}
+
+func TestDeleteBySourceUnsupported(t *testing.T) {
+	r := &SensorRepo{bucket: "b"}
+	err := r.deletePoints(context.Background(), repository.SensorFilter{DeviceID: "d1", Source: models.SourceMQTT})
+	if !errors.Is(err, repository.ErrUnsupported) {
+		t.Errorf("deletePoints() error = %v, want %v", err, repository.ErrUnsupported)
+	}
+}
@@ -0,0 +1,515 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the InfluxDB repository for sensor data in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package influx
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// Range bounds used when a filter leaves From or To open. maxTime is close
+// to the largest timestamp InfluxDB stores.
+var (
+	minTime = time.Unix(0, 0).UTC()
+	maxTime = time.Date(2262, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// aggregateWindows maps an aggregation unit to its Flux window and the
+// offset that starts weeks on Sunday like MongoDB's $dateTrunc; Flux
+// windows are aligned to the Unix epoch, a Thursday.
+var aggregateWindows = map[string]struct{ every, offset string }{
+	"hour":  {"1h", "0s"},
+	"day":   {"1d", "0s"},
+	"week":  {"1w", "3d"},
+	"month": {"1mo", "0s"},
+}
+
+type SensorRepo struct {
+	bucket string
+	org    string
+	write  api.WriteAPIBlocking
+	query  api.QueryAPI
+	delete api.DeleteAPI
+
+	// tagsMu serializes MergeTags.
+	tagsMu sync.Mutex
+}
+
+func NewSensorRepository(client influxdb2.Client, cfg config.InfluxDBConfig) *SensorRepo {
+	return &SensorRepo{
+		bucket: cfg.Bucket,
+		org:    cfg.Org,
+		write:  client.WriteAPIBlocking(cfg.Org, cfg.Bucket),
+		query:  client.QueryAPI(cfg.Org),
+		delete: client.DeleteAPI(),
+	}
+}
+
+// Insert writes data as a point. InfluxDB overwrites the fields of a point
+// with the same series and time, so a replayed reading replaces the values
+// of the stored one. Replacements are not counted in
+// readings_replaced_total.
+func (r *SensorRepo) Insert(ctx context.Context, data *models.SensorData) error {
+	if data.ID == "" {
+		data.ID = primitive.NewObjectID().Hex()
+	}
+	return r.write.WritePoint(ctx, NewPoint(data))
+}
+
+func (r *SensorRepo) InsertMany(ctx context.Context, data []*models.SensorData) error {
+	if len(data) == 0 {
+		return nil
+	}
+	points := make([]*write.Point, len(data))
+	for i, d := range data {
+		if d.ID == "" {
+			d.ID = primitive.NewObjectID().Hex()
+		}
+		points[i] = NewPoint(d)
+	}
+	return r.write.WritePoint(ctx, points...)
+}
+
+// Find returns the readings matching filter, newest first.
+func (r *SensorRepo) Find(ctx context.Context, filter repository.SensorFilter) ([]models.SensorData, error) {
+	return r.readings(ctx, r.rows(filter, true))
+}
+
+func (r *SensorRepo) Stream(ctx context.Context, filter repository.SensorFilter, fn func(*models.SensorData) error) error {
+	return r.each(ctx, r.rows(filter, false), fn)
+}
+
+func (r *SensorRepo) Latest(ctx context.Context, deviceIDs []string) ([]models.SensorData, error) {
+	if len(deviceIDs) == 0 {
+		return []models.SensorData{}, nil
+	}
+	q := fmt.Sprintf(`from(bucket: %s)
+  |> range(start: %s, stop: %s)
+  |> filter(fn: (r) => r._measurement == %s and contains(value: r.device_id, set: %s))
+  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+  |> group(columns: ["device_id"])
+  |> sort(columns: ["_time"], desc: true)
+  |> limit(n: 1)
+  |> group()`,
+		fluxString(r.bucket), fluxTime(minTime), fluxTime(maxTime),
+		fluxString(Measurement), fluxStrings(deviceIDs))
+	return r.readings(ctx, q)
+}
+
+// Aggregate cannot weigh averages by confidence, which would need the
+// fields of each reading joined; it returns ErrUnsupported for weighted.
+func (r *SensorRepo) Aggregate(ctx context.Context, filter repository.SensorFilter, unit, timezone string, weighted bool) ([]models.SensorAggregate, error) {
+	if weighted {
+		return nil, repository.ErrUnsupported
+	}
+	window, ok := aggregateWindows[unit]
+	if !ok {
+		return nil, fmt.Errorf("unsupported aggregation unit %q", unit)
+	}
+	names := sensorNames()
+	windowArgs := fmt.Sprintf(`every: %s, offset: %s, location: timezone.location(name: %s), createEmpty: false, timeSrc: "_start"`,
+		window.every, window.offset, fluxString(timezone))
+	q := fmt.Sprintf(`import "experimental"
+import "timezone"
+
+data = %s
+  |> group(columns: ["_field"])
+stats = (fn, suffix) => data
+  |> filter(fn: (r) => contains(value: r._field, set: %s))
+  |> aggregateWindow(fn: fn, %s)
+  |> group()
+  |> map(fn: (r) => ({r with _field: r._field + suffix}))
+counts = data
+  |> filter(fn: (r) => r._field == "id")
+  |> aggregateWindow(fn: count, %s)
+  |> group()
+  |> map(fn: (r) => ({r with _field: "count", _value: float(v: r._value)}))
+union(tables: [stats(fn: mean, suffix: "_avg"), stats(fn: min, suffix: "_min"), stats(fn: max, suffix: "_max"), counts])
+  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+  |> sort(columns: ["_time"])`,
+		r.fields(filter), fluxStrings(names), windowArgs, windowArgs)
+
+	res, err := r.query.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	out := []models.SensorAggregate{}
+	for res.Next() {
+		values := res.Record().Values()
+		agg := models.SensorAggregate{
+			Bucket: res.Record().Time(),
+			Fields: make(map[string]models.AggregateStats, len(names)),
+		}
+		if v, ok := values["count"].(float64); ok {
+			agg.Count = int64(v)
+		}
+		for _, name := range names {
+			agg.Fields[name] = models.AggregateStats{
+				Avg: optionalFloat(values[name+"_avg"]),
+				Min: optionalFloat(values[name+"_min"]),
+				Max: optionalFloat(values[name+"_max"]),
+			}
+		}
+		out = append(out, agg)
+	}
+	return out, res.Err()
+}
+
+// optionalFloat returns v if it is a float, or nil for the null of a window
+// without values.
+func optionalFloat(v any) *float64 {
+	f, ok := v.(float64)
+	if !ok {
+		return nil
+	}
+	return &f
+}
+
+// Stats is not supported: the percentiles and correlations would need the
+// fields of each reading joined, as weighted averages do.
+func (r *SensorRepo) Stats(ctx context.Context, filter repository.SensorFilter, fields []string) (*models.SensorStats, error) {
+	return nil, repository.ErrUnsupported
+}
+
+// Detach rewrites the readings of deviceID without the device_id tag and
+// with detached_from and detached_by_transfer tags, then deletes the
+// originals: InfluxDB cannot change the tags of stored points.
+func (r *SensorRepo) Detach(ctx context.Context, deviceID, transferID string) error {
+	data, err := r.Find(ctx, repository.SensorFilter{DeviceID: deviceID})
+	if err != nil || len(data) == 0 {
+		return err
+	}
+	points := make([]*write.Point, len(data))
+	for i := range data {
+		data[i].DeviceID = ""
+		points[i] = NewPoint(&data[i]).
+			AddTag("detached_from", deviceID).
+			AddTag("detached_by_transfer", transferID).
+			SortTags()
+	}
+	if err := r.write.WritePoint(ctx, points...); err != nil {
+		return err
+	}
+	return r.deletePoints(ctx, repository.SensorFilter{DeviceID: deviceID})
+}
+
+// TagPriorOwner is not supported: the readings would all have to be
+// rewritten, as Detach does, on every transfer.
+func (r *SensorRepo) TagPriorOwner(ctx context.Context, deviceID, ownerID string, before time.Time) error {
+	return repository.ErrUnsupported
+}
+
+// Archive rewrites the readings of deviceID to ArchiveMeasurement, then
+// deletes the originals. Points are keyed by series and time, so an
+// interrupted archive can simply be run again.
+func (r *SensorRepo) Archive(ctx context.Context, deviceID string, at time.Time) (int64, error) {
+	data, err := r.Find(ctx, repository.SensorFilter{DeviceID: deviceID})
+	if err != nil || len(data) == 0 {
+		return 0, err
+	}
+	points := make([]*write.Point, len(data))
+	for i := range data {
+		points[i] = newPoint(ArchiveMeasurement, &data[i]).
+			AddField("archived_at", at.UnixMilli()).
+			SortFields()
+	}
+	if err := r.write.WritePoint(ctx, points...); err != nil {
+		return 0, err
+	}
+	if err := r.deletePoints(ctx, repository.SensorFilter{DeviceID: deviceID}); err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
+
+func (r *SensorRepo) DeleteByDevice(ctx context.Context, deviceID string) (int64, error) {
+	return r.Delete(ctx, repository.SensorFilter{DeviceID: deviceID})
+}
+
+func (r *SensorRepo) Count(ctx context.Context, filter repository.SensorFilter) (int64, error) {
+	q := r.from(filter)
+	if conds := fieldConds(filter); len(conds) > 0 {
+		q += `
+  |> filter(fn: (r) => r._field == "id" or r._field == "source" or r._field == "aqi_category")
+  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+  |> filter(fn: (r) => ` + strings.Join(conds, " and ") + `)
+  |> map(fn: (r) => ({_time: r._time, _value: r.id}))`
+	} else {
+		q += `
+  |> filter(fn: (r) => r._field == "id")`
+	}
+	q += `
+  |> group()
+  |> count()`
+	res, err := r.query.Query(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Close()
+	var n int64
+	if res.Next() {
+		n, _ = res.Record().Value().(int64)
+	}
+	return n, res.Err()
+}
+
+// Delete reports the number of readings that matched filter just before
+// they were deleted; the delete API does not return a count.
+func (r *SensorRepo) Delete(ctx context.Context, filter repository.SensorFilter) (int64, error) {
+	filter.Limit = 0
+	n, err := r.Count(ctx, filter)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	if err := r.deletePoints(ctx, filter); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// BackfillAQI has nothing to do: this backend has never stored a reading
+// without its AQI.
+func (r *SensorRepo) BackfillAQI(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// deletePoints deletes the points of the device and time range of filter.
+// Delete predicates can only name tags, so a filter on a source or
+// category, which are fields, is not supported.
+func (r *SensorRepo) deletePoints(ctx context.Context, filter repository.SensorFilter) error {
+	if len(fieldConds(filter)) > 0 {
+		return repository.ErrUnsupported
+	}
+	start, stop := timeRange(filter)
+	predicate := fmt.Sprintf(`_measurement=%s AND device_id=%s`, predicateString(Measurement), predicateString(filter.DeviceID))
+	return r.delete.DeleteWithName(ctx, r.org, r.bucket, start, stop, predicate)
+}
+
+// from selects the points of the device and time range of filter, one
+// table per series; the other conditions of filter and Limit are left to
+// the caller.
+func (r *SensorRepo) from(filter repository.SensorFilter) string {
+	start, stop := timeRange(filter)
+	return fmt.Sprintf(`from(bucket: %s)
+  |> range(start: %s, stop: %s)
+  |> filter(fn: (r) => r._measurement == %s and r.device_id == %s)`,
+		fluxString(r.bucket), fluxTime(start), fluxTime(stop), fluxString(Measurement), fluxString(filter.DeviceID))
+}
+
+// fieldConds returns the Flux conditions on the source and category of
+// filter. Both are fields, so they can only be checked on rows pivoted on
+// _field.
+func fieldConds(filter repository.SensorFilter) []string {
+	var conds []string
+	if filter.Source != "" {
+		conds = append(conds, "r.source == "+fluxString(string(filter.Source)))
+	}
+	if filter.Category != "" {
+		conds = append(conds, "r.aqi_category == "+fluxString(filter.Category))
+	}
+	return conds
+}
+
+// fields selects the points of the readings matching the device, time
+// range, source and category of filter, one table per series. Readings are
+// only filtered by source or category pivoted into rows, which are then
+// turned back into points.
+func (r *SensorRepo) fields(filter repository.SensorFilter) string {
+	q := r.from(filter)
+	if conds := fieldConds(filter); len(conds) > 0 {
+		q += `
+  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+  |> filter(fn: (r) => ` + strings.Join(conds, " and ") + `)
+  |> experimental.unpivot()`
+	}
+	return q
+}
+
+// rows pivots the points matching filter into one row per reading, sorted
+// by time.
+func (r *SensorRepo) rows(filter repository.SensorFilter, desc bool) string {
+	q := r.from(filter) + `
+  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")`
+	if conds := fieldConds(filter); len(conds) > 0 {
+		q += "\n  |> filter(fn: (r) => " + strings.Join(conds, " and ") + ")"
+	}
+	if filter.MinConfidence != nil {
+		var conds []string
+		for _, name := range sensorNames() {
+			col := "r." + name + "_confidence"
+			conds = append(conds, fmt.Sprintf("(not exists %s or %s >= %s)", col, col, fluxFloat(*filter.MinConfidence)))
+		}
+		q += "\n  |> filter(fn: (r) => " + strings.Join(conds, " and ") + ")"
+	}
+	if len(filter.Tags) > 0 {
+		var conds []string
+		for k, v := range filter.Tags {
+			col := "r[" + fluxString(tagFieldPrefix+k) + "]"
+			conds = append(conds, fmt.Sprintf("(exists %s and %s == %s)", col, col, fluxString(v)))
+		}
+		sort.Strings(conds)
+		q += "\n  |> filter(fn: (r) => " + strings.Join(conds, " and ") + ")"
+	}
+	q += fmt.Sprintf(`
+  |> group()
+  |> sort(columns: ["_time"], desc: %t)`, desc)
+	if filter.Limit > 0 {
+		q += fmt.Sprintf("\n  |> limit(n: %d)", filter.Limit)
+	}
+	return q
+}
+
+// readings runs a query returning one pivoted row per reading.
+func (r *SensorRepo) readings(ctx context.Context, q string) ([]models.SensorData, error) {
+	out := []models.SensorData{}
+	err := r.each(ctx, q, func(d *models.SensorData) error {
+		out = append(out, *d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// each runs a query returning one pivoted row per reading and calls fn
+// with each of them.
+func (r *SensorRepo) each(ctx context.Context, q string, fn func(*models.SensorData) error) error {
+	res, err := r.query.Query(ctx, q)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	for res.Next() {
+		d := fromRecord(res.Record().Values())
+		if err := fn(&d); err != nil {
+			return err
+		}
+	}
+	return res.Err()
+}
+
+func (r *SensorRepo) GetByID(ctx context.Context, deviceID, id string) (*models.SensorData, error) {
+	q := fmt.Sprintf(`from(bucket: %s)
+  |> range(start: %s, stop: %s)
+  |> filter(fn: (r) => r._measurement == %s and r.device_id == %s)
+  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+  |> filter(fn: (r) => r.id == %s)
+  |> group()
+  |> limit(n: 1)`,
+		fluxString(r.bucket), fluxTime(minTime), fluxTime(maxTime),
+		fluxString(Measurement), fluxString(deviceID), fluxString(id))
+	found, err := r.readings(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return &found[0], nil
+}
+
+// MergeTags rewrites the point of the reading with the merged tags; its
+// series and time are unchanged, so InfluxDB updates it in place. InfluxDB
+// cannot update part of a point, so merges are serialized to keep one from
+// overwriting the tags of another; that holds within this process only.
+func (r *SensorRepo) MergeTags(ctx context.Context, deviceID, id string, tags map[string]string, maxTags int) (*models.SensorData, error) {
+	r.tagsMu.Lock()
+	defer r.tagsMu.Unlock()
+	d, err := r.GetByID(ctx, deviceID, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Tags == nil {
+		d.Tags = make(map[string]string, len(tags))
+	}
+	for k, v := range tags {
+		d.Tags[k] = v
+	}
+	if len(d.Tags) > maxTags {
+		return nil, repository.ErrLimitExceeded
+	}
+	if err := r.write.WritePoint(ctx, NewPoint(d)); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// timeRange turns the inclusive From/To of filter into the half-open range
+// InfluxDB expects.
+func timeRange(filter repository.SensorFilter) (time.Time, time.Time) {
+	start, stop := minTime, maxTime
+	if !filter.From.IsZero() {
+		start = filter.From
+	}
+	if !filter.To.IsZero() {
+		stop = filter.To.Add(time.Nanosecond)
+	}
+	return start, stop
+}
+
+func sensorNames() []string {
+	names := make([]string, 0, 5)
+	for name := range (models.Sensors{}).Fields() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var fluxEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`)
+
+// fluxString quotes s as a Flux string literal.
+func fluxString(s string) string {
+	return `"` + fluxEscaper.Replace(s) + `"`
+}
+
+func fluxStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fluxString(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// fluxFloat formats f as a Flux float literal, which needs a decimal point.
+func fluxFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+func fluxTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// predicateString quotes s for a delete predicate.
+func predicateString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,135 @@
+//go:build integration
+
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_repo_integration_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the round-trip tests of the InfluxDB sensor repository.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package influx
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// testRepo returns a repository on a fresh bucket of the InfluxDB server at
+// INFLUXDB_TEST_URL, deleted when the test ends. Tests are skipped without
+// one.
+func testRepo(t *testing.T) *SensorRepo {
+	t.Helper()
+	cfg := config.InfluxDBConfig{
+		URL:    os.Getenv("INFLUXDB_TEST_URL"),
+		Token:  os.Getenv("INFLUXDB_TEST_TOKEN"),
+		Org:    os.Getenv("INFLUXDB_TEST_ORG"),
+		Bucket: "airsense_test_" + primitive.NewObjectID().Hex(),
+	}
+	if cfg.URL == "" {
+		t.Skip("INFLUXDB_TEST_URL is not set")
+	}
+	ctx := context.Background()
+	client, err := Connect(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	org, err := client.OrganizationsAPI().FindOrganizationByName(ctx, cfg.Org)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucket, err := client.BucketsAPI().CreateBucketWithName(ctx, org, cfg.Bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := client.BucketsAPI().DeleteBucket(ctx, bucket); err != nil {
+			t.Errorf("delete bucket %s: %v", cfg.Bucket, err)
+		}
+		client.Close()
+	})
+	return NewSensorRepository(client, cfg)
+}
+
+func TestSensorRepoRoundTrip(t *testing.T) {
+	r := testRepo(t)
+	ctx := context.Background()
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	conf := 0.75
+	in := &models.SensorData{
+		DeviceID: "d1", Timestamp: at, Source: models.SourceMQTT, AQICategory: "good", Quality: models.QualityGood, AQI: 12,
+		Sensors: models.Sensors{PM25: models.SensorValue{Value: 12.5, Unit: "µg/m³", Confidence: &conf}},
+		Tags:    map[string]string{"event": "cooking"},
+	}
+	if err := r.Insert(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.GetByID(ctx, "d1", in.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Timestamp.Equal(at) || got.Source != in.Source || got.AQICategory != in.AQICategory || got.Quality != in.Quality ||
+		got.AQI != in.AQI || got.Sensors.PM25.Value != 12.5 || got.Sensors.PM25.Unit != "µg/m³" ||
+		got.Sensors.PM25.Confidence == nil || *got.Sensors.PM25.Confidence != conf || got.Tags["event"] != "cooking" {
+		t.Errorf("GetByID() = %+v, want %+v", got, in)
+	}
+
+	// A replay with another source, category and quality replaces the
+	// reading instead of adding one.
+	replay := *in
+	replay.Source, replay.AQICategory, replay.Quality = models.SourceManual, "moderate", models.QualityPoor
+	if err := r.Insert(ctx, &replay); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		filter repository.SensorFilter
+		want   int64
+	}{
+		{name: "device", filter: repository.SensorFilter{DeviceID: "d1"}, want: 1},
+		{name: "new source", filter: repository.SensorFilter{DeviceID: "d1", Source: models.SourceManual}, want: 1},
+		{name: "old source", filter: repository.SensorFilter{DeviceID: "d1", Source: models.SourceMQTT}, want: 0},
+		{name: "new category", filter: repository.SensorFilter{DeviceID: "d1", Category: "moderate"}, want: 1},
+		{name: "old category", filter: repository.SensorFilter{DeviceID: "d1", Category: "good"}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := r.Count(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.want {
+				t.Errorf("Count() = %d, want %d", n, tt.want)
+			}
+			found, err := r.Find(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int64(len(found)) != tt.want {
+				t.Errorf("Find() returned %d readings, want %d", len(found), tt.want)
+			}
+			aggs, err := r.Aggregate(ctx, tt.filter, "hour", "UTC", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var count int64
+			for _, a := range aggs {
+				count += a.Count
+			}
+			if count != tt.want {
+				t.Errorf("Aggregate() counted %d readings, want %d", count, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,510 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: interfaces.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the repository interfaces of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"airsense-be.com/internal/models"
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrDuplicate = errors.New("duplicate key")
+	// ErrUnsupported is returned for a query the storage backend cannot run.
+	ErrUnsupported = errors.New("not supported by the storage backend")
+	// ErrLimitExceeded is returned when a write would take a document past
+	// a limit it was given.
+	ErrLimitExceeded = errors.New("limit exceeded")
+)
+
+// SensorFilter selects readings of a single device. Zero values are ignored.
+type SensorFilter struct {
+	DeviceID string
+	Source   models.DataSource
+	// Category is an AQI category, see utils.PM25AQI.
+	Category string
+	From     time.Time
+	To       time.Time
+	Limit    int64
+
+	// MinConfidence drops readings with a sensor confidence below it.
+	// Readings without confidences are kept. The InfluxDB backend only
+	// applies it in Find and Stream.
+	MinConfidence *float64
+	// Tags must all be present on the reading with the given values. The
+	// InfluxDB backend only applies them in Find and Stream.
+	Tags map[string]string
+}
+
+// SensorDataRepository stores readings. It is implemented by the MongoDB
+// and InfluxDB backends, see config.StorageConfig.
+type SensorDataRepository interface {
+	// Insert stores data. A device has one reading per timestamp, so a
+	// reading of its device at the same timestamp is replaced. InsertMany
+	// does the same for each reading.
+	Insert(ctx context.Context, data *models.SensorData) error
+	InsertMany(ctx context.Context, data []*models.SensorData) error
+	Find(ctx context.Context, filter SensorFilter) ([]models.SensorData, error)
+	// Stream calls fn with each reading matching filter, oldest first,
+	// without loading them all, and stops at the first error fn returns.
+	Stream(ctx context.Context, filter SensorFilter, fn func(*models.SensorData) error) error
+	// Latest returns the newest reading of each of deviceIDs that has any.
+	Latest(ctx context.Context, deviceIDs []string) ([]models.SensorData, error)
+	// Aggregate buckets the readings matching filter by unit (hour, day,
+	// week or month) in the IANA timezone, oldest bucket first. With
+	// weighted, averages weigh each value by its confidence, 1 if it has
+	// none.
+	Aggregate(ctx context.Context, filter SensorFilter, unit, timezone string, weighted bool) ([]models.SensorAggregate, error)
+	// Stats summarises the values of fields in the readings matching
+	// filter and correlates each pair of them, in the order of fields.
+	Stats(ctx context.Context, filter SensorFilter, fields []string) (*models.SensorStats, error)
+	// Detach unlinks every reading of deviceID from the device so it is no
+	// longer returned for it, keeping the original ID in detached_from.
+	Detach(ctx context.Context, deviceID, transferID string) error
+	// TagPriorOwner sets prior_owner_id to ownerID on the readings of
+	// deviceID taken up to before that have none yet.
+	TagPriorOwner(ctx context.Context, deviceID, ownerID string, before time.Time) error
+	DeleteByDevice(ctx context.Context, deviceID string) (int64, error)
+	// Archive moves every reading of deviceID out of the live data into
+	// the archive, stamped with archived_at, and returns how many it moved.
+	Archive(ctx context.Context, deviceID string, at time.Time) (int64, error)
+	Count(ctx context.Context, filter SensorFilter) (int64, error)
+	// Delete removes the readings matching filter; Limit is ignored.
+	Delete(ctx context.Context, filter SensorFilter) (int64, error)
+	// BackfillAQI computes and stores the AQI of readings stored without one
+	// and returns how many were updated.
+	BackfillAQI(ctx context.Context) (int64, error)
+	// GetByID returns the reading id of deviceID.
+	GetByID(ctx context.Context, deviceID, id string) (*models.SensorData, error)
+	// MergeTags adds tags to the reading id of deviceID, overwriting the
+	// values of keys it has, and returns the updated reading. Concurrent
+	// merges keep each other's tags. A merge that would leave the reading
+	// with more than maxTags tags fails with ErrLimitExceeded.
+	MergeTags(ctx context.Context, deviceID, id string, tags map[string]string, maxTags int) (*models.SensorData, error)
+}
+
+// DeviceFilter selects the devices a user can see: the ones they own plus
+// the ones listed in SharedIDs. Soft-deleted devices are never included,
+// decommissioned ones only if asked for. Zero search fields do not filter.
+type DeviceFilter struct {
+	UserID    string
+	SharedIDs []string
+	// OrgID matches the devices of an organization, ignoring UserID and
+	// SharedIDs.
+	OrgID string
+	// AllUsers matches the devices of every user, ignoring UserID and
+	// SharedIDs.
+	AllUsers bool
+
+	// Name matches case-insensitively anywhere in the name.
+	Name     string
+	Location string
+	Status   models.DeviceStatus
+	// Firmware matches FirmwareVersion exactly.
+	Firmware string
+	// Tags must all be present with the given values.
+	Tags map[string]string
+	// Near, if set, keeps the devices whose coordinates lie within
+	// RadiusKm of it.
+	Near     *models.GeoPoint
+	RadiusKm float64
+
+	IncludeDecommissioned bool
+
+	// SortField is the BSON field to order by, ties broken by _id; empty
+	// sorts by _id alone.
+	SortField string
+	Desc      bool
+	// After resumes after the last device of the previous page.
+	After *DeviceCursor
+	Limit int64
+}
+
+// DeviceCursor is the sort key of the last device of a page. Value is nil
+// when the device has no value for the sort field.
+type DeviceCursor struct {
+	Value any
+	ID    string
+}
+
+type DeviceRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Device, error)
+	List(ctx context.Context, filter DeviceFilter) ([]models.Device, error)
+	// Count counts the devices matching filter, ignoring sort and paging.
+	Count(ctx context.Context, filter DeviceFilter) (int64, error)
+	// Touch records traffic from a live device at at and marks it online.
+	Touch(ctx context.Context, id string, at time.Time) error
+	// SetStatus records the status a live device reported at at. It
+	// returns ErrNotFound if the device is gone or decommissioned, which
+	// it checks in the same write.
+	SetStatus(ctx context.Context, id string, status models.DeviceStatus, at time.Time) error
+	// Create inserts a device, returning ErrDuplicate if the ID is taken.
+	Create(ctx context.Context, d *models.Device) error
+	CreateMany(ctx context.Context, devices []*models.Device) error
+	SoftDelete(ctx context.Context, id string) error
+	// Restore clears DeletedAt and applies the new name and location.
+	Restore(ctx context.Context, id, name, location string) (*models.Device, error)
+	Delete(ctx context.Context, id string) error
+	// ChangeOwner reassigns a live device only if it is still owned by
+	// fromUserID. The device leaves its organization, whose members got
+	// their access from the previous owner.
+	ChangeOwner(ctx context.Context, id, fromUserID, toUserID string) (bool, error)
+	// Update sets the given fields (BSON names) of a live device and bumps
+	// UpdatedAt, returning the updated device.
+	Update(ctx context.Context, id string, fields map[string]any) (*models.Device, error)
+	// Unset removes the given fields (BSON names, e.g. "tags.floor") of a
+	// live device and bumps UpdatedAt, returning the updated device.
+	Unset(ctx context.Context, id string, fields []string) (*models.Device, error)
+	// Decommission marks a live device decommissioned at at, returning
+	// ErrNotFound if it is gone or already decommissioned.
+	Decommission(ctx context.Context, id string, at time.Time) (*models.Device, error)
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, u *models.User) error
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// ReserveDevices adds n to the device count of userID if that keeps it
+	// within the user's limit (defaultLimit when unset), or the user is an
+	// admin, reporting whether it did.
+	ReserveDevices(ctx context.Context, userID string, n, defaultLimit int) (bool, error)
+	ReleaseDevices(ctx context.Context, userID string, n int) error
+	SetDeviceLimit(ctx context.Context, userID string, limit *int) error
+	Count(ctx context.Context) (int64, error)
+	// SetPassword replaces the password hash of userID and records
+	// changedAt as its PasswordChangedAt.
+	SetPassword(ctx context.Context, userID, hash string, changedAt time.Time) error
+	// RevokeSessions records at as the SessionsRevokedAt of userID.
+	RevokeSessions(ctx context.Context, userID string, at time.Time) error
+	// UpdateProfile sets the email and name of userID where they are not
+	// nil and returns the updated user, or ErrDuplicate if another user
+	// has the email. A new email leaves the user unverified.
+	UpdateProfile(ctx context.Context, userID string, email, name *string) (*models.User, error)
+	// ReplaceUnverifiedPassword replaces the password hash of userID, as
+	// SetPassword does, if it is still unverified, reporting whether it
+	// did.
+	ReplaceUnverifiedPassword(ctx context.Context, userID, hash string, changedAt time.Time) (bool, error)
+	// MarkVerified clears the Unverified flag of userID, recording at as
+	// its VerifiedAt unless it is verified already.
+	MarkVerified(ctx context.Context, userID string, at time.Time) error
+	// GetByOIDC returns the user linked to subject at issuer.
+	GetByOIDC(ctx context.Context, issuer, subject string) (*models.User, error)
+	// LinkOIDC links userID to subject at issuer unless it is linked to an
+	// identity already, reporting whether it did, or returns ErrDuplicate
+	// if another user has the identity.
+	LinkOIDC(ctx context.Context, userID, issuer, subject string) (bool, error)
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// Revoke marks a token revoked if it is not already, reporting whether
+	// this call performed the revocation.
+	Revoke(ctx context.Context, id, replacedBy string) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeUser(ctx context.Context, userID string) error
+}
+
+type SensorAnomalyRepository interface {
+	// Upsert stores anomalies, replacing those flagged before.
+	Upsert(ctx context.Context, anomalies []models.SensorAnomaly) error
+}
+
+// AuditFilter selects audit entries; empty fields match everything.
+type AuditFilter struct {
+	ResourceID string
+	UserID     string
+	Limit      int64
+}
+
+type AuditRepository interface {
+	CreateMany(ctx context.Context, entries []models.AuditEntry) error
+	// List returns the entries matching filter, newest first.
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error)
+}
+
+type LoginFailureRepository interface {
+	// Attempt counts a login under key, keeping the counter at least until
+	// expiresAt, and returns the counter as updated, in one step so that
+	// parallel logins each see their own count. While key is locked at now
+	// nothing is counted and the counter is returned as it is.
+	Attempt(ctx context.Context, key string, now, expiresAt time.Time) (*models.LoginFailures, error)
+	// Lock refuses logins under key until then and sets its count, unless
+	// it is locked at now already; it reports whether this call locked it.
+	Lock(ctx context.Context, key string, now, until time.Time, count int, expiresAt time.Time) (bool, error)
+	// Release takes back one login counted under key.
+	Release(ctx context.Context, key string) error
+	Delete(ctx context.Context, keys []string) error
+}
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, r *models.PasswordReset) error
+	GetByTokenHash(ctx context.Context, hash string) (*models.PasswordReset, error)
+	// MarkUsed uses an unused reset, reporting whether this call did.
+	MarkUsed(ctx context.Context, id string) (bool, error)
+	// DeleteByUser drops the resets of userID, used or not.
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+type OIDCLoginRepository interface {
+	Create(ctx context.Context, l *models.OIDCLogin) error
+	// Take deletes and returns the login with stateHash, so that each is
+	// used once.
+	Take(ctx context.Context, stateHash string) (*models.OIDCLogin, error)
+}
+
+type OIDCResultRepository interface {
+	Create(ctx context.Context, r *models.OIDCResult) error
+	// Take deletes and returns the result with codeHash, so that each is
+	// used once.
+	Take(ctx context.Context, codeHash string) (*models.OIDCResult, error)
+}
+
+type OIDCLinkRepository interface {
+	Create(ctx context.Context, l *models.OIDCLink) error
+	GetByTokenHash(ctx context.Context, hash string) (*models.OIDCLink, error)
+	// Delete drops link id, reporting whether this call did.
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, v *models.EmailVerification) error
+	GetByTokenHash(ctx context.Context, hash string) (*models.EmailVerification, error)
+	// DeleteByUser drops the verification tokens of userID.
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, o *models.Organization) error
+	GetByID(ctx context.Context, id string) (*models.Organization, error)
+	ListByIDs(ctx context.Context, ids []string) ([]models.Organization, error)
+}
+
+type OrgMemberRepository interface {
+	// Create adds a member, returning ErrDuplicate if the user is one
+	// already.
+	Create(ctx context.Context, m *models.OrgMember) error
+	Get(ctx context.Context, orgID, userID string) (*models.OrgMember, error)
+	ListByOrg(ctx context.Context, orgID string) ([]models.OrgMember, error)
+	ListByUser(ctx context.Context, userID string) ([]models.OrgMember, error)
+	CountByRole(ctx context.Context, orgID string, role models.OrgRole) (int64, error)
+	Delete(ctx context.Context, orgID, userID string) error
+}
+
+type OrgInvitationRepository interface {
+	Create(ctx context.Context, inv *models.OrgInvitation) error
+	GetByTokenHash(ctx context.Context, hash string) (*models.OrgInvitation, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type TransferRepository interface {
+	Create(ctx context.Context, t *models.DeviceTransfer) error
+	GetByTokenHash(ctx context.Context, hash string) (*models.DeviceTransfer, error)
+	// MarkAccepted completes a pending transfer, reporting whether this call
+	// performed the transition.
+	MarkAccepted(ctx context.Context, id, toUserID string) (bool, error)
+}
+
+type ShareRepository interface {
+	Create(ctx context.Context, share *models.DeviceShare) error
+	Get(ctx context.Context, deviceID, granteeUserID string) (*models.DeviceShare, error)
+	ListByDevice(ctx context.Context, deviceID string) ([]models.DeviceShare, error)
+	ListByGrantee(ctx context.Context, granteeUserID string) ([]models.DeviceShare, error)
+	Delete(ctx context.Context, deviceID, shareID string) error
+	DeleteByDevice(ctx context.Context, deviceID string) error
+}
+
+// CommandFilter selects the commands of a device, newest first. Zero
+// fields do not filter; Statuses matches any of its statuses.
+// BeforeCreatedAt/BeforeID resume after the last command of the previous
+// page.
+type CommandFilter struct {
+	DeviceID        string
+	Action          string
+	IssuedBy        string
+	Statuses        []models.CommandStatus
+	From            time.Time
+	To              time.Time
+	BeforeCreatedAt time.Time
+	BeforeID        string
+	Limit           int64
+
+	// Metadata keeps commands with all of these metadata values; keys must
+	// pass models.ValidMetadataKey.
+	Metadata map[string]string
+}
+
+type CommandRepository interface {
+	Create(ctx context.Context, cmd *models.Command) error
+	GetByID(ctx context.Context, commandID string) (*models.Command, error)
+	Find(ctx context.Context, filter CommandFilter) ([]models.Command, error)
+	// FindByBatch returns the commands of a batch, in creation order.
+	FindByBatch(ctx context.Context, batchID string) ([]models.Command, error)
+	// FindByIDs returns the commands with the given IDs that exist, in no
+	// particular order.
+	FindByIDs(ctx context.Context, commandIDs []string) ([]models.Command, error)
+	// FindExpired returns up to limit queued, pending or sent commands
+	// whose ExpiresAt is before now and that are not waiting for a retry.
+	FindExpired(ctx context.Context, now time.Time, limit int64) ([]models.Command, error)
+	// FindDueRetries returns up to limit pending commands whose
+	// NextAttemptAt is not after now.
+	FindDueRetries(ctx context.Context, now time.Time, limit int64) ([]models.Command, error)
+	// FindStale returns up to limit pending or sent commands created
+	// before before, oldest first.
+	FindStale(ctx context.Context, before time.Time, limit int64) ([]models.Command, error)
+	// FindQueued returns the queued commands of deviceID, oldest first.
+	FindQueued(ctx context.Context, deviceID string) ([]models.Command, error)
+	// Transition applies set and unset to a command that is still in the
+	// from status at the given attempt, reporting whether it was. Callers
+	// check the move is legal, see models.CommandStatus.CanTransition.
+	Transition(ctx context.Context, commandID string, from models.CommandStatus, attempts int, set map[string]any, unset []string) (bool, error)
+	Delete(ctx context.Context, commandID string) error
+}
+
+// LockRepository holds leases on named locks, so that a background job
+// runs on one server instance at a time.
+type LockRepository interface {
+	// Acquire takes or renews the lock name for owner until until, and
+	// reports whether owner holds it: false while another owner's lease
+	// has not run out.
+	Acquire(ctx context.Context, name, owner string, now, until time.Time) (bool, error)
+	// Release gives up the lock name if owner holds it.
+	Release(ctx context.Context, name, owner string) error
+}
+
+type CommandBatchRepository interface {
+	Create(ctx context.Context, b *models.CommandBatch) error
+	GetByID(ctx context.Context, id string) (*models.CommandBatch, error)
+	// SetSkipped stores the devices of a batch that got no command.
+	SetSkipped(ctx context.Context, id string, skipped []models.SkippedDevice) error
+}
+
+type CommandKeyRepository interface {
+	// Claim stores k, returning ErrDuplicate if the device already has an
+	// unexpired command with the same key.
+	Claim(ctx context.Context, k *models.CommandKey) error
+	// Get returns the unexpired key of deviceID.
+	Get(ctx context.Context, deviceID, key string) (*models.CommandKey, error)
+}
+
+type CommandScheduleRepository interface {
+	Create(ctx context.Context, s *models.CommandSchedule) error
+	GetByID(ctx context.Context, deviceID, id string) (*models.CommandSchedule, error)
+	ListByDevice(ctx context.Context, deviceID string) ([]models.CommandSchedule, error)
+	// Update stores the user-editable fields, the issuing UserID and
+	// NextRunAt of s, leaving any lease alone.
+	Update(ctx context.Context, s *models.CommandSchedule) error
+	Delete(ctx context.Context, deviceID, id string) error
+	// ClaimDue leases a schedule whose NextRunAt is not after now and that
+	// no other owner holds a lease on, to owner until until. It returns
+	// ErrNotFound when nothing is due.
+	ClaimDue(ctx context.Context, owner string, now, until time.Time) (*models.CommandSchedule, error)
+	// Release ends the lease of owner on s after a run at lastRunAt and
+	// sets NextRunAt to next, or unsets it when next is nil. NextRunAt is
+	// left alone if s was updated since it was claimed.
+	Release(ctx context.Context, s *models.CommandSchedule, owner string, lastRunAt time.Time, next *time.Time) error
+}
+
+type ScheduleRunRepository interface {
+	// Create stores r, returning ErrDuplicate if the schedule already has a
+	// run at r.ScheduledAt.
+	Create(ctx context.Context, r *models.ScheduleRun) error
+	// ListBySchedule returns the latest runs of a schedule, newest first.
+	ListBySchedule(ctx context.Context, scheduleID string, limit int64) ([]models.ScheduleRun, error)
+	DeleteBySchedule(ctx context.Context, scheduleID string) error
+}
+
+type SilenceRepository interface {
+	Create(ctx context.Context, s *models.Silence) error
+	GetByID(ctx context.Context, deviceID, id string) (*models.Silence, error)
+	ListByDevice(ctx context.Context, deviceID string) ([]models.Silence, error)
+	// ListActive returns the silences of deviceID that have not ended at
+	// now, including ones that have yet to start.
+	ListActive(ctx context.Context, deviceID string, now time.Time) ([]models.Silence, error)
+	Replace(ctx context.Context, s *models.Silence) error
+	Delete(ctx context.Context, deviceID, id string) error
+}
+
+type GroupRepository interface {
+	Create(ctx context.Context, g *models.DeviceGroup) error
+	GetByID(ctx context.Context, id string) (*models.DeviceGroup, error)
+	ListByUser(ctx context.Context, userID string) ([]models.DeviceGroup, error)
+	// Delete removes a group of userID.
+	Delete(ctx context.Context, id, userID string) error
+}
+
+type NotificationPreferenceRepository interface {
+	Get(ctx context.Context, userID string) (*models.NotificationPreference, error)
+	Upsert(ctx context.Context, p *models.NotificationPreference) error
+}
+
+// UserDeviceRepository stores the mobile app installations of users,
+// keyed by FCM token.
+type UserDeviceRepository interface {
+	// Upsert stores d, replacing any installation with its token.
+	Upsert(ctx context.Context, d *models.UserDevice) error
+	ListByUser(ctx context.Context, userID string) ([]models.UserDevice, error)
+	Delete(ctx context.Context, fcmToken string) error
+}
+
+type MQTTCredentialRepository interface {
+	Create(ctx context.Context, cred *models.MQTTCredential) error
+	// ListActive returns the credentials of deviceID not expired at now.
+	ListActive(ctx context.Context, deviceID string, now time.Time) ([]models.MQTTCredential, error)
+	// ExpireAll schedules every non-expiring credential of deviceID to
+	// expire at at.
+	ExpireAll(ctx context.Context, deviceID string, at time.Time) error
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *models.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	// GetByID returns a key of userID.
+	GetByID(ctx context.Context, userID, id string) (*models.APIKey, error)
+	ListByUser(ctx context.Context, userID string) ([]models.APIKey, error)
+	// Update applies set and unset (BSON names) to a key of userID and
+	// returns the updated key.
+	Update(ctx context.Context, userID, id string, set map[string]any, unset []string) (*models.APIKey, error)
+	// Touch records that the key was used at at.
+	Touch(ctx context.Context, id string, at time.Time) error
+	Delete(ctx context.Context, userID, id string) error
+	// DeleteByUser drops every key of userID.
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+type ScopedTokenRepository interface {
+	Create(ctx context.Context, t *models.ScopedToken) error
+	GetByID(ctx context.Context, id string) (*models.ScopedToken, error)
+	ListByUser(ctx context.Context, userID string) ([]models.ScopedToken, error)
+	// Delete removes a token of userID.
+	Delete(ctx context.Context, userID, id string) error
+}
+
+type ProvisioningTokenRepository interface {
+	Create(ctx context.Context, t *models.ProvisioningToken) error
+	// Use counts a use of token id if it has uses left and has not expired
+	// at now, returning ErrNotFound otherwise.
+	Use(ctx context.Context, id string, now time.Time) (*models.ProvisioningToken, error)
+	// Unuse gives back a use counted by Use whose provisioning failed.
+	Unuse(ctx context.Context, id string) error
+}
+
+type DeviceCredentialRepository interface {
+	Create(ctx context.Context, cred *models.DeviceCredential) error
+	GetByHash(ctx context.Context, hash string) (*models.DeviceCredential, error)
+	ListByDevice(ctx context.Context, deviceID string) ([]models.DeviceCredential, error)
+	// Revoke revokes a key of deviceID; already revoked keys are not found.
+	Revoke(ctx context.Context, deviceID, id string) error
+}
@@ -0,0 +1,115 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: api_key_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository of user API keys.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type APIKeyRepo struct {
+	coll *mongo.Collection
+}
+
+func NewAPIKeyRepository(db *mongo.Database) *APIKeyRepo {
+	return &APIKeyRepo{coll: db.Collection(APIKeysCollection)}
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, k *models.APIKey) error {
+	_, err := r.coll.InsertOne(ctx, k)
+	return err
+}
+
+func (r *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return r.findOne(ctx, bson.M{"key_hash": hash})
+}
+
+func (r *APIKeyRepo) GetByID(ctx context.Context, userID, id string) (*models.APIKey, error) {
+	return r.findOne(ctx, bson.M{"_id": id, "user_id": userID})
+}
+
+func (r *APIKeyRepo) ListByUser(ctx context.Context, userID string) ([]models.APIKey, error) {
+	cur, err := r.coll.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	keys := []models.APIKey{}
+	if err := cur.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepo) Update(ctx context.Context, userID, id string, set map[string]any, unset []string) (*models.APIKey, error) {
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		fields := bson.M{}
+		for _, k := range unset {
+			fields[k] = ""
+		}
+		update["$unset"] = fields
+	}
+	var k models.APIKey
+	err := r.coll.FindOneAndUpdate(ctx, bson.M{"_id": id, "user_id": userID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&k)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *APIKeyRepo) Touch(ctx context.Context, id string, at time.Time) error {
+	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": at}})
+	return err
+}
+
+func (r *APIKeyRepo) Delete(ctx context.Context, userID, id string) error {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *APIKeyRepo) DeleteByUser(ctx context.Context, userID string) error {
+	_, err := r.coll.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+func (r *APIKeyRepo) findOne(ctx context.Context, filter bson.M) (*models.APIKey, error) {
+	var k models.APIKey
+	err := r.coll.FindOne(ctx, filter).Decode(&k)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
@@ -0,0 +1,62 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: audit_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repository of the audit log.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type AuditRepo struct {
+	coll *mongo.Collection
+}
+
+func NewAuditRepository(db *mongo.Database) *AuditRepo {
+	return &AuditRepo{coll: db.Collection(AuditLogCollection)}
+}
+
+func (r *AuditRepo) CreateMany(ctx context.Context, entries []models.AuditEntry) error {
+	docs := make([]any, len(entries))
+	for i := range entries {
+		docs[i] = entries[i]
+	}
+	_, err := r.coll.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *AuditRepo) List(ctx context.Context, filter repository.AuditFilter) ([]models.AuditEntry, error) {
+	q := bson.M{}
+	if filter.ResourceID != "" {
+		q["resource_id"] = filter.ResourceID
+	}
+	if filter.UserID != "" {
+		q["user_id"] = filter.UserID
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(filter.Limit)
+	cur, err := r.coll.Find(ctx, q, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries := []models.AuditEntry{}
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,133 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: client.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB connection setup for the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"airsense-be.com/internal/config"
+)
+
+// Collection names shared by the repositories.
+const (
+	SensorDataCollection    = "sensor_data"
+	DevicesCollection       = "devices"
+	UsersCollection         = "users"
+	RefreshTokensCollection = "refresh_tokens"
+	TransfersCollection     = "device_transfers"
+	SharesCollection        = "device_shares"
+	CommandsCollection      = "commands"
+
+	NotificationPreferencesCollection = "notification_preferences"
+	MQTTCredentialsCollection         = "mqtt_credentials"
+	DeviceCredentialsCollection       = "device_credentials"
+	DeviceGroupsCollection            = "device_groups"
+	SilencesCollection                = "alert_silences"
+	CommandKeysCollection             = "command_idempotency_keys"
+	CommandBatchesCollection          = "command_batches"
+	APIKeysCollection                 = "api_keys"
+	CommandSchedulesCollection        = "command_schedules"
+	ScheduleRunsCollection            = "command_schedule_runs"
+	// SensorDataArchiveCollection holds the readings of decommissioned
+	// devices.
+	SensorDataArchiveCollection = "sensor_data_archive"
+	// LocksCollection holds the leader locks of background jobs that must
+	// run on a single instance.
+	LocksCollection = "locks"
+	// ProvisioningTokensCollection counts the uses of device provisioning
+	// tokens.
+	ProvisioningTokensCollection = "provisioning_tokens"
+	// UserDevicesCollection holds the mobile app installations receiving
+	// push notifications.
+	UserDevicesCollection = "user_devices"
+	// PasswordResetsCollection holds the single-use tokens of password
+	// resets.
+	PasswordResetsCollection = "password_resets"
+	// EmailVerificationsCollection holds the tokens confirming the email
+	// address of registered users.
+	EmailVerificationsCollection = "email_verifications"
+	// ScopedTokensCollection holds the device-scoped access tokens users
+	// minted, e.g. for kiosks.
+	ScopedTokensCollection = "scoped_tokens"
+	// LoginFailuresCollection counts failed logins per email address,
+	// client IP, or both.
+	LoginFailuresCollection = "login_failures"
+	// OrganizationsCollection, OrgMembersCollection and
+	// OrgInvitationsCollection hold the organizations owning devices
+	// together, their members and pending invitations.
+	OrganizationsCollection  = "organizations"
+	OrgMembersCollection     = "org_members"
+	OrgInvitationsCollection = "org_invitations"
+	// AuditLogCollection holds who changed what, see internal/audit.
+	AuditLogCollection = "audit_log"
+	// SensorAnomaliesCollection holds the readings flagged as anomalous.
+	SensorAnomaliesCollection = "sensor_anomalies"
+	// OIDCLoginsCollection, OIDCResultsCollection and OIDCLinksCollection
+	// hold the sign-ins in progress at the OpenID Connect provider, those
+	// finished there waiting for the dashboard, and the accounts waiting
+	// to be linked to an identity there.
+	OIDCLoginsCollection  = "oidc_logins"
+	OIDCResultsCollection = "oidc_results"
+	OIDCLinksCollection   = "oidc_links"
+)
+
+// Connect opens a client to the configured MongoDB deployment and pings it
+// within ConnectTimeout, so an unreachable database fails startup instead
+// of the first query.
+func Connect(ctx context.Context, cfg config.MongoDBConfig) (*mongo.Client, error) {
+	opts := options.Client().
+		ApplyURI(cfg.URI).
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetMinPoolSize(cfg.MinPoolSize).
+		SetConnectTimeout(cfg.ConnectTimeout).
+		SetServerSelectionTimeout(cfg.ServerSelectionTimeout)
+
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("connect mongodb: %w", err)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+	if err := client.Ping(pingCtx, readpref.Primary()); err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, fmt.Errorf("ping mongodb at %s within %s: %w", redactURI(cfg.URI), cfg.ConnectTimeout, err)
+	}
+	return client, nil
+}
+
+// redactURI returns uri without the user name and password, so that it
+// can be logged.
+func redactURI(uri string) string {
+	scheme := strings.Index(uri, "://")
+	if scheme < 0 {
+		return uri
+	}
+	rest := uri[scheme+3:]
+	hosts := rest
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		hosts = rest[:i]
+	}
+	// Passwords may hold an escaped @, never a raw one, but be lenient
+	// with URIs the driver would refuse anyway.
+	at := strings.LastIndexByte(hosts, '@')
+	if at < 0 {
+		return uri
+	}
+	return uri[:scheme+3] + "xxxxx@" + rest[at+1:]
+}
@@ -0,0 +1,59 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_batch_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository for bulk commands in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type CommandBatchRepo struct {
+	coll *mongo.Collection
+}
+
+func NewCommandBatchRepository(db *mongo.Database) *CommandBatchRepo {
+	return &CommandBatchRepo{coll: db.Collection(CommandBatchesCollection)}
+}
+
+func (r *CommandBatchRepo) Create(ctx context.Context, b *models.CommandBatch) error {
+	_, err := r.coll.InsertOne(ctx, b)
+	return err
+}
+
+func (r *CommandBatchRepo) GetByID(ctx context.Context, id string) (*models.CommandBatch, error) {
+	var b models.CommandBatch
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&b)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *CommandBatchRepo) SetSkipped(ctx context.Context, id string, skipped []models.SkippedDevice) error {
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"skipped": skipped}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_key_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository of command idempotency keys.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// CommandKeyRepo stores idempotency keys in a TTL collection. The TTL
+// monitor only runs once a minute, so expiry is also checked on read and
+// an expired key left behind is replaced on Claim.
+type CommandKeyRepo struct {
+	coll *mongo.Collection
+}
+
+func NewCommandKeyRepository(db *mongo.Database) *CommandKeyRepo {
+	return &CommandKeyRepo{coll: db.Collection(CommandKeysCollection)}
+}
+
+func (r *CommandKeyRepo) Claim(ctx context.Context, k *models.CommandKey) error {
+	// Matches only an expired key; otherwise the upsert inserts and the
+	// unique index rejects a key that is still live.
+	filter := bson.M{
+		"device_id":  k.DeviceID,
+		"key":        k.Key,
+		"created_at": bson.M{"$lte": k.CreatedAt.Add(-models.CommandKeyTTL)},
+	}
+	_, err := r.coll.ReplaceOne(ctx, filter, k, options.Replace().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+func (r *CommandKeyRepo) Get(ctx context.Context, deviceID, key string) (*models.CommandKey, error) {
+	var k models.CommandKey
+	err := r.coll.FindOne(ctx, bson.M{
+		"device_id":  deviceID,
+		"key":        key,
+		"created_at": bson.M{"$gt": time.Now().Add(-models.CommandKeyTTL)},
+	}).Decode(&k)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
@@ -0,0 +1,194 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository for commands in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type CommandRepo struct {
+	coll *mongo.Collection
+}
+
+func NewCommandRepository(db *mongo.Database) *CommandRepo {
+	return &CommandRepo{coll: db.Collection(CommandsCollection)}
+}
+
+func (r *CommandRepo) Create(ctx context.Context, cmd *models.Command) error {
+	_, err := r.coll.InsertOne(ctx, cmd)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+func (r *CommandRepo) GetByID(ctx context.Context, commandID string) (*models.Command, error) {
+	var cmd models.Command
+	err := r.coll.FindOne(ctx, bson.M{"command_id": commandID}).Decode(&cmd)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cmd, nil
+}
+
+func (r *CommandRepo) Find(ctx context.Context, filter repository.CommandFilter) ([]models.Command, error) {
+	q := bson.M{"device_id": filter.DeviceID}
+	if filter.Action != "" {
+		q["action"] = filter.Action
+	}
+	if filter.IssuedBy != "" {
+		q["issued_by"] = filter.IssuedBy
+	}
+	if len(filter.Statuses) > 0 {
+		q["status"] = bson.M{"$in": filter.Statuses}
+	}
+	for k, v := range filter.Metadata {
+		q["metadata."+k] = v
+	}
+	created := bson.M{}
+	if !filter.From.IsZero() {
+		created["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		created["$lte"] = filter.To
+	}
+	if len(created) > 0 {
+		q["created_at"] = created
+	}
+	if !filter.BeforeCreatedAt.IsZero() {
+		q["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": filter.BeforeCreatedAt}},
+			bson.M{"created_at": filter.BeforeCreatedAt, "command_id": bson.M{"$lt": filter.BeforeID}},
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "command_id", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+	cur, err := r.coll.Find(ctx, q, opts)
+	if err != nil {
+		return nil, err
+	}
+	cmds := []models.Command{}
+	if err := cur.All(ctx, &cmds); err != nil {
+		return nil, err
+	}
+	return cmds, nil
+}
+
+func (r *CommandRepo) FindByBatch(ctx context.Context, batchID string) ([]models.Command, error) {
+	cur, err := r.coll.Find(ctx, bson.M{"batch_id": batchID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "command_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	cmds := []models.Command{}
+	if err := cur.All(ctx, &cmds); err != nil {
+		return nil, err
+	}
+	return cmds, nil
+}
+
+func (r *CommandRepo) FindByIDs(ctx context.Context, commandIDs []string) ([]models.Command, error) {
+	cur, err := r.coll.Find(ctx, bson.M{"command_id": bson.M{"$in": commandIDs}})
+	if err != nil {
+		return nil, err
+	}
+	cmds := []models.Command{}
+	if err := cur.All(ctx, &cmds); err != nil {
+		return nil, err
+	}
+	return cmds, nil
+}
+
+func (r *CommandRepo) FindExpired(ctx context.Context, now time.Time, limit int64) ([]models.Command, error) {
+	return r.findPending(ctx, bson.M{
+		"status":          bson.M{"$in": bson.A{models.CommandPending, models.CommandSent, models.CommandQueued}},
+		"expires_at":      bson.M{"$lt": now},
+		"next_attempt_at": bson.M{"$exists": false},
+	}, "expires_at", limit)
+}
+
+func (r *CommandRepo) FindDueRetries(ctx context.Context, now time.Time, limit int64) ([]models.Command, error) {
+	return r.findPending(ctx, bson.M{"next_attempt_at": bson.M{"$lte": now}}, "next_attempt_at", limit)
+}
+
+func (r *CommandRepo) FindStale(ctx context.Context, before time.Time, limit int64) ([]models.Command, error) {
+	return r.findPending(ctx, bson.M{
+		"status":     bson.M{"$in": bson.A{models.CommandPending, models.CommandSent}},
+		"created_at": bson.M{"$lt": before},
+	}, "created_at", limit)
+}
+
+func (r *CommandRepo) FindQueued(ctx context.Context, deviceID string) ([]models.Command, error) {
+	return r.findPending(ctx, bson.M{"device_id": deviceID, "status": models.CommandQueued}, "created_at", 0)
+}
+
+// findPending returns the commands matching q, pending unless q says
+// otherwise, in ascending sortField order.
+func (r *CommandRepo) findPending(ctx context.Context, q bson.M, sortField string, limit int64) ([]models.Command, error) {
+	if _, ok := q["status"]; !ok {
+		q["status"] = models.CommandPending
+	}
+	cur, err := r.coll.Find(ctx, q, options.Find().SetSort(bson.D{{Key: sortField, Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	cmds := []models.Command{}
+	if err := cur.All(ctx, &cmds); err != nil {
+		return nil, err
+	}
+	return cmds, nil
+}
+
+func (r *CommandRepo) Delete(ctx context.Context, commandID string) error {
+	_, err := r.coll.DeleteOne(ctx, bson.M{"command_id": commandID})
+	return err
+}
+
+func (r *CommandRepo) Transition(ctx context.Context, commandID string, from models.CommandStatus, attempts int, set map[string]any, unset []string) (bool, error) {
+	filter := bson.M{"command_id": commandID, "status": from, "attempts": attempts}
+	if attempts == 0 {
+		// Commands stored before attempts were counted have no field.
+		filter["attempts"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	fields := bson.M{"updated_at": time.Now()}
+	for k, v := range set {
+		fields[k] = v
+	}
+	update := bson.M{"$set": fields}
+	if len(unset) > 0 {
+		fields := bson.M{}
+		for _, k := range unset {
+			fields[k] = ""
+		}
+		update["$unset"] = fields
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
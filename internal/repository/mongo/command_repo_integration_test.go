@@ -0,0 +1,181 @@
+//go:build integration
+
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_repo_integration_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the integration tests of the command history queries.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+func TestCommandRepoFind(t *testing.T) {
+	ctx := context.Background()
+	repo := NewCommandRepository(testDB(t))
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	seed := []models.Command{
+		{CommandID: "c1", DeviceID: "d1", Action: "reboot", Status: models.CommandSuccess, CreatedAt: base},
+		{CommandID: "c2", DeviceID: "d1", Action: "reboot", Status: models.CommandError, CreatedAt: base.Add(time.Hour)},
+		{CommandID: "c3", DeviceID: "d1", Action: "set_interval", Status: models.CommandSuccess, CreatedAt: base.Add(2 * time.Hour),
+			Metadata: map[string]string{"ticket": "T-1"}},
+		{CommandID: "c4", DeviceID: "d1", Action: "set_interval", Status: models.CommandPending, CreatedAt: base.Add(3 * time.Hour),
+			Metadata: map[string]string{"ticket": "T-2"}},
+		{CommandID: "c5", DeviceID: "d2", Action: "reboot", Status: models.CommandSuccess, CreatedAt: base.Add(time.Hour)},
+	}
+	for i := range seed {
+		if err := repo.Create(ctx, &seed[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter repository.CommandFilter
+		want   []string
+	}{
+		{"device only", repository.CommandFilter{DeviceID: "d1"}, []string{"c4", "c3", "c2", "c1"}},
+		{"other device", repository.CommandFilter{DeviceID: "d2"}, []string{"c5"}},
+		{"unknown device", repository.CommandFilter{DeviceID: "d9"}, []string{}},
+		{"action", repository.CommandFilter{DeviceID: "d1", Action: "reboot"}, []string{"c2", "c1"}},
+		{"status", repository.CommandFilter{DeviceID: "d1", Statuses: []models.CommandStatus{models.CommandSuccess}}, []string{"c3", "c1"}},
+		{"several statuses", repository.CommandFilter{DeviceID: "d1",
+			Statuses: []models.CommandStatus{models.CommandError, models.CommandPending}}, []string{"c4", "c2"}},
+		{"from", repository.CommandFilter{DeviceID: "d1", From: base.Add(2 * time.Hour)}, []string{"c4", "c3"}},
+		{"to", repository.CommandFilter{DeviceID: "d1", To: base.Add(time.Hour)}, []string{"c2", "c1"}},
+		{"range", repository.CommandFilter{DeviceID: "d1", From: base.Add(time.Hour), To: base.Add(2 * time.Hour)}, []string{"c3", "c2"}},
+		{"action and status", repository.CommandFilter{DeviceID: "d1", Action: "set_interval",
+			Statuses: []models.CommandStatus{models.CommandSuccess}}, []string{"c3"}},
+		{"action, status and range", repository.CommandFilter{DeviceID: "d1", Action: "reboot",
+			Statuses: []models.CommandStatus{models.CommandSuccess}, From: base.Add(time.Hour)}, []string{}},
+		{"metadata", repository.CommandFilter{DeviceID: "d1", Metadata: map[string]string{"ticket": "T-2"}}, []string{"c4"}},
+		{"limit", repository.CommandFilter{DeviceID: "d1", Limit: 2}, []string{"c4", "c3"}},
+		{"cursor", repository.CommandFilter{DeviceID: "d1", BeforeCreatedAt: base.Add(2 * time.Hour), BeforeID: "c3", Limit: 2},
+			[]string{"c2", "c1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds, err := repo.Find(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, c := range cmds {
+				got = append(got, c.CommandID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandRepoOneOpenOTA(t *testing.T) {
+	ctx := context.Background()
+	repo := NewCommandRepository(testDB(t))
+	ota := func(id, deviceID string, status models.CommandStatus) *models.Command {
+		return &models.Command{CommandID: id, DeviceID: deviceID, Action: models.ActionOTAUpdate, Status: status,
+			IssuedBy: "u1", Origin: models.OriginAPI, CreatedAt: time.Now().UTC()}
+	}
+	for _, c := range []*models.Command{
+		ota("done", "d1", models.CommandSuccess),
+		ota("failed", "d1", models.CommandError),
+		ota("open", "d1", models.CommandQueued),
+		ota("other", "d2", models.CommandSent),
+		{CommandID: "reboot", DeviceID: "d1", Action: "reboot", Status: models.CommandPending,
+			IssuedBy: "u1", Origin: models.OriginAPI, CreatedAt: time.Now().UTC()},
+	} {
+		if err := repo.Create(ctx, c); err != nil {
+			t.Fatalf("create %s: %v", c.CommandID, err)
+		}
+	}
+
+	for _, status := range []models.CommandStatus{models.CommandQueued, models.CommandPending, models.CommandSent} {
+		if err := repo.Create(ctx, ota("second-"+string(status), "d1", status)); !errors.Is(err, repository.ErrDuplicate) {
+			t.Errorf("second open update %s: err = %v, want ErrDuplicate", status, err)
+		}
+	}
+
+	// Moving through the open statuses keeps the update open; once it is
+	// final another may start.
+	for _, step := range []struct{ from, to models.CommandStatus }{
+		{models.CommandQueued, models.CommandPending},
+		{models.CommandPending, models.CommandSent},
+		{models.CommandSent, models.CommandTimedOut},
+	} {
+		ok, err := repo.Transition(ctx, "open", step.from, 0, map[string]any{"status": step.to}, nil)
+		if err != nil || !ok {
+			t.Fatalf("%s to %s: %v, %v", step.from, step.to, ok, err)
+		}
+	}
+	if err := repo.Create(ctx, ota("next", "d1", models.CommandPending)); err != nil {
+		t.Errorf("update after the last timed out: %v", err)
+	}
+}
+
+func TestCommandRepoMetadata(t *testing.T) {
+	ctx := context.Background()
+	repo := NewCommandRepository(testDB(t))
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	seed := []models.Command{
+		{CommandID: "m1", DeviceID: "d1", Action: "reboot", Status: models.CommandSuccess, CreatedAt: base,
+			Metadata: map[string]string{"ticket": "OPS-1", "team": "ops"}},
+		{CommandID: "m2", DeviceID: "d1", Action: "reboot", Status: models.CommandSuccess, CreatedAt: base.Add(time.Hour),
+			Metadata: map[string]string{"ticket": "OPS-2", "team": "ops"}},
+		{CommandID: "m3", DeviceID: "d1", Action: "reboot", Status: models.CommandSuccess, CreatedAt: base.Add(2 * time.Hour)},
+	}
+	for i := range seed {
+		if err := repo.Create(ctx, &seed[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stored, err := repo.GetByID(ctx, "m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(stored.Metadata, seed[0].Metadata) {
+		t.Errorf("stored metadata = %v, want %v", stored.Metadata, seed[0].Metadata)
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		want     []string
+	}{
+		{"one key", map[string]string{"team": "ops"}, []string{"m2", "m1"}},
+		{"all keys must match", map[string]string{"team": "ops", "ticket": "OPS-2"}, []string{"m2"}},
+		{"other value", map[string]string{"ticket": "OPS-3"}, []string{}},
+		{"unknown key", map[string]string{"customer": "acme"}, []string{}},
+		{"no filter", nil, []string{"m3", "m2", "m1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds, err := repo.Find(ctx, repository.CommandFilter{DeviceID: "d1", Metadata: tt.metadata})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, c := range cmds {
+				got = append(got, c.CommandID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
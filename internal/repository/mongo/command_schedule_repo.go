@@ -0,0 +1,174 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_schedule_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repositories of command schedules and their runs.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type CommandScheduleRepo struct {
+	coll *mongo.Collection
+}
+
+func NewCommandScheduleRepository(db *mongo.Database) *CommandScheduleRepo {
+	return &CommandScheduleRepo{coll: db.Collection(CommandSchedulesCollection)}
+}
+
+func (r *CommandScheduleRepo) Create(ctx context.Context, s *models.CommandSchedule) error {
+	_, err := r.coll.InsertOne(ctx, s)
+	return err
+}
+
+func (r *CommandScheduleRepo) GetByID(ctx context.Context, deviceID, id string) (*models.CommandSchedule, error) {
+	var s models.CommandSchedule
+	err := r.coll.FindOne(ctx, bson.M{"_id": id, "device_id": deviceID}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *CommandScheduleRepo) ListByDevice(ctx context.Context, deviceID string) ([]models.CommandSchedule, error) {
+	cur, err := r.coll.Find(ctx, bson.M{"device_id": deviceID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	schedules := []models.CommandSchedule{}
+	if err := cur.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *CommandScheduleRepo) Update(ctx context.Context, s *models.CommandSchedule) error {
+	set := bson.M{"user_id": s.UserID, "action": s.Action, "params": s.Params, "updated_at": s.UpdatedAt}
+	unset := bson.M{}
+	if s.RunAt != nil {
+		set["run_at"] = *s.RunAt
+	} else {
+		unset["run_at"] = ""
+	}
+	if s.Cron != "" {
+		set["cron"], set["timezone"] = s.Cron, s.Timezone
+	} else {
+		unset["cron"], unset["timezone"] = "", ""
+	}
+	if s.NextRunAt != nil {
+		set["next_run_at"] = *s.NextRunAt
+	} else {
+		unset["next_run_at"] = ""
+	}
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": s.ID, "device_id": s.DeviceID}, bson.M{"$set": set, "$unset": unset})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *CommandScheduleRepo) Delete(ctx context.Context, deviceID, id string) error {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": id, "device_id": deviceID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *CommandScheduleRepo) ClaimDue(ctx context.Context, owner string, now, until time.Time) (*models.CommandSchedule, error) {
+	filter := bson.M{
+		"next_run_at": bson.M{"$lte": now},
+		// Matches a missing lease as well as an expired one.
+		"lease_expires_at": bson.M{"$not": bson.M{"$gt": now}},
+	}
+	update := bson.M{"$set": bson.M{"leased_by": owner, "lease_expires_at": until}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+	var s models.CommandSchedule
+	err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *CommandScheduleRepo) Release(ctx context.Context, s *models.CommandSchedule, owner string, lastRunAt time.Time, next *time.Time) error {
+	set := bson.M{"last_run_at": lastRunAt}
+	unset := bson.M{"leased_by": "", "lease_expires_at": ""}
+	// Updated while running: the update already set the next run.
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": s.ID, "leased_by": owner, "updated_at": bson.M{"$ne": s.UpdatedAt}},
+		bson.M{"$set": set, "$unset": unset})
+	if err != nil || res.MatchedCount > 0 {
+		return err
+	}
+	if next != nil {
+		set["next_run_at"] = *next
+	} else {
+		unset["next_run_at"] = ""
+	}
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": s.ID, "leased_by": owner}, bson.M{"$set": set, "$unset": unset})
+	return err
+}
+
+type ScheduleRunRepo struct {
+	coll *mongo.Collection
+}
+
+func NewScheduleRunRepository(db *mongo.Database) *ScheduleRunRepo {
+	return &ScheduleRunRepo{coll: db.Collection(ScheduleRunsCollection)}
+}
+
+func (r *ScheduleRunRepo) Create(ctx context.Context, run *models.ScheduleRun) error {
+	_, err := r.coll.InsertOne(ctx, run)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+func (r *ScheduleRunRepo) ListBySchedule(ctx context.Context, scheduleID string, limit int64) ([]models.ScheduleRun, error) {
+	cur, err := r.coll.Find(ctx, bson.M{"schedule_id": scheduleID},
+		options.Find().SetSort(bson.D{{Key: "scheduled_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	runs := []models.ScheduleRun{}
+	if err := cur.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (r *ScheduleRunRepo) DeleteBySchedule(ctx context.Context, scheduleID string) error {
+	_, err := r.coll.DeleteMany(ctx, bson.M{"schedule_id": scheduleID})
+	return err
+}
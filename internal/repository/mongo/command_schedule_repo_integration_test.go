@@ -0,0 +1,79 @@
+//go:build integration
+
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_schedule_repo_integration_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the integration tests of updating command schedules.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+func TestCommandScheduleRepoUpdate(t *testing.T) {
+	ctx := context.Background()
+	repo := NewCommandScheduleRepository(testDB(t))
+	created := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	runAt, next := created.Add(24*time.Hour), created.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		update models.CommandSchedule
+	}{
+		{"new issuer and cron", models.CommandSchedule{
+			UserID: "u2", Action: "set_interval", Params: map[string]any{"interval": int32(60)},
+			Cron: "0 * * * *", Timezone: "Europe/Berlin", NextRunAt: &next,
+		}},
+		{"back to one shot", models.CommandSchedule{
+			UserID: "u3", Action: "reboot", Params: map[string]any{}, RunAt: &runAt, NextRunAt: &runAt,
+		}},
+		{"done", models.CommandSchedule{UserID: "u1", Action: "reboot", Params: map[string]any{}, RunAt: &runAt}},
+	}
+	s := &models.CommandSchedule{ID: "s1", DeviceID: "d1", UserID: "u1", Action: "reboot", Params: map[string]any{},
+		RunAt: &runAt, NextRunAt: &runAt, CreatedAt: created, UpdatedAt: created}
+	if err := repo.Create(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.update
+			want.ID, want.DeviceID, want.CreatedAt = "s1", "d1", created
+			want.UpdatedAt = created.Add(time.Duration(i+1) * time.Minute)
+			if err := repo.Update(ctx, &want); err != nil {
+				t.Fatal(err)
+			}
+			got, err := repo.GetByID(ctx, "d1", "s1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ts := range []**time.Time{&got.RunAt, &got.NextRunAt} {
+				if *ts != nil {
+					utc := (*ts).UTC()
+					*ts = &utc
+				}
+			}
+			got.CreatedAt, got.UpdatedAt = got.CreatedAt.UTC(), got.UpdatedAt.UTC()
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("stored %+v, want %+v", *got, want)
+			}
+		})
+	}
+
+	if err := repo.Update(ctx, &models.CommandSchedule{ID: "s1", DeviceID: "d2", UserID: "u2"}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("update on another device: err = %v, want ErrNotFound", err)
+	}
+}
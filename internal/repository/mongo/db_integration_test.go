@@ -0,0 +1,53 @@
+//go:build integration
+
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: db_integration_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB database the integration tests run against.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"airsense-be.com/internal/config"
+)
+
+// testDB returns a fresh database with the indexes of EnsureIndexes on the
+// deployment at MONGODB_TEST_URI, dropped when the test ends. Tests are
+// skipped without one.
+func testDB(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
+	ctx := context.Background()
+	client, err := Connect(ctx, config.MongoDBConfig{URI: uri, ConnectTimeout: 10 * time.Second, ServerSelectionTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database("airsense_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		if err := db.Drop(ctx); err != nil {
+			t.Errorf("drop %s: %v", db.Name(), err)
+		}
+		_ = client.Disconnect(ctx)
+	})
+	if err := EnsureIndexes(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
@@ -0,0 +1,74 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_credential_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository for device API keys in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type DeviceCredentialRepo struct {
+	coll *mongo.Collection
+}
+
+func NewDeviceCredentialRepository(db *mongo.Database) *DeviceCredentialRepo {
+	return &DeviceCredentialRepo{coll: db.Collection(DeviceCredentialsCollection)}
+}
+
+func (r *DeviceCredentialRepo) Create(ctx context.Context, cred *models.DeviceCredential) error {
+	_, err := r.coll.InsertOne(ctx, cred)
+	return err
+}
+
+func (r *DeviceCredentialRepo) GetByHash(ctx context.Context, hash string) (*models.DeviceCredential, error) {
+	var cred models.DeviceCredential
+	err := r.coll.FindOne(ctx, bson.M{"key_hash": hash}).Decode(&cred)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+func (r *DeviceCredentialRepo) ListByDevice(ctx context.Context, deviceID string) ([]models.DeviceCredential, error) {
+	cur, err := r.coll.Find(ctx, bson.M{"device_id": deviceID})
+	if err != nil {
+		return nil, err
+	}
+	creds := []models.DeviceCredential{}
+	if err := cur.All(ctx, &creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+func (r *DeviceCredentialRepo) Revoke(ctx context.Context, deviceID, id string) error {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "device_id": deviceID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
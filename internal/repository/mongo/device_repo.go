@@ -0,0 +1,318 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repository for devices in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// earthRadiusKm turns distances into the radians $centerSphere takes.
+const earthRadiusKm = 6378.1
+
+type DeviceRepo struct {
+	coll *mongo.Collection
+}
+
+func NewDeviceRepository(db *mongo.Database) *DeviceRepo {
+	return &DeviceRepo{coll: db.Collection(DevicesCollection)}
+}
+
+func (r *DeviceRepo) GetByID(ctx context.Context, id string) (*models.Device, error) {
+	var d models.Device
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *DeviceRepo) List(ctx context.Context, filter repository.DeviceFilter) ([]models.Device, error) {
+	and := deviceQuery(filter)
+	if filter.After != nil {
+		and = append(and, keysetAfter(filter.SortField, filter.Desc, filter.After))
+	}
+	order := 1
+	if filter.Desc {
+		order = -1
+	}
+	sort := bson.D{{Key: "_id", Value: order}}
+	if filter.SortField != "" {
+		sort = bson.D{{Key: filter.SortField, Value: order}, {Key: "_id", Value: order}}
+	}
+	opts := options.Find().SetSort(sort).SetLimit(filter.Limit)
+	if hint := deviceHint(filter); hint != nil {
+		opts.SetHint(hint)
+	}
+	cur, err := r.coll.Find(ctx, bson.M{"$and": and}, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := []models.Device{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *DeviceRepo) Count(ctx context.Context, filter repository.DeviceFilter) (int64, error) {
+	opts := options.Count()
+	if hint := deviceHint(filter); hint != nil {
+		opts.SetHint(hint)
+	}
+	return r.coll.CountDocuments(ctx, bson.M{"$and": deviceQuery(filter)}, opts)
+}
+
+// deviceHint picks the tags wildcard index for tag searches in ID order.
+// The planner would otherwise favour the user_id indexes, which scan every
+// device of the user; a sorted search keeps its sort index.
+func deviceHint(filter repository.DeviceFilter) any {
+	if len(filter.Tags) == 0 || filter.SortField != "" {
+		return nil
+	}
+	return bson.D{{Key: "tags.$**", Value: 1}}
+}
+
+func (r *DeviceRepo) Touch(ctx context.Context, id string, at time.Time) error {
+	filter := notDeleted()
+	filter["_id"] = id
+	filter["status"] = bson.M{"$ne": models.DeviceDecommissioned}
+	_, err := r.coll.UpdateOne(ctx, filter,
+		bson.M{"$set": bson.M{"last_seen_at": at, "status": models.DeviceOnline}})
+	return err
+}
+
+func (r *DeviceRepo) SetStatus(ctx context.Context, id string, status models.DeviceStatus, at time.Time) error {
+	filter := notDeleted()
+	filter["_id"] = id
+	filter["status"] = bson.M{"$ne": models.DeviceDecommissioned}
+	res, err := r.coll.UpdateOne(ctx, filter,
+		bson.M{"$set": bson.M{"status": status, "last_seen_at": at, "updated_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func deviceQuery(filter repository.DeviceFilter) bson.A {
+	owner := bson.M{"user_id": filter.UserID}
+	if len(filter.SharedIDs) > 0 {
+		owner = bson.M{"$or": bson.A{
+			bson.M{"user_id": filter.UserID},
+			bson.M{"_id": bson.M{"$in": filter.SharedIDs}},
+		}}
+	}
+	if filter.AllUsers {
+		owner = bson.M{}
+	}
+	if filter.OrgID != "" {
+		owner = bson.M{"org_id": filter.OrgID}
+	}
+	and := bson.A{owner, notDeleted()}
+	if !filter.IncludeDecommissioned {
+		and = append(and, bson.M{"status": bson.M{"$ne": models.DeviceDecommissioned}})
+	}
+	if filter.Name != "" {
+		and = append(and, bson.M{"name": primitive.Regex{Pattern: regexp.QuoteMeta(filter.Name), Options: "i"}})
+	}
+	if filter.Location != "" {
+		and = append(and, bson.M{"location": filter.Location})
+	}
+	if filter.Status != "" {
+		and = append(and, bson.M{"status": filter.Status})
+	}
+	if filter.Firmware != "" {
+		and = append(and, bson.M{"firmware_version": filter.Firmware})
+	}
+	for k, v := range filter.Tags {
+		and = append(and, bson.M{"tags." + k: v})
+	}
+	if filter.Near != nil {
+		// $geoWithin, unlike $near, leaves the sort order alone.
+		and = append(and, bson.M{"coordinates": bson.M{"$geoWithin": bson.M{
+			"$centerSphere": bson.A{filter.Near.Coordinates, filter.RadiusKm / earthRadiusKm},
+		}}})
+	}
+	return and
+}
+
+// keysetAfter matches the devices that sort after the cursor. Missing
+// values sort lowest, so they come first ascending and last descending.
+func keysetAfter(field string, desc bool, after *repository.DeviceCursor) bson.M {
+	cmp := "$gt"
+	if desc {
+		cmp = "$lt"
+	}
+	if field == "" {
+		return bson.M{"_id": bson.M{cmp: after.ID}}
+	}
+	tie := bson.M{field: after.Value, "_id": bson.M{cmp: after.ID}}
+	switch {
+	case after.Value == nil && desc:
+		return tie
+	case after.Value == nil:
+		return bson.M{"$or": bson.A{tie, bson.M{field: bson.M{"$ne": nil}}}}
+	case desc:
+		return bson.M{"$or": bson.A{bson.M{field: bson.M{cmp: after.Value}}, bson.M{field: nil}, tie}}
+	default:
+		return bson.M{"$or": bson.A{bson.M{field: bson.M{cmp: after.Value}}, tie}}
+	}
+}
+
+func (r *DeviceRepo) Create(ctx context.Context, d *models.Device) error {
+	_, err := r.coll.InsertOne(ctx, d)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+func (r *DeviceRepo) CreateMany(ctx context.Context, devices []*models.Device) error {
+	if len(devices) == 0 {
+		return nil
+	}
+	docs := make([]any, len(devices))
+	for i, d := range devices {
+		docs[i] = d
+	}
+	_, err := r.coll.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *DeviceRepo) SoftDelete(ctx context.Context, id string) error {
+	now := time.Now()
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *DeviceRepo) Restore(ctx context.Context, id, name, location string) (*models.Device, error) {
+	var d models.Device
+	err := r.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$exists": true}},
+		bson.M{
+			"$set":   bson.M{"name": name, "location": location, "updated_at": time.Now()},
+			"$unset": bson.M{"deleted_at": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *DeviceRepo) Update(ctx context.Context, id string, fields map[string]any) (*models.Device, error) {
+	set := bson.M{"updated_at": time.Now()}
+	for k, v := range fields {
+		set[k] = v
+	}
+	filter := notDeleted()
+	filter["_id"] = id
+	var d models.Device
+	err := r.coll.FindOneAndUpdate(ctx, filter, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *DeviceRepo) Unset(ctx context.Context, id string, fields []string) (*models.Device, error) {
+	unset := bson.M{}
+	for _, f := range fields {
+		unset[f] = ""
+	}
+	filter := notDeleted()
+	filter["_id"] = id
+	var d models.Device
+	err := r.coll.FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"updated_at": time.Now()}, "$unset": unset},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *DeviceRepo) Decommission(ctx context.Context, id string, at time.Time) (*models.Device, error) {
+	filter := notDeleted()
+	filter["_id"] = id
+	filter["status"] = bson.M{"$ne": models.DeviceDecommissioned}
+	var d models.Device
+	err := r.coll.FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"status": models.DeviceDecommissioned, "decommissioned_at": at, "updated_at": at}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *DeviceRepo) Delete(ctx context.Context, id string) error {
+	_, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *DeviceRepo) ChangeOwner(ctx context.Context, id, fromUserID, toUserID string) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": fromUserID, "deleted_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"user_id": toUserID, "updated_at": time.Now()}, "$unset": bson.M{"org_id": ""}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func notDeleted() bson.M {
+	return bson.M{"deleted_at": bson.M{"$exists": false}}
+}
@@ -0,0 +1,313 @@
+//go:build integration
+
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_repo_integration_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the integration tests of the device repository.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+func TestDeviceRepoSetStatus(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeviceRepository(testDB(t))
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	seed := []*models.Device{
+		{ID: "live", UserID: "u1", Status: models.DeviceOffline},
+		{ID: "retired", UserID: "u1", Status: models.DeviceOffline},
+		{ID: "deleted", UserID: "u1", Status: models.DeviceOffline},
+	}
+	for _, d := range seed {
+		if err := repo.Create(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.Decommission(ctx, "retired", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SoftDelete(ctx, "deleted"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id         string
+		wantErr    error
+		wantStatus models.DeviceStatus
+	}{
+		{"live", nil, models.DeviceOnline},
+		{"retired", repository.ErrNotFound, models.DeviceDecommissioned},
+		{"deleted", repository.ErrNotFound, models.DeviceOffline},
+		{"missing", repository.ErrNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if err := repo.SetStatus(ctx, tt.id, models.DeviceOnline, now); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			d, err := repo.GetByID(ctx, tt.id)
+			if tt.wantStatus == "" {
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", d.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDeviceRepoChangeOwner(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeviceRepository(testDB(t))
+	seed := []*models.Device{
+		{ID: "personal", UserID: "u1", Status: models.DeviceOnline},
+		{ID: "org", UserID: "u1", OrgID: "o1", Status: models.DeviceOnline},
+		{ID: "other", UserID: "u3", OrgID: "o1", Status: models.DeviceOnline},
+		{ID: "deleted", UserID: "u1", OrgID: "o1", Status: models.DeviceOffline},
+	}
+	for _, d := range seed {
+		if err := repo.Create(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.SoftDelete(ctx, "deleted"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id        string
+		wantMoved bool
+		wantOwner string
+		wantOrg   string
+	}{
+		{"personal", true, "u2", ""},
+		{"org", true, "u2", ""},
+		{"other", false, "u3", "o1"},
+		{"deleted", false, "u1", "o1"},
+		{"missing", false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			moved, err := repo.ChangeOwner(ctx, tt.id, "u1", "u2")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if moved != tt.wantMoved {
+				t.Errorf("moved = %v, want %v", moved, tt.wantMoved)
+			}
+			d, err := repo.GetByID(ctx, tt.id)
+			if tt.wantOwner == "" {
+				if !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("err = %v, want not found", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.UserID != tt.wantOwner || d.OrgID != tt.wantOrg {
+				t.Errorf("owner %q in org %q, want %q in %q", d.UserID, d.OrgID, tt.wantOwner, tt.wantOrg)
+			}
+		})
+	}
+}
+
+func TestDeviceRepoListPages(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeviceRepository(testDB(t))
+	t0 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	seen := func(h int) *time.Time {
+		at := t0.Add(time.Duration(h) * time.Hour)
+		return &at
+	}
+	// Names and last-seen times repeat, and some devices were never seen.
+	for _, d := range []*models.Device{
+		{ID: "d3", UserID: "u1", Name: "Kitchen", LastSeenAt: seen(1)},
+		{ID: "d1", UserID: "u1", Name: "Bedroom"},
+		{ID: "d5", UserID: "u1", Name: "Kitchen", LastSeenAt: seen(1)},
+		{ID: "d2", UserID: "u1", Name: "Kitchen"},
+		{ID: "d4", UserID: "u1", Name: "Attic", LastSeenAt: seen(2)},
+		{ID: "d6", UserID: "u2", Name: "Attic"},
+	} {
+		if err := repo.Create(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cursor := func(field string, d models.Device) *repository.DeviceCursor {
+		c := &repository.DeviceCursor{ID: d.ID}
+		switch field {
+		case "name":
+			c.Value = d.Name
+		case "last_seen_at":
+			if d.LastSeenAt != nil {
+				c.Value = *d.LastSeenAt
+			}
+		}
+		return c
+	}
+	tests := []struct {
+		field string
+		desc  bool
+		want  []string
+	}{
+		{"", false, []string{"d1", "d2", "d3", "d4", "d5"}},
+		{"", true, []string{"d5", "d4", "d3", "d2", "d1"}},
+		{"name", false, []string{"d4", "d1", "d2", "d3", "d5"}},
+		{"name", true, []string{"d5", "d3", "d2", "d1", "d4"}},
+		{"last_seen_at", false, []string{"d1", "d2", "d3", "d5", "d4"}},
+		{"last_seen_at", true, []string{"d4", "d5", "d3", "d2", "d1"}},
+	}
+	for _, tt := range tests {
+		name := tt.field
+		if tt.desc {
+			name = "-" + name
+		}
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			filter := repository.DeviceFilter{UserID: "u1", SortField: tt.field, Desc: tt.desc, Limit: 2}
+			for range 5 {
+				page, err := repo.List(ctx, filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(page) == 0 {
+					break
+				}
+				for _, d := range page {
+					got = append(got, d.ID)
+				}
+				filter.After = cursor(tt.field, page[len(page)-1])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceRepoListFilters(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeviceRepository(testDB(t))
+	for _, d := range []*models.Device{
+		{ID: "d1", UserID: "u1", Name: "Kitchen", Location: "Hanoi", Status: models.DeviceOnline, FirmwareVersion: "1.2.0",
+			Tags: map[string]string{"floor": "2", "wing": "east"}},
+		{ID: "d2", UserID: "u1", Name: "Kitchen annex", Location: "Hanoi", Status: models.DeviceOffline, FirmwareVersion: "1.2.0",
+			Tags: map[string]string{"floor": "2", "wing": "west"}},
+		{ID: "d3", UserID: "u1", Name: "Bedroom", Location: "Hue", Status: models.DeviceOnline, FirmwareVersion: "1.1.0",
+			Tags: map[string]string{"floor": "1"}},
+		{ID: "d4", UserID: "u1", Name: "Old kitchen", Location: "Hanoi", Status: models.DeviceDecommissioned, FirmwareVersion: "1.2.0"},
+		{ID: "d5", UserID: "u2", Name: "Kitchen", Location: "Hanoi", Status: models.DeviceOnline, FirmwareVersion: "1.2.0"},
+	} {
+		if err := repo.Create(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name   string
+		filter repository.DeviceFilter
+		want   []string
+	}{
+		{"name", repository.DeviceFilter{Name: "KITCHEN"}, []string{"d1", "d2"}},
+		{"name and status", repository.DeviceFilter{Name: "kitchen", Status: models.DeviceOnline}, []string{"d1"}},
+		{"location and firmware", repository.DeviceFilter{Location: "Hanoi", Firmware: "1.2.0"}, []string{"d1", "d2"}},
+		{"tags", repository.DeviceFilter{Tags: map[string]string{"floor": "2", "wing": "west"}}, []string{"d2"}},
+		{"name, location, firmware and tag", repository.DeviceFilter{Name: "kit", Location: "Hanoi", Firmware: "1.2.0",
+			Tags: map[string]string{"wing": "east"}}, []string{"d1"}},
+		{"with decommissioned", repository.DeviceFilter{Name: "kitchen", IncludeDecommissioned: true}, []string{"d1", "d2", "d4"}},
+		{"regexp characters are literal", repository.DeviceFilter{Name: "kit.*"}, []string{}},
+		{"nothing matches", repository.DeviceFilter{Name: "kitchen", Location: "Hue"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.UserID = "u1"
+			devices, err := repo.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if devices == nil {
+				t.Fatal("List() = nil, want an empty slice")
+			}
+			got := []string{}
+			for _, d := range devices {
+				got = append(got, d.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("devices = %v, want %v", got, tt.want)
+			}
+			n, err := repo.Count(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(len(tt.want)) {
+				t.Errorf("Count() = %d, want %d", n, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestDeviceRepoListNear(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeviceRepository(testDB(t))
+	for _, d := range []*models.Device{
+		{ID: "d1", UserID: "u1", Name: "Old Quarter", Coordinates: models.NewGeoPoint(105.85, 21.03)},
+		// About 4.6 km from d1.
+		{ID: "d2", UserID: "u1", Name: "West Lake", Coordinates: models.NewGeoPoint(105.82, 21.06)},
+		{ID: "d3", UserID: "u1", Name: "Saigon", Coordinates: models.NewGeoPoint(106.66, 10.76)},
+		{ID: "d4", UserID: "u1", Name: "Somewhere", Location: "Hanoi"},
+		{ID: "d5", UserID: "u2", Name: "Neighbour", Coordinates: models.NewGeoPoint(105.85, 21.03)},
+	} {
+		if err := repo.Create(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	near := func(radiusKm float64) []string {
+		t.Helper()
+		devices, err := repo.List(ctx, repository.DeviceFilter{UserID: "u1", Near: models.NewGeoPoint(105.85, 21.03), RadiusKm: radiusKm})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, d := range devices {
+			ids = append(ids, d.ID)
+		}
+		return ids
+	}
+	for _, tt := range []struct {
+		radiusKm float64
+		want     []string
+	}{
+		{1, []string{"d1"}},
+		{5, []string{"d1", "d2"}},
+		{2000, []string{"d1", "d2", "d3"}},
+	} {
+		if got := near(tt.radiusKm); !slices.Equal(got, tt.want) {
+			t.Errorf("within %g km: %v, want %v", tt.radiusKm, got, tt.want)
+		}
+	}
+
+	var none *models.GeoPoint
+	if _, err := repo.Update(ctx, "d2", map[string]any{"coordinates": none}); err != nil {
+		t.Fatal(err)
+	}
+	if got := near(5); !slices.Equal(got, []string{"d1"}) {
+		t.Errorf("within 5 km after clearing d2: %v, want [d1]", got)
+	}
+}
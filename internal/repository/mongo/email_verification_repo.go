@@ -0,0 +1,53 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: email_verification_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repository for email verification tokens in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type EmailVerificationRepo struct {
+	coll *mongo.Collection
+}
+
+func NewEmailVerificationRepository(db *mongo.Database) *EmailVerificationRepo {
+	return &EmailVerificationRepo{coll: db.Collection(EmailVerificationsCollection)}
+}
+
+func (r *EmailVerificationRepo) Create(ctx context.Context, v *models.EmailVerification) error {
+	_, err := r.coll.InsertOne(ctx, v)
+	return err
+}
+
+func (r *EmailVerificationRepo) GetByTokenHash(ctx context.Context, hash string) (*models.EmailVerification, error) {
+	var v models.EmailVerification
+	err := r.coll.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&v)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *EmailVerificationRepo) DeleteByUser(ctx context.Context, userID string) error {
+	_, err := r.coll.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,72 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: group_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository of device groups.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type GroupRepo struct {
+	coll *mongo.Collection
+}
+
+func NewGroupRepository(db *mongo.Database) *GroupRepo {
+	return &GroupRepo{coll: db.Collection(DeviceGroupsCollection)}
+}
+
+func (r *GroupRepo) Create(ctx context.Context, g *models.DeviceGroup) error {
+	_, err := r.coll.InsertOne(ctx, g)
+	return err
+}
+
+func (r *GroupRepo) GetByID(ctx context.Context, id string) (*models.DeviceGroup, error) {
+	var g models.DeviceGroup
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&g)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (r *GroupRepo) ListByUser(ctx context.Context, userID string) ([]models.DeviceGroup, error) {
+	cur, err := r.coll.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	groups := []models.DeviceGroup{}
+	if err := cur.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *GroupRepo) Delete(ctx context.Context, id, userID string) error {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: indexes.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB index definitions of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+)
+
+// indexes lists the indexes the repositories rely on, per collection.
+var indexes = map[string][]mongo.IndexModel{
+	SensorDataCollection: {
+		{Keys: bson.D{{Key: "device_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "device_id", Value: 1}, {Key: "aqi_category", Value: 1}, {Key: "timestamp", Value: -1}}},
+	},
+	SensorDataArchiveCollection: {
+		{Keys: bson.D{{Key: "device_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+	},
+	DevicesCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_seen_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "location", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "firmware_version", Value: 1}}},
+		{Keys: bson.D{{Key: "tags.$**", Value: 1}}},
+		{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{
+			Keys: bson.D{{Key: "serial_number", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"serial_number": bson.M{"$type": "string"}}),
+		},
+	},
+	ProvisioningTokensCollection: {
+		// Tokens are dropped once expired; Use checks expiry itself as the
+		// TTL monitor only runs every minute.
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	UsersCollection: {
+		// Concurrent registrations of one address cannot both succeed.
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// An OIDC identity signs in as one user at most.
+		{
+			Keys: bson.D{{Key: "oidc_issuer", Value: 1}, {Key: "oidc_subject", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"oidc_subject": bson.M{"$exists": true}}),
+		},
+	},
+	OIDCLoginsCollection: {
+		{Keys: bson.D{{Key: "state_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// Take checks expiry itself, as for provisioning tokens.
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	OIDCResultsCollection: {
+		{Keys: bson.D{{Key: "code_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// Exchange checks expiry itself, as for provisioning tokens.
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	OIDCLinksCollection: {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// ConfirmLink checks expiry itself, as for provisioning tokens.
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	UserDevicesCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	},
+	RefreshTokensCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	},
+	PasswordResetsCollection: {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		// Reset checks expiry itself, as for provisioning tokens.
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	EmailVerificationsCollection: {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		// Verify checks expiry itself, as for provisioning tokens.
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	OrgMembersCollection: {
+		{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	},
+	OrgInvitationsCollection: {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// Accept checks expiry itself, as for provisioning tokens.
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	SilencesCollection: {
+		{Keys: bson.D{{Key: "device_id", Value: 1}, {Key: "ends_at", Value: 1}}},
+	},
+	DeviceGroupsCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}}},
+	},
+	CommandsCollection: {
+		{Keys: bson.D{{Key: "command_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "device_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "command_id", Value: -1}}},
+		{Keys: bson.D{{Key: "device_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "batch_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		// One ota_update per device may be open, see CommandService.Create.
+		{Keys: bson.D{{Key: "device_id", Value: 1}}, Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(openOTAFilter)},
+	},
+	CommandSchedulesCollection: {
+		{Keys: bson.D{{Key: "device_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "next_run_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+	},
+	ScheduleRunsCollection: {
+		{Keys: bson.D{{Key: "schedule_id", Value: 1}, {Key: "scheduled_at", Value: -1}}, Options: options.Index().SetUnique(true)},
+	},
+	APIKeysCollection: {
+		{Keys: bson.D{{Key: "key_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	},
+	ScopedTokensCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		// Expired tokens are dropped; the JWT carries its own expiry.
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	SensorAnomaliesCollection: {
+		{Keys: bson.D{{Key: "device_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+	},
+	AuditLogCollection: {
+		{Keys: bson.D{{Key: "resource_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+	},
+	// Counters are forgotten once no login failed for a while.
+	LoginFailuresCollection: {
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	CommandKeysCollection: {
+		{Keys: bson.D{{Key: "device_id", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(models.CommandKeyTTL.Seconds()))},
+	},
+}
+
+// openOTAFilter matches the ota_update commands that are not final. Partial
+// indexes take no $in before MongoDB 6.0, so the open statuses are matched
+// as the range they sort in: pending, queued and sent.
+var openOTAFilter = bson.M{"$and": bson.A{
+	bson.M{"action": models.ActionOTAUpdate},
+	bson.M{"status": bson.M{"$gte": models.CommandPending}},
+	bson.M{"status": bson.M{"$lte": models.CommandSent}},
+}}
+
+// UniqueReadingIndex allows one reading per device and timestamp, see
+// SensorRepo.Insert; detached readings have no device_id and are left out.
+// It is not among the indexes EnsureIndexes creates, which earlier
+// migrations call too: it can only be built once duplicate readings are
+// removed, so the migration doing that creates it.
+var UniqueReadingIndex = mongo.IndexModel{
+	Keys: bson.D{{Key: "device_id", Value: 1}, {Key: "timestamp", Value: 1}},
+	Options: options.Index().SetUnique(true).
+		SetPartialFilterExpression(bson.M{"device_id": bson.M{"$exists": true}}),
+}
+
+// EnsureIndexes creates any missing index. Creating an existing index is a
+// no-op; it runs from the migrations in internal/migrate.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	for coll, idx := range indexes {
+		if _, err := db.Collection(coll).Indexes().CreateMany(ctx, idx); err != nil {
+			return fmt.Errorf("create indexes on %s: %w", coll, err)
+		}
+	}
+	return nil
+}
+
+// anomalyTTLIndex is the name of the index expiring flagged readings.
+const anomalyTTLIndex = "detected_at_1"
+
+// EnsureAnomalyTTL makes flagged readings expire ttl after they were
+// flagged. The retention is configuration rather than a fixed index, so it
+// runs at every start: the index is created if missing and changed in
+// place with collMod if it expires after another time.
+func EnsureAnomalyTTL(ctx context.Context, db *mongo.Database, ttl time.Duration) error {
+	seconds := int32(ttl.Seconds())
+	if seconds < 1 {
+		return fmt.Errorf("anomaly retention %s is shorter than a second", ttl)
+	}
+	coll := db.Collection(SensorAnomaliesCollection)
+	cur, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return fmt.Errorf("list indexes on %s: %w", SensorAnomaliesCollection, err)
+	}
+	var stored []struct {
+		Name               string `bson:"name"`
+		ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+	}
+	if err := cur.All(ctx, &stored); err != nil {
+		return fmt.Errorf("list indexes on %s: %w", SensorAnomaliesCollection, err)
+	}
+	for _, idx := range stored {
+		if idx.Name != anomalyTTLIndex {
+			continue
+		}
+		if idx.ExpireAfterSeconds != nil && *idx.ExpireAfterSeconds == int64(seconds) {
+			return nil
+		}
+		err := db.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: SensorAnomaliesCollection},
+			{Key: "index", Value: bson.D{
+				{Key: "keyPattern", Value: bson.D{{Key: "detected_at", Value: 1}}},
+				{Key: "expireAfterSeconds", Value: seconds},
+			}},
+		}).Err()
+		if err != nil {
+			return fmt.Errorf("change anomaly retention: %w", err)
+		}
+		log.Printf("mongo: flagged readings now expire after %s", ttl)
+		return nil
+	}
+	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "detected_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(seconds),
+	})
+	if err != nil {
+		return fmt.Errorf("create indexes on %s: %w", SensorAnomaliesCollection, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+//go:build integration
+
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: indexes_integration_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the integration tests of the indexes the repositories rely on.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// storedIndex is an index as listIndexes returns it.
+type storedIndex struct {
+	Name   string `bson:"name"`
+	Key    bson.D `bson:"key"`
+	Unique bool   `bson:"unique"`
+}
+
+func TestEnsureIndexes(t *testing.T) {
+	ctx := context.Background()
+	db := testDB(t)
+	// testDB already created them once; a second run must be a no-op.
+	if err := EnsureIndexes(ctx, db); err != nil {
+		t.Fatalf("second EnsureIndexes: %v", err)
+	}
+
+	stored := map[string][]storedIndex{}
+	for coll := range indexes {
+		cur, err := db.Collection(coll).Indexes().List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var list []storedIndex
+		if err := cur.All(ctx, &list); err != nil {
+			t.Fatal(err)
+		}
+		stored[coll] = list
+	}
+	find := func(coll string, keys bson.D) *storedIndex {
+		for i, idx := range stored[coll] {
+			if reflect.DeepEqual(normalizeKeys(idx.Key), normalizeKeys(keys)) {
+				return &stored[coll][i]
+			}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name   string
+		coll   string
+		keys   bson.D
+		unique bool
+	}{
+		{"readings by device and time", SensorDataCollection, bson.D{{Key: "device_id", Value: 1}, {Key: "timestamp", Value: -1}}, false},
+		{"commands by device, status and age", CommandsCollection,
+			bson.D{{Key: "device_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}, false},
+		{"API keys by hash", APIKeysCollection, bson.D{{Key: "key_hash", Value: 1}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := find(tt.coll, tt.keys)
+			if idx == nil {
+				t.Fatalf("no index %v on %s", tt.keys, tt.coll)
+			}
+			if idx.Unique != tt.unique {
+				t.Errorf("unique = %v, want %v", idx.Unique, tt.unique)
+			}
+		})
+	}
+
+	for coll, models := range indexes {
+		for _, m := range models {
+			if find(coll, m.Keys.(bson.D)) == nil {
+				t.Errorf("index %v missing on %s", m.Keys, coll)
+			}
+		}
+	}
+}
+
+// normalizeKeys makes the key directions, which the server returns as
+// int32 or float64, comparable with the int ones of indexes.
+func normalizeKeys(keys bson.D) bson.D {
+	out := make(bson.D, len(keys))
+	for i, e := range keys {
+		switch v := e.Value.(type) {
+		case int32:
+			e.Value = int(v)
+		case int64:
+			e.Value = int(v)
+		case float64:
+			e.Value = int(v)
+		}
+		out[i] = e
+	}
+	return out
+}
+
+func TestEnsureAnomalyTTL(t *testing.T) {
+	ctx := context.Background()
+	db := testDB(t)
+	expiry := func() int64 {
+		t.Helper()
+		cur, err := db.Collection(SensorAnomaliesCollection).Indexes().List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var list []struct {
+			Name               string `bson:"name"`
+			ExpireAfterSeconds int64  `bson:"expireAfterSeconds"`
+		}
+		if err := cur.All(ctx, &list); err != nil {
+			t.Fatal(err)
+		}
+		for _, idx := range list {
+			if idx.Name == anomalyTTLIndex {
+				return idx.ExpireAfterSeconds
+			}
+		}
+		t.Fatal("no expiry index on flagged readings")
+		return 0
+	}
+
+	// Created on a new database, kept, then changed in place.
+	for _, ttl := range []time.Duration{90 * 24 * time.Hour, 90 * 24 * time.Hour, 7 * 24 * time.Hour} {
+		if err := EnsureAnomalyTTL(ctx, db, ttl); err != nil {
+			t.Fatalf("EnsureAnomalyTTL(%s): %v", ttl, err)
+		}
+		if got, want := expiry(), int64(ttl.Seconds()); got != want {
+			t.Errorf("after EnsureAnomalyTTL(%s): expireAfterSeconds = %d, want %d", ttl, got, want)
+		}
+	}
+	if err := EnsureAnomalyTTL(ctx, db, 0); err == nil {
+		t.Error("EnsureAnomalyTTL(0) succeeded")
+	}
+}
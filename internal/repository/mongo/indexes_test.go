@@ -0,0 +1,37 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: indexes_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the index definitions.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"testing"
+
+	"airsense-be.com/internal/models"
+)
+
+// TestOpenOTAFilterStatuses checks that the status range of openOTAFilter
+// holds exactly the statuses that are not final, so a new status cannot
+// slip in or out of the unique index unnoticed.
+func TestOpenOTAFilterStatuses(t *testing.T) {
+	statuses := []models.CommandStatus{
+		models.CommandPending, models.CommandSent, models.CommandSuccess, models.CommandError,
+		models.CommandTimedOut, models.CommandCancelled, models.CommandQueued,
+	}
+	for _, s := range statuses {
+		if !s.Valid() {
+			t.Fatalf("%q is not a valid status", s)
+		}
+		inRange := s >= models.CommandPending && s <= models.CommandSent
+		if inRange == s.Terminal() {
+			t.Errorf("%s: in the open range = %v, final = %v", s, inRange, s.Terminal())
+		}
+	}
+}
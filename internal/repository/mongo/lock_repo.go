@@ -0,0 +1,55 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: lock_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repository of leader locks for background jobs.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LockRepo keeps one document per lock, keyed by its name.
+type LockRepo struct {
+	coll *mongo.Collection
+}
+
+func NewLockRepository(db *mongo.Database) *LockRepo {
+	return &LockRepo{coll: db.Collection(LocksCollection)}
+}
+
+func (r *LockRepo) Acquire(ctx context.Context, name, owner string, now, until time.Time) (bool, error) {
+	// Matches a lock owner already holds or whose lease ran out; with
+	// upsert, a lock held by someone else makes the insert fail on the
+	// duplicate _id.
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"owner": owner},
+			bson.M{"expires_at": bson.M{"$lt": now}},
+		},
+	}
+	_, err := r.coll.UpdateOne(ctx, filter,
+		bson.M{"$set": bson.M{"owner": owner, "expires_at": until}},
+		options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *LockRepo) Release(ctx context.Context, name, owner string) error {
+	_, err := r.coll.DeleteOne(ctx, bson.M{"_id": name, "owner": owner})
+	return err
+}
@@ -0,0 +1,79 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: login_failure_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repository of failed login counters.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+)
+
+// LoginFailureRepo keeps one document per key, shared by every server
+// instance.
+type LoginFailureRepo struct {
+	coll *mongo.Collection
+}
+
+func NewLoginFailureRepository(db *mongo.Database) *LoginFailureRepo {
+	return &LoginFailureRepo{coll: db.Collection(LoginFailuresCollection)}
+}
+
+func (r *LoginFailureRepo) Attempt(ctx context.Context, key string, now, expiresAt time.Time) (*models.LoginFailures, error) {
+	// A locked document does not match, so the upsert tries to insert it
+	// again and fails on its key.
+	filter := bson.M{"_id": key, "locked_until": bson.M{"$not": bson.M{"$gt": now}}}
+	update := bson.M{"$inc": bson.M{"count": 1}, "$max": bson.M{"expires_at": expiresAt}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var f models.LoginFailures
+	err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&f)
+	if mongo.IsDuplicateKeyError(err) {
+		// Either a concurrent login inserted the document first, which
+		// matches now, or the key is locked.
+		err = r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&f)
+		if mongo.IsDuplicateKeyError(err) {
+			err = r.coll.FindOne(ctx, bson.M{"_id": key}).Decode(&f)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *LoginFailureRepo) Lock(ctx context.Context, key string, now, until time.Time, count int, expiresAt time.Time) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": key, "locked_until": bson.M{"$not": bson.M{"$gt": now}}},
+		bson.M{
+			"$set": bson.M{"locked_until": until, "count": count},
+			"$inc": bson.M{"locks": 1},
+			"$max": bson.M{"expires_at": expiresAt},
+		})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *LoginFailureRepo) Release(ctx context.Context, key string) error {
+	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": key, "count": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"count": -1}})
+	return err
+}
+
+func (r *LoginFailureRepo) Delete(ctx context.Context, keys []string) error {
+	_, err := r.coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": keys}})
+	return err
+}
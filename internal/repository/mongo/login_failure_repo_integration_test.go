@@ -0,0 +1,98 @@
+//go:build integration
+
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: login_failure_repo_integration_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the integration tests of counting failed logins.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoginFailureRepoAttempt(t *testing.T) {
+	ctx := context.Background()
+	repo := NewLoginFailureRepository(testDB(t))
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	expires := now.Add(15 * time.Minute)
+
+	// Parallel attempts each see their own count, also the ones racing
+	// to insert the document.
+	var wg sync.WaitGroup
+	seen := make([]bool, 11)
+	var mu sync.Mutex
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f, err := repo.Attempt(ctx, "k", now, expires)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if f.Count < 1 || f.Count > 10 || seen[f.Count] {
+				t.Errorf("count %d seen twice or out of range", f.Count)
+				return
+			}
+			seen[f.Count] = true
+		}()
+	}
+	wg.Wait()
+
+	until := now.Add(time.Minute)
+	tests := []struct {
+		name      string
+		now       time.Time
+		wantLock  bool
+		wantCount int
+	}{
+		{"locks", now, true, 4},
+		{"locked already", now, false, 4},
+		{"locks again once the lockout ran out", until.Add(time.Second), true, 4},
+	}
+	for _, tt := range tests {
+		locked, err := repo.Lock(ctx, "k", tt.now, until, 4, until.Add(15*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if locked != tt.wantLock {
+			t.Errorf("%s: locked = %v, want %v", tt.name, locked, tt.wantLock)
+		}
+		if tt.now.Equal(now) {
+			// While locked nothing is counted.
+			f, err := repo.Attempt(ctx, "k", now, expires)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Count != tt.wantCount || f.LockedUntil == nil || !f.LockedUntil.Equal(until) {
+				t.Errorf("%s: count %d locked until %v, want %d and %s", tt.name, f.Count, f.LockedUntil, tt.wantCount, until)
+			}
+		}
+	}
+
+	f, err := repo.Attempt(ctx, "k", until.Add(time.Second), expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Count != 5 || f.Locks != 2 {
+		t.Errorf("after the lockout: count %d, locks %d, want 5 and 2", f.Count, f.Locks)
+	}
+	if err := repo.Release(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if f, _ = repo.Attempt(ctx, "k", until.Add(time.Second), expires); f.Count != 5 {
+		t.Errorf("count %d after a release and an attempt, want 5", f.Count)
+	}
+}
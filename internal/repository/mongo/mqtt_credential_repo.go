@@ -0,0 +1,60 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: mqtt_credential_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository for per-device MQTT credentials in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"airsense-be.com/internal/models"
+)
+
+type MQTTCredentialRepo struct {
+	coll *mongo.Collection
+}
+
+func NewMQTTCredentialRepository(db *mongo.Database) *MQTTCredentialRepo {
+	return &MQTTCredentialRepo{coll: db.Collection(MQTTCredentialsCollection)}
+}
+
+func (r *MQTTCredentialRepo) Create(ctx context.Context, cred *models.MQTTCredential) error {
+	_, err := r.coll.InsertOne(ctx, cred)
+	return err
+}
+
+func (r *MQTTCredentialRepo) ListActive(ctx context.Context, deviceID string, now time.Time) ([]models.MQTTCredential, error) {
+	cur, err := r.coll.Find(ctx, bson.M{
+		"device_id": deviceID,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	creds := []models.MQTTCredential{}
+	if err := cur.All(ctx, &creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+func (r *MQTTCredentialRepo) ExpireAll(ctx context.Context, deviceID string, at time.Time) error {
+	_, err := r.coll.UpdateMany(ctx,
+		bson.M{"device_id": deviceID, "expires_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"expires_at": at}})
+	return err
+}
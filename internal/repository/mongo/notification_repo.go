@@ -0,0 +1,49 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: notification_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository for notification preferences in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type NotificationPreferenceRepo struct {
+	coll *mongo.Collection
+}
+
+func NewNotificationPreferenceRepository(db *mongo.Database) *NotificationPreferenceRepo {
+	return &NotificationPreferenceRepo{coll: db.Collection(NotificationPreferencesCollection)}
+}
+
+func (r *NotificationPreferenceRepo) Get(ctx context.Context, userID string) (*models.NotificationPreference, error) {
+	var p models.NotificationPreference
+	err := r.coll.FindOne(ctx, bson.M{"_id": userID}).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *NotificationPreferenceRepo) Upsert(ctx context.Context, p *models.NotificationPreference) error {
+	_, err := r.coll.ReplaceOne(ctx, bson.M{"_id": p.UserID}, p, options.Replace().SetUpsert(true))
+	return err
+}
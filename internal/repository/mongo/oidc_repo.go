@@ -0,0 +1,106 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: oidc_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repositories of OIDC sign-ins and account links.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type OIDCLoginRepo struct {
+	coll *mongo.Collection
+}
+
+func NewOIDCLoginRepository(db *mongo.Database) *OIDCLoginRepo {
+	return &OIDCLoginRepo{coll: db.Collection(OIDCLoginsCollection)}
+}
+
+func (r *OIDCLoginRepo) Create(ctx context.Context, l *models.OIDCLogin) error {
+	_, err := r.coll.InsertOne(ctx, l)
+	return err
+}
+
+func (r *OIDCLoginRepo) Take(ctx context.Context, stateHash string) (*models.OIDCLogin, error) {
+	var l models.OIDCLogin
+	err := r.coll.FindOneAndDelete(ctx, bson.M{"state_hash": stateHash}).Decode(&l)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+type OIDCResultRepo struct {
+	coll *mongo.Collection
+}
+
+func NewOIDCResultRepository(db *mongo.Database) *OIDCResultRepo {
+	return &OIDCResultRepo{coll: db.Collection(OIDCResultsCollection)}
+}
+
+func (r *OIDCResultRepo) Create(ctx context.Context, res *models.OIDCResult) error {
+	_, err := r.coll.InsertOne(ctx, res)
+	return err
+}
+
+func (r *OIDCResultRepo) Take(ctx context.Context, codeHash string) (*models.OIDCResult, error) {
+	var res models.OIDCResult
+	err := r.coll.FindOneAndDelete(ctx, bson.M{"code_hash": codeHash}).Decode(&res)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type OIDCLinkRepo struct {
+	coll *mongo.Collection
+}
+
+func NewOIDCLinkRepository(db *mongo.Database) *OIDCLinkRepo {
+	return &OIDCLinkRepo{coll: db.Collection(OIDCLinksCollection)}
+}
+
+func (r *OIDCLinkRepo) Create(ctx context.Context, l *models.OIDCLink) error {
+	_, err := r.coll.InsertOne(ctx, l)
+	return err
+}
+
+func (r *OIDCLinkRepo) GetByTokenHash(ctx context.Context, hash string) (*models.OIDCLink, error) {
+	var l models.OIDCLink
+	err := r.coll.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&l)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *OIDCLinkRepo) Delete(ctx context.Context, id string) (bool, error) {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount == 1, nil
+}
@@ -0,0 +1,157 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: organization_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repositories for organizations, their members and invitations.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type OrganizationRepo struct {
+	coll *mongo.Collection
+}
+
+func NewOrganizationRepository(db *mongo.Database) *OrganizationRepo {
+	return &OrganizationRepo{coll: db.Collection(OrganizationsCollection)}
+}
+
+func (r *OrganizationRepo) Create(ctx context.Context, o *models.Organization) error {
+	_, err := r.coll.InsertOne(ctx, o)
+	return err
+}
+
+func (r *OrganizationRepo) GetByID(ctx context.Context, id string) (*models.Organization, error) {
+	var o models.Organization
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&o)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *OrganizationRepo) ListByIDs(ctx context.Context, ids []string) ([]models.Organization, error) {
+	out := []models.Organization{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	cur, err := r.coll.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type OrgMemberRepo struct {
+	coll *mongo.Collection
+}
+
+func NewOrgMemberRepository(db *mongo.Database) *OrgMemberRepo {
+	return &OrgMemberRepo{coll: db.Collection(OrgMembersCollection)}
+}
+
+func (r *OrgMemberRepo) Create(ctx context.Context, m *models.OrgMember) error {
+	_, err := r.coll.InsertOne(ctx, m)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+func (r *OrgMemberRepo) Get(ctx context.Context, orgID, userID string) (*models.OrgMember, error) {
+	var m models.OrgMember
+	err := r.coll.FindOne(ctx, bson.M{"org_id": orgID, "user_id": userID}).Decode(&m)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *OrgMemberRepo) ListByOrg(ctx context.Context, orgID string) ([]models.OrgMember, error) {
+	return r.find(ctx, bson.M{"org_id": orgID})
+}
+
+func (r *OrgMemberRepo) ListByUser(ctx context.Context, userID string) ([]models.OrgMember, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+func (r *OrgMemberRepo) CountByRole(ctx context.Context, orgID string, role models.OrgRole) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.M{"org_id": orgID, "role": role})
+}
+
+func (r *OrgMemberRepo) Delete(ctx context.Context, orgID, userID string) error {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"org_id": orgID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *OrgMemberRepo) find(ctx context.Context, filter bson.M) ([]models.OrgMember, error) {
+	cur, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	out := []models.OrgMember{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type OrgInvitationRepo struct {
+	coll *mongo.Collection
+}
+
+func NewOrgInvitationRepository(db *mongo.Database) *OrgInvitationRepo {
+	return &OrgInvitationRepo{coll: db.Collection(OrgInvitationsCollection)}
+}
+
+func (r *OrgInvitationRepo) Create(ctx context.Context, inv *models.OrgInvitation) error {
+	_, err := r.coll.InsertOne(ctx, inv)
+	return err
+}
+
+func (r *OrgInvitationRepo) GetByTokenHash(ctx context.Context, hash string) (*models.OrgInvitation, error) {
+	var inv models.OrgInvitation
+	err := r.coll.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&inv)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *OrgInvitationRepo) Delete(ctx context.Context, id string) error {
+	_, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,65 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: password_reset_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repository of password reset tokens.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type PasswordResetRepo struct {
+	coll *mongo.Collection
+}
+
+func NewPasswordResetRepository(db *mongo.Database) *PasswordResetRepo {
+	return &PasswordResetRepo{coll: db.Collection(PasswordResetsCollection)}
+}
+
+func (r *PasswordResetRepo) Create(ctx context.Context, reset *models.PasswordReset) error {
+	_, err := r.coll.InsertOne(ctx, reset)
+	return err
+}
+
+func (r *PasswordResetRepo) GetByTokenHash(ctx context.Context, hash string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	err := r.coll.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&reset)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+func (r *PasswordResetRepo) MarkUsed(ctx context.Context, id string) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "used_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"used_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *PasswordResetRepo) DeleteByUser(ctx context.Context, userID string) error {
+	_, err := r.coll.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
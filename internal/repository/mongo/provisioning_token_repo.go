@@ -0,0 +1,63 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: provisioning_token_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repository of device provisioning tokens.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type ProvisioningTokenRepo struct {
+	coll *mongo.Collection
+}
+
+func NewProvisioningTokenRepository(db *mongo.Database) *ProvisioningTokenRepo {
+	return &ProvisioningTokenRepo{coll: db.Collection(ProvisioningTokensCollection)}
+}
+
+func (r *ProvisioningTokenRepo) Create(ctx context.Context, t *models.ProvisioningToken) error {
+	_, err := r.coll.InsertOne(ctx, t)
+	return err
+}
+
+func (r *ProvisioningTokenRepo) Use(ctx context.Context, id string, now time.Time) (*models.ProvisioningToken, error) {
+	// One conditional increment, so concurrent devices cannot use the
+	// token more than MaxUses times between them.
+	filter := bson.M{
+		"_id":        id,
+		"expires_at": bson.M{"$gt": now},
+		"$expr":      bson.M{"$lt": bson.A{"$uses", "$max_uses"}},
+	}
+	var t models.ProvisioningToken
+	err := r.coll.FindOneAndUpdate(ctx, filter, bson.M{"$inc": bson.M{"uses": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *ProvisioningTokenRepo) Unuse(ctx context.Context, id string) error {
+	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": id, "uses": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"uses": -1}})
+	return err
+}
@@ -0,0 +1,80 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: refresh_token_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository for refresh tokens in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type RefreshTokenRepo struct {
+	coll *mongo.Collection
+}
+
+func NewRefreshTokenRepository(db *mongo.Database) *RefreshTokenRepo {
+	return &RefreshTokenRepo{coll: db.Collection(RefreshTokensCollection)}
+}
+
+func (r *RefreshTokenRepo) Create(ctx context.Context, token *models.RefreshToken) error {
+	_, err := r.coll.InsertOne(ctx, token)
+	return err
+}
+
+func (r *RefreshTokenRepo) GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var t models.RefreshToken
+	err := r.coll.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *RefreshTokenRepo) Revoke(ctx context.Context, id, replacedBy string) (bool, error) {
+	set := bson.M{"revoked_at": time.Now()}
+	if replacedBy != "" {
+		set["replaced_by"] = replacedBy
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *RefreshTokenRepo) RevokeUser(ctx context.Context, userID string) error {
+	_, err := r.coll.UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	return err
+}
+
+func (r *RefreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.coll.UpdateMany(ctx,
+		bson.M{"family_id": familyID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	return err
+}
@@ -0,0 +1,70 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: retry.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the classification of transient MongoDB errors for retries.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/repository"
+)
+
+// maxRetryDelay caps the backoff between attempts; a longer outage is
+// better reported than waited out by the request.
+const maxRetryDelay = 2 * time.Second
+
+// NewRetryPolicy retries the transient errors of IsTransient as configured.
+func NewRetryPolicy(cfg config.MongoDBConfig) repository.RetryPolicy {
+	return repository.RetryPolicy{
+		MaxAttempts: cfg.RetryMaxAttempts,
+		BaseDelay:   cfg.RetryBaseDelay,
+		MaxDelay:    maxRetryDelay,
+		Retryable:   IsTransient,
+	}
+}
+
+// retryableCodes are the server error codes the driver itself retries
+// reads and writes on: shutdowns, step-downs and host or network failures.
+var retryableCodes = []int{6, 7, 89, 91, 189, 262, 9001, 10107, 11600, 11602, 13435, 13436}
+
+// IsTransient reports whether err is likely to go away on retry: a network
+// error, no server being selectable, a full connection pool, or a server
+// error the driver labels or codes as retryable. Context errors are not,
+// and neither are server-side time limits, which a retry would hit again.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if mongo.IsNetworkError(err) ||
+		errors.As(err, &topology.ServerSelectionError{}) ||
+		errors.As(err, &topology.WaitQueueTimeoutError{}) {
+		return true
+	}
+	var le mongo.LabeledError
+	if errors.As(err, &le) && (le.HasErrorLabel("RetryableWriteError") || le.HasErrorLabel("TransientTransactionError")) {
+		return true
+	}
+	var se mongo.ServerError
+	if errors.As(err, &se) {
+		for _, code := range retryableCodes {
+			if se.HasErrorCode(code) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: scoped_token_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repository of device-scoped access tokens.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type ScopedTokenRepo struct {
+	coll *mongo.Collection
+}
+
+func NewScopedTokenRepository(db *mongo.Database) *ScopedTokenRepo {
+	return &ScopedTokenRepo{coll: db.Collection(ScopedTokensCollection)}
+}
+
+func (r *ScopedTokenRepo) Create(ctx context.Context, t *models.ScopedToken) error {
+	_, err := r.coll.InsertOne(ctx, t)
+	return err
+}
+
+func (r *ScopedTokenRepo) GetByID(ctx context.Context, id string) (*models.ScopedToken, error) {
+	var t models.ScopedToken
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *ScopedTokenRepo) ListByUser(ctx context.Context, userID string) ([]models.ScopedToken, error) {
+	cur, err := r.coll.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	tokens := []models.ScopedToken{}
+	if err := cur.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *ScopedTokenRepo) Delete(ctx context.Context, userID, id string) error {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
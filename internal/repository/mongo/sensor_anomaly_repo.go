@@ -0,0 +1,45 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_anomaly_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repository of flagged sensor readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+)
+
+type SensorAnomalyRepo struct {
+	coll *mongo.Collection
+}
+
+func NewSensorAnomalyRepository(db *mongo.Database) *SensorAnomalyRepo {
+	return &SensorAnomalyRepo{coll: db.Collection(SensorAnomaliesCollection)}
+}
+
+func (r *SensorAnomalyRepo) Upsert(ctx context.Context, anomalies []models.SensorAnomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, len(anomalies))
+	for i := range anomalies {
+		writes[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": anomalies[i].ID}).
+			SetReplacement(anomalies[i]).
+			SetUpsert(true)
+	}
+	_, err := r.coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
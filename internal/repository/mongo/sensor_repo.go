@@ -0,0 +1,472 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository for sensor data in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// SensorRepo retries writes and queries on transient errors as retry
+// allows. A device has at most one reading per timestamp: writes upsert on
+// device_id and timestamp, so a replayed reading replaces the stored one
+// and a retried write cannot store a reading twice. Stream, deletes and
+// archiving are not retried.
+type SensorRepo struct {
+	coll  *mongo.Collection
+	retry repository.RetryPolicy
+	// noPercentile is set once the server turned out not to know
+	// $percentile, see Stats.
+	noPercentile atomic.Bool
+}
+
+func NewSensorRepository(db *mongo.Database, retry repository.RetryPolicy) *SensorRepo {
+	return &SensorRepo{coll: db.Collection(SensorDataCollection), retry: retry}
+}
+
+// Insert stores data, or replaces the reading of its device at its
+// timestamp and sets data.ID to that reading's.
+func (r *SensorRepo) Insert(ctx context.Context, data *models.SensorData) error {
+	if data.ID == "" {
+		data.ID = primitive.NewObjectID().Hex()
+	}
+	update, err := sensorUpsert(data)
+	if err != nil {
+		return err
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetProjection(bson.M{"_id": 1})
+	var prior struct {
+		ID string `bson:"_id"`
+	}
+	err = r.retry.Do(ctx, func(int) error {
+		err := r.coll.FindOneAndUpdate(ctx, sensorKey(data), update, opts).Decode(&prior)
+		if mongo.IsDuplicateKeyError(err) {
+			// A concurrent write inserted the reading first; replace it.
+			err = r.coll.FindOneAndUpdate(ctx, sensorKey(data), update, opts).Decode(&prior)
+		}
+		return err
+	})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// An earlier attempt may have stored data already.
+	if prior.ID != data.ID {
+		metrics.ReadingsReplaced.Add(1)
+		data.ID = prior.ID
+	}
+	return nil
+}
+
+// InsertMany stores data in order, each like Insert, except that the IDs
+// of replaced readings are not updated.
+func (r *SensorRepo) InsertMany(ctx context.Context, data []*models.SensorData) error {
+	if len(data) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, len(data))
+	for i, d := range data {
+		if d.ID == "" {
+			d.ID = primitive.NewObjectID().Hex()
+		}
+		update, err := sensorUpsert(d)
+		if err != nil {
+			return err
+		}
+		writes[i] = mongo.NewUpdateOneModel().SetFilter(sensorKey(d)).SetUpdate(update).SetUpsert(true)
+	}
+	var res *mongo.BulkWriteResult
+	err := r.retry.Do(ctx, func(int) error {
+		var err error
+		res, err = r.coll.BulkWrite(ctx, writes)
+		if mongo.IsDuplicateKeyError(err) {
+			// The batch stopped at a reading a concurrent write inserted
+			// first; every write is an upsert, so it can run again.
+			res, err = r.coll.BulkWrite(ctx, writes)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// Readings an earlier attempt stored are counted too.
+	metrics.ReadingsReplaced.Add(res.MatchedCount)
+	return nil
+}
+
+// sensorKey selects the reading of the device of d at its timestamp.
+func sensorKey(d *models.SensorData) bson.M {
+	return bson.M{"device_id": d.DeviceID, "timestamp": d.Timestamp}
+}
+
+// sensorOptional are the fields of a reading that are left out when
+// empty. A replay lacking them removes them from the stored reading.
+var sensorOptional = []string{"source", "schema_version", "aqi_category", "quality"}
+
+// sensorUpsert replaces the reading at the timestamp of d with d, inserting
+// it under d.ID if there is none. Its sensors are replaced whole, and the
+// optional fields d lacks are removed; the stored reading keeps only its
+// ID and the tags and prior owner d lacks, which were added to it since.
+func sensorUpsert(d *models.SensorData) (bson.M, error) {
+	raw, err := bson.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var fields bson.D
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	set := make(bson.D, 0, len(fields))
+	present := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		present[f.Key] = true
+		if f.Key != "_id" {
+			set = append(set, f)
+		}
+	}
+	update := bson.M{"$set": set, "$setOnInsert": bson.M{"_id": d.ID}}
+	unset := bson.M{}
+	for _, key := range sensorOptional {
+		if !present[key] {
+			unset[key] = ""
+		}
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update, nil
+}
+
+// Find returns the readings matching filter, newest first.
+func (r *SensorRepo) Find(ctx context.Context, filter repository.SensorFilter) ([]models.SensorData, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+	var out []models.SensorData
+	err := r.retry.Do(ctx, func(int) error {
+		cur, err := r.coll.Find(ctx, sensorQuery(filter), opts)
+		if err != nil {
+			return err
+		}
+		out = []models.SensorData{}
+		return cur.All(ctx, &out)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *SensorRepo) Stream(ctx context.Context, filter repository.SensorFilter, fn func(*models.SensorData) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+	cur, err := r.coll.Find(ctx, sensorQuery(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var d models.SensorData
+		if err := cur.Decode(&d); err != nil {
+			return err
+		}
+		if err := fn(&d); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+func (r *SensorRepo) Latest(ctx context.Context, deviceIDs []string) ([]models.SensorData, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"device_id": bson.M{"$in": deviceIDs}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "device_id", Value: 1}, {Key: "timestamp", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$device_id", "doc": bson.M{"$first": "$$ROOT"}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$doc"}}},
+	}
+	var out []models.SensorData
+	err := r.retry.Do(ctx, func(int) error {
+		cur, err := r.coll.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		out = []models.SensorData{}
+		return cur.All(ctx, &out)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *SensorRepo) Aggregate(ctx context.Context, filter repository.SensorFilter, unit, timezone string, weighted bool) ([]models.SensorAggregate, error) {
+	group := bson.M{
+		"_id": bson.M{"$dateTrunc": bson.M{
+			"date":     "$timestamp",
+			"unit":     unit,
+			"timezone": timezone,
+		}},
+		"count": bson.M{"$sum": 1},
+	}
+	fields := bson.M{}
+	for name := range (models.Sensors{}).Fields() {
+		path := "$sensors." + name + ".value"
+		group[name+"_min"] = bson.M{"$min": path}
+		group[name+"_max"] = bson.M{"$max": path}
+		var avg any = "$" + name + "_avg"
+		if weighted {
+			// Readings without the field weigh nothing, so that a bucket
+			// of none has no average rather than 0.
+			weight := bson.M{"$cond": bson.A{
+				bson.M{"$isNumber": path},
+				bson.M{"$ifNull": bson.A{"$sensors." + name + ".confidence", 1}},
+				0,
+			}}
+			group[name+"_wsum"] = bson.M{"$sum": bson.M{"$multiply": bson.A{path, weight}}}
+			group[name+"_wtotal"] = bson.M{"$sum": weight}
+			// A bucket of readings that all have confidence 0 has no average.
+			avg = bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$" + name + "_wtotal", 0}},
+				bson.M{"$divide": bson.A{"$" + name + "_wsum", "$" + name + "_wtotal"}},
+				nil,
+			}}
+		} else {
+			group[name+"_avg"] = bson.M{"$avg": path}
+		}
+		fields[name] = bson.M{"avg": avg, "min": "$" + name + "_min", "max": "$" + name + "_max"}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: sensorQuery(filter)}},
+		{{Key: "$group", Value: group}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$project", Value: bson.M{"count": 1, "fields": fields}}},
+	}
+	var out []models.SensorAggregate
+	err := r.retry.Do(ctx, func(int) error {
+		cur, err := r.coll.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		out = []models.SensorAggregate{}
+		return cur.All(ctx, &out)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *SensorRepo) Detach(ctx context.Context, deviceID, transferID string) error {
+	_, err := r.coll.UpdateMany(ctx,
+		bson.M{"device_id": deviceID},
+		bson.M{
+			"$set":   bson.M{"detached_from": deviceID, "detached_by_transfer": transferID},
+			"$unset": bson.M{"device_id": ""},
+		},
+	)
+	return err
+}
+
+func (r *SensorRepo) TagPriorOwner(ctx context.Context, deviceID, ownerID string, before time.Time) error {
+	_, err := r.coll.UpdateMany(ctx,
+		bson.M{
+			"device_id":      deviceID,
+			"timestamp":      bson.M{"$lte": before},
+			"prior_owner_id": bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"prior_owner_id": ownerID}},
+	)
+	return err
+}
+
+func (r *SensorRepo) DeleteByDevice(ctx context.Context, deviceID string) (int64, error) {
+	res, err := r.coll.DeleteMany(ctx, bson.M{"device_id": deviceID})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// Archive copies the readings into SensorDataArchiveCollection before
+// deleting them, so an interrupted archive can simply be run again.
+func (r *SensorRepo) Archive(ctx context.Context, deviceID string, at time.Time) (int64, error) {
+	cur, err := r.coll.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"device_id": deviceID}}},
+		{{Key: "$set", Value: bson.M{"archived_at": at}}},
+		{{Key: "$merge", Value: bson.M{
+			"into":           SensorDataArchiveCollection,
+			"on":             "_id",
+			"whenMatched":    "replace",
+			"whenNotMatched": "insert",
+		}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := cur.Close(ctx); err != nil {
+		return 0, err
+	}
+	return r.DeleteByDevice(ctx, deviceID)
+}
+
+func (r *SensorRepo) Count(ctx context.Context, filter repository.SensorFilter) (int64, error) {
+	var n int64
+	err := r.retry.Do(ctx, func(int) error {
+		var err error
+		n, err = r.coll.CountDocuments(ctx, sensorQuery(filter))
+		return err
+	})
+	return n, err
+}
+
+func (r *SensorRepo) Delete(ctx context.Context, filter repository.SensorFilter) (int64, error) {
+	res, err := r.coll.DeleteMany(ctx, sensorQuery(filter))
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// backfillBatch is the number of updates sent per bulk write.
+const backfillBatch = 500
+
+func (r *SensorRepo) BackfillAQI(ctx context.Context) (int64, error) {
+	cur, err := r.coll.Find(ctx, bson.M{"aqi_category": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"sensors.pm25": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	var updated int64
+	var batch []mongo.WriteModel
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		res, err := r.coll.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return err
+		}
+		updated += res.ModifiedCount
+		batch = batch[:0]
+		return nil
+	}
+	for cur.Next(ctx) {
+		var d models.SensorData
+		if err := cur.Decode(&d); err != nil {
+			return updated, err
+		}
+		utils.ApplyAQI(&d)
+		batch = append(batch, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": d.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"aqi": d.AQI, "aqi_category": d.AQICategory}}))
+		if len(batch) == backfillBatch {
+			if err := flush(); err != nil {
+				return updated, err
+			}
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return updated, err
+	}
+	return updated, flush()
+}
+
+func (r *SensorRepo) GetByID(ctx context.Context, deviceID, id string) (*models.SensorData, error) {
+	var d models.SensorData
+	err := r.coll.FindOne(ctx, bson.M{"_id": id, "device_id": deviceID}).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// MergeTags sets each tag on its own path, so concurrent merges of other
+// keys survive, and only matches a reading the merged tags fit, so the
+// limit holds however merges interleave.
+func (r *SensorRepo) MergeTags(ctx context.Context, deviceID, id string, tags map[string]string, maxTags int) (*models.SensorData, error) {
+	set, added := bson.M{}, bson.M{}
+	for k, v := range tags {
+		set["tags."+k] = v
+		added[k] = v
+	}
+	merged := bson.M{"$mergeObjects": bson.A{bson.M{"$ifNull": bson.A{"$tags", bson.M{}}}, bson.M{"$literal": added}}}
+	filter := bson.M{
+		"_id":       id,
+		"device_id": deviceID,
+		"$expr":     bson.M{"$lte": bson.A{bson.M{"$size": bson.M{"$objectToArray": merged}}, maxTags}},
+	}
+	var d models.SensorData
+	err := r.coll.FindOneAndUpdate(ctx, filter, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := r.GetByID(ctx, deviceID, id); err != nil {
+			return nil, err
+		}
+		return nil, repository.ErrLimitExceeded
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func sensorQuery(filter repository.SensorFilter) bson.M {
+	q := bson.M{"device_id": filter.DeviceID}
+	if filter.Source != "" {
+		q["source"] = filter.Source
+	}
+	if filter.Category != "" {
+		q["aqi_category"] = filter.Category
+	}
+	ts := bson.M{}
+	if !filter.From.IsZero() {
+		ts["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		ts["$lte"] = filter.To
+	}
+	if len(ts) > 0 {
+		q["timestamp"] = ts
+	}
+	if filter.MinConfidence != nil {
+		// $not also matches values without a confidence.
+		for name := range (models.Sensors{}).Fields() {
+			q["sensors."+name+".confidence"] = bson.M{"$not": bson.M{"$lt": *filter.MinConfidence}}
+		}
+	}
+	for k, v := range filter.Tags {
+		q["tags."+k] = v
+	}
+	return q
+}
@@ -0,0 +1,440 @@
+//go:build integration
+
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_repo_integration_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the integration tests of the sensor reading queries.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+func TestSensorRepoAggregateMissingFields(t *testing.T) {
+	ctx := context.Background()
+	db := testDB(t)
+	repo := NewSensorRepository(db, repository.RetryPolicy{})
+	day1 := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	// Readings of older firmware lack the sensors they do not have.
+	_, err := db.Collection(SensorDataCollection).InsertMany(ctx, []any{
+		bson.M{"device_id": "d1", "timestamp": day1, "sensors": bson.M{"pm25": bson.M{"value": 10.0, "unit": "µg/m³"}}},
+		bson.M{"device_id": "d1", "timestamp": day1.Add(time.Hour), "sensors": bson.M{"pm25": bson.M{"value": 20.0, "unit": "µg/m³"}}},
+		bson.M{"device_id": "d1", "timestamp": day2, "sensors": bson.M{
+			"pm25": bson.M{"value": 30.0, "unit": "µg/m³"},
+			"co2":  bson.M{"value": 800.0, "unit": "ppm", "confidence": 0.5},
+		}},
+		bson.M{"device_id": "d1", "timestamp": day2.Add(time.Hour), "sensors": bson.M{"pm25": bson.M{"value": 50.0, "unit": "µg/m³"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, weighted := range []bool{false, true} {
+		aggs, err := repo.Aggregate(ctx, repository.SensorFilter{DeviceID: "d1"}, "day", "UTC", weighted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(aggs) != 2 {
+			t.Fatalf("weighted=%v: got %d buckets, want 2", weighted, len(aggs))
+		}
+		tests := []struct {
+			name   string
+			bucket int
+			field  string
+			want   *float64
+		}{
+			{"field in every reading", 0, "pm25", ptr(15.0)},
+			{"field in no reading", 0, "co2", nil},
+			{"field in no reading, other field", 0, "humidity", nil},
+			{"field in some readings", 1, "co2", ptr(800.0)},
+			{"field in every reading, second bucket", 1, "pm25", ptr(40.0)},
+		}
+		for _, tt := range tests {
+			s := aggs[tt.bucket].Fields[tt.field]
+			for stat, got := range map[string]*float64{"avg": s.Avg, "min": s.Min, "max": s.Max} {
+				if stat != "avg" && tt.want != nil {
+					continue
+				}
+				if (got == nil) != (tt.want == nil) || (got != nil && stat == "avg" && *got != *tt.want) {
+					t.Errorf("weighted=%v %s: %s %s = %v, want %v", weighted, tt.name, tt.field, stat, deref(got), deref(tt.want))
+				}
+			}
+		}
+	}
+}
+
+func ptr(f float64) *float64 { return &f }
+
+func deref(f *float64) any {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
+
+func TestSensorRepoDelete(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		filter   repository.SensorFilter
+		want     int64
+		wantLeft int64
+	}{
+		{"whole device", repository.SensorFilter{DeviceID: "d1"}, 5, 0},
+		{"from", repository.SensorFilter{DeviceID: "d1", From: base.Add(3 * time.Hour)}, 2, 3},
+		{"to", repository.SensorFilter{DeviceID: "d1", To: base.Add(time.Hour)}, 2, 3},
+		{"range", repository.SensorFilter{DeviceID: "d1", From: base.Add(time.Hour), To: base.Add(3 * time.Hour)}, 3, 2},
+		{"empty range", repository.SensorFilter{DeviceID: "d1", From: base.Add(10 * time.Hour)}, 0, 5},
+		{"other device", repository.SensorFilter{DeviceID: "d9"}, 0, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			repo := NewSensorRepository(db, repository.RetryPolicy{})
+			for i := 0; i < 5; i++ {
+				d := &models.SensorData{DeviceID: "d1", Timestamp: base.Add(time.Duration(i) * time.Hour)}
+				if err := repo.Insert(ctx, d); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Readings of another device are never touched.
+			if err := repo.Insert(ctx, &models.SensorData{DeviceID: "d2", Timestamp: base}); err != nil {
+				t.Fatal(err)
+			}
+			n, err := repo.Delete(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.want {
+				t.Errorf("Delete() = %d, want %d", n, tt.want)
+			}
+			left, err := repo.Count(ctx, repository.SensorFilter{DeviceID: "d1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if left != tt.wantLeft {
+				t.Errorf("%d readings left, want %d", left, tt.wantLeft)
+			}
+			if other, _ := repo.Count(ctx, repository.SensorFilter{DeviceID: "d2"}); other != 1 {
+				t.Errorf("%d readings of the other device left, want 1", other)
+			}
+		})
+	}
+}
+
+func TestSensorRepoFindSource(t *testing.T) {
+	ctx := context.Background()
+	db := testDB(t)
+	repo := NewSensorRepository(db, repository.RetryPolicy{})
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	sources := []models.DataSource{models.SourceMQTT, models.SourceHTTP, models.SourceBulkUpload, models.SourceMQTT, models.SourceSeed}
+	for i, source := range sources {
+		d := &models.SensorData{DeviceID: "d1", Timestamp: base.Add(time.Duration(i) * time.Hour), Source: source}
+		if err := repo.Insert(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		source models.DataSource
+		want   int
+	}{
+		{"", 5},
+		{models.SourceMQTT, 2},
+		{models.SourceHTTP, 1},
+		{models.SourceBulkUpload, 1},
+		{models.SourceSeed, 1},
+		{models.SourceInterpolated, 0},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.source), func(t *testing.T) {
+			got, err := repo.Find(ctx, repository.SensorFilter{DeviceID: "d1", Source: tt.source})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want {
+				t.Fatalf("found %d readings, want %d", len(got), tt.want)
+			}
+			for _, d := range got {
+				if tt.source != "" && d.Source != tt.source {
+					t.Errorf("found a reading of source %q", d.Source)
+				}
+			}
+		})
+	}
+}
+
+func TestSensorRepoFindCancelled(t *testing.T) {
+	db := testDB(t)
+	repo := NewSensorRepository(db, repository.RetryPolicy{})
+	if _, err := db.Collection(SensorDataCollection).InsertOne(context.Background(),
+		bson.M{"device_id": "d1", "timestamp": time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A deadline that passed before the call fails it at once.
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := repo.Find(ctx, repository.SensorFilter{DeviceID: "d1"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expired: err = %v, want context.DeadlineExceeded", err)
+	}
+
+	// One that passes mid-query stops the query rather than waiting for it.
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := db.Collection(SensorDataCollection).Find(ctx, bson.M{"$where": "sleep(5000) || true"})
+	if !errors.Is(err, context.DeadlineExceeded) && !mongo.IsTimeout(err) {
+		t.Errorf("mid-query: err = %v, want a timeout", err)
+	}
+	if took := time.Since(start); took > 2*time.Second {
+		t.Errorf("mid-query: returned after %v", took)
+	}
+}
+
+func TestSensorRepoMergeTags(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSensorRepository(testDB(t), repository.RetryPolicy{})
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	insert := func(at time.Time, tags map[string]string) string {
+		t.Helper()
+		d := &models.SensorData{DeviceID: "d1", Timestamp: at, Tags: tags}
+		if err := repo.Insert(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+		return d.ID
+	}
+
+	// Concurrent merges of different keys all land.
+	id := insert(base, map[string]string{"event": "cooking"})
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := repo.MergeTags(ctx, "d1", id, map[string]string{fmt.Sprintf("k%d", i): "v"}, 20); err != nil {
+				t.Errorf("merge k%d: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	d, err := repo.GetByID(ctx, "d1", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Tags) != 11 || d.Tags["event"] != "cooking" {
+		t.Errorf("tags = %v, want event and k0 to k9", d.Tags)
+	}
+
+	// Concurrent merges cannot take a reading past the limit together.
+	id = insert(base.Add(time.Hour), nil)
+	var accepted atomic.Int32
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.MergeTags(ctx, "d1", id, map[string]string{fmt.Sprintf("k%d", i): "v"}, 4)
+			switch {
+			case err == nil:
+				accepted.Add(1)
+			case !errors.Is(err, repository.ErrLimitExceeded):
+				t.Errorf("merge k%d: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if d, _ = repo.GetByID(ctx, "d1", id); len(d.Tags) != 4 || accepted.Load() != 4 {
+		t.Errorf("accepted %d merges, tags = %v, want 4", accepted.Load(), d.Tags)
+	}
+	// Overwriting a key at the limit adds none.
+	for k := range d.Tags {
+		if _, err := repo.MergeTags(ctx, "d1", id, map[string]string{k: "w"}, 4); err != nil {
+			t.Errorf("overwrite %s at the limit: %v", k, err)
+		}
+		break
+	}
+
+	if _, err := repo.MergeTags(ctx, "d2", id, map[string]string{"event": "x"}, 20); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("reading of another device: err = %v, want ErrNotFound", err)
+	}
+
+	insert(base.Add(2*time.Hour), map[string]string{"event": "cooking", "windows": "open"})
+	tests := []struct {
+		name string
+		tags map[string]string
+		want int
+	}{
+		{"one tag", map[string]string{"event": "cooking"}, 2},
+		{"all tags", map[string]string{"event": "cooking", "windows": "open"}, 1},
+		{"no match", map[string]string{"event": "sleeping"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := repo.Find(ctx, repository.SensorFilter{DeviceID: "d1", Tags: tt.tags})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(found) != tt.want {
+				t.Errorf("found %d readings, want %d", len(found), tt.want)
+			}
+		})
+	}
+}
+
+func TestSensorRepoStats(t *testing.T) {
+	ctx := context.Background()
+	db := testDB(t)
+	repo := NewSensorRepository(db, repository.RetryPolicy{})
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	// pm25 is 1 to 10, co2 rises with it and humidity falls; the last
+	// reading lacks co2.
+	var docs []any
+	for i := 1; i <= 10; i++ {
+		sensors := bson.M{
+			"pm25":     bson.M{"value": float64(i), "unit": "µg/m³"},
+			"humidity": bson.M{"value": 100 - float64(i), "unit": "%"},
+		}
+		if i < 10 {
+			sensors["co2"] = bson.M{"value": 400 + 10*float64(i), "unit": "ppm"}
+		}
+		docs = append(docs, bson.M{"device_id": "d1", "timestamp": base.Add(time.Duration(i) * time.Minute), "sensors": sensors})
+	}
+	if _, err := db.Collection(SensorDataCollection).InsertMany(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	fields := []string{"pm25", "co2", "humidity"}
+
+	tests := []struct {
+		name      string
+		inMemory  bool
+		exactPcts bool
+	}{
+		{"server percentiles", false, false},
+		{"in-memory percentiles", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.noPercentile.Store(tt.inMemory)
+			stats, err := repo.Stats(ctx, repository.SensorFilter{DeviceID: "d1"}, fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Count != 10 {
+				t.Errorf("count = %d, want 10", stats.Count)
+			}
+			pm := stats.Fields["pm25"]
+			// The sample variance of 1 to 10 is 82.5/9.
+			if pm.Count != 10 || pm.Avg != 5.5 || pm.Min != 1 || pm.Max != 10 || math.Abs(pm.StdDev-math.Sqrt(82.5/9)) > 1e-9 {
+				t.Errorf("pm25 = %+v", pm)
+			}
+			if co2 := stats.Fields["co2"]; co2.Count != 9 || co2.Avg != 450 || co2.Min != 410 || co2.Max != 490 {
+				t.Errorf("co2 = %+v", co2)
+			}
+			if tt.exactPcts {
+				if pm.P50 != 5 || pm.P95 != 10 || pm.P99 != 10 {
+					t.Errorf("pm25 percentiles = %v %v %v, want 5 10 10", pm.P50, pm.P95, pm.P99)
+				}
+			} else if pm.P50 < 5 || pm.P50 > 6 || pm.P95 < 9 || pm.P99 < 9 || pm.P99 > 10 {
+				t.Errorf("pm25 percentiles = %v %v %v, want about 5 10 10", pm.P50, pm.P95, pm.P99)
+			}
+			want := map[string]float64{"pm25/co2": 1, "pm25/humidity": -1, "co2/humidity": -1}
+			if len(stats.Correlations) != len(want) {
+				t.Fatalf("correlations = %+v", stats.Correlations)
+			}
+			for _, c := range stats.Correlations {
+				w, ok := want[c.X+"/"+c.Y]
+				if !ok || c.R == nil || math.Abs(*c.R-w) > 1e-9 {
+					t.Errorf("r(%s, %s) = %v, want %v", c.X, c.Y, deref(c.R), w)
+				}
+			}
+		})
+	}
+
+	t.Run("in-memory percentiles of too many readings", func(t *testing.T) {
+		repo.noPercentile.Store(true)
+		defer func(n int64) { maxPercentileReadings = n }(maxPercentileReadings)
+		maxPercentileReadings = 9
+		_, err := repo.Stats(ctx, repository.SensorFilter{DeviceID: "d1"}, fields)
+		if !errors.Is(err, repository.ErrLimitExceeded) {
+			t.Errorf("err = %v, want ErrLimitExceeded", err)
+		}
+	})
+}
+
+func TestSensorRepoReplay(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSensorRepository(testDB(t), repository.RetryPolicy{})
+	at := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	confidence := 0.9
+	first := &models.SensorData{
+		DeviceID: "d1", Timestamp: at, Source: models.SourceMQTT, SchemaVersion: 2,
+		Sensors: models.Sensors{
+			PM25: models.SensorValue{Value: 80, Unit: "µg/m³", Confidence: &confidence},
+			CO2:  models.SensorValue{Value: 900, Unit: "ppm"},
+		},
+		AQI: 160, AQICategory: "unhealthy", Quality: models.QualityDegraded,
+	}
+	if err := repo.Insert(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.MergeTags(ctx, "d1", first.ID, map[string]string{"event": "cooking"}, 20); err != nil {
+		t.Fatal(err)
+	}
+
+	replay := &models.SensorData{
+		DeviceID: "d1", Timestamp: at, Source: models.SourceHTTP,
+		Sensors: models.Sensors{PM25: models.SensorValue{Value: 10, Unit: "µg/m³"}},
+		AQI:     42,
+	}
+	if err := repo.Insert(ctx, replay); err != nil {
+		t.Fatal(err)
+	}
+	if replay.ID != first.ID {
+		t.Errorf("replay got ID %s, want the stored %s", replay.ID, first.ID)
+	}
+	got, err := repo.GetByID(ctx, "d1", first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := *replay
+	want.Tags = map[string]string{"event": "cooking"}
+	got.Timestamp = got.Timestamp.UTC()
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("stored %+v, want the replay %+v", *got, want)
+	}
+
+	// An older reading arriving later is stored beside the newer one.
+	older := &models.SensorData{DeviceID: "d1", Timestamp: at.Add(-time.Minute), Sensors: models.Sensors{PM25: models.SensorValue{Value: 5, Unit: "µg/m³"}}}
+	if err := repo.Insert(ctx, older); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := repo.Latest(ctx, []string{"d1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 1 || latest[0].ID != first.ID {
+		t.Errorf("latest = %+v, want %s", latest, first.ID)
+	}
+}
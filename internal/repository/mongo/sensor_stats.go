@@ -0,0 +1,238 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_stats.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the statistics of sensor readings computed by MongoDB.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// statsPercentiles are P50, P95 and P99 of models.FieldStats.
+var statsPercentiles = bson.A{0.5, 0.95, 0.99}
+
+// maxPercentileReadings bounds the readings loaded to compute percentiles
+// in memory, on servers without $percentile. A variable for tests.
+var maxPercentileReadings int64 = 100_000
+
+// Server error codes of an unknown $group operator, an unknown expression
+// and a feature the featureCompatibilityVersion does not allow yet.
+var unknownOperatorCodes = []int{15952, 168, 224}
+
+// pairSums are the sums Pearson's r of two sensors is computed from, over
+// the N readings that have both.
+type pairSums struct {
+	N   int64   `bson:"n"`
+	SX  float64 `bson:"sx"`
+	SY  float64 `bson:"sy"`
+	SXX float64 `bson:"sxx"`
+	SYY float64 `bson:"syy"`
+	SXY float64 `bson:"sxy"`
+}
+
+// Stats computes everything in one $group. Percentiles use $percentile,
+// which needs MongoDB 7.0; on older servers they are computed here from
+// the values of the readings, loaded and sorted in memory, and the server
+// is remembered not to support it. There, more than maxPercentileReadings
+// readings get repository.ErrLimitExceeded.
+func (r *SensorRepo) Stats(ctx context.Context, filter repository.SensorFilter, fields []string) (*models.SensorStats, error) {
+	percentile := !r.noPercentile.Load()
+	row, err := r.statsGroup(ctx, filter, fields, percentile)
+	if percentile && unknownOperator(err) {
+		log.Printf("mongo: server lacks $percentile, computing percentiles in memory: %v", err)
+		r.noPercentile.Store(true)
+		percentile = false
+		row, err = r.statsGroup(ctx, filter, fields, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.SensorStats{Count: row.Count, Fields: row.Fields, Correlations: []models.Correlation{}}
+	if stats.Fields == nil {
+		// No reading matched, so $group returned no document.
+		stats.Fields = make(map[string]models.FieldStats, len(fields))
+		for _, f := range fields {
+			stats.Fields[f] = models.FieldStats{}
+		}
+	}
+	if !percentile && stats.Count > maxPercentileReadings {
+		return nil, fmt.Errorf("%w: percentiles of more than %d readings need MongoDB 7.0", repository.ErrLimitExceeded, maxPercentileReadings)
+	}
+	if !percentile && stats.Count > 0 {
+		if err := r.percentiles(ctx, filter, fields, stats.Fields); err != nil {
+			return nil, err
+		}
+	}
+	for i, x := range fields {
+		for _, y := range fields[i+1:] {
+			stats.Correlations = append(stats.Correlations, models.Correlation{X: x, Y: y, R: pearson(row.Pairs[x+"_"+y])})
+		}
+	}
+	return stats, nil
+}
+
+type statsRow struct {
+	Count  int64                        `bson:"count"`
+	Fields map[string]models.FieldStats `bson:"fields"`
+	Pairs  map[string]pairSums          `bson:"pairs"`
+}
+
+func (r *SensorRepo) statsGroup(ctx context.Context, filter repository.SensorFilter, fields []string, percentile bool) (statsRow, error) {
+	group := bson.M{"_id": nil, "count": bson.M{"$sum": 1}}
+	project := bson.M{}
+	for _, f := range fields {
+		v := "$sensors." + f + ".value"
+		group[f+"_count"] = bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$isNumber": v}, 1, 0}}}
+		group[f+"_avg"] = bson.M{"$avg": v}
+		group[f+"_stddev"] = bson.M{"$stdDevSamp": v}
+		group[f+"_min"] = bson.M{"$min": v}
+		group[f+"_max"] = bson.M{"$max": v}
+		stats := bson.M{
+			"count": "$" + f + "_count", "avg": "$" + f + "_avg", "stddev": "$" + f + "_stddev",
+			"min": "$" + f + "_min", "max": "$" + f + "_max",
+		}
+		if percentile {
+			group[f+"_pct"] = bson.M{"$percentile": bson.M{"input": v, "p": statsPercentiles, "method": "approximate"}}
+			for i, name := range []string{"p50", "p95", "p99"} {
+				stats[name] = bson.M{"$arrayElemAt": bson.A{"$" + f + "_pct", i}}
+			}
+		}
+		project["fields."+f] = stats
+	}
+	for i, x := range fields {
+		for _, y := range fields[i+1:] {
+			vx, vy := "$sensors."+x+".value", "$sensors."+y+".value"
+			both := bson.M{"$and": bson.A{bson.M{"$isNumber": vx}, bson.M{"$isNumber": vy}}}
+			sum := func(e any) bson.M { return bson.M{"$sum": bson.M{"$cond": bson.A{both, e, 0}}} }
+			pair := x + "_" + y
+			group[pair+"_n"] = sum(1)
+			group[pair+"_sx"] = sum(vx)
+			group[pair+"_sy"] = sum(vy)
+			group[pair+"_sxx"] = sum(bson.M{"$multiply": bson.A{vx, vx}})
+			group[pair+"_syy"] = sum(bson.M{"$multiply": bson.A{vy, vy}})
+			group[pair+"_sxy"] = sum(bson.M{"$multiply": bson.A{vx, vy}})
+			sums := bson.M{}
+			for _, s := range []string{"n", "sx", "sy", "sxx", "syy", "sxy"} {
+				sums[s] = "$" + pair + "_" + s
+			}
+			project["pairs."+pair] = sums
+		}
+	}
+	project["count"] = 1
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: sensorQuery(filter)}},
+		{{Key: "$group", Value: group}},
+		{{Key: "$project", Value: project}},
+	}
+
+	var rows []statsRow
+	err := r.retry.Do(ctx, func(int) error {
+		cur, err := r.coll.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		rows = nil
+		return cur.All(ctx, &rows)
+	})
+	if err != nil || len(rows) == 0 {
+		return statsRow{}, err
+	}
+	return rows[0], nil
+}
+
+// percentiles sets the percentiles of fields in stats from the values of
+// the readings matching filter, at most maxPercentileReadings of them:
+// readings stored since they were counted are left out.
+func (r *SensorRepo) percentiles(ctx context.Context, filter repository.SensorFilter, fields []string, stats map[string]models.FieldStats) error {
+	projection := bson.M{"_id": 0}
+	for _, f := range fields {
+		projection["sensors."+f+".value"] = 1
+	}
+	opts := options.Find().SetProjection(projection).SetLimit(maxPercentileReadings)
+	cur, err := r.coll.Find(ctx, sensorQuery(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	values := make(map[string][]float64, len(fields))
+	for cur.Next(ctx) {
+		var d struct {
+			Sensors map[string]struct {
+				Value *float64 `bson:"value"`
+			} `bson:"sensors"`
+		}
+		if err := cur.Decode(&d); err != nil {
+			return err
+		}
+		for _, f := range fields {
+			if v := d.Sensors[f].Value; v != nil {
+				values[f] = append(values[f], *v)
+			}
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+	for _, f := range fields {
+		v := values[f]
+		slices.Sort(v)
+		s := stats[f]
+		s.P50, s.P95, s.P99 = nearestRank(v, 0.5), nearestRank(v, 0.95), nearestRank(v, 0.99)
+		stats[f] = s
+	}
+	return nil
+}
+
+// nearestRank returns the p-th percentile of sorted: its smallest value
+// that at least p of the values are less than or equal to, 0 if it is
+// empty.
+func nearestRank(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
+
+// pearson returns the correlation coefficient of s, nil if it is
+// undefined.
+func pearson(s pairSums) *float64 {
+	n := float64(s.N)
+	vx := n*s.SXX - s.SX*s.SX
+	vy := n*s.SYY - s.SY*s.SY
+	if s.N < 2 || vx <= 0 || vy <= 0 {
+		return nil
+	}
+	// Rounding can take r just past ±1.
+	r := max(-1, min(1, (n*s.SXY-s.SX*s.SY)/math.Sqrt(vx*vy)))
+	return &r
+}
+
+func unknownOperator(err error) bool {
+	var se mongo.ServerError
+	if !errors.As(err, &se) {
+		return false
+	}
+	return slices.ContainsFunc(unknownOperatorCodes, se.HasErrorCode)
+}
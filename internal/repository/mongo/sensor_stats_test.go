@@ -0,0 +1,106 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_stats_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the statistics of sensor readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNearestRank(t *testing.T) {
+	oneToTen := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	hundred := make([]float64, 100)
+	for i := range hundred {
+		hundred[i] = float64(i + 1)
+	}
+	tests := []struct {
+		name   string
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{"empty", nil, 0.5, 0},
+		{"single value", []float64{42}, 0.99, 42},
+		{"median of ten", oneToTen, 0.5, 5},
+		{"p95 of ten", oneToTen, 0.95, 10},
+		{"p99 of ten", oneToTen, 0.99, 10},
+		{"median of four", []float64{15, 20, 35, 40}, 0.5, 20},
+		{"median of five", []float64{3, 6, 7, 8, 8}, 0.5, 7},
+		{"p95 of a hundred", hundred, 0.95, 95},
+		{"p99 of a hundred", hundred, 0.99, 99},
+		{"p0", oneToTen, 0, 1},
+		{"p100", oneToTen, 1, 10},
+		{"repeated values", []float64{1, 1, 1, 2, 2, 9}, 0.5, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nearestRank(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("nearestRank(%v) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+// sums returns the pairSums of xs and ys.
+func sums(xs, ys []float64) pairSums {
+	s := pairSums{N: int64(len(xs))}
+	for i := range xs {
+		s.SX += xs[i]
+		s.SY += ys[i]
+		s.SXX += xs[i] * xs[i]
+		s.SYY += ys[i] * ys[i]
+		s.SXY += xs[i] * ys[i]
+	}
+	return s
+}
+
+func TestPearson(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+	tests := []struct {
+		name string
+		x, y []float64
+		// want is nil when r is undefined.
+		want *float64
+	}{
+		{"perfectly correlated", x, []float64{102, 104, 106, 108, 110}, ptrTo(1)},
+		{"perfectly anti-correlated", x, []float64{99, 98, 97, 96, 95}, ptrTo(-1)},
+		// Σdx·dy = 6, Σdx² = 10, Σdy² = 6.
+		{"known dataset", x, []float64{2, 4, 5, 4, 5}, ptrTo(6 / math.Sqrt(10*6))},
+		{"uncorrelated", []float64{1, 2, 3}, []float64{1, 0, 1}, ptrTo(0)},
+		{"constant sensor", x, []float64{7, 7, 7, 7, 7}, nil},
+		{"one reading", []float64{1}, []float64{2}, nil},
+		{"no readings", nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pearson(sums(tt.x, tt.y))
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("pearson() = %v, want %v", fmtR(got), fmtR(tt.want))
+			}
+			if got != nil && math.Abs(*got-*tt.want) > 1e-9 {
+				t.Errorf("pearson() = %v, want %v", *got, *tt.want)
+			}
+			if got != nil && (*got < -1 || *got > 1) {
+				t.Errorf("pearson() = %v, outside [-1, 1]", *got)
+			}
+		})
+	}
+}
+
+func ptrTo(f float64) *float64 { return &f }
+
+func fmtR(r *float64) any {
+	if r == nil {
+		return nil
+	}
+	return *r
+}
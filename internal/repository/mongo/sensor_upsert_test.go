@@ -0,0 +1,98 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_upsert_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of replacing replayed sensor readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"airsense-be.com/internal/models"
+)
+
+func TestSensorUpsert(t *testing.T) {
+	at := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	confidence := 0.9
+	tests := []struct {
+		name      string
+		data      models.SensorData
+		wantUnset []string
+	}{
+		{"every field", models.SensorData{
+			ID: "r1", DeviceID: "d1", Timestamp: at, Source: models.SourceMQTT, SchemaVersion: 2,
+			Sensors:     models.Sensors{PM25: models.SensorValue{Value: 12, Unit: "µg/m³", Confidence: &confidence}},
+			AQI:         50,
+			AQICategory: "good",
+			Quality:     models.QualityDegraded,
+			Tags:        map[string]string{"event": "cooking"},
+		}, nil},
+		{"bare reading", models.SensorData{ID: "r1", DeviceID: "d1", Timestamp: at},
+			[]string{"aqi_category", "quality", "schema_version", "source"}},
+		{"no quality", models.SensorData{ID: "r1", DeviceID: "d1", Timestamp: at, Source: models.SourceHTTP, AQICategory: "good"},
+			[]string{"quality", "schema_version"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update, err := sensorUpsert(&tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			set := update["$set"].(bson.D)
+			setKeys := make([]string, 0, len(set))
+			for _, f := range set {
+				setKeys = append(setKeys, f.Key)
+			}
+			if slices.Contains(setKeys, "_id") {
+				t.Error("$set changes the ID of the stored reading")
+			}
+			// The sensors are set as one sub-document, not per field, so
+			// values a replay lacks do not survive.
+			if !slices.Contains(setKeys, "sensors") || slices.ContainsFunc(setKeys, func(k string) bool { return strings.HasPrefix(k, "sensors.") }) {
+				t.Errorf("$set keys = %v, want sensors whole", setKeys)
+			}
+			var unset []string
+			if u, ok := update["$unset"].(bson.M); ok {
+				for k := range u {
+					unset = append(unset, k)
+				}
+			}
+			slices.Sort(unset)
+			if !slices.Equal(unset, tt.wantUnset) {
+				t.Errorf("$unset = %v, want %v", unset, tt.wantUnset)
+			}
+			if id := update["$setOnInsert"].(bson.M)["_id"]; id != tt.data.ID {
+				t.Errorf("inserted under %v, want %s", id, tt.data.ID)
+			}
+		})
+	}
+}
+
+// TestSensorOptional checks that every field of a reading left out when
+// empty is removed by a replay lacking it, or is one added after the
+// reading is stored.
+func TestSensorOptional(t *testing.T) {
+	addedLater := []string{"tags", "prior_owner_id"}
+	typ := reflect.TypeOf(models.SensorData{})
+	for i := range typ.NumField() {
+		name, opts, _ := strings.Cut(typ.Field(i).Tag.Get("bson"), ",")
+		if !strings.Contains(opts, "omitempty") {
+			continue
+		}
+		if !slices.Contains(sensorOptional, name) && !slices.Contains(addedLater, name) {
+			t.Errorf("optional field %s survives replays lacking it", name)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: share_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository for device shares in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type ShareRepo struct {
+	coll *mongo.Collection
+}
+
+func NewShareRepository(db *mongo.Database) *ShareRepo {
+	return &ShareRepo{coll: db.Collection(SharesCollection)}
+}
+
+func (r *ShareRepo) Create(ctx context.Context, share *models.DeviceShare) error {
+	_, err := r.coll.InsertOne(ctx, share)
+	return err
+}
+
+func (r *ShareRepo) Get(ctx context.Context, deviceID, granteeUserID string) (*models.DeviceShare, error) {
+	var s models.DeviceShare
+	err := r.coll.FindOne(ctx, bson.M{"device_id": deviceID, "grantee_user_id": granteeUserID}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *ShareRepo) ListByDevice(ctx context.Context, deviceID string) ([]models.DeviceShare, error) {
+	return r.find(ctx, bson.M{"device_id": deviceID})
+}
+
+func (r *ShareRepo) ListByGrantee(ctx context.Context, granteeUserID string) ([]models.DeviceShare, error) {
+	return r.find(ctx, bson.M{"grantee_user_id": granteeUserID})
+}
+
+func (r *ShareRepo) Delete(ctx context.Context, deviceID, shareID string) error {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": shareID, "device_id": deviceID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *ShareRepo) DeleteByDevice(ctx context.Context, deviceID string) error {
+	_, err := r.coll.DeleteMany(ctx, bson.M{"device_id": deviceID})
+	return err
+}
+
+func (r *ShareRepo) find(ctx context.Context, filter bson.M) ([]models.DeviceShare, error) {
+	cur, err := r.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	out := []models.DeviceShare{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
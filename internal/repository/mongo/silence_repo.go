@@ -0,0 +1,92 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: silence_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository of alert silences.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type SilenceRepo struct {
+	coll *mongo.Collection
+}
+
+func NewSilenceRepository(db *mongo.Database) *SilenceRepo {
+	return &SilenceRepo{coll: db.Collection(SilencesCollection)}
+}
+
+func (r *SilenceRepo) Create(ctx context.Context, s *models.Silence) error {
+	_, err := r.coll.InsertOne(ctx, s)
+	return err
+}
+
+func (r *SilenceRepo) GetByID(ctx context.Context, deviceID, id string) (*models.Silence, error) {
+	var s models.Silence
+	err := r.coll.FindOne(ctx, bson.M{"_id": id, "device_id": deviceID}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *SilenceRepo) ListByDevice(ctx context.Context, deviceID string) ([]models.Silence, error) {
+	return r.find(ctx, bson.M{"device_id": deviceID})
+}
+
+func (r *SilenceRepo) ListActive(ctx context.Context, deviceID string, now time.Time) ([]models.Silence, error) {
+	return r.find(ctx, bson.M{"device_id": deviceID, "ends_at": bson.M{"$gt": now}})
+}
+
+func (r *SilenceRepo) find(ctx context.Context, q bson.M) ([]models.Silence, error) {
+	cur, err := r.coll.Find(ctx, q, options.Find().SetSort(bson.D{{Key: "starts_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	silences := []models.Silence{}
+	if err := cur.All(ctx, &silences); err != nil {
+		return nil, err
+	}
+	return silences, nil
+}
+
+func (r *SilenceRepo) Replace(ctx context.Context, s *models.Silence) error {
+	res, err := r.coll.ReplaceOne(ctx, bson.M{"_id": s.ID, "device_id": s.DeviceID}, s)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SilenceRepo) Delete(ctx context.Context, deviceID, id string) error {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": id, "device_id": deviceID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: transfer_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository for device ownership transfers in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type TransferRepo struct {
+	coll *mongo.Collection
+}
+
+func NewTransferRepository(db *mongo.Database) *TransferRepo {
+	return &TransferRepo{coll: db.Collection(TransfersCollection)}
+}
+
+func (r *TransferRepo) Create(ctx context.Context, t *models.DeviceTransfer) error {
+	_, err := r.coll.InsertOne(ctx, t)
+	return err
+}
+
+func (r *TransferRepo) GetByTokenHash(ctx context.Context, hash string) (*models.DeviceTransfer, error) {
+	var t models.DeviceTransfer
+	err := r.coll.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *TransferRepo) MarkAccepted(ctx context.Context, id, toUserID string) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.TransferPending},
+		bson.M{"$set": bson.M{
+			"status":      models.TransferAccepted,
+			"to_user_id":  toUserID,
+			"accepted_at": time.Now(),
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
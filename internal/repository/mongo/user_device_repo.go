@@ -0,0 +1,52 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: user_device_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the MongoDB repository of the mobile app installations of users.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+)
+
+type UserDeviceRepo struct {
+	coll *mongo.Collection
+}
+
+func NewUserDeviceRepository(db *mongo.Database) *UserDeviceRepo {
+	return &UserDeviceRepo{coll: db.Collection(UserDevicesCollection)}
+}
+
+func (r *UserDeviceRepo) Upsert(ctx context.Context, d *models.UserDevice) error {
+	_, err := r.coll.ReplaceOne(ctx, bson.M{"_id": d.FCMToken}, d, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *UserDeviceRepo) ListByUser(ctx context.Context, userID string) ([]models.UserDevice, error) {
+	cur, err := r.coll.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	var devices []models.UserDevice
+	if err := cur.All(ctx, &devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (r *UserDeviceRepo) Delete(ctx context.Context, fcmToken string) error {
+	_, err := r.coll.DeleteOne(ctx, bson.M{"_id": fcmToken})
+	return err
+}
@@ -0,0 +1,202 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: user_repo.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the MongoDB repository for users in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type UserRepo struct {
+	coll *mongo.Collection
+}
+
+func NewUserRepository(db *mongo.Database) *UserRepo {
+	return &UserRepo{coll: db.Collection(UsersCollection)}
+}
+
+func (r *UserRepo) Create(ctx context.Context, u *models.User) error {
+	_, err := r.coll.InsertOne(ctx, u)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+func (r *UserRepo) GetByID(ctx context.Context, id string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *UserRepo) ReserveDevices(ctx context.Context, userID string, n, defaultLimit int) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{
+			"_id": userID,
+			"$or": bson.A{
+				// Admins are counted but never limited.
+				bson.M{"role": models.RoleAdmin},
+				bson.M{"$expr": bson.M{"$lte": bson.A{
+					bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$device_count", 0}}, n}},
+					bson.M{"$ifNull": bson.A{"$device_limit", defaultLimit}},
+				}}},
+			},
+		},
+		bson.M{"$inc": bson.M{"device_count": n}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *UserRepo) ReleaseDevices(ctx context.Context, userID string, n int) error {
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": userID, "device_count": bson.M{"$gte": n}},
+		bson.M{"$inc": bson.M{"device_count": -n}})
+	return err
+}
+
+func (r *UserRepo) SetDeviceLimit(ctx context.Context, userID string, limit *int) error {
+	update := bson.M{"$unset": bson.M{"device_limit": ""}}
+	if limit != nil {
+		update = bson.M{"$set": bson.M{"device_limit": *limit}}
+	}
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *UserRepo) SetPassword(ctx context.Context, userID, hash string, changedAt time.Time) error {
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"password": hash, "password_changed_at": changedAt}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *UserRepo) ReplaceUnverifiedPassword(ctx context.Context, userID, hash string, changedAt time.Time) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": userID, "unverified": true},
+		bson.M{"$set": bson.M{"password": hash, "password_changed_at": changedAt}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+func (r *UserRepo) RevokeSessions(ctx context.Context, userID string, at time.Time) error {
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"sessions_revoked_at": at}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *UserRepo) UpdateProfile(ctx context.Context, userID string, email, name *string) (*models.User, error) {
+	set := bson.M{}
+	if email != nil {
+		set["email"] = *email
+	}
+	if name != nil {
+		set["name"] = *name
+	}
+	if len(set) == 0 {
+		return r.GetByID(ctx, userID)
+	}
+	update := bson.M{"$set": set}
+	if email != nil {
+		set["unverified"] = true
+		update["$unset"] = bson.M{"verified_at": ""}
+	}
+	var u models.User
+	err := r.coll.FindOneAndUpdate(ctx, bson.M{"_id": userID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&u)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, repository.ErrDuplicate
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *UserRepo) MarkVerified(ctx context.Context, userID string, at time.Time) error {
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": userID, "unverified": true},
+		bson.M{"$set": bson.M{"verified_at": at}, "$unset": bson.M{"unverified": ""}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		// Verified already, or gone.
+		if _, err := r.GetByID(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *UserRepo) GetByOIDC(ctx context.Context, issuer, subject string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"oidc_issuer": issuer, "oidc_subject": subject})
+}
+
+func (r *UserRepo) LinkOIDC(ctx context.Context, userID, issuer, subject string) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": userID, "oidc_subject": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"oidc_issuer": issuer, "oidc_subject": subject}})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, repository.ErrDuplicate
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *UserRepo) Count(ctx context.Context) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.M{})
+}
+
+func (r *UserRepo) findOne(ctx context.Context, filter bson.M) (*models.User, error) {
+	var u models.User
+	err := r.coll.FindOne(ctx, filter).Decode(&u)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
@@ -0,0 +1,73 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: retry.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the retry with backoff of transient repository errors.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package repository
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy retries an operation that failed with an error its
+// Retryable reports as transient. The zero value makes a single attempt.
+type RetryPolicy struct {
+	// MaxAttempts bounds the attempts, the first one included.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles with each
+	// further retry up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Retryable func(error) bool
+}
+
+// Do runs op until it succeeds, fails with an error that is not retryable
+// or MaxAttempts is reached, and returns the last error. A retry that
+// would not start before the deadline of ctx is not made.
+func (p RetryPolicy) Do(ctx context.Context, op func(attempt int) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = op(attempt); err == nil || attempt >= p.MaxAttempts || p.Retryable == nil || !p.Retryable(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		wait := p.delay(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return err
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// delay is the backoff after attempt with equal jitter: half the
+// exponential delay plus a random share of the other half, so clients that
+// failed together do not retry in lockstep.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 {
+		d = min(d, p.MaxDelay)
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
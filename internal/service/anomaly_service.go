@@ -0,0 +1,131 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: anomaly_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the z-score anomaly scan of stored sensor readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"airsense-be.com/internal/alert"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+const (
+	// MaxAnomalyWindow bounds the rolling window of a scan.
+	MaxAnomalyWindow = 1000
+	// maxScannedReadings bounds the readings loaded for one scan.
+	maxScannedReadings = 10000
+)
+
+// AnomalyRequest selects the readings of DeviceID between From and To and
+// the Fields to scan. A zero ZThreshold or Window takes the default of the
+// live anomaly detector.
+type AnomalyRequest struct {
+	DeviceID   string
+	From       time.Time
+	To         time.Time
+	Fields     []string
+	ZThreshold float64
+	Window     int
+}
+
+// AnomalyService flags stored readings the way alert.AnomalyDetector
+// flags live ones, for a time range after the fact.
+type AnomalyService struct {
+	sensors   repository.SensorDataRepository
+	threshold float64
+	window    int
+	warmUp    int
+}
+
+func NewAnomalyService(sensors repository.SensorDataRepository, cfg config.AlertConfig) *AnomalyService {
+	return &AnomalyService{
+		sensors:   sensors,
+		threshold: cfg.AnomalyK,
+		window:    cfg.AnomalyWindow,
+		warmUp:    cfg.AnomalyMinSamples,
+	}
+}
+
+// Scan scores each value of the requested fields against the rolling mean
+// and standard deviation of the Window readings before it. Values whose
+// z-score exceeds ZThreshold in absolute value are returned, oldest first.
+// Nothing is stored: only the live detector keeps what it flags, see
+// SensorService.Ingest.
+func (s *AnomalyService) Scan(ctx context.Context, req AnomalyRequest) ([]models.SensorAnomaly, error) {
+	if req.ZThreshold == 0 {
+		req.ZThreshold = s.threshold
+	}
+	if req.Window == 0 {
+		req.Window = s.window
+	}
+	if req.ZThreshold <= 0 {
+		return nil, fmt.Errorf("%w: z_threshold must be positive", ErrInvalidAnomalyQuery)
+	}
+	if req.Window < 2 || req.Window > MaxAnomalyWindow {
+		return nil, fmt.Errorf("%w: window must be between 2 and %d", ErrInvalidAnomalyQuery, MaxAnomalyWindow)
+	}
+	if len(req.Fields) == 0 {
+		req.Fields = sensorFields
+	}
+	for _, f := range req.Fields {
+		if !slices.Contains(sensorFields, f) {
+			return nil, ErrUnknownField
+		}
+	}
+
+	readings, err := s.sensors.Find(ctx, repository.SensorFilter{
+		DeviceID: req.DeviceID,
+		From:     req.From,
+		To:       req.To,
+		Limit:    maxScannedReadings + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(readings) > maxScannedReadings {
+		return nil, ErrTooManyReadings
+	}
+	// Find returns newest first.
+	slices.Reverse(readings)
+
+	now := time.Now().UTC()
+	out := []models.SensorAnomaly{}
+	for _, f := range compact(req.Fields) {
+		values := make([]float64, len(readings))
+		for i, d := range readings {
+			values[i] = d.Sensors.Fields()[f].Value
+		}
+		for _, o := range alert.FindOutliers(values, req.Window, s.warmUp, req.ZThreshold) {
+			d := readings[o.Index]
+			out = append(out, models.SensorAnomaly{
+				ID:         models.SensorAnomalyID(req.DeviceID, d.Timestamp, f, models.AnomalyReasonZScore),
+				DeviceID:   req.DeviceID,
+				ReadingID:  d.ID,
+				Field:      f,
+				Value:      values[o.Index],
+				Reason:     models.AnomalyReasonZScore,
+				Mean:       o.Mean,
+				StdDev:     o.StdDev,
+				ZScore:     o.ZScore,
+				Timestamp:  d.Timestamp,
+				DetectedAt: now,
+			})
+		}
+	}
+	slices.SortStableFunc(out, func(a, b models.SensorAnomaly) int { return a.Timestamp.Compare(b.Timestamp) })
+	return out, nil
+}
@@ -0,0 +1,174 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: anomaly_service_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of scanning stored readings for anomalies and storing live ones.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/alert"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+var testAnomalyConfig = config.AlertConfig{AnomalyK: 3, AnomalyWindow: 4, AnomalyMinSamples: 2}
+
+func TestAnomalyScan(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	type flag struct {
+		minute       int
+		mean, stddev float64
+		z            float64
+	}
+	tests := []struct {
+		name    string
+		values  []float64
+		req     AnomalyRequest
+		want    []flag
+		wantErr error
+	}{
+		{
+			// The window before the spike is 10, 12, 10, 12: mean 11,
+			// standard deviation 1.
+			name:   "spike flagged",
+			values: []float64{10, 12, 10, 12, 30, 11},
+			req:    AnomalyRequest{Fields: []string{"pm25"}},
+			want:   []flag{{minute: 4, mean: 11, stddev: 1, z: 19}},
+		},
+		{
+			name:   "drop flagged",
+			values: []float64{10, 12, 10, 12, 5},
+			req:    AnomalyRequest{Fields: []string{"pm25"}},
+			want:   []flag{{minute: 4, mean: 11, stddev: 1, z: -6}},
+		},
+		{
+			name:   "within the threshold",
+			values: []float64{10, 12, 10, 12, 13},
+			req:    AnomalyRequest{Fields: []string{"pm25"}},
+		},
+		{
+			name:   "lower threshold",
+			values: []float64{10, 12, 10, 12, 13},
+			req:    AnomalyRequest{Fields: []string{"pm25"}, ZThreshold: 1.5},
+			want:   []flag{{minute: 4, mean: 11, stddev: 1, z: 2}},
+		},
+		{
+			// Over a window of two, 10 and 12, the 20 is 9 deviations
+			// away. The windows before it do not vary.
+			name:   "window",
+			values: []float64{10, 10, 10, 12, 20},
+			req:    AnomalyRequest{Fields: []string{"pm25"}, Window: 2},
+			want:   []flag{{minute: 4, mean: 11, stddev: 1, z: 9}},
+		},
+		{
+			name:   "steady readings",
+			values: []float64{10, 10, 10, 10, 10},
+			req:    AnomalyRequest{Fields: []string{"pm25"}},
+		},
+		{
+			name:   "warm-up",
+			values: []float64{10, 30},
+			req:    AnomalyRequest{Fields: []string{"pm25"}},
+		},
+		{
+			name:    "negative threshold",
+			req:     AnomalyRequest{ZThreshold: -1},
+			wantErr: ErrInvalidAnomalyQuery,
+		},
+		{
+			name:    "window too small",
+			req:     AnomalyRequest{Window: 1},
+			wantErr: ErrInvalidAnomalyQuery,
+		},
+		{
+			name:    "window too large",
+			req:     AnomalyRequest{Window: MaxAnomalyWindow + 1},
+			wantErr: ErrInvalidAnomalyQuery,
+		},
+		{
+			name:    "unknown field",
+			req:     AnomalyRequest{Fields: []string{"ozone"}},
+			wantErr: ErrUnknownField,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &seriesRepo{}
+			for i, v := range tt.values {
+				repo.readings = append(repo.readings, reading(t0.Add(time.Duration(i)*time.Minute), v))
+				repo.readings[i].ID = fmt.Sprintf("r%d", i)
+			}
+			s := NewAnomalyService(repo, testAnomalyConfig)
+			tt.req.DeviceID = "d1"
+			got, err := s.Scan(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Scan() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Scan() = %+v, want %d anomalies", got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				a := got[i]
+				at := t0.Add(time.Duration(w.minute) * time.Minute)
+				if !a.Timestamp.Equal(at) || a.ReadingID != repo.readings[w.minute].ID || a.Field != "pm25" ||
+					a.Reason != models.AnomalyReasonZScore {
+					t.Errorf("anomaly %d = %s %s %s %s, want the pm25 of minute %d", i, a.ReadingID, a.Field, a.Reason, a.Timestamp, w.minute)
+				}
+				if math.Abs(a.Mean-w.mean) > 1e-9 || math.Abs(a.StdDev-w.stddev) > 1e-9 || math.Abs(a.ZScore-w.z) > 1e-9 {
+					t.Errorf("anomaly %d scored mean %v stddev %v z %v, want %v %v %v", i, a.Mean, a.StdDev, a.ZScore, w.mean, w.stddev, w.z)
+				}
+			}
+		})
+	}
+}
+
+type memAnomalies struct {
+	repository.SensorAnomalyRepository
+	stored map[string]models.SensorAnomaly
+}
+
+func (r *memAnomalies) Upsert(_ context.Context, anomalies []models.SensorAnomaly) error {
+	for _, a := range anomalies {
+		r.stored[a.ID] = a
+	}
+	return nil
+}
+
+func TestSensorDetectAnomalies(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	flagged := &memAnomalies{stored: map[string]models.SensorAnomaly{}}
+	s := &SensorService{anomalies: alert.NewAnomalyDetector(testAnomalyConfig), flagged: flagged}
+	for i, v := range []float64{10, 12, 10, 12, 30} {
+		d := reading(t0.Add(time.Duration(i)*time.Minute), v)
+		s.detectAnomalies(context.Background(), &d)
+	}
+	// Every field of the spike is flagged, each once.
+	at := t0.Add(4 * time.Minute)
+	for _, f := range sensorFields {
+		a, ok := flagged.stored[models.SensorAnomalyID("d1", at, f, models.AnomalyReasonZScore)]
+		if !ok {
+			t.Errorf("%s of the spike not stored", f)
+			continue
+		}
+		if a.DeviceID != "d1" || a.Field != f || !a.Timestamp.Equal(at) || math.Abs(a.ZScore-19) > 1e-9 {
+			t.Errorf("stored %+v", a)
+		}
+	}
+	if len(flagged.stored) != len(sensorFields) {
+		t.Errorf("stored %d anomalies, want %d", len(flagged.stored), len(sensorFields))
+	}
+}
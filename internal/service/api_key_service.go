@@ -0,0 +1,204 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: api_key_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic of user API keys in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// userKeyPrefix tells user keys apart from device keys (apiKeyPrefix).
+const userKeyPrefix = "asu_"
+
+// keyLastUsedResolution limits how often a busy key rewrites LastUsedAt.
+const keyLastUsedResolution = time.Minute
+
+// maxKeyNameLength bounds the name of a key, in characters.
+const maxKeyNameLength = 64
+
+// CreatedAPIKey is returned once, when a key is created.
+type CreatedAPIKey struct {
+	models.APIKey `bson:",inline"`
+	Key           string `json:"key"`
+}
+
+type APIKeyService struct {
+	repo repository.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// Create mints a key named name for userID. Only its hash is stored, so
+// the returned Key cannot be retrieved again.
+func (s *APIKeyService) Create(ctx context.Context, userID, name string, permissions []string, expiresAt *time.Time) (*CreatedAPIKey, error) {
+	now := time.Now().UTC()
+	name, err := normalizeKeyName(name)
+	if err != nil {
+		return nil, err
+	}
+	perms, err := normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidKeyRequest)
+	}
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	key := userKeyPrefix + secret
+	k := models.APIKey{
+		ID:          primitive.NewObjectID().Hex(),
+		UserID:      userID,
+		Name:        name,
+		KeyHash:     auth.HashToken(key),
+		Permissions: perms,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   now,
+	}
+	if err := s.repo.Create(ctx, &k); err != nil {
+		return nil, err
+	}
+	return &CreatedAPIKey{APIKey: k, Key: key}, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, userID string) ([]models.APIKey, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *APIKeyService) Get(ctx context.Context, userID, id string) (*models.APIKey, error) {
+	k, err := s.repo.GetByID(ctx, userID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrKeyNotFound
+	}
+	return k, err
+}
+
+// APIKeyUpdate changes the fields of a key that are set. ClearExpiry makes
+// the key never expire.
+type APIKeyUpdate struct {
+	Name        *string
+	Permissions []string
+	ExpiresAt   *time.Time
+	ClearExpiry bool
+}
+
+func (s *APIKeyService) Update(ctx context.Context, userID, id string, u APIKeyUpdate) (*models.APIKey, error) {
+	set := map[string]any{}
+	var unset []string
+	if u.Name != nil {
+		name, err := normalizeKeyName(*u.Name)
+		if err != nil {
+			return nil, err
+		}
+		set["name"] = name
+	}
+	if u.Permissions != nil {
+		perms, err := normalizePermissions(u.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		set["permissions"] = perms
+	}
+	switch {
+	case u.ClearExpiry && u.ExpiresAt != nil:
+		return nil, fmt.Errorf("%w: expires_at cannot be set and cleared at once", ErrInvalidKeyRequest)
+	case u.ClearExpiry:
+		unset = append(unset, "expires_at")
+	case u.ExpiresAt != nil:
+		if !u.ExpiresAt.After(time.Now()) {
+			return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidKeyRequest)
+		}
+		set["expires_at"] = u.ExpiresAt.UTC()
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		return s.Get(ctx, userID, id)
+	}
+	k, err := s.repo.Update(ctx, userID, id, set, unset)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrKeyNotFound
+	}
+	return k, err
+}
+
+func (s *APIKeyService) Delete(ctx context.Context, userID, id string) error {
+	err := s.repo.Delete(ctx, userID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrKeyNotFound
+	}
+	return err
+}
+
+// Authenticate resolves a raw key. Unknown keys return ErrInvalidAPIKey
+// and expired ones ErrAPIKeyExpired.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	k, err := s.repo.GetByHash(ctx, auth.HashToken(key))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if k.Expired(now) {
+		return nil, ErrAPIKeyExpired
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= keyLastUsedResolution {
+		if err := s.repo.Touch(ctx, k.ID, now); err != nil {
+			log.Printf("apikeys: record use of key %s: %v", k.ID, err)
+		}
+	}
+	return k, nil
+}
+
+// normalizeKeyName trims name, which must then be 1 to maxKeyNameLength
+// characters.
+func normalizeKeyName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxKeyNameLength {
+		return "", fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidKeyRequest, maxKeyNameLength)
+	}
+	return name, nil
+}
+
+// normalizePermissions validates and deduplicates permissions, which must
+// not be empty.
+func normalizePermissions(permissions []string) ([]string, error) {
+	if len(permissions) == 0 {
+		return nil, fmt.Errorf("%w: at least one permission is required", ErrInvalidKeyRequest)
+	}
+	out := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		if !models.ValidPermission(p) {
+			return nil, fmt.Errorf("%w: permission %q must be %s or %s", ErrInvalidKeyRequest, p, models.PermissionRead, models.PermissionWrite)
+		}
+		if !slices.Contains(out, p) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
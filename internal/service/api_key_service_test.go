@@ -0,0 +1,162 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: api_key_service_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of creating and authenticating user API keys.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// memKeys stores API keys by ID.
+type memKeys struct {
+	repository.APIKeyRepository
+	keys    map[string]*models.APIKey
+	touched []string
+}
+
+func (r *memKeys) Create(_ context.Context, k *models.APIKey) error {
+	cp := *k
+	r.keys[k.ID] = &cp
+	return nil
+}
+
+func (r *memKeys) GetByHash(_ context.Context, hash string) (*models.APIKey, error) {
+	for _, k := range r.keys {
+		if k.KeyHash == hash {
+			cp := *k
+			return &cp, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memKeys) GetByID(_ context.Context, userID, id string) (*models.APIKey, error) {
+	k, ok := r.keys[id]
+	if !ok || k.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	cp := *k
+	return &cp, nil
+}
+
+func (r *memKeys) Touch(_ context.Context, id string, at time.Time) error {
+	r.touched = append(r.touched, id)
+	r.keys[id].LastUsedAt = &at
+	return nil
+}
+
+func TestAPIKeyCreate(t *testing.T) {
+	repo := &memKeys{keys: map[string]*models.APIKey{}}
+	s := NewAPIKeyService(repo)
+	ctx := context.Background()
+	created, err := s.Create(ctx, "u1", " ci ", []string{"read", "read"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(created.Key, userKeyPrefix) {
+		t.Errorf("key = %q, want prefix %q", created.Key, userKeyPrefix)
+	}
+	if created.Name != "ci" || len(created.Permissions) != 1 {
+		t.Errorf("name %q, permissions %v, want ci and [read]", created.Name, created.Permissions)
+	}
+
+	// Only the hash is stored, and reading the key back never shows it.
+	stored := repo.keys[created.ID]
+	if stored.KeyHash != auth.HashToken(created.Key) || strings.Contains(stored.KeyHash, created.Key) {
+		t.Errorf("stored hash %q is not the hash of the key", stored.KeyHash)
+	}
+	got, err := s.Get(ctx, "u1", created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), created.Key) || strings.Contains(string(body), stored.KeyHash) {
+		t.Errorf("key readable after creation: %s", body)
+	}
+	if _, err := s.Get(ctx, "u2", created.ID); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("key of another user: err = %v, want ErrKeyNotFound", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	rejected := []struct {
+		name        string
+		keyName     string
+		permissions []string
+		expiresAt   *time.Time
+	}{
+		{"no name", "  ", []string{"read"}, nil},
+		{"long name", strings.Repeat("k", maxKeyNameLength+1), []string{"read"}, nil},
+		{"no permissions", "ci", nil, nil},
+		{"unknown permission", "ci", []string{"admin"}, nil},
+		{"expired", "ci", []string{"read"}, &past},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Create(ctx, "u1", tt.keyName, tt.permissions, tt.expiresAt); !errors.Is(err, ErrInvalidKeyRequest) {
+				t.Errorf("err = %v, want ErrInvalidKeyRequest", err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	now := time.Now()
+	past, future, recent := now.Add(-time.Second), now.Add(time.Hour), now.Add(-time.Second)
+	repo := &memKeys{keys: map[string]*models.APIKey{
+		"k1": {ID: "k1", UserID: "u1", KeyHash: auth.HashToken("asu_live")},
+		"k2": {ID: "k2", UserID: "u1", KeyHash: auth.HashToken("asu_expired"), ExpiresAt: &past},
+		"k3": {ID: "k3", UserID: "u1", KeyHash: auth.HashToken("asu_later"), ExpiresAt: &future},
+		"k4": {ID: "k4", UserID: "u1", KeyHash: auth.HashToken("asu_busy"), LastUsedAt: &recent},
+	}}
+	s := NewAPIKeyService(repo)
+
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr error
+		touched bool
+	}{
+		{"live key", "asu_live", "k1", nil, true},
+		{"expired key", "asu_expired", "", ErrAPIKeyExpired, false},
+		{"key expiring later", "asu_later", "k3", nil, true},
+		{"key used just now", "asu_busy", "k4", nil, false},
+		{"unknown key", "asu_guess", "", ErrInvalidAPIKey, false},
+		{"hash as key", auth.HashToken("asu_live"), "", ErrInvalidAPIKey, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.touched = nil
+			k, err := s.Authenticate(context.Background(), tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && k.ID != tt.want {
+				t.Errorf("key = %s, want %s", k.ID, tt.want)
+			}
+			if got := len(repo.touched) > 0; got != tt.touched {
+				t.Errorf("touched = %v, want %v", got, tt.touched)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_batch.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic for bulk commands in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+const maxBatchDevices = 200
+
+type CommandBatchService struct {
+	repo     repository.CommandBatchRepository
+	commands *CommandService
+	devices  *DeviceService
+	groups   *GroupService
+}
+
+func NewCommandBatchService(repo repository.CommandBatchRepository, commands *CommandService,
+	devices *DeviceService, groups *GroupService) *CommandBatchService {
+	return &CommandBatchService{repo: repo, commands: commands, devices: devices, groups: groups}
+}
+
+// BatchRequest sends one command to DeviceIDs or to the devices of the
+// user's group GroupID.
+type BatchRequest struct {
+	UserID    string
+	DeviceIDs []string
+	GroupID   string
+	Action    string
+	Params    map[string]any
+	TTL       time.Duration
+	Retry     *models.CommandRetry
+}
+
+// BatchSummary is a batch with the current status of its commands.
+type BatchSummary struct {
+	*models.CommandBatch
+	// Counts has an entry for every status.
+	Counts   map[models.CommandStatus]int `json:"counts"`
+	Commands []models.Command             `json:"commands"`
+}
+
+// Create creates and publishes one command per device the user may
+// control. Devices they cannot see or control, and devices whose command
+// could not be stored, are skipped instead of failing the batch; a command
+// that was stored but not published counts with status error. The batch is
+// stored before its commands, so every command's BatchID names a batch
+// even if creating the batch fails partway, and its skipped devices are
+// added once all commands were created.
+func (s *CommandBatchService) Create(ctx context.Context, req BatchRequest) (*BatchSummary, error) {
+	if (len(req.DeviceIDs) == 0) == (req.GroupID == "") {
+		return nil, fmt.Errorf("%w: either device_ids or group_id is required", ErrInvalidCommand)
+	}
+	if err := validateCommand(CommandRequest{Action: req.Action, Params: req.Params, TTL: req.TTL, Retry: req.Retry}); err != nil {
+		return nil, err
+	}
+	deviceIDs := req.DeviceIDs
+	if req.GroupID != "" {
+		g, err := s.groups.Get(ctx, req.GroupID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		deviceIDs = g.DeviceIDs
+	}
+	seen := make(map[string]bool, len(deviceIDs))
+	ids := make([]string, 0, len(deviceIDs))
+	for _, id := range deviceIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > maxBatchDevices {
+		return nil, fmt.Errorf("%w: at most %d devices per batch", ErrInvalidCommand, maxBatchDevices)
+	}
+
+	b := &models.CommandBatch{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    req.UserID,
+		GroupID:   req.GroupID,
+		Action:    req.Action,
+		Params:    req.Params,
+		Skipped:   []models.SkippedDevice{},
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.Create(ctx, b); err != nil {
+		return nil, err
+	}
+	cmds := make([]models.Command, 0, len(ids))
+	for _, id := range ids {
+		if _, err := s.devices.Authorize(ctx, id, req.UserID, AccessControl, false); err != nil {
+			b.Skipped = append(b.Skipped, models.SkippedDevice{DeviceID: id, Reason: skipReason(b.ID, id, err)})
+			continue
+		}
+		cmd, err := s.commands.Create(ctx, CommandRequest{
+			DeviceID: id,
+			Action:   req.Action,
+			Params:   req.Params,
+			TTL:      req.TTL,
+			Retry:    req.Retry,
+			UserID:   req.UserID,
+			BatchID:  b.ID,
+		})
+		if err != nil && !errors.Is(err, ErrCommandNotPublished) {
+			b.Skipped = append(b.Skipped, models.SkippedDevice{DeviceID: id, Reason: skipReason(b.ID, id, err)})
+			continue
+		}
+		cmds = append(cmds, *cmd)
+	}
+	if len(b.Skipped) > 0 {
+		if err := s.repo.SetSkipped(ctx, b.ID, b.Skipped); err != nil {
+			return nil, err
+		}
+	}
+	return summarize(b, cmds), nil
+}
+
+// Get returns a batch of userID with the current status of its commands.
+func (s *CommandBatchService) Get(ctx context.Context, id, userID string) (*BatchSummary, error) {
+	b, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrBatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.UserID != userID {
+		return nil, ErrBatchNotFound
+	}
+	cmds, err := s.commands.repo.FindByBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return summarize(b, cmds), nil
+}
+
+func summarize(b *models.CommandBatch, cmds []models.Command) *BatchSummary {
+	counts := map[models.CommandStatus]int{
+		models.CommandPending:   0,
+		models.CommandSent:      0,
+		models.CommandQueued:    0,
+		models.CommandSuccess:   0,
+		models.CommandError:     0,
+		models.CommandTimedOut:  0,
+		models.CommandCancelled: 0,
+	}
+	for _, cmd := range cmds {
+		counts[cmd.Status]++
+	}
+	return &BatchSummary{CommandBatch: b, Counts: counts, Commands: cmds}
+}
+
+// skipReason maps why a device got no command to what the batch reports,
+// logging unexpected errors.
+func skipReason(batchID, deviceID string, err error) models.SkipReason {
+	switch {
+	case errors.Is(err, ErrDeviceNotFound):
+		return models.SkipNotFound
+	case errors.Is(err, ErrForbidden):
+		return models.SkipForbidden
+	case errors.Is(err, ErrDeviceDecommissioned):
+		return models.SkipDecommissioned
+	}
+	log.Printf("commands: batch %s: device %s: %v", batchID, deviceID, err)
+	return models.SkipFailed
+}
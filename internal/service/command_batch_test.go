@@ -0,0 +1,125 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_batch_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of creating command batches.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// memBatches keeps batches in memory, appending its writes to log, and
+// fails Create with err when it is set.
+type memBatches struct {
+	repository.CommandBatchRepository
+	batches map[string]models.CommandBatch
+	err     error
+	log     *[]string
+}
+
+func (r *memBatches) Create(_ context.Context, b *models.CommandBatch) error {
+	*r.log = append(*r.log, "insert batch")
+	if r.err != nil {
+		return r.err
+	}
+	r.batches[b.ID] = *b
+	return nil
+}
+
+func (r *memBatches) SetSkipped(_ context.Context, id string, skipped []models.SkippedDevice) error {
+	*r.log = append(*r.log, "set skipped")
+	b, ok := r.batches[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	b.Skipped = skipped
+	r.batches[id] = b
+	return nil
+}
+
+func TestCommandBatchCreate(t *testing.T) {
+	sent := []string{"insert pending", "publish", "transition sent"}
+	tests := []struct {
+		name        string
+		deviceIDs   []string
+		createErr   error
+		wantErr     bool
+		wantLog     []string
+		wantSkipped []models.SkippedDevice
+		wantCmds    int
+	}{
+		{
+			name:      "all devices",
+			deviceIDs: []string{"d1", "d2"},
+			wantLog:   slices.Concat([]string{"insert batch"}, sent, sent),
+			wantCmds:  2,
+		},
+		{
+			name:        "skipped devices",
+			deviceIDs:   []string{"d1", "d3", "d4"},
+			wantLog:     slices.Concat([]string{"insert batch"}, sent, []string{"set skipped"}),
+			wantSkipped: []models.SkippedDevice{{DeviceID: "d3", Reason: models.SkipForbidden}, {DeviceID: "d4", Reason: models.SkipNotFound}},
+			wantCmds:    1,
+		},
+		{
+			name:      "batch not stored",
+			deviceIDs: []string{"d1", "d2"},
+			createErr: errors.New("down"),
+			wantErr:   true,
+			wantLog:   []string{"insert batch"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds, repo, _, log := newTestCommandService(nil)
+			devices := cmds.devices.(*cmdDevices)
+			devices.devices["d1"].UserID = "u1"
+			devices.devices["d2"].UserID, devices.devices["d2"].Status = "u1", models.DeviceOnline
+			devices.devices["d3"] = &models.Device{ID: "d3", UserID: "u2", Status: models.DeviceOnline}
+			batches := &memBatches{batches: make(map[string]models.CommandBatch), err: tt.createErr, log: log}
+			s := &CommandBatchService{repo: batches, commands: cmds, devices: &DeviceService{repo: devices, shares: noShares{}}}
+
+			summary, err := s.Create(context.Background(), BatchRequest{UserID: "u1", DeviceIDs: tt.deviceIDs, Action: "reboot"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(*log, tt.wantLog) {
+				t.Errorf("calls = %q, want %q", *log, tt.wantLog)
+			}
+			if err != nil {
+				if len(repo.cmds) != 0 {
+					t.Errorf("stored %d commands without their batch", len(repo.cmds))
+				}
+				return
+			}
+			stored, ok := batches.batches[summary.ID]
+			if !ok {
+				t.Fatal("batch not stored")
+			}
+			if !slices.Equal(stored.Skipped, tt.wantSkipped) {
+				t.Errorf("stored skipped = %v, want %v", stored.Skipped, tt.wantSkipped)
+			}
+			if len(summary.Commands) != tt.wantCmds || summary.Counts[models.CommandSent] != tt.wantCmds {
+				t.Errorf("summary has %d commands, %d sent, want %d", len(summary.Commands), summary.Counts[models.CommandSent], tt.wantCmds)
+			}
+			for _, cmd := range repo.cmds {
+				if cmd.BatchID != summary.ID {
+					t.Errorf("command %s has batch %q, want %q", cmd.CommandID, cmd.BatchID, summary.ID)
+				}
+			}
+		})
+	}
+}
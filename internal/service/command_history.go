@@ -0,0 +1,56 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_history.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the command history query of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// History returns one page of the commands sent to a device, newest
+// first, and the cursor of the next page (nil on the last page). The cursor
+// is the creation time and ID of the last command returned.
+func (s *CommandService) History(ctx context.Context, filter repository.CommandFilter, cursor string) ([]models.Command, *string, error) {
+	raw, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	if raw != "" {
+		ts, id, ok := strings.Cut(raw, "|")
+		if !ok {
+			return nil, nil, utils.ErrInvalidCursor
+		}
+		if filter.BeforeCreatedAt, err = time.Parse(time.RFC3339Nano, ts); err != nil {
+			return nil, nil, utils.ErrInvalidCursor
+		}
+		filter.BeforeID = id
+	}
+
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	cmds, err := s.repo.Find(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(cmds)) <= limit {
+		return cmds, nil, nil
+	}
+	cmds = cmds[:limit]
+	last := cmds[len(cmds)-1]
+	next := utils.EncodeCursor(last.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + last.CommandID)
+	return cmds, &next, nil
+}
@@ -0,0 +1,105 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_history_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the paging of the command history.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// historyRepo returns n commands, newest first, and records the filter of
+// the last Find.
+type historyRepo struct {
+	repository.CommandRepository
+	n      int
+	filter repository.CommandFilter
+}
+
+func (r *historyRepo) Find(_ context.Context, filter repository.CommandFilter) ([]models.Command, error) {
+	r.filter = filter
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	var cmds []models.Command
+	for i := r.n; i > 0 && int64(len(cmds)) < filter.Limit; i-- {
+		cmds = append(cmds, models.Command{CommandID: fmt.Sprintf("c%d", i), CreatedAt: base.Add(time.Duration(i) * time.Minute)})
+	}
+	return cmds, nil
+}
+
+func TestCommandHistory(t *testing.T) {
+	at := time.Date(2026, 10, 1, 0, 3, 0, 500, time.UTC)
+	tests := []struct {
+		name       string
+		n          int
+		limit      int64
+		cursor     string
+		wantLen    int
+		wantNext   bool
+		wantBefore time.Time
+		wantID     string
+		wantErr    error
+	}{
+		{name: "last page", n: 3, limit: 5, wantLen: 3},
+		{name: "exactly one page", n: 5, limit: 5, wantLen: 5},
+		{name: "more pages", n: 6, limit: 5, wantLen: 5, wantNext: true},
+		{name: "cursor", n: 2, limit: 5, cursor: utils.EncodeCursor(at.Format(time.RFC3339Nano) + "|c3"),
+			wantLen: 2, wantBefore: at, wantID: "c3"},
+		{name: "cursor without ID", limit: 5, cursor: utils.EncodeCursor(at.Format(time.RFC3339Nano)), wantErr: utils.ErrInvalidCursor},
+		{name: "cursor with bad time", limit: 5, cursor: utils.EncodeCursor("yesterday|c3"), wantErr: utils.ErrInvalidCursor},
+		{name: "cursor not base64", limit: 5, cursor: "!!", wantErr: utils.ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &historyRepo{n: tt.n}
+			s := &CommandService{repo: repo}
+			cmds, next, err := s.History(context.Background(), repository.CommandFilter{DeviceID: "d1", Limit: tt.limit}, tt.cursor)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("History() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cmds) != tt.wantLen {
+				t.Errorf("got %d commands, want %d", len(cmds), tt.wantLen)
+			}
+			if repo.filter.Limit != tt.limit+1 {
+				t.Errorf("Find limit = %d, want %d", repo.filter.Limit, tt.limit+1)
+			}
+			if !repo.filter.BeforeCreatedAt.Equal(tt.wantBefore) || repo.filter.BeforeID != tt.wantID {
+				t.Errorf("Find before = %v %q, want %v %q", repo.filter.BeforeCreatedAt, repo.filter.BeforeID, tt.wantBefore, tt.wantID)
+			}
+			if (next != nil) != tt.wantNext {
+				t.Fatalf("next cursor = %v, want one: %v", next, tt.wantNext)
+			}
+			if next == nil {
+				return
+			}
+			// The next page starts after the last command returned.
+			last := cmds[len(cmds)-1]
+			if _, _, err := s.History(context.Background(), repository.CommandFilter{DeviceID: "d1", Limit: tt.limit}, *next); err != nil {
+				t.Fatal(err)
+			}
+			if !repo.filter.BeforeCreatedAt.Equal(last.CreatedAt) || repo.filter.BeforeID != last.CommandID {
+				t.Errorf("next page before = %v %q, want %v %q", repo.filter.BeforeCreatedAt, repo.filter.BeforeID, last.CreatedAt, last.CommandID)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_metadata_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the client metadata of commands.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCommandMetadata(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := range 11 {
+		tooMany["k"+strconv.Itoa(i)] = "v"
+	}
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		wantErr  error
+	}{
+		{"none", nil, nil},
+		{"ticket", map[string]string{"ticket": "OPS-42", "requested_by": "jane"}, nil},
+		{"ten keys", map[string]string{"k0": "", "k1": "", "k2": "", "k3": "", "k4": "", "k5": "", "k6": "", "k7": "", "k8": "", "k9": ""}, nil},
+		{"eleven keys", tooMany, ErrInvalidCommand},
+		{"longest key", map[string]string{strings.Repeat("k", 64): "v"}, nil},
+		{"key too long", map[string]string{strings.Repeat("k", 65): "v"}, ErrInvalidCommand},
+		{"empty key", map[string]string{"": "v"}, ErrInvalidCommand},
+		{"dotted key", map[string]string{"a.b": "v"}, ErrInvalidCommand},
+		{"operator key", map[string]string{"$where": "v"}, ErrInvalidCommand},
+		{"longest value", map[string]string{"note": strings.Repeat("é", 512)}, nil},
+		{"value too long", map[string]string{"note": strings.Repeat("e", 513)}, ErrInvalidCommand},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _, _ := newTestCommandService(nil)
+			cmd, err := s.Create(context.Background(), CommandRequest{DeviceID: "d1", Action: "reboot", UserID: "u1", Metadata: tt.metadata})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.cmds) != 0 {
+					t.Error("a command with invalid metadata was stored")
+				}
+				return
+			}
+			stored, err := repo.GetByID(context.Background(), cmd.CommandID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(stored.Metadata, tt.metadata) {
+				t.Errorf("stored metadata = %v, want %v", stored.Metadata, tt.metadata)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_ota.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the firmware update (OTA) commands of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+var (
+	sha256Pattern          = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+	firmwareVersionPattern = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z.+_-]{0,63}$`)
+)
+
+// PublishFirmwareUpdate sends deviceID an ota_update command for the
+// firmware version at rawURL with the SHA-256 checksum sha256, issued by
+// userID. It is tracked like any other command, see Create.
+func (s *CommandService) PublishFirmwareUpdate(ctx context.Context, userID, deviceID, version, rawURL, sha256 string) (*models.Command, error) {
+	return s.Create(ctx, CommandRequest{
+		DeviceID: deviceID,
+		Action:   models.ActionOTAUpdate,
+		Params:   map[string]any{"version": version, "url": rawURL, "sha256": sha256},
+		UserID:   userID,
+	})
+}
+
+// validateOTA checks the params of an ota_update command beyond their
+// types: an HTTPS download URL, a hex SHA-256 checksum and a version
+// usable in device queries.
+func validateOTA(params map[string]any) error {
+	version, _ := params["version"].(string)
+	if !firmwareVersionPattern.MatchString(version) {
+		return fmt.Errorf("%w: version must be 1-64 letters, digits, '.', '+', '_' or '-'", ErrInvalidCommand)
+	}
+	rawURL, _ := params["url"].(string)
+	if u, err := url.Parse(rawURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute https URL", ErrInvalidCommand)
+	}
+	sum, _ := params["sha256"].(string)
+	if !sha256Pattern.MatchString(sum) {
+		return fmt.Errorf("%w: sha256 must be 64 hexadecimal digits", ErrInvalidCommand)
+	}
+	return nil
+}
+
+// checkNoOTA returns ErrOTAInProgress if deviceID has an ota_update
+// command that is not final yet. Two requests may both pass it; the unique
+// index on open updates then refuses the second insert.
+func (s *CommandService) checkNoOTA(ctx context.Context, deviceID string) error {
+	cmds, err := s.repo.Find(ctx, repository.CommandFilter{
+		DeviceID: deviceID,
+		Action:   models.ActionOTAUpdate,
+		Statuses: []models.CommandStatus{models.CommandQueued, models.CommandPending, models.CommandSent},
+		Limit:    1,
+	})
+	if err != nil {
+		return err
+	}
+	if len(cmds) > 0 {
+		return ErrOTAInProgress
+	}
+	return nil
+}
+
+// recordFirmware keeps the firmware fields of the device of an ota_update
+// command up to date: the target when it is created, the version once it
+// succeeded, and no target once it failed, timed out or was cancelled.
+// Failures are logged; the command itself stands.
+func (s *CommandService) recordFirmware(ctx context.Context, cmd *models.Command) {
+	version, _ := cmd.Params["version"].(string)
+	var err error
+	switch {
+	case cmd.Status == models.CommandSuccess:
+		if _, err = s.devices.Update(ctx, cmd.DeviceID, map[string]any{"firmware_version": version}); err == nil {
+			_, err = s.devices.Unset(ctx, cmd.DeviceID, []string{"firmware_target"})
+		}
+	case cmd.Status.Terminal():
+		_, err = s.devices.Unset(ctx, cmd.DeviceID, []string{"firmware_target"})
+	default:
+		_, err = s.devices.Update(ctx, cmd.DeviceID, map[string]any{"firmware_target": version})
+	}
+	if err != nil {
+		log.Printf("commands: record firmware %s of device %s: %v", version, cmd.DeviceID, err)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_ota_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of firmware update (OTA) commands.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+func otaRequest(deviceID, version string) CommandRequest {
+	return CommandRequest{
+		DeviceID: deviceID,
+		Action:   models.ActionOTAUpdate,
+		Params: map[string]any{
+			"version": version,
+			"url":     "https://firmware.example.com/airsense-" + version + ".bin",
+			"sha256":  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		},
+		UserID: "u1",
+	}
+}
+
+func TestOTAFirmwareTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		end         func(s *CommandService, cmd *models.Command) error
+		wantStatus  models.CommandStatus
+		wantVersion string
+	}{
+		{
+			name: "installed",
+			end: func(s *CommandService, cmd *models.Command) error {
+				_, err := s.UpdateStatus(context.Background(), "d1", cmd.CommandID, CommandAck{Status: models.CommandSuccess})
+				return err
+			},
+			wantStatus: models.CommandSuccess, wantVersion: "2.0.0",
+		},
+		{
+			name: "failed",
+			end: func(s *CommandService, cmd *models.Command) error {
+				_, err := s.UpdateStatus(context.Background(), "d1", cmd.CommandID, CommandAck{Status: models.CommandError, Error: "bad image"})
+				return err
+			},
+			wantStatus: models.CommandError, wantVersion: "1.0.0",
+		},
+		{
+			name: "timed out",
+			end: func(s *CommandService, cmd *models.Command) error {
+				_, err := s.ReapExpired(context.Background(), time.Now().Add(2*time.Hour))
+				return err
+			},
+			wantStatus: models.CommandTimedOut, wantVersion: "1.0.0",
+		},
+		{
+			name: "cancelled",
+			end: func(s *CommandService, cmd *models.Command) error {
+				_, err := s.Cancel(context.Background(), "d1", cmd.CommandID)
+				return err
+			},
+			wantStatus: models.CommandCancelled, wantVersion: "1.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _, _ := newTestCommandService(nil)
+			device := s.devices.(*cmdDevices).devices["d1"]
+			device.FirmwareVersion = "1.0.0"
+			req := otaRequest("d1", "2.0.0")
+			req.TTL = time.Hour
+			cmd, err := s.Create(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if device.FirmwareTarget != "2.0.0" {
+				t.Fatalf("target after create = %q, want 2.0.0", device.FirmwareTarget)
+			}
+			if err := tt.end(s, cmd); err != nil {
+				t.Fatal(err)
+			}
+			if got := repo.cmds[cmd.CommandID].Status; got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+			if device.FirmwareTarget != "" {
+				t.Errorf("target = %q, want none", device.FirmwareTarget)
+			}
+			if device.FirmwareVersion != tt.wantVersion {
+				t.Errorf("version = %q, want %q", device.FirmwareVersion, tt.wantVersion)
+			}
+		})
+	}
+}
+
+// uniqueOTA finds no open update, as when two requests check before either
+// stored its command, and refuses a second open one like the index.
+type uniqueOTA struct {
+	*memCommands
+}
+
+func (r uniqueOTA) Find(context.Context, repository.CommandFilter) ([]models.Command, error) {
+	return nil, nil
+}
+
+func (r uniqueOTA) Create(ctx context.Context, cmd *models.Command) error {
+	for _, c := range r.cmds {
+		if c.DeviceID == cmd.DeviceID && c.Action == models.ActionOTAUpdate && !c.Status.Terminal() {
+			return repository.ErrDuplicate
+		}
+	}
+	return r.memCommands.Create(ctx, cmd)
+}
+
+func TestOTAInProgress(t *testing.T) {
+	tests := []struct {
+		name   string
+		racing bool
+	}{
+		{"checked before insert", false},
+		{"refused by the index", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _, _ := newTestCommandService(nil)
+			if tt.racing {
+				s.repo = uniqueOTA{repo}
+			}
+			if _, err := s.Create(context.Background(), otaRequest("d1", "2.0.0")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Create(context.Background(), otaRequest("d1", "2.0.1")); !errors.Is(err, ErrOTAInProgress) {
+				t.Errorf("second update: err = %v, want ErrOTAInProgress", err)
+			}
+			if _, err := s.Create(context.Background(), otaRequest("d2", "2.0.1")); err != nil {
+				t.Errorf("update of another device: %v", err)
+			}
+			if got := len(repo.cmds); got != 2 {
+				t.Errorf("stored %d commands, want 2", got)
+			}
+		})
+	}
+}
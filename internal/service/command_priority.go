@@ -0,0 +1,55 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_priority.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the priority ordering and preemption of commands.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"slices"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// sortByPriority orders cmds by priority, highest first, keeping the order
+// of commands with the same priority.
+func sortByPriority(cmds []models.Command) {
+	slices.SortStableFunc(cmds, func(a, b models.Command) int {
+		return cmp.Compare(b.Priority.Rank(), a.Priority.Rank())
+	})
+}
+
+// preempt cancels the queued, pending and sent commands of the device of
+// cmd that have its action and a lower priority, so that they cannot undo
+// cmd once it ran. Failures are logged: they must not hold cmd back.
+func (s *CommandService) preempt(ctx context.Context, cmd *models.Command) {
+	cmds, err := s.repo.Find(ctx, repository.CommandFilter{
+		DeviceID: cmd.DeviceID,
+		Action:   cmd.Action,
+		Statuses: []models.CommandStatus{models.CommandQueued, models.CommandPending, models.CommandSent},
+	})
+	if err != nil {
+		log.Printf("commands: find commands preempted by %s: %v", cmd.CommandID, err)
+		return
+	}
+	for _, c := range cmds {
+		if c.CommandID == cmd.CommandID || c.Priority.Rank() >= cmd.Priority.Rank() {
+			continue
+		}
+		_, err := s.cancel(ctx, c.DeviceID, c.CommandID, models.DetailPreempted)
+		if err != nil && !errors.Is(err, ErrCommandTerminal) {
+			log.Printf("commands: cancel %s preempted by %s: %v", c.CommandID, cmd.CommandID, err)
+		}
+	}
+}
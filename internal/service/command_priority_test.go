@@ -0,0 +1,139 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_priority_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of dispatching commands by priority and of preemption.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+)
+
+// publishedIDs returns the IDs of the commands published, in order.
+func publishedIDs(pub *fakePublisher) []string {
+	ids := make([]string, 0, len(pub.published))
+	for _, c := range pub.published {
+		ids = append(ids, c.CommandID)
+	}
+	return ids
+}
+
+// mixedPriorities are commands of d1 with IDs in the order they were
+// created.
+var mixedPriorities = []struct {
+	id       string
+	priority models.CommandPriority
+}{
+	{"c1", models.PriorityLow},
+	{"c2", models.PriorityNormal},
+	{"c3", models.PriorityHigh},
+	{"c4", models.PriorityNormal},
+	{"c5", models.PriorityHigh},
+	{"c6", models.PriorityLow},
+}
+
+func TestFlushQueuedPriority(t *testing.T) {
+	s, repo, pub, _ := newTestCommandService(nil)
+	base := time.Now().Add(-time.Hour)
+	for i, c := range mixedPriorities {
+		repo.cmds[c.id] = models.Command{CommandID: c.id, DeviceID: "d1", Action: "reboot", Status: models.CommandQueued,
+			Priority: c.priority, CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+	}
+	n, err := s.FlushQueued(context.Background(), "d1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"c3", "c5", "c2", "c4", "c1", "c6"}
+	if got := publishedIDs(pub); !slices.Equal(got, want) {
+		t.Errorf("published %v, want %v", got, want)
+	}
+	if n != len(want) {
+		t.Errorf("flushed %d, want %d", n, len(want))
+	}
+}
+
+func TestRetryDuePriority(t *testing.T) {
+	s, repo, pub, _ := newTestCommandService(nil)
+	due := time.Now().Add(-time.Minute)
+	for _, c := range mixedPriorities {
+		repo.cmds[c.id] = models.Command{CommandID: c.id, DeviceID: "d1", Action: "reboot", Status: models.CommandPending,
+			Priority: c.priority, Attempts: 1, NextAttemptAt: &due, Retry: &models.CommandRetry{MaxAttempts: 3}}
+	}
+	if _, err := s.RetryDue(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"c3", "c5", "c2", "c4", "c1", "c6"}
+	if got := publishedIDs(pub); !slices.Equal(got, want) {
+		t.Errorf("republished %v, want %v", got, want)
+	}
+}
+
+func TestCommandPreempt(t *testing.T) {
+	open := []models.Command{
+		{CommandID: "a", Action: "power", Status: models.CommandQueued, Priority: models.PriorityLow},
+		{CommandID: "b", Action: "power", Status: models.CommandPending, Priority: models.PriorityNormal},
+		{CommandID: "c", Action: "power", Status: models.CommandSent, Priority: models.PriorityNormal},
+		{CommandID: "d", Action: "power", Status: models.CommandSent, Priority: models.PriorityHigh},
+		{CommandID: "e", Action: "power", Status: models.CommandSuccess, Priority: models.PriorityLow},
+		{CommandID: "f", Action: "reboot", Status: models.CommandPending, Priority: models.PriorityLow},
+	}
+	tests := []struct {
+		name      string
+		priority  models.CommandPriority
+		cancelled []string
+	}{
+		{"high preempts lower with the same action", models.PriorityHigh, []string{"a", "b", "c"}},
+		{"normal preempts nothing", models.PriorityNormal, nil},
+		{"default preempts nothing", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _, _ := newTestCommandService(nil)
+			for _, c := range open {
+				c.DeviceID = "d1"
+				repo.cmds[c.CommandID] = c
+			}
+			cmd, err := s.Create(context.Background(), CommandRequest{DeviceID: "d1", Action: "power",
+				Params: map[string]any{"on": true}, Priority: tt.priority, UserID: "u1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.priority; cmd.Priority != want && !(want == "" && cmd.Priority == models.PriorityNormal) {
+				t.Errorf("priority = %q, want %q", cmd.Priority, want)
+			}
+			var cancelled []string
+			for _, c := range open {
+				got := repo.cmds[c.CommandID]
+				if got.Status == models.CommandCancelled {
+					cancelled = append(cancelled, c.CommandID)
+					if got.StatusDetail != models.DetailPreempted {
+						t.Errorf("%s detail = %q, want %q", c.CommandID, got.StatusDetail, models.DetailPreempted)
+					}
+				}
+			}
+			if !slices.Equal(cancelled, tt.cancelled) {
+				t.Errorf("cancelled %v, want %v", cancelled, tt.cancelled)
+			}
+		})
+	}
+}
+
+func TestCommandPriorityValidation(t *testing.T) {
+	s, _, _, _ := newTestCommandService(nil)
+	_, err := s.Create(context.Background(), CommandRequest{DeviceID: "d1", Action: "reboot", Priority: "urgent", UserID: "u1"})
+	if !errors.Is(err, ErrInvalidCommand) {
+		t.Errorf("err = %v, want ErrInvalidCommand", err)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_queue.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the delivery of commands queued for offline devices.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/models"
+)
+
+// reasonExpiredQueued is the Error of a queued command that expired before
+// its device came back online.
+const reasonExpiredQueued = "device offline until expiry"
+
+// FlushQueued publishes the queued commands of deviceID, highest priority
+// and then oldest first, once the device is back online, and returns how
+// many it published. Commands that expired meanwhile time out instead of
+// being delivered. Each command is moved out of the queue before it is
+// published, so only one flush ever delivers it; a flush that is already
+// running for the device makes another one return at once, which keeps
+// the order.
+func (s *CommandService) FlushQueued(ctx context.Context, deviceID string) (int, error) {
+	s.flushMu.Lock()
+	if s.flushing[deviceID] {
+		s.flushMu.Unlock()
+		return 0, nil
+	}
+	s.flushing[deviceID] = true
+	s.flushMu.Unlock()
+	defer func() {
+		s.flushMu.Lock()
+		delete(s.flushing, deviceID)
+		s.flushMu.Unlock()
+	}()
+
+	cmds, err := s.repo.FindQueued(ctx, deviceID)
+	if err != nil {
+		return 0, err
+	}
+	sortByPriority(cmds)
+	flushed := 0
+	for i := range cmds {
+		cmd := &cmds[i]
+		now := time.Now().UTC()
+		if cmd.Expired(now) {
+			if _, err := s.expireQueued(ctx, cmd); err != nil {
+				return flushed, err
+			}
+			continue
+		}
+		ok, err := s.transition(ctx, cmd, models.CommandPending,
+			map[string]any{"last_attempt_at": now}, []string{"status_detail"})
+		if err != nil {
+			return flushed, err
+		}
+		if !ok {
+			// Cancelled, expired or flushed by another instance.
+			continue
+		}
+		cmd.StatusDetail, cmd.LastAttemptAt = "", &now
+		if err := s.deliver(ctx, cmd); err != nil && !errors.Is(err, ErrCommandNotPublished) {
+			return flushed, err
+		}
+		flushed++
+	}
+	return flushed, nil
+}
+
+// expireQueued times out a queued command that expired, reporting whether
+// it was still queued.
+func (s *CommandService) expireQueued(ctx context.Context, cmd *models.Command) (bool, error) {
+	ok, err := s.transition(ctx, cmd, models.CommandTimedOut, map[string]any{"error": reasonExpiredQueued}, nil)
+	if err != nil || !ok {
+		return ok, err
+	}
+	metrics.CommandsTimedOut.Add(1)
+	cmd.Error = reasonExpiredQueued
+	s.notify(cmd)
+	return true, nil
+}
+
+// recheckQueued flushes the queue of deviceID if the device came online
+// while a command was being queued, as its online event may have been
+// handled before the command was stored.
+func (s *CommandService) recheckQueued(ctx context.Context, deviceID string) {
+	d, err := s.devices.GetByID(ctx, deviceID)
+	if err != nil || d.Status == models.DeviceOffline {
+		return
+	}
+	if _, err := s.FlushQueued(ctx, deviceID); err != nil {
+		log.Printf("commands: flush queue of device %s: %v", deviceID, err)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_retry.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the republishing of failed commands with backoff.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/models"
+)
+
+// RetryDue republishes the pending commands whose next attempt is due,
+// highest priority first within each batch, and returns how many were
+// republished.
+func (s *CommandService) RetryDue(ctx context.Context, now time.Time) (int, error) {
+	cmds, err := s.repo.FindDueRetries(ctx, now, reapBatch)
+	if err != nil {
+		return 0, err
+	}
+	sortByPriority(cmds)
+	retried := 0
+	for i := range cmds {
+		cmd := &cmds[i]
+		prev := cmd.Attempts
+		at := time.Now().UTC()
+		set := map[string]any{"attempts": prev + 1, "last_attempt_at": at}
+		if cmd.ExpiresAt != nil && cmd.LastAttemptAt != nil {
+			// Every attempt gets the TTL the command was created with.
+			expires := at.Add(cmd.ExpiresAt.Sub(*cmd.LastAttemptAt))
+			set["expires_at"] = expires
+			cmd.ExpiresAt = &expires
+		}
+		ok, err := s.transition(ctx, cmd, models.CommandPending, set, []string{"next_attempt_at"})
+		if err != nil {
+			return retried, err
+		}
+		if !ok {
+			continue
+		}
+		cmd.Attempts, cmd.LastAttemptAt, cmd.NextAttemptAt = prev+1, &at, nil
+
+		if err := s.publisher.PublishCommand(cmd); err != nil {
+			log.Printf("commands: republish %s to device %s (attempt %d): %v", cmd.CommandID, cmd.DeviceID, cmd.Attempts, err)
+			if _, err := s.failAttempt(ctx, cmd, models.ReasonMQTTPublishFailed, models.CommandError); err != nil {
+				return retried, err
+			}
+			continue
+		}
+		retried++
+		if ok, err := s.transition(ctx, cmd, models.CommandSent, nil, nil); err != nil {
+			return retried, err
+		} else if ok {
+			s.notify(cmd)
+		}
+	}
+	return retried, nil
+}
+
+// failAttempt records that the current attempt of cmd failed for reason.
+// The command goes back to pending for another attempt when its policy
+// allows; otherwise it moves to final, or to error if it had retries. It
+// reports whether cmd was still in its status at that attempt and updates
+// cmd to match.
+func (s *CommandService) failAttempt(ctx context.Context, cmd *models.Command, reason string, final models.CommandStatus) (bool, error) {
+	if cmd.CanRetry() {
+		next := time.Now().UTC().Add(cmd.Retry.Delay(cmd.Attempts))
+		ok, err := s.transition(ctx, cmd, models.CommandPending,
+			map[string]any{"next_attempt_at": next, "error": reason}, nil)
+		if err != nil || !ok {
+			return ok, err
+		}
+		cmd.NextAttemptAt, cmd.Error = &next, reason
+		s.notify(cmd)
+		return true, nil
+	}
+
+	if cmd.Retry != nil {
+		final = models.CommandError
+	}
+	ok, err := s.transition(ctx, cmd, final, map[string]any{"error": reason}, []string{"next_attempt_at"})
+	if err != nil || !ok {
+		return ok, err
+	}
+	if final == models.CommandTimedOut {
+		metrics.CommandsTimedOut.Add(1)
+	}
+	cmd.Error, cmd.NextAttemptAt = reason, nil
+	s.notify(cmd)
+	return true, nil
+}
@@ -0,0 +1,348 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_schedule.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the business logic and the scheduler of scheduled commands in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+const (
+	// scheduleLease is how long an instance may take to run a schedule
+	// before another instance may run it again.
+	scheduleLease = time.Minute
+	// scheduleMisfireGrace is how late a run may still fire, e.g. after a
+	// restart; later runs are recorded as missed instead.
+	scheduleMisfireGrace = 15 * time.Minute
+	// scheduleBatch bounds the schedules run per scheduler pass.
+	scheduleBatch = 500
+)
+
+type CommandScheduleService struct {
+	repo     repository.CommandScheduleRepository
+	runs     repository.ScheduleRunRepository
+	commands *CommandService
+	devices  *DeviceService
+	// owner identifies this instance in schedule leases.
+	owner string
+}
+
+func NewCommandScheduleService(repo repository.CommandScheduleRepository, runs repository.ScheduleRunRepository,
+	commands *CommandService, devices *DeviceService) *CommandScheduleService {
+	return &CommandScheduleService{repo: repo, runs: runs, commands: commands, devices: devices, owner: primitive.NewObjectID().Hex()}
+}
+
+// ScheduleSpec is the user-editable part of a schedule: the command and
+// either a one-shot RunAt or a five-field Cron expression evaluated in
+// Timezone, UTC by default.
+type ScheduleSpec struct {
+	Action   string
+	Params   map[string]any
+	RunAt    *time.Time
+	Cron     string
+	Timezone string
+}
+
+func (s *CommandScheduleService) Create(ctx context.Context, deviceID, userID string, spec ScheduleSpec) (*models.CommandSchedule, error) {
+	now := time.Now().UTC()
+	sch := &models.CommandSchedule{
+		ID:        primitive.NewObjectID().Hex(),
+		DeviceID:  deviceID,
+		UserID:    userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := applyScheduleSpec(sch, spec, now); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, sch); err != nil {
+		return nil, err
+	}
+	return sch, nil
+}
+
+func (s *CommandScheduleService) List(ctx context.Context, deviceID string) ([]models.CommandSchedule, error) {
+	return s.repo.ListByDevice(ctx, deviceID)
+}
+
+func (s *CommandScheduleService) Get(ctx context.Context, deviceID, id string) (*models.CommandSchedule, error) {
+	sch, err := s.repo.GetByID(ctx, deviceID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrScheduleNotFound
+	}
+	return sch, err
+}
+
+// Update replaces the spec of a schedule and plans its next run from now.
+// The user updating it becomes the one its commands are sent as.
+func (s *CommandScheduleService) Update(ctx context.Context, deviceID, id, userID string, spec ScheduleSpec) (*models.CommandSchedule, error) {
+	sch, err := s.Get(ctx, deviceID, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if err := applyScheduleSpec(sch, spec, now); err != nil {
+		return nil, err
+	}
+	sch.UserID, sch.UpdatedAt = userID, now
+	err = s.repo.Update(ctx, sch)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sch, nil
+}
+
+// Delete removes a schedule and its history. Commands it created stay.
+func (s *CommandScheduleService) Delete(ctx context.Context, deviceID, id string) error {
+	err := s.repo.Delete(ctx, deviceID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrScheduleNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.runs.DeleteBySchedule(ctx, id)
+}
+
+// DeleteByDevice removes every schedule of deviceID and their history.
+func (s *CommandScheduleService) DeleteByDevice(ctx context.Context, deviceID string) error {
+	schedules, err := s.repo.ListByDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	for _, sch := range schedules {
+		if err := s.Delete(ctx, deviceID, sch.ID); err != nil && !errors.Is(err, ErrScheduleNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Runs returns the latest runs of a schedule, newest first, each with the
+// current state of the command it created.
+func (s *CommandScheduleService) Runs(ctx context.Context, deviceID, id string, limit int64) ([]models.ScheduleRun, error) {
+	if _, err := s.Get(ctx, deviceID, id); err != nil {
+		return nil, err
+	}
+	runs, err := s.runs.ListBySchedule(ctx, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(runs))
+	for _, r := range runs {
+		if r.CommandID != "" {
+			ids = append(ids, r.CommandID)
+		}
+	}
+	if len(ids) == 0 {
+		return runs, nil
+	}
+	cmds, err := s.commands.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.Command, len(cmds))
+	for i := range cmds {
+		byID[cmds[i].CommandID] = &cmds[i]
+	}
+	for i := range runs {
+		runs[i].Command = byID[runs[i].CommandID]
+	}
+	return runs, nil
+}
+
+func applyScheduleSpec(sch *models.CommandSchedule, spec ScheduleSpec, now time.Time) error {
+	if err := validateCommand(CommandRequest{Action: spec.Action, Params: spec.Params}); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+	spec.Cron = strings.TrimSpace(spec.Cron)
+	if (spec.RunAt == nil) == (spec.Cron == "") {
+		return fmt.Errorf("%w: either run_at or cron is required", ErrInvalidSchedule)
+	}
+	sch.Action, sch.Params = spec.Action, spec.Params
+	sch.RunAt, sch.Cron, sch.Timezone = nil, "", ""
+	if spec.RunAt != nil {
+		if spec.Timezone != "" {
+			return fmt.Errorf("%w: timezone only applies to cron schedules", ErrInvalidSchedule)
+		}
+		if !spec.RunAt.After(now) {
+			return fmt.Errorf("%w: run_at must be in the future", ErrInvalidSchedule)
+		}
+		at := spec.RunAt.UTC()
+		sch.RunAt = &at
+	} else {
+		if spec.Timezone == "" {
+			spec.Timezone = "UTC"
+		}
+		sch.Cron, sch.Timezone = spec.Cron, spec.Timezone
+	}
+	next, err := nextRun(sch, now)
+	if err != nil {
+		return err
+	}
+	sch.NextRunAt = next
+	return nil
+}
+
+// nextRun returns the first run of sch after t, or nil if it has none.
+func nextRun(sch *models.CommandSchedule, t time.Time) (*time.Time, error) {
+	if sch.Cron == "" {
+		if sch.RunAt == nil || !sch.RunAt.After(t) {
+			return nil, nil
+		}
+		return sch.RunAt, nil
+	}
+	// "Local" would follow the zone of whichever server runs the schedule.
+	loc, err := time.LoadLocation(sch.Timezone)
+	if err != nil || sch.Timezone == "Local" {
+		return nil, fmt.Errorf("%w: timezone must be an IANA timezone name such as Europe/Berlin", ErrInvalidSchedule)
+	}
+	expr, err := cron.ParseStandard(sch.Cron)
+	if err != nil || strings.HasPrefix(sch.Cron, "TZ=") || strings.HasPrefix(sch.Cron, "CRON_TZ=") {
+		return nil, fmt.Errorf("%w: cron must be a five-field expression such as \"0 7 * * *\"", ErrInvalidSchedule)
+	}
+	next := expr.Next(t.In(loc))
+	if next.IsZero() {
+		return nil, nil
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+// RunScheduler runs due schedules every interval until ctx is done.
+func (s *CommandScheduleService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := s.RunDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("schedules: run due: %v", err)
+		}
+	}
+}
+
+// RunDue runs the schedules due at now and returns how many it ran. Each
+// schedule is leased before it runs, so with several server instances
+// only one runs it. An instance dying mid-run leaves the lease to expire
+// and the run to be retried; the command is then not created twice, as it
+// carries an idempotency key derived from the schedule and run time.
+func (s *CommandScheduleService) RunDue(ctx context.Context, now time.Time) (int, error) {
+	ran := 0
+	for ran < scheduleBatch {
+		sch, err := s.repo.ClaimDue(ctx, s.owner, now, now.Add(scheduleLease))
+		if errors.Is(err, repository.ErrNotFound) {
+			return ran, nil
+		}
+		if err != nil {
+			return ran, err
+		}
+		if err := s.run(ctx, sch, now); err != nil {
+			return ran, err
+		}
+		ran++
+	}
+	return ran, nil
+}
+
+// run fires the due run of sch, records it and plans the next one.
+// Missed runs between the due one and now are not caught up on.
+func (s *CommandScheduleService) run(ctx context.Context, sch *models.CommandSchedule, now time.Time) error {
+	run := &models.ScheduleRun{
+		ID:          primitive.NewObjectID().Hex(),
+		ScheduleID:  sch.ID,
+		DeviceID:    sch.DeviceID,
+		ScheduledAt: *sch.NextRunAt,
+		RanAt:       now.UTC(),
+		Status:      models.RunSkipped,
+	}
+	if now.Sub(run.ScheduledAt) > scheduleMisfireGrace {
+		run.Reason = models.SkipMissed
+	} else if cmd, reason := s.fire(ctx, sch, run.ScheduledAt); cmd != nil {
+		run.Status, run.CommandID = models.RunFired, cmd.CommandID
+	} else {
+		run.Reason = reason
+	}
+	if err := s.runs.Create(ctx, run); err != nil && !errors.Is(err, repository.ErrDuplicate) {
+		return err
+	}
+
+	next, err := nextRun(sch, now)
+	if err != nil {
+		// Only possible if the stored spec became invalid, e.g. a zone
+		// removed from tzdata; stop the schedule rather than retry it.
+		log.Printf("schedules: schedule %s of device %s: %v", sch.ID, sch.DeviceID, err)
+	}
+	return s.repo.Release(ctx, sch, s.owner, run.RanAt, next)
+}
+
+// fire creates the command of sch for the run at at, unless the device is
+// gone or the schedule's user may no longer control it.
+func (s *CommandScheduleService) fire(ctx context.Context, sch *models.CommandSchedule, at time.Time) (*models.Command, models.SkipReason) {
+	d, err := s.devices.repo.GetByID(ctx, sch.DeviceID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return nil, models.SkipNotFound
+	case err != nil:
+		log.Printf("schedules: schedule %s: device %s: %v", sch.ID, sch.DeviceID, err)
+		return nil, models.SkipFailed
+	case d.DeletedAt != nil:
+		return nil, models.SkipDeviceDeleted
+	}
+	if _, err := s.devices.Authorize(ctx, sch.DeviceID, sch.UserID, AccessControl, false); err != nil {
+		return nil, scheduleSkipReason(sch, err)
+	}
+	cmd, err := s.commands.Create(ctx, CommandRequest{
+		DeviceID:       sch.DeviceID,
+		Action:         sch.Action,
+		Params:         sch.Params,
+		UserID:         sch.UserID,
+		IdempotencyKey: "schedule:" + sch.ID + ":" + strconv.FormatInt(at.Unix(), 10),
+		ScheduleID:     sch.ID,
+	})
+	if err != nil && !errors.Is(err, ErrCommandNotPublished) && !errors.Is(err, ErrCommandReplayed) {
+		return nil, scheduleSkipReason(sch, err)
+	}
+	return cmd, ""
+}
+
+// scheduleSkipReason maps why a run created no command to what its history
+// reports, logging unexpected errors.
+func scheduleSkipReason(sch *models.CommandSchedule, err error) models.SkipReason {
+	switch {
+	case errors.Is(err, ErrDeviceNotFound):
+		return models.SkipNotFound
+	case errors.Is(err, ErrForbidden):
+		return models.SkipForbidden
+	case errors.Is(err, ErrDeviceDecommissioned):
+		return models.SkipDecommissioned
+	}
+	log.Printf("schedules: schedule %s of device %s: %v", sch.ID, sch.DeviceID, err)
+	return models.SkipFailed
+}
@@ -0,0 +1,689 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic for device commands in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/events"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// CommandPublisher delivers a command, or its cancellation, to its device.
+type CommandPublisher interface {
+	PublishCommand(cmd *models.Command) error
+	PublishCancel(cmd *models.Command) error
+}
+
+type CommandService struct {
+	repo       repository.CommandRepository
+	keys       repository.CommandKeyRepository
+	devices    repository.DeviceRepository
+	publisher  CommandPublisher
+	events     *events.Hub
+	defaultTTL time.Duration
+	// queueOffline holds commands for offline devices until they are
+	// back online.
+	queueOffline bool
+
+	flushMu  sync.Mutex
+	flushing map[string]bool
+}
+
+func NewCommandService(repo repository.CommandRepository, keys repository.CommandKeyRepository, devices repository.DeviceRepository,
+	publisher CommandPublisher, hub *events.Hub, cfg config.CommandConfig) *CommandService {
+	return &CommandService{
+		repo:         repo,
+		keys:         keys,
+		devices:      devices,
+		publisher:    publisher,
+		events:       hub,
+		defaultTTL:   cfg.DefaultTTL,
+		queueOffline: cfg.QueueOffline,
+		flushing:     make(map[string]bool),
+	}
+}
+
+const (
+	maxCommandTTL        = 24 * time.Hour
+	maxIdempotencyKeyLen = 255
+	// reapBatch bounds the commands timed out per reaper pass.
+	reapBatch = 500
+)
+
+// CommandRequest is a command a user issues to a device.
+type CommandRequest struct {
+	DeviceID string
+	Action   string
+	Params   map[string]any
+	// TTL bounds how long the device may still execute the command; zero
+	// uses the configured default. A retried command gets a fresh TTL on
+	// every attempt.
+	TTL   time.Duration
+	Retry *models.CommandRetry
+	// Priority defaults to normal.
+	Priority models.CommandPriority
+
+	// UserID is the user issuing the command, the owner of the schedule or
+	// rule for commands they create. It is required.
+	UserID string
+	// IdempotencyKey, when set, makes retries of the same request by the
+	// same user return the first command, see models.CommandKey.
+	IdempotencyKey string
+	// BatchID links the command to the CommandBatch creating it.
+	BatchID string
+	// ScheduleID links the command to the CommandSchedule creating it.
+	ScheduleID string
+	// RuleID links the command to the alert rule creating it.
+	RuleID string
+	// Metadata is stored with the command as is, see validateMetadata.
+	Metadata map[string]string
+	// Raw lets an action that is not registered through with any params.
+	// Only admins may send raw commands; callers check that.
+	Raw bool
+}
+
+const (
+	maxRetryAttempts = 10
+	maxRetryBackoff  = time.Hour
+)
+
+// Create validates the params against the action registry, stores the
+// command as pending and then publishes it, moving it to sent. Storing
+// first means a device answering immediately always finds the command. When the publish fails
+// and the retry policy allows it, the command stays pending with a
+// NextAttemptAt; otherwise it is moved to error, so it stays visible in the
+// history, and is returned together with ErrCommandNotPublished.
+//
+// A request repeating the IdempotencyKey of an earlier one returns that
+// command, as it is now, together with ErrCommandReplayed and publishes
+// nothing. Decommissioned devices get no new commands. With queueing of
+// offline commands on, a command for a device marked offline is stored as
+// queued instead of published, see FlushQueued. A high-priority command
+// cancels the unfinished lower-priority commands of its device with the
+// same action before it is published, see preempt. A device takes one
+// ota_update at a time, see PublishFirmwareUpdate.
+func (s *CommandService) Create(ctx context.Context, req CommandRequest) (*models.Command, error) {
+	if len(req.IdempotencyKey) > maxIdempotencyKeyLen {
+		return nil, fmt.Errorf("%w: Idempotency-Key must be at most %d characters", ErrInvalidCommand, maxIdempotencyKeyLen)
+	}
+	if req.IdempotencyKey != "" {
+		if cmd, err := s.replay(ctx, req); cmd != nil || err != nil {
+			return cmd, err
+		}
+	}
+	if err := validateCommand(req); err != nil {
+		return nil, err
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("%w: the issuing user is required", ErrInvalidCommand)
+	}
+	d, err := s.devices.GetByID(ctx, req.DeviceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.DecommissionedAt != nil {
+		return nil, ErrDeviceDecommissioned
+	}
+	if req.Action == models.ActionOTAUpdate {
+		if err := s.checkNoOTA(ctx, req.DeviceID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	cmd := &models.Command{
+		CommandID:  primitive.NewObjectID().Hex(),
+		DeviceID:   req.DeviceID,
+		Action:     req.Action,
+		Params:     req.Params,
+		Status:     models.CommandPending,
+		BatchID:    req.BatchID,
+		ScheduleID: req.ScheduleID,
+		RuleID:     req.RuleID,
+		Metadata:   req.Metadata,
+		IssuedBy:   req.UserID,
+		Origin:     commandOrigin(req),
+		Priority:   req.Priority,
+		Retry:      req.Retry,
+		Attempts:   1,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	cmd.LastAttemptAt = &now
+	if s.queueOffline && d.Status == models.DeviceOffline {
+		cmd.Status, cmd.StatusDetail, cmd.LastAttemptAt = models.CommandQueued, models.DetailQueuedOffline, nil
+	}
+	if cmd.Priority == "" {
+		cmd.Priority = models.PriorityNormal
+	}
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = s.defaultTTL
+	}
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		cmd.ExpiresAt = &expires
+	}
+	if err := s.repo.Create(ctx, cmd); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) && cmd.Action == models.ActionOTAUpdate {
+			// A concurrent update of the device was stored after checkNoOTA.
+			return nil, ErrOTAInProgress
+		}
+		return nil, err
+	}
+	if req.IdempotencyKey != "" {
+		// The command is stored before the key is claimed, so whoever wins
+		// the claim has a command to return to the losers.
+		err := s.keys.Claim(ctx, &models.CommandKey{
+			DeviceID:  cmd.DeviceID,
+			Key:       req.IdempotencyKey,
+			UserID:    req.UserID,
+			CommandID: cmd.CommandID,
+			CreatedAt: now,
+		})
+		if errors.Is(err, repository.ErrDuplicate) {
+			// A concurrent request with the same key won. Ours was never
+			// published, so drop it and return theirs.
+			if err := s.repo.Delete(ctx, cmd.CommandID); err != nil {
+				return nil, err
+			}
+			return s.replay(ctx, req)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	auditCommand(ctx, cmd)
+
+	if cmd.Action == models.ActionOTAUpdate {
+		s.recordFirmware(ctx, cmd)
+	}
+	if cmd.Priority == models.PriorityHigh {
+		s.preempt(ctx, cmd)
+	}
+	if cmd.Status == models.CommandQueued {
+		s.notify(cmd)
+		s.recheckQueued(ctx, cmd.DeviceID)
+		return cmd, nil
+	}
+	if err := s.deliver(ctx, cmd); err != nil {
+		if errors.Is(err, ErrCommandNotPublished) {
+			return cmd, err
+		}
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// calibrationActions are audited as calibrations of the device as well.
+var calibrationActions = map[string]bool{"calibrate": true, "request_calibration": true}
+
+// auditCommand records the creation of cmd in the audit log.
+func auditCommand(ctx context.Context, cmd *models.Command) {
+	audit.Record(ctx, audit.ActionCommandPublish, audit.ResourceCommand, cmd.CommandID, models.AuditDiff{
+		"device_id": {To: cmd.DeviceID},
+		"action":    {To: cmd.Action},
+		"params":    {To: cmd.Params},
+	})
+	if calibrationActions[cmd.Action] {
+		audit.Record(ctx, audit.ActionDeviceCalibrate, audit.ResourceDevice, cmd.DeviceID, models.AuditDiff{
+			"command_id": {To: cmd.CommandID},
+			"params":     {To: cmd.Params},
+		})
+	}
+}
+
+// commandOrigin tells what created the command req asks for.
+func commandOrigin(req CommandRequest) models.CommandOrigin {
+	switch {
+	case req.ScheduleID != "":
+		return models.OriginSchedule
+	case req.RuleID != "":
+		return models.OriginAlert
+	}
+	return models.OriginAPI
+}
+
+// deliver publishes a pending command and moves it to sent. A failed
+// publish is handled like in Create, returning ErrCommandNotPublished if cmd
+// ended in error.
+func (s *CommandService) deliver(ctx context.Context, cmd *models.Command) error {
+	if err := s.publisher.PublishCommand(cmd); err != nil {
+		log.Printf("commands: publish %s to device %s: %v", cmd.CommandID, cmd.DeviceID, err)
+		if _, err := s.failAttempt(ctx, cmd, models.ReasonMQTTPublishFailed, models.CommandError); err != nil {
+			return err
+		}
+		if cmd.Status == models.CommandError {
+			return ErrCommandNotPublished
+		}
+		return nil
+	}
+	ok, err := s.transition(ctx, cmd, models.CommandSent, nil, nil)
+	if err != nil {
+		return err
+	}
+	if ok {
+		s.notify(cmd)
+	}
+	// Otherwise the ack or a cancellation came first and was notified.
+	return nil
+}
+
+// validateCommand checks everything of req that does not depend on the
+// device: TTL, priority, retry policy and the params of the action. The params of a
+// raw command are only checked if its action is registered.
+func validateCommand(req CommandRequest) error {
+	if req.TTL < 0 || req.TTL > maxCommandTTL {
+		return fmt.Errorf("%w: ttl must be between 0 and %s", ErrInvalidCommand, maxCommandTTL)
+	}
+	if req.Priority != "" && !req.Priority.Valid() {
+		return fmt.Errorf("%w: priority must be low, normal or high", ErrInvalidCommand)
+	}
+	if r := req.Retry; r != nil {
+		if r.MaxAttempts < 1 || r.MaxAttempts > maxRetryAttempts {
+			return fmt.Errorf("%w: max_attempts must be between 1 and %d", ErrInvalidCommand, maxRetryAttempts)
+		}
+		if r.BackoffSeconds < 1 || time.Duration(r.BackoffSeconds)*time.Second > maxRetryBackoff {
+			return fmt.Errorf("%w: backoff_seconds must be between 1 and %d", ErrInvalidCommand, int(maxRetryBackoff.Seconds()))
+		}
+	}
+	if err := validateMetadata(req.Metadata); err != nil {
+		return err
+	}
+	if req.Raw && !models.KnownAction(req.Action) {
+		return nil
+	}
+	cmd := models.Command{Action: req.Action, Params: req.Params}
+	if err := cmd.ValidateParams(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCommand, err)
+	}
+	if req.Action == models.ActionOTAUpdate {
+		return validateOTA(req.Params)
+	}
+	return nil
+}
+
+// validateMetadata allows up to models.MaxCommandMetadataKeys keys, see
+// models.ValidMetadataKey, with values of at most
+// models.MaxCommandMetadataValueLen characters.
+func validateMetadata(metadata map[string]string) error {
+	if len(metadata) > models.MaxCommandMetadataKeys {
+		return fmt.Errorf("%w: metadata has at most %d keys", ErrInvalidCommand, models.MaxCommandMetadataKeys)
+	}
+	for k, v := range metadata {
+		if !models.ValidMetadataKey(k) {
+			return fmt.Errorf("%w: metadata key %q must be 1-64 letters, digits or _", ErrInvalidCommand, k)
+		}
+		if utf8.RuneCountInString(v) > models.MaxCommandMetadataValueLen {
+			return fmt.Errorf("%w: metadata value of %q exceeds %d characters", ErrInvalidCommand, k, models.MaxCommandMetadataValueLen)
+		}
+	}
+	return nil
+}
+
+// replay returns the command created for the idempotency key of req with
+// ErrCommandReplayed, or nil and no error if the key is unused.
+func (s *CommandService) replay(ctx context.Context, req CommandRequest) (*models.Command, error) {
+	k, err := s.keys.Get(ctx, req.DeviceID, req.IdempotencyKey)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if k.UserID != req.UserID {
+		return nil, ErrIdempotencyKeyInUse
+	}
+	cmd, err := s.repo.GetByID(ctx, k.CommandID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCommandNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cmd, ErrCommandReplayed
+}
+
+// Get returns a command of deviceID.
+func (s *CommandService) Get(ctx context.Context, deviceID, commandID string) (*models.Command, error) {
+	cmd, err := s.repo.GetByID(ctx, commandID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCommandNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cmd.DeviceID != deviceID {
+		return nil, ErrCommandNotFound
+	}
+	return cmd, nil
+}
+
+// Lookup returns a command by ID alone, e.g. to authorize access to its
+// device.
+func (s *CommandService) Lookup(ctx context.Context, commandID string) (*models.Command, error) {
+	cmd, err := s.repo.GetByID(ctx, commandID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCommandNotFound
+	}
+	return cmd, err
+}
+
+// Cancel moves a queued, pending or sent command of deviceID to cancelled,
+// which also ends
+// its retries, and tells the device to drop it should it arrive later. A
+// command that is already final is returned unchanged with
+// ErrCommandTerminal. The cancellation stands even if it cannot be
+// published: acks of cancelled commands are ignored.
+func (s *CommandService) Cancel(ctx context.Context, deviceID, commandID string) (*models.Command, error) {
+	return s.cancel(ctx, deviceID, commandID, "")
+}
+
+// cancel is Cancel, also setting the StatusDetail of the command to detail
+// if not empty.
+func (s *CommandService) cancel(ctx context.Context, deviceID, commandID, detail string) (*models.Command, error) {
+	var set map[string]any
+	if detail != "" {
+		set = map[string]any{"status_detail": detail}
+	}
+	for {
+		cmd, err := s.Get(ctx, deviceID, commandID)
+		if err != nil {
+			return nil, err
+		}
+		if cmd.Status.Terminal() {
+			return cmd, ErrCommandTerminal
+		}
+		queued := cmd.Status == models.CommandQueued
+		ok, err := s.transition(ctx, cmd, models.CommandCancelled, set, []string{"next_attempt_at"})
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			// Acked, retried, sent or dequeued meanwhile; look again.
+			continue
+		}
+		if detail != "" {
+			cmd.StatusDetail = detail
+		}
+		cmd.NextAttemptAt = nil
+		if queued {
+			// Never published, so the device has nothing to drop.
+			s.notify(cmd)
+			return cmd, nil
+		}
+		if err := s.publisher.PublishCancel(cmd); err != nil {
+			log.Printf("commands: publish cancellation of %s to device %s: %v", cmd.CommandID, cmd.DeviceID, err)
+		}
+		s.notify(cmd)
+		return cmd, nil
+	}
+}
+
+// CancelPending cancels every command of deviceID that is not final yet, as
+// Cancel does, and returns how many it cancelled.
+func (s *CommandService) CancelPending(ctx context.Context, deviceID string) (int, error) {
+	return s.cancelPending(ctx, deviceID, "")
+}
+
+// cancelPending is CancelPending, also setting the StatusDetail of the
+// commands to detail if not empty.
+func (s *CommandService) cancelPending(ctx context.Context, deviceID, detail string) (int, error) {
+	cmds, err := s.repo.Find(ctx, repository.CommandFilter{
+		DeviceID: deviceID,
+		Statuses: []models.CommandStatus{models.CommandQueued, models.CommandPending, models.CommandSent},
+	})
+	if err != nil {
+		return 0, err
+	}
+	cancelled := 0
+	for _, cmd := range cmds {
+		_, err := s.cancel(ctx, deviceID, cmd.CommandID, detail)
+		if errors.Is(err, ErrCommandTerminal) {
+			continue
+		}
+		if err != nil {
+			return cancelled, err
+		}
+		cancelled++
+	}
+	return cancelled, nil
+}
+
+// CommandAck is the final status of a command reported by its device, with
+// the optional data and error it sent along.
+type CommandAck struct {
+	Status models.CommandStatus
+	Result map[string]any
+	Error  string
+}
+
+// UpdateStatus applies a status, and optional result, reported by the
+// device. A success reported after ExpiresAt is refused and the command is
+// moved to timeout instead, returning ErrCommandExpired. Final commands
+// never change, so a late ack cannot overwrite a final status; a queued
+// command, which the device cannot have received, is refused with
+// ErrInvalidTransition.
+func (s *CommandService) UpdateStatus(ctx context.Context, deviceID, commandID string, ack CommandAck) (*models.Command, error) {
+	if ack.Status != models.CommandSuccess && ack.Status != models.CommandError {
+		return nil, ErrInvalidCommandStatus
+	}
+	result, errMsg := ackOutcome(commandID, ack)
+	set, unset := map[string]any{}, []string{"next_attempt_at"}
+	if result != nil {
+		set["result"] = result
+	}
+	if errMsg != "" {
+		set["error"] = errMsg
+	} else {
+		unset = append(unset, "error")
+	}
+	for {
+		cmd, err := s.Get(ctx, deviceID, commandID)
+		if err != nil {
+			return nil, err
+		}
+		if cmd.Status.Terminal() {
+			return cmd, ErrCommandTerminal
+		}
+
+		target, outcome := ack.Status, error(nil)
+		if ack.Status == models.CommandSuccess && cmd.Expired(time.Now()) {
+			target, outcome = models.CommandTimedOut, ErrCommandExpired
+		}
+		ok, err := s.transition(ctx, cmd, target, set, unset)
+		if err != nil {
+			return cmd, err
+		}
+		if !ok {
+			// Sent, retried or finished meanwhile; look again.
+			continue
+		}
+		cmd.Error, cmd.NextAttemptAt = errMsg, nil
+		if result != nil {
+			cmd.Result = result
+		}
+		s.notify(cmd)
+		return cmd, outcome
+	}
+}
+
+// ackOutcome bounds what a device acked to what is stored: a result over
+// models.MaxCommandResultSize is dropped, with ReasonResultTooLarge as the
+// error unless the device sent one, and errors are truncated.
+func ackOutcome(commandID string, ack CommandAck) (map[string]any, string) {
+	result, errMsg := ack.Result, ack.Error
+	if result != nil {
+		if b, err := json.Marshal(result); err != nil || len(b) > models.MaxCommandResultSize {
+			log.Printf("commands: drop result of command %s: larger than %d bytes", commandID, models.MaxCommandResultSize)
+			result = nil
+			if errMsg == "" {
+				errMsg = models.ReasonResultTooLarge
+			}
+		}
+	}
+	if len(errMsg) > models.MaxCommandErrorLen {
+		errMsg = strings.ToValidUTF8(errMsg[:models.MaxCommandErrorLen], "")
+	}
+	return result, errMsg
+}
+
+// reaperLock is the leader lock of the command reaper.
+const reaperLock = "command_reaper"
+
+// RunReaper is the one background job ending commands that will not be
+// acked, every interval until ctx is done: it times out expired commands,
+// republishes the retries that are due and, with a pendingTTL, fails
+// commands still pending that long after their creation. With several
+// server instances only the one holding the leader lock runs it; the lease
+// outlives two intervals, so another instance takes over after the leader
+// stops renewing it.
+func (s *CommandService) RunReaper(ctx context.Context, locks repository.LockRepository, interval, pendingTTL time.Duration) {
+	owner := primitive.NewObjectID().Hex()
+	defer func() {
+		// ctx is done; the lock must still go so another instance can
+		// take over without waiting for the lease.
+		rctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := locks.Release(rctx, reaperLock, owner); err != nil {
+			log.Printf("commands: release reaper lock: %v", err)
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		now := time.Now()
+		leader, err := locks.Acquire(ctx, reaperLock, owner, now, now.Add(2*interval+time.Second))
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("commands: acquire reaper lock: %v", err)
+			}
+			continue
+		}
+		if !leader {
+			continue
+		}
+		s.reap(ctx, now, pendingTTL)
+	}
+}
+
+// reap runs one pass of the reaper at now.
+func (s *CommandService) reap(ctx context.Context, now time.Time, pendingTTL time.Duration) {
+	if _, err := s.ReapExpired(ctx, now); err != nil && ctx.Err() == nil {
+		log.Printf("commands: reap expired: %v", err)
+	}
+	if pendingTTL > 0 {
+		if _, err := s.SweepStale(ctx, now.Add(-pendingTTL)); err != nil && ctx.Err() == nil {
+			log.Printf("commands: sweep stale: %v", err)
+		}
+	}
+	if _, err := s.RetryDue(ctx, now); err != nil && ctx.Err() == nil {
+		log.Printf("commands: retry: %v", err)
+	}
+}
+
+// ReapExpired handles pending or sent commands that expired before now
+// without an ack: a command that may be retried is scheduled for its next
+// attempt, any other moves to timeout, or to error once its retries are
+// exhausted. Queued commands that expired move to timeout. It returns how
+// many commands it handled. Changes only apply while the command is still
+// in the same status at the same attempt, so an ack that lands first keeps
+// its status.
+func (s *CommandService) ReapExpired(ctx context.Context, now time.Time) (int, error) {
+	reaped := 0
+	for {
+		cmds, err := s.repo.FindExpired(ctx, now, reapBatch)
+		if err != nil {
+			return reaped, err
+		}
+		for i := range cmds {
+			if cmds[i].Status == models.CommandQueued {
+				ok, err := s.expireQueued(ctx, &cmds[i])
+				if err != nil {
+					return reaped, err
+				}
+				if ok {
+					reaped++
+				}
+				continue
+			}
+			ok, err := s.failAttempt(ctx, &cmds[i], "no acknowledgement before expiry", models.CommandTimedOut)
+			if err != nil {
+				return reaped, err
+			}
+			if ok {
+				reaped++
+			}
+		}
+		if len(cmds) < reapBatch {
+			return reaped, nil
+		}
+	}
+}
+
+// transition moves cmd to the status to, applying set and unset, if it is
+// still in its status at its attempt, and reports whether it was. Every
+// status change goes through here, so illegal moves are refused with
+// ErrInvalidTransition. On success cmd has its new status; the caller
+// updates the other fields it set. A final ota_update command updates the
+// firmware of its device.
+func (s *CommandService) transition(ctx context.Context, cmd *models.Command, to models.CommandStatus, set map[string]any, unset []string) (bool, error) {
+	if !cmd.Status.CanTransition(to) {
+		return false, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, cmd.Status, to)
+	}
+	fields := map[string]any{"status": to}
+	for k, v := range set {
+		fields[k] = v
+	}
+	ok, err := s.repo.Transition(ctx, cmd.CommandID, cmd.Status, cmd.Attempts, fields, unset)
+	if err != nil || !ok {
+		return ok, err
+	}
+	cmd.Status, cmd.UpdatedAt = to, time.Now().UTC()
+	if cmd.Action == models.ActionOTAUpdate && to.Terminal() {
+		s.recordFirmware(ctx, cmd)
+	}
+	return true, nil
+}
+
+// notify publishes the current status of cmd.
+func (s *CommandService) notify(cmd *models.Command) {
+	s.events.Publish(events.Event{
+		Type:     events.TypeCommandStatus,
+		DeviceID: cmd.DeviceID,
+		At:       cmd.UpdatedAt,
+		Data:     cmd,
+	})
+}
@@ -0,0 +1,476 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_service_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of creating commands with a mocked MQTT publisher.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/events"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// memCommands keeps commands in memory and appends the calls that change
+// them to log.
+type memCommands struct {
+	repository.CommandRepository
+	cmds map[string]models.Command
+	log  *[]string
+}
+
+func newMemCommands(log *[]string) *memCommands {
+	return &memCommands{cmds: make(map[string]models.Command), log: log}
+}
+
+func (r *memCommands) Create(_ context.Context, cmd *models.Command) error {
+	*r.log = append(*r.log, "insert "+string(cmd.Status))
+	r.cmds[cmd.CommandID] = *cmd
+	return nil
+}
+
+func (r *memCommands) GetByID(_ context.Context, commandID string) (*models.Command, error) {
+	cmd, ok := r.cmds[commandID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &cmd, nil
+}
+
+func (r *memCommands) Transition(_ context.Context, commandID string, from models.CommandStatus, attempts int, set map[string]any, unset []string) (bool, error) {
+	cmd, ok := r.cmds[commandID]
+	if !ok || cmd.Status != from || cmd.Attempts != attempts {
+		return false, nil
+	}
+	for k, v := range set {
+		switch k {
+		case "status":
+			cmd.Status = v.(models.CommandStatus)
+		case "error":
+			cmd.Error = v.(string)
+		case "status_detail":
+			cmd.StatusDetail = v.(string)
+		case "next_attempt_at":
+			next := v.(time.Time)
+			cmd.NextAttemptAt = &next
+		case "attempts":
+			cmd.Attempts = v.(int)
+		}
+	}
+	for _, k := range unset {
+		if k == "next_attempt_at" {
+			cmd.NextAttemptAt = nil
+		}
+	}
+	*r.log = append(*r.log, "transition "+string(cmd.Status))
+	r.cmds[commandID] = cmd
+	return true, nil
+}
+
+func (r *memCommands) FindExpired(_ context.Context, now time.Time, limit int64) ([]models.Command, error) {
+	return r.find(limit, func(c models.Command) bool {
+		return (c.Status == models.CommandPending || c.Status == models.CommandSent || c.Status == models.CommandQueued) &&
+			c.ExpiresAt != nil && c.ExpiresAt.Before(now) && c.NextAttemptAt == nil
+	}), nil
+}
+
+func (r *memCommands) FindDueRetries(_ context.Context, now time.Time, limit int64) ([]models.Command, error) {
+	return r.find(limit, func(c models.Command) bool {
+		return c.Status == models.CommandPending && c.NextAttemptAt != nil && !c.NextAttemptAt.After(now)
+	}), nil
+}
+
+func (r *memCommands) FindStale(_ context.Context, before time.Time, limit int64) ([]models.Command, error) {
+	return r.find(limit, func(c models.Command) bool {
+		return (c.Status == models.CommandPending || c.Status == models.CommandSent) && c.CreatedAt.Before(before)
+	}), nil
+}
+
+func (r *memCommands) Find(_ context.Context, filter repository.CommandFilter) ([]models.Command, error) {
+	return r.find(filter.Limit, func(c models.Command) bool {
+		return c.DeviceID == filter.DeviceID && (filter.Action == "" || c.Action == filter.Action) &&
+			(len(filter.Statuses) == 0 || slices.Contains(filter.Statuses, c.Status))
+	}), nil
+}
+
+func (r *memCommands) FindQueued(_ context.Context, deviceID string) ([]models.Command, error) {
+	return r.find(0, func(c models.Command) bool {
+		return c.DeviceID == deviceID && c.Status == models.CommandQueued
+	}), nil
+}
+
+// find returns up to limit commands matching keep, by ID.
+func (r *memCommands) find(limit int64, keep func(models.Command) bool) []models.Command {
+	var out []models.Command
+	for _, c := range r.cmds {
+		if keep(c) {
+			out = append(out, c)
+		}
+	}
+	slices.SortFunc(out, func(a, b models.Command) int { return strings.Compare(a.CommandID, b.CommandID) })
+	if limit > 0 && int64(len(out)) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// cmdDevices returns the devices it holds.
+type cmdDevices struct {
+	repository.DeviceRepository
+	devices map[string]*models.Device
+}
+
+func (r *cmdDevices) GetByID(_ context.Context, id string) (*models.Device, error) {
+	d, ok := r.devices[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return d, nil
+}
+
+// Update and Unset apply the firmware fields, which ota_update commands
+// keep up to date.
+func (r *cmdDevices) Update(ctx context.Context, id string, fields map[string]any) (*models.Device, error) {
+	d, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := fields["firmware_target"].(string); ok {
+		d.FirmwareTarget = v
+	}
+	if v, ok := fields["firmware_version"].(string); ok {
+		d.FirmwareVersion = v
+	}
+	return d, nil
+}
+
+func (r *cmdDevices) Unset(ctx context.Context, id string, fields []string) (*models.Device, error) {
+	d, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(fields, "firmware_target") {
+		d.FirmwareTarget = ""
+	}
+	return d, nil
+}
+
+// fakePublisher stands in for the MQTT client, failing every publish with
+// err when it is set.
+type fakePublisher struct {
+	err       error
+	published []*models.Command
+	log       *[]string
+}
+
+func (p *fakePublisher) PublishCommand(cmd *models.Command) error {
+	*p.log = append(*p.log, "publish")
+	if p.err != nil {
+		return p.err
+	}
+	c := *cmd
+	p.published = append(p.published, &c)
+	return nil
+}
+
+func (p *fakePublisher) PublishCancel(*models.Command) error {
+	*p.log = append(*p.log, "publish cancel")
+	return p.err
+}
+
+// newTestCommandService returns a service with the device d1, online, and
+// d2, offline, that queues commands for offline devices.
+func newTestCommandService(publishErr error) (*CommandService, *memCommands, *fakePublisher, *[]string) {
+	log := &[]string{}
+	repo := newMemCommands(log)
+	pub := &fakePublisher{err: publishErr, log: log}
+	devices := &cmdDevices{devices: map[string]*models.Device{
+		"d1": {ID: "d1", Status: models.DeviceOnline},
+		"d2": {ID: "d2", Status: models.DeviceOffline},
+	}}
+	s := &CommandService{
+		repo:         repo,
+		devices:      devices,
+		publisher:    pub,
+		events:       events.NewHub(),
+		queueOffline: true,
+		flushing:     make(map[string]bool),
+	}
+	return s, repo, pub, log
+}
+
+func TestCommandCreate(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         CommandRequest
+		publishErr  error
+		wantErr     error
+		wantStatus  models.CommandStatus
+		wantError   string
+		wantLog     []string
+		wantStored  bool
+		wantPublish int
+	}{
+		{
+			name:        "published",
+			req:         CommandRequest{DeviceID: "d1", Action: "reboot", UserID: "u1"},
+			wantStatus:  models.CommandSent,
+			wantLog:     []string{"insert pending", "publish", "transition sent"},
+			wantStored:  true,
+			wantPublish: 1,
+		},
+		{
+			name:       "publish fails",
+			req:        CommandRequest{DeviceID: "d1", Action: "reboot", UserID: "u1"},
+			publishErr: errors.New("not connected"),
+			wantErr:    ErrCommandNotPublished,
+			wantStatus: models.CommandError,
+			wantError:  models.ReasonMQTTPublishFailed,
+			wantLog:    []string{"insert pending", "publish", "transition error"},
+			wantStored: true,
+		},
+		{
+			name:       "publish fails with retries left",
+			req:        CommandRequest{DeviceID: "d1", Action: "reboot", UserID: "u1", Retry: &models.CommandRetry{MaxAttempts: 3, BackoffSeconds: 5}},
+			publishErr: errors.New("not connected"),
+			wantStatus: models.CommandPending,
+			wantError:  models.ReasonMQTTPublishFailed,
+			wantLog:    []string{"insert pending", "publish", "transition pending"},
+			wantStored: true,
+		},
+		{
+			name:       "offline device queued",
+			req:        CommandRequest{DeviceID: "d2", Action: "reboot", UserID: "u1"},
+			wantStatus: models.CommandQueued,
+			wantLog:    []string{"insert queued"},
+			wantStored: true,
+		},
+		{
+			name:    "unknown device",
+			req:     CommandRequest{DeviceID: "d3", Action: "reboot", UserID: "u1"},
+			wantErr: ErrDeviceNotFound,
+		},
+		{
+			name:    "invalid params",
+			req:     CommandRequest{DeviceID: "d1", Action: "set_fan_speed", Params: map[string]any{"speed": 101.0}, UserID: "u1"},
+			wantErr: ErrInvalidCommand,
+		},
+		{
+			name:    "no issuing user",
+			req:     CommandRequest{DeviceID: "d1", Action: "reboot"},
+			wantErr: ErrInvalidCommand,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, pub, log := newTestCommandService(tt.publishErr)
+			cmd, err := s.Create(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(*log, tt.wantLog) {
+				t.Errorf("calls = %q, want %q", *log, tt.wantLog)
+			}
+			if len(pub.published) != tt.wantPublish {
+				t.Errorf("published %d commands, want %d", len(pub.published), tt.wantPublish)
+			}
+			if !tt.wantStored {
+				if len(repo.cmds) != 0 {
+					t.Errorf("stored %d commands, want none", len(repo.cmds))
+				}
+				return
+			}
+			if cmd == nil {
+				t.Fatal("Create() returned no command")
+			}
+			stored, ok := repo.cmds[cmd.CommandID]
+			if !ok {
+				t.Fatalf("command %s was not stored", cmd.CommandID)
+			}
+			if cmd.Status != tt.wantStatus || stored.Status != tt.wantStatus {
+				t.Errorf("status = %s, stored %s, want %s", cmd.Status, stored.Status, tt.wantStatus)
+			}
+			if stored.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", stored.Error, tt.wantError)
+			}
+			if stored.IssuedBy != tt.req.UserID || stored.Priority != models.PriorityNormal {
+				t.Errorf("stored issued_by %q priority %q", stored.IssuedBy, stored.Priority)
+			}
+		})
+	}
+}
+
+func TestCommandGet(t *testing.T) {
+	s, repo, _, _ := newTestCommandService(nil)
+	repo.cmds["c1"] = models.Command{CommandID: "c1", DeviceID: "d1", Status: models.CommandSent}
+	tests := []struct {
+		name      string
+		deviceID  string
+		commandID string
+		wantErr   error
+	}{
+		{name: "found", deviceID: "d1", commandID: "c1"},
+		{name: "unknown command", deviceID: "d1", commandID: "c2", wantErr: ErrCommandNotFound},
+		{name: "command of another device", deviceID: "d2", commandID: "c1", wantErr: ErrCommandNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := s.Get(context.Background(), tt.deviceID, tt.commandID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Get() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && cmd.CommandID != tt.commandID {
+				t.Errorf("Get() = %s, want %s", cmd.CommandID, tt.commandID)
+			}
+		})
+	}
+}
+
+func TestCommandReap(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+	tests := []struct {
+		name       string
+		cmd        models.Command
+		pendingTTL time.Duration
+		want       models.CommandStatus
+		wantError  string
+	}{
+		{name: "expired", cmd: models.Command{Status: models.CommandSent, CreatedAt: now, ExpiresAt: ago(time.Second)},
+			want: models.CommandTimedOut, wantError: "no acknowledgement before expiry"},
+		{name: "expired while queued", cmd: models.Command{Status: models.CommandQueued, CreatedAt: now, ExpiresAt: ago(time.Second)},
+			want: models.CommandTimedOut, wantError: reasonExpiredQueued},
+		{name: "not expired", cmd: models.Command{Status: models.CommandSent, CreatedAt: now, ExpiresAt: ago(-time.Minute)},
+			want: models.CommandSent},
+		{name: "stale", cmd: models.Command{Status: models.CommandPending, CreatedAt: *ago(2 * time.Hour)}, pendingTTL: time.Hour,
+			want: models.CommandError, wantError: models.ReasonTimeout},
+		{name: "stale without sweep", cmd: models.Command{Status: models.CommandPending, CreatedAt: *ago(2 * time.Hour)},
+			want: models.CommandPending},
+		{name: "stale but final", cmd: models.Command{Status: models.CommandSuccess, CreatedAt: *ago(2 * time.Hour)}, pendingTTL: time.Hour,
+			want: models.CommandSuccess},
+		{name: "retry due", cmd: models.Command{Status: models.CommandPending, CreatedAt: now, NextAttemptAt: ago(time.Second),
+			Retry: &models.CommandRetry{MaxAttempts: 3, BackoffSeconds: 1}, Attempts: 1},
+			want: models.CommandSent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _, _ := newTestCommandService(nil)
+			tt.cmd.CommandID, tt.cmd.DeviceID = "c1", "d1"
+			repo.cmds["c1"] = tt.cmd
+			s.reap(context.Background(), now, tt.pendingTTL)
+			got := repo.cmds["c1"]
+			if got.Status != tt.want {
+				t.Errorf("status = %s, want %s", got.Status, tt.want)
+			}
+			if got.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", got.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestCommandUpdateStatus(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name       string
+		cmd        models.Command
+		ack        models.CommandStatus
+		wantErr    error
+		wantStatus models.CommandStatus
+	}{
+		{"sent succeeds", models.Command{Status: models.CommandSent}, models.CommandSuccess, nil, models.CommandSuccess},
+		{"sent fails", models.Command{Status: models.CommandSent}, models.CommandError, nil, models.CommandError},
+		{"ack overtakes the send", models.Command{Status: models.CommandPending}, models.CommandSuccess, nil, models.CommandSuccess},
+		{"success after expiry", models.Command{Status: models.CommandSent, ExpiresAt: &past}, models.CommandSuccess,
+			ErrCommandExpired, models.CommandTimedOut},
+		{"error after expiry", models.Command{Status: models.CommandSent, ExpiresAt: &past}, models.CommandError,
+			nil, models.CommandError},
+		{"queued never reached the device", models.Command{Status: models.CommandQueued}, models.CommandSuccess,
+			ErrInvalidTransition, models.CommandQueued},
+		{"already final", models.Command{Status: models.CommandCancelled}, models.CommandSuccess,
+			ErrCommandTerminal, models.CommandCancelled},
+		{"acked as sent", models.Command{Status: models.CommandSent}, models.CommandSent,
+			ErrInvalidCommandStatus, models.CommandSent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _, _ := newTestCommandService(nil)
+			cmd := tt.cmd
+			cmd.CommandID, cmd.DeviceID, cmd.Action = "c1", "d1", "reboot"
+			repo.cmds["c1"] = cmd
+			_, err := s.UpdateStatus(context.Background(), "d1", "c1", CommandAck{Status: tt.ack})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := repo.cmds["c1"].Status; got != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCommandCreateAudit(t *testing.T) {
+	tests := []struct {
+		name string
+		req  CommandRequest
+		want []string
+	}{
+		{
+			name: "published",
+			req:  CommandRequest{DeviceID: "d1", Action: "reboot", UserID: "u1"},
+			want: []string{audit.ActionCommandPublish},
+		},
+		{
+			name: "calibration",
+			req:  CommandRequest{DeviceID: "d1", Action: "calibrate", Params: map[string]any{"targetSensor": "pm25"}, UserID: "u1"},
+			want: []string{audit.ActionCommandPublish, audit.ActionDeviceCalibrate},
+		},
+		{
+			name: "queued",
+			req:  CommandRequest{DeviceID: "d2", Action: "reboot", UserID: "u1"},
+			want: []string{audit.ActionCommandPublish},
+		},
+		{
+			name: "refused",
+			req:  CommandRequest{DeviceID: "d3", Action: "reboot", UserID: "u1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, _, _ := newTestCommandService(nil)
+			ctx, entries := audit.Collect(context.Background())
+			cmd, _ := s.Create(ctx, tt.req)
+			var got []string
+			for _, e := range entries.Entries() {
+				got = append(got, e.Action)
+				wantID := tt.req.DeviceID
+				if e.ResourceType == audit.ResourceCommand {
+					wantID = cmd.CommandID
+				}
+				if e.ResourceID != wantID {
+					t.Errorf("%s of %s, want %s", e.Action, e.ResourceID, wantID)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("recorded %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: command_sweep.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the sweep failing commands left pending past their TTL.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/models"
+)
+
+// SweepStale moves pending or sent commands created before before to error
+// with ReasonTimeout and returns how many it moved; RunReaper calls it. A
+// command that is acked or retried meanwhile keeps its new state.
+func (s *CommandService) SweepStale(ctx context.Context, before time.Time) (int, error) {
+	swept := 0
+	for {
+		cmds, err := s.repo.FindStale(ctx, before, reapBatch)
+		if err != nil {
+			return swept, err
+		}
+		for i := range cmds {
+			cmd := &cmds[i]
+			ok, err := s.transition(ctx, cmd, models.CommandError,
+				map[string]any{"error": models.ReasonTimeout}, []string{"next_attempt_at"})
+			if err != nil {
+				return swept, err
+			}
+			if !ok {
+				continue
+			}
+			metrics.CommandsExpired.Add(1)
+			cmd.Error, cmd.NextAttemptAt = models.ReasonTimeout, nil
+			s.notify(cmd)
+			swept++
+		}
+		if len(cmds) < reapBatch {
+			if swept > 0 {
+				log.Printf("commands: failed %d command(s) pending since before %s", swept, before.Format(time.RFC3339))
+			}
+			return swept, nil
+		}
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: dashboard_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the per-user dashboard summary of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"airsense-be.com/internal/alert"
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// DashboardEntry summarises one device. The reading fields are nil when the
+// device has never reported.
+type DashboardEntry struct {
+	DeviceID      string                 `json:"device_id"`
+	Name          string                 `json:"name"`
+	Status        models.DeviceStatus    `json:"status,omitempty"`
+	SharedBy      string                 `json:"shared_by,omitempty"`
+	Permission    models.SharePermission `json:"permission,omitempty"`
+	LastReadingAt *time.Time             `json:"last_reading_at"`
+	PM25          *float64               `json:"pm25"`
+	CO2           *float64               `json:"co2"`
+	Temperature   *float64               `json:"temperature"`
+	AQI           *int                   `json:"aqi"`
+	AQICategory   *string                `json:"aqi_category"`
+	ActiveAlerts  int                    `json:"active_alerts"`
+}
+
+type DashboardService struct {
+	devices *DeviceService
+	sensors repository.SensorDataRepository
+	latest  *cache.LatestCache
+	alerts  *alert.Evaluator
+}
+
+func NewDashboardService(devices *DeviceService, sensors repository.SensorDataRepository,
+	latest *cache.LatestCache, alerts *alert.Evaluator) *DashboardService {
+	return &DashboardService{devices: devices, sensors: sensors, latest: latest, alerts: alerts}
+}
+
+// Summary builds the dashboard of every device userID can see with one
+// devices query and, for devices missing from the latest-reading cache, one
+// readings query.
+func (s *DashboardService) Summary(ctx context.Context, userID string) ([]DashboardEntry, error) {
+	views, err := s.devices.All(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]models.SensorData, len(views))
+	var misses []string
+	for _, v := range views {
+		if d, ok := s.latest.Get(v.ID); ok {
+			latest[v.ID] = d
+		} else {
+			misses = append(misses, v.ID)
+		}
+	}
+	if len(misses) > 0 {
+		found, err := s.sensors.Latest(ctx, misses)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range found {
+			s.latest.Set(d)
+			latest[d.DeviceID] = d
+		}
+	}
+
+	entries := make([]DashboardEntry, len(views))
+	for i, v := range views {
+		e := DashboardEntry{
+			DeviceID:   v.ID,
+			Name:       v.Name,
+			Status:     v.Status,
+			SharedBy:   v.SharedBy,
+			Permission: v.Permission,
+		}
+		if s.alerts != nil {
+			e.ActiveAlerts = s.alerts.Active(v.ID)
+		}
+		if d, ok := latest[v.ID]; ok {
+			ts, pm25, co2, temp := d.Timestamp, d.Sensors.PM25.Value, d.Sensors.CO2.Value, d.Sensors.Temperature.Value
+			aqi, category := utils.PM25AQI(pm25)
+			e.LastReadingAt, e.PM25, e.CO2, e.Temperature = &ts, &pm25, &co2, &temp
+			e.AQI, e.AQICategory = &aqi, &category
+		}
+		entries[i] = e
+	}
+	return entries, nil
+}
+
+// FleetReading is the latest reading of a device along with its status.
+// Reading is nil when the device never reported or its lookup timed out.
+type FleetReading struct {
+	Status  models.DeviceStatus `json:"status"`
+	Reading *models.SensorData  `json:"reading"`
+}
+
+const (
+	// latestFetchConcurrency bounds the readings queries of one Latest call.
+	latestFetchConcurrency = 8
+	// latestFetchTimeout bounds the readings query of a single device.
+	latestFetchTimeout = 2 * time.Second
+)
+
+// Latest returns the latest reading of every device userID can see, by
+// device ID. Devices missing from the latest-reading cache are looked up
+// concurrently, each with its own timeout, so a slow lookup only leaves
+// that device without a reading.
+func (s *DashboardService) Latest(ctx context.Context, userID string) (map[string]FleetReading, error) {
+	views, err := s.devices.All(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]FleetReading, len(views))
+	var misses []string
+	for _, v := range views {
+		r := FleetReading{Status: v.Status}
+		if d, ok := s.latest.Get(v.ID); ok {
+			r.Reading = &d
+		} else {
+			misses = append(misses, v.ID)
+		}
+		out[v.ID] = r
+	}
+
+	found := make([]*models.SensorData, len(misses))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(latestFetchConcurrency)
+	for i, id := range misses {
+		g.Go(func() error {
+			dctx, cancel := context.WithTimeout(gctx, latestFetchTimeout)
+			defer cancel()
+			readings, err := s.sensors.Latest(dctx, []string{id})
+			if errors.Is(err, context.DeadlineExceeded) && gctx.Err() == nil {
+				log.Printf("dashboard: latest reading of device %s: timed out after %s", id, latestFetchTimeout)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if len(readings) > 0 {
+				found[i] = &readings[0]
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	for i, d := range found {
+		if d == nil {
+			continue
+		}
+		s.latest.Set(*d)
+		r := out[misses[i]]
+		r.Reading = d
+		out[misses[i]] = r
+	}
+	return out, nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: decommission_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the decommissioning of devices in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type DecommissionService struct {
+	devices     repository.DeviceRepository
+	sensors     repository.SensorDataRepository
+	credentials repository.MQTTCredentialRepository
+	commands    *CommandService
+	latest      *cache.LatestCache
+}
+
+func NewDecommissionService(devices repository.DeviceRepository, sensors repository.SensorDataRepository,
+	credentials repository.MQTTCredentialRepository, commands *CommandService, latest *cache.LatestCache) *DecommissionService {
+	return &DecommissionService{devices: devices, sensors: sensors, credentials: credentials, commands: commands, latest: latest}
+}
+
+// Decommission is the outcome of decommissioning a device.
+type Decommission struct {
+	Device            *models.Device `json:"device"`
+	CancelledCommands int            `json:"cancelled_commands"`
+	ArchivedReadings  int64          `json:"archived_readings"`
+}
+
+// Decommission retires a device for good without deleting it. The device
+// is marked decommissioned first, which stops ingest and new commands;
+// then its MQTT credentials are expired, so the broker refuses it at its
+// next connection, its pending commands are cancelled and its readings
+// are archived. The backend subscribes to topic wildcards, so there is no
+// per-device subscription to drop: messages the device still sends are
+// discarded. Decommissioning a decommissioned device again finishes the
+// steps an earlier attempt did not.
+func (s *DecommissionService) Decommission(ctx context.Context, deviceID string) (*Decommission, error) {
+	d, err := s.devices.GetByID(ctx, deviceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.DeletedAt != nil {
+		return nil, ErrDeviceNotFound
+	}
+	now := time.Now().UTC()
+	if d.DecommissionedAt == nil {
+		updated, err := s.devices.Decommission(ctx, deviceID, now)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		if updated == nil {
+			// Decommissioned or deleted concurrently; look again.
+			return s.Decommission(ctx, deviceID)
+		}
+		audit.Record(ctx, audit.ActionDeviceDecommission, audit.ResourceDevice, deviceID, audit.Changes(d, updated))
+		d = updated
+	}
+
+	out := &Decommission{Device: d}
+	if err := s.credentials.ExpireAll(ctx, deviceID, now); err != nil {
+		return nil, err
+	}
+	if out.CancelledCommands, err = s.commands.CancelPending(ctx, deviceID); err != nil {
+		return nil, err
+	}
+	if out.ArchivedReadings, err = s.sensors.Archive(ctx, deviceID, now); err != nil {
+		return nil, err
+	}
+	s.latest.Delete(deviceID)
+	return out, nil
+}
@@ -0,0 +1,250 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: decommission_service_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of decommissioning devices and of status reports racing it.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/events"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// liveDevices keeps devices in memory and applies the filters of the
+// MongoDB repository, so that concurrent writes race as they would there.
+type liveDevices struct {
+	repository.DeviceRepository
+	mu      sync.Mutex
+	devices map[string]models.Device
+}
+
+func (r *liveDevices) GetByID(_ context.Context, id string) (*models.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.devices[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &d, nil
+}
+
+func (r *liveDevices) SetStatus(_ context.Context, id string, status models.DeviceStatus, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.devices[id]
+	if !ok || d.DeletedAt != nil || d.Status == models.DeviceDecommissioned {
+		return repository.ErrNotFound
+	}
+	d.Status, d.LastSeenAt = status, &at
+	r.devices[id] = d
+	return nil
+}
+
+func (r *liveDevices) Touch(context.Context, string, time.Time) error {
+	return nil
+}
+
+func (r *liveDevices) Decommission(_ context.Context, id string, at time.Time) (*models.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.devices[id]
+	if !ok || d.DeletedAt != nil || d.Status == models.DeviceDecommissioned {
+		return nil, repository.ErrNotFound
+	}
+	d.Status, d.DecommissionedAt = models.DeviceDecommissioned, &at
+	r.devices[id] = d
+	return &d, nil
+}
+
+// expiringCredentials records the devices whose credentials it expired.
+type expiringCredentials struct {
+	repository.MQTTCredentialRepository
+	expired []string
+}
+
+func (r *expiringCredentials) ExpireAll(_ context.Context, deviceID string, _ time.Time) error {
+	r.expired = append(r.expired, deviceID)
+	return nil
+}
+
+// archivingSensors archives the readings it holds per device.
+type archivingSensors struct {
+	repository.SensorDataRepository
+	readings map[string]int64
+	inserted int
+}
+
+func (r *archivingSensors) Archive(_ context.Context, deviceID string, _ time.Time) (int64, error) {
+	n := r.readings[deviceID]
+	delete(r.readings, deviceID)
+	return n, nil
+}
+
+func (r *archivingSensors) Insert(context.Context, *models.SensorData) error {
+	r.inserted++
+	return nil
+}
+
+func TestDecommission(t *testing.T) {
+	deleted := time.Now()
+	devices := &liveDevices{devices: map[string]models.Device{
+		"d1":  {ID: "d1", UserID: "u1", Status: models.DeviceOnline},
+		"old": {ID: "old", UserID: "u1", DeletedAt: &deleted},
+	}}
+	commands, commandRepo, _, _ := newTestCommandService(nil)
+	commands.devices = devices
+	for _, c := range []models.Command{
+		{CommandID: "c1", DeviceID: "d1", Status: models.CommandSent},
+		{CommandID: "c2", DeviceID: "d1", Status: models.CommandQueued},
+		{CommandID: "c3", DeviceID: "d1", Status: models.CommandSuccess},
+	} {
+		commandRepo.cmds[c.CommandID] = c
+	}
+	credentials := &expiringCredentials{}
+	sensors := &archivingSensors{readings: map[string]int64{"d1": 42}}
+	latest := cache.NewLatestCache()
+	latest.Set(models.SensorData{DeviceID: "d1", Timestamp: time.Now()})
+	s := NewDecommissionService(devices, sensors, credentials, commands, latest)
+	ctx := context.Background()
+
+	out, err := s.Decommission(ctx, "d1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		name string
+		ok   bool
+	}{
+		{"device marked decommissioned", out.Device.Status == models.DeviceDecommissioned && out.Device.DecommissionedAt != nil},
+		{"decommission stored", devices.devices["d1"].Status == models.DeviceDecommissioned},
+		{"credentials expired", len(credentials.expired) == 1 && credentials.expired[0] == "d1"},
+		{"open commands cancelled", out.CancelledCommands == 2 &&
+			commandRepo.cmds["c1"].Status == models.CommandCancelled && commandRepo.cmds["c2"].Status == models.CommandCancelled},
+		{"final commands left alone", commandRepo.cmds["c3"].Status == models.CommandSuccess},
+		{"readings archived", out.ArchivedReadings == 42},
+		{"latest reading forgotten", func() bool { _, ok := latest.Get("d1"); return !ok }()},
+	}
+	for _, step := range steps {
+		if !step.ok {
+			t.Errorf("%s: not done", step.name)
+		}
+	}
+
+	// Again, it finishes the steps without decommissioning anew.
+	at := *out.Device.DecommissionedAt
+	again, err := s.Decommission(ctx, "d1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Device.DecommissionedAt.Equal(at) || again.CancelledCommands != 0 || again.ArchivedReadings != 0 {
+		t.Errorf("second decommission = %+v, want the first time kept and nothing left to do", again)
+	}
+	if len(credentials.expired) != 2 {
+		t.Errorf("credentials expired %d times, want 2", len(credentials.expired))
+	}
+
+	for _, id := range []string{"old", "missing"} {
+		if _, err := s.Decommission(ctx, id); !errors.Is(err, ErrDeviceNotFound) {
+			t.Errorf("decommission %s: err = %v, want ErrDeviceNotFound", id, err)
+		}
+	}
+
+	// Decommissioned devices take no readings and no commands.
+	sensorService := &SensorService{repo: sensors, devices: devices, latest: latest, events: events.NewHub()}
+	reading := &models.SensorData{DeviceID: "d1", Timestamp: time.Now(), Sensors: models.Sensors{PM25: models.SensorValue{Value: 10}}}
+	if err := sensorService.Ingest(ctx, reading); !errors.Is(err, ErrDeviceDecommissioned) {
+		t.Errorf("ingest: err = %v, want ErrDeviceDecommissioned", err)
+	}
+	if sensors.inserted != 0 {
+		t.Errorf("stored %d readings, want none", sensors.inserted)
+	}
+	if _, err := commands.Create(ctx, CommandRequest{DeviceID: "d1", Action: "reboot", UserID: "u1"}); !errors.Is(err, ErrDeviceDecommissioned) {
+		t.Errorf("command: err = %v, want ErrDeviceDecommissioned", err)
+	}
+}
+
+func TestReportStatus(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		device     *models.Device
+		status     models.DeviceStatus
+		wantErr    error
+		wantStatus models.DeviceStatus
+	}{
+		{"online", &models.Device{ID: "d1", Status: models.DeviceOffline}, models.DeviceOnline, nil, models.DeviceOnline},
+		{"offline", &models.Device{ID: "d1", Status: models.DeviceOnline}, models.DeviceOffline, nil, models.DeviceOffline},
+		{"reports decommissioned", &models.Device{ID: "d1", Status: models.DeviceOnline}, models.DeviceDecommissioned,
+			ErrInvalidDevice, models.DeviceOnline},
+		{"unknown status", &models.Device{ID: "d1", Status: models.DeviceOnline}, "asleep", ErrInvalidDevice, models.DeviceOnline},
+		{"decommissioned", &models.Device{ID: "d1", Status: models.DeviceDecommissioned, DecommissionedAt: &now},
+			models.DeviceOnline, ErrDeviceDecommissioned, models.DeviceDecommissioned},
+		{"deleted", &models.Device{ID: "d1", Status: models.DeviceOffline, DeletedAt: &now}, models.DeviceOnline,
+			ErrDeviceNotFound, models.DeviceOffline},
+		{"unknown device", nil, models.DeviceOnline, ErrDeviceNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devices := &liveDevices{devices: map[string]models.Device{}}
+			if tt.device != nil {
+				devices.devices["d1"] = *tt.device
+			}
+			s := &DeviceService{repo: devices}
+			if err := s.ReportStatus(context.Background(), "d1", tt.status, now); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := devices.devices["d1"].Status; got != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestReportStatusRacingDecommission sends status reports while the device
+// is decommissioned: none may bring it back.
+func TestReportStatusRacingDecommission(t *testing.T) {
+	ctx := context.Background()
+	for range 50 {
+		devices := &liveDevices{devices: map[string]models.Device{"d1": {ID: "d1", Status: models.DeviceOnline}}}
+		commands, _, _, _ := newTestCommandService(nil)
+		commands.devices = devices
+		decommission := NewDecommissionService(devices, &archivingSensors{}, &expiringCredentials{}, commands, cache.NewLatestCache())
+		s := &DeviceService{repo: devices}
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 20 {
+					err := s.ReportStatus(ctx, "d1", models.DeviceOnline, time.Now())
+					if err != nil && !errors.Is(err, ErrDeviceDecommissioned) {
+						t.Errorf("report: %v", err)
+						return
+					}
+				}
+			}()
+		}
+		if _, err := decommission.Decommission(ctx, "d1"); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+		if got := devices.devices["d1"].Status; got != models.DeviceDecommissioned {
+			t.Fatalf("status = %q after the reports, want decommissioned", got)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_import.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the CSV bulk import of devices in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/models"
+)
+
+const (
+	maxImportRows        = 1000
+	maxDeviceNameLen     = 100
+	maxDeviceLocationLen = 200
+)
+
+var importHeaders = [][]string{
+	{"name", "location"},
+	{"name", "location", "user_id"},
+}
+
+// ImportResult reports the outcome of one CSV row. Line is the line number
+// in the uploaded file, the header being line 1.
+type ImportResult struct {
+	Line     int    `json:"line"`
+	DeviceID string `json:"device_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// MQTTCredentials are issued for every imported device.
+	MQTTCredentials *MQTTCredentials `json:"mqtt_credentials,omitempty"`
+}
+
+// Import creates one device per valid CSV row for userID in a single bulk
+// write. Invalid rows are reported and skipped; a header other than
+// "name,location[,user_id]" rejects the whole upload. Only admins may fill
+// in user_id, creating the device for that user instead; rows of a user
+// who does not exist or has no quota left fail. Exceeding the quota of
+// userID fails the whole upload.
+func (s *DeviceService) Import(ctx context.Context, userID string, admin bool, r io.Reader) ([]ImportResult, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, ErrInvalidCSVHeader
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	if !slices.ContainsFunc(importHeaders, func(h []string) bool { return slices.Equal(h, header) }) {
+		return nil, ErrInvalidCSVHeader
+	}
+
+	now := time.Now()
+	var (
+		results []ImportResult
+		devices []*models.Device
+		// rows holds the index in results of each device.
+		rows []int
+	)
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(results) >= maxImportRows {
+			return nil, ErrTooManyRows
+		}
+		res := ImportResult{Line: line}
+		if err != nil {
+			res.Error = "malformed CSV row"
+			results = append(results, res)
+			continue
+		}
+
+		d, rowErr := importRow(record, len(header), userID, admin)
+		if rowErr != nil {
+			res.Error = rowErr.Error()
+			results = append(results, res)
+			continue
+		}
+		d.ID = primitive.NewObjectID().Hex()
+		d.CreatedAt, d.UpdatedAt = now, now
+		res.DeviceID = d.ID
+		rows = append(rows, len(results))
+		results = append(results, res)
+		devices = append(devices, d)
+	}
+
+	if len(devices) == 0 {
+		return results, nil
+	}
+	reserved, err := s.reserveImport(ctx, userID, devices)
+	if err != nil {
+		return nil, err
+	}
+	var created []*models.Device
+	for i, d := range devices {
+		if n, ok := reserved[d.UserID]; ok && n.err != nil {
+			results[rows[i]].DeviceID = ""
+			results[rows[i]].Error = n.err.Error()
+			continue
+		}
+		created = append(created, d)
+	}
+	if len(created) == 0 {
+		return results, nil
+	}
+	if err := s.repo.CreateMany(ctx, created); err != nil {
+		for owner, n := range reserved {
+			if n.err == nil {
+				s.releaseQuota(ctx, owner, n.count)
+			}
+		}
+		return nil, err
+	}
+	for _, d := range created {
+		audit.Record(ctx, audit.ActionDeviceCreate, audit.ResourceDevice, d.ID, audit.Changes(nil, d))
+	}
+	return results, nil
+}
+
+// importQuota is the outcome of reserving the quota of one owner of
+// imported devices.
+type importQuota struct {
+	count int
+	err   error
+}
+
+// reserveImport reserves the quota for devices by owner. The devices of
+// other users than userID whose quota could not be reserved get the error
+// to report on their rows; failing to reserve that of userID releases all
+// and returns the error.
+func (s *DeviceService) reserveImport(ctx context.Context, userID string, devices []*models.Device) (map[string]*importQuota, error) {
+	reserved := make(map[string]*importQuota)
+	var owners []string
+	for _, d := range devices {
+		n, ok := reserved[d.UserID]
+		if !ok {
+			n = &importQuota{}
+			reserved[d.UserID] = n
+			owners = append(owners, d.UserID)
+		}
+		n.count++
+	}
+	for i, owner := range owners {
+		n := reserved[owner]
+		err := s.quota.Reserve(ctx, owner, n.count)
+		var quotaErr *QuotaExceededError
+		switch {
+		case err == nil:
+			continue
+		case owner != userID && errors.Is(err, ErrUserNotFound):
+			n.err = errors.New("user_id does not exist")
+		case owner != userID && (errors.As(err, &quotaErr) || errors.Is(err, ErrEmailNotVerified)):
+			n.err = fmt.Errorf("user_id: %w", err)
+		default:
+			for _, o := range owners[:i] {
+				if reserved[o].err == nil {
+					s.releaseQuota(ctx, o, reserved[o].count)
+				}
+			}
+			return nil, err
+		}
+	}
+	return reserved, nil
+}
+
+func importRow(record []string, columns int, userID string, admin bool) (*models.Device, error) {
+	if len(record) != columns {
+		return nil, fmt.Errorf("expected %d columns, got %d", columns, len(record))
+	}
+	name, location := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if len(name) > maxDeviceNameLen {
+		return nil, fmt.Errorf("name exceeds %d characters", maxDeviceNameLen)
+	}
+	if len(location) > maxDeviceLocationLen {
+		return nil, fmt.Errorf("location exceeds %d characters", maxDeviceLocationLen)
+	}
+	owner := userID
+	if columns == 3 {
+		if v := strings.TrimSpace(record[2]); v != "" && v != userID {
+			if !admin {
+				return nil, errors.New("assigning user_id requires the admin role")
+			}
+			owner = v
+		}
+	}
+	return &models.Device{UserID: owner, Name: name, Location: location}, nil
+}
@@ -0,0 +1,56 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_import_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the CSV bulk import of devices.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportRow(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns int
+		record  []string
+		admin   bool
+		owner   string
+		wantErr string
+	}{
+		{"two columns", 2, []string{"Kitchen", "Home"}, false, "u1", ""},
+		{"empty user_id", 3, []string{"Kitchen", "Home", ""}, false, "u1", ""},
+		{"own user_id", 3, []string{"Kitchen", "Home", "u1"}, false, "u1", ""},
+		{"other user_id", 3, []string{"Kitchen", "Home", "u2"}, false, "", "requires the admin role"},
+		{"other user_id as admin", 3, []string{"Kitchen", "Home", "u2"}, true, "u2", ""},
+		{"padded user_id as admin", 3, []string{"Kitchen", "Home", " u2 "}, true, "u2", ""},
+		{"missing name", 3, []string{" ", "Home", ""}, true, "", "name is required"},
+		{"long name", 3, []string{strings.Repeat("n", maxDeviceNameLen+1), "Home", ""}, false, "", "name exceeds"},
+		{"long location", 3, []string{"Kitchen", strings.Repeat("l", maxDeviceLocationLen+1), ""}, false, "", "location exceeds"},
+		{"short row", 3, []string{"Kitchen", "Home"}, false, "", "expected 3 columns"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := importRow(tt.record, tt.columns, "u1", tt.admin)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("importRow() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("importRow() error = %v", err)
+			}
+			if d.UserID != tt.owner {
+				t.Errorf("UserID = %q, want %q", d.UserID, tt.owner)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_key_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic for device API keys in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// apiKeyPrefix makes leaked keys easy to recognise in logs and scanners.
+const apiKeyPrefix = "ask_"
+
+// MintedKey is returned once, when a key is created.
+type MintedKey struct {
+	models.DeviceCredential `bson:",inline"`
+	Key                     string `json:"key"`
+}
+
+type DeviceKeyService struct {
+	repo repository.DeviceCredentialRepository
+}
+
+func NewDeviceKeyService(repo repository.DeviceCredentialRepository) *DeviceKeyService {
+	return &DeviceKeyService{repo: repo}
+}
+
+func (s *DeviceKeyService) Mint(ctx context.Context, deviceID string) (*MintedKey, error) {
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	key := apiKeyPrefix + secret
+	cred := models.DeviceCredential{
+		ID:        primitive.NewObjectID().Hex(),
+		DeviceID:  deviceID,
+		KeyHash:   auth.HashToken(key),
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.Create(ctx, &cred); err != nil {
+		return nil, err
+	}
+	return &MintedKey{DeviceCredential: cred, Key: key}, nil
+}
+
+func (s *DeviceKeyService) List(ctx context.Context, deviceID string) ([]models.DeviceCredential, error) {
+	return s.repo.ListByDevice(ctx, deviceID)
+}
+
+func (s *DeviceKeyService) Revoke(ctx context.Context, deviceID, keyID string) error {
+	err := s.repo.Revoke(ctx, deviceID, keyID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrKeyNotFound
+	}
+	return err
+}
+
+// Authenticate resolves an API key to its device ID. Unknown and revoked
+// keys return ErrInvalidAPIKey.
+func (s *DeviceKeyService) Authenticate(ctx context.Context, key string) (string, error) {
+	cred, err := s.repo.GetByHash(ctx, auth.HashToken(key))
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", ErrInvalidAPIKey
+	}
+	if err != nil {
+		return "", err
+	}
+	if cred.RevokedAt != nil {
+		return "", ErrInvalidAPIKey
+	}
+	return cred.DeviceID, nil
+}
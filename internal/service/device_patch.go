@@ -0,0 +1,120 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_patch.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the partial update of device metadata in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// DevicePatch holds the new values of a PATCH; only the fields named in the
+// accompanying mask are applied. Null Coordinates clear them.
+type DevicePatch struct {
+	Name          string           `json:"name"`
+	Location      string           `json:"location"`
+	Coordinates   *models.GeoPoint `json:"coordinates"`
+	MinIntervalMs int64            `json:"min_interval_ms"`
+}
+
+// maxMinInterval bounds the thinning interval of a device.
+const maxMinInterval = 24 * time.Hour
+
+var (
+	patchableDeviceFields = map[string]bool{"name": true, "location": true, "coordinates": true, "min_interval_ms": true}
+	immutableDeviceFields = map[string]bool{
+		"id": true, "user_id": true, "created_at": true, "updated_at": true, "deleted_at": true,
+	}
+)
+
+// Patch applies the fields of p named in mask (JSON field names) to d.
+// Nothing is written, and UpdatedAt is left alone, when no value changes.
+func (s *DeviceService) Patch(ctx context.Context, d *models.Device, mask []string, p DevicePatch) (*models.Device, error) {
+	var immutable, unknown []string
+	for _, f := range mask {
+		switch {
+		case patchableDeviceFields[f]:
+		case immutableDeviceFields[f]:
+			immutable = append(immutable, f)
+		default:
+			unknown = append(unknown, f)
+		}
+	}
+	if len(immutable) > 0 {
+		sort.Strings(immutable)
+		return nil, &FieldsError{Reason: "immutable", Fields: immutable}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, &FieldsError{Reason: "unknown", Fields: unknown}
+	}
+
+	set := make(map[string]any)
+	for _, f := range mask {
+		switch f {
+		case "name":
+			name := strings.TrimSpace(p.Name)
+			if name == "" {
+				return nil, fmt.Errorf("%w: name must not be empty", ErrInvalidDevice)
+			}
+			if len(name) > maxDeviceNameLen {
+				return nil, fmt.Errorf("%w: name exceeds %d characters", ErrInvalidDevice, maxDeviceNameLen)
+			}
+			if name != d.Name {
+				set["name"] = name
+			}
+		case "location":
+			location := strings.TrimSpace(p.Location)
+			if len(location) > maxDeviceLocationLen {
+				return nil, fmt.Errorf("%w: location exceeds %d characters", ErrInvalidDevice, maxDeviceLocationLen)
+			}
+			if location != d.Location {
+				set["location"] = location
+			}
+		case "coordinates":
+			if p.Coordinates != nil && !p.Coordinates.Valid() {
+				return nil, fmt.Errorf("%w: coordinates must be a GeoJSON Point of a longitude and a latitude", ErrInvalidDevice)
+			}
+			if !reflect.DeepEqual(p.Coordinates, d.Coordinates) {
+				set["coordinates"] = p.Coordinates
+			}
+		case "min_interval_ms":
+			if p.MinIntervalMs < 0 || p.MinIntervalMs > maxMinInterval.Milliseconds() {
+				return nil, fmt.Errorf("%w: min_interval_ms must be between 0 and %d", ErrInvalidDevice, maxMinInterval.Milliseconds())
+			}
+			if p.MinIntervalMs != d.MinIntervalMs {
+				set["min_interval_ms"] = p.MinIntervalMs
+			}
+		}
+	}
+	if len(set) == 0 {
+		return d, nil
+	}
+
+	updated, err := s.repo.Update(ctx, d.ID, set)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, audit.ActionDeviceUpdate, audit.ResourceDevice, d.ID, audit.Changes(d, updated))
+	return updated, nil
+}
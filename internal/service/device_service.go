@@ -0,0 +1,536 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the business logic for devices in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// Access is the level of access an operation needs on a device.
+type Access int
+
+const (
+	// AccessRead allows reading the device and its data: owner, any share
+	// or any member of its organization.
+	AccessRead Access = iota
+	// AccessControl allows commands and metadata changes: owner, a
+	// "control" share or an organization admin.
+	AccessControl
+	// AccessOwner is reserved to the owner and organization owners, e.g.
+	// sharing.
+	AccessOwner
+)
+
+// orgAccess is the device access each organization role grants.
+var orgAccess = map[models.OrgRole]Access{
+	models.OrgViewer: AccessRead,
+	models.OrgAdmin:  AccessControl,
+	models.OrgOwner:  AccessOwner,
+}
+
+// DeviceView is a device as seen by a particular user. SharedBy is set when
+// the user sees the device through a share rather than owning it.
+type DeviceView struct {
+	models.Device `bson:",inline"`
+	SharedBy      string                 `json:"shared_by,omitempty"`
+	Permission    models.SharePermission `json:"permission,omitempty"`
+}
+
+type DeviceService struct {
+	repo    repository.DeviceRepository
+	shares  repository.ShareRepository
+	members repository.OrgMemberRepository
+	sensors repository.SensorDataRepository
+	quota   *QuotaService
+	// latest forgets the readings of deleted devices.
+	latest *cache.LatestCache
+}
+
+func NewDeviceService(repo repository.DeviceRepository, shares repository.ShareRepository, members repository.OrgMemberRepository,
+	sensors repository.SensorDataRepository, quota *QuotaService, latest *cache.LatestCache) *DeviceService {
+	return &DeviceService{repo: repo, shares: shares, members: members, sensors: sensors, quota: quota, latest: latest}
+}
+
+// Register adds a device for userID. Registering the ID of a device the
+// same user soft-deleted restores it (restored is true); any other existing
+// device with that ID is a conflict. New and restored devices count against
+// the user's quota.
+func (s *DeviceService) Register(ctx context.Context, userID, id, name, location string) (d *models.Device, restored bool, err error) {
+	existing, err := s.repo.GetByID(ctx, id)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+	case err != nil:
+		return nil, false, err
+	case existing.DeletedAt != nil && existing.UserID == userID:
+		if err := s.quota.Reserve(ctx, userID, 1); err != nil {
+			return nil, false, err
+		}
+		d, err = s.repo.Restore(ctx, id, name, location)
+		if err != nil {
+			s.releaseQuota(ctx, userID, 1)
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			// Restored concurrently by another request.
+			return nil, false, ErrDeviceExists
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		audit.Record(ctx, audit.ActionDeviceRestore, audit.ResourceDevice, id, audit.Changes(existing, d))
+		return d, true, nil
+	default:
+		return nil, false, ErrDeviceExists
+	}
+
+	if err := s.quota.Reserve(ctx, userID, 1); err != nil {
+		return nil, false, err
+	}
+	now := time.Now()
+	d = &models.Device{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Location:  location,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Create(ctx, d); err != nil {
+		s.releaseQuota(ctx, userID, 1)
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, false, ErrDeviceExists
+		}
+		return nil, false, err
+	}
+	audit.Record(ctx, audit.ActionDeviceCreate, audit.ResourceDevice, id, audit.Changes(nil, d))
+	return d, false, nil
+}
+
+// Provision adds a device with a new ID for userID on behalf of the device
+// with serial number serial, which can only be provisioned once. It counts
+// against the user's quota like Register.
+func (s *DeviceService) Provision(ctx context.Context, userID, serial string) (*models.Device, error) {
+	if err := s.quota.Reserve(ctx, userID, 1); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	d := &models.Device{
+		ID:           primitive.NewObjectID().Hex(),
+		UserID:       userID,
+		Name:         serial,
+		SerialNumber: serial,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.repo.Create(ctx, d); err != nil {
+		s.releaseQuota(ctx, userID, 1)
+		if errors.Is(err, repository.ErrDuplicate) {
+			// The ID is new, so the serial number is taken.
+			return nil, ErrSerialNumberExists
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// Delete soft-deletes a device and frees its quota slot. Its readings stay
+// until it is purged.
+func (s *DeviceService) Delete(ctx context.Context, id string) error {
+	d, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrDeviceNotFound
+	}
+	if err != nil {
+		return err
+	}
+	err = s.repo.SoftDelete(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrDeviceNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.latest.Delete(id)
+	s.releaseQuota(ctx, d.UserID, 1)
+	audit.Record(ctx, audit.ActionDeviceDelete, audit.ResourceDevice, id, audit.Changes(d, nil))
+	return nil
+}
+
+// ReportStatus records the status a device reported about itself at at.
+func (s *DeviceService) ReportStatus(ctx context.Context, id string, status models.DeviceStatus, at time.Time) error {
+	if !status.Valid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidDevice, status)
+	}
+	// The write itself skips decommissioned devices, so a report racing
+	// a decommission cannot bring the device back.
+	err := s.repo.SetStatus(ctx, id, status, at)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	d, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrDeviceNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if d.DecommissionedAt != nil && d.DeletedAt == nil {
+		return ErrDeviceDecommissioned
+	}
+	return ErrDeviceNotFound
+}
+
+// Purge permanently removes a soft-deleted device together with its
+// readings and shares. userID needs owner access to it, as for Delete.
+func (s *DeviceService) Purge(ctx context.Context, id, userID string) (int64, error) {
+	d, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, ErrDeviceNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := s.grants(ctx, d, userID, AccessOwner); err != nil {
+		return 0, err
+	}
+	if d.DeletedAt == nil {
+		return 0, ErrDeviceNotDeleted
+	}
+
+	deleted, err := s.sensors.DeleteByDevice(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.shares.DeleteByDevice(ctx, id); err != nil {
+		return deleted, err
+	}
+	s.latest.Delete(id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return deleted, err
+	}
+	audit.Record(ctx, audit.ActionDevicePurge, audit.ResourceDevice, id, audit.Changes(d, nil))
+	return deleted, nil
+}
+
+// releaseQuota gives back device slots; a failure only leaves the user
+// with fewer free slots, so it is logged rather than returned.
+func (s *DeviceService) releaseQuota(ctx context.Context, userID string, n int) {
+	if err := s.quota.Release(ctx, userID, n); err != nil {
+		log.Printf("quota: release %d device(s) of user %s: %v", n, userID, err)
+	}
+}
+
+// DeviceQuery searches the devices a user can see. Sort is one of name,
+// created_at or last_seen, prefixed with "-" for descending order; empty
+// keeps registration (ID) order.
+type DeviceQuery struct {
+	Name     string
+	Location string
+	Status   models.DeviceStatus
+	Firmware string
+	Tags     map[string]string
+	// Near and RadiusKm keep the devices whose coordinates lie within
+	// RadiusKm of Near; they go together.
+	Near      *models.GeoPoint
+	RadiusKm  float64
+	Sort      string
+	Cursor    string
+	Limit     int64
+	WithTotal bool
+	// IncludeDecommissioned also lists decommissioned devices.
+	IncludeDecommissioned bool
+}
+
+// DevicePage is one page of devices. NextCursor is nil on the last page and
+// Total is only counted when asked for.
+type DevicePage struct {
+	Data       []DeviceView `json:"data"`
+	NextCursor *string      `json:"next_cursor"`
+	Total      *int64       `json:"total,omitempty"`
+}
+
+// maxDeviceRadiusKm is about half the Earth's circumference: a wider
+// search radius matches every device with coordinates anyway.
+const maxDeviceRadiusKm = 20000
+
+var deviceSortFields = map[string]string{
+	"name":       "name",
+	"created_at": "created_at",
+	"last_seen":  "last_seen_at",
+}
+
+// deviceCursor is the JSON form of repository.DeviceCursor. Times are kept
+// as RFC 3339 strings and parsed back according to the sort field.
+type deviceCursor struct {
+	Value any    `json:"v"`
+	ID    string `json:"id"`
+}
+
+// List returns one page of the devices the user owns or has been shared
+// that match q.
+func (s *DeviceService) List(ctx context.Context, userID string, q DeviceQuery) (*DevicePage, error) {
+	return s.list(ctx, repository.DeviceFilter{UserID: userID}, true, q)
+}
+
+// ListAll is List over the devices of every user, for admins, or only
+// those ownerID owns if it is set. Shares are left out.
+func (s *DeviceService) ListAll(ctx context.Context, ownerID string, q DeviceQuery) (*DevicePage, error) {
+	return s.list(ctx, repository.DeviceFilter{UserID: ownerID, AllUsers: ownerID == ""}, false, q)
+}
+
+// ListOrg is List over the devices of organization orgID. The caller must
+// already have checked that the user is a member.
+func (s *DeviceService) ListOrg(ctx context.Context, orgID string, q DeviceQuery) (*DevicePage, error) {
+	return s.list(ctx, repository.DeviceFilter{OrgID: orgID}, false, q)
+}
+
+// list lists the devices matching owner, the owner fields of a filter,
+// and, with shared, those shared with owner.UserID.
+func (s *DeviceService) list(ctx context.Context, owner repository.DeviceFilter, shared bool, q DeviceQuery) (*DevicePage, error) {
+	userID := owner.UserID
+	filter := owner
+	filter.Name = q.Name
+	filter.Location = q.Location
+	filter.Status = q.Status
+	filter.Firmware = q.Firmware
+	filter.Tags = q.Tags
+	filter.Near, filter.RadiusKm = q.Near, q.RadiusKm
+	filter.IncludeDecommissioned = q.IncludeDecommissioned
+
+	if q.Status != "" && !q.Status.Valid() {
+		return nil, ErrInvalidDeviceQuery
+	}
+	for k, v := range q.Tags {
+		if validateTag(k, v) != nil {
+			return nil, ErrInvalidDeviceQuery
+		}
+	}
+	if q.Near != nil && (!q.Near.Valid() || !(q.RadiusKm > 0 && q.RadiusKm <= maxDeviceRadiusKm)) {
+		return nil, ErrInvalidDeviceQuery
+	}
+	if q.Sort != "" {
+		name, desc := strings.CutPrefix(q.Sort, "-")
+		field, ok := deviceSortFields[name]
+		if !ok {
+			return nil, ErrInvalidDeviceQuery
+		}
+		filter.SortField, filter.Desc = field, desc
+	}
+	after, err := decodeDeviceCursor(q.Cursor, filter.SortField)
+	if err != nil {
+		return nil, err
+	}
+	filter.After = after
+
+	var byDevice map[string]models.DeviceShare
+	if shared {
+		if byDevice, err = s.sharedWith(ctx, userID); err != nil {
+			return nil, err
+		}
+		filter.SharedIDs = sharedIDs(byDevice)
+	}
+
+	page := &DevicePage{}
+	if q.WithTotal {
+		total, err := s.repo.Count(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	// Fetch one extra document to learn whether another page exists.
+	filter.Limit = q.Limit + 1
+	devices, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(devices)) > q.Limit {
+		devices = devices[:q.Limit]
+		c, err := encodeDeviceCursor(&devices[len(devices)-1], filter.SortField)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = &c
+	}
+
+	page.Data = deviceViews(devices, byDevice, userID)
+	return page, nil
+}
+
+// All returns every device the user owns or has been shared, in one query.
+func (s *DeviceService) All(ctx context.Context, userID string) ([]DeviceView, error) {
+	byDevice, err := s.sharedWith(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.repo.List(ctx, repository.DeviceFilter{
+		UserID:    userID,
+		SharedIDs: sharedIDs(byDevice),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deviceViews(devices, byDevice, userID), nil
+}
+
+func sharedIDs(byDevice map[string]models.DeviceShare) []string {
+	ids := make([]string, 0, len(byDevice))
+	for id := range byDevice {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// sharedWith returns the shares granted to userID keyed by device ID.
+func (s *DeviceService) sharedWith(ctx context.Context, userID string) (map[string]models.DeviceShare, error) {
+	shares, err := s.shares.ListByGrantee(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byDevice := make(map[string]models.DeviceShare, len(shares))
+	for _, sh := range shares {
+		byDevice[sh.DeviceID] = sh
+	}
+	return byDevice, nil
+}
+
+func deviceViews(devices []models.Device, byDevice map[string]models.DeviceShare, userID string) []DeviceView {
+	views := make([]DeviceView, len(devices))
+	for i, d := range devices {
+		views[i] = DeviceView{Device: d}
+		if sh, ok := byDevice[d.ID]; ok && d.UserID != userID {
+			views[i].SharedBy = sh.OwnerID
+			views[i].Permission = sh.Permission
+		}
+	}
+	return views
+}
+
+func encodeDeviceCursor(d *models.Device, field string) (string, error) {
+	c := deviceCursor{ID: d.ID}
+	switch field {
+	case "name":
+		c.Value = d.Name
+	case "created_at":
+		c.Value = d.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "last_seen_at":
+		if d.LastSeenAt != nil {
+			c.Value = d.LastSeenAt.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return utils.EncodeCursor(string(b)), nil
+}
+
+func decodeDeviceCursor(cursor, field string) (*repository.DeviceCursor, error) {
+	raw, err := utils.DecodeCursor(cursor)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	var c deviceCursor
+	if err := json.Unmarshal([]byte(raw), &c); err != nil || c.ID == "" {
+		return nil, utils.ErrInvalidCursor
+	}
+	after := &repository.DeviceCursor{ID: c.ID}
+	switch v := c.Value.(type) {
+	case nil:
+		if field == "name" || field == "created_at" {
+			return nil, utils.ErrInvalidCursor
+		}
+	case string:
+		after.Value = v
+		if field == "created_at" || field == "last_seen_at" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, utils.ErrInvalidCursor
+			}
+			after.Value = t
+		}
+	default:
+		return nil, utils.ErrInvalidCursor
+	}
+	return after, nil
+}
+
+// Authorize returns the device if userID has at least the wanted access to
+// it, as its owner, through the role in its organization or through a
+// share. Soft-deleted devices are not
+// found, except for read access by the owner when includeDeleted is set.
+// Decommissioned devices refuse control access with ErrDeviceDecommissioned.
+func (s *DeviceService) Authorize(ctx context.Context, id, userID string, want Access, includeDeleted bool) (*models.Device, error) {
+	d, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.DeletedAt != nil && !(includeDeleted && want == AccessRead && d.UserID == userID) {
+		return nil, ErrDeviceNotFound
+	}
+	if d.DecommissionedAt != nil && want == AccessControl {
+		return nil, ErrDeviceDecommissioned
+	}
+	if err := s.grants(ctx, d, userID, want); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// grants returns nil if userID has at least the wanted access to d,
+// whatever its state, and ErrForbidden if not.
+func (s *DeviceService) grants(ctx context.Context, d *models.Device, userID string, want Access) error {
+	if d.UserID == userID {
+		return nil
+	}
+	if d.OrgID != "" {
+		m, err := s.members.Get(ctx, d.OrgID, userID)
+		if err == nil && orgAccess[m.Role] >= want {
+			return nil
+		}
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+	}
+	if want == AccessOwner {
+		return ErrForbidden
+	}
+
+	share, err := s.shares.Get(ctx, d.ID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+	if want == AccessControl && share.Permission != models.ShareControl {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_service_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of listing and managing devices.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// pagedDevices lists its devices in the order of the repository: by the
+// sort field, ties broken by ID, after the cursor and up to the limit. It
+// keeps the filters it was given.
+type pagedDevices struct {
+	repository.DeviceRepository
+	devices []models.Device
+	filters []repository.DeviceFilter
+}
+
+func (r *pagedDevices) List(_ context.Context, filter repository.DeviceFilter) ([]models.Device, error) {
+	r.filters = append(r.filters, filter)
+	key := func(d models.Device) string {
+		switch filter.SortField {
+		case "name":
+			return d.Name
+		case "created_at":
+			return d.CreatedAt.UTC().Format(time.RFC3339Nano)
+		}
+		return ""
+	}
+	compare := func(a, b models.Device) int {
+		c := cmp.Or(cmp.Compare(key(a), key(b)), cmp.Compare(a.ID, b.ID))
+		if filter.Desc {
+			return -c
+		}
+		return c
+	}
+	sorted := slices.Clone(r.devices)
+	slices.SortFunc(sorted, compare)
+	out := []models.Device{}
+	for _, d := range sorted {
+		if filter.After != nil {
+			after := models.Device{ID: filter.After.ID}
+			switch v := filter.After.Value.(type) {
+			case string:
+				after.Name = v
+			case time.Time:
+				after.CreatedAt = v
+			}
+			if compare(d, after) <= 0 {
+				continue
+			}
+		}
+		if filter.Limit > 0 && int64(len(out)) == filter.Limit {
+			break
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func TestDeviceListPages(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	// Names and creation times repeat, so that pages end within ties.
+	devices := []models.Device{
+		{ID: "d3", Name: "Kitchen", CreatedAt: t0},
+		{ID: "d1", Name: "Bedroom", CreatedAt: t0.Add(time.Hour)},
+		{ID: "d5", Name: "Kitchen", CreatedAt: t0},
+		{ID: "d2", Name: "Kitchen", CreatedAt: t0.Add(time.Hour)},
+		{ID: "d4", Name: "Attic", CreatedAt: t0},
+	}
+	tests := []struct {
+		sort  string
+		limit int64
+		want  []string
+	}{
+		{"", 2, []string{"d1", "d2", "d3", "d4", "d5"}},
+		{"name", 2, []string{"d4", "d1", "d2", "d3", "d5"}},
+		{"-name", 2, []string{"d5", "d3", "d2", "d1", "d4"}},
+		{"created_at", 2, []string{"d3", "d4", "d5", "d1", "d2"}},
+		{"-created_at", 3, []string{"d2", "d1", "d5", "d4", "d3"}},
+		{"name", 5, []string{"d4", "d1", "d2", "d3", "d5"}},
+		{"name", 10, []string{"d4", "d1", "d2", "d3", "d5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			s := NewDeviceService(&pagedDevices{devices: devices}, noShares{}, nil, nil, nil, nil)
+			var got []string
+			cursor := ""
+			for pages := 1; ; pages++ {
+				page, err := s.List(context.Background(), "u1", DeviceQuery{Sort: tt.sort, Cursor: cursor, Limit: tt.limit})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, d := range page.Data {
+					got = append(got, d.ID)
+				}
+				if page.NextCursor == nil {
+					if int64(len(page.Data)) > tt.limit || (len(page.Data) == 0 && pages > 1) {
+						t.Errorf("last page has %d devices", len(page.Data))
+					}
+					break
+				}
+				if int64(len(page.Data)) != tt.limit {
+					t.Errorf("page %d has %d devices and a next cursor, want %d", pages, len(page.Data), tt.limit)
+				}
+				if pages > len(devices) {
+					t.Fatal("paging does not end")
+				}
+				cursor = *page.NextCursor
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("devices = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceListInvalidCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		sort   string
+		cursor string
+	}{
+		{"not base64", "", "!!!"},
+		{"not JSON", "", utils.EncodeCursor("d1")},
+		{"no id", "", utils.EncodeCursor(`{"v": "Kitchen"}`)},
+		{"no name", "name", utils.EncodeCursor(`{"id": "d1"}`)},
+		{"number", "name", utils.EncodeCursor(`{"v": 3, "id": "d1"}`)},
+		{"bad time", "created_at", utils.EncodeCursor(`{"v": "yesterday", "id": "d1"}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &pagedDevices{}
+			s := NewDeviceService(repo, noShares{}, nil, nil, nil, nil)
+			_, err := s.List(context.Background(), "u1", DeviceQuery{Sort: tt.sort, Cursor: tt.cursor, Limit: 10})
+			if !errors.Is(err, utils.ErrInvalidCursor) {
+				t.Errorf("err = %v, want ErrInvalidCursor", err)
+			}
+			if len(repo.filters) != 0 {
+				t.Error("listed the devices")
+			}
+		})
+	}
+}
+
+func TestDeviceListFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       DeviceQuery
+		want    repository.DeviceFilter
+		wantErr error
+	}{
+		{"name and status", DeviceQuery{Name: "kit", Status: models.DeviceOnline},
+			repository.DeviceFilter{Name: "kit", Status: models.DeviceOnline}, nil},
+		{"location, firmware and tags", DeviceQuery{Location: "Hanoi", Firmware: "1.2.0", Tags: map[string]string{"floor": "2", "wing": "east"}},
+			repository.DeviceFilter{Location: "Hanoi", Firmware: "1.2.0", Tags: map[string]string{"floor": "2", "wing": "east"}}, nil},
+		{"everything, sorted", DeviceQuery{Name: "kit", Location: "Hanoi", Status: models.DeviceOffline, Firmware: "1.2.0",
+			Tags: map[string]string{"floor": "2"}, Sort: "-last_seen", IncludeDecommissioned: true},
+			repository.DeviceFilter{Name: "kit", Location: "Hanoi", Status: models.DeviceOffline, Firmware: "1.2.0",
+				Tags: map[string]string{"floor": "2"}, SortField: "last_seen_at", Desc: true, IncludeDecommissioned: true}, nil},
+		{"unknown status", DeviceQuery{Name: "kit", Status: "asleep"}, repository.DeviceFilter{}, ErrInvalidDeviceQuery},
+		{"bad tag key", DeviceQuery{Status: models.DeviceOnline, Tags: map[string]string{"floor 2": "x"}}, repository.DeviceFilter{}, ErrInvalidDeviceQuery},
+		{"unknown sort", DeviceQuery{Name: "kit", Sort: "firmware"}, repository.DeviceFilter{}, ErrInvalidDeviceQuery},
+		{"near", DeviceQuery{Status: models.DeviceOnline, Near: models.NewGeoPoint(105.85, 21.03), RadiusKm: 5},
+			repository.DeviceFilter{Status: models.DeviceOnline, Near: models.NewGeoPoint(105.85, 21.03), RadiusKm: 5}, nil},
+		{"near off the map", DeviceQuery{Near: models.NewGeoPoint(105.85, 91), RadiusKm: 5}, repository.DeviceFilter{}, ErrInvalidDeviceQuery},
+		{"near without a radius", DeviceQuery{Near: models.NewGeoPoint(105.85, 21.03)}, repository.DeviceFilter{}, ErrInvalidDeviceQuery},
+		{"radius too wide", DeviceQuery{Near: models.NewGeoPoint(105.85, 21.03), RadiusKm: 20001}, repository.DeviceFilter{}, ErrInvalidDeviceQuery},
+		{"radius not a number", DeviceQuery{Near: models.NewGeoPoint(105.85, 21.03), RadiusKm: math.NaN()}, repository.DeviceFilter{}, ErrInvalidDeviceQuery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &pagedDevices{}
+			tt.q.Limit = 10
+			_, err := NewDeviceService(repo, noShares{}, nil, nil, nil, nil).List(context.Background(), "u1", tt.q)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.filters) != 0 {
+					t.Error("listed the devices")
+				}
+				return
+			}
+			got := repo.filters[0]
+			if got.UserID != "u1" || got.Name != tt.want.Name || got.Location != tt.want.Location || got.Status != tt.want.Status ||
+				got.Firmware != tt.want.Firmware || !maps.Equal(got.Tags, tt.want.Tags) || got.SortField != tt.want.SortField ||
+				got.Desc != tt.want.Desc || got.IncludeDecommissioned != tt.want.IncludeDecommissioned ||
+				!reflect.DeepEqual(got.Near, tt.want.Near) || got.RadiusKm != tt.want.RadiusKm {
+				t.Errorf("filter = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceListEmpty(t *testing.T) {
+	s := NewDeviceService(&pagedDevices{}, noShares{}, nil, nil, nil, nil)
+	page, err := s.List(context.Background(), "u1", DeviceQuery{Name: "nothing", Status: models.DeviceOnline, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"data":[],"next_cursor":null}`; string(b) != want {
+		t.Errorf("page = %s, want %s", b, want)
+	}
+}
+
+// patchedDevices applies updates to its one device and keeps the fields
+// it was asked to set.
+type patchedDevices struct {
+	repository.DeviceRepository
+	device models.Device
+	set    map[string]any
+}
+
+func (r *patchedDevices) Update(_ context.Context, _ string, fields map[string]any) (*models.Device, error) {
+	r.set = fields
+	d := r.device
+	if p, ok := fields["coordinates"]; ok {
+		d.Coordinates = p.(*models.GeoPoint)
+	}
+	return &d, nil
+}
+
+func TestDevicePatchCoordinates(t *testing.T) {
+	hanoi := models.NewGeoPoint(105.85, 21.03)
+	tests := []struct {
+		name    string
+		stored  *models.GeoPoint
+		patch   *models.GeoPoint
+		wantSet bool
+		wantErr error
+	}{
+		{"set", nil, hanoi, true, nil},
+		{"move", models.NewGeoPoint(106.66, 10.76), hanoi, true, nil},
+		{"unchanged", hanoi, models.NewGeoPoint(105.85, 21.03), false, nil},
+		{"clear", hanoi, nil, true, nil},
+		{"clear none", nil, nil, false, nil},
+		{"latitude out of range", nil, models.NewGeoPoint(21.03, 105.85), false, ErrInvalidDevice},
+		{"longitude out of range", nil, models.NewGeoPoint(-181, 0), false, ErrInvalidDevice},
+		{"not a point", nil, &models.GeoPoint{Type: "LineString", Coordinates: []float64{105.85, 21.03}}, false, ErrInvalidDevice},
+		{"three coordinates", nil, &models.GeoPoint{Type: "Point", Coordinates: []float64{105.85, 21.03, 10}}, false, ErrInvalidDevice},
+		{"not a number", nil, models.NewGeoPoint(math.NaN(), 0), false, ErrInvalidDevice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &patchedDevices{device: models.Device{ID: "d1", Coordinates: tt.stored}}
+			d := repo.device
+			got, err := NewDeviceService(repo, noShares{}, nil, nil, nil, nil).
+				Patch(context.Background(), &d, []string{"coordinates"}, DevicePatch{Coordinates: tt.patch})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if _, set := repo.set["coordinates"]; set != tt.wantSet {
+				t.Errorf("coordinates written: %v, want %v", set, tt.wantSet)
+			}
+			if err == nil && !reflect.DeepEqual(got.Coordinates, tt.patch) {
+				t.Errorf("coordinates = %+v, want %+v", got.Coordinates, tt.patch)
+			}
+		})
+	}
+}
+
+// purgedDevice holds one device and records whether it was removed.
+type purgedDevice struct {
+	repository.DeviceRepository
+	device  models.Device
+	removed bool
+}
+
+func (r *purgedDevice) GetByID(context.Context, string) (*models.Device, error) {
+	d := r.device
+	return &d, nil
+}
+
+func (r *purgedDevice) Delete(context.Context, string) error {
+	r.removed = true
+	return nil
+}
+
+type purgedReadings struct {
+	repository.SensorDataRepository
+}
+
+func (purgedReadings) DeleteByDevice(context.Context, string) (int64, error) {
+	return 3, nil
+}
+
+type purgedShares struct {
+	noShares
+}
+
+func (purgedShares) DeleteByDevice(context.Context, string) error {
+	return nil
+}
+
+// orgRoles holds the role of each member of every organization.
+type orgRoles struct {
+	repository.OrgMemberRepository
+	roles map[string]models.OrgRole
+}
+
+func (r orgRoles) Get(_ context.Context, orgID, userID string) (*models.OrgMember, error) {
+	role, ok := r.roles[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &models.OrgMember{OrgID: orgID, UserID: userID, Role: role}, nil
+}
+
+func TestDevicePurgeAccess(t *testing.T) {
+	deleted := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	members := orgRoles{roles: map[string]models.OrgRole{
+		"owner": models.OrgOwner, "admin": models.OrgAdmin, "viewer": models.OrgViewer,
+	}}
+	tests := []struct {
+		name    string
+		device  models.Device
+		userID  string
+		wantErr error
+	}{
+		{"device owner", models.Device{ID: "d1", UserID: "u1", DeletedAt: &deleted}, "u1", nil},
+		{"organization owner", models.Device{ID: "d1", UserID: "u1", OrgID: "o1", DeletedAt: &deleted}, "owner", nil},
+		{"organization admin", models.Device{ID: "d1", UserID: "u1", OrgID: "o1", DeletedAt: &deleted}, "admin", ErrForbidden},
+		{"organization viewer", models.Device{ID: "d1", UserID: "u1", OrgID: "o1", DeletedAt: &deleted}, "viewer", ErrForbidden},
+		{"owner of another organization's device", models.Device{ID: "d1", UserID: "u1", DeletedAt: &deleted}, "owner", ErrForbidden},
+		{"stranger", models.Device{ID: "d1", UserID: "u1", OrgID: "o1", DeletedAt: &deleted}, "u2", ErrForbidden},
+		{"not deleted", models.Device{ID: "d1", UserID: "u1", OrgID: "o1"}, "owner", ErrDeviceNotDeleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &purgedDevice{device: tt.device}
+			s := NewDeviceService(repo, purgedShares{}, members, purgedReadings{}, nil, cache.NewLatestCache())
+			n, err := s.Purge(context.Background(), "d1", tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if repo.removed != (tt.wantErr == nil) {
+				t.Errorf("removed = %v, want %v", repo.removed, tt.wantErr == nil)
+			}
+			if tt.wantErr == nil && n != 3 {
+				t.Errorf("deleted readings = %d, want 3", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: device_tags.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the management of device tags in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// maxDeviceTags bounds the tags of one device.
+const maxDeviceTags = 50
+
+var (
+	// Tag keys become part of a MongoDB field path, so "." and "$" must
+	// never get through.
+	tagKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	tagValuePattern = regexp.MustCompile(`^[A-Za-z0-9 _.:/@+-]{1,128}$`)
+)
+
+func validateTag(key, value string) error {
+	if !tagKeyPattern.MatchString(key) {
+		return fmt.Errorf("%w: tag keys must be 1-64 letters, digits, _ or -", ErrInvalidDevice)
+	}
+	if !tagValuePattern.MatchString(value) {
+		return fmt.Errorf("%w: tag values must be 1-128 letters, digits, spaces or _ . : / @ + -", ErrInvalidDevice)
+	}
+	return nil
+}
+
+// SetTag adds the tag key to d or changes its value, leaving the other
+// tags alone.
+func (s *DeviceService) SetTag(ctx context.Context, d *models.Device, key, value string) (*models.Device, error) {
+	if err := validateTag(key, value); err != nil {
+		return nil, err
+	}
+	if cur, ok := d.Tags[key]; ok && cur == value {
+		return d, nil
+	} else if !ok && len(d.Tags) >= maxDeviceTags {
+		return nil, fmt.Errorf("%w: a device has at most %d tags", ErrInvalidDevice, maxDeviceTags)
+	}
+	updated, err := s.repo.Update(ctx, d.ID, map[string]any{"tags." + key: value})
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, audit.ActionDeviceUpdate, audit.ResourceDevice, d.ID, audit.Changes(d, updated))
+	return updated, nil
+}
+
+// RemoveTag removes the tag key from d, or returns ErrTagNotFound if d has
+// no such tag.
+func (s *DeviceService) RemoveTag(ctx context.Context, d *models.Device, key string) (*models.Device, error) {
+	if _, ok := d.Tags[key]; !ok {
+		return nil, ErrTagNotFound
+	}
+	updated, err := s.repo.Unset(ctx, d.ID, []string{"tags." + key})
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, audit.ActionDeviceUpdate, audit.ResourceDevice, d.ID, audit.Changes(d, updated))
+	return updated, nil
+}
@@ -0,0 +1,139 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: errors.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the errors returned by the service layer of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrDeviceNotFound = errors.New("device not found")
+	ErrForbidden      = errors.New("forbidden")
+
+	ErrDeviceExists       = errors.New("device already registered")
+	ErrDeviceDeleted      = errors.New("device deleted")
+	ErrDeviceNotDeleted   = errors.New("device must be deleted before it is purged")
+	ErrInvalidDevice      = errors.New("invalid device")
+	ErrTagNotFound        = errors.New("device tag not found")
+	ErrInvalidCSVHeader   = errors.New("CSV header must be name,location[,user_id]")
+	ErrTooManyRows        = errors.New("too many rows")
+	ErrInvalidQuota       = errors.New("invalid device quota")
+	ErrInvalidDeviceQuery = errors.New("invalid device query")
+	// ErrDeviceDecommissioned refuses data and commands for a
+	// decommissioned device.
+	ErrDeviceDecommissioned = errors.New("device decommissioned")
+
+	ErrInvalidInterval      = errors.New("invalid aggregation interval")
+	ErrInvalidComparison    = errors.New("invalid comparison")
+	ErrInvalidTimezone      = errors.New("unknown timezone")
+	ErrConfirmationRequired = errors.New("confirmation required")
+	ErrInvalidInterpolation = errors.New("invalid interpolation request")
+	ErrInvalidWindow        = errors.New("invalid smoothing window")
+	ErrInvalidSmoothing     = errors.New("invalid smoothing")
+	ErrUnknownField         = errors.New("unknown sensor field")
+	ErrTooManyReadings      = errors.New("too many readings")
+	ErrTooManyStatsReadings = errors.New("too many readings for percentiles")
+	ErrInvalidAnomalyQuery  = errors.New("invalid anomaly query")
+	ErrUnsupportedQuery     = errors.New("query not supported by the storage backend")
+	ErrReadingNotFound      = errors.New("reading not found")
+
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrWeakPassword       = errors.New("password does not meet the policy")
+	ErrInvalidResetToken  = errors.New("invalid or expired password reset token")
+	ErrInvalidEmail       = errors.New("invalid email address")
+	ErrWrongPassword      = errors.New("current password is incorrect")
+	ErrInvalidProfile     = errors.New("invalid profile")
+	// ErrEmailNotVerified refuses devices to a user who has not confirmed
+	// their email address.
+	ErrEmailNotVerified         = errors.New("email address not verified")
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+
+	ErrOIDCDisabled         = errors.New("oidc sign-in is disabled")
+	ErrInvalidOIDCState     = errors.New("invalid or expired oidc sign-in")
+	ErrInvalidOIDCCode      = errors.New("invalid or expired oidc sign-in code")
+	ErrOIDCFailed           = errors.New("oidc sign-in failed")
+	ErrOIDCEmailNotVerified = errors.New("email address not verified by the identity provider")
+	ErrOIDCDomainNotAllowed = errors.New("email domain not allowed to sign in")
+	ErrInvalidOIDCLink      = errors.New("invalid or expired oidc link token")
+	// ErrOIDCAccountConflict refuses to link a user who is linked to
+	// another identity already, or has no password to confirm with.
+	ErrOIDCAccountConflict = errors.New("account cannot be linked to this identity")
+
+	ErrTransferNotFound = errors.New("transfer not found")
+	ErrTransferExpired  = errors.New("transfer expired")
+	ErrTransferInvalid  = errors.New("transfer is no longer valid")
+	ErrTransferToSelf   = errors.New("cannot transfer a device to its owner")
+
+	ErrShareNotFound     = errors.New("share not found")
+	ErrShareExists       = errors.New("device is already shared with this user")
+	ErrShareWithOwner    = errors.New("cannot share a device with its owner")
+	ErrInvalidPermission = errors.New("invalid share permission")
+
+	ErrCommandNotFound      = errors.New("command not found")
+	ErrCommandTerminal      = errors.New("command already completed")
+	ErrCommandExpired       = errors.New("command expired")
+	ErrInvalidTransition    = errors.New("illegal command status transition")
+	ErrInvalidCommandStatus = errors.New("invalid command status")
+	ErrInvalidCommand       = errors.New("invalid command")
+	ErrCommandNotPublished  = errors.New("command could not be published")
+	ErrCommandReplayed      = errors.New("command already created for this idempotency key")
+	ErrIdempotencyKeyInUse  = errors.New("idempotency key used by another user")
+	ErrBatchNotFound        = errors.New("command batch not found")
+	ErrScheduleNotFound     = errors.New("command schedule not found")
+	ErrInvalidSchedule      = errors.New("invalid command schedule")
+	ErrOTAInProgress        = errors.New("firmware update already in progress")
+
+	ErrOrgNotFound       = errors.New("organization not found")
+	ErrInvalidOrg        = errors.New("invalid organization")
+	ErrInvalidOrgRole    = errors.New("invalid organization role")
+	ErrOrgMemberNotFound = errors.New("organization member not found")
+	ErrOrgMemberExists   = errors.New("user is already a member of the organization")
+	ErrLastOrgOwner      = errors.New("an organization must keep an owner")
+	ErrInvalidInvitation = errors.New("invalid or expired organization invitation")
+	ErrDeviceInOtherOrg  = errors.New("device belongs to another organization")
+
+	ErrGroupNotFound = errors.New("group not found")
+	ErrInvalidGroup  = errors.New("invalid group")
+
+	ErrInvalidSeverity = errors.New("invalid alert severity")
+	ErrInvalidPushApp  = errors.New("invalid push notification app")
+	ErrSilenceNotFound = errors.New("silence not found")
+	ErrInvalidSilence  = errors.New("invalid silence")
+
+	ErrKeyNotFound       = errors.New("api key not found")
+	ErrInvalidAPIKey     = errors.New("invalid api key")
+	ErrAPIKeyExpired     = errors.New("api key expired")
+	ErrInvalidKeyRequest = errors.New("invalid api key request")
+
+	ErrScopedTokenNotFound = errors.New("scoped token not found")
+	ErrInvalidScopedToken  = errors.New("invalid scoped token")
+	ErrInvalidScope        = errors.New("invalid scoped token request")
+
+	ErrProvisioningDisabled     = errors.New("device provisioning is disabled")
+	ErrInvalidProvisioningToken = errors.New("invalid provisioning token")
+	ErrInvalidProvisioning      = errors.New("invalid provisioning request")
+	ErrSerialNumberExists       = errors.New("serial number already provisioned")
+)
+
+// FieldsError rejects a request naming fields that cannot be set, e.g. the
+// immutable or unknown fields of a PATCH.
+type FieldsError struct {
+	Reason string
+	Fields []string
+}
+
+func (e *FieldsError) Error() string {
+	return fmt.Sprintf("%s fields: %s", e.Reason, strings.Join(e.Fields, ", "))
+}
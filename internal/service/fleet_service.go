@@ -0,0 +1,67 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: fleet_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the fleet-wide statistics shown to admins.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// FleetStats counts the users and the devices of every user by status.
+// Deleted devices are not counted.
+type FleetStats struct {
+	Users   int64            `json:"users"`
+	Devices FleetDeviceStats `json:"devices"`
+}
+
+type FleetDeviceStats struct {
+	Total          int64 `json:"total"`
+	Online         int64 `json:"online"`
+	Offline        int64 `json:"offline"`
+	Decommissioned int64 `json:"decommissioned"`
+}
+
+type FleetService struct {
+	devices repository.DeviceRepository
+	users   repository.UserRepository
+}
+
+func NewFleetService(devices repository.DeviceRepository, users repository.UserRepository) *FleetService {
+	return &FleetService{devices: devices, users: users}
+}
+
+func (s *FleetService) Stats(ctx context.Context) (*FleetStats, error) {
+	var stats FleetStats
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		stats.Users, err = s.users.Count(gctx)
+		return err
+	})
+	count := func(dst *int64, status models.DeviceStatus) {
+		g.Go(func() (err error) {
+			*dst, err = s.devices.Count(gctx, repository.DeviceFilter{AllUsers: true, Status: status, IncludeDecommissioned: true})
+			return err
+		})
+	}
+	count(&stats.Devices.Total, "")
+	count(&stats.Devices.Online, models.DeviceOnline)
+	count(&stats.Devices.Offline, models.DeviceOffline)
+	count(&stats.Devices.Decommissioned, models.DeviceDecommissioned)
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
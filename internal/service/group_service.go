@@ -0,0 +1,153 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: group_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic of device groups in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+const maxGroupDevices = 200
+
+type GroupService struct {
+	repo    repository.GroupRepository
+	devices *DeviceService
+	sensors repository.SensorDataRepository
+}
+
+func NewGroupService(repo repository.GroupRepository, devices *DeviceService, sensors repository.SensorDataRepository) *GroupService {
+	return &GroupService{repo: repo, devices: devices, sensors: sensors}
+}
+
+// Create groups deviceIDs, each of which userID must be able to see.
+func (s *GroupService) Create(ctx context.Context, userID, name string, deviceIDs []string) (*models.DeviceGroup, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(deviceIDs) == 0 || len(deviceIDs) > maxGroupDevices {
+		return nil, fmt.Errorf("%w: a name and 1 to %d devices are required", ErrInvalidGroup, maxGroupDevices)
+	}
+	visible, err := s.visibleDevices(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(deviceIDs))
+	ids := make([]string, 0, len(deviceIDs))
+	for _, id := range deviceIDs {
+		if !visible[id] {
+			return nil, fmt.Errorf("%w: device %s not found", ErrInvalidGroup, id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	g := &models.DeviceGroup{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    userID,
+		Name:      name,
+		DeviceIDs: ids,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.Create(ctx, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (s *GroupService) List(ctx context.Context, userID string) ([]models.DeviceGroup, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Get returns a group of userID.
+func (s *GroupService) Get(ctx context.Context, id, userID string) (*models.DeviceGroup, error) {
+	g, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if g.UserID != userID {
+		return nil, ErrGroupNotFound
+	}
+	return g, nil
+}
+
+func (s *GroupService) Delete(ctx context.Context, id, userID string) error {
+	err := s.repo.Delete(ctx, id, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrGroupNotFound
+	}
+	return err
+}
+
+// LatestReading is the newest reading of a group member.
+type LatestReading struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Sensors   models.Sensors `json:"sensors"`
+}
+
+// Latest returns the newest reading of every device of the group, keyed by
+// device ID, in one aggregation. Devices without readings map to nil.
+// Devices the owner can no longer see are left out.
+func (s *GroupService) Latest(ctx context.Context, id, userID string) (map[string]*LatestReading, error) {
+	g, err := s.Get(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := s.visibleDevices(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]*LatestReading, len(g.DeviceIDs))
+	ids := make([]string, 0, len(g.DeviceIDs))
+	for _, deviceID := range g.DeviceIDs {
+		if visible[deviceID] {
+			out[deviceID] = nil
+			ids = append(ids, deviceID)
+		}
+	}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	readings, err := s.sensors.Latest(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range readings {
+		out[d.DeviceID] = &LatestReading{Timestamp: d.Timestamp, Sensors: d.Sensors}
+	}
+	return out, nil
+}
+
+// visibleDevices returns the IDs of the devices userID owns or has been
+// shared, as a set.
+func (s *GroupService) visibleDevices(ctx context.Context, userID string) (map[string]bool, error) {
+	views, err := s.devices.All(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(views))
+	for _, v := range views {
+		set[v.ID] = true
+	}
+	return set, nil
+}
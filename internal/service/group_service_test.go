@@ -0,0 +1,185 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: group_service_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the latest readings of device groups.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// groupRepo holds a single group.
+type groupRepo struct {
+	repository.GroupRepository
+	g models.DeviceGroup
+}
+
+func (r *groupRepo) GetByID(_ context.Context, id string) (*models.DeviceGroup, error) {
+	if id != r.g.ID {
+		return nil, repository.ErrNotFound
+	}
+	g := r.g
+	return &g, nil
+}
+
+// ownedDevices lists the devices it holds as owned by their user.
+type ownedDevices struct {
+	repository.DeviceRepository
+	devices []models.Device
+}
+
+func (r *ownedDevices) List(_ context.Context, filter repository.DeviceFilter) ([]models.Device, error) {
+	var out []models.Device
+	for _, d := range r.devices {
+		if d.UserID == filter.UserID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+type noShares struct {
+	repository.ShareRepository
+}
+
+func (noShares) ListByGrantee(context.Context, string) ([]models.DeviceShare, error) {
+	return nil, nil
+}
+
+func (noShares) Get(context.Context, string, string) (*models.DeviceShare, error) {
+	return nil, repository.ErrNotFound
+}
+
+// latestRepo returns the readings it holds of the devices asked for, and
+// counts the calls.
+type latestRepo struct {
+	repository.SensorDataRepository
+	readings map[string]models.SensorData
+	calls    int
+}
+
+func (r *latestRepo) Latest(_ context.Context, deviceIDs []string) ([]models.SensorData, error) {
+	r.calls++
+	var out []models.SensorData
+	for _, id := range deviceIDs {
+		if d, ok := r.readings[id]; ok {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func deviceReading(deviceID string, at time.Time, v float64) models.SensorData {
+	d := reading(at, v)
+	d.DeviceID = deviceID
+	return d
+}
+
+func TestGroupLatest(t *testing.T) {
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		deviceIDs []string
+		userID    string
+		groupID   string
+		readings  map[string]models.SensorData
+		want      map[string]bool // device ID to whether it has a reading
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "all devices have data",
+			deviceIDs: []string{"d1", "d2"},
+			readings:  map[string]models.SensorData{"d1": deviceReading("d1", at, 1), "d2": deviceReading("d2", at, 2)},
+			want:      map[string]bool{"d1": true, "d2": true},
+			wantCalls: 1,
+		},
+		{
+			name:      "device without data",
+			deviceIDs: []string{"d1", "d2"},
+			readings:  map[string]models.SensorData{"d1": deviceReading("d1", at, 1)},
+			want:      map[string]bool{"d1": true, "d2": false},
+			wantCalls: 1,
+		},
+		{
+			name:      "no device has data",
+			deviceIDs: []string{"d1", "d2"},
+			want:      map[string]bool{"d1": false, "d2": false},
+			wantCalls: 1,
+		},
+		{
+			name:      "device no longer visible",
+			deviceIDs: []string{"d1", "d3"},
+			readings:  map[string]models.SensorData{"d1": deviceReading("d1", at, 1), "d3": deviceReading("d3", at, 3)},
+			want:      map[string]bool{"d1": true},
+			wantCalls: 1,
+		},
+		{
+			name:      "no device visible",
+			deviceIDs: []string{"d3"},
+			want:      map[string]bool{},
+		},
+		{name: "group of another user", deviceIDs: []string{"d1"}, userID: "u2", wantErr: ErrGroupNotFound},
+		{name: "unknown group", deviceIDs: []string{"d1"}, groupID: "g2", wantErr: ErrGroupNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sensors := &latestRepo{readings: tt.readings}
+			devices := &DeviceService{
+				repo:   &ownedDevices{devices: []models.Device{{ID: "d1", UserID: "u1"}, {ID: "d2", UserID: "u1"}, {ID: "d3", UserID: "u2"}}},
+				shares: noShares{},
+			}
+			s := &GroupService{
+				repo:    &groupRepo{g: models.DeviceGroup{ID: "g1", UserID: "u1", DeviceIDs: tt.deviceIDs}},
+				devices: devices,
+				sensors: sensors,
+			}
+			userID, groupID := "u1", "g1"
+			if tt.userID != "" {
+				userID = tt.userID
+			}
+			if tt.groupID != "" {
+				groupID = tt.groupID
+			}
+			got, err := s.Latest(context.Background(), groupID, userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Latest() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Latest() = %v, want devices %v", got, tt.want)
+			}
+			for id, hasData := range tt.want {
+				r, ok := got[id]
+				if !ok {
+					t.Errorf("device %s missing", id)
+					continue
+				}
+				if (r != nil) != hasData {
+					t.Errorf("device %s reading = %v, want data %v", id, r, hasData)
+				}
+				if r != nil && (!r.Timestamp.Equal(at) || r.Sensors.PM25 != tt.readings[id].Sensors.PM25) {
+					t.Errorf("device %s reading = %+v, want %+v", id, r, tt.readings[id])
+				}
+			}
+			if sensors.calls != tt.wantCalls {
+				t.Errorf("Latest queried sensors %d times, want %d", sensors.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: interpolation_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the interpolation of gaps in sensor readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// seriesRepo holds the readings of one device, oldest first, and records
+// the readings inserted.
+type seriesRepo struct {
+	repository.SensorDataRepository
+	readings []models.SensorData
+	limit    int64
+	inserted []*models.SensorData
+}
+
+func (r *seriesRepo) Find(_ context.Context, filter repository.SensorFilter) ([]models.SensorData, error) {
+	r.limit = filter.Limit
+	var out []models.SensorData
+	for i := len(r.readings) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && int64(len(out)) == filter.Limit {
+			break
+		}
+		out = append(out, r.readings[i])
+	}
+	return out, nil
+}
+
+func (r *seriesRepo) InsertMany(_ context.Context, data []*models.SensorData) error {
+	r.inserted = append(r.inserted, data...)
+	return nil
+}
+
+func reading(at time.Time, v float64) models.SensorData {
+	return models.SensorData{DeviceID: "d1", Timestamp: at, Sensors: models.Sensors{
+		PM25:        models.SensorValue{Value: v, Unit: "µg/m³"},
+		CO2:         models.SensorValue{Value: 10 * v, Unit: "ppm"},
+		CO:          models.SensorValue{Value: v / 10, Unit: "ppm"},
+		Temperature: models.SensorValue{Value: v - 5, Unit: "°C"},
+		Humidity:    models.SensorValue{Value: 2 * v, Unit: "%"},
+	}}
+}
+
+func TestInterpolate(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	minute := func(n int) time.Time { return t0.Add(time.Duration(n) * time.Minute) }
+	tests := []struct {
+		name       string
+		readings   []models.SensorData
+		method     string
+		maxGap     int
+		wantGaps   int
+		wantPM25   []float64
+		wantMinute []int
+	}{
+		{
+			name:     "no gap",
+			readings: []models.SensorData{reading(minute(0), 10), reading(minute(1), 20), reading(minute(2), 30)},
+			method:   InterpolateLinear, maxGap: 10,
+		},
+		{
+			name:     "linear",
+			readings: []models.SensorData{reading(minute(0), 10), reading(minute(4), 50)},
+			method:   InterpolateLinear, maxGap: 10,
+			wantGaps: 1, wantPM25: []float64{20, 30, 40}, wantMinute: []int{1, 2, 3},
+		},
+		{
+			name:     "previous value",
+			readings: []models.SensorData{reading(minute(0), 10), reading(minute(3), 40)},
+			method:   InterpolatePrevious, maxGap: 10,
+			wantGaps: 1, wantPM25: []float64{10, 10}, wantMinute: []int{1, 2},
+		},
+		{
+			name:     "gap of max_gap is filled",
+			readings: []models.SensorData{reading(minute(0), 0), reading(minute(3), 30)},
+			method:   InterpolateLinear, maxGap: 3,
+			wantGaps: 1, wantPM25: []float64{10, 20}, wantMinute: []int{1, 2},
+		},
+		{
+			name:     "gap over max_gap is not filled",
+			readings: []models.SensorData{reading(minute(0), 0), reading(minute(4), 40)},
+			method:   InterpolateLinear, maxGap: 3,
+		},
+		{
+			name: "only the short of two gaps",
+			readings: []models.SensorData{reading(minute(0), 0), reading(minute(2), 20),
+				reading(minute(20), 200)},
+			method: InterpolateLinear, maxGap: 10,
+			wantGaps: 1, wantPM25: []float64{10}, wantMinute: []int{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &seriesRepo{readings: tt.readings}
+			s := &SensorService{repo: repo}
+			res, err := s.Interpolate(context.Background(), InterpolateRequest{
+				DeviceID: "d1", From: t0, To: minute(60), Interval: time.Minute, MaxGap: tt.maxGap, Method: tt.method,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.GapsFilled != tt.wantGaps || res.Inserted != len(tt.wantPM25) {
+				t.Errorf("result = %+v, want %d gaps and %d readings", res, tt.wantGaps, len(tt.wantPM25))
+			}
+			if len(repo.inserted) != len(tt.wantPM25) {
+				t.Fatalf("inserted %d readings, want %d", len(repo.inserted), len(tt.wantPM25))
+			}
+			for i, d := range repo.inserted {
+				if !d.Timestamp.Equal(minute(tt.wantMinute[i])) {
+					t.Errorf("reading %d at %v, want %v", i, d.Timestamp, minute(tt.wantMinute[i]))
+				}
+				if d.Source != models.SourceInterpolated || d.DeviceID != "d1" {
+					t.Errorf("reading %d: source %q, device %q", i, d.Source, d.DeviceID)
+				}
+				// Every field follows the same line as pm25.
+				want := reading(d.Timestamp, tt.wantPM25[i]).Sensors
+				for f, v := range d.Sensors.Fields() {
+					w := want.Fields()[f]
+					if math.Abs(v.Value-w.Value) > 1e-9 || v.Unit != w.Unit {
+						t.Errorf("reading %d: %s = %v %s, want %v %s", i, f, v.Value, v.Unit, w.Value, w.Unit)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestInterpolateRejects(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	valid := InterpolateRequest{DeviceID: "d1", From: t0, To: t0.Add(time.Hour), Interval: time.Minute, MaxGap: 10, Method: InterpolateLinear}
+	tests := []struct {
+		name     string
+		edit     func(*InterpolateRequest)
+		readings int
+		wantErr  error
+	}{
+		{"to before from", func(r *InterpolateRequest) { r.To = t0.Add(-time.Hour) }, 0, ErrInvalidInterpolation},
+		{"interval under a second", func(r *InterpolateRequest) { r.Interval = time.Millisecond }, 0, ErrInvalidInterpolation},
+		{"max_gap 0", func(r *InterpolateRequest) { r.MaxGap = 0 }, 0, ErrInvalidInterpolation},
+		{"unknown method", func(r *InterpolateRequest) { r.Method = "cubic" }, 0, ErrInvalidInterpolation},
+		{"too many readings", func(*InterpolateRequest) {}, maxInterpolationSource + 1, ErrTooManyReadings},
+		{"too many generated", func(r *InterpolateRequest) { r.Interval, r.MaxGap = time.Second, 20000 }, 2, ErrInvalidInterpolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &seriesRepo{}
+			for i := 0; i < tt.readings; i++ {
+				// Far enough apart to leave a gap of 16000 seconds between two.
+				repo.readings = append(repo.readings, reading(t0.Add(time.Duration(i)*16000*time.Second), 1))
+			}
+			req := valid
+			tt.edit(&req)
+			s := &SensorService{repo: repo}
+			if _, err := s.Interpolate(context.Background(), req); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Interpolate() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.readings > 0 && repo.limit != maxInterpolationSource+1 {
+				t.Errorf("Find limit = %d, want %d", repo.limit, maxInterpolationSource+1)
+			}
+			if len(repo.inserted) != 0 {
+				t.Errorf("inserted %d readings, want none", len(repo.inserted))
+			}
+		})
+	}
+}
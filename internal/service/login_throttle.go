@@ -0,0 +1,212 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: login_throttle.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the throttling of failed logins.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// LoginLockedError is returned while logins to an account from a client
+// IP, or from the IP altogether, are locked after too many failures.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("login locked for %s", e.RetryAfter)
+}
+
+const (
+	// minLoginDelay is the delay of the first login to an account beyond
+	// LoginAccountMaxFailures, doubled with every further one up to
+	// maxLoginDelay.
+	minLoginDelay = 250 * time.Millisecond
+	maxLoginDelay = 5 * time.Second
+)
+
+// LoginThrottle slows down password guessing. Every login is counted
+// before its password is checked, in one atomic step per key, so parallel
+// guesses cannot all pass before the first failure counts; a successful
+// login is taken back. Logins are counted per client IP alone, per email
+// address and client IP, and per email address alone:
+//
+//   - an IP beyond its limit, whatever accounts it tries, may try once
+//     per lockout, which stops credential stuffing without locking any
+//     account;
+//   - an account beyond its limit from one IP is locked for that IP, so
+//     an attacker locks out no one else behind the same NAT, for a
+//     lockout doubled with every further one;
+//   - an account beyond its higher limit from anywhere answers logins more
+//     slowly, but is never locked, so nobody can lock out its owner.
+//
+// The counters live in the database, shared by every server instance.
+type LoginThrottle struct {
+	repo repository.LoginFailureRepository
+
+	maxFailures        int
+	ipMaxFailures      int
+	accountMaxFailures int
+	lockout            time.Duration
+	maxLockout         time.Duration
+	window             time.Duration
+	// sleep delays logins to accounts beyond their limit.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func NewLoginThrottle(repo repository.LoginFailureRepository, cfg config.JWTConfig) *LoginThrottle {
+	maxFailures := cfg.LoginMaxFailures
+	if maxFailures == 1 {
+		// A single bad request must not lock anyone out.
+		maxFailures = 2
+	}
+	ipMaxFailures := cfg.LoginIPMaxFailures
+	if ipMaxFailures > 0 {
+		ipMaxFailures = max(ipMaxFailures, maxFailures)
+	}
+	return &LoginThrottle{
+		repo:               repo,
+		maxFailures:        maxFailures,
+		ipMaxFailures:      ipMaxFailures,
+		accountMaxFailures: max(cfg.LoginAccountMaxFailures, maxFailures),
+		lockout:            cfg.LoginLockout,
+		maxLockout:         max(cfg.LoginMaxLockout, cfg.LoginLockout),
+		window:             cfg.LoginFailureWindow,
+		sleep:              sleepContext,
+	}
+}
+
+// Attempt counts a login to email from ip, before its password is
+// checked. It returns a LoginLockedError if the login is refused, and
+// waits before returning while the account is beyond its limit.
+func (t *LoginThrottle) Attempt(ctx context.Context, email, ip string) error {
+	if t.maxFailures <= 0 {
+		return nil
+	}
+	ipKey, pairKey, accountKey := loginKeys(email, ip)
+	now := time.Now()
+	if t.ipMaxFailures > 0 {
+		f, err := t.repo.Attempt(ctx, ipKey, now, now.Add(t.window))
+		if err != nil {
+			return err
+		}
+		if err := t.limit(ctx, f, now, t.ipMaxFailures, t.lockout); err != nil {
+			return err
+		}
+	}
+	f, err := t.repo.Attempt(ctx, pairKey, now, now.Add(t.window))
+	if err != nil {
+		return err
+	}
+	if err := t.limit(ctx, f, now, t.maxFailures, t.backoff(f.Locks)); err != nil {
+		return err
+	}
+	f, err = t.repo.Attempt(ctx, accountKey, now, now.Add(t.window))
+	if err != nil {
+		return err
+	}
+	if extra := f.Count - t.accountMaxFailures; extra > 0 {
+		return t.sleep(ctx, loginDelay(extra))
+	}
+	return nil
+}
+
+// limit refuses the login counted in f while its key is locked, and locks
+// the key for lockout once the login is beyond limit. The count restarts
+// just below the limit, so that after the lockout one more login may try
+// before the next.
+func (t *LoginThrottle) limit(ctx context.Context, f *models.LoginFailures, now time.Time, limit int, lockout time.Duration) error {
+	if f.LockedUntil != nil && f.LockedUntil.After(now) {
+		return &LoginLockedError{RetryAfter: f.LockedUntil.Sub(now)}
+	}
+	if f.Count <= limit {
+		return nil
+	}
+	until := now.Add(lockout)
+	locked, err := t.repo.Lock(ctx, f.Key, now, until, limit-1, until.Add(t.window))
+	if err != nil {
+		return err
+	}
+	if locked {
+		metrics.LoginLockouts.Add(1)
+		log.Printf("auth: %d failed logins under %s, locked until %s", f.Count-1, f.Key, until.Format(time.RFC3339))
+	}
+	return &LoginLockedError{RetryAfter: lockout}
+}
+
+// Fail counts a login whose password was wrong in the metrics; Attempt
+// counted it against the limits already.
+func (t *LoginThrottle) Fail() {
+	if t.maxFailures > 0 {
+		metrics.LoginFailures.Add(1)
+	}
+}
+
+// Reset forgets the failures of email after a successful login from ip,
+// and takes the login back from the count of ip.
+func (t *LoginThrottle) Reset(ctx context.Context, email, ip string) error {
+	if t.maxFailures <= 0 {
+		return nil
+	}
+	ipKey, pairKey, accountKey := loginKeys(email, ip)
+	if t.ipMaxFailures > 0 {
+		if err := t.repo.Release(ctx, ipKey); err != nil {
+			return err
+		}
+	}
+	return t.repo.Delete(ctx, []string{pairKey, accountKey})
+}
+
+// backoff is the lockout after earlier ones: it doubles with each.
+func (t *LoginThrottle) backoff(locks int) time.Duration {
+	d := t.lockout
+	for i := 0; i < locks && d < t.maxLockout; i++ {
+		d *= 2
+	}
+	return min(d, t.maxLockout)
+}
+
+// loginDelay is the delay of a login extra logins beyond the limit of its
+// account.
+func loginDelay(extra int) time.Duration {
+	d := minLoginDelay
+	for i := 1; i < extra && d < maxLoginDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxLoginDelay)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loginKeys returns the counter keys of ip alone, of email and ip, and of
+// email alone.
+func loginKeys(email, ip string) (ipKey, pairKey, accountKey string) {
+	accountKey = "account:" + strings.ToLower(strings.TrimSpace(email))
+	return "ip:" + ip, accountKey + "|ip:" + ip, accountKey
+}
@@ -0,0 +1,278 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: login_throttle_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of throttling failed logins.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// memFailures counts logins like the collection does, each update atomic.
+type memFailures struct {
+	repository.LoginFailureRepository
+	mu       sync.Mutex
+	counters map[string]*models.LoginFailures
+}
+
+func (r *memFailures) Attempt(_ context.Context, key string, now, expiresAt time.Time) (*models.LoginFailures, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.counters[key]
+	if !ok {
+		f = &models.LoginFailures{Key: key}
+		r.counters[key] = f
+	}
+	if f.LockedUntil == nil || !f.LockedUntil.After(now) {
+		f.Count++
+		f.ExpiresAt = maxTime(f.ExpiresAt, expiresAt)
+	}
+	cp := *f
+	return &cp, nil
+}
+
+func (r *memFailures) Lock(_ context.Context, key string, now, until time.Time, count int, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := r.counters[key]
+	if f.LockedUntil != nil && f.LockedUntil.After(now) {
+		return false, nil
+	}
+	f.LockedUntil, f.Count = &until, count
+	f.Locks++
+	f.ExpiresAt = maxTime(f.ExpiresAt, expiresAt)
+	return true, nil
+}
+
+func (r *memFailures) Release(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.counters[key]; ok && f.Count > 0 {
+		f.Count--
+	}
+	return nil
+}
+
+func (r *memFailures) Delete(_ context.Context, keys []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range keys {
+		delete(r.counters, k)
+	}
+	return nil
+}
+
+// unlock ends the lockout of key, as if it had run out.
+func (r *memFailures) unlock(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	past := time.Now().Add(-time.Second)
+	r.counters[key].LockedUntil = &past
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// newTestThrottle returns a throttle recording the delays it would sleep.
+func newTestThrottle(cfg config.JWTConfig) (*LoginThrottle, *memFailures, *[]time.Duration) {
+	repo := &memFailures{counters: map[string]*models.LoginFailures{}}
+	t := NewLoginThrottle(repo, cfg)
+	var mu sync.Mutex
+	delays := &[]time.Duration{}
+	t.sleep = func(_ context.Context, d time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*delays = append(*delays, d)
+		return nil
+	}
+	return t, repo, delays
+}
+
+var testThrottleConfig = config.JWTConfig{
+	LoginMaxFailures:        3,
+	LoginIPMaxFailures:      5,
+	LoginAccountMaxFailures: 4,
+	LoginLockout:            time.Minute,
+	LoginMaxLockout:         time.Hour,
+	LoginFailureWindow:      15 * time.Minute,
+}
+
+func TestLoginThrottle(t *testing.T) {
+	type login struct {
+		email, ip string
+		ok        bool // the password is right
+	}
+	tests := []struct {
+		name   string
+		logins []login
+		// refused lists the logins answered 429, by index.
+		refused []int
+		delays  int
+	}{
+		{
+			name:    "one account from one ip is locked there",
+			logins:  []login{{"a@x", "1", false}, {"a@x", "1", false}, {"a@x", "1", false}, {"a@x", "1", true}, {"a@x", "2", true}},
+			refused: []int{3},
+		},
+		{
+			name:   "a success resets the account",
+			logins: []login{{"a@x", "1", false}, {"a@x", "1", false}, {"a@x", "1", true}, {"a@x", "1", false}, {"a@x", "1", false}, {"a@x", "1", true}},
+		},
+		{
+			name: "an ip spraying accounts is slowed down",
+			logins: []login{{"a@x", "1", false}, {"b@x", "1", false}, {"c@x", "1", false}, {"d@x", "1", false},
+				{"e@x", "1", false}, {"f@x", "1", false}, {"f@x", "2", true}},
+			refused: []int{5},
+		},
+		{
+			name: "successes from an ip do not add up",
+			logins: []login{{"a@x", "1", true}, {"b@x", "1", true}, {"c@x", "1", true}, {"d@x", "1", true},
+				{"e@x", "1", true}, {"f@x", "1", true}, {"g@x", "1", true}},
+		},
+		{
+			name: "an account guessed from anywhere is slowed down, not locked",
+			logins: []login{{"a@x", "1", false}, {"a@x", "2", false}, {"a@x", "3", false}, {"a@x", "4", false},
+				{"a@x", "5", false}, {"a@x", "6", false}, {"a@x", "7", true}},
+			delays: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle, _, delays := newTestThrottle(testThrottleConfig)
+			ctx := context.Background()
+			var refused []int
+			for i, l := range tt.logins {
+				err := throttle.Attempt(ctx, l.email, l.ip)
+				var locked *LoginLockedError
+				if errors.As(err, &locked) {
+					if locked.RetryAfter <= 0 {
+						t.Errorf("login %d: retry after %s", i, locked.RetryAfter)
+					}
+					refused = append(refused, i)
+					continue
+				}
+				if err != nil {
+					t.Fatalf("login %d: %v", i, err)
+				}
+				if l.ok {
+					if err := throttle.Reset(ctx, l.email, l.ip); err != nil {
+						t.Fatal(err)
+					}
+				} else {
+					throttle.Fail()
+				}
+			}
+			if !slices.Equal(refused, tt.refused) {
+				t.Errorf("refused %v, want %v", refused, tt.refused)
+			}
+			if len(*delays) != tt.delays {
+				t.Errorf("delayed %v, want %d delays", *delays, tt.delays)
+			}
+		})
+	}
+}
+
+func TestLoginThrottleParallelGuesses(t *testing.T) {
+	throttle, _, _ := newTestThrottle(testThrottleConfig)
+	ctx := context.Background()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if throttle.Attempt(ctx, "a@x", "1") == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != testThrottleConfig.LoginMaxFailures {
+		t.Errorf("%d parallel guesses allowed, want %d", allowed, testThrottleConfig.LoginMaxFailures)
+	}
+}
+
+func TestLoginThrottleAfterLockout(t *testing.T) {
+	cfg := testThrottleConfig
+	cfg.LoginIPMaxFailures = 0
+	throttle, repo, _ := newTestThrottle(cfg)
+	ctx := context.Background()
+	_, pairKey, _ := loginKeys("a@x", "1")
+	for range testThrottleConfig.LoginMaxFailures {
+		if err := throttle.Attempt(ctx, "a@x", "1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var locked *LoginLockedError
+	if err := throttle.Attempt(ctx, "a@x", "1"); !errors.As(err, &locked) || locked.RetryAfter != time.Minute {
+		t.Fatalf("err = %v, want locked for a minute", err)
+	}
+
+	// Once the lockout ran out, one more guess may try before the next,
+	// twice as long, lockout.
+	repo.unlock(pairKey)
+	if err := throttle.Attempt(ctx, "a@x", "1"); err != nil {
+		t.Fatalf("first guess after the lockout: %v", err)
+	}
+	if err := throttle.Attempt(ctx, "a@x", "1"); !errors.As(err, &locked) || locked.RetryAfter != 2*time.Minute {
+		t.Fatalf("err = %v, want locked for two minutes", err)
+	}
+}
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		extra int
+		want  time.Duration
+	}{
+		{1, minLoginDelay},
+		{2, 2 * minLoginDelay},
+		{3, 4 * minLoginDelay},
+		{5, 16 * minLoginDelay},
+		{6, maxLoginDelay},
+		{1000, maxLoginDelay},
+	}
+	for _, tt := range tests {
+		if got := loginDelay(tt.extra); got != tt.want {
+			t.Errorf("loginDelay(%d) = %s, want %s", tt.extra, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottleDisabled(t *testing.T) {
+	cfg := testThrottleConfig
+	cfg.LoginMaxFailures = 0
+	throttle, repo, _ := newTestThrottle(cfg)
+	for range 10 {
+		if err := throttle.Attempt(context.Background(), "a@x", "1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(repo.counters) != 0 {
+		t.Errorf("counted %d keys while disabled", len(repo.counters))
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: mqtt_credential_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic for per-device MQTT credentials and topic ACLs in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// MQTTCredentials are returned once, when issued; only the hash is kept.
+type MQTTCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// MQTT actions checked by Authorize.
+const (
+	MQTTPublish   = "publish"
+	MQTTSubscribe = "subscribe"
+)
+
+// TopicParser extracts the device ID and kind from a device topic. It is
+// implemented by mqtt.Topics, which cannot be imported from here.
+type TopicParser interface {
+	Parse(topic string) (deviceID, kind string, err error)
+}
+
+type MQTTCredentialService struct {
+	repo    repository.MQTTCredentialRepository
+	devices repository.DeviceRepository
+	topics  TopicParser
+	cfg     config.MQTTConfig
+}
+
+func NewMQTTCredentialService(repo repository.MQTTCredentialRepository, devices repository.DeviceRepository,
+	topics TopicParser, cfg config.MQTTConfig) *MQTTCredentialService {
+	return &MQTTCredentialService{repo: repo, devices: devices, topics: topics, cfg: cfg}
+}
+
+// Issue creates new credentials for deviceID. Credentials issued before
+// keep working for the configured grace period.
+func (s *MQTTCredentialService) Issue(ctx context.Context, deviceID string) (*MQTTCredentials, error) {
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := s.repo.ExpireAll(ctx, deviceID, now.Add(s.cfg.CredentialGracePeriod)); err != nil {
+		return nil, err
+	}
+	cred := &models.MQTTCredential{
+		ID:         primitive.NewObjectID().Hex(),
+		DeviceID:   deviceID,
+		SecretHash: auth.HashToken(secret),
+		CreatedAt:  now,
+	}
+	if err := s.repo.Create(ctx, cred); err != nil {
+		return nil, err
+	}
+	return &MQTTCredentials{Username: deviceID, Password: secret}, nil
+}
+
+// Authenticate checks a broker login. The backend's own account from
+// MQTTConfig is accepted; any other username must be a live device, not
+// decommissioned, holding an active credential with that password.
+func (s *MQTTCredentialService) Authenticate(ctx context.Context, username, password string) (bool, error) {
+	if s.isBackend(username) {
+		return subtle.ConstantTimeCompare([]byte(password), []byte(s.cfg.Password)) == 1, nil
+	}
+	d, err := s.devices.GetByID(ctx, username)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if d.DeletedAt != nil || d.DecommissionedAt != nil {
+		return false, nil
+	}
+	creds, err := s.repo.ListActive(ctx, username, time.Now())
+	if err != nil {
+		return false, err
+	}
+	hash := auth.HashToken(password)
+	for _, c := range creds {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(c.SecretHash)) == 1 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Authorize restricts a device to its own branch of the topic tree: it may
+// publish sensors, status and command acks, and subscribe to commands.
+// The backend account may do anything.
+func (s *MQTTCredentialService) Authorize(username, topic, action string) bool {
+	if s.isBackend(username) {
+		return true
+	}
+	deviceID, kind, err := s.topics.Parse(topic)
+	if err != nil || deviceID != username {
+		return false
+	}
+	switch action {
+	case MQTTPublish:
+		return kind == "sensors" || kind == "status" || kind == "commands/ack"
+	case MQTTSubscribe:
+		return kind == "commands"
+	}
+	return false
+}
+
+func (s *MQTTCredentialService) isBackend(username string) bool {
+	return s.cfg.Username != "" && username == s.cfg.Username
+}
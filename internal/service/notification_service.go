@@ -0,0 +1,81 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: notification_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic for notification preferences in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"airsense-be.com/internal/alert"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// maxFCMTokenLen bounds FCM registration tokens, which are about 160
+// characters today.
+const maxFCMTokenLen = 4096
+
+type NotificationService struct {
+	prefs       repository.NotificationPreferenceRepository
+	userDevices repository.UserDeviceRepository
+}
+
+func NewNotificationService(prefs repository.NotificationPreferenceRepository, userDevices repository.UserDeviceRepository) *NotificationService {
+	return &NotificationService{prefs: prefs, userDevices: userDevices}
+}
+
+// Get returns the preferences of userID. Users that never saved any get
+// email disabled.
+func (s *NotificationService) Get(ctx context.Context, userID string) (*models.NotificationPreference, error) {
+	p, err := s.prefs.Get(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &models.NotificationPreference{UserID: userID, AlertSeverities: []string{}}, nil
+	}
+	return p, err
+}
+
+func (s *NotificationService) Update(ctx context.Context, p *models.NotificationPreference) error {
+	for _, sev := range p.AlertSeverities {
+		if sev != string(alert.SeverityWarning) && sev != string(alert.SeverityCritical) {
+			return ErrInvalidSeverity
+		}
+	}
+	if p.AlertSeverities == nil {
+		p.AlertSeverities = []string{}
+	}
+	p.UpdatedAt = time.Now().UTC()
+	return s.prefs.Upsert(ctx, p)
+}
+
+// RegisterFCM registers the app installation with fcmToken for push
+// notifications to userID, or updates it if the token is known, e.g.
+// after another user signed in on the same phone.
+func (s *NotificationService) RegisterFCM(ctx context.Context, userID, fcmToken, platform string) (*models.UserDevice, error) {
+	if fcmToken == "" || len(fcmToken) > maxFCMTokenLen {
+		return nil, fmt.Errorf("%w: fcm_token must be 1-%d characters", ErrInvalidPushApp, maxFCMTokenLen)
+	}
+	if platform != models.PlatformAndroid && platform != models.PlatformIOS {
+		return nil, fmt.Errorf("%w: platform must be android or ios", ErrInvalidPushApp)
+	}
+	d := &models.UserDevice{
+		FCMToken:     fcmToken,
+		UserID:       userID,
+		Platform:     platform,
+		RegisteredAt: time.Now().UTC(),
+	}
+	if err := s.userDevices.Upsert(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
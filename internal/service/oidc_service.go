@@ -0,0 +1,352 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: oidc_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains signing in with an OpenID Connect provider and linking existing accounts.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// oidcResultTTL is how long the dashboard has to exchange the code of a
+// sign-in finished at the provider.
+const oidcResultTTL = time.Minute
+
+// OIDCLinkRequiredError is returned when an identity at the OpenID Connect
+// provider signs in for the first time with the email address of an
+// existing password user. The accounts are not merged until the user
+// confirms the link with LinkToken and their password, see ConfirmLink.
+type OIDCLinkRequiredError struct {
+	Email     string
+	LinkToken string
+	ExpiresAt time.Time
+}
+
+func (e *OIDCLinkRequiredError) Error() string {
+	return fmt.Sprintf("account %s must be linked before signing in", e.Email)
+}
+
+// OIDCService signs users in with an OpenID Connect provider. An identity
+// signs in as the user it is linked to; a new one as a new, verified user
+// without a password, or, if a user has its email address, as that user
+// once they confirmed the link.
+type OIDCService struct {
+	provider *auth.OIDCProvider
+	logins   repository.OIDCLoginRepository
+	results  repository.OIDCResultRepository
+	links    repository.OIDCLinkRepository
+	users    repository.UserRepository
+	stateTTL time.Duration
+	linkTTL  time.Duration
+}
+
+// NewOIDCService returns the service; without a provider every call
+// returns ErrOIDCDisabled.
+func NewOIDCService(provider *auth.OIDCProvider, logins repository.OIDCLoginRepository, results repository.OIDCResultRepository,
+	links repository.OIDCLinkRepository, users repository.UserRepository, cfg config.OIDCConfig) *OIDCService {
+	return &OIDCService{provider: provider, logins: logins, results: results, links: links, users: users,
+		stateTTL: cfg.StateTTL, linkTTL: cfg.LinkTTL}
+}
+
+// Start begins a sign-in. It returns the URL of the provider to send the
+// user to and the state the provider sends back to the callback.
+func (s *OIDCService) Start(ctx context.Context) (redirect, state string, err error) {
+	if s.provider == nil {
+		return "", "", ErrOIDCDisabled
+	}
+	var nonce, verifier string
+	for _, v := range []*string{&state, &nonce, &verifier} {
+		if *v, err = auth.GenerateOpaqueToken(); err != nil {
+			return "", "", err
+		}
+	}
+	now := time.Now()
+	err = s.logins.Create(ctx, &models.OIDCLogin{
+		ID:        primitive.NewObjectID().Hex(),
+		StateHash: auth.HashToken(state),
+		Nonce:     nonce,
+		Verifier:  verifier,
+		ExpiresAt: now.Add(s.stateTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	redirect, err = s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", err
+	}
+	return redirect, state, nil
+}
+
+// Callback finishes the sign-in of state with the code the provider sent
+// back. The ID token must carry a verified email address in an allowed
+// domain. It returns a single-use code the dashboard exchanges for the
+// outcome, see Exchange, so that no token is put in a URL.
+func (s *OIDCService) Callback(ctx context.Context, code, state string) (string, error) {
+	if s.provider == nil {
+		return "", ErrOIDCDisabled
+	}
+	login, err := s.logins.Take(ctx, auth.HashToken(state))
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", ErrInvalidOIDCState
+	}
+	if err != nil {
+		return "", err
+	}
+	if !time.Now().Before(login.ExpiresAt) {
+		return "", ErrInvalidOIDCState
+	}
+	raw, err := s.provider.Exchange(ctx, code, login.Verifier)
+	if errors.Is(err, auth.ErrCodeExchange) {
+		return "", fmt.Errorf("%w: %v", ErrOIDCFailed, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	claims, err := s.provider.Verify(ctx, raw, login.Nonce)
+	if errors.Is(err, auth.ErrInvalidIDToken) {
+		return "", fmt.Errorf("%w: %v", ErrOIDCFailed, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	result, err := s.signIn(ctx, claims)
+	if err != nil {
+		return "", err
+	}
+	return s.finish(ctx, result)
+}
+
+// finish stores result and returns the code to exchange it with.
+func (s *OIDCService) finish(ctx context.Context, result *models.OIDCResult) (string, error) {
+	code, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	result.ID = primitive.NewObjectID().Hex()
+	result.CodeHash = auth.HashToken(code)
+	result.ExpiresAt = now.Add(oidcResultTTL)
+	result.CreatedAt = now
+	if err := s.results.Create(ctx, result); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// Exchange returns the user the sign-in of code signed in as. A user with
+// the address of the identity who is not linked to it yet gets an
+// *OIDCLinkRequiredError instead. A code works once and within a minute.
+func (s *OIDCService) Exchange(ctx context.Context, code string) (*models.User, error) {
+	if s.provider == nil {
+		return nil, ErrOIDCDisabled
+	}
+	result, err := s.results.Take(ctx, auth.HashToken(code))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidOIDCCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(result.ExpiresAt) {
+		return nil, ErrInvalidOIDCCode
+	}
+	u, err := s.users.GetByID(ctx, result.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidOIDCCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.LinkSubject == "" {
+		return u, nil
+	}
+	return nil, s.offerLink(ctx, u, result.Issuer, result.LinkSubject)
+}
+
+// signIn finds or creates the user the identity of claims signs in as, or
+// finds the user it must be linked to first.
+func (s *OIDCService) signIn(ctx context.Context, claims *auth.IDClaims) (*models.OIDCResult, error) {
+	if !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+	email, err := normalizeEmail(claims.Email)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCFailed, err)
+	}
+	if !s.provider.AllowsEmail(email) {
+		return nil, ErrOIDCDomainNotAllowed
+	}
+	issuer := s.provider.Issuer()
+	u, err := s.users.GetByOIDC(ctx, issuer, claims.Subject)
+	if err == nil {
+		return &models.OIDCResult{UserID: u.ID}, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	u, err = s.users.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.provision(ctx, claims, issuer, email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The provider vouches for the address, not for the account: whoever
+	// controls it must still prove they own the password before the
+	// identity can sign in as this user.
+	if u.OIDCSubject != "" || u.Password == "" {
+		return nil, ErrOIDCAccountConflict
+	}
+	return &models.OIDCResult{UserID: u.ID, Issuer: issuer, LinkSubject: claims.Subject}, nil
+}
+
+// offerLink creates the link of the identity subject at issuer to u and
+// returns the *OIDCLinkRequiredError carrying its token.
+func (s *OIDCService) offerLink(ctx context.Context, u *models.User, issuer, subject string) error {
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	link := &models.OIDCLink{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    u.ID,
+		Issuer:    issuer,
+		Subject:   subject,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: now.Add(s.linkTTL),
+		CreatedAt: now,
+	}
+	if err := s.links.Create(ctx, link); err != nil {
+		return err
+	}
+	return &OIDCLinkRequiredError{Email: u.Email, LinkToken: token, ExpiresAt: link.ExpiresAt}
+}
+
+// provision creates the user of a new identity. Its address is verified,
+// by the provider, and it has no password.
+func (s *OIDCService) provision(ctx context.Context, claims *auth.IDClaims, issuer, email string) (*models.OIDCResult, error) {
+	now := time.Now().UTC()
+	u := &models.User{
+		ID:          primitive.NewObjectID().Hex(),
+		Email:       email,
+		CreatedAt:   now,
+		VerifiedAt:  &now,
+		OIDCIssuer:  issuer,
+		OIDCSubject: claims.Subject,
+	}
+	if name := strings.TrimSpace(claims.Name); utf8.RuneCountInString(name) <= maxNameLength {
+		u.Name = name
+	}
+	if err := s.users.Create(ctx, u); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			// A concurrent sign-in or registration took the address or
+			// the identity; signing in again finds it.
+			return s.signIn(ctx, claims)
+		}
+		return nil, err
+	}
+	log.Printf("auth: user %s signed up with oidc", u.ID)
+	return &models.OIDCResult{UserID: u.ID}, nil
+}
+
+// LinkUser returns the user the link token would link, so that callers
+// can throttle the confirmation like a login.
+func (s *OIDCService) LinkUser(ctx context.Context, token string) (*models.User, error) {
+	link, err := s.link(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.linkUser(ctx, link)
+}
+
+// ConfirmLink links the identity of the link token to its user, who proves
+// owning the account with password. The token is used up only once the
+// password is right.
+func (s *OIDCService) ConfirmLink(ctx context.Context, token, password string) error {
+	link, err := s.link(ctx, token)
+	if err != nil {
+		return err
+	}
+	u, err := s.linkUser(ctx, link)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+		return ErrWrongPassword
+	}
+	deleted, err := s.links.Delete(ctx, link.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrInvalidOIDCLink
+	}
+	linked, err := s.users.LinkOIDC(ctx, u.ID, link.Issuer, link.Subject)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return ErrOIDCAccountConflict
+	}
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return ErrOIDCAccountConflict
+	}
+	if u.Unverified {
+		// The provider verified the address.
+		if err := s.users.MarkVerified(ctx, u.ID, time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+	log.Printf("auth: user %s linked to oidc identity at %s", u.ID, link.Issuer)
+	return nil
+}
+
+func (s *OIDCService) link(ctx context.Context, token string) (*models.OIDCLink, error) {
+	if s.provider == nil {
+		return nil, ErrOIDCDisabled
+	}
+	link, err := s.links.GetByTokenHash(ctx, auth.HashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidOIDCLink
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(link.ExpiresAt) {
+		return nil, ErrInvalidOIDCLink
+	}
+	return link, nil
+}
+
+func (s *OIDCService) linkUser(ctx context.Context, link *models.OIDCLink) (*models.User, error) {
+	u, err := s.users.GetByID(ctx, link.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidOIDCLink
+	}
+	return u, err
+}
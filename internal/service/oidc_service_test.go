@@ -0,0 +1,333 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: oidc_service_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of signing in with an OpenID Connect provider.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+const testIssuer = "https://id.example.com"
+
+// oidcUsers adds the OIDC identities to profileUsers.
+type oidcUsers struct {
+	profileUsers
+}
+
+func (r oidcUsers) GetByOIDC(_ context.Context, issuer, subject string) (*models.User, error) {
+	for _, u := range r.users {
+		if u.OIDCIssuer == issuer && u.OIDCSubject == subject {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r oidcUsers) LinkOIDC(_ context.Context, userID, issuer, subject string) (bool, error) {
+	u, ok := r.users[userID]
+	if !ok || u.OIDCSubject != "" {
+		return false, nil
+	}
+	u.OIDCIssuer, u.OIDCSubject = issuer, subject
+	return true, nil
+}
+
+func (r oidcUsers) MarkVerified(_ context.Context, userID string, at time.Time) error {
+	u := r.users[userID]
+	u.Unverified, u.VerifiedAt = false, &at
+	return nil
+}
+
+type oidcLogins struct {
+	repository.OIDCLoginRepository
+	logins map[string]models.OIDCLogin
+}
+
+func (r *oidcLogins) Take(_ context.Context, stateHash string) (*models.OIDCLogin, error) {
+	l, ok := r.logins[stateHash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	delete(r.logins, stateHash)
+	return &l, nil
+}
+
+type oidcResults struct {
+	results map[string]models.OIDCResult
+}
+
+func (r *oidcResults) Create(_ context.Context, res *models.OIDCResult) error {
+	r.results[res.CodeHash] = *res
+	return nil
+}
+
+func (r *oidcResults) Take(_ context.Context, codeHash string) (*models.OIDCResult, error) {
+	res, ok := r.results[codeHash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	delete(r.results, codeHash)
+	return &res, nil
+}
+
+type oidcLinks struct {
+	links map[string]models.OIDCLink
+}
+
+func (r *oidcLinks) Create(_ context.Context, l *models.OIDCLink) error {
+	r.links[l.ID] = *l
+	return nil
+}
+
+func (r *oidcLinks) GetByTokenHash(_ context.Context, hash string) (*models.OIDCLink, error) {
+	for _, l := range r.links {
+		if l.TokenHash == hash {
+			return &l, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *oidcLinks) Delete(_ context.Context, id string) (bool, error) {
+	_, ok := r.links[id]
+	delete(r.links, id)
+	return ok, nil
+}
+
+type testOIDC struct {
+	*OIDCService
+	users  oidcUsers
+	logins *oidcLogins
+	links  *oidcLinks
+}
+
+func newTestOIDC(t *testing.T, users ...*models.User) testOIDC {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("password-1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := testOIDC{
+		users:  oidcUsers{profileUsers{&regUsers{users: map[string]*models.User{}, log: &[]string{}}}},
+		logins: &oidcLogins{logins: map[string]models.OIDCLogin{}},
+		links:  &oidcLinks{links: map[string]models.OIDCLink{}},
+	}
+	for _, u := range users {
+		if u.Password != "" {
+			u.Password = string(hash)
+		}
+		o.users.users[u.ID] = u
+	}
+	cfg := config.OIDCConfig{IssuerURL: testIssuer, ClientID: "airsense", AllowedDomains: []string{"example.com"},
+		StateTTL: time.Minute, LinkTTL: time.Minute}
+	o.OIDCService = NewOIDCService(auth.NewOIDCProvider(cfg), o.logins, &oidcResults{results: map[string]models.OIDCResult{}},
+		o.links, o.users, cfg)
+	return o
+}
+
+// signIn signs in with claims as if they came with a verified ID token,
+// and exchanges the code the dashboard would be sent.
+func (o testOIDC) signIn(t *testing.T, claims *auth.IDClaims) (*models.User, error) {
+	t.Helper()
+	result, err := o.OIDCService.signIn(context.Background(), claims)
+	if err != nil {
+		return nil, err
+	}
+	code, err := o.finish(context.Background(), result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o.Exchange(context.Background(), code)
+}
+
+func idClaims(subject, email string) *auth.IDClaims {
+	return &auth.IDClaims{Email: email, EmailVerified: true, RegisteredClaims: jwt.RegisteredClaims{Subject: subject}}
+}
+
+func TestOIDCSignIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		users    []*models.User
+		claims   *auth.IDClaims
+		wantUser string // "" for a new user
+		wantErr  error
+	}{
+		{"linked identity", []*models.User{{ID: "u1", Email: "ann@example.com", OIDCIssuer: testIssuer, OIDCSubject: "s1"}},
+			idClaims("s1", "ann@example.com"), "u1", nil},
+		{"linked identity with a new address", []*models.User{{ID: "u1", Email: "ann@example.com", OIDCIssuer: testIssuer, OIDCSubject: "s1"}},
+			idClaims("s1", "ann.new@example.com"), "u1", nil},
+		{"new identity", nil, idClaims("s1", "Ann@Example.com"), "", nil},
+		{"unverified address", nil, &auth.IDClaims{Email: "ann@example.com", RegisteredClaims: jwt.RegisteredClaims{Subject: "s1"}},
+			"", ErrOIDCEmailNotVerified},
+		{"domain not allowed", nil, idClaims("s1", "ann@elsewhere.com"), "", ErrOIDCDomainNotAllowed},
+		{"address of a user linked to another identity", []*models.User{{ID: "u1", Email: "ann@example.com", OIDCIssuer: testIssuer, OIDCSubject: "s2"}},
+			idClaims("s1", "ann@example.com"), "", ErrOIDCAccountConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newTestOIDC(t, tt.users...)
+			u, err := o.signIn(t, tt.claims)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(o.users.users) != len(tt.users) {
+					t.Error("created a user")
+				}
+				return
+			}
+			if tt.wantUser != "" {
+				if u.ID != tt.wantUser {
+					t.Errorf("signed in as %s, want %s", u.ID, tt.wantUser)
+				}
+				return
+			}
+			stored := o.users.users[u.ID]
+			if stored == nil || stored.Email != "ann@example.com" || stored.VerifiedAt == nil || stored.Password != "" ||
+				stored.OIDCIssuer != testIssuer || stored.OIDCSubject != "s1" {
+				t.Errorf("created %+v", stored)
+			}
+		})
+	}
+}
+
+func TestOIDCLinkRequired(t *testing.T) {
+	ctx := context.Background()
+	o := newTestOIDC(t, &models.User{ID: "u1", Email: "ann@example.com", Password: "set", Unverified: true})
+	_, err := o.signIn(t, idClaims("s1", "ann@example.com"))
+	var linkErr *OIDCLinkRequiredError
+	if !errors.As(err, &linkErr) {
+		t.Fatalf("err = %v, want OIDCLinkRequiredError", err)
+	}
+	if linkErr.Email != "ann@example.com" || linkErr.LinkToken == "" {
+		t.Fatalf("link = %+v", linkErr)
+	}
+	if u := o.users.users["u1"]; u.OIDCSubject != "" {
+		t.Fatal("linked before the password was confirmed")
+	}
+
+	if u, err := o.LinkUser(ctx, linkErr.LinkToken); err != nil || u.ID != "u1" {
+		t.Fatalf("LinkUser() = %v, %v", u, err)
+	}
+	if err := o.ConfirmLink(ctx, "guess", "password-1"); !errors.Is(err, ErrInvalidOIDCLink) {
+		t.Errorf("unknown token: err = %v, want ErrInvalidOIDCLink", err)
+	}
+	if err := o.ConfirmLink(ctx, linkErr.LinkToken, "password-2"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("wrong password: err = %v, want ErrWrongPassword", err)
+	}
+	if err := o.ConfirmLink(ctx, linkErr.LinkToken, "password-1"); err != nil {
+		t.Fatalf("right password after a wrong one: %v", err)
+	}
+	u := o.users.users["u1"]
+	if u.OIDCIssuer != testIssuer || u.OIDCSubject != "s1" || u.Unverified {
+		t.Errorf("user after linking = %+v", u)
+	}
+	if err := o.ConfirmLink(ctx, linkErr.LinkToken, "password-1"); !errors.Is(err, ErrInvalidOIDCLink) {
+		t.Errorf("used token: err = %v, want ErrInvalidOIDCLink", err)
+	}
+	// The identity now signs in as the user.
+	if u, err := o.signIn(t, idClaims("s1", "ann@example.com")); err != nil || u.ID != "u1" {
+		t.Errorf("sign-in after linking = %v, %v", u, err)
+	}
+}
+
+func TestOIDCLinkExpired(t *testing.T) {
+	o := newTestOIDC(t, &models.User{ID: "u1", Email: "ann@example.com", Password: "set"})
+	o.links.links["l1"] = models.OIDCLink{ID: "l1", UserID: "u1", Issuer: testIssuer, Subject: "s1",
+		TokenHash: auth.HashToken("token"), ExpiresAt: time.Now().Add(-time.Second)}
+	if err := o.ConfirmLink(context.Background(), "token", "password-1"); !errors.Is(err, ErrInvalidOIDCLink) {
+		t.Errorf("err = %v, want ErrInvalidOIDCLink", err)
+	}
+}
+
+func TestOIDCCallbackState(t *testing.T) {
+	tests := []struct {
+		name  string
+		login *models.OIDCLogin
+	}{
+		{"unknown state", nil},
+		{"expired", &models.OIDCLogin{ID: "l1", StateHash: auth.HashToken("state"), Nonce: "n", Verifier: "v",
+			ExpiresAt: time.Now().Add(-time.Second)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newTestOIDC(t)
+			if tt.login != nil {
+				o.logins.logins[tt.login.StateHash] = *tt.login
+			}
+			// The provider is never asked: it is not there.
+			if _, err := o.Callback(context.Background(), "code", "state"); !errors.Is(err, ErrInvalidOIDCState) {
+				t.Errorf("err = %v, want ErrInvalidOIDCState", err)
+			}
+			if len(o.logins.logins) != 0 {
+				t.Error("the login is still there")
+			}
+		})
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	ctx := context.Background()
+	o := newTestOIDC(t, &models.User{ID: "u1", Email: "ann@example.com", OIDCIssuer: testIssuer, OIDCSubject: "s1"})
+	code, err := o.finish(ctx, &models.OIDCResult{UserID: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := o.finish(ctx, &models.OIDCResult{UserID: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := o.results.(*oidcResults)
+	for hash, r := range results.results {
+		if hash == auth.HashToken(expired) {
+			r.ExpiresAt = time.Now().Add(-time.Second)
+			results.results[hash] = r
+		}
+	}
+
+	if u, err := o.Exchange(ctx, code); err != nil || u.ID != "u1" {
+		t.Fatalf("Exchange() = %v, %v", u, err)
+	}
+	for name, c := range map[string]string{"used": code, "expired": expired, "unknown": "guess"} {
+		if _, err := o.Exchange(ctx, c); !errors.Is(err, ErrInvalidOIDCCode) {
+			t.Errorf("%s code: err = %v, want ErrInvalidOIDCCode", name, err)
+		}
+	}
+}
+
+func TestOIDCDisabled(t *testing.T) {
+	s := NewOIDCService(nil, nil, nil, nil, nil, config.OIDCConfig{})
+	ctx := context.Background()
+	if _, _, err := s.Start(ctx); !errors.Is(err, ErrOIDCDisabled) {
+		t.Errorf("Start: err = %v", err)
+	}
+	if _, err := s.Callback(ctx, "code", "state"); !errors.Is(err, ErrOIDCDisabled) {
+		t.Errorf("Callback: err = %v", err)
+	}
+	if _, err := s.Exchange(ctx, "code"); !errors.Is(err, ErrOIDCDisabled) {
+		t.Errorf("Exchange: err = %v", err)
+	}
+	if err := s.ConfirmLink(ctx, "token", "password"); !errors.Is(err, ErrOIDCDisabled) {
+		t.Errorf("ConfirmLink: err = %v", err)
+	}
+}
@@ -0,0 +1,407 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: org_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the business logic of organizations, their members and devices.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+const (
+	orgInvitationTTL = 7 * 24 * time.Hour
+	// maxOrgNameLength bounds the name of an organization, in characters.
+	maxOrgNameLength = 100
+)
+
+// OrgInvitationMailer delivers invitation tokens, see
+// notifications.OrgInvitationMailer.
+type OrgInvitationMailer interface {
+	SendOrgInvitation(to, orgName, role, token string, expiresAt time.Time)
+}
+
+// OrgMembership is an organization as seen by one of its members.
+type OrgMembership struct {
+	models.Organization `bson:",inline"`
+	Role                models.OrgRole `json:"role"`
+}
+
+// IssuedOrgInvitation is returned once, when created; the token is not
+// stored.
+type IssuedOrgInvitation struct {
+	models.OrgInvitation `bson:",inline"`
+	Token                string `json:"token"`
+}
+
+// OrgService manages organizations. Only members learn that an
+// organization exists: everyone else gets ErrOrgNotFound. Device access
+// through a membership is granted by DeviceService.Authorize.
+type OrgService struct {
+	orgs        repository.OrganizationRepository
+	members     repository.OrgMemberRepository
+	invitations repository.OrgInvitationRepository
+	users       repository.UserRepository
+	deviceRepo  repository.DeviceRepository
+	devices     *DeviceService
+	mailer      OrgInvitationMailer
+}
+
+// NewOrgService returns the service; without a mailer invitations are
+// only returned to the inviter.
+func NewOrgService(orgs repository.OrganizationRepository, members repository.OrgMemberRepository,
+	invitations repository.OrgInvitationRepository, users repository.UserRepository, deviceRepo repository.DeviceRepository,
+	devices *DeviceService, mailer OrgInvitationMailer) *OrgService {
+	return &OrgService{orgs: orgs, members: members, invitations: invitations, users: users, deviceRepo: deviceRepo,
+		devices: devices, mailer: mailer}
+}
+
+// Create makes an organization with userID as its owner.
+func (s *OrgService) Create(ctx context.Context, userID, name string) (*OrgMembership, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxOrgNameLength || strings.ContainsFunc(name, unicode.IsControl) {
+		return nil, fmt.Errorf("%w: name must be 1 to %d printable characters", ErrInvalidOrg, maxOrgNameLength)
+	}
+	now := time.Now()
+	o := models.Organization{
+		ID:        primitive.NewObjectID().Hex(),
+		Name:      name,
+		CreatedBy: userID,
+		CreatedAt: now,
+	}
+	if err := s.orgs.Create(ctx, &o); err != nil {
+		return nil, err
+	}
+	if err := s.addMember(ctx, o.ID, userID, models.OrgOwner); err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, audit.ActionOrgCreate, audit.ResourceOrg, o.ID, models.AuditDiff{"name": {To: name}})
+	return &OrgMembership{Organization: o, Role: models.OrgOwner}, nil
+}
+
+// List returns the organizations userID is a member of, by name.
+func (s *OrgService) List(ctx context.Context, userID string) ([]OrgMembership, error) {
+	ms, err := s.members.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[string]models.OrgRole, len(ms))
+	ids := make([]string, 0, len(ms))
+	for _, m := range ms {
+		roles[m.OrgID] = m.Role
+		ids = append(ids, m.OrgID)
+	}
+	orgs, err := s.orgs.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]OrgMembership, len(orgs))
+	for i, o := range orgs {
+		out[i] = OrgMembership{Organization: o, Role: roles[o.ID]}
+	}
+	return out, nil
+}
+
+// Get returns organization orgID as seen by its member userID.
+func (s *OrgService) Get(ctx context.Context, orgID, userID string) (*OrgMembership, error) {
+	role, err := s.role(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	o, err := s.orgs.GetByID(ctx, orgID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrgNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &OrgMembership{Organization: *o, Role: role}, nil
+}
+
+// Members lists the members of orgID to its member userID.
+func (s *OrgService) Members(ctx context.Context, orgID, userID string) ([]models.OrgMember, error) {
+	if _, err := s.role(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+	return s.members.ListByOrg(ctx, orgID)
+}
+
+// Invite asks the user registered under email to join orgID with role. An
+// admin invites admins and viewers, only an owner invites owners. The
+// token is mailed to email and returned; whoever accepts it must be signed
+// in as email.
+func (s *OrgService) Invite(ctx context.Context, orgID, userID, email string, role models.OrgRole) (*IssuedOrgInvitation, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidOrgRole
+	}
+	own, err := s.require(ctx, orgID, userID, models.OrgAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if !own.AtLeast(role) {
+		return nil, ErrForbidden
+	}
+	email, err = normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := s.users.GetByEmail(ctx, email); err == nil {
+		if _, err := s.members.Get(ctx, orgID, u.ID); err == nil {
+			return nil, ErrOrgMemberExists
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	o, err := s.orgs.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	inv := models.OrgInvitation{
+		ID:        primitive.NewObjectID().Hex(),
+		OrgID:     orgID,
+		Email:     email,
+		Role:      role,
+		InvitedBy: userID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: now.Add(orgInvitationTTL),
+		CreatedAt: now,
+	}
+	if err := s.invitations.Create(ctx, &inv); err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, audit.ActionOrgInvite, audit.ResourceOrg, orgID, models.AuditDiff{
+		"email": {To: email},
+		"role":  {To: role},
+	})
+	if s.mailer != nil {
+		s.mailer.SendOrgInvitation(email, o.Name, string(role), token, inv.ExpiresAt)
+	} else {
+		log.Printf("orgs: invitation %s not mailed, email is disabled", inv.ID)
+	}
+	return &IssuedOrgInvitation{OrgInvitation: inv, Token: token}, nil
+}
+
+// Accept makes userID, who must be the user the invitation was addressed
+// to, a member with the invited role and uses up the invitation.
+func (s *OrgService) Accept(ctx context.Context, token, userID string) (*models.OrgMember, error) {
+	inv, err := s.invitations.GetByTokenHash(ctx, auth.HashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Expired invitations linger until the TTL monitor runs.
+	if !time.Now().Before(inv.ExpiresAt) {
+		return nil, ErrInvalidInvitation
+	}
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Email != inv.Email {
+		return nil, ErrForbidden
+	}
+	if err := s.addMember(ctx, inv.OrgID, userID, inv.Role); err != nil {
+		return nil, err
+	}
+	if err := s.invitations.Delete(ctx, inv.ID); err != nil {
+		log.Printf("orgs: drop accepted invitation %s: %v", inv.ID, err)
+	}
+	return s.members.Get(ctx, inv.OrgID, userID)
+}
+
+// RemoveMember removes memberID from orgID. Any member may leave; admins
+// remove admins and viewers, owners anyone. The last owner cannot go.
+func (s *OrgService) RemoveMember(ctx context.Context, orgID, userID, memberID string) error {
+	own, err := s.role(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	m, err := s.members.Get(ctx, orgID, memberID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrOrgMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if memberID != userID && (!own.AtLeast(models.OrgAdmin) || !own.AtLeast(m.Role)) {
+		return ErrForbidden
+	}
+	if m.Role == models.OrgOwner {
+		owners, err := s.members.CountByRole(ctx, orgID, models.OrgOwner)
+		if err != nil {
+			return err
+		}
+		if owners <= 1 {
+			return ErrLastOrgOwner
+		}
+	}
+	err = s.members.Delete(ctx, orgID, memberID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrOrgMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	audit.Record(ctx, audit.ActionOrgMemberRemove, audit.ResourceOrg, orgID, models.AuditDiff{
+		"user_id": {From: memberID},
+		"role":    {From: m.Role},
+	})
+	return nil
+}
+
+// Devices lists the devices of orgID to its member userID.
+func (s *OrgService) Devices(ctx context.Context, orgID, userID string, q DeviceQuery) (*DevicePage, error) {
+	if _, err := s.role(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+	return s.devices.ListOrg(ctx, orgID, q)
+}
+
+// AddDevice puts a device userID owns into orgID, where userID must be an
+// admin. The device stays counted against the quota of userID.
+func (s *OrgService) AddDevice(ctx context.Context, orgID, userID, deviceID string) (*models.Device, error) {
+	if _, err := s.require(ctx, orgID, userID, models.OrgAdmin); err != nil {
+		return nil, err
+	}
+	d, err := s.device(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if d.UserID != userID {
+		return nil, ErrForbidden
+	}
+	switch d.OrgID {
+	case orgID:
+		return d, nil
+	case "":
+	default:
+		return nil, ErrDeviceInOtherOrg
+	}
+	updated, err := s.deviceRepo.Update(ctx, deviceID, map[string]any{"org_id": orgID})
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, audit.ActionDeviceUpdate, audit.ResourceDevice, deviceID, audit.Changes(d, updated))
+	return updated, nil
+}
+
+// RemoveDevice takes a device out of orgID, back to its owner alone. Its
+// owner may do so, member or not, and so may the admins of orgID.
+func (s *OrgService) RemoveDevice(ctx context.Context, orgID, userID, deviceID string) (*models.Device, error) {
+	d, err := s.device(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if d.UserID != userID {
+		if _, err := s.require(ctx, orgID, userID, models.OrgAdmin); err != nil {
+			return nil, err
+		}
+	}
+	if d.OrgID != orgID {
+		return nil, ErrDeviceNotFound
+	}
+	updated, err := s.deviceRepo.Unset(ctx, deviceID, []string{"org_id"})
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, audit.ActionDeviceUpdate, audit.ResourceDevice, deviceID, audit.Changes(d, updated))
+	return updated, nil
+}
+
+func (s *OrgService) addMember(ctx context.Context, orgID, userID string, role models.OrgRole) error {
+	err := s.members.Create(ctx, &models.OrgMember{
+		ID:        primitive.NewObjectID().Hex(),
+		OrgID:     orgID,
+		UserID:    userID,
+		Role:      role,
+		CreatedAt: time.Now(),
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return ErrOrgMemberExists
+	}
+	if err != nil {
+		return err
+	}
+	audit.Record(ctx, audit.ActionOrgMemberAdd, audit.ResourceOrg, orgID, models.AuditDiff{
+		"user_id": {To: userID},
+		"role":    {To: role},
+	})
+	return nil
+}
+
+// role returns the role of userID in orgID, or ErrOrgNotFound if they are
+// not a member.
+func (s *OrgService) role(ctx context.Context, orgID, userID string) (models.OrgRole, error) {
+	m, err := s.members.Get(ctx, orgID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", ErrOrgNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return m.Role, nil
+}
+
+// require returns the role of userID in orgID if it is at least want, or
+// ErrForbidden.
+func (s *OrgService) require(ctx context.Context, orgID, userID string, want models.OrgRole) (models.OrgRole, error) {
+	role, err := s.role(ctx, orgID, userID)
+	if err != nil {
+		return "", err
+	}
+	if !role.AtLeast(want) {
+		return "", ErrForbidden
+	}
+	return role, nil
+}
+
+// device returns a live device.
+func (s *OrgService) device(ctx context.Context, id string) (*models.Device, error) {
+	d, err := s.deviceRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.DeletedAt != nil {
+		return nil, ErrDeviceNotFound
+	}
+	return d, nil
+}
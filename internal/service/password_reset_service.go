@@ -0,0 +1,167 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: password_reset_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the password reset flow of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// Password policy. bcrypt ignores everything past 72 bytes, so longer
+// passwords are refused rather than silently truncated.
+const (
+	minPasswordLength = 8
+	maxPasswordBytes  = 72
+)
+
+// PasswordResetMailer delivers reset tokens, see
+// notifications.PasswordResetMailer.
+type PasswordResetMailer interface {
+	SendPasswordReset(to, token string, expiresAt time.Time)
+}
+
+type PasswordResetService struct {
+	resets   repository.PasswordResetRepository
+	users    repository.UserRepository
+	sessions *SessionService
+	mailer   PasswordResetMailer
+	ttl      time.Duration
+	cost     int
+	emails   *utils.RateLimiter
+}
+
+// NewPasswordResetService returns the service; without a mailer reset
+// requests are accepted but no token is sent.
+func NewPasswordResetService(resets repository.PasswordResetRepository, users repository.UserRepository,
+	sessions *SessionService, mailer PasswordResetMailer, cfg config.JWTConfig) *PasswordResetService {
+	s := &PasswordResetService{resets: resets, users: users, sessions: sessions, mailer: mailer, ttl: cfg.PasswordResetTTL, cost: cfg.BcryptCost}
+	if cfg.PasswordResetEmailLimit > 0 {
+		s.emails = utils.NewRateLimiter(cfg.PasswordResetEmailLimit, time.Hour, cfg.PasswordResetEmailLimit)
+	}
+	return s
+}
+
+// Forgot mails a reset token to the user with email, if there is one. To
+// not reveal which addresses are registered it succeeds either way, also
+// when the address has asked too often and nothing is sent.
+func (s *PasswordResetService) Forgot(ctx context.Context, email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if s.emails != nil {
+		if ok, _ := s.emails.Allow(email); !ok {
+			return nil
+		}
+	}
+	u, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if s.mailer == nil {
+		log.Printf("auth: password reset for user %s not sent, email is disabled", u.ID)
+		return nil
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	reset := &models.PasswordReset{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    u.ID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+	}
+	if err := s.resets.Create(ctx, reset); err != nil {
+		return err
+	}
+	s.mailer.SendPasswordReset(u.Email, token, reset.ExpiresAt)
+	return nil
+}
+
+// Reset sets the password of the user token was issued to and uses up
+// token. All sessions of the user end, see SessionService.RevokeAll.
+func (s *PasswordResetService) Reset(ctx context.Context, token, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+	reset, err := s.resets.GetByTokenHash(ctx, auth.HashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	if reset.UsedAt != nil || !time.Now().Before(reset.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
+	if err != nil {
+		return err
+	}
+	// Marking the reset used is what makes it single-use under
+	// concurrent requests.
+	used, err := s.resets.MarkUsed(ctx, reset.ID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidResetToken
+	}
+	if err := s.users.SetPassword(ctx, reset.UserID, string(hash), time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if err := s.sessions.RevokeAll(ctx, reset.UserID); err != nil {
+		return err
+	}
+	if err := s.resets.DeleteByUser(ctx, reset.UserID); err != nil {
+		log.Printf("auth: drop password resets of user %s: %v", reset.UserID, err)
+	}
+	return nil
+}
+
+// validatePassword requires minPasswordLength to maxPasswordBytes bytes
+// with at least one letter and one digit.
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength || len(password) > maxPasswordBytes {
+		return fmt.Errorf("%w: it must be %d to %d bytes long", ErrWeakPassword, minPasswordLength, maxPasswordBytes)
+	}
+	var letter, digit bool
+	for _, r := range password {
+		letter = letter || unicode.IsLetter(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	if !letter || !digit {
+		return fmt.Errorf("%w: it must contain a letter and a digit", ErrWeakPassword)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: provisioning_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the business logic for device self-registration with provisioning tokens.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// maxProvisioningUses bounds the devices one token may provision.
+const maxProvisioningUses = 10000
+
+// serialNumberPattern is what a device may send as its serial number.
+var serialNumberPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,63}$`)
+
+// IssuedProvisioningToken is returned once, when created; the signed token
+// is not stored.
+type IssuedProvisioningToken struct {
+	models.ProvisioningToken `bson:",inline"`
+	Token                    string `json:"token"`
+}
+
+// ProvisionedDevice is what a device gets back for registering itself. The
+// MQTT password is not retrievable later, only rotated by the owner.
+type ProvisionedDevice struct {
+	DeviceID     string          `json:"device_id"`
+	SerialNumber string          `json:"serial_number"`
+	MQTT         MQTTCredentials `json:"mqtt"`
+}
+
+type ProvisioningService struct {
+	tokens      repository.ProvisioningTokenRepository
+	users       repository.UserRepository
+	devices     *DeviceService
+	credentials *MQTTCredentialService
+	cfg         config.ProvisioningConfig
+}
+
+func NewProvisioningService(tokens repository.ProvisioningTokenRepository, users repository.UserRepository,
+	devices *DeviceService, credentials *MQTTCredentialService, cfg config.ProvisioningConfig) *ProvisioningService {
+	return &ProvisioningService{tokens: tokens, users: users, devices: devices, credentials: credentials, cfg: cfg}
+}
+
+// CreateToken issues a token provisioning up to maxUses devices for userID
+// within ttl. A zero ttl is the configured default and a zero maxUses one
+// device.
+func (s *ProvisioningService) CreateToken(ctx context.Context, adminID, userID string, ttl time.Duration, maxUses int) (*IssuedProvisioningToken, error) {
+	if s.cfg.Secret == "" {
+		return nil, ErrProvisioningDisabled
+	}
+	if ttl == 0 {
+		ttl = s.cfg.TokenTTL
+	}
+	if ttl < time.Second || ttl > s.cfg.MaxTokenTTL {
+		return nil, fmt.Errorf("%w: ttl must be between 1s and %s", ErrInvalidProvisioning, s.cfg.MaxTokenTTL)
+	}
+	if maxUses == 0 {
+		maxUses = 1
+	}
+	if maxUses < 1 || maxUses > maxProvisioningUses {
+		return nil, fmt.Errorf("%w: max_uses must be between 1 and %d", ErrInvalidProvisioning, maxProvisioningUses)
+	}
+	if userID == "" {
+		userID = adminID
+	} else if _, err := s.users.GetByID(ctx, userID); errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	t := models.ProvisioningToken{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    userID,
+		CreatedBy: adminID,
+		MaxUses:   maxUses,
+		ExpiresAt: now.Add(ttl).Truncate(time.Second),
+		CreatedAt: now,
+	}
+	signed, err := utils.GenerateProvisioningToken(t.ID, s.cfg.Secret, t.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.Create(ctx, &t); err != nil {
+		return nil, err
+	}
+	return &IssuedProvisioningToken{ProvisioningToken: t, Token: signed}, nil
+}
+
+// Provision registers the device with serial number serial for the user
+// of token and issues its MQTT credentials. Each call uses the token once;
+// a device that could not be created does not use it up.
+func (s *ProvisioningService) Provision(ctx context.Context, token, serial string) (*ProvisionedDevice, error) {
+	if s.cfg.Secret == "" {
+		return nil, ErrProvisioningDisabled
+	}
+	claims, err := utils.ParseProvisioningToken(token, s.cfg.Secret)
+	if err != nil {
+		return nil, ErrInvalidProvisioningToken
+	}
+	if !serialNumberPattern.MatchString(serial) {
+		return nil, fmt.Errorf("%w: serial_number must be 1-64 letters, digits, '.', '_', ':' or '-'", ErrInvalidProvisioning)
+	}
+
+	t, err := s.tokens.Use(ctx, claims.ID, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		// Used up, or expired before the TTL index dropped it.
+		return nil, ErrInvalidProvisioningToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.devices.Provision(ctx, t.UserID, serial)
+	if err != nil {
+		if uerr := s.tokens.Unuse(ctx, t.ID); uerr != nil {
+			log.Printf("provisioning: give back a use of token %s: %v", t.ID, uerr)
+		}
+		return nil, err
+	}
+	creds, err := s.credentials.Issue(ctx, d.ID)
+	if err != nil {
+		// The device stays; its owner can rotate its credentials.
+		return nil, fmt.Errorf("issue mqtt credentials of device %s: %w", d.ID, err)
+	}
+	return &ProvisionedDevice{DeviceID: d.ID, SerialNumber: serial, MQTT: *creds}, nil
+}
@@ -0,0 +1,100 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: quota_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the per-user device quota of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"airsense-be.com/internal/repository"
+)
+
+// QuotaExceededError is returned when registering devices would take a
+// user over their limit.
+type QuotaExceededError struct {
+	Count int
+	Limit int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("device quota exceeded: %d of %d devices", e.Count, e.Limit)
+}
+
+// QuotaService enforces the number of devices a user may own. Only devices
+// that are not soft-deleted count, and admins are never limited. The count
+// lives on the user document and is only changed with conditional updates,
+// so concurrent registrations cannot overshoot the limit. With
+// requireVerified, users who have not confirmed their email address yet get
+// no slots at all.
+type QuotaService struct {
+	users           repository.UserRepository
+	defaultLimit    int
+	requireVerified bool
+}
+
+func NewQuotaService(users repository.UserRepository, defaultLimit int, requireVerified bool) *QuotaService {
+	return &QuotaService{users: users, defaultLimit: defaultLimit, requireVerified: requireVerified}
+}
+
+// Reserve takes n device slots of userID, or returns *QuotaExceededError,
+// or ErrEmailNotVerified. Callers must Release the slots again if they fail
+// to create the devices.
+func (s *QuotaService) Reserve(ctx context.Context, userID string, n int) error {
+	if s.requireVerified {
+		u, err := s.users.GetByID(ctx, userID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if u.Unverified {
+			return ErrEmailNotVerified
+		}
+	}
+	ok, err := s.users.ReserveDevices(ctx, userID, n, s.defaultLimit)
+	if err != nil || ok {
+		return err
+	}
+	u, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return &QuotaExceededError{Count: u.DeviceCount, Limit: s.limit(u.DeviceLimit)}
+}
+
+func (s *QuotaService) Release(ctx context.Context, userID string, n int) error {
+	return s.users.ReleaseDevices(ctx, userID, n)
+}
+
+// SetLimit overrides the device limit of userID; nil restores the default.
+func (s *QuotaService) SetLimit(ctx context.Context, userID string, limit *int) error {
+	if limit != nil && *limit < 0 {
+		return ErrInvalidQuota
+	}
+	err := s.users.SetDeviceLimit(ctx, userID, limit)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+func (s *QuotaService) limit(userLimit *int) int {
+	if userLimit != nil {
+		return *userLimit
+	}
+	return s.defaultLimit
+}
@@ -0,0 +1,213 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: registration_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the self-service registration and email verification of users.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// VerificationMailer delivers email verification tokens, see
+// notifications.VerificationMailer.
+type VerificationMailer interface {
+	SendVerification(to, token string, expiresAt time.Time)
+}
+
+type RegistrationService struct {
+	verifications repository.EmailVerificationRepository
+	users         repository.UserRepository
+	sessions      *SessionService
+	keys          repository.APIKeyRepository
+	mailer        VerificationMailer
+	ttl           time.Duration
+	cost          int
+	emails        *utils.RateLimiter
+}
+
+// NewRegistrationService returns the service; without a mailer users can
+// register but no verification token is sent.
+func NewRegistrationService(verifications repository.EmailVerificationRepository, users repository.UserRepository,
+	sessions *SessionService, keys repository.APIKeyRepository, mailer VerificationMailer, cfg config.JWTConfig) *RegistrationService {
+	s := &RegistrationService{verifications: verifications, users: users, sessions: sessions, keys: keys, mailer: mailer,
+		ttl: cfg.VerificationTTL, cost: cfg.BcryptCost}
+	if cfg.VerificationEmailLimit > 0 {
+		s.emails = utils.NewRateLimiter(cfg.VerificationEmailLimit, time.Hour, cfg.VerificationEmailLimit)
+	}
+	return s
+}
+
+// Register creates an unverified user with email and password and mails it
+// a verification token; an unverified user with email is reclaimed
+// instead. To not reveal which addresses are registered it succeeds,
+// sending nothing, when email belongs to a verified user; the unique index
+// on the address decides between concurrent registrations.
+func (s *RegistrationService) Register(ctx context.Context, email, password string) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
+	if err != nil {
+		return err
+	}
+	u := &models.User{
+		ID:         primitive.NewObjectID().Hex(),
+		Email:      email,
+		Password:   string(hash),
+		CreatedAt:  time.Now().UTC(),
+		Unverified: true,
+	}
+	if err := s.users.Create(ctx, u); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return s.reclaim(ctx, email, u.Password)
+		}
+		return err
+	}
+	log.Printf("auth: user %s registered", u.ID)
+	return s.send(ctx, u)
+}
+
+// reclaim registers email again, with the password hash, if its user is
+// still unverified. Whoever registered it first never proved owning the
+// address, so they must not keep the account: their password is replaced,
+// their sessions and API keys end, and so do the links mailed to them.
+// The new registrant gets a link of their own. A verified user is left
+// alone.
+func (s *RegistrationService) reclaim(ctx context.Context, email, hash string) error {
+	u, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !u.Unverified {
+		return nil
+	}
+	replaced, err := s.users.ReplaceUnverifiedPassword(ctx, u.ID, hash, time.Now())
+	if err != nil || !replaced {
+		// Verified in the meantime.
+		return err
+	}
+	if err := s.sessions.RevokeAll(ctx, u.ID); err != nil {
+		return err
+	}
+	if err := s.keys.DeleteByUser(ctx, u.ID); err != nil {
+		return err
+	}
+	if err := s.verifications.DeleteByUser(ctx, u.ID); err != nil {
+		return err
+	}
+	log.Printf("auth: unverified user %s registered again, replaced their password", u.ID)
+	return s.send(ctx, u)
+}
+
+// Resend mails a new verification token to the user with email if it is
+// not verified yet; earlier tokens stay valid until they expire. Like
+// Register it succeeds either way, also when the address has asked too
+// often.
+func (s *RegistrationService) Resend(ctx context.Context, email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	u, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !u.Unverified {
+		return nil
+	}
+	return s.send(ctx, u)
+}
+
+// Reverify asks u to confirm the email address they changed to: the links
+// mailed to the old one stop working and a new one is mailed to it.
+func (s *RegistrationService) Reverify(ctx context.Context, u *models.User) error {
+	if err := s.verifications.DeleteByUser(ctx, u.ID); err != nil {
+		return err
+	}
+	return s.send(ctx, u)
+}
+
+// Verify confirms the email address of the user token was issued to and
+// uses up its tokens.
+func (s *RegistrationService) Verify(ctx context.Context, token string) error {
+	v, err := s.verifications.GetByTokenHash(ctx, auth.HashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return err
+	}
+	if !time.Now().Before(v.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+	if err := s.users.MarkVerified(ctx, v.UserID, time.Now().UTC()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		return err
+	}
+	if err := s.verifications.DeleteByUser(ctx, v.UserID); err != nil {
+		log.Printf("auth: drop email verifications of user %s: %v", v.UserID, err)
+	}
+	log.Printf("auth: user %s verified", v.UserID)
+	return nil
+}
+
+// send mails u a new verification token, at most VerificationEmailLimit
+// times an hour per address.
+func (s *RegistrationService) send(ctx context.Context, u *models.User) error {
+	if s.emails != nil {
+		if ok, _ := s.emails.Allow(u.Email); !ok {
+			return nil
+		}
+	}
+	if s.mailer == nil {
+		log.Printf("auth: email verification for user %s not sent, email is disabled", u.ID)
+		return nil
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	v := &models.EmailVerification{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    u.ID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+	}
+	if err := s.verifications.Create(ctx, v); err != nil {
+		return err
+	}
+	s.mailer.SendVerification(u.Email, token, v.ExpiresAt)
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: registration_service_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of registering users.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// regUsers keeps users by ID, their email addresses unique, and logs the
+// calls ending sessions and dropping keys and links.
+type regUsers struct {
+	repository.UserRepository
+	users map[string]*models.User
+	log   *[]string
+}
+
+func (r *regUsers) Create(_ context.Context, u *models.User) error {
+	for _, other := range r.users {
+		if other.Email == u.Email {
+			return repository.ErrDuplicate
+		}
+	}
+	cp := *u
+	r.users[u.ID] = &cp
+	return nil
+}
+
+func (r *regUsers) GetByEmail(_ context.Context, email string) (*models.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *regUsers) ReplaceUnverifiedPassword(_ context.Context, userID, hash string, _ time.Time) (bool, error) {
+	u, ok := r.users[userID]
+	if !ok || !u.Unverified {
+		return false, nil
+	}
+	u.Password = hash
+	return true, nil
+}
+
+func (r *regUsers) RevokeSessions(_ context.Context, userID string, _ time.Time) error {
+	*r.log = append(*r.log, "revoke sessions "+userID)
+	return nil
+}
+
+type regTokens struct {
+	repository.RefreshTokenRepository
+	log *[]string
+}
+
+func (r *regTokens) RevokeUser(_ context.Context, userID string) error {
+	*r.log = append(*r.log, "revoke refresh tokens "+userID)
+	return nil
+}
+
+type regKeys struct {
+	repository.APIKeyRepository
+	log *[]string
+}
+
+func (r *regKeys) DeleteByUser(_ context.Context, userID string) error {
+	*r.log = append(*r.log, "delete api keys "+userID)
+	return nil
+}
+
+type regVerifications struct {
+	repository.EmailVerificationRepository
+	log *[]string
+}
+
+func (r *regVerifications) Create(_ context.Context, v *models.EmailVerification) error {
+	*r.log = append(*r.log, "create verification "+v.UserID)
+	return nil
+}
+
+func (r *regVerifications) DeleteByUser(_ context.Context, userID string) error {
+	*r.log = append(*r.log, "delete verifications "+userID)
+	return nil
+}
+
+type regMailer struct{ sent []string }
+
+func (m *regMailer) SendVerification(to, _ string, _ time.Time) { m.sent = append(m.sent, to) }
+
+func TestRegister(t *testing.T) {
+	existing := func(unverified bool) map[string]*models.User {
+		hash, _ := bcrypt.GenerateFromPassword([]byte("first-password-1"), bcrypt.MinCost)
+		return map[string]*models.User{"u1": {ID: "u1", Email: "a@example.com", Password: string(hash), Unverified: unverified}}
+	}
+	tests := []struct {
+		name         string
+		users        map[string]*models.User
+		wantPassword string // of a@example.com afterwards
+		wantLog      []string
+		wantMail     int
+	}{
+		{
+			name:         "new address",
+			users:        map[string]*models.User{},
+			wantPassword: "second-password-2",
+			wantMail:     1,
+		},
+		{
+			name:         "unverified address reclaimed",
+			users:        existing(true),
+			wantPassword: "second-password-2",
+			wantLog: []string{"revoke sessions u1", "revoke refresh tokens u1", "delete api keys u1",
+				"delete verifications u1", "create verification u1"},
+			wantMail: 1,
+		},
+		{
+			name:         "verified address left alone",
+			users:        existing(false),
+			wantPassword: "first-password-1",
+			wantLog:      []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			had := len(tt.users)
+			log := &[]string{}
+			users := &regUsers{users: tt.users, log: log}
+			mailer := &regMailer{}
+			cfg := config.JWTConfig{VerificationTTL: time.Hour, BcryptCost: bcrypt.MinCost}
+			s := NewRegistrationService(&regVerifications{log: log}, users,
+				NewSessionService(users, &regTokens{log: log}, cfg), &regKeys{log: log}, mailer, cfg)
+			if err := s.Register(context.Background(), " A@example.com ", "second-password-2"); err != nil {
+				t.Fatal(err)
+			}
+			u, err := users.GetByEmail(context.Background(), "a@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(tt.wantPassword)) != nil {
+				t.Errorf("password is not %q", tt.wantPassword)
+			}
+			if had > 0 && u.ID != "u1" {
+				t.Errorf("user %s, want u1 kept", u.ID)
+			}
+			if tt.wantLog != nil && !slices.Equal(*log, tt.wantLog) {
+				t.Errorf("calls = %q, want %q", *log, tt.wantLog)
+			}
+			if len(mailer.sent) != tt.wantMail {
+				t.Errorf("sent %d mails, want %d", len(mailer.sent), tt.wantMail)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: scoped_token_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the business logic of device-scoped access tokens.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// IssuedScopedToken is returned once, when created; the signed token is
+// not stored.
+type IssuedScopedToken struct {
+	models.ScopedToken `bson:",inline"`
+	Token              string `json:"token"`
+}
+
+type ScopedTokenService struct {
+	repo    repository.ScopedTokenRepository
+	devices *DeviceService
+	secret  string
+	maxTTL  time.Duration
+}
+
+func NewScopedTokenService(repo repository.ScopedTokenRepository, devices *DeviceService, cfg config.JWTConfig) *ScopedTokenService {
+	return &ScopedTokenService{repo: repo, devices: devices, secret: cfg.Secret, maxTTL: cfg.ScopedTokenMaxTTL}
+}
+
+// Create mints a token valid for ttl that can perform operations on
+// deviceIDs, all of which userID must own.
+func (s *ScopedTokenService) Create(ctx context.Context, userID, name string, deviceIDs, operations []string, ttl time.Duration) (*IssuedScopedToken, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxKeyNameLength {
+		return nil, fmt.Errorf("%w: name must be at most %d characters", ErrInvalidScope, maxKeyNameLength)
+	}
+	if ttl < time.Second || ttl > s.maxTTL {
+		return nil, fmt.Errorf("%w: ttl_seconds must be between 1 and %d", ErrInvalidScope, int64(s.maxTTL.Seconds()))
+	}
+	devices := compact(deviceIDs)
+	if len(devices) == 0 || len(devices) > models.MaxScopedTokenDevices {
+		return nil, fmt.Errorf("%w: device_ids must list 1 to %d devices", ErrInvalidScope, models.MaxScopedTokenDevices)
+	}
+	ops := compact(operations)
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("%w: at least one operation is required", ErrInvalidScope)
+	}
+	for _, op := range ops {
+		if !models.ValidScopeOperation(op) {
+			return nil, fmt.Errorf("%w: operation %q must be %s or %s", ErrInvalidScope, op, models.ScopeRead, models.ScopeControl)
+		}
+	}
+	for _, id := range devices {
+		if _, err := s.devices.Authorize(ctx, id, userID, AccessOwner, false); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	t := models.ScopedToken{
+		ID:         primitive.NewObjectID().Hex(),
+		UserID:     userID,
+		Name:       name,
+		DeviceIDs:  devices,
+		Operations: ops,
+		ExpiresAt:  now.Add(ttl).Truncate(time.Second),
+		CreatedAt:  now,
+	}
+	signed, err := utils.GenerateScopedToken(t.ID, userID, t.DeviceIDs, t.Operations, s.secret, t.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, &t); err != nil {
+		return nil, err
+	}
+	return &IssuedScopedToken{ScopedToken: t, Token: signed}, nil
+}
+
+func (s *ScopedTokenService) List(ctx context.Context, userID string) ([]models.ScopedToken, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Revoke deletes a token of userID, which is refused from then on.
+func (s *ScopedTokenService) Revoke(ctx context.Context, userID, id string) error {
+	err := s.repo.Delete(ctx, userID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrScopedTokenNotFound
+	}
+	return err
+}
+
+// Authenticate resolves the record of verified scoped token claims. Revoked
+// and expired tokens return ErrInvalidScopedToken. The scope is taken from
+// the record, not from the claims.
+func (s *ScopedTokenService) Authenticate(ctx context.Context, claims *utils.Claims) (*models.ScopedToken, error) {
+	if claims.ID == "" {
+		return nil, ErrInvalidScopedToken
+	}
+	t, err := s.repo.GetByID(ctx, claims.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidScopedToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Expired records linger until the TTL monitor runs.
+	if t.UserID != claims.UserID || !time.Now().Before(t.ExpiresAt) {
+		return nil, ErrInvalidScopedToken
+	}
+	return t, nil
+}
+
+// compact trims values and drops empty and repeated ones, keeping the
+// order of the rest.
+func compact(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
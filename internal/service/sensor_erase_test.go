@@ -0,0 +1,84 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_erase_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the erasure of sensor readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// eraseRepo holds count readings and records whether Delete ran.
+type eraseRepo struct {
+	repository.SensorDataRepository
+	count   int64
+	deleted bool
+	filter  repository.SensorFilter
+}
+
+func (r *eraseRepo) Count(_ context.Context, filter repository.SensorFilter) (int64, error) {
+	return r.count, nil
+}
+
+func (r *eraseRepo) Delete(_ context.Context, filter repository.SensorFilter) (int64, error) {
+	r.deleted, r.filter = true, filter
+	return r.count, nil
+}
+
+func TestSensorServiceDelete(t *testing.T) {
+	tests := []struct {
+		name        string
+		count       int64
+		confirm     bool
+		wantDeleted bool
+		wantErr     error
+	}{
+		{"nothing to delete", 0, false, true, nil},
+		{"below the limit", 10, false, true, nil},
+		{"at the limit", MaxUnconfirmedDelete, false, true, nil},
+		{"above the limit", MaxUnconfirmedDelete + 1, false, false, ErrConfirmationRequired},
+		{"above the limit, confirmed", MaxUnconfirmedDelete + 1, true, true, nil},
+	}
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &eraseRepo{count: tt.count}
+			latest := cache.NewLatestCache()
+			latest.Set(models.SensorData{DeviceID: "d1", Timestamp: from})
+			s := &SensorService{repo: repo, latest: latest}
+			filter := repository.SensorFilter{DeviceID: "d1", From: from, To: to}
+			n, err := s.Delete(context.Background(), filter, tt.confirm)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete() error = %v, want %v", err, tt.wantErr)
+			}
+			// Refused erasures report how many readings they would delete.
+			if n != tt.count {
+				t.Errorf("Delete() = %d, want %d", n, tt.count)
+			}
+			if repo.deleted != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", repo.deleted, tt.wantDeleted)
+			}
+			if _, cached := latest.Get("d1"); cached == repo.deleted {
+				t.Errorf("latest reading cached = %v after deleted = %v", cached, repo.deleted)
+			}
+			if repo.deleted && (repo.filter.DeviceID != "d1" || !repo.filter.From.Equal(from) || !repo.filter.To.Equal(to)) {
+				t.Errorf("Delete filter = %+v", repo.filter)
+			}
+		})
+	}
+}
@@ -0,0 +1,400 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic for sensor data in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"airsense-be.com/internal/alert"
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/events"
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/quality"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// ReadingWriter stores readings in the background, see
+// storage.WriterPool. It calls stored once data is stored.
+type ReadingWriter interface {
+	Write(ctx context.Context, data *models.SensorData, stored func()) error
+}
+
+type SensorService struct {
+	repo      repository.SensorDataRepository
+	writer    ReadingWriter
+	devices   repository.DeviceRepository
+	anomalies *alert.AnomalyDetector
+	flagged   repository.SensorAnomalyRepository
+	alerts    *alert.Evaluator
+	quality   *quality.Scorer
+	latest    *cache.LatestCache
+	events    *events.Hub
+}
+
+// NewSensorService returns a service storing readings received over MQTT
+// through writer, if it is not nil, and the others with repo directly.
+// What anomalies flags is stored in flagged.
+func NewSensorService(repo repository.SensorDataRepository, writer ReadingWriter, devices repository.DeviceRepository,
+	anomalies *alert.AnomalyDetector, flagged repository.SensorAnomalyRepository, alerts *alert.Evaluator,
+	scorer *quality.Scorer, latest *cache.LatestCache, hub *events.Hub) *SensorService {
+	return &SensorService{repo: repo, writer: writer, devices: devices, anomalies: anomalies, flagged: flagged, alerts: alerts,
+		quality: scorer, latest: latest, events: hub}
+}
+
+// Ingest validates a reading, rates its quality and persists it. The caller
+// sets Source. Readings of soft-deleted devices are refused with
+// ErrDeviceDeleted, those of decommissioned ones with
+// ErrDeviceDecommissioned. An MQTT reading handed to the writer may still
+// be queued when Ingest returns: nobody waits for an answer to it. Either
+// way it becomes the latest reading and is streamed to listeners only once
+// it is stored.
+//
+// A reading taken within the MinInterval of the device after the last one
+// stored is thinned: it becomes the latest reading, is streamed to
+// listeners and checked for alerts, but it is neither stored nor checked
+// for anomalies.
+func (s *SensorService) Ingest(ctx context.Context, data *models.SensorData) error {
+	if err := utils.ValidateSensorData(data); err != nil {
+		return err
+	}
+	d, err := s.devices.GetByID(ctx, data.DeviceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrDeviceNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if d.DeletedAt != nil {
+		return ErrDeviceDeleted
+	}
+	if d.DecommissionedAt != nil {
+		return ErrDeviceDecommissioned
+	}
+	utils.ApplyAQI(data)
+	s.score(data)
+	// Thinned readings are checked too, or a breach between two stored
+	// ones would go unnoticed.
+	s.evaluateAlerts(ctx, data)
+	publish := func() {
+		s.latest.Set(*data)
+		s.events.Publish(events.Event{Type: events.TypeSensorData, DeviceID: data.DeviceID, At: data.Timestamp, Data: data})
+	}
+	thinned := s.thin(d, data)
+	if thinned {
+		metrics.ReadingsThinned.Add(1)
+		publish()
+	} else {
+		if err := s.store(ctx, data, publish); err != nil {
+			return err
+		}
+		s.latest.SetStored(data.DeviceID, data.Timestamp)
+	}
+	s.touch(ctx, d)
+	if thinned {
+		return nil
+	}
+	s.detectAnomalies(ctx, data)
+	return nil
+}
+
+// thin reports whether data is taken less than the MinInterval of d after
+// the last reading of d stored since startup. Concurrent readings of a
+// device may both be stored. Late readings, taken before the last one
+// stored, are never thinned: a device replaying its buffer sends them.
+func (s *SensorService) thin(d *models.Device, data *models.SensorData) bool {
+	if d.MinIntervalMs <= 0 {
+		return false
+	}
+	last, ok := s.latest.StoredAt(d.ID)
+	since := data.Timestamp.Sub(last)
+	return ok && since >= 0 && since < d.MinInterval()
+}
+
+// store persists data, through the writer if it came over MQTT, and calls
+// stored once it is.
+func (s *SensorService) store(ctx context.Context, data *models.SensorData, stored func()) error {
+	if s.writer != nil && data.Source == models.SourceMQTT {
+		return s.writer.Write(ctx, data, stored)
+	}
+	if err := s.repo.Insert(ctx, data); err != nil {
+		return err
+	}
+	stored()
+	return nil
+}
+
+func (s *SensorService) evaluateAlerts(ctx context.Context, data *models.SensorData) {
+	if s.alerts == nil {
+		return
+	}
+	for _, e := range s.alerts.Evaluate(ctx, data) {
+		metrics.AlertsFired.Add(1)
+		log.Printf("alert: device %s %s %s=%.2f (threshold %.2f)",
+			e.DeviceID, e.Severity, e.Field, e.Value, e.Threshold)
+	}
+}
+
+// lastSeenResolution limits how often a chatty device rewrites LastSeenAt.
+const lastSeenResolution = 30 * time.Second
+
+func (s *SensorService) touch(ctx context.Context, d *models.Device) {
+	now := time.Now()
+	if d.Status == models.DeviceOnline && d.LastSeenAt != nil && now.Sub(*d.LastSeenAt) < lastSeenResolution {
+		return
+	}
+	if err := s.devices.Touch(ctx, d.ID, now); err != nil {
+		log.Printf("sensors: update last seen of device %s: %v", d.ID, err)
+	}
+}
+
+func (s *SensorService) score(data *models.SensorData) {
+	if s.quality == nil {
+		return
+	}
+	data.Quality = s.quality.Score(*data)
+}
+
+// detectAnomalies checks data against the live detector and stores what it
+// flags. A failed write is logged: the reading itself is stored.
+func (s *SensorService) detectAnomalies(ctx context.Context, data *models.SensorData) {
+	if s.anomalies == nil {
+		return
+	}
+	var flagged []models.SensorAnomaly
+	now := time.Now().UTC()
+	for _, a := range s.anomalies.Check(data) {
+		metrics.SensorAnomalies.Add(1)
+		log.Printf("anomaly: device %s %s=%.2f (mean %.2f, stddev %.2f, z %.2f)",
+			a.DeviceID, a.Field, a.Value, a.Mean, a.StdDev, a.ZScore)
+		flagged = append(flagged, models.SensorAnomaly{
+			ID:         models.SensorAnomalyID(a.DeviceID, data.Timestamp, a.Field, models.AnomalyReasonZScore),
+			DeviceID:   a.DeviceID,
+			Field:      a.Field,
+			Value:      a.Value,
+			Reason:     models.AnomalyReasonZScore,
+			Mean:       a.Mean,
+			StdDev:     a.StdDev,
+			ZScore:     a.ZScore,
+			Timestamp:  data.Timestamp,
+			DetectedAt: now,
+		})
+	}
+	if s.flagged == nil || len(flagged) == 0 {
+		return
+	}
+	if err := s.flagged.Upsert(ctx, flagged); err != nil {
+		log.Printf("anomaly: store %d anomalies of device %s: %v", len(flagged), data.DeviceID, err)
+	}
+}
+
+// IngestBulk validates and stores a batch uploaded over REST for deviceID.
+// Nothing is stored if any reading is invalid.
+func (s *SensorService) IngestBulk(ctx context.Context, deviceID string, data []*models.SensorData) error {
+	for i, d := range data {
+		d.ResetServerFields()
+		d.DeviceID = deviceID
+		d.Source = models.SourceBulkUpload
+		if err := utils.ValidateSensorData(d); err != nil {
+			return fmt.Errorf("reading %d: %w", i, err)
+		}
+		utils.ApplyAQI(d)
+		s.score(d)
+	}
+	if err := s.repo.InsertMany(ctx, data); err != nil {
+		return err
+	}
+	for _, d := range data {
+		s.latest.Set(*d)
+		s.latest.SetStored(d.DeviceID, d.Timestamp)
+	}
+	return nil
+}
+
+func (s *SensorService) Query(ctx context.Context, filter repository.SensorFilter) ([]models.SensorData, error) {
+	return s.repo.Find(ctx, filter)
+}
+
+// Latest returns the newest reading of deviceID, from the latest-reading
+// cache if it has it, or nil if the device has none.
+func (s *SensorService) Latest(ctx context.Context, deviceID string) (*models.SensorData, error) {
+	if d, ok := s.latest.Get(deviceID); ok {
+		return &d, nil
+	}
+	readings, err := s.repo.Latest(ctx, []string{deviceID})
+	if err != nil || len(readings) == 0 {
+		return nil, err
+	}
+	s.latest.Set(readings[0])
+	return &readings[0], nil
+}
+
+// HealthScore is the composite health score of a reading, see
+// models.Sensors.CompositeScore. Limiting names the pollutants with the
+// lowest subscore, those dragging the score down.
+type HealthScore struct {
+	DeviceID  string         `json:"device_id"`
+	ReadingID string         `json:"reading_id"`
+	Timestamp time.Time      `json:"timestamp"`
+	Score     int            `json:"score"`
+	Subscores map[string]int `json:"subscores"`
+	Limiting  []string       `json:"limiting"`
+}
+
+// Score returns the health score of the newest reading of deviceID, or
+// ErrReadingNotFound if it has none.
+func (s *SensorService) Score(ctx context.Context, deviceID string) (*HealthScore, error) {
+	d, err := s.Latest(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrReadingNotFound
+	}
+	score, subscores := d.Sensors.CompositeScore()
+	limiting := []string{}
+	for field, sub := range subscores {
+		if sub == score {
+			limiting = append(limiting, field)
+		}
+	}
+	slices.Sort(limiting)
+	return &HealthScore{
+		DeviceID:  deviceID,
+		ReadingID: d.ID,
+		Timestamp: d.Timestamp,
+		Score:     score,
+		Subscores: subscores,
+		Limiting:  limiting,
+	}, nil
+}
+
+// Export streams the readings matching filter to fn, oldest first.
+func (s *SensorService) Export(ctx context.Context, filter repository.SensorFilter, fn func(*models.SensorData) error) error {
+	return s.repo.Stream(ctx, filter, fn)
+}
+
+func (s *SensorService) Count(ctx context.Context, filter repository.SensorFilter) (int64, error) {
+	return s.repo.Count(ctx, filter)
+}
+
+// MaxUnconfirmedDelete is the largest erasure Delete performs without
+// confirmation.
+const MaxUnconfirmedDelete = 10000
+
+// Delete erases the readings matching filter, e.g. for a data-erasure
+// request. Above MaxUnconfirmedDelete readings nothing is deleted unless
+// confirm is set; ErrConfirmationRequired is returned with the count.
+func (s *SensorService) Delete(ctx context.Context, filter repository.SensorFilter, confirm bool) (int64, error) {
+	if !confirm {
+		n, err := s.repo.Count(ctx, filter)
+		if err != nil {
+			return 0, err
+		}
+		if n > MaxUnconfirmedDelete {
+			return n, ErrConfirmationRequired
+		}
+	}
+	deleted, err := s.repo.Delete(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	// The cached reading may be among the erased ones.
+	s.latest.Delete(filter.DeviceID)
+	log.Printf("sensors: erased %d reading(s) of device %s", deleted, filter.DeviceID)
+	return deleted, nil
+}
+
+var aggregateUnits = map[string]bool{"hour": true, "day": true, "week": true, "month": true}
+
+// Aggregate buckets readings by interval aligned to local time in tz, e.g.
+// "day" buckets start at local midnight. An empty tz means UTC. Bucket
+// times are returned in tz, which is also returned resolved. With weighted,
+// averages weigh values by their confidence; a storage backend that cannot
+// returns ErrUnsupportedQuery.
+func (s *SensorService) Aggregate(ctx context.Context, filter repository.SensorFilter, interval, tz string, weighted bool) ([]models.SensorAggregate, *time.Location, error) {
+	loc, err := aggregateLocation(interval, tz)
+	if err != nil {
+		return nil, nil, err
+	}
+	buckets, err := aggregateIn(ctx, s.repo, filter, interval, loc, weighted)
+	if errors.Is(err, repository.ErrUnsupported) {
+		return nil, nil, ErrUnsupportedQuery
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return buckets, loc, nil
+}
+
+// aggregateLocation checks an aggregation interval and resolves its
+// timezone, UTC if empty.
+func aggregateLocation(interval, tz string) (*time.Location, error) {
+	if !aggregateUnits[interval] {
+		return nil, ErrInvalidInterval
+	}
+	if tz == "" {
+		tz = "UTC"
+	}
+	// "Local" would mean the server's zone, which MongoDB does not know.
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// aggregateIn aggregates the readings of filter into buckets aligned to
+// loc and returns the bucket times in loc.
+func aggregateIn(ctx context.Context, repo repository.SensorDataRepository, filter repository.SensorFilter, interval string, loc *time.Location, weighted bool) ([]models.SensorAggregate, error) {
+	buckets, err := repo.Aggregate(ctx, filter, interval, loc.String(), weighted)
+	if err != nil {
+		return nil, err
+	}
+	for i := range buckets {
+		buckets[i].Bucket = buckets[i].Bucket.In(loc)
+	}
+	return buckets, nil
+}
+
+// Stats summarises fields, every sensor if it is empty, in the readings
+// matching filter and correlates each pair of them. A storage backend that
+// cannot returns ErrUnsupportedQuery, one that cannot for that many
+// readings ErrTooManyStatsReadings.
+func (s *SensorService) Stats(ctx context.Context, filter repository.SensorFilter, fields []string) (*models.SensorStats, error) {
+	if len(fields) == 0 {
+		fields = sensorFields
+	}
+	var unique []string
+	for _, f := range fields {
+		if !slices.Contains(sensorFields, f) {
+			return nil, ErrUnknownField
+		}
+		if !slices.Contains(unique, f) {
+			unique = append(unique, f)
+		}
+	}
+	stats, err := s.repo.Stats(ctx, filter, unique)
+	if errors.Is(err, repository.ErrUnsupported) {
+		return nil, ErrUnsupportedQuery
+	}
+	if errors.Is(err, repository.ErrLimitExceeded) {
+		return nil, ErrTooManyStatsReadings
+	}
+	return stats, err
+}
@@ -0,0 +1,43 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_tags.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the annotation of readings with user-defined tags.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// MergeTags adds tags to the reading id of deviceID, changing the values
+// of the keys it already has and leaving its other tags alone, also those
+// a concurrent merge adds. Invalid tags, or more than utils.MaxSensorTags
+// in total, are refused with utils.ErrInvalidSensorData.
+func (s *SensorService) MergeTags(ctx context.Context, deviceID, id string, tags map[string]string) (*models.SensorData, error) {
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("%w: at least one tag is required", utils.ErrInvalidSensorData)
+	}
+	if err := utils.ValidateSensorTags(tags); err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.MergeTags(ctx, deviceID, id, tags, utils.MaxSensorTags)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return nil, ErrReadingNotFound
+	case errors.Is(err, repository.ErrLimitExceeded):
+		return nil, fmt.Errorf("%w: a reading has at most %d tags", utils.ErrInvalidSensorData, utils.MaxSensorTags)
+	}
+	return updated, err
+}
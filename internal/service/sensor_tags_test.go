@@ -0,0 +1,112 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_tags_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of tagging readings.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+	"airsense-be.com/internal/utils"
+)
+
+// taggedReadings merges tags into the readings it holds by ID, like the
+// repositories do.
+type taggedReadings struct {
+	repository.SensorDataRepository
+	readings map[string]*models.SensorData
+	merges   int
+}
+
+func (r *taggedReadings) MergeTags(_ context.Context, deviceID, id string, tags map[string]string, maxTags int) (*models.SensorData, error) {
+	r.merges++
+	d, ok := r.readings[id]
+	if !ok || d.DeviceID != deviceID {
+		return nil, repository.ErrNotFound
+	}
+	merged := maps.Clone(d.Tags)
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, tags)
+	if len(merged) > maxTags {
+		return nil, repository.ErrLimitExceeded
+	}
+	d.Tags = merged
+	out := *d
+	return &out, nil
+}
+
+func TestSensorMergeTags(t *testing.T) {
+	full := map[string]string{}
+	for i := range utils.MaxSensorTags {
+		full[fmt.Sprintf("k%d", i)] = "v"
+	}
+	tests := []struct {
+		name      string
+		stored    map[string]string
+		readingID string
+		tags      map[string]string
+		want      map[string]string
+		wantErr   error
+		wantMerge bool
+	}{
+		{name: "first tags", readingID: "r1", tags: map[string]string{"event": "cooking"},
+			want: map[string]string{"event": "cooking"}, wantMerge: true},
+		{name: "keeps other tags", stored: map[string]string{"event": "cooking"}, readingID: "r1",
+			tags: map[string]string{"windows": "closed"}, want: map[string]string{"event": "cooking", "windows": "closed"}, wantMerge: true},
+		{name: "overwrites a key", stored: map[string]string{"event": "cooking", "windows": "closed"}, readingID: "r1",
+			tags: map[string]string{"windows": "open"}, want: map[string]string{"event": "cooking", "windows": "open"}, wantMerge: true},
+		{name: "value of 256 characters", readingID: "r1", tags: map[string]string{"note": strings.Repeat("é", 256)},
+			want: map[string]string{"note": strings.Repeat("é", 256)}, wantMerge: true},
+		{name: "value over 256 characters", readingID: "r1", tags: map[string]string{"note": strings.Repeat("a", 257)},
+			wantErr: utils.ErrInvalidSensorData},
+		{name: "empty value", readingID: "r1", tags: map[string]string{"note": ""}, wantErr: utils.ErrInvalidSensorData},
+		{name: "bad key", readingID: "r1", tags: map[string]string{"tags.nested": "x"}, wantErr: utils.ErrInvalidSensorData},
+		{name: "no tags", readingID: "r1", tags: map[string]string{}, wantErr: utils.ErrInvalidSensorData},
+		{name: "one tag too many", stored: full, readingID: "r1", tags: map[string]string{"extra": "x"},
+			wantErr: utils.ErrInvalidSensorData, wantMerge: true},
+		{name: "overwrite at the limit", stored: full, readingID: "r1", tags: map[string]string{"k0": "w"},
+			want: func() map[string]string { m := maps.Clone(full); m["k0"] = "w"; return m }(), wantMerge: true},
+		{name: "unknown reading", readingID: "r9", tags: map[string]string{"event": "cooking"},
+			wantErr: ErrReadingNotFound, wantMerge: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &taggedReadings{readings: map[string]*models.SensorData{
+				"r1": {ID: "r1", DeviceID: "d1", Tags: maps.Clone(tt.stored)},
+			}}
+			s := &SensorService{repo: repo}
+			d, err := s.MergeTags(context.Background(), "d1", tt.readingID, tt.tags)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if (repo.merges > 0) != tt.wantMerge {
+				t.Errorf("merged = %v, want %v", repo.merges > 0, tt.wantMerge)
+			}
+			if err != nil {
+				if got := repo.readings["r1"].Tags; !maps.Equal(got, tt.stored) {
+					t.Errorf("stored tags = %v, want them unchanged %v", got, tt.stored)
+				}
+				return
+			}
+			if !maps.Equal(d.Tags, tt.want) {
+				t.Errorf("tags = %v, want %v", d.Tags, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: sensor_thinning_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of checking thinned readings for alerts.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/alert"
+	"airsense-be.com/internal/cache"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/events"
+	"airsense-be.com/internal/models"
+)
+
+// firedAlerts records the alerts it is notified of.
+type firedAlerts struct {
+	events []alert.Event
+}
+
+func (s *firedAlerts) Notify(_ context.Context, e alert.Event) {
+	s.events = append(s.events, e)
+}
+
+func TestIngestThinnedAlerts(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		minInterval  time.Duration
+		second       time.Duration
+		wantInserted int
+	}{
+		{"thinned", time.Minute, 10 * time.Second, 1},
+		{"after the interval", time.Minute, time.Minute, 2},
+		{"no thinning", 0, 10 * time.Second, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devices := &liveDevices{devices: map[string]models.Device{
+				"d1": {ID: "d1", Status: models.DeviceOnline, MinIntervalMs: tt.minInterval.Milliseconds()},
+			}}
+			repo := &archivingSensors{}
+			fired := &firedAlerts{}
+			alerts := alert.NewEvaluator(config.AlertConfig{Thresholds: map[string]config.Threshold{
+				"pm25": {Warning: 35, Critical: 150},
+			}}, nil, fired)
+			s := NewSensorService(repo, nil, devices, nil, nil, alerts, nil, cache.NewLatestCache(), events.NewHub())
+
+			// A clean reading, then a breach within the interval.
+			for i, at := range []time.Time{t0, t0.Add(tt.second)} {
+				r := reading(at, 10)
+				r.Source = models.SourceManual
+				if i == 1 {
+					r.Sensors.PM25.Value = 200
+				}
+				if err := s.Ingest(context.Background(), &r); err != nil {
+					t.Fatalf("ingest reading %d: %v", i, err)
+				}
+			}
+			if repo.inserted != tt.wantInserted {
+				t.Errorf("stored %d readings, want %d", repo.inserted, tt.wantInserted)
+			}
+			var pm25 []alert.Event
+			for _, e := range fired.events {
+				if e.Field == "pm25" {
+					pm25 = append(pm25, e)
+				}
+			}
+			if len(pm25) != 1 || pm25[0].Severity != alert.SeverityCritical || !pm25[0].At.Equal(t0.Add(tt.second)) {
+				t.Errorf("pm25 alerts = %+v, want one critical at the breach", pm25)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: session_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the revocation of user sessions and its cache for the auth middleware.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/repository"
+)
+
+// sessionCacheSize is the number of users cached before expired entries
+// are swept.
+const sessionCacheSize = 10000
+
+// SessionService ends the sessions of users and tells the auth middleware
+// since when their access tokens are valid. That time is cached per user,
+// so checking a token costs no database round trip; a revocation takes
+// effect at once on this instance and within the cache TTL on others.
+type SessionService struct {
+	users  repository.UserRepository
+	tokens repository.RefreshTokenRepository
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]sessionEntry
+}
+
+type sessionEntry struct {
+	since   time.Time
+	expires time.Time
+}
+
+func NewSessionService(users repository.UserRepository, tokens repository.RefreshTokenRepository, cfg config.JWTConfig) *SessionService {
+	return &SessionService{users: users, tokens: tokens, ttl: cfg.SessionCacheTTL, cache: make(map[string]sessionEntry)}
+}
+
+// ValidSince returns the time before which access tokens of userID are
+// refused, zero if there is none, or ErrUserNotFound.
+func (s *SessionService) ValidSince(ctx context.Context, userID string) (time.Time, error) {
+	now := time.Now()
+	s.mu.Lock()
+	e, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.since, nil
+	}
+
+	u, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return time.Time{}, ErrUserNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.store(userID, u.SessionsValidSince()), nil
+}
+
+// RevokeAll ends every session of userID: its refresh tokens are revoked
+// and access tokens issued until now are refused. API keys are not
+// affected.
+func (s *SessionService) RevokeAll(ctx context.Context, userID string) error {
+	now := time.Now()
+	if err := s.users.RevokeSessions(ctx, userID, now); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	s.store(userID, now)
+	return s.tokens.RevokeUser(ctx, userID)
+}
+
+// store caches since for userID and returns the time now cached. The time
+// only moves forward, so a lookup that raced with a revocation cannot
+// replace the revocation with what it read before.
+func (s *SessionService) store(userID string, since time.Time) time.Time {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.cache[userID]; ok && e.since.After(since) {
+		since = e.since
+	}
+	if len(s.cache) >= sessionCacheSize {
+		for id, e := range s.cache {
+			if !now.Before(e.expires) {
+				delete(s.cache, id)
+			}
+		}
+	}
+	s.cache[userID] = sessionEntry{since: since, expires: now.Add(s.ttl)}
+	return since
+}
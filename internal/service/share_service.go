@@ -0,0 +1,81 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: share_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic for device sharing in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+type ShareService struct {
+	shares repository.ShareRepository
+	users  repository.UserRepository
+}
+
+func NewShareService(shares repository.ShareRepository, users repository.UserRepository) *ShareService {
+	return &ShareService{shares: shares, users: users}
+}
+
+// Create shares device with the user registered under email. The caller
+// must already have checked that ownerID owns the device.
+func (s *ShareService) Create(ctx context.Context, device *models.Device, email string, perm models.SharePermission) (*models.DeviceShare, error) {
+	if !perm.Valid() {
+		return nil, ErrInvalidPermission
+	}
+	grantee, err := s.users.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if grantee.ID == device.UserID {
+		return nil, ErrShareWithOwner
+	}
+	if _, err := s.shares.Get(ctx, device.ID, grantee.ID); err == nil {
+		return nil, ErrShareExists
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	share := &models.DeviceShare{
+		ID:            primitive.NewObjectID().Hex(),
+		DeviceID:      device.ID,
+		OwnerID:       device.UserID,
+		GranteeUserID: grantee.ID,
+		Permission:    perm,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.shares.Create(ctx, share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+func (s *ShareService) List(ctx context.Context, deviceID string) ([]models.DeviceShare, error) {
+	return s.shares.ListByDevice(ctx, deviceID)
+}
+
+func (s *ShareService) Revoke(ctx context.Context, deviceID, shareID string) error {
+	err := s.shares.Delete(ctx, deviceID, shareID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrShareNotFound
+	}
+	return err
+}
@@ -0,0 +1,131 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: silence_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic of alert silences in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// maxSilenceDuration keeps a forgotten silence from muting a device forever.
+const maxSilenceDuration = 30 * 24 * time.Hour
+
+type SilenceService struct {
+	repo repository.SilenceRepository
+}
+
+func NewSilenceService(repo repository.SilenceRepository) *SilenceService {
+	return &SilenceService{repo: repo}
+}
+
+// SilenceSpec is the user-editable part of a silence. A zero StartsAt
+// starts the silence now.
+type SilenceSpec struct {
+	AlertIDs []string
+	StartsAt time.Time
+	EndsAt   time.Time
+	Reason   string
+}
+
+func (s *SilenceService) Create(ctx context.Context, deviceID, userID string, spec SilenceSpec) (*models.Silence, error) {
+	now := time.Now().UTC()
+	silence := &models.Silence{
+		ID:        primitive.NewObjectID().Hex(),
+		DeviceID:  deviceID,
+		CreatedBy: userID,
+		CreatedAt: now,
+	}
+	if err := applySilenceSpec(silence, spec, now); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, silence); err != nil {
+		return nil, err
+	}
+	return silence, nil
+}
+
+func (s *SilenceService) List(ctx context.Context, deviceID string) ([]models.Silence, error) {
+	return s.repo.ListByDevice(ctx, deviceID)
+}
+
+func (s *SilenceService) Get(ctx context.Context, deviceID, id string) (*models.Silence, error) {
+	silence, err := s.repo.GetByID(ctx, deviceID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSilenceNotFound
+	}
+	return silence, err
+}
+
+// Update replaces the spec of a silence, e.g. to end it early.
+func (s *SilenceService) Update(ctx context.Context, deviceID, id string, spec SilenceSpec) (*models.Silence, error) {
+	silence, err := s.Get(ctx, deviceID, id)
+	if err != nil {
+		return nil, err
+	}
+	if spec.StartsAt.IsZero() {
+		spec.StartsAt = silence.StartsAt
+	}
+	if err := applySilenceSpec(silence, spec, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	err = s.repo.Replace(ctx, silence)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSilenceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return silence, nil
+}
+
+func (s *SilenceService) Delete(ctx context.Context, deviceID, id string) error {
+	err := s.repo.Delete(ctx, deviceID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrSilenceNotFound
+	}
+	return err
+}
+
+func applySilenceSpec(silence *models.Silence, spec SilenceSpec, now time.Time) error {
+	if spec.StartsAt.IsZero() {
+		spec.StartsAt = now
+	}
+	if !spec.EndsAt.After(spec.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidSilence)
+	}
+	if spec.EndsAt.Sub(spec.StartsAt) > maxSilenceDuration {
+		return fmt.Errorf("%w: a silence lasts at most %s", ErrInvalidSilence, maxSilenceDuration)
+	}
+	ids := []string{}
+	for _, id := range spec.AlertIDs {
+		if !slices.Contains(sensorFields, id) {
+			return fmt.Errorf("%w: unknown alert %q, use one of %s", ErrInvalidSilence, id, strings.Join(sensorFields, ", "))
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	silence.AlertIDs = ids
+	silence.StartsAt = spec.StartsAt.UTC()
+	silence.EndsAt = spec.EndsAt.UTC()
+	silence.Reason = strings.TrimSpace(spec.Reason)
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: smoothing.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the moving average smoothing of sensor time series.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+const (
+	MinSmoothingWindow = 3
+	MaxSmoothingWindow = 51
+	// maxSmoothedReadings bounds the raw readings loaded for one request.
+	maxSmoothedReadings = 10000
+)
+
+// SmoothRequest selects the readings of DeviceID between From and To and
+// the Fields to smooth over Window readings.
+type SmoothRequest struct {
+	DeviceID string
+	From     time.Time
+	To       time.Time
+	Window   int
+	Fields   []string
+}
+
+// SmoothedPoint is the average of a window, stamped with the timestamp of
+// the reading at its centre.
+type SmoothedPoint struct {
+	Timestamp time.Time          `json:"timestamp"`
+	Values    map[string]float64 `json:"values"`
+}
+
+// Smooth applies a simple moving average to the requested fields. Only
+// full windows are averaged, so n readings give n-window+1 points.
+func (s *SensorService) Smooth(ctx context.Context, req SmoothRequest) ([]SmoothedPoint, error) {
+	if req.Window < MinSmoothingWindow || req.Window > MaxSmoothingWindow || req.Window%2 == 0 {
+		return nil, ErrInvalidWindow
+	}
+	if len(req.Fields) == 0 {
+		return nil, ErrUnknownField
+	}
+	for _, f := range req.Fields {
+		if !slices.Contains(sensorFields, f) {
+			return nil, ErrUnknownField
+		}
+	}
+
+	readings, err := s.repo.Find(ctx, repository.SensorFilter{
+		DeviceID: req.DeviceID,
+		From:     req.From,
+		To:       req.To,
+		Limit:    maxSmoothedReadings + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(readings) > maxSmoothedReadings {
+		return nil, ErrTooManyReadings
+	}
+	// Find returns newest first.
+	slices.Reverse(readings)
+
+	points := make([]SmoothedPoint, max(len(readings)-req.Window+1, 0))
+	for i := range points {
+		points[i] = SmoothedPoint{
+			Timestamp: readings[i+req.Window/2].Timestamp,
+			Values:    make(map[string]float64, len(req.Fields)),
+		}
+	}
+	series := make(map[string][]float64, len(req.Fields))
+	for _, d := range readings {
+		values := d.Sensors.Fields()
+		for _, f := range req.Fields {
+			series[f] = append(series[f], values[f].Value)
+		}
+	}
+	for f, values := range series {
+		for i, v := range movingAverage(values, req.Window) {
+			points[i].Values[f] = v
+		}
+	}
+	return points, nil
+}
+
+// sensorFields are the names accepted by Sensors.Fields.
+var sensorFields = []string{"pm25", "co2", "co", "temperature", "humidity"}
+
+// movingAverage returns the mean of every run of window consecutive values,
+// len(values)-window+1 of them, keeping a running sum.
+func movingAverage(values []float64, window int) []float64 {
+	if len(values) < window {
+		return nil
+	}
+	out := make([]float64, 0, len(values)-window+1)
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= window {
+			sum -= values[i-window]
+		}
+		if i >= window-1 {
+			out = append(out, sum/float64(window))
+		}
+	}
+	return out
+}
+
+// SmoothBuckets replaces the average of each field of buckets with the
+// mean of the averages of the n buckets centred on it, shrinking the
+// window to the buckets there are near the ends. It works on the buckets
+// as returned, so a bucket without readings, which is left out, does not
+// count, and neither does one whose field has no average: the mean is of
+// the averages there are. Minimums and maximums are kept. n must be from 2
+// to the number of buckets.
+func SmoothBuckets(buckets []models.SensorAggregate, n int) error {
+	if len(buckets) == 0 {
+		return nil
+	}
+	if n < 2 || n > len(buckets) {
+		return fmt.Errorf("%w: smooth must be from 2 to the %d buckets returned", ErrInvalidSmoothing, len(buckets))
+	}
+	avgs := make([]*float64, len(buckets))
+	for f := range buckets[0].Fields {
+		for i, b := range buckets {
+			avgs[i] = b.Fields[f].Avg
+		}
+		for i, v := range centredAverage(avgs, n) {
+			s := buckets[i].Fields[f]
+			if s.Avg == nil {
+				continue
+			}
+			s.Avg = &v
+			buckets[i].Fields[f] = s
+		}
+	}
+	return nil
+}
+
+// centredAverage returns the mean of the window values around each value,
+// from (window-1)/2 before to window/2 after it, or fewer near the ends.
+// Nil values are left out of the means; a window of nil values only has a
+// mean of 0.
+func centredAverage(values []*float64, window int) []float64 {
+	sums := make([]float64, len(values)+1)
+	counts := make([]int, len(values)+1)
+	for i, v := range values {
+		sums[i+1], counts[i+1] = sums[i], counts[i]
+		if v != nil {
+			sums[i+1] += *v
+			counts[i+1]++
+		}
+	}
+	out := make([]float64, len(values))
+	for i := range values {
+		lo, hi := max(i-(window-1)/2, 0), min(i+window/2+1, len(values))
+		if n := counts[hi] - counts[lo]; n > 0 {
+			out[i] = (sums[hi] - sums[lo]) / float64(n)
+		}
+	}
+	return out
+}
@@ -0,0 +1,160 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: smoothing_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the moving average smoothing of sensor series.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/models"
+)
+
+func TestMovingAverage(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		window int
+		want   []float64
+	}{
+		{name: "constant", values: []float64{4, 4, 4, 4}, window: 3, want: []float64{4, 4}},
+		{name: "ramp", values: []float64{1, 2, 3, 4, 5}, window: 3, want: []float64{2, 3, 4}},
+		{name: "spike", values: []float64{10, 10, 100, 10, 10}, window: 5, want: []float64{28}},
+		{name: "window of all values", values: []float64{1, 2, 6}, window: 3, want: []float64{3}},
+		{name: "fewer values than window", values: []float64{1, 2}, window: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := movingAverage(tt.values, tt.window)
+			if len(got) != len(tt.want) {
+				t.Fatalf("movingAverage() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("movingAverage()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSmooth(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	var readings []models.SensorData
+	for i := range 10 {
+		readings = append(readings, reading(t0.Add(time.Duration(i)*time.Minute), float64(i)))
+	}
+	tests := []struct {
+		name    string
+		n       int
+		window  int
+		fields  []string
+		wantLen int
+		wantErr error
+	}{
+		{name: "window 3", n: 10, window: 3, fields: []string{"pm25"}, wantLen: 8},
+		{name: "window 5", n: 10, window: 5, fields: []string{"pm25", "co2"}, wantLen: 6},
+		{name: "window of all readings", n: 3, window: 3, fields: []string{"pm25"}, wantLen: 1},
+		{name: "fewer readings than window", n: 2, window: 3, fields: []string{"pm25"}, wantLen: 0},
+		{name: "even window", n: 10, window: 4, fields: []string{"pm25"}, wantErr: ErrInvalidWindow},
+		{name: "window below 3", n: 10, window: 1, fields: []string{"pm25"}, wantErr: ErrInvalidWindow},
+		{name: "window above 51", n: 10, window: 53, fields: []string{"pm25"}, wantErr: ErrInvalidWindow},
+		{name: "negative window", n: 10, window: -3, fields: []string{"pm25"}, wantErr: ErrInvalidWindow},
+		{name: "unknown field", n: 10, window: 3, fields: []string{"ozone"}, wantErr: ErrUnknownField},
+		{name: "no fields", n: 10, window: 3, wantErr: ErrUnknownField},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SensorService{repo: &seriesRepo{readings: readings[:tt.n]}}
+			points, err := s.Smooth(context.Background(), SmoothRequest{DeviceID: "d1", Window: tt.window, Fields: tt.fields})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Smooth() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(points) != tt.wantLen {
+				t.Fatalf("Smooth() returned %d points, want %d", len(points), tt.wantLen)
+			}
+			for i, p := range points {
+				// The readings ramp up by 1, so every window averages to
+				// the reading at its centre.
+				centre := i + tt.window/2
+				if !p.Timestamp.Equal(readings[centre].Timestamp) {
+					t.Errorf("point %d at %v, want %v", i, p.Timestamp, readings[centre].Timestamp)
+				}
+				if got := p.Values["pm25"]; math.Abs(got-float64(centre)) > 1e-9 {
+					t.Errorf("point %d pm25 = %v, want %v", i, got, float64(centre))
+				}
+				if len(p.Values) != len(tt.fields) {
+					t.Errorf("point %d values = %v, want fields %v", i, p.Values, tt.fields)
+				}
+			}
+		})
+	}
+}
+
+func TestSmoothBuckets(t *testing.T) {
+	tests := []struct {
+		name    string
+		avgs    []*float64
+		n       int
+		want    []*float64
+		wantErr error
+	}{
+		{"ramp", []*float64{ptrF(1), ptrF(2), ptrF(3), ptrF(4)}, 3,
+			[]*float64{ptrF(1.5), ptrF(2), ptrF(3), ptrF(3.5)}, nil},
+		{"even window", []*float64{ptrF(2), ptrF(4), ptrF(6)}, 2,
+			[]*float64{ptrF(3), ptrF(5), ptrF(6)}, nil},
+		{"gap is skipped, not zero", []*float64{ptrF(10), nil, ptrF(20), ptrF(30)}, 3,
+			[]*float64{ptrF(10), nil, ptrF(25), ptrF(25)}, nil},
+		{"gap at the start", []*float64{nil, nil, ptrF(6), ptrF(8)}, 3,
+			[]*float64{nil, nil, ptrF(7), ptrF(7)}, nil},
+		{"no averages", []*float64{nil, nil, nil}, 3, []*float64{nil, nil, nil}, nil},
+		{"window of one", []*float64{ptrF(1), ptrF(2)}, 1, nil, ErrInvalidSmoothing},
+		{"window past the buckets", []*float64{ptrF(1), ptrF(2)}, 3, nil, ErrInvalidSmoothing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets := make([]models.SensorAggregate, len(tt.avgs))
+			for i, avg := range tt.avgs {
+				buckets[i].Fields = map[string]models.AggregateStats{
+					"pm25": {Avg: avg, Min: avg, Max: avg},
+				}
+			}
+			err := SmoothBuckets(buckets, tt.n)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SmoothBuckets() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			for i, b := range buckets {
+				got, want := b.Fields["pm25"], tt.want[i]
+				if (got.Avg == nil) != (want == nil) || (want != nil && math.Abs(*got.Avg-*want) > 1e-9) {
+					t.Errorf("bucket %d avg = %v, want %v", i, derefF(got.Avg), derefF(want))
+				}
+				if got.Min != tt.avgs[i] || got.Max != tt.avgs[i] {
+					t.Errorf("bucket %d min and max changed", i)
+				}
+			}
+		})
+	}
+}
+
+func ptrF(f float64) *float64 { return &f }
+
+func derefF(f *float64) any {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
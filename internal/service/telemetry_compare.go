@@ -0,0 +1,117 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: telemetry_compare.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the side-by-side aggregation of the readings of several devices.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// MaxCompareDevices bounds the devices of one comparison.
+const MaxCompareDevices = 10
+
+// Comparison aligns the aggregates of several devices on one time axis:
+// Points[i] of every series belongs to Buckets[i].
+type Comparison struct {
+	Buckets  []time.Time        `json:"buckets"`
+	Series   []ComparisonSeries `json:"series"`
+	Timezone string             `json:"timezone"`
+}
+
+// ComparisonSeries is the aggregates of one device. A point is nil for a
+// bucket in which the device has no readings.
+type ComparisonSeries struct {
+	DeviceID string          `json:"device_id"`
+	Name     string          `json:"name"`
+	Points   []*ComparePoint `json:"points"`
+}
+
+type ComparePoint struct {
+	Count  int64                            `json:"count"`
+	Fields map[string]models.AggregateStats `json:"fields"`
+}
+
+// Compare aggregates the readings of every device of deviceIDs between
+// from and to into interval buckets in tz, as for SensorService.Aggregate,
+// and aligns them on the buckets of any device. userID must be able to
+// read every device; duplicate IDs are compared once.
+func (s *DashboardService) Compare(ctx context.Context, userID string, deviceIDs []string, from, to time.Time, interval, tz string) (*Comparison, error) {
+	var ids []string
+	for _, id := range deviceIDs {
+		if id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: devices is required", ErrInvalidComparison)
+	}
+	if len(ids) > MaxCompareDevices {
+		return nil, fmt.Errorf("%w: at most %d devices can be compared", ErrInvalidComparison, MaxCompareDevices)
+	}
+	loc, err := aggregateLocation(interval, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]ComparisonSeries, len(ids))
+	for i, id := range ids {
+		d, err := s.devices.Authorize(ctx, id, userID, AccessRead, false)
+		if err != nil {
+			return nil, fmt.Errorf("device %s: %w", id, err)
+		}
+		series[i] = ComparisonSeries{DeviceID: d.ID, Name: d.Name}
+	}
+
+	aggregates := make([][]models.SensorAggregate, len(ids))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(latestFetchConcurrency)
+	for i, id := range ids {
+		g.Go(func() error {
+			filter := repository.SensorFilter{DeviceID: id, From: from, To: to}
+			var err error
+			aggregates[i], err = aggregateIn(gctx, s.sensors, filter, interval, loc, false)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var buckets []time.Time
+	for _, aggs := range aggregates {
+		for _, a := range aggs {
+			buckets = append(buckets, a.Bucket)
+		}
+	}
+	slices.SortFunc(buckets, time.Time.Compare)
+	buckets = slices.CompactFunc(buckets, time.Time.Equal)
+
+	for i, aggs := range aggregates {
+		points := make([]*ComparePoint, len(buckets))
+		for _, a := range aggs {
+			j, _ := slices.BinarySearchFunc(buckets, a.Bucket, time.Time.Compare)
+			points[j] = &ComparePoint{Count: a.Count, Fields: a.Fields}
+		}
+		series[i].Points = points
+	}
+	if buckets == nil {
+		buckets = []time.Time{}
+	}
+	return &Comparison{Buckets: buckets, Series: series, Timezone: loc.String()}, nil
+}
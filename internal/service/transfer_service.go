@@ -0,0 +1,248 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: transfer_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic for device ownership transfers in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/auth"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+const transferTTL = 7 * 24 * time.Hour
+
+type TransferService struct {
+	transfers repository.TransferRepository
+	devices   repository.DeviceRepository
+	users     repository.UserRepository
+	sensors   repository.SensorDataRepository
+	shares    repository.ShareRepository
+	quota     *QuotaService
+	commands  *CommandService
+	schedules *CommandScheduleService
+}
+
+func NewTransferService(transfers repository.TransferRepository, devices repository.DeviceRepository,
+	users repository.UserRepository, sensors repository.SensorDataRepository, shares repository.ShareRepository,
+	quota *QuotaService, commands *CommandService, schedules *CommandScheduleService) *TransferService {
+	return &TransferService{transfers: transfers, devices: devices, users: users, sensors: sensors, shares: shares, quota: quota,
+		commands: commands, schedules: schedules}
+}
+
+// Initiate starts a transfer of deviceID from ownerID to the user registered
+// under toEmail. The returned token is the only way to accept it.
+func (s *TransferService) Initiate(ctx context.Context, deviceID, ownerID, toEmail string, keepHistory bool) (*models.DeviceTransfer, string, error) {
+	if err := s.checkOwner(ctx, deviceID, ownerID); err != nil {
+		return nil, "", err
+	}
+
+	owner, err := s.users.GetByID(ctx, ownerID)
+	if err != nil {
+		return nil, "", err
+	}
+	toEmail = strings.ToLower(strings.TrimSpace(toEmail))
+	if toEmail == owner.Email {
+		return nil, "", ErrTransferToSelf
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	t := &models.DeviceTransfer{
+		ID:          primitive.NewObjectID().Hex(),
+		DeviceID:    deviceID,
+		FromUserID:  ownerID,
+		ToEmail:     toEmail,
+		KeepHistory: keepHistory,
+		TokenHash:   auth.HashToken(token),
+		Status:      models.TransferPending,
+		ExpiresAt:   now.Add(transferTTL),
+		CreatedAt:   now,
+	}
+	if err := s.transfers.Create(ctx, t); err != nil {
+		return nil, "", err
+	}
+	audit.Record(ctx, audit.ActionTransferOffer, audit.ResourceDevice, deviceID, models.AuditDiff{
+		"transfer_id": {To: t.ID},
+		"to_email":    {To: toEmail},
+	})
+	return t, token, nil
+}
+
+// Accept completes the transfer identified by token on behalf of userID,
+// who must be the user the transfer was addressed to.
+func (s *TransferService) Accept(ctx context.Context, token, userID string) (*models.DeviceTransfer, error) {
+	t, err := s.transfers.GetByTokenHash(ctx, auth.HashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != models.TransferPending {
+		return nil, ErrTransferInvalid
+	}
+	if time.Now().After(t.ExpiresAt) {
+		return nil, ErrTransferExpired
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email != t.ToEmail {
+		return nil, ErrForbidden
+	}
+
+	if err := s.complete(ctx, t, userID); err != nil {
+		return nil, err
+	}
+	t.Status = models.TransferAccepted
+	t.ToUserID = userID
+	return t, nil
+}
+
+// Transfer moves deviceID from ownerID to the user toUserID at once, with
+// no acceptance, and returns the accepted transfer, which is kept as its
+// audit record. Historical readings stay with the device unless
+// keepHistory is false.
+func (s *TransferService) Transfer(ctx context.Context, deviceID, ownerID, toUserID string, keepHistory bool) (*models.DeviceTransfer, error) {
+	if err := s.checkOwner(ctx, deviceID, ownerID); err != nil {
+		return nil, err
+	}
+	if toUserID == ownerID {
+		return nil, ErrTransferToSelf
+	}
+	to, err := s.users.GetByID(ctx, toUserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The record needs a token hash for its unique index; the token is
+	// never handed out.
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	t := &models.DeviceTransfer{
+		ID:          primitive.NewObjectID().Hex(),
+		DeviceID:    deviceID,
+		FromUserID:  ownerID,
+		ToEmail:     to.Email,
+		KeepHistory: keepHistory,
+		TokenHash:   auth.HashToken(token),
+		Status:      models.TransferPending,
+		ExpiresAt:   now.Add(transferTTL),
+		CreatedAt:   now,
+	}
+	if err := s.transfers.Create(ctx, t); err != nil {
+		return nil, err
+	}
+	if err := s.complete(ctx, t, toUserID); err != nil {
+		return nil, err
+	}
+	acceptedAt := time.Now()
+	t.Status = models.TransferAccepted
+	t.ToUserID = toUserID
+	t.AcceptedAt = &acceptedAt
+	return t, nil
+}
+
+// checkOwner verifies that ownerID owns deviceID, which must not be
+// deleted.
+func (s *TransferService) checkOwner(ctx context.Context, deviceID, ownerID string) error {
+	d, err := s.devices.GetByID(ctx, deviceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrDeviceNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if d.DeletedAt != nil {
+		return ErrDeviceNotFound
+	}
+	if d.UserID != ownerID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// complete moves the device of the pending transfer t to userID and marks
+// t accepted.
+func (s *TransferService) complete(ctx context.Context, t *models.DeviceTransfer, userID string) error {
+	// The device takes a slot of the new owner's quota and frees one of
+	// the previous owner's.
+	if err := s.quota.Reserve(ctx, userID, 1); err != nil {
+		return err
+	}
+	// The conditional owner change is what serialises concurrent accepts:
+	// only one can move the device away from the initiating owner.
+	moved, err := s.devices.ChangeOwner(ctx, t.DeviceID, t.FromUserID, userID)
+	if err != nil || !moved {
+		if relErr := s.quota.Release(ctx, userID, 1); relErr != nil {
+			log.Printf("quota: release device of user %s: %v", userID, relErr)
+		}
+		if err != nil {
+			return err
+		}
+		return ErrTransferInvalid
+	}
+	if err := s.quota.Release(ctx, t.FromUserID, 1); err != nil {
+		log.Printf("quota: release device of user %s: %v", t.FromUserID, err)
+	}
+	if _, err := s.transfers.MarkAccepted(ctx, t.ID, userID); err != nil {
+		return err
+	}
+
+	// Shares were granted by the previous owner and do not carry over.
+	if err := s.shares.DeleteByDevice(ctx, t.DeviceID); err != nil {
+		return err
+	}
+	// So are the commands still to run and the schedules sending more.
+	if _, err := s.commands.cancelPending(ctx, t.DeviceID, models.DetailTransferred); err != nil {
+		return err
+	}
+	if err := s.schedules.DeleteByDevice(ctx, t.DeviceID); err != nil {
+		return err
+	}
+	if t.KeepHistory {
+		// The readings so far were taken for the previous owner.
+		err := s.sensors.TagPriorOwner(ctx, t.DeviceID, t.FromUserID, time.Now())
+		if err != nil && !errors.Is(err, repository.ErrUnsupported) {
+			return err
+		}
+	} else if err := s.sensors.Detach(ctx, t.DeviceID, t.ID); err != nil {
+		return err
+	}
+
+	log.Printf("transfer %s: device %s moved from user %s to user %s", t.ID, t.DeviceID, t.FromUserID, userID)
+	audit.Record(ctx, audit.ActionTransfer, audit.ResourceDevice, t.DeviceID, models.AuditDiff{
+		"user_id":      {From: t.FromUserID, To: userID},
+		"transfer_id":  {To: t.ID},
+		"keep_history": {To: t.KeepHistory},
+	})
+	return nil
+}
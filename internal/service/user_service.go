@@ -0,0 +1,216 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: user_service.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the business logic for users in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"airsense-be.com/internal/audit"
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// maxNameLength bounds the display name of a user, in characters.
+const maxNameLength = 100
+
+// PasswordChangedMailer tells users their password was changed, see
+// notifications.PasswordChangedMailer.
+type PasswordChangedMailer interface {
+	SendPasswordChanged(to string, at time.Time)
+}
+
+// AddressVerifier asks users to confirm a changed email address, see
+// RegistrationService.Reverify.
+type AddressVerifier interface {
+	Reverify(ctx context.Context, u *models.User) error
+}
+
+type UserService struct {
+	repo     repository.UserRepository
+	sessions *SessionService
+	resets   repository.PasswordResetRepository
+	verifier AddressVerifier
+	mailer   PasswordChangedMailer
+	cost     int
+}
+
+// NewUserService returns the service; without a mailer password changes
+// are not notified.
+func NewUserService(repo repository.UserRepository, sessions *SessionService, resets repository.PasswordResetRepository,
+	verifier AddressVerifier, mailer PasswordChangedMailer, cfg config.JWTConfig) *UserService {
+	return &UserService{repo: repo, sessions: sessions, resets: resets, verifier: verifier, mailer: mailer, cost: cfg.BcryptCost}
+}
+
+// ProfileUpdate holds the fields of a profile to change; nil ones are kept.
+// CurrentPassword is needed to change Email.
+type ProfileUpdate struct {
+	Email           *string
+	Name            *string
+	CurrentPassword string
+}
+
+// Authenticate checks an email/password pair against the stored bcrypt hash.
+func (s *UserService) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	u, err := s.repo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return u, nil
+}
+
+// Profile returns the user userID.
+func (s *UserService) Profile(ctx context.Context, userID string) (*models.User, error) {
+	u, err := s.repo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return u, err
+}
+
+// UpdateProfile changes the email and name of userID and reports whether
+// it was asked to change the email address. That takes CurrentPassword,
+// else ErrWrongPassword is returned, and leaves the user unverified until
+// they confirm the new address with the link mailed to it. It ends every
+// session of the user, this one included, and drops their password reset
+// and verification tokens. An address of another user is not taken over,
+// but it is answered the same, so callers cannot tell which are
+// registered.
+func (s *UserService) UpdateProfile(ctx context.Context, userID string, upd ProfileUpdate) (*models.User, bool, error) {
+	before, err := s.Profile(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if upd.Email != nil {
+		email, err := normalizeEmail(*upd.Email)
+		if err != nil {
+			return nil, false, err
+		}
+		if email == before.Email {
+			upd.Email = nil
+		} else {
+			upd.Email = &email
+		}
+	}
+	if upd.Email != nil {
+		if upd.CurrentPassword == "" {
+			return nil, false, fmt.Errorf("%w: current_password is required to change the email address", ErrInvalidProfile)
+		}
+		if bcrypt.CompareHashAndPassword([]byte(before.Password), []byte(upd.CurrentPassword)) != nil {
+			return nil, false, ErrWrongPassword
+		}
+	}
+	if upd.Name != nil {
+		name := strings.TrimSpace(*upd.Name)
+		if utf8.RuneCountInString(name) > maxNameLength {
+			return nil, false, fmt.Errorf("%w: name must be at most %d characters", ErrInvalidProfile, maxNameLength)
+		}
+		upd.Name = &name
+	}
+
+	after, err := s.repo.UpdateProfile(ctx, userID, upd.Email, upd.Name)
+	taken := errors.Is(err, repository.ErrDuplicate)
+	if taken {
+		after, err = s.repo.UpdateProfile(ctx, userID, nil, upd.Name)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, false, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if diff := audit.Changes(before, after); len(diff) > 0 {
+		audit.Record(ctx, audit.ActionUserUpdate, audit.ResourceUser, userID, diff)
+	}
+	if upd.Email == nil {
+		return after, false, nil
+	}
+	if taken {
+		log.Printf("auth: user %s asked for an email address in use", userID)
+	} else {
+		log.Printf("auth: user %s changed their email address, ending their sessions", userID)
+	}
+	if err := s.sessions.RevokeAll(ctx, userID); err != nil {
+		return nil, false, err
+	}
+	if err := s.resets.DeleteByUser(ctx, userID); err != nil {
+		return nil, false, err
+	}
+	if !taken && s.verifier != nil {
+		if err := s.verifier.Reverify(ctx, after); err != nil {
+			return nil, false, err
+		}
+	}
+	return after, true, nil
+}
+
+// ChangePassword replaces the password of userID after checking current,
+// and mails the user about it. Like a reset it ends every session of the
+// user, this one included; the caller issues the current session new
+// tokens. current is compared before anything else is looked at, so the
+// time taken does not tell whether it or the new password was refused.
+func (s *UserService) ChangePassword(ctx context.Context, userID, current, password string) error {
+	u, err := s.Profile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(current)) != nil {
+		return ErrWrongPassword
+	}
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := s.repo.SetPassword(ctx, userID, string(hash), now); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	audit.Record(ctx, audit.ActionUserPasswordChange, audit.ResourceUser, userID, nil)
+	if err := s.sessions.RevokeAll(ctx, userID); err != nil {
+		return err
+	}
+	if s.mailer != nil {
+		s.mailer.SendPasswordChanged(u.Email, now)
+	}
+	return nil
+}
+
+// normalizeEmail lowercases and trims email, returning ErrInvalidEmail
+// unless it is a bare address.
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
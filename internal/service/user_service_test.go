@@ -0,0 +1,170 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: user_service_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of updating user profiles.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// profileUsers adds the profile updates to regUsers.
+type profileUsers struct {
+	*regUsers
+}
+
+func (r profileUsers) GetByID(_ context.Context, id string) (*models.User, error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (r profileUsers) UpdateProfile(ctx context.Context, userID string, email, name *string) (*models.User, error) {
+	u, ok := r.users[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if email != nil {
+		if other, err := r.GetByEmail(ctx, *email); err == nil && other.ID != userID {
+			return nil, repository.ErrDuplicate
+		}
+		u.Email, u.Unverified, u.VerifiedAt = *email, true, nil
+	}
+	if name != nil {
+		u.Name = *name
+	}
+	return r.GetByID(ctx, userID)
+}
+
+type profileResets struct {
+	repository.PasswordResetRepository
+	log *[]string
+}
+
+func (r *profileResets) DeleteByUser(_ context.Context, userID string) error {
+	*r.log = append(*r.log, "delete resets "+userID)
+	return nil
+}
+
+type profileVerifier struct{ log *[]string }
+
+func (v *profileVerifier) Reverify(_ context.Context, u *models.User) error {
+	*v.log = append(*v.log, "verify "+u.Email)
+	return nil
+}
+
+func TestUpdateProfile(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+	tests := []struct {
+		name        string
+		upd         ProfileUpdate
+		wantErr     error
+		wantChanged bool
+		wantEmail   string
+		wantName    string
+		wantLog     []string
+	}{
+		{
+			name:      "name only",
+			upd:       ProfileUpdate{Name: ptr(" Ann ")},
+			wantEmail: "a@example.com",
+			wantName:  "Ann",
+		},
+		{
+			name:      "same email needs no password",
+			upd:       ProfileUpdate{Email: ptr("A@example.com")},
+			wantEmail: "a@example.com",
+		},
+		{
+			name:        "new email",
+			upd:         ProfileUpdate{Email: ptr("new@example.com"), CurrentPassword: "password-1"},
+			wantChanged: true,
+			wantEmail:   "new@example.com",
+			wantLog:     []string{"revoke sessions u1", "revoke refresh tokens u1", "delete resets u1", "verify new@example.com"},
+		},
+		{
+			// Answered like a free address, but nothing is taken over.
+			name:        "email in use",
+			upd:         ProfileUpdate{Email: ptr("b@example.com"), Name: ptr("Ann"), CurrentPassword: "password-1"},
+			wantChanged: true,
+			wantEmail:   "a@example.com",
+			wantName:    "Ann",
+			wantLog:     []string{"revoke sessions u1", "revoke refresh tokens u1", "delete resets u1"},
+		},
+		{
+			name:      "wrong current password",
+			upd:       ProfileUpdate{Email: ptr("new@example.com"), CurrentPassword: "password-2"},
+			wantErr:   ErrWrongPassword,
+			wantEmail: "a@example.com",
+		},
+		{
+			name:      "no current password",
+			upd:       ProfileUpdate{Email: ptr("new@example.com")},
+			wantErr:   ErrInvalidProfile,
+			wantEmail: "a@example.com",
+		},
+		{
+			name:      "invalid email",
+			upd:       ProfileUpdate{Email: ptr("nobody"), CurrentPassword: "password-1"},
+			wantErr:   ErrInvalidEmail,
+			wantEmail: "a@example.com",
+		},
+	}
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password-1"), bcrypt.MinCost)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &[]string{}
+			verifiedAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+			users := profileUsers{&regUsers{log: log, users: map[string]*models.User{
+				"u1": {ID: "u1", Email: "a@example.com", Password: string(hash), VerifiedAt: &verifiedAt},
+				"u2": {ID: "u2", Email: "b@example.com"},
+			}}}
+			cfg := config.JWTConfig{BcryptCost: bcrypt.MinCost}
+			s := NewUserService(users, NewSessionService(users, &regTokens{log: log}, cfg), &profileResets{log: log},
+				&profileVerifier{log: log}, nil, cfg)
+			u, changed, err := s.UpdateProfile(context.Background(), "u1", tt.upd)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateProfile() error = %v, want %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("email changed = %v, want %v", changed, tt.wantChanged)
+			}
+			stored := users.users["u1"]
+			if stored.Email != tt.wantEmail || stored.Name != tt.wantName {
+				t.Errorf("stored %q %q, want %q %q", stored.Email, stored.Name, tt.wantEmail, tt.wantName)
+			}
+			if err == nil && (u.Email != stored.Email || u.Name != stored.Name) {
+				t.Errorf("returned %q %q, stored %q %q", u.Email, u.Name, stored.Email, stored.Name)
+			}
+			if moved := stored.Email != "a@example.com"; stored.Unverified != moved || (stored.VerifiedAt == nil) != moved {
+				t.Errorf("unverified %v, verified at %v after moving to %s", stored.Unverified, stored.VerifiedAt, stored.Email)
+			}
+			if users.users["u2"].Email != "b@example.com" {
+				t.Error("the other user lost their address")
+			}
+			if !slices.Equal(*log, tt.wantLog) {
+				t.Errorf("calls = %q, want %q", *log, tt.wantLog)
+			}
+		})
+	}
+}
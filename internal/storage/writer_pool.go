@@ -0,0 +1,205 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: writer_pool.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the pool of workers writing sensor data in batches.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+// Package storage batches the writes of sensor data, which one insert per
+// reading cannot keep up with under heavy MQTT ingest.
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/metrics"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// ErrWriterClosed is returned by Write once Shutdown has been called.
+var ErrWriterClosed = errors.New("sensor writer is shut down")
+
+// WriterPool collects readings in a bounded queue from which WorkerCount
+// workers insert them with InsertMany, in batches of up to BatchSize that
+// are flushed at the latest FlushInterval after their first reading.
+type WriterPool struct {
+	repo          repository.SensorDataRepository
+	queue         chan pendingWrite
+	workers       int
+	batchSize     int
+	flushInterval time.Duration
+
+	// ctx is the context of the inserts, cancelled when Shutdown gives up
+	// waiting for them.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+	// closing is closed by Shutdown to wake writers waiting for room.
+	closing chan struct{}
+	// writers counts the Write calls that may still send to queue, which
+	// Shutdown closes only once they are done.
+	writers sync.WaitGroup
+	wg      sync.WaitGroup
+}
+
+// pendingWrite is a queued reading and the function to call once it is
+// stored.
+type pendingWrite struct {
+	data   *models.SensorData
+	stored func()
+}
+
+func NewWriterPool(repo repository.SensorDataRepository, cfg config.StorageConfig) *WriterPool {
+	workers := max(cfg.WorkerCount, 1)
+	batchSize := max(cfg.BatchSize, 1)
+	size := max(cfg.QueueSize, 1)
+	interval := cfg.FlushInterval
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WriterPool{
+		repo:          repo,
+		queue:         make(chan pendingWrite, size),
+		workers:       workers,
+		batchSize:     batchSize,
+		flushInterval: interval,
+		ctx:           ctx,
+		cancel:        cancel,
+		closing:       make(chan struct{}),
+	}
+}
+
+func (p *WriterPool) Start() {
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.run()
+	}
+}
+
+// Write queues data for insertion and gives it an ID if it has none, so
+// it can be referred to before it is stored. stored, if not nil, is called
+// from a worker once the reading is inserted, and never if the insert
+// fails. While the queue is full Write blocks until a worker makes room,
+// ctx is done or the pool shuts down.
+func (p *WriterPool) Write(ctx context.Context, data *models.SensorData, stored func()) error {
+	if data.ID == "" {
+		data.ID = primitive.NewObjectID().Hex()
+	}
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return ErrWriterClosed
+	}
+	p.writers.Add(1)
+	p.mu.RUnlock()
+	defer p.writers.Done()
+
+	select {
+	case p.queue <- pendingWrite{data: data, stored: stored}:
+		metrics.SensorWriteQueueDepth.Set(int64(len(p.queue)))
+		return nil
+	case <-p.closing:
+		return ErrWriterClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *WriterPool) run() {
+	defer p.wg.Done()
+	batch := make([]pendingWrite, 0, p.batchSize)
+	timer := time.NewTimer(p.flushInterval)
+	timer.Stop()
+	for {
+		select {
+		case w, ok := <-p.queue:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+			metrics.SensorWriteQueueDepth.Set(int64(len(p.queue)))
+			batch = append(batch, w)
+			if len(batch) == 1 {
+				timer.Reset(p.flushInterval)
+			}
+			if len(batch) >= p.batchSize {
+				timer.Stop()
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-timer.C:
+			p.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush inserts batch and reports the readings stored. A batch that still
+// fails after the retries of the repository is counted and logged; its
+// readings are lost.
+func (p *WriterPool) flush(batch []pendingWrite) {
+	if len(batch) == 0 {
+		return
+	}
+	data := make([]*models.SensorData, len(batch))
+	for i, w := range batch {
+		data[i] = w.data
+	}
+	if err := p.repo.InsertMany(p.ctx, data); err != nil {
+		metrics.SensorWritesFailed.Add(int64(len(batch)))
+		log.Printf("storage: insert %d readings: %v", len(batch), err)
+		return
+	}
+	for _, w := range batch {
+		if w.stored != nil {
+			w.stored()
+		}
+	}
+}
+
+// Shutdown stops accepting readings and waits for the workers to write
+// everything already queued. Once ctx is done it cancels the inserts
+// still running, which fail the readings left, and returns ctx.Err().
+func (p *WriterPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	first := !p.closed
+	p.closed = true
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	if first {
+		close(p.closing)
+		go func() {
+			// No writer can send once they are all done, so the queue
+			// can be closed for the workers to drain it.
+			p.writers.Wait()
+			close(p.queue)
+		}()
+	}
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
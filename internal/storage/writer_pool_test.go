@@ -0,0 +1,272 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: writer_pool_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the pool of workers writing sensor data in batches.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"airsense-be.com/internal/config"
+	"airsense-be.com/internal/models"
+	"airsense-be.com/internal/repository"
+)
+
+// batchRepo records the batches inserted. With block set, each insert
+// waits for its context instead.
+type batchRepo struct {
+	repository.SensorDataRepository
+	block   bool
+	started chan struct{}
+	err     error
+
+	mu      sync.Mutex
+	batches []int
+	stored  map[string]bool
+}
+
+func newBatchRepo() *batchRepo {
+	return &batchRepo{stored: map[string]bool{}, started: make(chan struct{}, 100)}
+}
+
+func (r *batchRepo) InsertMany(ctx context.Context, data []*models.SensorData) error {
+	r.started <- struct{}{}
+	if r.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if r.err != nil {
+		return r.err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, len(data))
+	for _, d := range data {
+		r.stored[d.ID] = true
+	}
+	return nil
+}
+
+func (r *batchRepo) isStored(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stored[id]
+}
+
+func reading(i int) *models.SensorData {
+	return &models.SensorData{DeviceID: "d1", Timestamp: time.Unix(int64(i), 0)}
+}
+
+func TestWriterPoolBatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.StorageConfig
+		writers  int
+		readings int
+		// wantBatches is checked when set; concurrent workers make the
+		// batches of the other cases vary.
+		wantBatches []int
+	}{
+		{"full batches and the rest at shutdown", config.StorageConfig{WorkerCount: 1, BatchSize: 10, FlushInterval: time.Hour, QueueSize: 100},
+			1, 95, []int{10, 10, 10, 10, 10, 10, 10, 10, 10, 5}},
+		{"under load", config.StorageConfig{WorkerCount: 4, BatchSize: 50, FlushInterval: 5 * time.Millisecond, QueueSize: 64},
+			8, 4000, nil},
+		{"one worker under load", config.StorageConfig{WorkerCount: 1, BatchSize: 25, FlushInterval: time.Millisecond, QueueSize: 1},
+			8, 1000, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newBatchRepo()
+			repo.started = make(chan struct{}, tt.readings)
+			p := NewWriterPool(repo, tt.cfg)
+			p.Start()
+			var (
+				wg    sync.WaitGroup
+				mu    sync.Mutex
+				ids   []string
+				calls = map[string]int{}
+			)
+			for w := range tt.writers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := w; i < tt.readings; i += tt.writers {
+						d := reading(i)
+						err := p.Write(context.Background(), d, func() {
+							mu.Lock()
+							calls[d.ID]++
+							mu.Unlock()
+						})
+						if err != nil {
+							t.Errorf("write %d: %v", i, err)
+							return
+						}
+						mu.Lock()
+						ids = append(ids, d.ID)
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := p.Shutdown(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(ids) != tt.readings || len(repo.stored) != tt.readings {
+				t.Fatalf("wrote %d readings, stored %d, want %d", len(ids), len(repo.stored), tt.readings)
+			}
+			for _, id := range ids {
+				if !repo.stored[id] || calls[id] != 1 {
+					t.Fatalf("reading %s: stored %v, reported %d times", id, repo.stored[id], calls[id])
+				}
+			}
+			total := 0
+			for _, n := range repo.batches {
+				if n < 1 || n > tt.cfg.BatchSize {
+					t.Errorf("batch of %d, want 1 to %d", n, tt.cfg.BatchSize)
+				}
+				total += n
+			}
+			if total != tt.readings {
+				t.Errorf("batches hold %d readings, want %d", total, tt.readings)
+			}
+			if tt.wantBatches != nil && fmt.Sprint(repo.batches) != fmt.Sprint(tt.wantBatches) {
+				t.Errorf("batches = %v, want %v", repo.batches, tt.wantBatches)
+			}
+		})
+	}
+}
+
+func TestWriterPoolFlushInterval(t *testing.T) {
+	repo := newBatchRepo()
+	p := NewWriterPool(repo, config.StorageConfig{WorkerCount: 1, BatchSize: 100, FlushInterval: 20 * time.Millisecond, QueueSize: 10})
+	p.Start()
+	defer p.Shutdown(context.Background())
+
+	stored := make(chan string, 3)
+	for i := range 3 {
+		d := reading(i)
+		if err := p.Write(context.Background(), d, func() {
+			// The reading is reported only once the repository has it.
+			if !repo.isStored(d.ID) {
+				t.Errorf("reading %s reported before it was stored", d.ID)
+			}
+			stored <- d.ID
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range 3 {
+		select {
+		case <-stored:
+		case <-time.After(5 * time.Second):
+			t.Fatal("a partial batch was not flushed")
+		}
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if fmt.Sprint(repo.batches) != "[3]" {
+		t.Errorf("batches = %v, want [3]", repo.batches)
+	}
+}
+
+func TestWriterPoolFailedInsert(t *testing.T) {
+	repo := newBatchRepo()
+	repo.err = errors.New("connection reset")
+	p := NewWriterPool(repo, config.StorageConfig{WorkerCount: 1, BatchSize: 1, QueueSize: 10})
+	p.Start()
+	reported := false
+	if err := p.Write(context.Background(), reading(1), func() { reported = true }); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if reported {
+		t.Error("a reading whose insert failed was reported as stored")
+	}
+}
+
+func TestWriterPoolShutdownReleasesBlockedWriters(t *testing.T) {
+	repo := newBatchRepo()
+	// Not started: nothing drains the queue of one.
+	p := NewWriterPool(repo, config.StorageConfig{WorkerCount: 1, BatchSize: 1, QueueSize: 1})
+	if err := p.Write(context.Background(), reading(1), nil); err != nil {
+		t.Fatal(err)
+	}
+	blocked := make(chan error, 1)
+	go func() { blocked <- p.Write(context.Background(), reading(2), nil) }()
+	time.Sleep(20 * time.Millisecond)
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- p.Shutdown(context.Background()) }()
+	select {
+	case err := <-blocked:
+		if !errors.Is(err, ErrWriterClosed) {
+			t.Errorf("blocked write: err = %v, want ErrWriterClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not release the blocked writer")
+	}
+	select {
+	case err := <-shutdown:
+		if err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown blocked")
+	}
+	if err := p.Write(context.Background(), reading(3), nil); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("write after shutdown: err = %v, want ErrWriterClosed", err)
+	}
+}
+
+func TestWriterPoolShutdownDeadline(t *testing.T) {
+	repo := newBatchRepo()
+	repo.block = true
+	p := NewWriterPool(repo, config.StorageConfig{WorkerCount: 1, BatchSize: 1, QueueSize: 10})
+	p.Start()
+	reported := false
+	if err := p.Write(context.Background(), reading(1), func() { reported = true }); err != nil {
+		t.Fatal(err)
+	}
+	<-repo.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("shutdown: err = %v, want context.DeadlineExceeded", err)
+	}
+	if took := time.Since(start); took > 2*time.Second {
+		t.Errorf("shutdown took %v past its deadline", took)
+	}
+	// The cancelled insert lets the worker finish.
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the worker kept inserting after the deadline")
+	}
+	if reported {
+		t.Error("a cancelled insert was reported as stored")
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: aqi.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the US EPA air quality index computation of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package utils
+
+import (
+	"math"
+
+	"airsense-be.com/internal/models"
+)
+
+// AQI categories, from best to worst.
+const (
+	AQIGood               = "good"
+	AQIModerate           = "moderate"
+	AQIUnhealthySensitive = "unhealthy_for_sensitive_groups"
+	AQIUnhealthy          = "unhealthy"
+	AQIVeryUnhealthy      = "very_unhealthy"
+	AQIHazardous          = "hazardous"
+)
+
+// ValidAQICategory reports whether category is one of the AQI categories.
+func ValidAQICategory(category string) bool {
+	for _, bp := range pm25Breakpoints {
+		if bp.category == category {
+			return true
+		}
+	}
+	return false
+}
+
+type aqiBreakpoint struct {
+	cLow, cHigh float64
+	iLow, iHigh int
+	category    string
+}
+
+// pm25Breakpoints are the EPA 24-hour PM2.5 breakpoints (2024 revision),
+// concentrations in µg/m³.
+var pm25Breakpoints = []aqiBreakpoint{
+	{0.0, 9.0, 0, 50, AQIGood},
+	{9.1, 35.4, 51, 100, AQIModerate},
+	{35.5, 55.4, 101, 150, AQIUnhealthySensitive},
+	{55.5, 125.4, 151, 200, AQIUnhealthy},
+	{125.5, 225.4, 201, 300, AQIVeryUnhealthy},
+	{225.5, 325.4, 301, 500, AQIHazardous},
+}
+
+// PM25AQI converts a PM2.5 concentration to its AQI and category.
+// Concentrations above the last breakpoint are capped at 500.
+func PM25AQI(concentration float64) (int, string) {
+	// The EPA truncates to one decimal before the lookup.
+	c := math.Floor(concentration*10) / 10
+	if c < 0 {
+		c = 0
+	}
+	for _, bp := range pm25Breakpoints {
+		if c <= bp.cHigh {
+			aqi := float64(bp.iHigh-bp.iLow)/(bp.cHigh-bp.cLow)*(c-bp.cLow) + float64(bp.iLow)
+			return int(math.Round(aqi)), bp.category
+		}
+	}
+	return 500, AQIHazardous
+}
+
+// ApplyAQI stores the AQI and category of the reading's PM2.5 value on it.
+func ApplyAQI(d *models.SensorData) {
+	d.AQI, d.AQICategory = PM25AQI(d.Sensors.PM25.Value)
+}
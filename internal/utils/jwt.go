@@ -0,0 +1,130 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: jwt.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the JWT helpers used to authenticate users of the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type Claims struct {
+	UserID string `json:"user_id"`
+	// Role is the role of the user when the token was issued; tokens
+	// issued before roles were added lack it. It tells clients what to
+	// show, the server checks the user record.
+	Role string `json:"role,omitempty"`
+	// Devices and Operations are set on device-scoped tokens only, whose
+	// ID names their models.ScopedToken.
+	Devices    []string `json:"devices,omitempty"`
+	Operations []string `json:"ops,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Scoped reports whether the claims are those of a device-scoped token.
+// Session tokens have no ID, so any token with one counts as scoped.
+func (c *Claims) Scoped() bool {
+	return len(c.Devices) > 0 || c.ID != ""
+}
+
+// GenerateToken issues an HS256 access token for userID with role.
+func GenerateToken(userID, role, secret string, expire time.Duration) (string, error) {
+	return GenerateTokenAt(userID, role, secret, time.Now(), expire)
+}
+
+// GenerateTokenAt is GenerateToken with the issue time now.
+func GenerateTokenAt(userID, role, secret string, now time.Time, expire time.Duration) (string, error) {
+	claims := Claims{
+		UserID: userID,
+		Role:   role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expire)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// GenerateScopedToken issues an HS256 access token with ID id for userID,
+// limited to operations on devices, valid until expiresAt.
+func GenerateScopedToken(id, userID string, devices, operations []string, secret string, expiresAt time.Time) (string, error) {
+	claims := Claims{
+		UserID:     userID,
+		Devices:    devices,
+		Operations: operations,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ParseToken verifies the signature and expiry of an access token.
+func ParseToken(tokenString, secret string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (any, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("parse token: %w", err)
+	}
+	return claims, nil
+}
+
+// ProvisionScope is the scope of device provisioning tokens.
+const ProvisionScope = "provision"
+
+// ProvisioningClaims are the claims of a device provisioning token. Its ID
+// names the record that counts the token's uses.
+type ProvisioningClaims struct {
+	Scope string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// GenerateProvisioningToken issues an HS256 provisioning token with ID id,
+// valid until expiresAt.
+func GenerateProvisioningToken(id, secret string, expiresAt time.Time) (string, error) {
+	claims := ProvisioningClaims{
+		Scope: ProvisionScope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ParseProvisioningToken verifies the signature, expiry and scope of a
+// provisioning token.
+func ParseProvisioningToken(tokenString, secret string) (*ProvisioningClaims, error) {
+	claims := &ProvisioningClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (any, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("parse provisioning token: %w", err)
+	}
+	if claims.Scope != ProvisionScope {
+		return nil, fmt.Errorf("parse provisioning token: scope %q is not %q", claims.Scope, ProvisionScope)
+	}
+	if claims.ID == "" {
+		return nil, errors.New("parse provisioning token: missing jti")
+	}
+	return claims, nil
+}
@@ -0,0 +1,109 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: rate_limiter.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains an in-memory rate limiter keyed by client.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package utils
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// minLimiterIdle is the least time the bucket of an unused key is kept.
+const minLimiterIdle = 10 * time.Minute
+
+// limiterIdle is how long the bucket of an unused key is kept: until it
+// would be full again anyway, as dropping it earlier would refill it. A
+// bucket that never refills is never dropped.
+func limiterIdle(limit rate.Limit, burst int) time.Duration {
+	if limit <= 0 {
+		return math.MaxInt64
+	}
+	refill := float64(burst) / float64(limit) * float64(time.Second)
+	if refill >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return max(time.Duration(math.Ceil(refill)), minLimiterIdle)
+}
+
+type keyBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter gives every key, e.g. an API key ID or a client IP, a token
+// bucket of its own. Buckets live in memory, so limits are per server
+// instance.
+type RateLimiter struct {
+	limit rate.Limit
+	burst int
+	idle  time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*keyBucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter allows each key n events per period, in bursts of up to
+// burst.
+func NewRateLimiter(n int, period time.Duration, burst int) *RateLimiter {
+	l := &RateLimiter{
+		limit:   rate.Limit(float64(n) / period.Seconds()),
+		burst:   max(burst, 1),
+		buckets: map[string]*keyBucket{},
+	}
+	l.idle = limiterIdle(l.limit, l.burst)
+	return l
+}
+
+// SetLimit changes the limit to n events per period, in bursts of up to
+// burst, for the buckets of keys already seen too.
+func (l *RateLimiter) SetLimit(n int, period time.Duration, burst int) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit, l.burst = rate.Limit(float64(n)/period.Seconds()), max(burst, 1)
+	l.idle = limiterIdle(l.limit, l.burst)
+	for _, b := range l.buckets {
+		b.limiter.SetLimitAt(now, l.limit)
+		b.limiter.SetBurstAt(now, l.burst)
+	}
+}
+
+// Allow takes an event from the bucket of key. If it is empty, it returns
+// false and how long until the next event is allowed.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= l.idle {
+		for k, b := range l.buckets {
+			if now.Sub(b.lastSeen) >= l.idle {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &keyBucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
@@ -0,0 +1,91 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: rate_limiter_test.go
+ * Author: [trung.la]
+ * Created: [2026-10-15]
+ * Last Updated: [2026-10-15]
+ * Description: This file contains the tests of the in-memory rate limiter keyed by client.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterIdle(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		period time.Duration
+		burst  int
+		want   time.Duration
+	}{
+		{"per minute", 60, time.Minute, 10, minLimiterIdle},
+		{"hourly", 5, time.Hour, 5, time.Hour},
+		{"hourly burst of one", 3, time.Hour, 1, minLimiterIdle + 10*time.Minute},
+		{"daily", 10, 24 * time.Hour, 10, 24 * time.Hour},
+		{"never refills", 0, time.Hour, 3, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(tt.n, tt.period, tt.burst)
+			// Allow for the rounding of the rate.
+			if d := l.idle - tt.want; d < -time.Second || d > time.Second {
+				t.Errorf("idle = %v, want %v", l.idle, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterKeepsBucketsUntilRefilled(t *testing.T) {
+	tests := []struct {
+		name        string
+		idleFor     time.Duration
+		wantDropped bool
+	}{
+		{"past the minimum", minLimiterIdle + time.Minute, false},
+		{"refilled", time.Hour + time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(3, time.Hour, 3)
+			for range 3 {
+				if ok, _ := l.Allow("a"); !ok {
+					t.Fatal("a full bucket refused an event")
+				}
+			}
+			// Age the bucket as if idle for idleFor; the sweep drops it
+			// only once it would have refilled.
+			b := l.buckets["a"]
+			b.lastSeen = b.lastSeen.Add(-tt.idleFor)
+			l.lastSweep = time.Time{}
+			l.Allow("b")
+			_, kept := l.buckets["a"]
+			if kept == tt.wantDropped {
+				t.Errorf("bucket kept = %v after %v idle", kept, tt.idleFor)
+			}
+			if !kept {
+				return
+			}
+			if ok, _ := l.Allow("a"); ok {
+				t.Error("a kept bucket allowed an event before refilling")
+			}
+		})
+	}
+}
+
+func TestRateLimiterSetLimitIdle(t *testing.T) {
+	l := NewRateLimiter(60, time.Minute, 60)
+	if l.idle != minLimiterIdle {
+		t.Fatalf("idle = %v, want %v", l.idle, minLimiterIdle)
+	}
+	l.SetLimit(2, time.Hour, 2)
+	if l.idle != time.Hour {
+		t.Errorf("idle after SetLimit = %v, want %v", l.idle, time.Hour)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Project: AirSense Backend (airsense-be)
+ * Filename: validators.go
+ * Author: [trung.la]
+ * Created: [2026-10-14]
+ * Last Updated: [2026-10-14]
+ * Description: This file contains the validation helpers for inbound data in the AirSense system.
+ *
+ * Copyright (c) [2025] [AirSense Organization]. All rights reserved.
+ */
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"airsense-be.com/internal/models"
+)
+
+var ErrInvalidSensorData = errors.New("invalid sensor data")
+
+// sensorRange is the physical range a sensor can report.
+type sensorRange struct {
+	Min, Max float64
+}
+
+// SensorRanges maps each sensor field to the physical limits of the hardware.
+var SensorRanges = map[string]sensorRange{
+	"pm25":        {0, 1000},
+	"co2":         {0, 10000},
+	"co":          {0, 1000},
+	"temperature": {-40, 85},
+	"humidity":    {0, 100},
+}
+
+// Bounds of the tags of one reading.
+const (
+	MaxSensorTags        = 20
+	MaxSensorTagValueLen = 256
+)
+
+// Tag keys become part of a MongoDB field path, so "." and "$" must never
+// get through.
+var sensorTagKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidateSensorTags checks that tags has at most MaxSensorTags tags with
+// keys of 1-64 letters, digits, _ or - and values of 1 to
+// MaxSensorTagValueLen characters.
+func ValidateSensorTags(tags map[string]string) error {
+	if len(tags) > MaxSensorTags {
+		return fmt.Errorf("%w: a reading has at most %d tags", ErrInvalidSensorData, MaxSensorTags)
+	}
+	for k, v := range tags {
+		if !sensorTagKeyPattern.MatchString(k) {
+			return fmt.Errorf("%w: tag keys must be 1-64 letters, digits, _ or -", ErrInvalidSensorData)
+		}
+		if n := utf8.RuneCountInString(v); n == 0 || n > MaxSensorTagValueLen {
+			return fmt.Errorf("%w: tag %s must be 1-%d characters", ErrInvalidSensorData, k, MaxSensorTagValueLen)
+		}
+	}
+	return nil
+}
+
+// ValidateSensorData checks the identifying fields, that every reading
+// falls inside the physical range of its sensor, that confidences are
+// between 0 and 1 and the tags, see ValidateSensorTags.
+func ValidateSensorData(d *models.SensorData) error {
+	if d.DeviceID == "" {
+		return fmt.Errorf("%w: missing device id", ErrInvalidSensorData)
+	}
+	if d.Timestamp.IsZero() {
+		return fmt.Errorf("%w: missing timestamp", ErrInvalidSensorData)
+	}
+	for field, v := range d.Sensors.Fields() {
+		r := SensorRanges[field]
+		if v.Value < r.Min || v.Value > r.Max {
+			return fmt.Errorf("%w: %s value %v out of range [%v, %v]", ErrInvalidSensorData, field, v.Value, r.Min, r.Max)
+		}
+		if c := v.Confidence; c != nil && (*c < 0 || *c > 1) {
+			return fmt.Errorf("%w: %s confidence %v out of range [0, 1]", ErrInvalidSensorData, field, *c)
+		}
+	}
+	return ValidateSensorTags(d.Tags)
+}